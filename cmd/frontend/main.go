@@ -10,6 +10,7 @@ import (
 	"flag"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/profiler"
@@ -17,13 +18,20 @@ import (
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/cmd/internal/cmdconfig"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/dcensus"
+	"golang.org/x/pkgsite/internal/diagram"
+	"golang.org/x/pkgsite/internal/failover"
+	"golang.org/x/pkgsite/internal/fault"
 	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/fetchdatasource"
 	"golang.org/x/pkgsite/internal/frontend"
+	"golang.org/x/pkgsite/internal/imageproxy"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/namespace"
+	"golang.org/x/pkgsite/internal/oidc"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/queue"
@@ -44,6 +52,25 @@ var (
 		"as a direct backend, bypassing the database")
 	bypassLicenseCheck = flag.Bool("bypass_license_check", false, "display all information, even for non-redistributable paths")
 	hostAddr           = flag.String("host", "localhost:8080", "Host address for the server")
+	shadowRate         = flag.Float64("shadow_rate", 0, "fraction of GET requests to mirror with the experiments in -shadow_experiments, comparing the responses")
+	shadowExperiments  = flag.String("shadow_experiments", "", "comma-separated experiments to turn on for mirrored requests")
+	faultInjection     = flag.Bool("fault_injection", false, "enable fault injection into calls to postgres and the proxy; faults can be changed at /_debug/fault")
+	faultPostgres      = flag.String("fault_postgres", "", "faults to inject into postgres queries, like \"latency=200ms,error=0.1\"; implies -fault_injection")
+	faultProxy         = flag.String("fault_proxy", "", "faults to inject into proxy requests, like \"latency=1s,error=0.1,truncate=0.05\"; implies -fault_injection")
+)
+
+// queryBudgets are the database query budgets of the frontend routes, keyed by
+// path prefix. Requests that exceed them are logged.
+var queryBudgets = map[string]middleware.QueryBudget{
+	"/":       {MaxQueries: 30, MaxDuration: 2 * time.Second},
+	"/search": {MaxQueries: 15, MaxDuration: 5 * time.Second},
+}
+
+// The primary database is considered down after failoverThreshold failed
+// checks in a row, made every failoverCheckPeriod.
+const (
+	failoverThreshold   = 3
+	failoverCheckPeriod = 10 * time.Second
 )
 
 func main() {
@@ -63,19 +90,31 @@ func main() {
 	var (
 		dsg        func(context.Context) internal.DataSource
 		fetchQueue queue.Queue
+		expg       middleware.ExperimentGetter
+		bannerg    func(context.Context) (string, error)
+		homepageg  func(context.Context) ([]*internal.HomepageSection, error)
+		vocabg     func(context.Context, int) (map[string]int, error)
+		apiKeyg    func(context.Context, []byte) (*apikey.Key, error)
+		pageViews  func(context.Context, []*postgres.PageView) error
+		searchQs   func(context.Context, []*postgres.SearchQuery) error
+		monitor    *failover.Monitor
 	)
 	if *bypassLicenseCheck {
 		log.Info(ctx, "BYPASSING LICENSE CHECKING: DISPLAYING NON-REDISTRIBUTABLE INFORMATION")
 	}
 
-	log.Infof(ctx, "cmd/frontend: initializing cmdconfig.ExperimentGetter")
-	expg := cmdconfig.ExperimentGetter(ctx, cfg)
-	log.Infof(ctx, "cmd/frontend: initialized cmdconfig.ExperimentGetter")
-
 	proxyClient, err := proxy.New(*proxyURL)
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	faultInjector, err := newFaultInjector()
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	if faultInjector != nil {
+		log.Info(ctx, "FAULT INJECTION ENABLED")
+		proxyClient.HTTPClient.Transport = faultInjector.Transport(fault.Proxy, proxyClient.HTTPClient.Transport)
+	}
 
 	if *directProxy {
 		ds := fetchdatasource.Options{
@@ -84,13 +123,50 @@ func main() {
 			BypassLicenseCheck:   *bypassLicenseCheck,
 		}.New()
 		dsg = func(context.Context) internal.DataSource { return ds }
+		expg = cmdconfig.ExperimentGetter(ctx, cfg, nil)
 	} else {
 		db, err := cmdconfig.OpenDB(ctx, cfg, *bypassLicenseCheck)
 		if err != nil {
 			log.Fatalf(ctx, "%v", err)
 		}
 		defer db.Close()
-		dsg = func(context.Context) internal.DataSource { return db }
+		if faultInjector != nil {
+			db.Underlying().SetQueryHook(func(ctx context.Context) error {
+				return faultInjector.Inject(ctx, fault.Postgres)
+			})
+		}
+		if cfg.SearchSynonyms != nil {
+			if err := db.SetSearchSynonyms(cfg.SearchSynonyms); err != nil {
+				log.Fatalf(ctx, "%v", err)
+			}
+		}
+		standby, err := cmdconfig.OpenStandbyDB(ctx, cfg, *bypassLicenseCheck)
+		if err != nil {
+			// The site can run without a standby, so don't fail.
+			log.Errorf(ctx, "%v", err)
+		}
+		if standby != nil {
+			defer standby.Close()
+		}
+		monitor = failover.NewMonitor(db.Underlying().PingContext, failoverThreshold)
+		monitor.Start(ctx, failoverCheckPeriod)
+		dsg = func(context.Context) internal.DataSource {
+			if standby != nil && monitor.Degraded() {
+				return standby
+			}
+			return db
+		}
+		expg = cmdconfig.ExperimentGetter(ctx, cfg, db)
+		bannerg = db.GetSiteBanner
+		homepageg = db.GetHomepageSections
+		vocabg = db.GetSearchVocabulary
+		apiKeyg = db.GetAPIKey
+		if cfg.RecordPageViews {
+			pageViews = db.AddPageViews
+		}
+		if cfg.RecordSearchQueries {
+			searchQs = db.AddSearchQueries
+		}
 		sourceClient := source.NewClient(config.SourceTimeout)
 		// The closure passed to queue.New is only used for testing and local
 		// execution, not in production. So it's okay that it doesn't use a
@@ -111,6 +187,30 @@ func main() {
 	if err != nil {
 		log.Fatalf(ctx, "vulndbc.NewClient: %v", err)
 	}
+	var renderDiagram func(context.Context, string, string) ([]byte, error)
+	if cfg.DiagramRendererURL != "" {
+		dr, err := diagram.NewRenderer(cfg.DiagramRendererURL, &http.Client{})
+		if err != nil {
+			log.Fatalf(ctx, "diagram.NewRenderer: %v", err)
+		}
+		renderDiagram = dr.Render
+	}
+	var imageProxy *imageproxy.Proxy
+	if cfg.ImageProxyKey != nil {
+		imageProxy, err = imageproxy.New(cfg.ImageProxyKey, imageproxy.NewClient())
+		if err != nil {
+			log.Fatalf(ctx, "imageproxy.New: %v", err)
+		}
+	}
+	var fetchAuthorizer func(*http.Request) (string, error)
+	if fa := cfg.FetchAuth; fa.Issuer != "" {
+		v, err := oidc.NewVerifier(ctx, fa.Issuer, fa.Audience, &http.Client{Timeout: time.Minute})
+		if err != nil {
+			log.Fatalf(ctx, "oidc.NewVerifier: %v", err)
+		}
+		a := &oidc.Authorizer{Verifier: v, Header: fa.Header, GroupsClaim: fa.GroupsClaim, Groups: fa.Groups}
+		fetchAuthorizer = a.Authorize
+	}
 	staticSource := template.TrustedSourceFromFlag(flag.Lookup("static").Value)
 	server, err := frontend.NewServer(frontend.ServerConfig{
 		Config:               cfg,
@@ -124,6 +224,18 @@ func main() {
 		DevMode:              *devMode,
 		ReportingClient:      rc,
 		VulndbClient:         vc,
+		BannerGetter:         bannerg,
+		HomepageGetter:       homepageg,
+		VocabularyGetter:     vocabg,
+		FaultInjector:        faultInjector,
+		Failover:             monitor,
+		ModuleZipGetter:      frontend.ProxyZipGetter(proxyClient),
+		NamespaceVerifier:    namespace.NewVerifier(&http.Client{Timeout: time.Minute}).Verify,
+		DiagramRenderer:      renderDiagram,
+		ImageProxy:           imageProxy,
+		FetchAuthorizer:      fetchAuthorizer,
+		PageViewRecorder:     pageViews,
+		SearchQueryRecorder:  searchQs,
 	})
 	if err != nil {
 		log.Fatalf(ctx, "frontend.NewServer: %v", err)
@@ -144,6 +256,7 @@ func main() {
 	views := append(dcensus.ServerViews,
 		postgres.SearchLatencyDistribution,
 		postgres.SearchResponseCount,
+		postgres.SchemaChangeVerifyCount,
 		frontend.FetchLatencyDistribution,
 		frontend.FetchResponseCount,
 		frontend.VersionTypeCount,
@@ -151,6 +264,10 @@ func main() {
 		middleware.CacheErrorCount,
 		middleware.CacheLatency,
 		middleware.QuotaResultCount,
+		middleware.APIKeyRequestCount,
+		middleware.ShadowResultCount,
+		middleware.ShadowLatency,
+		middleware.QueryBudgetExceededCount,
 		frontend.DepsDevResultCount,
 	)
 	if err := dcensus.Init(cfg, views...); err != nil {
@@ -177,18 +294,55 @@ func main() {
 	if rc != nil {
 		ermw = middleware.ErrorReporting(rc.Report)
 	}
+	shadowmw := middleware.Identity()
+	if *shadowRate > 0 {
+		var exps []string
+		if *shadowExperiments != "" {
+			exps = strings.Split(*shadowExperiments, ",")
+		}
+		log.Infof(ctx, "mirroring %g of requests with experiments %v", *shadowRate, exps)
+		shadowmw = middleware.Shadow(middleware.ShadowConfig{
+			Rate:        *shadowRate,
+			Experiments: exps,
+		})
+	}
+	apikeymw := middleware.Identity()
+	if apiKeyg != nil {
+		apikeymw = middleware.APIKeys(cfg.APIKeys, apiKeyg, cacheClient)
+	}
 	mw := middleware.Chain(
 		middleware.RequestLog(cmdconfig.Logger(ctx, cfg, "frontend-log")),
+		middleware.CanonicalHost(cfg.CanonicalHost, cfg.HSTSMaxAge, middleware.HealthCheckPaths),
 		middleware.AcceptRequests(http.MethodGet, http.MethodPost, http.MethodHead), // accept only GETs, POSTs and HEADs
 		middleware.BetaPkgGoDevRedirect(),
 		middleware.Quota(cfg.Quota, cacheClient),
-		middleware.SecureHeaders(!*disableCSP), // must come before any caching for nonces to work
+		apikeymw,
+		middleware.SecureHeaders(!*disableCSP, cfg.CSP), // must come before any caching for nonces to work
 		middleware.Experiment(experimenter),
 		middleware.Panic(panicHandler),
 		ermw,
 		middleware.Timeout(54*time.Second),
+		middleware.QueryBudgets(queryBudgets, cfg.AuthValues),
+		shadowmw,
 	)
 	addr := cfg.HostAddr(*hostAddr)
 	log.Infof(ctx, "Listening on addr %s", addr)
 	log.Fatal(ctx, http.ListenAndServe(addr, mw(router)))
 }
+
+// newFaultInjector returns a fault.Injector configured from the flags, or
+// nil if fault injection is not enabled.
+func newFaultInjector() (*fault.Injector, error) {
+	if !*faultInjection && *faultPostgres == "" && *faultProxy == "" {
+		return nil, nil
+	}
+	inj := fault.NewInjector()
+	for dep, spec := range map[string]string{fault.Postgres: *faultPostgres, fault.Proxy: *faultProxy} {
+		c, err := fault.ParseConfig(spec)
+		if err != nil {
+			return nil, err
+		}
+		inj.Set(dep, c)
+	}
+	return inj, nil
+}
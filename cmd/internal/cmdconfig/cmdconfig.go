@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/errorreporting"
@@ -68,24 +69,55 @@ func Experimenter(ctx context.Context, cfg *config.Config, getter middleware.Exp
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	e.SetTrustedProxies(cfg.TrustedProxies)
 	return e
 }
 
-// ExperimentGetter returns an ExperimentGetter using the config.
-func ExperimentGetter(ctx context.Context, cfg *config.Config) middleware.ExperimentGetter {
-	if cfg.DynamicConfigLocation == "" {
+// ExperimentGetter returns an ExperimentGetter using the config. If db is not
+// nil, the experiments in its experiments table are also used, and take
+// precedence over experiments of the same name in the dynamic config.
+func ExperimentGetter(ctx context.Context, cfg *config.Config, db *postgres.DB) middleware.ExperimentGetter {
+	var getters []middleware.ExperimentGetter
+	if cfg.DynamicConfigLocation != "" {
+		log.Debugf(ctx, "using dynamic config from %s for experiments", cfg.DynamicConfigLocation)
+		getters = append(getters, func(ctx context.Context) ([]*internal.Experiment, error) {
+			dc, err := dynconfig.Read(ctx, cfg.DynamicConfigLocation)
+			if err != nil {
+				return nil, err
+			}
+			return dc.Experiments, nil
+		})
+	}
+	if db != nil {
+		log.Debugf(ctx, "using the database for experiments")
+		getters = append(getters, db.GetExperiments)
+	}
+	if len(getters) == 0 {
 		log.Warningf(ctx, "experiments are not configured")
 		return func(context.Context) ([]*internal.Experiment, error) { return nil, nil }
 	}
-	log.Debugf(ctx, "using dynamic config from %s for experiments", cfg.DynamicConfigLocation)
 	return func(ctx context.Context) ([]*internal.Experiment, error) {
-		dc, err := dynconfig.Read(ctx, cfg.DynamicConfigLocation)
-		if err != nil {
-			return nil, err
+		var (
+			exps   []*internal.Experiment
+			byName = map[string]int{}
+		)
+		for _, g := range getters {
+			es, err := g(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range es {
+				if i, ok := byName[e.Name]; ok {
+					exps[i] = e
+				} else {
+					byName[e.Name] = len(exps)
+					exps = append(exps, e)
+				}
+			}
 		}
 
 		var s []string
-		for _, e := range dc.Experiments {
+		for _, e := range exps {
 			s = append(s, fmt.Sprintf("%s:%d", e.Name, e.Rollout))
 			if desc, ok := internal.Experiments[e.Name]; ok {
 				if e.Description == "" {
@@ -96,7 +128,7 @@ func ExperimentGetter(ctx context.Context, cfg *config.Config) middleware.Experi
 			}
 		}
 		log.Debugf(ctx, "read experiments %s", strings.Join(s, ", "))
-		return dc.Experiments, nil
+		return exps, nil
 	}
 }
 
@@ -106,10 +138,9 @@ func ExperimentGetter(ctx context.Context, cfg *config.Config) middleware.Experi
 func OpenDB(ctx context.Context, cfg *config.Config, bypassLicenseCheck bool) (_ *postgres.DB, err error) {
 	defer derrors.Wrap(&err, "cmdconfig.OpenDB(ctx, cfg)")
 
-	// Wrap the postgres driver with our own wrapper, which adds OpenCensus instrumentation.
-	ocDriver, err := database.RegisterOCWrapper("pgx", ocsql.WithAllTraceOptions())
+	ocDriver, err := registerOCDriver()
 	if err != nil {
-		return nil, fmt.Errorf("unable to register the ocsql driver: %v", err)
+		return nil, err
 	}
 	log.Infof(ctx, "opening database on host %s", cfg.DBHost)
 	ddb, err := database.Open(ocDriver, cfg.DBConnInfo(), cfg.InstanceID)
@@ -135,3 +166,46 @@ func OpenDB(ctx context.Context, cfg *config.Config, bypassLicenseCheck bool) (_
 	}
 	return postgres.New(ddb), nil
 }
+
+// OpenStandbyDB opens the read-only standby database specified by the
+// config. It returns nil if there is no standby.
+func OpenStandbyDB(ctx context.Context, cfg *config.Config, bypassLicenseCheck bool) (_ *postgres.DB, err error) {
+	defer derrors.Wrap(&err, "cmdconfig.OpenStandbyDB(ctx, cfg)")
+
+	ci := cfg.DBStandbyConnInfo()
+	if ci == "" {
+		return nil, nil
+	}
+	ocDriver, err := registerOCDriver()
+	if err != nil {
+		return nil, err
+	}
+	log.Infof(ctx, "opening standby database on host %s", cfg.DBStandbyHost)
+	ddb, err := database.Open(ocDriver, ci, cfg.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	if bypassLicenseCheck {
+		return postgres.NewBypassingLicenseCheck(ddb), nil
+	}
+	return postgres.New(ddb), nil
+}
+
+var (
+	ocDriverOnce sync.Once
+	ocDriverName string
+	ocDriverErr  error
+)
+
+// registerOCDriver wraps the postgres driver with our own wrapper, which adds
+// OpenCensus instrumentation, and returns the name of the wrapper. The
+// wrapper is registered only once.
+func registerOCDriver() (string, error) {
+	ocDriverOnce.Do(func() {
+		ocDriverName, ocDriverErr = database.RegisterOCWrapper("pgx", ocsql.WithAllTraceOptions())
+		if ocDriverErr != nil {
+			ocDriverErr = fmt.Errorf("unable to register the ocsql driver: %v", ocDriverErr)
+		}
+	})
+	return ocDriverName, ocDriverErr
+}
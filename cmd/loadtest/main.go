@@ -0,0 +1,331 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The loadtest command replays a sample of access logs against a frontend,
+// and reports the latency of each route. It is meant to be run against a
+// staging instance to estimate the capacity needed before a feature is
+// enabled in production.
+//
+// The log file has one request per line. A line is either a URL or path, or
+// a JSON log entry exported from Cloud Logging, whose httpRequest.requestUrl
+// field holds the URL. Only the path and query of each URL are used, so the
+// logs should already be anonymized; no other fields are read. Empty lines
+// and lines starting with "#" are ignored.
+//
+// Usage:
+//
+//	loadtest -target https://staging.example.com -concurrency 20 requests.txt
+//
+// If GO_DISCOVERY_FRONTEND_AUTHORIZATION is set, its value is sent as a
+// bearer token with each request.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/pkgsite/internal/auth"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+var (
+	target      = flag.String("target", "http://localhost:8080", "base URL of the frontend to send requests to")
+	concurrency = flag.Int("concurrency", 10, "number of requests to send at once")
+	maxRequests = flag.Int("n", 0, "maximum number of requests to send; 0 means all requests in the log")
+	timeout     = flag.Duration("timeout", 30*time.Second, "timeout for each request")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: loadtest [flags] LOGFILE\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Replays the requests in LOGFILE (or stdin, if LOGFILE is -) against -target.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if err := run(ctx, flag.Arg(0)); err != nil {
+		log.Fatal(ctx, err)
+	}
+}
+
+func run(ctx context.Context, filename string) error {
+	base, err := url.Parse(*target)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	reqs, err := readRequests(r)
+	if err != nil {
+		return err
+	}
+	if *maxRequests > 0 && len(reqs) > *maxRequests {
+		reqs = reqs[:*maxRequests]
+	}
+
+	client := &http.Client{}
+	if tok, ok := os.LookupEnv("GO_DISCOVERY_FRONTEND_AUTHORIZATION"); ok {
+		client = auth.NewClientBearer(tok)
+	}
+	client.Timeout = *timeout
+	// Measure each route on its own, rather than together with the target
+	// of its redirect.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	start := time.Now()
+	results := replay(ctx, client, base, reqs, *concurrency)
+	elapsed := time.Since(start)
+	fmt.Printf("sent %d requests in %s (%.1f requests/s) with concurrency %d\n\n",
+		len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds(), *concurrency)
+	return writeReport(os.Stdout, summarize(results))
+}
+
+// readRequests reads requests from a log, as described in the package
+// documentation. It returns the path and query of each request.
+func readRequests(r io.Reader) ([]string, error) {
+	var reqs []string
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 1024*1024)
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var entry struct {
+				HTTPRequest struct {
+					RequestURL string `json:"requestUrl"`
+				} `json:"httpRequest"`
+			}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, fmt.Errorf("%q: %v", line, err)
+			}
+			line = entry.HTTPRequest.RequestURL
+			if line == "" {
+				continue
+			}
+		}
+		u, err := url.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(u.Path, "/") {
+			return nil, fmt.Errorf("%q: not an absolute path", line)
+		}
+		reqs = append(reqs, u.RequestURI())
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// A result is the outcome of one request.
+type result struct {
+	route   string
+	status  int // 0 if there was an error
+	err     error
+	latency time.Duration
+}
+
+// replay sends a GET request for each of reqs, relative to base, using n
+// goroutines.
+func replay(ctx context.Context, client *http.Client, base *url.URL, reqs []string, n int) []result {
+	var (
+		mu      sync.Mutex
+		results []result
+		wg      sync.WaitGroup
+	)
+	ch := make(chan string)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range ch {
+				res := send(ctx, client, base, req)
+				if res.err != nil {
+					log.Debugf(ctx, "%s: %v", req, res.err)
+				} else if res.status >= 500 {
+					log.Debugf(ctx, "%s: status %d", req, res.status)
+				}
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, req := range reqs {
+		ch <- req
+	}
+	close(ch)
+	wg.Wait()
+	return results
+}
+
+// send sends a GET request for req and returns its result.
+func send(ctx context.Context, client *http.Client, base *url.URL, req string) result {
+	u, err := base.Parse(req)
+	if err != nil {
+		// readRequests has already parsed req.
+		panic(err)
+	}
+	res := result{route: route(u)}
+	hreq, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	start := time.Now()
+	resp, err := client.Do(hreq)
+	if err != nil {
+		res.latency = time.Since(start)
+		res.err = err
+		return res
+	}
+	// The latency includes reading the body, so that it is comparable to
+	// what a user sees.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	res.latency = time.Since(start)
+	res.status = resp.StatusCode
+	return res
+}
+
+// prefixRoutes are the routes of the frontend that serve a tree of paths.
+// Requests under each are reported together.
+var prefixRoutes = []string{
+	"/_debug/", "/api/", "/badge/", "/changes/", "/fetch/", "/files/", "/mod/", "/pkg/",
+	"/play/", "/sitemap/", "/static/", "/third_party/", "/vuln/",
+}
+
+// pageRoutes are the routes of the frontend that serve a single path.
+var pageRoutes = map[string]bool{
+	"/":               true,
+	"/about":          true,
+	"/favicon.ico":    true,
+	"/golang.org/x":   true,
+	"/license-policy": true,
+	"/new":            true,
+	"/new.atom":       true,
+	"/robots.txt":     true,
+	"/search":         true,
+	"/search-help":    true,
+	"/stats":          true,
+	"/styleguide":     true,
+	"/trending":       true,
+	"/trending.atom":  true,
+	"/vuln":           true,
+}
+
+// route returns the name under which the request for u is reported. Paths
+// that do not match a route above are unit pages, which are reported by tab.
+func route(u *url.URL) string {
+	if pageRoutes[u.Path] {
+		return u.Path
+	}
+	for _, r := range prefixRoutes {
+		if strings.HasPrefix(u.Path, r) {
+			return r + "*"
+		}
+	}
+	r := "unit"
+	if tab := u.Query().Get("tab"); tab != "" {
+		r += "?tab=" + tab
+	}
+	return r
+}
+
+// A summary holds the latency distribution of a route.
+type summary struct {
+	route         string
+	count         int
+	errors        int // requests that failed or had a 5xx status
+	p50, p90, p99 time.Duration
+	max           time.Duration
+}
+
+// summarize groups results by route, and returns the summary of each route,
+// sorted by decreasing number of requests.
+func summarize(results []result) []*summary {
+	byRoute := map[string][]result{}
+	for _, r := range results {
+		byRoute[r.route] = append(byRoute[r.route], r)
+	}
+	var sums []*summary
+	for rt, rs := range byRoute {
+		lats := make([]time.Duration, len(rs))
+		s := &summary{route: rt, count: len(rs)}
+		for i, r := range rs {
+			lats[i] = r.latency
+			if r.status == 0 || r.status >= 500 {
+				s.errors++
+			}
+		}
+		sort.Slice(lats, func(i, j int) bool { return lats[i] < lats[j] })
+		s.p50 = percentile(lats, 50)
+		s.p90 = percentile(lats, 90)
+		s.p99 = percentile(lats, 99)
+		s.max = lats[len(lats)-1]
+		sums = append(sums, s)
+	}
+	sort.Slice(sums, func(i, j int) bool {
+		if sums[i].count != sums[j].count {
+			return sums[i].count > sums[j].count
+		}
+		return sums[i].route < sums[j].route
+	})
+	return sums
+}
+
+// percentile returns the p'th percentile of the sorted, non-empty slice
+// lats, using the nearest-rank method.
+func percentile(lats []time.Duration, p int) time.Duration {
+	i := (p*len(lats)+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return lats[i]
+}
+
+// writeReport writes sums to w as a table.
+func writeReport(w io.Writer, sums []*summary) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "route\tcount\terrors\tp50\tp90\tp99\tmax\t\n")
+	for _, s := range sums {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", s.route, s.count, s.errors,
+			ms(s.p50), ms(s.p90), ms(s.p99), ms(s.max))
+	}
+	return tw.Flush()
+}
+
+// ms formats d in milliseconds.
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadRequests(t *testing.T) {
+	in := `
+# comment
+/net/http?tab=doc
+https://pkg.go.dev/search?q=json
+{"httpRequest": {"requestMethod": "GET", "requestUrl": "https://pkg.go.dev/golang.org/x/text@v0.3.7"}}
+{"textPayload": "no request"}
+`
+	got, err := readRequests(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/net/http?tab=doc", "/search?q=json", "/golang.org/x/text@v0.3.7"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	if _, err := readRequests(strings.NewReader("net/http\n")); err == nil {
+		t.Error("got nil error for relative path, want error")
+	}
+}
+
+func TestRoute(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"/", "/"},
+		{"/search?q=json", "/search"},
+		{"/static/frontend/frontend.js", "/static/*"},
+		{"/api/v1/module?module=example.com", "/api/*"},
+		{"/net/http", "unit"},
+		{"/golang.org/x/text@v0.3.7?tab=versions", "unit?tab=versions"},
+		{"/golang.org/x", "/golang.org/x"},
+		{"/golang.org/x/net", "unit"},
+	} {
+		u, err := url.Parse(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := route(u); got != test.want {
+			t.Errorf("route(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var results []result
+	for i := 1; i <= 100; i++ {
+		results = append(results, result{route: "unit", status: 200, latency: time.Duration(i) * time.Millisecond})
+	}
+	results = append(results,
+		result{route: "/search", status: 500, latency: time.Second},
+		result{route: "/search", status: 0, latency: 2 * time.Second})
+
+	got := summarize(results)
+	want := []*summary{
+		{route: "unit", count: 100, p50: 50 * time.Millisecond, p90: 90 * time.Millisecond, p99: 99 * time.Millisecond, max: 100 * time.Millisecond},
+		{route: "/search", count: 2, errors: 2, p50: time.Second, p90: 2 * time.Second, p99: 2 * time.Second, max: 2 * time.Second},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(summary{})); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultDocServer is the server that the doc subcommand reads documentation
+// from by default.
+const defaultDocServer = "https://pkg.go.dev"
+
+// runDoc runs the doc subcommand, which prints the documentation of a
+// package or symbol indexed by a pkgsite server, in the style of go doc:
+//
+//	pkgsite doc [-server URL] PACKAGE[@VERSION] [SYMBOL]
+//
+// The module of the package is not downloaded.
+//
+// With -complete, it instead prints the paths of the packages that begin
+// with its argument, one per line, for use by shell completion scripts.
+func runDoc(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("doc", flag.ContinueOnError)
+	server := fs.String("server", defaultDocServer, "URL of the pkgsite server to read documentation from")
+	complete := fs.Bool("complete", false, "print the package paths that begin with the argument")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: %s doc [flags] PACKAGE[@VERSION] [SYMBOL]\n", os.Args[0])
+		fmt.Fprintf(out, "    where SYMBOL is a name such as Client or a method such as Client.Do\n")
+		fmt.Fprintf(out, "   or: %s doc -complete [flags] PREFIX\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*complete && fs.NArg() != 1) {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var (
+		u   string
+		err error
+	)
+	if *complete {
+		u, err = apiURL(*server, "complete", url.Values{"prefix": {fs.Arg(0)}})
+	} else {
+		u, err = docURL(*server, fs.Arg(0), fs.Arg(1))
+	}
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", fs.Arg(0), strings.TrimSpace(string(body)))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// docURL returns the URL of the documentation of the package pkg, which may
+// have a version suffix, on server.
+func docURL(server, pkg, symbol string) (string, error) {
+	path, version, _ := strings.Cut(pkg, "@")
+	q := url.Values{"path": {path}}
+	if version != "" {
+		q.Set("version", version)
+	}
+	if symbol != "" {
+		q.Set("symbol", symbol)
+	}
+	return apiURL(server, "doc", q)
+}
+
+// apiURL returns the URL of the given endpoint of the API of server, with
+// query q.
+func apiURL(server, endpoint string, q url.Values) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", server)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1/" + endpoint
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
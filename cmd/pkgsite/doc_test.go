@@ -0,0 +1,99 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocURL(t *testing.T) {
+	for _, test := range []struct {
+		server, pkg, symbol string
+		want                string
+	}{
+		{"https://pkg.go.dev", "net/http", "", "https://pkg.go.dev/api/v1/doc?path=net%2Fhttp"},
+		{"https://pkg.go.dev/", "net/http", "Client.Do", "https://pkg.go.dev/api/v1/doc?path=net%2Fhttp&symbol=Client.Do"},
+		{"http://localhost:8080", "example.com/m@v1.2.3", "F", "http://localhost:8080/api/v1/doc?path=example.com%2Fm&symbol=F&version=v1.2.3"},
+	} {
+		got, err := docURL(test.server, test.pkg, test.symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("docURL(%q, %q, %q) = %q, want %q", test.server, test.pkg, test.symbol, got, test.want)
+		}
+	}
+	if _, err := docURL("pkg.go.dev", "net/http", ""); err == nil {
+		t.Error("got nil error for server URL without scheme")
+	}
+}
+
+func TestRunDoc(t *testing.T) {
+	cacheDir := filepath.Join("..", "..", "internal/fetch/testdata/modcache")
+	ctx := context.Background()
+	getters, err := buildGetters(ctx, nil, false, cacheDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := newServer(getters, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	server.Install(mux.Handle, nil, nil)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{
+			[]string{"modcache.com@v1.0.0"},
+			"package p // import \"modcache.com\"\n\nvar V = 1\n",
+		},
+		{
+			[]string{"modcache.com", "V"},
+			"var V = 1\n    V is a variable.\n",
+		},
+	} {
+		var buf strings.Builder
+		if err := runDoc(ctx, append([]string{"-server", ts.URL}, test.args...), &buf); err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%v: got\n%s\nwant\n%s", test.args, got, test.want)
+		}
+	}
+
+	err = runDoc(ctx, []string{"-server", ts.URL, "modcache.com", "Nope"}, &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), `no symbol "Nope"`) {
+		t.Errorf("got %v, want error about missing symbol", err)
+	}
+}
+
+func TestRunDocComplete(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/complete" || r.FormValue("prefix") != "github.com/org/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("github.com/org/a\ngithub.com/org/b\n"))
+	}))
+	defer ts.Close()
+
+	var buf strings.Builder
+	if err := runDoc(context.Background(), []string{"-server", ts.URL, "-complete", "github.com/org/"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "github.com/org/a\ngithub.com/org/b\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -17,6 +17,12 @@
 // required versions. You can disable serving the required modules by passing
 // -list=false.
 //
+// If the directory contains a go.work file, pkgsite serves all the modules of
+// the workspace, along with the local directories they are replaced by, so
+// links between the workspace's modules go to the local versions:
+//
+//	cd ~/repos/workspace && pkgsite
+//
 // You can also serve docs from your module cache, directly from the proxy
 // (it uses the GOPROXY environment variable), or both:
 //
@@ -32,6 +38,16 @@
 // while to appear the first time because the Go repo must be cloned and
 // processed. If you clone the repo yourself (https://go.googlesource.com/go),
 // you can provide its location with the -gorepo flag to save a little time.
+//
+// The doc subcommand prints the documentation of a package or symbol in the
+// terminal, in the style of go doc, without downloading its module. It reads
+// the documentation from pkg.go.dev, or from the server given by -server:
+//
+//	pkgsite doc net/http Client.Do
+//	pkgsite doc -server http://localhost:8080 example.com/mod/pkg@v1.2.3
+//
+// For shell completion, pkgsite doc -complete PREFIX prints the paths of the
+// packages that begin with PREFIX.
 package main
 
 import (
@@ -74,15 +90,25 @@ var (
 )
 
 func main() {
+	ctx := context.Background()
+	if len(os.Args) > 1 && os.Args[1] == "doc" {
+		if err := runDoc(ctx, os.Args[2:], os.Stdout); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(2)
+			}
+			die("%v", err)
+		}
+		return
+	}
 	flag.Usage = func() {
 		out := flag.CommandLine.Output()
 		fmt.Fprintf(out, "usage: %s [flags] [PATHS ...]\n", os.Args[0])
 		fmt.Fprintf(out, "    where each PATHS is a single path or a comma-separated list\n")
 		fmt.Fprintf(out, "    (default is current directory if neither -cache nor -proxy is provided)\n")
+		fmt.Fprintf(out, "   or: %s doc [flags] PACKAGE[@VERSION] [SYMBOL]\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
-	ctx := context.Background()
 
 	paths := collectPaths(flag.Args())
 	if len(paths) == 0 && !*useCache && !*useProxy {
@@ -127,11 +153,19 @@ func main() {
 
 	var cacheMods []internal.Modver
 	if *useListedMods && !*useCache {
+		// The go command resolves the modules of a workspace and their
+		// replacements itself.
 		var err error
 		paths, cacheMods, err = listModsForPaths(paths, modCacheDir)
 		if err != nil {
 			die("listing mods (consider passing -list=false): %v", err)
 		}
+	} else {
+		var err error
+		paths, err = expandWorkspaces(paths)
+		if err != nil {
+			die("reading workspace: %v", err)
+		}
 	}
 
 	getters, err := buildGetters(ctx, paths, *gopathMode, modCacheDir, cacheMods, prox)
@@ -269,6 +303,9 @@ func runGo(dir string, args ...string) ([]byte, error) {
 func listModsForPaths(paths []string, cacheDir string) ([]string, []internal.Modver, error) {
 	var outPaths []string
 	var cacheMods []internal.Modver
+	// Paths in the same workspace list the same modules.
+	seenPaths := map[string]bool{}
+	seenMods := map[internal.Modver]bool{}
 	for _, p := range paths {
 		lms, err := listModules(p)
 		if err != nil {
@@ -276,9 +313,16 @@ func listModsForPaths(paths []string, cacheDir string) ([]string, []internal.Mod
 		}
 		for _, lm := range lms {
 			if strings.HasPrefix(lm.GoMod, cacheDir) {
-				cacheMods = append(cacheMods, lm.Modver)
-			} else { // probably the result of a replace directive
-				outPaths = append(outPaths, filepath.Dir(lm.GoMod))
+				if !seenMods[lm.Modver] {
+					seenMods[lm.Modver] = true
+					cacheMods = append(cacheMods, lm.Modver)
+				}
+			} else { // a main module, or the result of a replace directive
+				dir := filepath.Dir(lm.GoMod)
+				if !seenPaths[dir] {
+					seenPaths[dir] = true
+					outPaths = append(outPaths, dir)
+				}
 			}
 		}
 	}
@@ -216,7 +216,9 @@ func TestListModsForPaths(t *testing.T) {
 	}
 	defer func() { listModules = _listModules }()
 
-	gotPaths, gotCacheMods, err := listModsForPaths([]string{"m1"}, "/dir")
+	// Listing the same modules twice, as happens for two members of one
+	// workspace, should not produce duplicates.
+	gotPaths, gotCacheMods, err := listModsForPaths([]string{"m1", "m2"}, "/dir")
 	if err != nil {
 		t.Fatal(err)
 	}
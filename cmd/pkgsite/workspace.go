@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// expandWorkspaces replaces each path that contains a go.work file with the
+// directories of the workspace's modules, as returned by workspaceDirs.
+// Other paths are returned unchanged. Duplicate directories are removed.
+func expandWorkspaces(paths []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	for _, p := range paths {
+		dirs, err := workspaceDirs(p)
+		if err != nil {
+			return nil, err
+		}
+		if dirs == nil {
+			add(p)
+			continue
+		}
+		for _, d := range dirs {
+			add(d)
+		}
+	}
+	return out, nil
+}
+
+// workspaceDirs returns the directories of the modules in the workspace
+// defined by the go.work file in dir: the directories of its use directives,
+// followed by the local targets of its replace directives. Relative
+// directories are resolved against dir.
+//
+// If dir does not contain a go.work file, workspaceDirs returns nil.
+func workspaceDirs(dir string) ([]string, error) {
+	filename := filepath.Join(dir, "go.work")
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The version of x/mod we depend on cannot parse go.work files, but a
+	// go.work file has the same syntax as a go.mod file, so we parse it
+	// leniently and read the use and replace directives from the syntax tree.
+	f, err := modfile.ParseLax(filename, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	var uses, replaces [][]string
+	for _, stmt := range f.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if len(stmt.Token) == 0 {
+				continue
+			}
+			switch stmt.Token[0] {
+			case "use":
+				uses = append(uses, stmt.Token[1:])
+			case "replace":
+				replaces = append(replaces, stmt.Token[1:])
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) != 1 {
+				continue
+			}
+			for _, line := range stmt.Line {
+				switch stmt.Token[0] {
+				case "use":
+					uses = append(uses, line.Token)
+				case "replace":
+					replaces = append(replaces, line.Token)
+				}
+			}
+		}
+	}
+
+	var dirs []string
+	addDir := func(d string) {
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(dir, d)
+		}
+		dirs = append(dirs, d)
+	}
+	for _, args := range uses {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s: usage: use local/dir", filename)
+		}
+		d, err := parseWorkToken(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		addDir(d)
+	}
+	for _, args := range replaces {
+		// A replace directive has the form
+		//   old [version] => new [version]
+		// Only replacements by a local directory have no version.
+		i := indexOf(args, "=>")
+		if i < 0 || len(args)-i-1 != 1 {
+			continue
+		}
+		d, err := parseWorkToken(args[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		if isDirectoryPath(d) {
+			addDir(d)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("%s: no modules in workspace", filename)
+	}
+	return dirs, nil
+}
+
+// parseWorkToken returns the value of a token in a go.work file, unquoting it
+// if necessary.
+func parseWorkToken(tok string) (string, error) {
+	if strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, "`") {
+		return strconv.Unquote(tok)
+	}
+	return tok, nil
+}
+
+// isDirectoryPath reports whether the replacement path p refers to a local
+// directory rather than a module path.
+func isDirectoryPath(p string) bool {
+	return p == "." || p == ".." ||
+		strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") ||
+		strings.HasPrefix(p, `.\`) || strings.HasPrefix(p, `..\`) ||
+		filepath.IsAbs(p)
+}
+
+func indexOf(ss []string, s string) int {
+	for i, x := range ss {
+		if x == s {
+			return i
+		}
+	}
+	return -1
+}
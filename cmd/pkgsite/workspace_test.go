@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandWorkspaces(t *testing.T) {
+	dir := t.TempDir()
+	ws := filepath.Join(dir, "ws")
+	write := func(name, contents string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ws/go.work", `go 1.18
+
+use (
+	./a
+	"b"
+)
+
+use ./a/c
+
+replace example.com/fork => ../fork
+
+replace (
+	example.com/x v1.0.0 => example.com/y v1.1.0
+	example.com/d => ./d
+)
+`)
+	write("other/go.mod", "module example.com/other\n")
+
+	got, err := expandWorkspaces([]string{ws, filepath.Join(dir, "other"), filepath.Join(ws, "a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(ws, "a"),
+		filepath.Join(ws, "b"),
+		filepath.Join(ws, "a", "c"),
+		filepath.Join(dir, "fork"),
+		filepath.Join(ws, "d"),
+		filepath.Join(dir, "other"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	write("empty/go.work", "go 1.18\n")
+	if _, err := expandWorkspaces([]string{filepath.Join(dir, "empty")}); err == nil {
+		t.Error("got nil error for workspace without modules")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/pkgsite/cmd/internal/cmdconfig"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/worker"
+)
+
+// runExport runs the export subcommand, which writes an archive of the
+// module versions processed since a time to a file, or to stdout:
+//
+//	worker export -since TIME [FILE]
+//
+// The archive can be loaded into the database of another instance with the
+// import subcommand.
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	since := fs.String("since", "", "export the module versions processed since this time, in RFC 3339 format")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: %s export [flags] [FILE]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var t time.Time
+	if *since != "" {
+		var err error
+		t, err = time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return fmt.Errorf("-since: %v", err)
+		}
+	}
+	cfg, err := config.Init(ctx)
+	if err != nil {
+		return err
+	}
+	// Read the licenses of non-redistributable modules too, so that the
+	// importing instance can apply its own license checks.
+	db, err := cmdconfig.OpenDB(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f := os.Stdout
+	if fs.NArg() == 1 {
+		f, err = os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+	}
+	n, err := worker.ExportArchive(ctx, db, t, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Infof(ctx, "exported %d module versions", n)
+	return nil
+}
+
+// runImport runs the import subcommand, which inserts the module versions in
+// an archive written by the export subcommand into the database:
+//
+//	worker import [FILE]
+//
+// The archive is read from stdin if no file is given.
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	bypass := fs.Bool("bypass_license_check", false, "insert all data into the DB, even for non-redistributable paths")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: %s import [flags] [FILE]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	cfg, err := config.Init(ctx)
+	if err != nil {
+		return err
+	}
+	db, err := cmdconfig.OpenDB(ctx, cfg, *bypass)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var r io.Reader = os.Stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	n, err := worker.ImportArchive(ctx, db, r, cfg.AppVersionLabel())
+	log.Infof(ctx, "imported %d module versions", n)
+	return err
+}
@@ -4,6 +4,9 @@
 
 // The worker command runs a service with the primary job of fetching modules
 // from a proxy and writing them to the database.
+//
+// The export and import subcommands copy processed module versions between
+// the databases of two instances; see doc/worker.md.
 package main
 
 import (
@@ -20,16 +23,20 @@ import (
 	_ "github.com/jackc/pgx/v4/stdlib" // for pgx driver
 	"golang.org/x/pkgsite/cmd/internal/cmdconfig"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/analysis"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/dcensus"
+	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/index"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/namespace"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/queue"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/worker"
+	vulnc "golang.org/x/vuln/client"
 )
 
 var (
@@ -42,9 +49,21 @@ var (
 )
 
 func main() {
-	flag.Parse()
-
 	ctx := context.Background()
+	if len(os.Args) > 1 && (os.Args[1] == "export" || os.Args[1] == "import") {
+		run := runExport
+		if os.Args[1] == "import" {
+			run = runImport
+		}
+		if err := run(ctx, os.Args[2:]); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(2)
+			}
+			log.Fatal(ctx, err)
+		}
+		return
+	}
+	flag.Parse()
 
 	cfg, err := config.Init(ctx)
 	if err != nil {
@@ -75,13 +94,19 @@ func main() {
 		log.Fatal(ctx, err)
 	}
 	sourceClient := source.NewClient(config.SourceTimeout)
-	expg := cmdconfig.ExperimentGetter(ctx, cfg)
+	analyzers, err := analysis.ParseCommands(cfg.Analyzers)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	expg := cmdconfig.ExperimentGetter(ctx, cfg, db)
 	fetchQueue, err := queue.New(ctx, cfg, queueName, *workers, expg,
 		func(ctx context.Context, modulePath, version string) (int, error) {
 			f := &worker.Fetcher{
 				ProxyClient:  proxyClient,
 				SourceClient: sourceClient,
 				DB:           db,
+				UseDocCache:  cfg.DocCache,
+				Analyzers:    analyzers,
 			}
 			code, _, err := f.FetchAndUpdateState(ctx, modulePath, version, cfg.AppVersionLabel())
 			return code, err
@@ -94,6 +119,10 @@ func main() {
 	redisCacheClient := getCacheRedis(ctx, cfg)
 	redisBetaCacheClient := getBetaCacheRedis(ctx, cfg)
 	experimenter := cmdconfig.Experimenter(ctx, cfg, expg, reportingClient)
+	vulnClient, err := vulnc.NewClient([]string{cfg.VulnDB}, vulnc.Options{})
+	if err != nil {
+		log.Fatalf(ctx, "vulnc.NewClient: %v", err)
+	}
 	server, err := worker.NewServer(cfg, worker.ServerConfig{
 		DB:                   db,
 		IndexClient:          indexClient,
@@ -105,6 +134,9 @@ func main() {
 		ReportingClient:      reportingClient,
 		StaticPath:           template.TrustedSourceFromFlag(flag.Lookup("static").Value),
 		GetExperiments:       experimenter.Experiments,
+		VulnClient:           vulnClient,
+		Analyzers:            analyzers,
+		NamespaceVerifier:    namespace.NewVerifier(&http.Client{Timeout: time.Minute}).Verify,
 	})
 	if err != nil {
 		log.Fatal(ctx, err)
@@ -122,7 +154,8 @@ func main() {
 		worker.SheddedFetchCount,
 		worker.FetchLatencyDistribution,
 		worker.FetchResponseCount,
-		worker.FetchPackageCount)
+		worker.FetchPackageCount,
+		fetch.DocCacheCount)
 	if err := dcensus.Init(cfg, views...); err != nil {
 		log.Fatal(ctx, err)
 	}
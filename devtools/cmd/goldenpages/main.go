@@ -0,0 +1,197 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The goldenpages command manages the corpus of modules and the golden files
+// used by the tests in internal/testing/goldenpages.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/testing/goldenpages"
+	"golang.org/x/tools/txtar"
+)
+
+var (
+	dir     = flag.String("dir", "internal/testing/goldenpages/testdata", "directory containing the corpus and golden directories")
+	version = flag.String("version", "v1.0.0", "version of the module for add")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: goldenpages [flags] cmd [args]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  list: lists the modules in the corpus and the number of golden files for each\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  add DIR: adds the module in DIR to the corpus, at -version\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  update: renders the corpus and rewrites all golden files\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  prune: removes golden files of modules that are no longer in the corpus\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Golden files can also be updated with `go test ./internal/testing/goldenpages -update`.\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if err := run(ctx, flag.Arg(0), flag.Args()[1:]); err != nil {
+		log.Fatal(ctx, err)
+	}
+}
+
+func run(ctx context.Context, cmd string, args []string) error {
+	corpusDir := filepath.Join(*dir, "corpus")
+	goldenDir := filepath.Join(*dir, "golden")
+	switch cmd {
+	case "list":
+		return list(corpusDir, goldenDir)
+	case "add":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: add DIR")
+		}
+		return add(corpusDir, args[0], *version)
+	case "update":
+		return update(ctx, corpusDir, goldenDir)
+	case "prune":
+		return prune(corpusDir, goldenDir)
+	default:
+		return fmt.Errorf("unsupported arg: %q", cmd)
+	}
+}
+
+// list prints each module version in the corpus, with the number of its
+// golden files.
+func list(corpusDir, goldenDir string) error {
+	for _, m := range goldenpages.LoadCorpus(corpusDir) {
+		mv := m.ModulePath + "@" + m.Version
+		n := 0
+		err := filepath.WalkDir(filepath.Join(goldenDir, filepath.FromSlash(mv)), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ".golden") {
+				n++
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("%s\t%d\n", mv, n)
+	}
+	return nil
+}
+
+// add writes the files of the module in srcDir to a txtar file in the
+// corpus.
+func add(corpusDir, srcDir, version string) error {
+	data, err := os.ReadFile(filepath.Join(srcDir, "go.mod"))
+	if err != nil {
+		return err
+	}
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return fmt.Errorf("%s: no module path in go.mod", srcDir)
+	}
+	var ar txtar.Archive
+	err = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != srcDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		ar.Files = append(ar.Files, txtar.File{Name: filepath.ToSlash(rel), Data: data})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(ar.Files, func(i, j int) bool { return ar.Files[i].Name < ar.Files[j].Name })
+	// The go.mod file determines the module path, so the file name only
+	// needs to be unique.
+	name := strings.ReplaceAll(strings.TrimPrefix(modulePath, "example.com/"), "/", ":")
+	filename := filepath.Join(corpusDir, name+"@"+version+".txtar")
+	if err := os.WriteFile(filename, txtar.Format(&ar), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s; run update to create its golden files\n", filename)
+	return nil
+}
+
+// update renders every page of the corpus and writes its golden file.
+func update(ctx context.Context, corpusDir, goldenDir string) error {
+	ds, mods, err := goldenpages.NewDataSource(ctx, goldenpages.LoadCorpus(corpusDir))
+	if err != nil {
+		return err
+	}
+	h, err := goldenpages.NewHandler(ds)
+	if err != nil {
+		return err
+	}
+	pages, err := goldenpages.RenderAll(h, mods)
+	if err != nil {
+		return err
+	}
+	for _, p := range pages {
+		if err := goldenpages.WriteGolden(goldenDir, p); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("wrote %d golden files\n", len(pages))
+	return nil
+}
+
+// prune removes the golden directories of module versions that are not in
+// the corpus.
+func prune(corpusDir, goldenDir string) error {
+	keep := map[string]bool{}
+	for _, m := range goldenpages.LoadCorpus(corpusDir) {
+		keep[filepath.Join(goldenDir, filepath.FromSlash(m.ModulePath+"@"+m.Version))] = true
+	}
+	var remove []string
+	err := filepath.WalkDir(goldenDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !strings.Contains(d.Name(), "@") {
+			return nil
+		}
+		if !keep[path] {
+			remove = append(remove, path)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range remove {
+		fmt.Printf("removing %s\n", path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
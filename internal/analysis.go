@@ -0,0 +1,20 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+// An AnalysisResult is a finding of an analyzer that the operator of the site
+// runs over module versions. See package internal/analysis.
+type AnalysisResult struct {
+	// Analyzer is the name of the analyzer.
+	Analyzer string
+	// PackagePath is the path of the package that the result is about, or
+	// empty if it is about the whole module.
+	PackagePath string
+	// Severity is "info", "warning" or "error".
+	Severity string
+	Message  string
+	// URL links to more information about the result. It may be empty.
+	URL string
+}
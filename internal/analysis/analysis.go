@@ -0,0 +1,195 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analysis supports analyses that the operator of a pkgsite instance
+// runs over each module version that the worker processes, such as custom
+// lint checks, license policy checks or internal tagging. Their results are
+// stored in the database and displayed in the Analyses tab of the frontend.
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Severities of results.
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// An Analyzer analyzes module versions.
+type Analyzer interface {
+	// Name identifies the analyzer. It is displayed with its results.
+	Name() string
+	// Analyze returns the results of the analysis of m, in which Analyzer
+	// need not be set.
+	Analyze(ctx context.Context, m *internal.Module) ([]*internal.AnalysisResult, error)
+}
+
+// Timeout is the time an analyzer has to analyze a module version.
+const Timeout = time.Minute
+
+// Run runs a over m, and returns its results after checking them.
+func Run(ctx context.Context, a Analyzer, m *internal.Module) (_ []*internal.AnalysisResult, err error) {
+	defer derrors.Wrap(&err, "Run(%q, %q, %q)", a.Name(), m.ModulePath, m.Version)
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	results, err := a.Analyze(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		r.Analyzer = a.Name()
+		if r.Severity == "" {
+			r.Severity = SeverityInfo
+		}
+		if err := check(r, m); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func check(r *internal.AnalysisResult, m *internal.Module) error {
+	switch r.Severity {
+	case SeverityInfo, SeverityWarning, SeverityError:
+	default:
+		return fmt.Errorf("result has unknown severity %q", r.Severity)
+	}
+	if r.Message == "" {
+		return fmt.Errorf("result has no message")
+	}
+	if r.URL != "" && !strings.HasPrefix(r.URL, "https://") {
+		return fmt.Errorf("result URL %q is not https", r.URL)
+	}
+	if r.PackagePath != "" {
+		for _, u := range m.Units {
+			if u.Path == r.PackagePath {
+				return nil
+			}
+		}
+		return fmt.Errorf("result is about %q, which is not in the module", r.PackagePath)
+	}
+	return nil
+}
+
+// nameRegexp matches valid analyzer names.
+var nameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// ParseCommands parses analyzer specifications of the form NAME=COMMAND, as
+// in the GO_DISCOVERY_ANALYZERS environment variable, into Commands. The
+// arguments of a command are separated by spaces.
+func ParseCommands(specs []string) (_ []Analyzer, err error) {
+	defer derrors.Wrap(&err, "ParseCommands(%q)", specs)
+
+	var as []Analyzer
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		name, command, ok := strings.Cut(spec, "=")
+		if !ok || !nameRegexp.MatchString(name) || len(strings.Fields(command)) == 0 {
+			return nil, fmt.Errorf("bad analyzer %q: want NAME=COMMAND, where NAME is lower-case letters, digits and hyphens", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate analyzer %q", name)
+		}
+		seen[name] = true
+		as = append(as, &Command{name: name, args: strings.Fields(command)})
+	}
+	return as, nil
+}
+
+// A Command is an Analyzer that runs a program. The program reads a
+// CommandInput in JSON from its standard input, and writes a JSON array of
+// results, with the fields of internal.AnalysisResult other than Analyzer, to
+// its standard output. A program that exits with a non-zero status fails the
+// analysis.
+type Command struct {
+	name string
+	args []string
+}
+
+// CommandInput describes a module version to the program of a Command.
+type CommandInput struct {
+	ModulePath string
+	Version    string
+	// Licenses are the types of the licenses of the module, such as "MIT".
+	Licenses []string
+	Packages []*CommandPackage
+}
+
+// CommandPackage describes a package to the program of a Command.
+type CommandPackage struct {
+	Path              string
+	Name              string
+	Synopsis          string
+	Imports           []string
+	IsRedistributable bool
+}
+
+// Name implements Analyzer.Name.
+func (c *Command) Name() string { return c.name }
+
+// Analyze implements Analyzer.Analyze.
+func (c *Command) Analyze(ctx context.Context, m *internal.Module) (_ []*internal.AnalysisResult, err error) {
+	defer derrors.Wrap(&err, "Command(%q).Analyze", c.name)
+
+	in, err := json.Marshal(commandInput(m))
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var results []*internal.AnalysisResult
+	if err := json.Unmarshal(out, &results); err != nil {
+		return nil, fmt.Errorf("reading results: %v", err)
+	}
+	return results, nil
+}
+
+func commandInput(m *internal.Module) *CommandInput {
+	in := &CommandInput{ModulePath: m.ModulePath, Version: m.Version}
+	seen := map[string]bool{}
+	for _, l := range m.Licenses {
+		for _, t := range l.Types {
+			if !seen[t] {
+				seen[t] = true
+				in.Licenses = append(in.Licenses, t)
+			}
+		}
+	}
+	for _, u := range m.Units {
+		if !u.IsPackage() {
+			continue
+		}
+		p := &CommandPackage{
+			Path:              u.Path,
+			Name:              u.Name,
+			Imports:           u.Imports,
+			IsRedistributable: u.IsRedistributable,
+		}
+		if len(u.Documentation) > 0 {
+			p.Synopsis = u.Documentation[0].Synopsis
+		}
+		in.Packages = append(in.Packages, p)
+	}
+	return in
+}
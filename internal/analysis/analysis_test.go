@@ -0,0 +1,108 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestParseCommands(t *testing.T) {
+	as, err := ParseCommands([]string{"lint=/bin/lint -strict", "policy=policy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, a := range as {
+		got[a.Name()] = a.(*Command).args
+	}
+	want := map[string][]string{
+		"lint":   {"/bin/lint", "-strict"},
+		"policy": {"policy"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, specs := range [][]string{
+		{"lint"},
+		{"lint="},
+		{"Lint=lint"},
+		{"lint=a", "lint=b"},
+	} {
+		if _, err := ParseCommands(specs); err == nil {
+			t.Errorf("%q: got nil error, want one", specs)
+		}
+	}
+}
+
+type fakeAnalyzer []*internal.AnalysisResult
+
+func (fakeAnalyzer) Name() string { return "fake" }
+
+func (a fakeAnalyzer) Analyze(context.Context, *internal.Module) ([]*internal.AnalysisResult, error) {
+	return a, nil
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	m := sample.Module("example.com/m", "v1.0.0", "a")
+
+	got, err := Run(ctx, fakeAnalyzer{
+		{Message: "hello"},
+		{PackagePath: "example.com/m/a", Severity: SeverityError, Message: "bad", URL: "https://example.com"},
+	}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.AnalysisResult{
+		{Analyzer: "fake", Severity: SeverityInfo, Message: "hello"},
+		{Analyzer: "fake", PackagePath: "example.com/m/a", Severity: SeverityError, Message: "bad", URL: "https://example.com"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, r := range []*internal.AnalysisResult{
+		{Message: "x", Severity: "fatal"},
+		{Severity: SeverityInfo},
+		{Message: "x", URL: "javascript:alert(1)"},
+		{Message: "x", PackagePath: "example.com/other"},
+	} {
+		if _, err := Run(ctx, fakeAnalyzer{r}, m); err == nil {
+			t.Errorf("%+v: got nil error, want one", r)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	ctx := context.Background()
+	m := sample.Module("example.com/m", "v1.0.0", "a")
+
+	// The program reports the module path it reads.
+	c := &Command{name: "echo", args: []string{"sh", "-c",
+		`sed -n 's/.*"ModulePath":"\([^"]*\)".*/[{"Message": "\1"}]/p'`}}
+	got, err := Run(ctx, c, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.AnalysisResult{{Analyzer: "echo", Severity: SeverityInfo, Message: "example.com/m"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	c = &Command{name: "fail", args: []string{"sh", "-c", "echo oops >&2; exit 1"}}
+	if _, err := Run(ctx, c, m); err == nil {
+		t.Error("got nil error from failing command, want one")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package apikey defines the API keys that authenticate clients of the
+// frontend's JSON API, and the scopes that they grant.
+//
+// Keys are random strings that clients send in the X-API-Key header. Only
+// their SHA-256 hashes are stored.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Header is the request header with the API key.
+const Header = "X-API-Key"
+
+// prefix is the prefix of all keys, which makes them easy to recognize, for
+// instance by secret scanners.
+const prefix = "pkgsite_"
+
+// Scopes of API keys.
+const (
+	// ScopeRead allows reading documentation and module information.
+	ScopeRead = "read"
+	// ScopeSearch allows searching.
+	ScopeSearch = "search"
+	// ScopeFetch allows fetching modules, even if fetching is restricted.
+	ScopeFetch = "fetch"
+	// ScopeAdmin allows listing API keys, and grants all other scopes.
+	ScopeAdmin = "admin"
+)
+
+// Scopes are all the scopes, in order of increasing privilege.
+var Scopes = []string{ScopeRead, ScopeSearch, ScopeFetch, ScopeAdmin}
+
+// A Key is an API key, without its secret value.
+type Key struct {
+	ID   int
+	Name string
+	// Prefix is the start of the key, to help recognize it.
+	Prefix string
+	Scopes []string
+	// RateLimit is the number of requests per minute allowed for the key.
+	// If zero, the default limit applies.
+	RateLimit int
+	CreatedAt time.Time
+	// RevokedAt is when the key was revoked, or zero if it is active.
+	RevokedAt time.Time
+}
+
+// HasScope reports whether k grants scope.
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes parses a list of scopes separated by commas or spaces.
+func ParseScopes(s string) ([]string, error) {
+	var scopes []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !isScope(f) {
+			return nil, fmt.Errorf("unknown scope %q", f)
+		}
+		scopes = append(scopes, f)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("no scopes")
+	}
+	return scopes, nil
+}
+
+func isScope(s string) bool {
+	for _, sc := range Scopes {
+		if s == sc {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate returns a new random key, and the prefix that is displayed for it.
+func Generate() (key, keyPrefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(prefix)+6], nil
+}
+
+// Hash returns the hash of key that is stored.
+func Hash(key string) []byte {
+	h := sha256.Sum256([]byte(key))
+	return h[:]
+}
+
+// ScopeForPath returns the scope needed to request path with a key, or the
+// empty string if path is not part of the API. The uploads of vulnerability
+// reports and benchmarks are not, since they have their own tokens.
+func ScopeForPath(path string) string {
+	switch {
+	case path == "/api/v1/complete":
+		return ScopeSearch
+	case path == "/api/v1/keys":
+		return ScopeAdmin
+	case path == "/api/v1/vulnreports" || path == "/api/v1/benchmarks":
+		return ""
+	case strings.HasPrefix(path, "/api/v1/"):
+		return ScopeRead
+	case strings.HasPrefix(path, "/fetch/"):
+		return ScopeFetch
+	}
+	return ""
+}
+
+// FromRequest returns the API key in r, or the empty string if there is none.
+func FromRequest(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(Header))
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries the authenticated key k.
+func NewContext(ctx context.Context, k *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, k)
+}
+
+// FromContext returns the authenticated key of a request, or nil if it was
+// not made with one.
+func FromContext(ctx context.Context) *Key {
+	k, _ := ctx.Value(contextKey{}).(*Key)
+	return k
+}
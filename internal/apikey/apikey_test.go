@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apikey
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	k1, p1, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, _, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 == k2 {
+		t.Error("keys are equal")
+	}
+	if !strings.HasPrefix(k1, p1) || !strings.HasPrefix(p1, prefix) {
+		t.Errorf("got key %q with prefix %q", k1, p1)
+	}
+	if bytes.Equal(Hash(k1), Hash(k2)) {
+		t.Error("hashes are equal")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes("read, search fetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"read", "search", "fetch"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, s := range []string{"", "write", "read,,all"} {
+		if _, err := ParseScopes(s); err == nil {
+			t.Errorf("ParseScopes(%q): got no error", s)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	k := &Key{Scopes: []string{ScopeRead}}
+	if !k.HasScope(ScopeRead) || k.HasScope(ScopeSearch) {
+		t.Errorf("read key: got wrong scopes")
+	}
+	admin := &Key{Scopes: []string{ScopeAdmin}}
+	for _, s := range Scopes {
+		if !admin.HasScope(s) {
+			t.Errorf("admin key does not have scope %q", s)
+		}
+	}
+}
+
+func TestScopeForPath(t *testing.T) {
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"/api/v1/package", ScopeRead},
+		{"/api/v1/complete", ScopeSearch},
+		{"/api/v1/keys", ScopeAdmin},
+		{"/api/v1/vulnreports", ""},
+		{"/fetch/example.com/m", ScopeFetch},
+		{"/example.com/m", ""},
+	} {
+		if got := ScopeForPath(test.path); got != test.want {
+			t.Errorf("ScopeForPath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+// A CodeIndex holds code navigation data for the non-test .go files of a
+// package: the definitions of its package-level declarations, and the
+// references to them and to the package-level declarations of imported
+// packages. It is computed from the syntax of the files alone, without type
+// checking, so references to methods and struct fields are not recorded.
+type CodeIndex struct {
+	Files []*CodeIndexFile `json:"files"`
+}
+
+// A CodeIndexFile holds the definitions and references of one file of a
+// package.
+type CodeIndexFile struct {
+	// Name is the name of the file, relative to the package directory.
+	Name string          `json:"name"`
+	Defs []*CodeIndexDef `json:"defs,omitempty"`
+	Refs []*CodeIndexRef `json:"refs,omitempty"`
+}
+
+// A CodeIndexDef is the definition of a package-level declaration.
+type CodeIndexDef struct {
+	// Name is the name of the declaration, or "Type.Method" for a method.
+	Name  string    `json:"name"`
+	Range CodeRange `json:"range"`
+	// Decl is the source of the declaration, without its doc comment or
+	// function body.
+	Decl string `json:"decl"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// A CodeIndexRef is a reference to a package-level declaration.
+type CodeIndexRef struct {
+	// Package is the import path of the package of the declaration.
+	Package string    `json:"pkg"`
+	Name    string    `json:"name"`
+	Range   CodeRange `json:"range"`
+}
+
+// A CodeRange is a span of source text in the coordinates of the Language
+// Server Protocol: a start line and character and an end line and character,
+// where lines are zero-based and characters are counted in UTF-16 code units.
+type CodeRange [4]int
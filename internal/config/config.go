@@ -160,6 +160,7 @@ type Config struct {
 
 	DBSecret, DBUser, DBHost, DBPort, DBName, DBSSL string
 	DBSecondaryHost                                 string // DB host to use if first one is down
+	DBStandbyHost                                   string // read-only DB host to use while the primary is down
 	DBPassword                                      string `json:"-"`
 
 	// Configuration for redis page cache.
@@ -188,6 +189,115 @@ type Config struct {
 
 	// VulnDB is the URL of the Go vulnerability DB.
 	VulnDB string
+
+	// DiagramRendererURL is the URL of a Kroki service that renders the
+	// diagrams in READMEs and guides. If empty, diagrams are displayed as
+	// code.
+	DiagramRendererURL string
+
+	// ImageProxySecret is the name of the secret holding the hex-encoded key
+	// that signs the URLs of the image proxy. If empty, images in READMEs
+	// and guides are not proxied.
+	ImageProxySecret string
+	// ImageProxyKey is the key read from ImageProxySecret.
+	ImageProxyKey []byte `json:"-"`
+
+	// ExportBucket is the name of the Cloud Storage bucket that the worker
+	// writes exports of the corpus to. If empty, exports are disabled.
+	ExportBucket string
+
+	// DigestPrefixes are the module path prefixes that the worker's weekly
+	// digest of new releases covers by default.
+	DigestPrefixes []string
+
+	// CanonicalHost is the host that the frontend redirects requests for
+	// other hosts, and plain HTTP requests, to. If empty, there are no such
+	// redirects.
+	CanonicalHost string
+
+	// VersionedCanonical makes the rel=canonical link of a page for a
+	// specific version of a unit point to that page. By default, it points
+	// to the unversioned page for the latest version, so that search engines
+	// attribute all versions of a unit to one URL.
+	VersionedCanonical bool
+
+	// VulnReportTokens are the bearer tokens that authorize uploads of
+	// govulncheck reports. If empty, uploads are disabled.
+	VulnReportTokens []string `json:"-"`
+
+	// BenchmarkTokens are the bearer tokens that authorize uploads of
+	// benchmark results. If empty, uploads are disabled.
+	BenchmarkTokens []string `json:"-"`
+
+	// SupportContact is an email address or URL that error pages direct users
+	// to for help. If empty, error pages do not show a contact.
+	SupportContact string
+
+	// LandingPage is a path on the frontend, such as "/example.com/docs",
+	// that requests for the homepage are redirected to. If empty, the
+	// homepage is served.
+	LandingPage string
+
+	// MirrorUpstreamURL is the URL of the site that the frontend mirrors,
+	// such as "https://pkg.go.dev". If set, unit pages link to their
+	// upstream pages and say when their module version was processed, so
+	// that readers know that they may be out of date.
+	MirrorUpstreamURL string
+
+	// TrustedProxies is the number of proxies in front of the frontend, such
+	// as load balancers, that append the address of their client to the
+	// X-Forwarded-For header. It determines which entry of the header is
+	// matched against the allowlists of experiments.
+	TrustedProxies int
+
+	// RecordPageViews is whether the frontend counts the views of package
+	// pages, per day and tab, in the database. Nothing about the viewers is
+	// recorded.
+	RecordPageViews bool
+
+	// RecordSearchQueries is whether the frontend counts the searches, per
+	// day and normalized query, with the number of results and the top
+	// result, in the database. Nothing about the searchers is recorded.
+	RecordSearchQueries bool
+
+	// SearchQueryRetentionDays is the number of days for which the worker
+	// keeps the search counts recorded by the frontend.
+	SearchQueryRetentionDays int
+
+	// DocCache is whether the worker stores the processed packages of
+	// modules in the database, by a hash of the module contents, and reuses
+	// them instead of processing the same contents again.
+	DocCache bool
+
+	// Analyzers are the analyzers that the worker runs over each module
+	// version it processes, in the form NAME=COMMAND. See package
+	// internal/analysis.
+	Analyzers []string
+
+	// CSP configures the Content-Security-Policy of the frontend.
+	CSP CSPSettings
+
+	// FetchAuth restricts fetching modules from the frontend to some users.
+	FetchAuth FetchAuthSettings
+
+	// APIKeys configures the API keys of the frontend.
+	APIKeys APIKeySettings
+
+	// SearchSynonyms are groups of terms that are interchangeable in search
+	// queries. If nil, the default groups in internal/postgres/search are
+	// used.
+	SearchSynonyms [][]string
+
+	// SearchEmbedFrameAncestors are the sources, such as
+	// https://portal.example.com, of the pages that may show the search
+	// widget served at /search-embed in a frame. If empty, no other site may
+	// embed it.
+	SearchEmbedFrameAncestors []string
+
+	// HSTSMaxAge is the max-age, in seconds, of the Strict-Transport-Security
+	// header that the frontend sets on HTTPS responses. If zero, the header
+	// is not set.
+	HSTSMaxAge int
 }
 
 // AppVersionLabel returns the version label for the current instance.  This is
@@ -259,6 +369,16 @@ func (c *Config) DBSecondaryConnInfo() string {
 	return c.dbConnInfo(c.DBSecondaryHost)
 }
 
+// DBStandbyConnInfo returns a PostgreSQL connection string constructed from
+// environment variables, using the standby database host. It returns the
+// empty string if no standby is configured.
+func (c *Config) DBStandbyConnInfo() string {
+	if c.DBStandbyHost == "" {
+		return ""
+	}
+	return c.dbConnInfo(c.DBStandbyHost)
+}
+
 // dbConnInfo returns a PostgresSQL connection string for the given host.
 func (c *Config) dbConnInfo(host string) string {
 	// For the connection string syntax, see
@@ -349,6 +469,54 @@ type QuotaSettings struct {
 	HMACKey    []byte `json:"-"` // key for obfuscating IPs
 }
 
+// CSPSettings is config for the Content-Security-Policy set by
+// internal/middleware/secureheaders.go.
+type CSPSettings struct {
+	// Directives are added to the enforced policy. A directive replaces the
+	// default directive with the same name, if any.
+	Directives []string
+	// ReportOnlyDirectives are added to the enforced policy to form a
+	// report-only policy, which lets a stricter policy be tried out
+	// without breaking pages. If empty, there is no report-only policy.
+	ReportOnlyDirectives []string
+	// ReportSampleRate is the fraction of violation reports that are
+	// stored. If zero, browsers are not asked to send reports.
+	ReportSampleRate float64
+	// ReportRetentionDays is the number of days for which the worker keeps
+	// the stored reports.
+	ReportRetentionDays int
+}
+
+// FetchAuthSettings is config for restricting fetching modules from the
+// frontend to the users in some groups, who are identified by the OpenID
+// Connect ID tokens that an authenticating proxy adds to their requests.
+type FetchAuthSettings struct {
+	// Issuer is the URL of the OpenID Connect provider. If empty, anyone
+	// may fetch modules.
+	Issuer string
+	// Audience is the client ID that the ID tokens are issued for.
+	Audience string
+	// Header is the request header with the ID token. If it is
+	// "Authorization", the token is a bearer token.
+	Header string
+	// GroupsClaim is the claim of the ID tokens with the groups of the user.
+	GroupsClaim string
+	// Groups are the groups whose members may fetch modules. If empty, all
+	// authenticated users may.
+	Groups []string
+}
+
+// APIKeySettings is config for the API keys that authenticate clients of the
+// JSON API of the frontend.
+type APIKeySettings struct {
+	// Required makes keys required for all requests to the API. Otherwise,
+	// only requests that need the admin scope require a key.
+	Required bool
+	// DefaultRateLimit is the number of requests per minute allowed for
+	// keys without a limit of their own. If zero, there is no limit.
+	DefaultRateLimit int
+}
+
 // Init resolves all configuration values provided by the config package. It
 // must be called before any configuration values are used.
 func Init(ctx context.Context) (_ *Config, err error) {
@@ -383,6 +551,7 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		DBUser:               GetEnv("GO_DISCOVERY_DATABASE_USER", "postgres"),
 		DBPassword:           os.Getenv("GO_DISCOVERY_DATABASE_PASSWORD"),
 		DBSecondaryHost:      chooseOne(os.Getenv("GO_DISCOVERY_DATABASE_SECONDARY_HOST")),
+		DBStandbyHost:        os.Getenv("GO_DISCOVERY_DATABASE_STANDBY_HOST"),
 		DBPort:               GetEnv("GO_DISCOVERY_DATABASE_PORT", "5432"),
 		DBName:               GetEnv("GO_DISCOVERY_DATABASE_NAME", "discovery-db"),
 		DBSecret:             os.Getenv("GO_DISCOVERY_DATABASE_SECRET"),
@@ -401,11 +570,48 @@ func Init(ctx context.Context) (_ *Config, err error) {
 			}(),
 			AuthValues: parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
 		},
-		UseProfiler:           os.Getenv("GO_DISCOVERY_USE_PROFILER") == "true",
-		LogLevel:              os.Getenv("GO_DISCOVERY_LOG_LEVEL"),
-		ServeStats:            os.Getenv("GO_DISCOVERY_SERVE_STATS") == "true",
-		DisableErrorReporting: os.Getenv("GO_DISCOVERY_DISABLE_ERROR_REPORTING") == "true",
-		VulnDB:                GetEnv("GO_DISCOVERY_VULN_DB", "https://storage.googleapis.com/go-vulndb"),
+		UseProfiler:               os.Getenv("GO_DISCOVERY_USE_PROFILER") == "true",
+		LogLevel:                  os.Getenv("GO_DISCOVERY_LOG_LEVEL"),
+		ServeStats:                os.Getenv("GO_DISCOVERY_SERVE_STATS") == "true",
+		DisableErrorReporting:     os.Getenv("GO_DISCOVERY_DISABLE_ERROR_REPORTING") == "true",
+		VulnDB:                    GetEnv("GO_DISCOVERY_VULN_DB", "https://storage.googleapis.com/go-vulndb"),
+		DiagramRendererURL:        os.Getenv("GO_DISCOVERY_DIAGRAM_RENDERER_URL"),
+		ImageProxySecret:          os.Getenv("GO_DISCOVERY_IMAGE_PROXY_SECRET"),
+		ExportBucket:              os.Getenv("GO_DISCOVERY_EXPORT_BUCKET"),
+		DigestPrefixes:            parseCommaList(os.Getenv("GO_DISCOVERY_DIGEST_PREFIXES")),
+		CanonicalHost:             os.Getenv("GO_DISCOVERY_CANONICAL_HOST"),
+		VersionedCanonical:        os.Getenv("GO_DISCOVERY_VERSIONED_CANONICAL") == "true",
+		VulnReportTokens:          parseCommaList(os.Getenv("GO_DISCOVERY_VULN_REPORT_TOKENS")),
+		BenchmarkTokens:           parseCommaList(os.Getenv("GO_DISCOVERY_BENCHMARK_TOKENS")),
+		SupportContact:            os.Getenv("GO_DISCOVERY_SUPPORT_CONTACT"),
+		LandingPage:               os.Getenv("GO_DISCOVERY_LANDING_PAGE"),
+		MirrorUpstreamURL:         strings.TrimSuffix(os.Getenv("GO_DISCOVERY_MIRROR_UPSTREAM_URL"), "/"),
+		TrustedProxies:            GetEnvInt(ctx, "GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		RecordPageViews:           os.Getenv("GO_DISCOVERY_RECORD_PAGE_VIEWS") == "true",
+		RecordSearchQueries:       os.Getenv("GO_DISCOVERY_RECORD_SEARCH_QUERIES") == "true",
+		SearchQueryRetentionDays:  GetEnvInt(ctx, "GO_DISCOVERY_SEARCH_QUERY_RETENTION_DAYS", 90),
+		DocCache:                  os.Getenv("GO_DISCOVERY_DOC_CACHE") == "true",
+		Analyzers:                 parseCommaList(os.Getenv("GO_DISCOVERY_ANALYZERS")),
+		HSTSMaxAge:                GetEnvInt(ctx, "GO_DISCOVERY_HSTS_MAX_AGE", 0),
+		SearchSynonyms:            parseSynonyms(os.Getenv("GO_DISCOVERY_SEARCH_SYNONYMS")),
+		SearchEmbedFrameAncestors: parseCommaList(os.Getenv("GO_DISCOVERY_SEARCH_EMBED_FRAME_ANCESTORS")),
+		FetchAuth: FetchAuthSettings{
+			Issuer:      os.Getenv("GO_DISCOVERY_FETCH_OIDC_ISSUER"),
+			Audience:    os.Getenv("GO_DISCOVERY_FETCH_OIDC_AUDIENCE"),
+			Header:      GetEnv("GO_DISCOVERY_FETCH_OIDC_HEADER", "Authorization"),
+			GroupsClaim: GetEnv("GO_DISCOVERY_FETCH_OIDC_GROUPS_CLAIM", "groups"),
+			Groups:      parseCommaList(os.Getenv("GO_DISCOVERY_FETCH_GROUPS")),
+		},
+		APIKeys: APIKeySettings{
+			Required:         os.Getenv("GO_DISCOVERY_API_KEYS_REQUIRED") == "true",
+			DefaultRateLimit: GetEnvInt(ctx, "GO_DISCOVERY_API_KEY_RATE_LIMIT", 600),
+		},
+		CSP: CSPSettings{
+			Directives:           parseSemicolonList(os.Getenv("GO_DISCOVERY_CSP_DIRECTIVES")),
+			ReportOnlyDirectives: parseSemicolonList(os.Getenv("GO_DISCOVERY_CSP_REPORT_ONLY")),
+			ReportSampleRate:     GetEnvFloat64("GO_DISCOVERY_CSP_REPORT_SAMPLE_RATE", 0),
+			ReportRetentionDays:  GetEnvInt(ctx, "GO_DISCOVERY_CSP_REPORT_RETENTION_DAYS", 30),
+		},
 	}
 	log.SetLevel(cfg.LogLevel)
 
@@ -509,6 +715,19 @@ func Init(ctx context.Context) (_ *Config, err error) {
 	} else {
 		log.Debugf(ctx, "quota enforcement disabled")
 	}
+	if cfg.ImageProxySecret != "" {
+		s, err := secrets.Get(ctx, cfg.ImageProxySecret)
+		if err != nil {
+			return nil, fmt.Errorf("could not get image proxy secret: %v", err)
+		}
+		cfg.ImageProxyKey, err = hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.ImageProxyKey) < 16 {
+			return nil, errors.New("image proxy secret must be at least 16 bytes")
+		}
+	}
 
 	// If the <env>-override.yaml file exists in the configured bucket, it
 	// should provide overrides for selected configuration.
@@ -616,8 +835,29 @@ func gceMetadata(ctx context.Context, name string) (_ string, err error) {
 }
 
 func parseCommaList(s string) []string {
+	return parseList(s, ",")
+}
+
+// parseSemicolonList parses a list of CSP directives, which are separated by
+// semicolons.
+func parseSemicolonList(s string) []string {
+	return parseList(s, ";")
+}
+
+// parseSynonyms parses groups of synonyms. Groups are separated by
+// semicolons, and the terms of a group by commas, as in
+// "yaml,yml;postgres,postgresql". It returns nil if s is empty.
+func parseSynonyms(s string) [][]string {
+	var groups [][]string
+	for _, g := range parseSemicolonList(s) {
+		groups = append(groups, parseCommaList(g))
+	}
+	return groups
+}
+
+func parseList(s, sep string) []string {
 	var a []string
-	for _, p := range strings.Split(s, ",") {
+	for _, p := range strings.Split(s, sep) {
 		p = strings.TrimSpace(p)
 		if p != "" {
 			a = append(a, p)
@@ -26,7 +26,8 @@ import (
 //
 // CopyInsert works by first creating a temporary table, populating it with
 // CopyFrom, and then running an INSERT...SELECT... to insert its rows into the
-// original table.
+// original table. On CockroachDB, it inserts the rows with bulk inserts
+// instead.
 func (db *DB) CopyInsert(ctx context.Context, table string, columns []string, src pgx.CopyFromSource, dropColumn string) (err error) {
 	defer derrors.Wrap(&err, "CopyInsert(%q)", table)
 	return db.copy(ctx, table, columns, src, dropColumn, "")
@@ -43,7 +44,8 @@ func (db *DB) CopyInsert(ctx context.Context, table string, columns []string, sr
 //
 // CopyUpsert works by first creating a temporary table, populating it with
 // CopyFrom, and then running an INSERT...SELECT...ON CONFLICT to upsert its
-// rows into the original table.
+// rows into the original table. On CockroachDB, it upserts the rows with bulk
+// inserts instead.
 func (db *DB) CopyUpsert(ctx context.Context, table string, columns []string, src pgx.CopyFromSource, conflictColumns []string, dropColumn string) (err error) {
 	defer derrors.Wrap(&err, "CopyUpsert(%q)", table)
 	return db.copy(ctx, table, columns, src, dropColumn, buildUpsertConflictAction(columns, conflictColumns))
@@ -53,6 +55,10 @@ func (db *DB) copy(ctx context.Context, table string, columns []string, src pgx.
 	if !db.InTransaction() {
 		return errors.New("not in a transaction")
 	}
+	if db.dialect == CockroachDB {
+		// CockroachDB does not support ON COMMIT DROP for temporary tables.
+		return db.copyWithBulkInsert(ctx, table, columns, src, conflictAction)
+	}
 
 	return db.WithPGXConn(func(conn *pgx.Conn) error {
 		tempTable := fmt.Sprintf("__%s_copy", table)
@@ -90,6 +96,23 @@ func (db *DB) copy(ctx context.Context, table string, columns []string, src pgx.
 	})
 }
 
+// copyWithBulkInsert reads all the rows of src, and inserts them into table
+// with bulkInsert.
+func (db *DB) copyWithBulkInsert(ctx context.Context, table string, columns []string, src pgx.CopyFromSource, conflictAction string) error {
+	var values []interface{}
+	for src.Next() {
+		vs, err := src.Values()
+		if err != nil {
+			return err
+		}
+		values = append(values, vs...)
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	return db.bulkInsert(ctx, table, columns, nil, values, conflictAction, nil)
+}
+
 func (db *DB) WithPGXConn(f func(conn *pgx.Conn) error) error {
 	if !db.InTransaction() {
 		return errors.New("not in a transaction")
@@ -38,6 +38,8 @@ type DB struct {
 	opts       sql.TxOptions // valid when tx != nil
 	mu         sync.Mutex
 	maxRetries int // max times a single transaction was retried
+	queryHook  func(context.Context) error
+	dialect    Dialect
 }
 
 // Open creates a new DB  for the given connection string.
@@ -55,7 +57,13 @@ func Open(driverName, dbinfo, instanceID string) (_ *DB, err error) {
 	if err := db.PingContext(ctx); err != nil {
 		return nil, err
 	}
-	return New(db, instanceID), nil
+	d, err := detectDialect(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	ddb := New(db, instanceID)
+	ddb.dialect = d
+	return ddb, nil
 }
 
 // New creates a new DB from a sql.DB.
@@ -63,10 +71,35 @@ func New(db *sql.DB, instanceID string) *DB {
 	return &DB{db: db, instanceID: instanceID}
 }
 
+// SetQueryHook arranges for f to be called before db, or any transaction
+// started from it, runs a query or statement. If f returns an error, the
+// query is not run and the error is returned in its place. It is meant for
+// injecting faults, and must be called before db is used.
+func (db *DB) SetQueryHook(f func(context.Context) error) {
+	db.queryHook = f
+}
+
+// runQueryHook calls the query hook of db, if any.
+func (db *DB) runQueryHook(ctx context.Context) error {
+	if db.queryHook == nil {
+		return nil
+	}
+	return db.queryHook(ctx)
+}
+
 func (db *DB) Ping() error {
 	return db.db.Ping()
 }
 
+// PingContext verifies that the database is reachable, within the deadline
+// of ctx. Like a query, it runs the query hook first.
+func (db *DB) PingContext(ctx context.Context) error {
+	if err := db.runQueryHook(ctx); err != nil {
+		return err
+	}
+	return db.db.PingContext(ctx)
+}
+
 func (db *DB) InTransaction() bool {
 	return db.tx != nil
 }
@@ -102,6 +135,9 @@ func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (_ in
 
 // execResult executes a SQL statement and returns a sql.Result.
 func (db *DB) execResult(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	if err := db.runQueryHook(ctx); err != nil {
+		return nil, err
+	}
 	if db.tx != nil {
 		return db.tx.ExecContext(ctx, query, args...)
 	}
@@ -111,6 +147,9 @@ func (db *DB) execResult(ctx context.Context, query string, args ...interface{})
 // Query runs the DB query.
 func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (_ *sql.Rows, err error) {
 	defer logQuery(ctx, query, args, db.instanceID, db.IsRetryable())(&err)
+	if err := db.runQueryHook(ctx); err != nil {
+		return nil, err
+	}
 	if db.tx != nil {
 		return db.tx.QueryContext(ctx, query, args...)
 	}
@@ -128,14 +167,25 @@ func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *
 			log.Errorf(ctx, "QueryRow context error: %v "+msg, ctx.Err())
 		}
 	}()
+	qctx := ctx
+	if err := db.runQueryHook(ctx); err != nil {
+		// A sql.Row can't be created with an arbitrary error, so make the
+		// query fail by running it with a canceled context.
+		var cancel context.CancelFunc
+		qctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
 	if db.tx != nil {
-		return db.tx.QueryRowContext(ctx, query, args...)
+		return db.tx.QueryRowContext(qctx, query, args...)
 	}
-	return db.db.QueryRowContext(ctx, query, args...)
+	return db.db.QueryRowContext(qctx, query, args...)
 }
 
 func (db *DB) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
 	defer logQuery(ctx, "preparing "+query, nil, db.instanceID, db.IsRetryable())
+	if err := db.runQueryHook(ctx); err != nil {
+		return nil, err
+	}
 	if db.tx != nil {
 		return db.tx.PrepareContext(ctx, query)
 	}
@@ -303,6 +353,8 @@ func (db *DB) transact(ctx context.Context, opts *sql.TxOptions, txFunc func(*DB
 	dbtx.tx = tx
 	dbtx.conn = conn
 	dbtx.opts = *opts
+	dbtx.queryHook = db.queryHook
+	dbtx.dialect = db.dialect
 	defer dbtx.logTransaction(ctx)(&err)
 	if err := txFunc(dbtx); err != nil {
 		return fmt.Errorf("txFunc(tx): %w", err)
@@ -578,3 +578,36 @@ func TestCollectStrings(t *testing.T) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 }
+
+func TestQueryHook(t *testing.T) {
+	ctx := context.Background()
+	errHook := errors.New("hook")
+	fail := true
+	db := New(testDB.db, "test")
+	db.SetQueryHook(func(context.Context) error {
+		if fail {
+			return errHook
+		}
+		return nil
+	})
+
+	if _, err := db.Exec(ctx, `SELECT 1`); !errors.Is(err, errHook) {
+		t.Errorf("Exec: got %v, want hook error", err)
+	}
+	var i int
+	if err := db.QueryRow(ctx, `SELECT 1`).Scan(&i); err == nil {
+		t.Error("QueryRow: got nil, want error")
+	}
+	err := db.Transact(ctx, sql.LevelDefault, func(tx *DB) error {
+		_, err := tx.Exec(ctx, `SELECT 1`)
+		return err
+	})
+	if !errors.Is(err, errHook) {
+		t.Errorf("Transact: got %v, want hook error", err)
+	}
+
+	fail = false
+	if err := db.QueryRow(ctx, `SELECT 1`).Scan(&i); err != nil || i != 1 {
+		t.Errorf("QueryRow: got %d, %v; want 1, nil", i, err)
+	}
+}
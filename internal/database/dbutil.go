@@ -184,6 +184,12 @@ func ResetDB(ctx context.Context, db *DB) error {
 		if _, err := tx.Exec(ctx, `TRUNCATE excluded_prefixes;`); err != nil {
 			return err
 		}
+		if _, err := tx.Exec(ctx, `TRUNCATE license_reviews;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE corpus_stats, daily_processed_versions, module_imported_by_snapshots, module_feeds, experiments, csp_reports, site_banner, search_interleaving_clicks, search_vocabulary, homepage_sections, fetch_requests, api_keys, page_views, doc_cache, module_redirects;`); err != nil {
+			return err
+		}
 		return nil
 	}); err != nil {
 		return fmt.Errorf("error resetting test DB: %v", err)
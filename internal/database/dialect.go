@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A Dialect is a variant of SQL spoken by a database server.
+type Dialect int
+
+const (
+	// Postgres is the dialect of PostgreSQL, which pkgsite is developed
+	// against.
+	Postgres Dialect = iota
+	// CockroachDB is the dialect of CockroachDB. It is mostly compatible
+	// with PostgreSQL, but lacks some of its features, so some statements
+	// have alternatives that are used instead.
+	CockroachDB
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case CockroachDB:
+		return "cockroachdb"
+	default:
+		return "unknown"
+	}
+}
+
+// Dialect returns the dialect of the server that db is connected to. It is
+// detected by Open; a DB created with New is assumed to be Postgres.
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// detectDialect returns the dialect of the server that db is connected to.
+func detectDialect(ctx context.Context, db *sql.DB) (_ Dialect, err error) {
+	defer derrors.Wrap(&err, "detectDialect")
+
+	var version string
+	if err := db.QueryRowContext(ctx, `SELECT version()`).Scan(&version); err != nil {
+		return 0, err
+	}
+	if strings.Contains(version, "CockroachDB") {
+		return CockroachDB, nil
+	}
+	return Postgres, nil
+}
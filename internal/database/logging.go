@@ -28,12 +28,14 @@ type queryEndLogEntry struct {
 	Query           string
 	Args            string
 	DurationSeconds float64
+	Slow            bool   `json:",omitempty"`
 	Error           string `json:",omitempty"`
 }
 
 func logQuery(ctx context.Context, query string, args []interface{}, instanceID string, retryable bool) func(*error) {
+	record := recordQueryStats(ctx)
 	if QueryLoggingDisabled {
-		return func(*error) {}
+		return func(*error) { record() }
 	}
 	const maxlen = 300 // maximum length of displayed query
 
@@ -76,9 +78,15 @@ func logQuery(ctx context.Context, query string, args []interface{}, instanceID
 	log.Debugf(ctx, "%s %s args=%s", uid, query, argString)
 	start := time.Now()
 	return func(errp *error) {
+		record()
 		dur := time.Since(start)
+		slow := dur >= SlowQueryThreshold
 		if errp == nil { // happens with queryRow
-			log.Debugf(ctx, "%s done", uid)
+			if slow {
+				log.Warningf(ctx, "%s slow query done in %s: %s args=%s", uid, dur, query, argString)
+			} else {
+				log.Debugf(ctx, "%s done", uid)
+			}
 		} else {
 			derrors.Wrap(errp, "DB running query %s", uid)
 			entry := queryEndLogEntry{
@@ -86,9 +94,14 @@ func logQuery(ctx context.Context, query string, args []interface{}, instanceID
 				Query:           query,
 				Args:            argString,
 				DurationSeconds: dur.Seconds(),
+				Slow:            slow,
 			}
 			if *errp == nil {
-				log.Debug(ctx, entry)
+				if slow {
+					log.Warning(ctx, entry)
+				} else {
+					log.Debug(ctx, entry)
+				}
 			} else {
 				entry.Error = (*errp).Error()
 				// There are many places in our logs when a query will be
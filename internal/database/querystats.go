@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SlowQueryThreshold is the duration above which a query is logged as slow.
+var SlowQueryThreshold = time.Second
+
+// QueryStats accumulates statistics about the queries issued on behalf of a
+// single request. It is safe for concurrent use, since a request may run
+// queries in parallel.
+type QueryStats struct {
+	mu       sync.Mutex
+	count    int
+	duration time.Duration
+	slowest  time.Duration
+}
+
+type queryStatsKey struct{}
+
+// NewContextWithQueryStats returns a context that records statistics about
+// the queries run with it in the returned QueryStats.
+func NewContextWithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	qs := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, qs), qs
+}
+
+// QueryStatsFromContext returns the QueryStats of ctx, or nil if there are
+// none.
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	qs, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return qs
+}
+
+// Get returns the number of queries recorded so far, their cumulative
+// duration and the duration of the slowest one.
+func (qs *QueryStats) Get() (count int, duration, slowest time.Duration) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	return qs.count, qs.duration, qs.slowest
+}
+
+// Add records a query that took d.
+func (qs *QueryStats) Add(d time.Duration) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	qs.count++
+	qs.duration += d
+	if d > qs.slowest {
+		qs.slowest = d
+	}
+}
+
+// recordQueryStats returns a function that records the time since it was
+// created in the QueryStats of ctx, if any.
+func recordQueryStats(ctx context.Context) func() {
+	qs := QueryStatsFromContext(ctx)
+	if qs == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { qs.Add(time.Since(start)) }
+}
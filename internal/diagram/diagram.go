@@ -0,0 +1,155 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package diagram renders diagrams written in text, such as Mermaid and
+// PlantUML, to SVG.
+//
+// Rendering is done by a separate service that implements the Kroki API
+// (https://kroki.io), so that the untrusted input is never processed in the
+// frontend itself.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Kinds of diagrams.
+const (
+	Mermaid  = "mermaid"
+	PlantUML = "plantuml"
+)
+
+// kinds maps the languages of fenced code blocks to the kinds of diagrams
+// they hold.
+var kinds = map[string]string{
+	"mermaid":  Mermaid,
+	"plantuml": PlantUML,
+	"puml":     PlantUML,
+}
+
+// Kind returns the kind of diagram in a fenced code block with the given
+// language, or the empty string if it is not a diagram.
+func Kind(language string) string {
+	return kinds[strings.ToLower(language)]
+}
+
+const (
+	// renderTimeout is the maximum time to render one diagram.
+	renderTimeout = 5 * time.Second
+
+	// maxSourceSize is the maximum size of a diagram's source. Larger
+	// diagrams are not rendered.
+	maxSourceSize = 16 * 1024
+
+	// maxSVGSize is the maximum size of a rendered diagram.
+	maxSVGSize = 1024 * 1024
+
+	// maxCachedDiagrams is the number of rendered diagrams that are cached.
+	maxCachedDiagrams = 1000
+
+	// failureTTL is how long a failure to render a diagram is remembered, so
+	// that pages are not slowed down by retrying it on every request.
+	failureTTL = time.Minute
+)
+
+// A Renderer renders diagrams using a Kroki service. It caches the results.
+// It is safe for concurrent use.
+type Renderer struct {
+	baseURL    string
+	httpClient *http.Client
+	cache      *lru.Cache
+}
+
+// cacheEntry is the result of rendering a diagram.
+type cacheEntry struct {
+	svg     []byte
+	err     error
+	expires time.Time // for failures only
+}
+
+// NewRenderer returns a Renderer that uses the Kroki service at baseURL.
+func NewRenderer(baseURL string, client *http.Client) (*Renderer, error) {
+	cache, err := lru.New(maxCachedDiagrams)
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: client,
+		cache:      cache,
+	}, nil
+}
+
+// Render returns the SVG for the diagram of the given kind with the given
+// source. It returns an error wrapping derrors.InvalidArgument if the kind is
+// unknown or the source is too large.
+func (r *Renderer) Render(ctx context.Context, kind, source string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "Render(ctx, %q)", kind)
+
+	if kind != Mermaid && kind != PlantUML {
+		return nil, fmt.Errorf("unknown kind of diagram: %w", derrors.InvalidArgument)
+	}
+	if len(source) > maxSourceSize {
+		return nil, fmt.Errorf("source is larger than %d bytes: %w", maxSourceSize, derrors.InvalidArgument)
+	}
+	key := sha256.Sum256([]byte(kind + "\x00" + source))
+	if v, ok := r.cache.Get(key); ok {
+		e := v.(*cacheEntry)
+		if e.err == nil || time.Now().Before(e.expires) {
+			return e.svg, e.err
+		}
+	}
+	svg, err := r.render(ctx, kind, source)
+	if err != nil {
+		// Don't remember failures caused by the caller.
+		if ctx.Err() == nil {
+			r.cache.Add(key, &cacheEntry{err: err, expires: time.Now().Add(failureTTL)})
+		}
+		return nil, err
+	}
+	r.cache.Add(key, &cacheEntry{svg: svg})
+	return svg, nil
+}
+
+// render asks the service to render the diagram.
+func (r *Renderer) render(ctx context.Context, kind, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/"+kind+"/svg", strings.NewReader(source))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("renderer returned status %d", resp.StatusCode)
+	}
+	svg, err := io.ReadAll(io.LimitReader(resp.Body, maxSVGSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(svg) > maxSVGSize {
+		return nil, fmt.Errorf("diagram is larger than %d bytes", maxSVGSize)
+	}
+	if !bytes.Contains(svg, []byte("<svg")) {
+		return nil, errors.New("renderer did not return an SVG image")
+	}
+	return svg, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diagram
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestRender(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method != http.MethodPost:
+			http.Error(w, "bad method", http.StatusMethodNotAllowed)
+		case strings.Contains(string(body), "bad"):
+			http.Error(w, "syntax error", http.StatusBadRequest)
+		default:
+			io.WriteString(w, "<svg>"+r.URL.Path+"</svg>")
+		}
+	}))
+	defer ts.Close()
+	r, err := NewRenderer(ts.URL+"/", ts.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	got, err := r.Render(ctx, Mermaid, "graph TD; A-->B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<svg>/mermaid/svg</svg>"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := r.Render(ctx, Mermaid, "graph TD; A-->B"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests for the same diagram, want 1", requests)
+	}
+
+	// Failures are remembered for a while.
+	for i := 0; i < 2; i++ {
+		if _, err := r.Render(ctx, PlantUML, "bad"); err == nil {
+			t.Error("got nil error for a bad diagram")
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+
+	for _, test := range []struct{ kind, source string }{
+		{"graphviz", "digraph {}"},
+		{Mermaid, strings.Repeat("x", maxSourceSize+1)},
+	} {
+		if _, err := r.Render(ctx, test.kind, test.source); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("Render(%q, %d bytes): got error %v, want InvalidArgument", test.kind, len(test.source), err)
+		}
+	}
+}
+
+func TestKind(t *testing.T) {
+	for lang, want := range map[string]string{
+		"mermaid": Mermaid,
+		"Mermaid": Mermaid,
+		"puml":    PlantUML,
+		"go":      "",
+		"":        "",
+	} {
+		if got := Kind(lang); got != want {
+			t.Errorf("Kind(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
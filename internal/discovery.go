@@ -51,6 +51,9 @@ type ModuleInfo struct {
 	// HasGoMod describes whether the module zip has a go.mod file.
 	HasGoMod   bool
 	SourceInfo *source.Info
+	// GoVersion is the version from the go directive of the module's go.mod
+	// file, such as "1.18". It is empty if there is no go directive.
+	GoVersion string
 
 	// Deprecated describes whether the module is deprecated.
 	Deprecated bool
@@ -60,6 +63,9 @@ type ModuleInfo struct {
 	Retracted bool
 	// RetractionRationale is the reason for the retraction, if any.
 	RetractionRationale string
+	// RecommendedVersion is the version that the owners of the module
+	// recommend instead of the latest version, if any.
+	RecommendedVersion string
 }
 
 // VersionMap holds metadata associated with module queries for a version.
@@ -153,6 +159,70 @@ type Module struct {
 	// that may be contained in nested subdirectories.
 	Licenses []*licenses.License
 	Units    []*Unit
+	// Replacements holds the replace directives of the module's go.mod file,
+	// in file order.
+	Replacements []*ModuleReplacement
+	// Requirements holds the require directives of the module's go.mod file,
+	// in file order.
+	Requirements []*ModuleRequirement
+	// MigrationGuide is the name of a file at the root of the module, such
+	// as MIGRATION.md, that explains how to upgrade to it from an earlier
+	// major version. It is empty if there is none.
+	MigrationGuide string
+	// Metadata is the information that the authors of the module provide in
+	// a .pkgsite.yaml file at its root. It is nil if there is no such file,
+	// or it is invalid.
+	Metadata *ModuleMetadata
+	// Guides are the markdown files in the docs or doc directory at the root
+	// of the module, sorted by file path. They are displayed in the Guides
+	// tab of the module's pages.
+	Guides []*Readme
+}
+
+// A ModuleReplacement is a replace directive in a go.mod file.
+type ModuleReplacement struct {
+	OldPath    string
+	OldVersion string // empty if all versions of OldPath are replaced
+	NewPath    string // a module path, or a local directory
+	NewVersion string // empty if NewPath is a local directory
+}
+
+// ModuleMetadata is the information about a module that its authors provide
+// for display on its pages.
+type ModuleMetadata struct {
+	// Description is a one-line description of the module.
+	Description string `json:"description,omitempty"`
+	// Homepage is the URL of the homepage of the project.
+	Homepage string `json:"homepage,omitempty"`
+	// Logo is the URL of an image of the logo of the project.
+	Logo string `json:"logo,omitempty"`
+	// Tags are the categories of the module, such as "database" or "cli".
+	Tags []string `json:"tags,omitempty"`
+	// EntryPoints are the import paths of the packages of the module that
+	// users should start with.
+	EntryPoints []string `json:"entrypoints,omitempty"`
+}
+
+// A ModuleRequirement is a require directive in a go.mod file.
+type ModuleRequirement struct {
+	Path    string
+	Version string
+	// Indirect reports whether the requirement is marked with an
+	// "// indirect" comment, meaning that no package of the module imports a
+	// package of Path directly.
+	Indirect bool
+}
+
+// IsLocal reports whether r replaces a module with a directory on the local
+// filesystem.
+func (r *ModuleReplacement) IsLocal() bool {
+	return r.NewVersion == ""
+}
+
+// Replaces reports whether r applies to the package with the given import
+// path, ignoring OldVersion.
+func (r *ModuleReplacement) Replaces(importPath string) bool {
+	return importPath == r.OldPath || strings.HasPrefix(importPath, r.OldPath+"/")
 }
 
 // Packages returns all of the units for a module that are packages.
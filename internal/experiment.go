@@ -7,6 +7,8 @@ package internal
 
 const (
 	ExperimentEnableStdFrontendFetch = "enable-std-frontend-fetch"
+	ExperimentSearchInterleaving     = "search-interleaving"
+	ExperimentSearchSnippets         = "search-snippets"
 	ExperimentStyleGuide             = "styleguide"
 )
 
@@ -14,6 +16,8 @@ const (
 // a description of each experiment.
 var Experiments = map[string]string{
 	ExperimentEnableStdFrontendFetch: "Enable frontend fetching for module std.",
+	ExperimentSearchInterleaving:     "Interleave the results of an alternative search ranking with the default ones, and log clicks on them.",
+	ExperimentSearchSnippets:         "Highlight the terms that match the query in the synopses or README excerpts of search results.",
 	ExperimentStyleGuide:             "Enable the styleguide.",
 }
 
@@ -30,6 +34,10 @@ type Experiment struct {
 	// Rollout is the percentage of requests enrolled in the experiment.
 	Rollout uint
 
+	// Allowlist contains IP addresses and CIDR ranges of clients whose
+	// requests are always enrolled in the experiment, regardless of Rollout.
+	Allowlist []string
+
 	// Description provides a description of the experiment.
 	Description string
 }
@@ -0,0 +1,134 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package failover watches the primary database, so that a server can
+// degrade to read-only operation while the primary is unreachable, instead
+// of failing every request.
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// pingTimeout is how long a check waits for the primary to respond.
+const pingTimeout = 5 * time.Second
+
+// A Monitor checks the primary database periodically. After a number of
+// consecutive failed checks it considers the primary down, and the server is
+// degraded until a check succeeds again. It is safe for concurrent use.
+type Monitor struct {
+	ping      func(context.Context) error
+	threshold int
+
+	mu       sync.Mutex
+	failures int       // consecutive failed checks
+	degraded bool      // whether the primary is considered down
+	since    time.Time // when degraded last changed
+}
+
+// NewMonitor returns a Monitor that checks the primary with ping, and
+// considers it down after threshold consecutive failures. The Monitor
+// starts out not degraded.
+func NewMonitor(ping func(context.Context) error, threshold int) *Monitor {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Monitor{ping: ping, threshold: threshold, since: time.Now()}
+}
+
+// Start checks the primary every period, until ctx is done.
+func (m *Monitor) Start(ctx context.Context, period time.Duration) {
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Check pings the primary once, and updates whether m is degraded.
+func (m *Monitor) Check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err := m.ping(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.failures = 0
+		if m.degraded {
+			log.Infof(ctx, "failover: primary database is reachable again after %s; leaving read-only mode",
+				time.Since(m.since).Round(time.Second))
+			m.degraded = false
+			m.since = time.Now()
+		}
+		return
+	}
+	m.failures++
+	log.Warningf(ctx, "failover: primary database check failed (%d in a row): %v", m.failures, err)
+	if !m.degraded && m.failures >= m.threshold {
+		m.degraded = true
+		m.since = time.Now()
+		log.Errorf(ctx, "failover: primary database is unreachable; entering read-only mode")
+	}
+}
+
+// Degraded reports whether the primary is considered down. It is false for a
+// nil Monitor.
+func (m *Monitor) Degraded() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.degraded
+}
+
+// Status describes the state of a Monitor.
+type Status struct {
+	// Mode is "normal" or "read-only".
+	Mode string `json:"mode"`
+	// Since is when the server entered the mode.
+	Since time.Time `json:"since"`
+}
+
+// Status returns the status of m.
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := Status{Mode: "normal", Since: m.since}
+	if m.degraded {
+		s.Mode = "read-only"
+	}
+	return s
+}
+
+// Handler returns a handler that serves the status of m as JSON. The status
+// code is 503 (Service Unavailable) while m is degraded, so that uptime
+// checks can tell.
+func (m *Monitor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := m.Status()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if s.Mode != "normal" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(s); err != nil {
+			log.Errorf(r.Context(), "failover: writing status: %v", err)
+		}
+	})
+}
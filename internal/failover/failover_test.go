@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	var pingErr error
+	m := NewMonitor(func(context.Context) error { return pingErr }, 2)
+
+	check := func(wantDegraded bool) {
+		t.Helper()
+		m.Check(ctx)
+		if got := m.Degraded(); got != wantDegraded {
+			t.Fatalf("after check with error %v: got Degraded() = %t, want %t", pingErr, got, wantDegraded)
+		}
+	}
+
+	check(false)
+	pingErr = errors.New("connection refused")
+	check(false) // one failure is below the threshold
+	check(true)
+	check(true)
+	pingErr = nil
+	check(false)
+	pingErr = errors.New("connection refused")
+	check(false) // the count of failures was reset
+}
+
+func TestNilMonitor(t *testing.T) {
+	var m *Monitor
+	if m.Degraded() {
+		t.Error("nil Monitor is degraded")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var pingErr error
+	m := NewMonitor(func(context.Context) error { return pingErr }, 1)
+
+	get := func() (int, Status) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/_status", nil))
+		var s Status
+		if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, s
+	}
+
+	if code, s := get(); code != http.StatusOK || s.Mode != "normal" {
+		t.Errorf("got %d, %q; want 200, normal", code, s.Mode)
+	}
+	pingErr = errors.New("timeout")
+	m.Check(context.Background())
+	if code, s := get(); code != http.StatusServiceUnavailable || s.Mode != "read-only" {
+		t.Errorf("got %d, %q; want 503, read-only", code, s.Mode)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fault injects faults into the calls a server makes to its
+// dependencies, so that operators can check how the server behaves when a
+// dependency is slow or failing.
+package fault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Names of dependencies.
+const (
+	Postgres = "postgres"
+	Proxy    = "proxy"
+)
+
+// ErrInjected is the error returned for an injected failure.
+var ErrInjected = errors.New("injected fault")
+
+// A Config describes the faults to inject into the calls to a dependency.
+type Config struct {
+	// Latency is added to each call.
+	Latency time.Duration
+	// ErrorRate is the fraction of calls that fail with ErrInjected.
+	ErrorRate float64
+	// TruncateRate is the fraction of HTTP responses whose body is cut in
+	// half. It applies only to dependencies reached over HTTP.
+	TruncateRate float64
+}
+
+// IsZero reports whether c injects no faults.
+func (c Config) IsZero() bool {
+	return c == Config{}
+}
+
+// String returns c in the form read by ParseConfig.
+func (c Config) String() string {
+	var parts []string
+	if c.Latency != 0 {
+		parts = append(parts, "latency="+c.Latency.String())
+	}
+	if c.ErrorRate != 0 {
+		parts = append(parts, "error="+strconv.FormatFloat(c.ErrorRate, 'g', -1, 64))
+	}
+	if c.TruncateRate != 0 {
+		parts = append(parts, "truncate="+strconv.FormatFloat(c.TruncateRate, 'g', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseConfig parses a comma-separated list of key=value pairs into a
+// Config. The keys are "latency", a duration, and "error" and "truncate",
+// which are fractions between 0 and 1. For example:
+//
+//	latency=200ms,error=0.1
+//
+// The empty string is a Config that injects no faults.
+func ParseConfig(s string) (_ Config, err error) {
+	defer derrors.Wrap(&err, "ParseConfig(%q)", s)
+
+	var c Config
+	if s == "" {
+		return c, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return Config{}, fmt.Errorf("%q: missing '='", kv)
+		}
+		switch k {
+		case "latency":
+			c.Latency, err = time.ParseDuration(v)
+		case "error":
+			c.ErrorRate, err = parseRate(v)
+		case "truncate":
+			c.TruncateRate, err = parseRate(v)
+		default:
+			return Config{}, fmt.Errorf("unknown key %q", k)
+		}
+		if err != nil {
+			return Config{}, err
+		}
+	}
+	return c, nil
+}
+
+func parseRate(s string) (float64, error) {
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if r < 0 || r > 1 {
+		return 0, fmt.Errorf("rate %g is not between 0 and 1", r)
+	}
+	return r, nil
+}
+
+// An Injector holds the fault configuration of each dependency. It is safe
+// for concurrent use.
+type Injector struct {
+	mu      sync.Mutex
+	configs map[string]Config
+	rand    *rand.Rand
+}
+
+// NewInjector returns an Injector that injects no faults.
+func NewInjector() *Injector {
+	return &Injector{
+		configs: map[string]Config{},
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Set sets the configuration of dep. A zero Config turns off fault injection
+// for dep.
+func (inj *Injector) Set(dep string, c Config) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if c.IsZero() {
+		delete(inj.configs, dep)
+	} else {
+		inj.configs[dep] = c
+	}
+}
+
+// Get returns the configuration of dep.
+func (inj *Injector) Get(dep string) Config {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.configs[dep]
+}
+
+// Configs returns the configurations of all dependencies that have faults.
+func (inj *Injector) Configs() map[string]Config {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	m := map[string]Config{}
+	for dep, c := range inj.configs {
+		m[dep] = c
+	}
+	return m
+}
+
+// chance reports whether an event with probability p happens.
+func (inj *Injector) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.rand.Float64() < p
+}
+
+// Inject injects the configured latency and errors of dep into a call. It
+// waits for the latency or until ctx is done, and then returns ErrInjected
+// if the call should fail.
+func (inj *Injector) Inject(ctx context.Context, dep string) error {
+	c := inj.Get(dep)
+	if c.Latency > 0 {
+		t := time.NewTimer(c.Latency)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+	if inj.chance(c.ErrorRate) {
+		return fmt.Errorf("%s: %w", dep, ErrInjected)
+	}
+	return nil
+}
+
+// Transport returns an http.RoundTripper that injects the faults of dep into
+// the requests made with base.
+func (inj *Injector) Transport(dep string, base http.RoundTripper) http.RoundTripper {
+	return &transport{inj: inj, dep: dep, base: base}
+}
+
+type transport struct {
+	inj  *Injector
+	dep  string
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.inj.Inject(req.Context(), t.dep); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.inj.chance(t.inj.Get(t.dep).TruncateRate) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// Keep the original Content-Length, as a truncated response would.
+	resp.Body = io.NopCloser(bytes.NewReader(body[:len(body)/2]))
+	return resp, nil
+}
+
+// Handler returns a handler for changing the faults of inj. A GET request
+// returns the configurations as JSON. A POST request with the form values
+// "dep" and "config" sets the configuration of a dependency, where config
+// is in the form read by ParseConfig; an empty config turns off faults for
+// the dependency.
+func (inj *Injector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			dep := r.FormValue("dep")
+			if dep != Postgres && dep != Proxy {
+				http.Error(w, fmt.Sprintf("unknown dep %q", dep), http.StatusBadRequest)
+				return
+			}
+			c, err := ParseConfig(r.FormValue("config"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			inj.Set(dep, c)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		out := map[string]string{}
+		for dep, c := range inj.Configs() {
+			out[dep] = c.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want Config
+	}{
+		{"", Config{}},
+		{"latency=200ms", Config{Latency: 200 * time.Millisecond}},
+		{"latency=1s, error=0.1,truncate=0.5", Config{Latency: time.Second, ErrorRate: 0.1, TruncateRate: 0.5}},
+	} {
+		got, err := ParseConfig(test.in)
+		if err != nil {
+			t.Fatalf("ParseConfig(%q): %v", test.in, err)
+		}
+		if got != test.want {
+			t.Errorf("ParseConfig(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+		// String and ParseConfig round-trip.
+		if got2, err := ParseConfig(got.String()); err != nil || got2 != got {
+			t.Errorf("ParseConfig(%q) = %+v, %v; want %+v", got.String(), got2, err, got)
+		}
+	}
+	for _, in := range []string{"latency", "latency=fast", "error=2", "drop=0.1"} {
+		if _, err := ParseConfig(in); err == nil {
+			t.Errorf("ParseConfig(%q): got nil error, want error", in)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	ctx := context.Background()
+	inj := NewInjector()
+	if err := inj.Inject(ctx, Postgres); err != nil {
+		t.Fatalf("no faults: got %v", err)
+	}
+
+	inj.Set(Postgres, Config{ErrorRate: 1})
+	if err := inj.Inject(ctx, Postgres); !errors.Is(err, ErrInjected) {
+		t.Errorf("got %v, want ErrInjected", err)
+	}
+	if err := inj.Inject(ctx, Proxy); err != nil {
+		t.Errorf("other dependency: got %v, want nil", err)
+	}
+
+	inj.Set(Postgres, Config{Latency: time.Hour})
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := inj.Inject(cctx, Postgres); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want DeadlineExceeded", err)
+	}
+
+	inj.Set(Postgres, Config{})
+	if got := inj.Configs(); len(got) != 0 {
+		t.Errorf("after reset: got %v, want no configs", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	const body = "0123456789"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	inj := NewInjector()
+	client := &http.Client{Transport: inj.Transport(Proxy, http.DefaultTransport)}
+	get := func() (string, error) {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	if got, err := get(); err != nil || got != body {
+		t.Errorf("no faults: got %q, %v; want %q", got, err, body)
+	}
+	inj.Set(Proxy, Config{TruncateRate: 1})
+	if got, err := get(); err != nil || got != body[:5] {
+		t.Errorf("truncate: got %q, %v; want %q", got, err, body[:5])
+	}
+	inj.Set(Proxy, Config{ErrorRate: 1})
+	if _, err := get(); !errors.Is(err, ErrInjected) {
+		t.Errorf("error: got %v, want ErrInjected", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	inj := NewInjector()
+	h := inj.Handler()
+	post := func(dep, config string) *httptest.ResponseRecorder {
+		form := url.Values{"dep": {dep}, "config": {config}}
+		r := httptest.NewRequest("POST", "/_debug/fault", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post(Postgres, "latency=10ms,error=0.5")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", w.Code, w.Body)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), `{"postgres":"latency=10ms,error=0.5"}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := inj.Get(Postgres), (Config{Latency: 10 * time.Millisecond, ErrorRate: 0.5}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	for _, test := range []struct{ dep, config string }{
+		{"redis", "error=1"},
+		{Proxy, "error=high"},
+	} {
+		if w := post(test.dep, test.config); w.Code != http.StatusBadRequest {
+			t.Errorf("post(%q, %q): got status %d, want 400", test.dep, test.config, w.Code)
+		}
+	}
+
+	post(Postgres, "")
+	if got := inj.Get(Postgres); !got.IsZero() {
+		t.Errorf("after reset: got %+v, want zero", got)
+	}
+}
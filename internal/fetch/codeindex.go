@@ -0,0 +1,342 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// maxCodeIndexRefs is the maximum number of references recorded in the code
+// index of a package. References past the limit are dropped.
+const maxCodeIndexRefs = 100000
+
+// codeIndex returns the code index of the package with the given import path
+// made of the non-test .go files in files. Like testStats, it ignores build
+// constraints, so a name may have several definitions. Files that do not
+// parse are skipped. It returns nil if there are no such files.
+func codeIndex(importPath string, files map[string][]byte) *internal.CodeIndex {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range sortedNames(files) {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, files[name], parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, f)
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	// The package-level names, and the declarations that define them.
+	pkgNames := map[string]bool{}
+	pkgDecls := map[interface{}]bool{}
+	for _, f := range parsed {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					pkgNames[d.Name.Name] = true
+					pkgDecls[d] = true
+				}
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					for _, id := range specNames(s) {
+						pkgNames[id.Name] = true
+					}
+					pkgDecls[s] = true
+				}
+			}
+		}
+	}
+	ci := &internal.CodeIndex{}
+	numRefs := 0
+	for _, f := range parsed {
+		ix := &fileIndexer{
+			importPath: importPath,
+			fset:       fset,
+			src:        files[fset.Position(f.Package).Filename],
+			pkgNames:   pkgNames,
+			pkgDecls:   pkgDecls,
+			imports:    fileImports(f),
+			maxRefs:    maxCodeIndexRefs - numRefs,
+			file:       &internal.CodeIndexFile{Name: fset.Position(f.Package).Filename},
+		}
+		ix.indexFile(f)
+		numRefs += len(ix.file.Refs)
+		ci.Files = append(ci.Files, ix.file)
+	}
+	return ci
+}
+
+// fileIndexer computes the code index of one file.
+type fileIndexer struct {
+	importPath string
+	fset       *token.FileSet
+	src        []byte
+	pkgNames   map[string]bool
+	pkgDecls   map[interface{}]bool
+	imports    map[string]string // local package name to import path
+	maxRefs    int
+	file       *internal.CodeIndexFile
+}
+
+func (ix *fileIndexer) indexFile(f *ast.File) {
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverBaseName(d.Recv.List[0].Type) + "." + name
+				ix.inspect(d.Recv)
+			}
+			fd := *d
+			fd.Doc = nil
+			fd.Body = nil
+			ix.def(name, d.Name, &fd, d.Doc)
+			ix.inspect(d.Type)
+			if d.Body != nil {
+				ix.inspect(d.Body)
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				ix.genSpec(d, s)
+			}
+		}
+	}
+}
+
+// genSpec indexes a spec of a package-level declaration.
+func (ix *fileIndexer) genSpec(d *ast.GenDecl, s ast.Spec) {
+	doc := d.Doc
+	if len(d.Specs) > 1 || doc == nil {
+		switch s := s.(type) {
+		case *ast.ValueSpec:
+			doc = s.Doc
+		case *ast.TypeSpec:
+			doc = s.Doc
+		}
+	}
+	switch s := s.(type) {
+	case *ast.ValueSpec:
+		vs := *s
+		vs.Doc = nil
+		vs.Comment = nil
+		decl := &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{&vs}}
+		for _, id := range s.Names {
+			ix.def(id.Name, id, decl, doc)
+		}
+		if s.Type != nil {
+			ix.inspect(s.Type)
+		}
+		for _, v := range s.Values {
+			ix.inspect(v)
+		}
+	case *ast.TypeSpec:
+		ts := *s
+		ts.Doc = nil
+		ts.Comment = nil
+		ix.def(s.Name.Name, s.Name, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ts}}, doc)
+		if s.TypeParams != nil {
+			ix.inspect(s.TypeParams)
+		}
+		ix.inspect(s.Type)
+	}
+}
+
+// def records the definition of name at id, with the given declaration and
+// doc comment.
+func (ix *fileIndexer) def(name string, id *ast.Ident, decl ast.Node, doc *ast.CommentGroup) {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, ix.fset, decl); err != nil {
+		buf.Reset()
+	}
+	ix.file.Defs = append(ix.file.Defs, &internal.CodeIndexDef{
+		Name:  name,
+		Range: ix.identRange(id),
+		Decl:  buf.String(),
+		Doc:   doc.Text(),
+	})
+}
+
+// ref records a reference at id to the package-level declaration name of the
+// package with the given import path.
+func (ix *fileIndexer) ref(pkg, name string, id *ast.Ident) {
+	if len(ix.file.Refs) >= ix.maxRefs {
+		return
+	}
+	ix.file.Refs = append(ix.file.Refs, &internal.CodeIndexRef{
+		Package: pkg,
+		Name:    name,
+		Range:   ix.identRange(id),
+	})
+}
+
+// inspect records the references in n.
+func (ix *fileIndexer) inspect(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Obj == nil {
+				if p, ok := ix.imports[x.Name]; ok {
+					ix.ref(p, n.Sel.Name, n.Sel)
+					return false
+				}
+			}
+			// Without types, the selected field or method is unknown.
+			ix.inspect(n.X)
+			return false
+		case *ast.KeyValueExpr:
+			// A key that is an identifier may be a struct field.
+			if _, ok := n.Key.(*ast.Ident); !ok {
+				ix.inspect(n.Key)
+			}
+			ix.inspect(n.Value)
+			return false
+		case *ast.Field:
+			// The names of fields and parameters are not references.
+			ix.inspect(n.Type)
+			return false
+		case *ast.Ident:
+			if ix.isPackageLevel(n) {
+				ix.ref(ix.importPath, n.Name, n)
+			}
+		}
+		return true
+	})
+}
+
+// isPackageLevel reports whether id refers to a package-level declaration of
+// the package. The parser resolves identifiers declared in the same file,
+// and leaves the others unresolved.
+func (ix *fileIndexer) isPackageLevel(id *ast.Ident) bool {
+	if id.Obj != nil {
+		return ix.pkgDecls[id.Obj.Decl]
+	}
+	return ix.pkgNames[id.Name]
+}
+
+// identRange returns the range of id.
+func (ix *fileIndexer) identRange(id *ast.Ident) internal.CodeRange {
+	pos := ix.fset.Position(id.Pos())
+	line := pos.Line - 1
+	start := utf16Column(ix.src, pos.Offset, pos.Column)
+	return internal.CodeRange{line, start, line, start + utf16Len(id.Name)}
+}
+
+// utf16Column returns the number of UTF-16 code units that precede the byte
+// at offset in its line, whose one-based byte column is col.
+func utf16Column(src []byte, offset, col int) int {
+	lineStart := offset - (col - 1)
+	if lineStart < 0 || offset > len(src) {
+		return col - 1
+	}
+	return utf16Len(string(src[lineStart:offset]))
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r == utf8.RuneError {
+			n++
+			continue
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// specNames returns the names declared by a spec.
+func specNames(s ast.Spec) []*ast.Ident {
+	switch s := s.(type) {
+	case *ast.ValueSpec:
+		return s.Names
+	case *ast.TypeSpec:
+		return []*ast.Ident{s.Name}
+	}
+	return nil
+}
+
+// receiverBaseName returns the name of the type of a method receiver,
+// without pointers or type parameters.
+func receiverBaseName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// fileImports returns a map from the names by which the imported packages of
+// f are referred to, to their import paths. Blank and dot imports are
+// omitted. The name of a package imported without a name is guessed from its
+// path.
+func fileImports(f *ast.File) map[string]string {
+	m := map[string]string{}
+	for _, im := range f.Imports {
+		p, err := strconv.Unquote(im.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importPathName(p)
+		if im.Name != nil {
+			name = im.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		m[name] = p
+	}
+	return m
+}
+
+// importPathName guesses the package name of an import path: its last
+// element, ignoring a major version suffix such as "/v2" or ".v2", and a
+// "go-" prefix.
+func importPathName(importPath string) string {
+	dir, base := path.Split(importPath)
+	if isMajorVersion(base) && dir != "" {
+		base = path.Base(dir)
+	}
+	if i := strings.LastIndex(base, ".v"); i > 0 && isMajorVersion(base[i+1:]) {
+		base = base[:i]
+	}
+	base = strings.TrimPrefix(base, "go-")
+	return strings.NewReplacer("-", "_", ".", "_").Replace(base)
+}
+
+// isMajorVersion reports whether s has the form "vN" for a number N.
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
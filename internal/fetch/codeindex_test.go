@@ -0,0 +1,86 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestCodeIndex(t *testing.T) {
+	const src = `package p
+
+import (
+	"fmt"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// T is a type.
+type T struct {
+	N int
+}
+
+// M is a method.
+func (t *T) M() string { return fmt.Sprint(t.N, Max) }
+
+const Max = 10
+
+func F(ü T) T { x := T{N: Max}; _ = yaml.Marshal; return x }
+`
+	files := map[string][]byte{
+		"p.go":      []byte(src),
+		"p_test.go": []byte("package p\n\nvar _ = F\n"),
+		"bad.go":    []byte("package p\n\nfunc Bad("),
+		"README":    []byte("not Go"),
+	}
+	got := codeIndex("example.com/p", files)
+	want := &internal.CodeIndex{
+		Files: []*internal.CodeIndexFile{{
+			Name: "p.go",
+			Defs: []*internal.CodeIndexDef{
+				{Name: "T", Range: internal.CodeRange{8, 5, 8, 6}, Decl: "type T struct {\n\tN int\n}", Doc: "T is a type.\n"},
+				{Name: "T.M", Range: internal.CodeRange{13, 12, 13, 13}, Decl: "func (t *T) M() string", Doc: "M is a method.\n"},
+				{Name: "Max", Range: internal.CodeRange{15, 6, 15, 9}, Decl: "const Max = 10"},
+				{Name: "F", Range: internal.CodeRange{17, 5, 17, 6}, Decl: "func F(ü T) T"},
+			},
+			Refs: []*internal.CodeIndexRef{
+				{Package: "example.com/p", Name: "T", Range: internal.CodeRange{13, 9, 13, 10}},
+				{Package: "fmt", Name: "Sprint", Range: internal.CodeRange{13, 36, 13, 42}},
+				{Package: "example.com/p", Name: "Max", Range: internal.CodeRange{13, 48, 13, 51}},
+				{Package: "example.com/p", Name: "T", Range: internal.CodeRange{17, 9, 17, 10}},
+				{Package: "example.com/p", Name: "T", Range: internal.CodeRange{17, 12, 17, 13}},
+				{Package: "example.com/p", Name: "T", Range: internal.CodeRange{17, 21, 17, 22}},
+				{Package: "example.com/p", Name: "Max", Range: internal.CodeRange{17, 26, 17, 29}},
+				{Package: "gopkg.in/yaml.v3", Name: "Marshal", Range: internal.CodeRange{17, 41, 17, 48}},
+			},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	if got := codeIndex("example.com/p", map[string][]byte{"p_test.go": []byte("package p")}); got != nil {
+		t.Errorf("got %+v for test files only, want nil", got)
+	}
+}
+
+func TestImportPathName(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"fmt", "fmt"},
+		{"net/http", "http"},
+		{"github.com/a/b/v2", "b"},
+		{"gopkg.in/yaml.v3", "yaml"},
+		{"github.com/a/go-cmp", "cmp"},
+		{"github.com/a/b-c", "b_c"},
+	} {
+		if got := importPathName(test.in); got != test.want {
+			t.Errorf("importPathName(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+// RendererVersion is the version of the code that processes the packages of
+// a module. It is part of the keys of the doc cache, so it must be
+// incremented whenever a change to this package or to internal/godoc changes
+// the packages that extractPackages returns for the same contents.
+const RendererVersion = 2
+
+// A DocCache stores the processed packages of module contents, so that they
+// are not processed again, by this process or another one.
+type DocCache interface {
+	// Get returns the data stored with key, or nil if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores data with key. The data was produced from a version of
+	// modulePath.
+	Put(ctx context.Context, key, modulePath string, data []byte) error
+}
+
+type docCacheKey struct{}
+
+// NewContextWithDocCache returns a context that makes FetchModule look up
+// the packages of modules in c before processing them, and store them in c
+// afterwards.
+func NewContextWithDocCache(ctx context.Context, c DocCache) context.Context {
+	return context.WithValue(ctx, docCacheKey{}, c)
+}
+
+func docCacheFromContext(ctx context.Context) DocCache {
+	c, _ := ctx.Value(docCacheKey{}).(DocCache)
+	return c
+}
+
+var (
+	keyDocCacheResult = tag.MustNewKey("doccache.result")
+	docCacheLookups   = stats.Int64(
+		"go-discovery/doc_cache_count",
+		"The result of a doc cache lookup.",
+		stats.UnitDimensionless,
+	)
+	// DocCacheCount counts doc cache lookups by result: hit, miss or error.
+	DocCacheCount = &view.View{
+		Name:        "go-discovery/doc_cache/count",
+		Measure:     docCacheLookups,
+		Aggregation: view.Count(),
+		Description: "doc cache lookups, by result",
+		TagKeys:     []tag.Key{keyDocCacheResult},
+	}
+)
+
+func recordDocCacheResult(ctx context.Context, result string) {
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyDocCacheResult, result),
+	}, docCacheLookups.M(1))
+}
+
+// extractPackagesCached is like extractPackages, but if ctx has a DocCache,
+// it returns the packages stored there for the same module path and contents,
+// and stores the packages it extracts.
+func extractPackagesCached(ctx context.Context, modulePath, resolvedVersion string, contentDir fs.FS, d *licenses.Detector, sourceInfo *source.Info) (_ []*goPackage, _ []*internal.PackageVersionState, err error) {
+	c := docCacheFromContext(ctx)
+	if c == nil {
+		return extractPackages(ctx, modulePath, resolvedVersion, contentDir, d, sourceInfo)
+	}
+	key, err := contentKey(modulePath, contentDir)
+	if err != nil {
+		log.Warningf(ctx, "doc cache: %v", err)
+		recordDocCacheResult(ctx, "error")
+		return extractPackages(ctx, modulePath, resolvedVersion, contentDir, d, sourceInfo)
+	}
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		log.Warningf(ctx, "doc cache: %v", err)
+		recordDocCacheResult(ctx, "error")
+	} else if data != nil {
+		pkgs, pvs, err := decodeCachedPackages(data, resolvedVersion)
+		if err == nil {
+			recordDocCacheResult(ctx, "hit")
+			return pkgs, pvs, nil
+		}
+		log.Warningf(ctx, "doc cache: %s: %v", key, err)
+		recordDocCacheResult(ctx, "error")
+	} else {
+		recordDocCacheResult(ctx, "miss")
+	}
+
+	pkgs, pvs, err := extractPackages(ctx, modulePath, resolvedVersion, contentDir, d, sourceInfo)
+	if err != nil || !cacheable(pkgs) {
+		return pkgs, pvs, err
+	}
+	data, err = encodeCachedPackages(pkgs, pvs)
+	if err == nil {
+		err = c.Put(ctx, key, modulePath, data)
+	}
+	if err != nil {
+		log.Warningf(ctx, "doc cache: %v", err)
+	}
+	return pkgs, pvs, nil
+}
+
+// contentKey returns the doc cache key of the packages of modulePath with
+// the given contents. It is a hash of RendererVersion, modulePath and the
+// "h1:" hash of the files in contentDir, which is that of the module zip.
+func contentKey(modulePath string, contentDir fs.FS) (_ string, err error) {
+	defer derrors.Wrap(&err, "contentKey(%q)", modulePath)
+
+	var files []string
+	err = fs.WalkDir(contentDir, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	h1, err := dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return contentDir.Open(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s\n", RendererVersion, modulePath, h1)))
+	return hex.EncodeToString(h[:]), nil
+}
+
+// cacheable reports whether pkgs can be stored in the doc cache. Packages
+// whose loading timed out are not, since they may be complete next time.
+func cacheable(pkgs []*goPackage) bool {
+	for _, p := range pkgs {
+		for _, d := range p.docs {
+			if d.Truncated {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cachedPackages are the results of extractPackages in the doc cache.
+type cachedPackages struct {
+	Packages []*cachedPackage
+	States   []*internal.PackageVersionState
+}
+
+// A cachedPackage is a goPackage in the doc cache.
+type cachedPackage struct {
+	Path              string
+	Name              string
+	Imports           []string
+	IsRedistributable bool
+	LicenseMeta       []*licenses.Metadata
+	V1Path            string
+	Docs              []*internal.Documentation
+	TestStats         *internal.TestStats
+	Benchmarks        []string
+	FuzzTargets       []*internal.FuzzTarget
+	CodeIndex         *internal.CodeIndex
+	Examples          []*internal.Example
+}
+
+func encodeCachedPackages(pkgs []*goPackage, pvs []*internal.PackageVersionState) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "encodeCachedPackages")
+
+	cps := cachedPackages{States: pvs}
+	for _, p := range pkgs {
+		cps.Packages = append(cps.Packages, &cachedPackage{
+			Path:              p.path,
+			Name:              p.name,
+			Imports:           p.imports,
+			IsRedistributable: p.isRedistributable,
+			LicenseMeta:       p.licenseMeta,
+			V1Path:            p.v1path,
+			Docs:              p.docs,
+			TestStats:         p.testStats,
+			Benchmarks:        p.benchmarks,
+			FuzzTargets:       p.fuzzTargets,
+			CodeIndex:         p.codeIndex,
+			Examples:          p.examples,
+		})
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(&cps); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCachedPackages decodes the packages and package version states in
+// data, which may have been stored for another version of the module than
+// resolvedVersion.
+func decodeCachedPackages(data []byte, resolvedVersion string) (_ []*goPackage, _ []*internal.PackageVersionState, err error) {
+	defer derrors.Wrap(&err, "decodeCachedPackages")
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	var cps cachedPackages
+	if err := json.NewDecoder(zr).Decode(&cps); err != nil {
+		return nil, nil, err
+	}
+	var pkgs []*goPackage
+	for _, cp := range cps.Packages {
+		pkgs = append(pkgs, &goPackage{
+			path:              cp.Path,
+			name:              cp.Name,
+			imports:           cp.Imports,
+			isRedistributable: cp.IsRedistributable,
+			licenseMeta:       cp.LicenseMeta,
+			v1path:            cp.V1Path,
+			docs:              cp.Docs,
+			testStats:         cp.TestStats,
+			benchmarks:        cp.Benchmarks,
+			fuzzTargets:       cp.FuzzTargets,
+			codeIndex:         cp.CodeIndex,
+			examples:          cp.Examples,
+		})
+	}
+	for _, s := range cps.States {
+		s.Version = resolvedVersion
+	}
+	return pkgs, cps.States, nil
+}
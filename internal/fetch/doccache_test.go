@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+type memDocCache struct {
+	entries map[string][]byte
+	hits    int
+}
+
+func (c *memDocCache) Get(_ context.Context, key string) ([]byte, error) {
+	data := c.entries[key]
+	if data != nil {
+		c.hits++
+	}
+	return data, nil
+}
+
+func (c *memDocCache) Put(_ context.Context, key, _ string, data []byte) error {
+	c.entries[key] = data
+	return nil
+}
+
+func TestDocCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	c := &memDocCache{entries: map[string][]byte{}}
+	ctx = NewContextWithDocCache(ctx, c)
+	mod := moduleMultiPackage.modfunc()
+
+	want, _ := proxyFetcher(t, false, ctx, mod, "")
+	if want.Error != nil {
+		t.Fatal(want.Error)
+	}
+	if len(c.entries) != 1 || c.hits != 0 {
+		t.Fatalf("after first fetch: got %d entries and %d hits, want 1 and 0", len(c.entries), c.hits)
+	}
+	got, _ := proxyFetcher(t, false, ctx, mod, "")
+	if got.Error != nil {
+		t.Fatal(got.Error)
+	}
+	if len(c.entries) != 1 || c.hits != 1 {
+		t.Fatalf("after second fetch: got %d entries and %d hits, want 1 and 1", len(c.entries), c.hits)
+	}
+	sortFetchResult(want)
+	sortFetchResult(got)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(source.Info{})); diff != "" {
+		t.Errorf("mismatch (-uncached +cached):\n%s", diff)
+	}
+
+	// Other contents have a different key.
+	other := *mod
+	other.Files = map[string]string{}
+	for name, contents := range mod.Files {
+		other.Files[name] = contents
+	}
+	other.Files["README.md"] = "changed"
+	if fr, _ := proxyFetcher(t, false, ctx, &other, ""); fr.Error != nil {
+		t.Fatal(fr.Error)
+	}
+	if len(c.entries) != 2 || c.hits != 1 {
+		t.Errorf("after fetching other contents: got %d entries and %d hits, want 2 and 1", len(c.entries), c.hits)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+// maxEmbeddedFiles is the maximum number of embedded files recorded for a
+// single variable.
+const maxEmbeddedFiles = 100
+
+// hasEmbedDirective reports whether any of the .go files contain a
+// //go:embed directive.
+func hasEmbedDirective(files map[string][]byte) bool {
+	for name, b := range files {
+		if strings.HasSuffix(name, ".go") && bytes.Contains(b, []byte("//go:embed")) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageDirFiles returns the paths of all files in the directory dir of
+// contentDir and its subdirectories, relative to dir. Subdirectories that
+// contain a go.mod file belong to other modules, so their files are omitted.
+func packageDirFiles(contentDir fs.FS, dir string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	var files []string
+	err := fs.WalkDir(contentDir, dir, func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if pathname != dir {
+				if _, err := fs.Stat(contentDir, path.Join(pathname, "go.mod")); err == nil {
+					return fs.SkipDir
+				}
+			}
+			return nil
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(pathname, dir), "/")
+		if dir == "." {
+			rel = pathname
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// extractEmbeds returns the files embedded by //go:embed directives in
+// the non-test files of goFiles. pkgFiles holds the paths of the files in the
+// package directory, as returned by packageDirFiles.
+func extractEmbeds(goFiles map[string]*ast.File, pkgFiles []string) []*godoc.Embed {
+	var embeds []*godoc.Embed
+	for name, f := range goFiles {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				doc := vs.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				patterns := embedPatterns(doc)
+				if len(patterns) == 0 || len(vs.Names) != 1 {
+					continue
+				}
+				files := embeddedFiles(patterns, pkgFiles)
+				e := &godoc.Embed{
+					Name:     vs.Names[0].Name,
+					Patterns: patterns,
+					Files:    files,
+					NumFiles: len(files),
+				}
+				if len(e.Files) > maxEmbeddedFiles {
+					e.Files = e.Files[:maxEmbeddedFiles]
+				}
+				embeds = append(embeds, e)
+			}
+		}
+	}
+	sort.Slice(embeds, func(i, j int) bool { return embeds[i].Name < embeds[j].Name })
+	return embeds
+}
+
+// embedPatterns returns the patterns of the //go:embed directives in cg.
+func embedPatterns(cg *ast.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+	var patterns []string
+	for _, c := range cg.List {
+		args := strings.TrimPrefix(c.Text, "//go:embed")
+		if args == c.Text || (args != "" && args[0] != ' ' && args[0] != '\t') {
+			continue
+		}
+		patterns = append(patterns, parseEmbedArgs(args)...)
+	}
+	return patterns
+}
+
+// parseEmbedArgs splits the arguments of a //go:embed directive into
+// patterns. Patterns may be quoted using Go string or raw string syntax.
+// Malformed quoted patterns are skipped.
+func parseEmbedArgs(args string) []string {
+	var patterns []string
+	for {
+		args = strings.TrimLeft(args, " \t")
+		if args == "" {
+			return patterns
+		}
+		var arg string
+		switch args[0] {
+		case '"', '`':
+			i := 1
+			for ; i < len(args); i++ {
+				if args[i] == '\\' && args[0] == '"' {
+					i++
+					continue
+				}
+				if args[i] == args[0] {
+					break
+				}
+			}
+			if i >= len(args) {
+				return patterns
+			}
+			q, err := strconv.Unquote(args[:i+1])
+			args = args[i+1:]
+			if err != nil {
+				continue
+			}
+			arg = q
+		default:
+			i := strings.IndexAny(args, " \t")
+			if i < 0 {
+				i = len(args)
+			}
+			arg, args = args[:i], args[i:]
+		}
+		patterns = append(patterns, arg)
+	}
+}
+
+// embeddedFiles returns the sorted paths in pkgFiles that are matched by
+// the embed patterns. As with the go command, a pattern matching a directory
+// embeds all files in it and its subdirectories, except those whose names
+// begin with '.' or '_', unless the pattern has the "all:" prefix.
+func embeddedFiles(patterns, pkgFiles []string) []string {
+	matched := map[string]bool{}
+	for _, pattern := range patterns {
+		all := strings.HasPrefix(pattern, "all:")
+		pattern = strings.TrimPrefix(pattern, "all:")
+		for _, f := range pkgFiles {
+			if ok, _ := path.Match(pattern, f); ok {
+				matched[f] = true
+				continue
+			}
+			for dir := path.Dir(f); dir != "."; dir = path.Dir(dir) {
+				if ok, _ := path.Match(pattern, dir); !ok {
+					continue
+				}
+				if all || !hasHiddenElem(strings.TrimPrefix(f, dir+"/")) {
+					matched[f] = true
+				}
+				break
+			}
+		}
+	}
+	var files []string
+	for f := range matched {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// hasHiddenElem reports whether any element of the slash-separated path p
+// begins with '.' or '_'.
+func hasHiddenElem(p string) bool {
+	for _, elem := range strings.Split(p, "/") {
+		if strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+	return false
+}
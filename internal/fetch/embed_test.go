@@ -0,0 +1,105 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+func TestParseEmbedArgs(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" a.txt", []string{"a.txt"}},
+		{" a.txt\t b/*.tmpl", []string{"a.txt", "b/*.tmpl"}},
+		{` "with space.txt" ` + "`raw name`", []string{"with space.txt", "raw name"}},
+		{` "esc\"aped" all:static`, []string{`esc"aped`, "all:static"}},
+		{` "unterminated`, nil},
+	} {
+		got := parseEmbedArgs(test.in)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.in, diff)
+		}
+	}
+}
+
+func TestEmbeddedFiles(t *testing.T) {
+	pkgFiles := []string{
+		"a.txt",
+		"b.txt",
+		".hidden.txt",
+		"static/index.html",
+		"static/.keep",
+		"static/_partial.html",
+		"static/css/main.css",
+	}
+	for _, test := range []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"a.txt"}, []string{"a.txt"}},
+		{[]string{"*.txt"}, []string{".hidden.txt", "a.txt", "b.txt"}},
+		{[]string{"static"}, []string{"static/css/main.css", "static/index.html"}},
+		{[]string{"all:static"}, []string{"static/.keep", "static/_partial.html", "static/css/main.css", "static/index.html"}},
+		{[]string{"static/.keep", "a.txt"}, []string{"a.txt", "static/.keep"}},
+		{[]string{"nomatch"}, nil},
+	} {
+		got := embeddedFiles(test.patterns, pkgFiles)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.patterns, diff)
+		}
+	}
+}
+
+func TestLoadPackageEmbeds(t *testing.T) {
+	contentDir := fstest.MapFS{
+		"go.mod": {Data: []byte("module example.com/m")},
+		"p/p.go": {Data: []byte(`package p
+
+import "embed"
+
+//go:embed templates/*.tmpl
+var Templates embed.FS
+
+var (
+	// Version is the version.
+	//go:embed VERSION
+	Version string
+
+	other int
+)
+`)},
+		"p/p_test.go":            {Data: []byte("package p\n\n//go:embed testdata\nvar testFS embed.FS\n")},
+		"p/VERSION":              {Data: []byte("v1")},
+		"p/templates/a.tmpl":     {Data: []byte("a")},
+		"p/templates/b.tmpl":     {Data: []byte("b")},
+		"p/testdata/in.txt":      {Data: []byte("in")},
+		"p/nested/go.mod":        {Data: []byte("module example.com/m/p/nested")},
+		"p/nested/templates.txt": {Data: []byte("x")},
+	}
+	modInfo := &godoc.ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0", ModulePackages: map[string]bool{}}
+	pkg, err := loadPackage(context.Background(), contentDir, []string{"p/p.go", "p/p_test.go"}, nil, "p", nil, modInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docPkg, err := godoc.DecodePackage(pkg.docs[0].Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*godoc.Embed{
+		{Name: "Templates", Patterns: []string{"templates/*.tmpl"}, Files: []string{"templates/a.tmpl", "templates/b.tmpl"}, NumFiles: 2},
+		{Name: "Version", Patterns: []string{"VERSION"}, Files: []string{"VERSION"}, NumFiles: 1},
+	}
+	if diff := cmp.Diff(want, docPkg.Embeds); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// examples returns the examples declared in the _test.go files in files,
+// sorted by name. Like testStats, it ignores build constraints, keeping the
+// first declaration of each example, and skips files that do not parse.
+//
+// An example is runnable if go/doc can turn it into a complete program,
+// which is the case for most examples in external test packages.
+func examples(files map[string][]byte) []*internal.Example {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range sortedNames(files) {
+		if !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, files[name], parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, f)
+	}
+	var (
+		exs  []*internal.Example
+		seen = map[string]bool{}
+	)
+	for _, ex := range doc.Examples(parsed...) {
+		// ex.Name is the name of the function without its prefix, such as
+		// "Client_Do" or "_suffix".
+		name := "Example" + ex.Name
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		e := &internal.Example{
+			Name:   name,
+			Doc:    ex.Doc,
+			Output: ex.Output,
+		}
+		var err error
+		if ex.Play != nil {
+			e.Code, err = formatNode(fset, ex.Play)
+			e.Runnable = err == nil
+		}
+		if !e.Runnable {
+			e.Code, err = formatNode(fset, ex.Code)
+			if err != nil {
+				continue
+			}
+			if _, ok := ex.Code.(*ast.BlockStmt); ok {
+				e.Code = unindentBlock(e.Code)
+			}
+		}
+		exs = append(exs, e)
+	}
+	return exs
+}
+
+// formatNode returns the source of node, formatted like gofmt.
+func formatNode(fset *token.FileSet, node interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// unindentBlock returns the statements of a formatted block, without its
+// braces and one level of indentation.
+func unindentBlock(block string) string {
+	block = strings.TrimSuffix(strings.TrimPrefix(block, "{"), "}")
+	lines := strings.Split(strings.Trim(block, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExamples(t *testing.T) {
+	files := map[string][]byte{
+		"p.go": []byte("package p\n\nfunc ExampleLike() {}\n"),
+		"example_test.go": []byte(`package p_test
+
+import "fmt"
+
+// This example greets.
+func Example() {
+	fmt.Println("hello")
+	// Output: hello
+}
+
+func ExampleT_M() {
+	fmt.Println("m")
+}
+`),
+		"internal_test.go": []byte(`package p
+
+func ExampleT() {
+	x := T{}
+	_ = x
+}
+`),
+		"other_test.go": []byte(`//go:build windows
+
+package p_test
+
+func Example() {}
+`),
+		"bad_test.go": []byte("package p\n\nfunc ExampleBroken("),
+	}
+	want := []*internal.Example{
+		{
+			Name:     "Example",
+			Doc:      "This example greets.\n",
+			Code:     "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n",
+			Output:   "hello\n",
+			Runnable: true,
+		},
+		{
+			Name: "ExampleT",
+			Code: "x := T{}\n_ = x",
+		},
+		{
+			Name:     "ExampleT_M",
+			Code:     "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tfmt.Println(\"m\")\n}\n",
+			Runnable: true,
+		},
+	}
+	if diff := cmp.Diff(want, examples(files)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
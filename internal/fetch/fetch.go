@@ -211,7 +211,7 @@ func processModuleContents(ctx context.Context, modulePath, resolvedVersion, req
 	}
 	d := licenses.NewDetectorFS(modulePath, v, contentDir, logf)
 	allLicenses := d.AllLicenses()
-	packages, packageVersionStates, err := extractPackages(ctx, modulePath, resolvedVersion, contentDir, d, sourceInfo)
+	packages, packageVersionStates, err := extractPackagesCached(ctx, modulePath, resolvedVersion, contentDir, d, sourceInfo)
 	if errors.Is(err, ErrModuleContainsNoPackages) {
 		return nil, nil, fmt.Errorf("%v: %w", err.Error(), derrors.BadModule)
 	}
@@ -227,9 +227,12 @@ func processModuleContents(ctx context.Context, modulePath, resolvedVersion, req
 		// HasGoMod is populated by the caller.
 	}
 	return &internal.Module{
-		ModuleInfo: minfo,
-		Licenses:   allLicenses,
-		Units:      moduleUnits(modulePath, minfo, packages, readmes, d),
+		ModuleInfo:     minfo,
+		Licenses:       allLicenses,
+		Units:          moduleUnits(modulePath, minfo, packages, readmes, d),
+		MigrationGuide: findMigrationGuide(contentDir),
+		Metadata:       moduleMetadata(ctx, modulePath, contentDir, packages),
+		Guides:         extractGuides(ctx, modulePath, contentDir),
 	}, packageVersionStates, nil
 }
 
@@ -247,6 +250,24 @@ func processGoModFile(goModBytes []byte, mod *internal.Module) (err error) {
 		return err
 	}
 	mod.Deprecated, mod.DeprecationComment = extractDeprecatedComment(mf)
+	if mf.Go != nil {
+		mod.GoVersion = mf.Go.Version
+	}
+	for _, r := range mf.Require {
+		mod.Requirements = append(mod.Requirements, &internal.ModuleRequirement{
+			Path:     r.Mod.Path,
+			Version:  r.Mod.Version,
+			Indirect: r.Indirect,
+		})
+	}
+	for _, r := range mf.Replace {
+		mod.Replacements = append(mod.Replacements, &internal.ModuleReplacement{
+			OldPath:    r.Old.Path,
+			OldVersion: r.Old.Version,
+			NewPath:    r.New.Path,
+			NewVersion: r.New.Version,
+		})
+	}
 	return nil
 }
 
@@ -127,7 +127,9 @@ func TestFetchModule(t *testing.T) {
 					sortFetchResult(fr)
 					sortFetchResult(got)
 					opts := []cmp.Option{
-						cmpopts.IgnoreFields(internal.Documentation{}, "Source"),
+						cmpopts.IgnoreFields(internal.Documentation{}, "Source", "ContentHash"),
+						// Checked by TestTestStats, TestFuzzTargets, TestCodeIndex and TestExamples.
+						cmpopts.IgnoreFields(internal.Unit{}, "TestStats", "Benchmarks", "FuzzTargets", "CodeIndex", "Examples"),
 						cmpopts.IgnoreFields(internal.PackageVersionState{}, "Error"),
 						cmp.AllowUnexported(source.Info{}),
 						cmpopts.EquateEmpty(),
@@ -288,3 +290,51 @@ func TestExtractDeprecatedComment(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessGoModFileRequirements(t *testing.T) {
+	goMod := `module m
+
+require example.com/a v1.0.0
+
+require (
+	example.com/b v1.2.0 // indirect
+	example.com/c v0.0.0-20220101000000-abcdefabcdef
+)
+`
+	mod := &internal.Module{}
+	if err := processGoModFile([]byte(goMod), mod); err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleRequirement{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.2.0", Indirect: true},
+		{Path: "example.com/c", Version: "v0.0.0-20220101000000-abcdefabcdef"},
+	}
+	if diff := cmp.Diff(want, mod.Requirements); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestProcessGoModFileReplacements(t *testing.T) {
+	goMod := `module m
+
+replace example.com/a => ../a
+
+replace (
+	example.com/b v1.0.0 => example.com/fork/b v1.0.1
+	example.com/c => example.com/fork/c v1.2.0
+)
+`
+	mod := &internal.Module{}
+	if err := processGoModFile([]byte(goMod), mod); err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleReplacement{
+		{OldPath: "example.com/a", NewPath: "../a"},
+		{OldPath: "example.com/b", OldVersion: "v1.0.0", NewPath: "example.com/fork/b", NewVersion: "v1.0.1"},
+		{OldPath: "example.com/c", NewPath: "example.com/fork/c", NewVersion: "v1.2.0"},
+	}
+	if diff := cmp.Diff(want, mod.Replacements); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
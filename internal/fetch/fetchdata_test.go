@@ -162,6 +162,7 @@ var moduleMultiPackage = &testModule{
 			ModuleInfo: internal.ModuleInfo{
 				ModulePath:        "example.com/multi",
 				HasGoMod:          true,
+				GoVersion:         "1.13",
 				SourceInfo:        source.NewGitHubInfo("https://example.com/multi", "", "v1.0.0"),
 				IsRedistributable: true,
 			},
@@ -494,6 +495,7 @@ var moduleNonRedist = &testModule{
 			ModuleInfo: internal.ModuleInfo{
 				ModulePath:        "example.com/nonredist",
 				HasGoMod:          true,
+				GoVersion:         "1.13",
 				SourceInfo:        source.NewGitHubInfo("https://example.com/nonredist", "", "v1.0.0"),
 				IsRedistributable: true,
 			},
@@ -2828,6 +2830,7 @@ var moduleGenerics = &testModule{
 			ModuleInfo: internal.ModuleInfo{
 				ModulePath:        "example.com/generics",
 				HasGoMod:          true,
+				GoVersion:         "1.18",
 				SourceInfo:        source.NewGitHubInfo("https://example.com/generics", "", "v1.0.0"),
 				IsRedistributable: true,
 			},
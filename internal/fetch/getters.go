@@ -410,3 +410,111 @@ func (g *fsProxyModuleGetter) String() string {
 	sort.Strings(as)
 	return fmt.Sprintf("FSProxy(%s, %s)", g.dir, strings.Join(as, ","))
 }
+
+// A zipModuleGetter is a ModuleGetter for a single module version whose
+// source is a zip file in the module zip format, such as one uploaded by the
+// author of the module before tagging the version.
+type zipModuleGetter struct {
+	modulePath string
+	version    string
+	zr         *zip.Reader
+}
+
+// NewZipModuleGetter returns a ModuleGetter for the module version in zr. The
+// module path and version are those of the "<module>@<version>" directory
+// that contains the files of the zip.
+func NewZipModuleGetter(zr *zip.Reader) (_ *zipModuleGetter, err error) {
+	defer derrors.Wrap(&err, "NewZipModuleGetter")
+
+	var prefix string
+	for _, f := range zr.File {
+		// Module paths cannot contain "@", and versions cannot contain "/",
+		// so the directory ends at the first "/" after the first "@".
+		at := strings.IndexByte(f.Name, '@')
+		slash := strings.IndexByte(f.Name[at+1:], '/')
+		if at < 0 || slash < 0 {
+			return nil, fmt.Errorf("file %q is not in a <module>@<version> directory: %w", f.Name, derrors.BadModule)
+		}
+		dir := f.Name[:at+1+slash]
+		if prefix == "" {
+			prefix = dir
+		} else if dir != prefix {
+			return nil, fmt.Errorf("files in both %q and %q: %w", prefix, dir, derrors.BadModule)
+		}
+	}
+	if prefix == "" {
+		return nil, fmt.Errorf("empty zip: %w", derrors.BadModule)
+	}
+	modulePath, vers, _ := strings.Cut(prefix, "@")
+	if err := module.Check(modulePath, vers); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, derrors.BadModule)
+	}
+	return &zipModuleGetter{modulePath: modulePath, version: vers, zr: zr}, nil
+}
+
+// ModulePath returns the path of the module in the zip.
+func (g *zipModuleGetter) ModulePath() string {
+	return g.modulePath
+}
+
+// Version returns the version of the module in the zip.
+func (g *zipModuleGetter) Version() string {
+	return g.version
+}
+
+func (g *zipModuleGetter) checkModule(path, vers string) error {
+	if path != g.modulePath || (vers != g.version && vers != version.Latest) {
+		return fmt.Errorf("%s@%s does not match %s@%s in zip: %w",
+			path, vers, g.modulePath, g.version, derrors.NotFound)
+	}
+	return nil
+}
+
+// Info returns basic information about the module.
+func (g *zipModuleGetter) Info(ctx context.Context, path, vers string) (*proxy.VersionInfo, error) {
+	if err := g.checkModule(path, vers); err != nil {
+		return nil, err
+	}
+	return &proxy.VersionInfo{
+		Version: g.version,
+		Time:    LocalCommitTime,
+	}, nil
+}
+
+// Mod returns the contents of the module's go.mod file.
+// If the file does not exist, it returns a synthesized one.
+func (g *zipModuleGetter) Mod(ctx context.Context, path, vers string) ([]byte, error) {
+	dir, err := g.ContentDir(ctx, path, vers)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(dir, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) {
+		return []byte(fmt.Sprintf("module %s\n", g.modulePath)), nil
+	}
+	return data, err
+}
+
+// ContentDir returns an fs.FS for the module's contents.
+func (g *zipModuleGetter) ContentDir(ctx context.Context, path, vers string) (fs.FS, error) {
+	if err := g.checkModule(path, vers); err != nil {
+		return nil, err
+	}
+	return fs.Sub(g.zr, g.modulePath+"@"+g.version)
+}
+
+// SourceInfo returns nil, because the files of the module are not served.
+func (g *zipModuleGetter) SourceInfo(ctx context.Context, _, _ string) (*source.Info, error) {
+	return nil, nil
+}
+
+// SourceFS returns an empty path and a nil FS, because the files of the
+// module are not served.
+func (g *zipModuleGetter) SourceFS() (string, fs.FS) {
+	return "", nil
+}
+
+// For testing.
+func (g *zipModuleGetter) String() string {
+	return fmt.Sprintf("Zip(%s@%s)", g.modulePath, g.version)
+}
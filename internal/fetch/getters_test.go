@@ -5,6 +5,8 @@
 package fetch
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
 	"io/ioutil"
@@ -177,3 +179,67 @@ func TestFSProxyGetterAllowed(t *testing.T) {
 	check("github.com/jackc/pgio", "v1.0.0", false)
 	check("github.com/jackc/pgio", "latest", false)
 }
+
+func TestZipModuleGetter(t *testing.T) {
+	ctx := context.Background()
+	newZip := func(files map[string]string) *zip.Reader {
+		t.Helper()
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, contents := range files {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(contents)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return zr
+	}
+
+	g, err := NewZipModuleGetter(newZip(map[string]string{
+		"example.com/m@v1.2.0/p/p.go": "// Package p is a package.\npackage p\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ModulePath() != "example.com/m" || g.Version() != "v1.2.0" {
+		t.Errorf("got %s@%s, want example.com/m@v1.2.0", g.ModulePath(), g.Version())
+	}
+	if _, err := g.Info(ctx, "example.com/other", "v1.2.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+	got, err := g.Mod(ctx, "example.com/m", "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "module example.com/m\n"; string(got) != want {
+		t.Errorf("got go.mod %q, want %q", got, want)
+	}
+	fr := FetchModule(ctx, "example.com/m", version.Latest, g)
+	if fr.Error != nil {
+		t.Fatal(fr.Error)
+	}
+	if fr.ResolvedVersion != "v1.2.0" || len(fr.Module.Packages()) != 1 {
+		t.Errorf("got version %q and %d packages, want v1.2.0 and 1", fr.ResolvedVersion, len(fr.Module.Packages()))
+	}
+
+	for _, files := range []map[string]string{
+		{"p.go": "package p\n"},
+		{"example.com/m@v1.0.0/p.go": "package p\n", "example.com/m@v1.1.0/p.go": "package p\n"},
+		{"example.com/m/p.go": "package p\n"},
+		{"example.com/m@latest/p.go": "package p\n"},
+	} {
+		if _, err := NewZipModuleGetter(newZip(files)); !errors.Is(err, derrors.BadModule) {
+			t.Errorf("%v: got %v, want BadModule", files, err)
+		}
+	}
+}
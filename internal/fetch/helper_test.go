@@ -117,12 +117,22 @@ func updateFetchResultVersions(t *testing.T, fr *FetchResult, local bool) *Fetch
 		for _, pvs := range fr.PackageVersionStates {
 			pvs.Version = LocalVersion
 		}
+		if !fr.HasGoMod {
+			fr.Module.GoVersion = ""
+		}
 	} else {
+		// The test proxy serves a go.mod file with a go 1.12 directive for
+		// modules that don't have one.
+		if !fr.HasGoMod {
+			fr.Module.GoVersion = "1.12"
+		}
 		for _, u := range fr.Module.Units {
-			// Copy all of ModuleInfo except HasGoMod.
+			// Copy all of ModuleInfo except HasGoMod and GoVersion, which are
+			// only set on the module.
 			h := u.UnitMeta.ModuleInfo.HasGoMod
 			u.UnitMeta.ModuleInfo = fr.Module.ModuleInfo
 			u.UnitMeta.HasGoMod = h
+			u.UnitMeta.GoVersion = ""
 		}
 		for _, pvs := range fr.PackageVersionStates {
 			pvs.Version = fr.Module.Version
@@ -4,6 +4,8 @@
 
 package fetch
 
+import "time"
+
 // Limits for discovery worker.
 const (
 	maxPackagesPerModule = 10000
@@ -15,4 +17,26 @@ const (
 	MaxFileSize = 30 * megabyte
 )
 
+// Limits on the resources used to load the documentation of a single package.
+// They are variables for testing.
+var (
+	// MaxPackageSourceSize is the maximum total size of the .go files of a
+	// package. Loading a package takes memory in proportion to the size of
+	// its source, so this bounds the memory used for it. A package that
+	// exceeds this limit is not processed.
+	MaxPackageSourceSize int64 = 60 * megabyte
+
+	// PackageLoadTimeout is the maximum time spent loading the documentation
+	// of a package for all build contexts. If it is exceeded, the
+	// documentation for the build contexts loaded so far is kept and marked
+	// as truncated. The first build context is always loaded.
+	PackageLoadTimeout = 2 * time.Minute
+
+	// MaxPackageDocumentationSize is the maximum total size of the encoded
+	// documentation source of a package, summed over build contexts. If it
+	// is exceeded, the documentation for the remaining build contexts is
+	// dropped and the rest is marked as truncated.
+	MaxPackageDocumentationSize = 20 * megabyte
+)
+
 const megabyte = 1000 * 1000
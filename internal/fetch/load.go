@@ -8,6 +8,8 @@ package fetch
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -22,11 +24,13 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"go.opencensus.io/trace"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
@@ -48,9 +52,13 @@ func (bpe *BadPackageError) Error() string { return bpe.Err.Error() }
 // same order that the build contexts are listed. If none of them result in a
 // package, then loadPackage returns nil, nil.
 //
+// Assembly files in asmFilePaths are matched against each build context like
+// .go files, and the functions they implement are recorded in the
+// documentation source.
+//
 // If a package is fine except that its documentation is too large, loadPackage
 // returns a goPackage whose err field is a non-nil error with godoc.ErrTooLarge in its chain.
-func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, innerPath string,
+func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths, asmFilePaths []string, innerPath string,
 	sourceInfo *source.Info, modInfo *godoc.ModuleInfo) (_ *goPackage, err error) {
 	defer derrors.Wrap(&err, "loadPackage(ctx, zipGoFiles, %q, sourceInfo, modInfo)", innerPath)
 	ctx, span := trace.StartSpan(ctx, "fetch.loadPackage")
@@ -58,7 +66,7 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 
 	// Make a map with all the zip file contents.
 	files := make(map[string][]byte)
-	for _, p := range goFilePaths {
+	for _, p := range append(goFilePaths, asmFilePaths...) {
 		_, name := path.Split(p)
 		b, err := readFSFile(contentDir, p, MaxFileSize)
 		if err != nil {
@@ -66,6 +74,15 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 		}
 		files[name] = b
 	}
+	// The files in the package directory are needed only to resolve
+	// //go:embed patterns.
+	var pkgFiles []string
+	if hasEmbedDirective(files) {
+		pkgFiles, err = packageDirFiles(contentDir, innerPath)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	modulePath := modInfo.ModulePath
 	importPath := path.Join(modulePath, innerPath)
@@ -73,14 +90,29 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 		importPath = innerPath
 	}
 	v1path := internal.V1Path(importPath, modulePath)
+	stats, funcs := testStats(files)
+	fuzz := fuzzTargets(contentDir, innerPath, funcs.fuzzTargets)
+	index := codeIndex(importPath, files)
+	exs := examples(files)
 
-	var pkg *goPackage
+	var (
+		pkg       *goPackage
+		deadline  = time.Now().Add(PackageLoadTimeout)
+		docSize   int
+		truncated bool
+	)
 	// Parse the package for each build context.
 	// The documentation is determined by the set of matching files, so keep
 	// track of those to avoid duplication.
 	docsByFiles := map[string]*internal.Documentation{}
+buildContexts:
 	for _, bc := range internal.BuildContexts {
-		mfiles, err := matchingFiles(bc.GOOS, bc.GOARCH, files)
+		if pkg != nil && time.Now().After(deadline) {
+			log.Infof(ctx, "%s: stopped loading at %s after %s", importPath, bc, PackageLoadTimeout)
+			truncated = true
+			break
+		}
+		mfiles, err := buildContextFiles(bc, files)
 		if err != nil {
 			return nil, err
 		}
@@ -105,7 +137,7 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 			continue
 		}
 		name, imports, synopsis, source, api, err := loadPackageForBuildContext(ctx,
-			mfiles, innerPath, sourceInfo, modInfo)
+			mfiles, pkgFiles, innerPath, sourceInfo, modInfo)
 		for _, s := range api {
 			s.GOOS = bc.GOOS
 			s.GOARCH = bc.GOARCH
@@ -123,17 +155,24 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 			// simple, return a single package with this error that will be used
 			// for all build contexts, and ignore the others.
 			return &goPackage{
-				err:     err,
-				path:    importPath,
-				v1path:  v1path,
-				name:    name,
-				imports: imports,
+				err:         err,
+				path:        importPath,
+				v1path:      v1path,
+				name:        name,
+				imports:     imports,
+				testStats:   stats,
+				benchmarks:  funcs.benchmarks,
+				fuzzTargets: fuzz,
+				codeIndex:   index,
+				examples:    exs,
 				docs: []*internal.Documentation{{
 					GOOS:     internal.All,
 					GOARCH:   internal.All,
 					Synopsis: synopsis,
 					Source:   source,
 					API:      api,
+
+					ContentHash: contentHash(source),
 				}},
 			}, nil
 		case err != nil:
@@ -143,10 +182,15 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 			// No error.
 			if pkg == nil {
 				pkg = &goPackage{
-					path:    importPath,
-					v1path:  v1path,
-					name:    name,
-					imports: imports, // Use the imports from the first successful build context.
+					path:        importPath,
+					v1path:      v1path,
+					name:        name,
+					imports:     imports, // Use the imports from the first successful build context.
+					testStats:   stats,
+					benchmarks:  funcs.benchmarks,
+					fuzzTargets: fuzz,
+					codeIndex:   index,
+					examples:    exs,
 				}
 			}
 			// All the build contexts should use the same package name. Although
@@ -157,17 +201,31 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 					Err: fmt.Errorf("more than one package name (%q and %q)", pkg.name, name),
 				}
 			}
+			if len(pkg.docs) > 0 && docSize+len(source) > MaxPackageDocumentationSize {
+				log.Infof(ctx, "%s: dropped documentation for %s and later build contexts: size exceeds %d",
+					importPath, bc, MaxPackageDocumentationSize)
+				truncated = true
+				break buildContexts
+			}
+			docSize += len(source)
 			doc := &internal.Documentation{
 				GOOS:     bc.GOOS,
 				GOARCH:   bc.GOARCH,
 				Synopsis: synopsis,
 				Source:   source,
 				API:      api,
+
+				ContentHash: contentHash(source),
 			}
 			docsByFiles[filesKey] = doc
 			pkg.docs = append(pkg.docs, doc)
 		}
 	}
+	if truncated {
+		for _, d := range pkg.docs {
+			d.Truncated = true
+		}
+	}
 	// If all the build contexts succeeded and had the same set of files, then
 	// assume that the package doc is valid for all build contexts. Represent
 	// this with a single Documentation whose GOOS and GOARCH are both "all".
@@ -187,12 +245,27 @@ func loadPackage(ctx context.Context, contentDir fs.FS, goFilePaths []string, in
 // names and can be used as a map key.
 // It assumes the filenames do not contain spaces.
 func mapKeyForFiles(files map[string][]byte) string {
+	return strings.Join(sortedNames(files), " ")
+}
+
+// sortedNames returns the keys of m in sorted order. Files are processed in
+// this order so that the encoded documentation source, and the positions
+// recorded in it, do not depend on map iteration order.
+func sortedNames[V any](m map[string]V) []string {
 	var names []string
-	for n := range files {
+	for n := range m {
 		names = append(names, n)
 	}
 	sort.Strings(names)
-	return strings.Join(names, " ")
+	return names
+}
+
+// contentHash returns a hex-encoded SHA-256 hash of the encoded documentation
+// source of a package. Encoding is deterministic, so the hash changes only if
+// the documentation does.
+func contentHash(source []byte) string {
+	h := sha256.Sum256(source)
+	return hex.EncodeToString(h[:])
 }
 
 // httpPost allows package fetch tests to stub out playground URL fetches.
@@ -203,8 +276,9 @@ var httpPost = http.Post
 // modulePath is stdlib.ModulePath for the Go standard library and the
 // module path for all other modules. innerPath is the path of the Go package
 // directory relative to the module root. The files argument must contain only
-// .go files that have been verified to be of reasonable size and that match
-// the build context.
+// .go and .s files that have been verified to be of reasonable size and that
+// match the build context. If pkgFiles is non-nil, it holds the paths of the
+// files in the package directory, used to resolve //go:embed patterns.
 //
 // It returns the package name, list of imports, the package synopsis, and the
 // serialized source (AST) for the package.
@@ -216,7 +290,7 @@ var httpPost = http.Post
 //
 // If it returns an error with ErrTooLarge in its chain, the other return values
 // are still valid.
-func loadPackageForBuildContext(ctx context.Context, files map[string][]byte, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo) (
+func loadPackageForBuildContext(ctx context.Context, files map[string][]byte, pkgFiles []string, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo) (
 	name string, imports []string, synopsis string, source []byte, api []*internal.Symbol, err error) {
 	modulePath := modInfo.ModulePath
 	defer derrors.Wrap(&err, "loadPackageWithBuildContext(files, %q, %q, %+v)", innerPath, modulePath, sourceInfo)
@@ -226,7 +300,11 @@ func loadPackageForBuildContext(ctx context.Context, files map[string][]byte, in
 		return "", nil, "", nil, nil, err
 	}
 	docPkg := godoc.NewPackage(fset, modInfo.ModulePackages)
-	for _, pf := range goFiles {
+	if pkgFiles != nil {
+		docPkg.Embeds = extractEmbeds(goFiles, pkgFiles)
+	}
+	for _, name := range sortedNames(goFiles) {
+		pf := goFiles[name]
 		removeNodes := true
 		// Don't strip the seemingly unexported functions from the builtin package;
 		// they are actually Go builtins like make, new, etc.
@@ -235,6 +313,17 @@ func loadPackageForBuildContext(ctx context.Context, files map[string][]byte, in
 		}
 		docPkg.AddFile(pf, removeNodes)
 	}
+	var asmNames []string
+	for name := range files {
+		if strings.HasSuffix(name, ".s") {
+			asmNames = append(asmNames, name)
+		}
+	}
+	// Sort for a deterministic encoding.
+	sort.Strings(asmNames)
+	for _, name := range asmNames {
+		docPkg.AddAsmFile(name, packageName, files[name])
+	}
 
 	// Encode first, because Render messes with the AST.
 	src, err := docPkg.Encode(ctx)
@@ -262,8 +351,11 @@ func loadFilesWithBuildContext(innerPath string, files map[string][]byte) (pkgNa
 		packageName     string
 		packageNameFile string // Name of file where packageName came from.
 	)
-	for name, b := range files {
-		pf, err := parser.ParseFile(fset, name, b, parser.ParseComments)
+	for _, name := range sortedNames(files) {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		pf, err := parser.ParseFile(fset, name, files[name], parser.ParseComments)
 		if err != nil {
 			if pf == nil {
 				return "", nil, nil, fmt.Errorf("internal error: the source couldn't be read: %v", err)
@@ -298,17 +390,65 @@ func loadFilesWithBuildContext(innerPath string, files map[string][]byte) (pkgNa
 	return packageName, goFiles, fset, nil
 }
 
+// noCgoBuildContexts is the set of build contexts for which cgo is not
+// available, so files that import "C" are never part of the package.
+var noCgoBuildContexts = map[internal.BuildContext]bool{
+	internal.BuildContextJS: true,
+}
+
+// buildContextFiles returns the files in allFiles that make up the package for
+// the build context bc.
+//
+// Files that use cgo are selected if cgo is available for bc. If those files
+// don't make up a package, the files selected with cgo disabled are returned
+// instead, since they often provide pure Go fallbacks for the cgo
+// implementation.
+func buildContextFiles(bc internal.BuildContext, allFiles map[string][]byte) (map[string][]byte, error) {
+	cgo := !noCgoBuildContexts[bc]
+	files, err := matchingFiles(bc.GOOS, bc.GOARCH, cgo, allFiles)
+	if err != nil || !cgo || isPackage(files) {
+		return files, err
+	}
+	nocgoFiles, err := matchingFiles(bc.GOOS, bc.GOARCH, false, allFiles)
+	if err != nil {
+		return nil, err
+	}
+	if isPackage(nocgoFiles) {
+		return nocgoFiles, nil
+	}
+	return files, nil
+}
+
+// isPackage reports whether files contains at least one non-test .go file,
+// and all the non-test .go files have the same package name.
+func isPackage(files map[string][]byte) bool {
+	fset := token.NewFileSet()
+	var name string
+	for fname, b := range files {
+		if !strings.HasSuffix(fname, ".go") || strings.HasSuffix(fname, "_test.go") {
+			continue
+		}
+		pf, err := parser.ParseFile(fset, fname, b, parser.PackageClauseOnly)
+		if err != nil || (name != "" && pf.Name.Name != name) {
+			return false
+		}
+		name = pf.Name.Name
+	}
+	return name != ""
+}
+
 // matchingFiles returns a map from file names to their contents, read from zipGoFiles.
 // It includes only those files that match the build context determined by goos and goarch.
-func matchingFiles(goos, goarch string, allFiles map[string][]byte) (matchedFiles map[string][]byte, err error) {
-	defer derrors.Wrap(&err, "matchingFiles(%q, %q, zipGoFiles)", goos, goarch)
+// Files that import "C" are included only if cgo is true.
+func matchingFiles(goos, goarch string, cgo bool, allFiles map[string][]byte) (matchedFiles map[string][]byte, err error) {
+	defer derrors.Wrap(&err, "matchingFiles(%q, %q, %t, zipGoFiles)", goos, goarch, cgo)
 
 	// bctx is used to make decisions about which of the .go files are included
 	// by build constraints.
 	bctx := &build.Context{
 		GOOS:        goos,
 		GOARCH:      goarch,
-		CgoEnabled:  true,
+		CgoEnabled:  cgo,
 		Compiler:    build.Default.Compiler,
 		ReleaseTags: build.Default.ReleaseTags,
 
@@ -338,13 +478,32 @@ func matchingFiles(goos, goarch string, allFiles map[string][]byte) (matchedFile
 		if err != nil {
 			return nil, &BadPackageError{Err: fmt.Errorf(`bctx.MatchFile(".", %q): %w`, name, err)}
 		}
-		if !match {
+		if !match || (!cgo && importsC(name, allFiles[name])) {
 			delete(matchedFiles, name)
 		}
 	}
 	return matchedFiles, nil
 }
 
+// importsC reports whether the Go file with the given name and contents
+// imports "C". Unlike go/build's Import, MatchFile does not exclude such files
+// when cgo is disabled.
+func importsC(name string, contents []byte) bool {
+	if !strings.HasSuffix(name, ".go") {
+		return false
+	}
+	pf, err := parser.ParseFile(token.NewFileSet(), name, contents, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+	for _, im := range pf.Imports {
+		if im.Path.Value == `"C"` {
+			return true
+		}
+	}
+	return false
+}
+
 // readFSFile reads up to limit bytes from path in fsys.
 func readFSFile(fsys fs.FS, path string, limit int64) (_ []byte, err error) {
 	defer derrors.Add(&err, "readFSFile(%q)", path)
@@ -5,9 +5,17 @@
 package fetch
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
 
@@ -28,6 +36,12 @@ func TestMatchingFiles(t *testing.T) {
 		"plain.go":   plainGoBody,
 	}
 
+	asmContents := map[string]string{
+		"plain.go":    plainGoBody,
+		"sum_amd64.s": "TEXT ·Sum(SB),$0\n",
+		"sum_arm64.s": "TEXT ·Sum(SB),$0\n",
+	}
+
 	jsContents := map[string]string{
 		"README.md":  "THIS IS A README",
 		"LICENSE.md": testhelper.MITLicense,
@@ -57,6 +71,16 @@ func TestMatchingFiles(t *testing.T) {
 				"plain.go": []byte(plainGoBody),
 			},
 		},
+		{
+			name:     "asm-amd64",
+			goos:     "linux",
+			goarch:   "amd64",
+			contents: asmContents,
+			want: map[string][]byte{
+				"plain.go":    []byte(plainGoBody),
+				"sum_amd64.s": []byte(asmContents["sum_amd64.s"]),
+			},
+		},
 		{
 			name:     "wasm-linux",
 			goos:     "linux",
@@ -79,7 +103,7 @@ func TestMatchingFiles(t *testing.T) {
 			for n, c := range test.contents {
 				files[n] = []byte(c)
 			}
-			got, err := matchingFiles(test.goos, test.goarch, files)
+			got, err := matchingFiles(test.goos, test.goarch, true, files)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -89,3 +113,177 @@ func TestMatchingFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildContextFiles(t *testing.T) {
+	const (
+		cgoBody = `
+			package sqlite
+			// #include <sqlite3.h>
+			import "C"
+			type Conn struct{ db *C.sqlite3 }`
+		stubBody = `
+			//go:build !cgo
+
+			package sqlite
+			type Conn struct{}`
+		otherPkgBody = `
+			package other`
+	)
+	for _, test := range []struct {
+		name     string
+		bc       internal.BuildContext
+		contents map[string]string
+		want     []string
+	}{
+		{
+			name:     "cgo",
+			bc:       internal.BuildContextLinux,
+			contents: map[string]string{"cgo.go": cgoBody, "stub.go": stubBody},
+			want:     []string{"cgo.go"},
+		},
+		{
+			name:     "no cgo for js",
+			bc:       internal.BuildContextJS,
+			contents: map[string]string{"cgo.go": cgoBody, "stub.go": stubBody},
+			want:     []string{"stub.go"},
+		},
+		{
+			name:     "only stubs",
+			bc:       internal.BuildContextLinux,
+			contents: map[string]string{"stub.go": stubBody},
+			want:     []string{"stub.go"},
+		},
+		{
+			name:     "bad cgo package",
+			bc:       internal.BuildContextLinux,
+			contents: map[string]string{"cgo.go": cgoBody, "other.go": "//go:build cgo\n" + otherPkgBody, "stub.go": stubBody},
+			want:     []string{"stub.go"},
+		},
+		{
+			name:     "no package",
+			bc:       internal.BuildContextLinux,
+			contents: map[string]string{"cgo_test.go": cgoBody},
+			want:     []string{"cgo_test.go"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for n, c := range test.contents {
+				files[n] = []byte(c)
+			}
+			got, err := buildContextFiles(test.bc, files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotNames []string
+			for n := range got {
+				gotNames = append(gotNames, n)
+			}
+			if diff := cmp.Diff(test.want, gotNames, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadPackageLimits(t *testing.T) {
+	// The package has one set of files for linux and another for the other
+	// build contexts, so it has two distinct documentations.
+	contentDir := fstest.MapFS{
+		"p/a.go":       {Data: []byte("package p\n\n// A is a.\nfunc A() {}\n")},
+		"p/b_linux.go": {Data: []byte("package p\n\n// B is b.\nfunc B() {}\n")},
+	}
+	modInfo := &godoc.ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0"}
+
+	for _, test := range []struct {
+		name          string
+		timeout       time.Duration
+		maxDocSize    int
+		wantNumDocs   int
+		wantTruncated bool
+	}{
+		{"no limits", PackageLoadTimeout, MaxPackageDocumentationSize, len(internal.BuildContexts), false},
+		{"timeout", -time.Second, MaxPackageDocumentationSize, 1, true},
+		{"documentation size", PackageLoadTimeout, 1, 1, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer func(timeout time.Duration, maxDocSize int) {
+				PackageLoadTimeout = timeout
+				MaxPackageDocumentationSize = maxDocSize
+			}(PackageLoadTimeout, MaxPackageDocumentationSize)
+			PackageLoadTimeout = test.timeout
+			MaxPackageDocumentationSize = test.maxDocSize
+
+			pkg, err := loadPackage(context.Background(), contentDir, []string{"p/a.go", "p/b_linux.go"}, nil, "p", nil, modInfo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(pkg.docs); got != test.wantNumDocs {
+				t.Errorf("got %d docs, want %d", got, test.wantNumDocs)
+			}
+			if pkg.docs[0].GOOS != internal.BuildContextLinux.GOOS {
+				t.Errorf("got first doc for %s, want %s", pkg.docs[0].GOOS, internal.BuildContextLinux.GOOS)
+			}
+			for _, d := range pkg.docs {
+				if d.Truncated != test.wantTruncated {
+					t.Errorf("%s/%s: got Truncated %t, want %t", d.GOOS, d.GOARCH, d.Truncated, test.wantTruncated)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractPackagesSourceSizeLimit(t *testing.T) {
+	defer func(max int64) { MaxPackageSourceSize = max }(MaxPackageSourceSize)
+	MaxPackageSourceSize = 100
+
+	contentDir := fstest.MapFS{
+		"small/s.go": {Data: []byte("package small\n")},
+		"big/b.go":   {Data: []byte("package big\n\n" + strings.Repeat("// A long comment.\n", 10))},
+	}
+	pkgs, states, err := extractPackages(context.Background(), "example.com/m", "v1.0.0", contentDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 || pkgs[0].path != "example.com/m/small" {
+		t.Errorf("got packages %v, want only example.com/m/small", pkgs)
+	}
+	var found bool
+	for _, s := range states {
+		if s.PackagePath == "example.com/m/big" {
+			found = true
+			if want := derrors.ToStatus(derrors.PackageMaxFileSizeLimitExceeded); s.Status != want {
+				t.Errorf("got status %d for big package, want %d", s.Status, want)
+			}
+		}
+	}
+	if !found {
+		t.Error("no package version state for big package")
+	}
+}
+
+func TestLoadPackageDeterministic(t *testing.T) {
+	contentDir := fstest.MapFS{
+		"p/a.go": {Data: []byte("package p\n\n// A is a.\nfunc A() {}\n")},
+		"p/b.go": {Data: []byte("package p\n\n// B is b.\ntype B map[string]int\n")},
+		"p/c.go": {Data: []byte("package p\n\n// C is c.\nvar C = map[string]bool{\"x\": true, \"y\": false}\n")},
+	}
+	modInfo := &godoc.ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0"}
+	load := func() *internal.Documentation {
+		pkg, err := loadPackage(context.Background(), contentDir, []string{"p/a.go", "p/b.go", "p/c.go"}, nil, "p", nil, modInfo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg.docs[0]
+	}
+	want := load()
+	if want.ContentHash != contentHash(want.Source) {
+		t.Fatalf("got ContentHash %q, want hash of source", want.ContentHash)
+	}
+	for i := 0; i < 10; i++ {
+		got := load()
+		if got.ContentHash != want.ContentHash {
+			t.Fatalf("load %d: got ContentHash %q, want %q", i, got.ContentHash, want.ContentHash)
+		}
+	}
+}
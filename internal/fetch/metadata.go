@@ -0,0 +1,183 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// metadataFileName is the name of the file at the root of a module in which
+// its authors provide information about it.
+const metadataFileName = ".pkgsite.yaml"
+
+// Limits on the contents of a metadata file.
+const (
+	maxMetadataFileSize  = 16 * 1024
+	maxDescriptionLength = 200
+	maxURLLength         = 2048
+	maxTags              = 10
+	maxTagLength         = 30
+	maxEntryPoints       = 10
+)
+
+// tagRegexp matches valid tags: short lowercase words, separated by hyphens.
+var tagRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// metadataFile is the contents of a metadata file. Entry points are
+// directories relative to the module root, such as "." or "cmd/tool".
+type metadataFile struct {
+	Description string   `json:"description"`
+	Homepage    string   `json:"homepage"`
+	Logo        string   `json:"logo"`
+	Tags        []string `json:"tags"`
+	EntryPoints []string `json:"entrypoints"`
+}
+
+// moduleMetadata returns the metadata in the metadata file at the root of
+// contentDir, or nil if there is none. If the file is invalid, the problem is
+// logged, and it is ignored, so that the rest of the module is still
+// processed. Entry points must be among the packages of the module.
+func moduleMetadata(ctx context.Context, modulePath string, contentDir fs.FS, packages []*goPackage) *internal.ModuleMetadata {
+	pkgPaths := map[string]bool{}
+	for _, p := range packages {
+		pkgPaths[p.path] = true
+	}
+	md, err := readModuleMetadata(contentDir, modulePath, pkgPaths)
+	if err != nil {
+		log.Infof(ctx, "%s: ignoring %s: %v", modulePath, metadataFileName, err)
+		return nil
+	}
+	return md
+}
+
+// readModuleMetadata reads and validates the metadata file at the root of
+// contentDir. It returns nil if there is none. Entry points are converted to
+// import paths, which must be in pkgPaths.
+func readModuleMetadata(contentDir fs.FS, modulePath string, pkgPaths map[string]bool) (_ *internal.ModuleMetadata, err error) {
+	defer derrors.Wrap(&err, "readModuleMetadata(%q)", modulePath)
+
+	info, err := fs.Stat(contentDir, metadataFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", metadataFileName)
+	}
+	if info.Size() > maxMetadataFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxMetadataFileSize)
+	}
+	data, err := fs.ReadFile(contentDir, metadataFileName)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := parseMetadataFile(data)
+	if err != nil {
+		return nil, err
+	}
+	md := &internal.ModuleMetadata{
+		Description: mf.Description,
+		Homepage:    mf.Homepage,
+		Logo:        mf.Logo,
+		Tags:        mf.Tags,
+	}
+	for _, ep := range mf.EntryPoints {
+		p := path.Join(modulePath, ep)
+		if !pkgPaths[p] {
+			return nil, fmt.Errorf("entry point %q is not a package of the module", ep)
+		}
+		md.EntryPoints = append(md.EntryPoints, p)
+	}
+	return md, nil
+}
+
+// parseMetadataFile parses and validates the contents of a metadata file.
+// Unknown fields are errors, so that misspellings are not silently ignored.
+func parseMetadataFile(data []byte) (*metadataFile, error) {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var mf metadataFile
+	// An empty file is null.
+	if !bytes.Equal(j, []byte("null")) {
+		dec := json.NewDecoder(bytes.NewReader(j))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&mf); err != nil {
+			return nil, err
+		}
+	}
+	mf.Description = strings.TrimSpace(mf.Description)
+	if strings.ContainsAny(mf.Description, "\r\n") {
+		return nil, errors.New("description must be a single line")
+	}
+	if utf8.RuneCountInString(mf.Description) > maxDescriptionLength {
+		return nil, fmt.Errorf("description is longer than %d characters", maxDescriptionLength)
+	}
+	for _, u := range []struct{ name, value string }{
+		{"homepage", mf.Homepage},
+		{"logo", mf.Logo},
+	} {
+		if u.value != "" {
+			if err := checkMetadataURL(u.value); err != nil {
+				return nil, fmt.Errorf("%s: %v", u.name, err)
+			}
+		}
+	}
+	if len(mf.Tags) > maxTags {
+		return nil, fmt.Errorf("more than %d tags", maxTags)
+	}
+	seen := map[string]bool{}
+	for _, t := range mf.Tags {
+		if len(t) > maxTagLength || !tagRegexp.MatchString(t) {
+			return nil, fmt.Errorf("invalid tag %q: tags are lowercase words of letters and digits separated by hyphens, up to %d characters", t, maxTagLength)
+		}
+		if seen[t] {
+			return nil, fmt.Errorf("duplicate tag %q", t)
+		}
+		seen[t] = true
+	}
+	if len(mf.EntryPoints) > maxEntryPoints {
+		return nil, fmt.Errorf("more than %d entry points", maxEntryPoints)
+	}
+	for _, ep := range mf.EntryPoints {
+		if ep == "" || path.IsAbs(ep) || ep != path.Clean(ep) || ep == ".." || strings.HasPrefix(ep, "../") {
+			return nil, fmt.Errorf("invalid entry point %q: entry points are directories relative to the module root, such as \".\" or \"cmd/tool\"", ep)
+		}
+	}
+	return &mf, nil
+}
+
+// checkMetadataURL checks that u is an absolute https URL.
+func checkMetadataURL(u string) error {
+	if len(u) > maxURLLength {
+		return fmt.Errorf("URL is longer than %d characters", maxURLLength)
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if pu.Scheme != "https" || pu.Host == "" {
+		return fmt.Errorf("%q is not an https URL", u)
+	}
+	return nil
+}
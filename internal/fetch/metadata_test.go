@@ -0,0 +1,116 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestReadModuleMetadata(t *testing.T) {
+	const modulePath = "example.com/m"
+	pkgPaths := map[string]bool{
+		"example.com/m":          true,
+		"example.com/m/cmd/tool": true,
+	}
+	for _, test := range []struct {
+		name     string
+		contents string // no file if empty
+		want     *internal.ModuleMetadata
+		wantErr  string
+	}{
+		{
+			name: "no file",
+		},
+		{
+			name: "valid",
+			contents: `
+description: A module for testing.
+homepage: https://example.com
+logo: https://example.com/logo.png
+tags: [testing, command-line]
+entrypoints:
+  - .
+  - cmd/tool
+`,
+			want: &internal.ModuleMetadata{
+				Description: "A module for testing.",
+				Homepage:    "https://example.com",
+				Logo:        "https://example.com/logo.png",
+				Tags:        []string{"testing", "command-line"},
+				EntryPoints: []string{"example.com/m", "example.com/m/cmd/tool"},
+			},
+		},
+		{
+			name:     "empty",
+			contents: "# nothing yet\n",
+			want:     &internal.ModuleMetadata{},
+		},
+		{
+			name:     "unknown field",
+			contents: "descripton: typo\n",
+			wantErr:  "unknown field",
+		},
+		{
+			name:     "multiline description",
+			contents: "description: |\n  one\n  two\n",
+			wantErr:  "single line",
+		},
+		{
+			name:     "long description",
+			contents: "description: " + strings.Repeat("x", maxDescriptionLength+1) + "\n",
+			wantErr:  "longer than",
+		},
+		{
+			name:     "http homepage",
+			contents: "homepage: http://example.com\n",
+			wantErr:  "not an https URL",
+		},
+		{
+			name:     "invalid tag",
+			contents: "tags: [Web]\n",
+			wantErr:  "invalid tag",
+		},
+		{
+			name:     "duplicate tag",
+			contents: "tags: [web, web]\n",
+			wantErr:  "duplicate tag",
+		},
+		{
+			name:     "entry point outside the module",
+			contents: "entrypoints: [../other]\n",
+			wantErr:  "invalid entry point",
+		},
+		{
+			name:     "entry point not a package",
+			contents: "entrypoints: [internal]\n",
+			wantErr:  "not a package",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"go.mod": {Data: []byte("module " + modulePath)}}
+			if test.contents != "" {
+				fsys[metadataFileName] = &fstest.MapFile{Data: []byte(test.contents)}
+			}
+			got, err := readModuleMetadata(fsys, modulePath, pkgPaths)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// maxModuleGraphSize is the maximum number of go.mod files read when
+// computing a module graph.
+const maxModuleGraphSize = 2000
+
+// A ModuleGraph is the module requirement graph of a module, as reported by
+// "go mod graph" when run in the module's root directory.
+type ModuleGraph struct {
+	Main module.Version
+	// Pruned reports whether the main module's go.mod file specifies go 1.17
+	// or later, enabling module graph pruning.
+	Pruned bool
+	// Requirements maps each module version in the graph whose go.mod file
+	// was loaded to its requirements, in go.mod order.
+	Requirements map[module.Version][]module.Version
+}
+
+// LoadModuleGraph computes the module graph of modulePath@version, reading
+// go.mod files with mg.
+//
+// Like the go command, it respects module graph pruning
+// (https://go.dev/ref/mod#graph-pruning): if a module at go 1.17 or later
+// is reached from a pruned module, its requirements appear in the graph, but
+// the go.mod files of those requirements are not loaded. If the main module
+// is not pruned, the go.mod files of all modules in the graph are loaded.
+func LoadModuleGraph(ctx context.Context, modulePath, version string, mg ModuleGetter) (_ *ModuleGraph, err error) {
+	defer derrors.Wrap(&err, "LoadModuleGraph(%q, %q)", modulePath, version)
+
+	main := module.Version{Path: modulePath, Version: version}
+	mainFile, err := loadModFile(ctx, main, mg)
+	if err != nil {
+		return nil, err
+	}
+	g := &ModuleGraph{
+		Main:         main,
+		Pruned:       isPrunedModFile(mainFile),
+		Requirements: map[module.Version][]module.Version{},
+	}
+	g.Requirements[main] = modFileRequirements(mainFile)
+
+	// As in the go command, each module version is enqueued at most once
+	// as pruned (reached only through modules with pruned graphs) and at
+	// most once as unpruned.
+	type item struct {
+		m      module.Version
+		pruned bool
+	}
+	var queue []item
+	enqueued := map[item]bool{}
+	enqueue := func(m module.Version, pruned bool) {
+		it := item{m, pruned}
+		if m.Version != "none" && !enqueued[it] {
+			enqueued[it] = true
+			queue = append(queue, it)
+		}
+	}
+	for _, r := range g.Requirements[main] {
+		enqueue(r, g.Pruned)
+	}
+	// prunedFile records, for each loaded module version, whether its go.mod
+	// file enables graph pruning.
+	prunedFile := map[module.Version]bool{}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		pruned, ok := prunedFile[it.m]
+		if !ok {
+			if len(prunedFile) >= maxModuleGraphSize {
+				return nil, fmt.Errorf("more than %d modules in graph: %w", maxModuleGraphSize, derrors.ModuleTooLarge)
+			}
+			f, err := loadModFile(ctx, it.m, mg)
+			if err != nil {
+				return nil, err
+			}
+			pruned = isPrunedModFile(f)
+			prunedFile[it.m] = pruned
+			g.Requirements[it.m] = modFileRequirements(f)
+		}
+		// The requirements of a pruned module reached through pruned
+		// modules are part of the graph, but their go.mod files are not
+		// loaded. Otherwise, the full transitive graph is loaded.
+		if it.pruned && pruned {
+			continue
+		}
+		for _, r := range g.Requirements[it.m] {
+			enqueue(r, false)
+		}
+	}
+	return g, nil
+}
+
+// Edges returns the edges of g in the order printed by "go mod graph": sorted
+// by source module, then in go.mod order.
+func (g *ModuleGraph) Edges() [][2]module.Version {
+	var froms []module.Version
+	for m := range g.Requirements {
+		froms = append(froms, m)
+	}
+	sort.Slice(froms, func(i, j int) bool {
+		if froms[i] == g.Main || froms[j] == g.Main {
+			return froms[i] == g.Main && froms[j] != g.Main
+		}
+		return compareVersions(froms[i], froms[j]) < 0
+	})
+	var edges [][2]module.Version
+	for _, from := range froms {
+		for _, to := range g.Requirements[from] {
+			edges = append(edges, [2]module.Version{from, to})
+		}
+	}
+	return edges
+}
+
+// BuildList returns the module versions selected by minimal version selection
+// over g, excluding the main module, sorted by path. It is the dependency set
+// reported by "go list -m all".
+func (g *ModuleGraph) BuildList() []module.Version {
+	selected := map[string]string{}
+	for from, reqs := range g.Requirements {
+		for _, m := range append([]module.Version{from}, reqs...) {
+			if m.Path == g.Main.Path {
+				continue
+			}
+			if v, ok := selected[m.Path]; !ok || semver.Compare(m.Version, v) > 0 {
+				selected[m.Path] = m.Version
+			}
+		}
+	}
+	var list []module.Version
+	for p, v := range selected {
+		list = append(list, module.Version{Path: p, Version: v})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+func compareVersions(a, b module.Version) int {
+	if a.Path != b.Path {
+		if a.Path < b.Path {
+			return -1
+		}
+		return 1
+	}
+	return semver.Compare(a.Version, b.Version)
+}
+
+// loadModFile fetches and parses the go.mod file of m.
+func loadModFile(ctx context.Context, m module.Version, mg ModuleGetter) (*modfile.File, error) {
+	b, err := mg.Mod(ctx, m.Path, m.Version)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax(fmt.Sprintf("%s@%s/go.mod", m.Path, m.Version), b, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, derrors.BadModule)
+	}
+	return f, nil
+}
+
+// modFileRequirements returns the requirements of f, in order.
+func modFileRequirements(f *modfile.File) []module.Version {
+	var reqs []module.Version
+	for _, r := range f.Require {
+		reqs = append(reqs, r.Mod)
+	}
+	return reqs
+}
+
+// isPrunedModFile reports whether f specifies go 1.17 or later, the first
+// version to support module graph pruning.
+func isPrunedModFile(f *modfile.File) bool {
+	if f.Go == nil {
+		return false
+	}
+	major, minor, ok := parseGoVersion(f.Go.Version)
+	return ok && (major > 1 || (major == 1 && minor >= 17))
+}
+
+// parseGoVersion parses the major and minor numbers of a Go version such as
+// "1.17", "1.21.3" or "1.22rc1".
+func parseGoVersion(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	minorPart := parts[1]
+	if i := strings.IndexFunc(minorPart, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minorPart = minorPart[:i]
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(minorPart)
+	return major, minor, err1 == nil && err2 == nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// modFileGetter is a ModuleGetter that serves only go.mod files.
+type modFileGetter struct {
+	ModuleGetter
+	mods map[string]string // "path@version" to go.mod contents
+}
+
+func (g *modFileGetter) Mod(ctx context.Context, path, version string) ([]byte, error) {
+	contents, ok := g.mods[path+"@"+version]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", path, version, derrors.NotFound)
+	}
+	return []byte(contents), nil
+}
+
+func TestLoadModuleGraph(t *testing.T) {
+	// a@v1.0.0 (go 1.17) requires b@v1.0.0 (go 1.16), which requires c@v1.0.0.
+	// d@v1.0.0 (go 1.17) requires e@v1.0.0 (go 1.17), which requires f@v1.0.0.
+	mods := map[string]string{
+		"a@v1.0.0": "module a\ngo 1.17\nrequire b v1.0.0\n",
+		"b@v1.0.0": "module b\ngo 1.16\nrequire c v1.0.0\n",
+		"c@v1.0.0": "module c\ngo 1.16\nrequire g v1.1.0\n",
+		"d@v1.0.0": "module d\ngo 1.17\nrequire e v1.0.0\n",
+		"e@v1.0.0": "module e\ngo 1.17\nrequire f v1.0.0\n",
+		"f@v1.0.0": "module f\ngo 1.17\n",
+		"g@v1.1.0": "module g\n",
+		"g@v1.0.0": "module g\n",
+	}
+	mg := &modFileGetter{mods: mods}
+	v := func(path, version string) module.Version { return module.Version{Path: path, Version: version} }
+
+	for _, test := range []struct {
+		name      string
+		goVersion string
+		wantEdges [][2]module.Version
+		wantList  []module.Version
+	}{
+		{
+			name:      "pruned",
+			goVersion: "1.21.0",
+			wantEdges: [][2]module.Version{
+				{v("m", "v1.0.0"), v("a", "v1.0.0")},
+				{v("m", "v1.0.0"), v("d", "v1.0.0")},
+				{v("m", "v1.0.0"), v("g", "v1.0.0")},
+				// a is pruned, so b's go.mod is not loaded.
+				{v("a", "v1.0.0"), v("b", "v1.0.0")},
+				// d is pruned, so e's go.mod is not loaded.
+				{v("d", "v1.0.0"), v("e", "v1.0.0")},
+			},
+			wantList: []module.Version{v("a", "v1.0.0"), v("b", "v1.0.0"), v("d", "v1.0.0"), v("e", "v1.0.0"), v("g", "v1.0.0")},
+		},
+		{
+			name:      "unpruned",
+			goVersion: "1.16",
+			wantEdges: [][2]module.Version{
+				{v("m", "v1.0.0"), v("a", "v1.0.0")},
+				{v("m", "v1.0.0"), v("d", "v1.0.0")},
+				{v("m", "v1.0.0"), v("g", "v1.0.0")},
+				{v("a", "v1.0.0"), v("b", "v1.0.0")},
+				{v("b", "v1.0.0"), v("c", "v1.0.0")},
+				{v("c", "v1.0.0"), v("g", "v1.1.0")},
+				{v("d", "v1.0.0"), v("e", "v1.0.0")},
+				{v("e", "v1.0.0"), v("f", "v1.0.0")},
+			},
+			wantList: []module.Version{
+				v("a", "v1.0.0"), v("b", "v1.0.0"), v("c", "v1.0.0"), v("d", "v1.0.0"),
+				v("e", "v1.0.0"), v("f", "v1.0.0"), v("g", "v1.1.0"),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mods["m@v1.0.0"] = fmt.Sprintf("module m\ngo %s\nrequire (\n\ta v1.0.0\n\td v1.0.0\n\tg v1.0.0\n)\n", test.goVersion)
+			g, err := LoadModuleGraph(context.Background(), "m", "v1.0.0", mg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.wantEdges, g.Edges()); diff != "" {
+				t.Errorf("Edges mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantList, g.BuildList()); diff != "" {
+				t.Errorf("BuildList mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadModuleGraphUnprunedDependency(t *testing.T) {
+	// An unpruned dependency of a pruned module has its full graph loaded.
+	mg := &modFileGetter{mods: map[string]string{
+		"m@v1.0.0": "module m\ngo 1.17\nrequire a v1.0.0\n",
+		"a@v1.0.0": "module a\ngo 1.16\nrequire b v1.0.0\n",
+		"b@v1.0.0": "module b\ngo 1.17\nrequire c v1.0.0\n",
+		"c@v1.0.0": "module c\ngo 1.17\n",
+	}}
+	g, err := LoadModuleGraph(context.Background(), "m", "v1.0.0", mg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(g.Edges()), 3; got != want {
+		t.Errorf("got %d edges, want %d: %v", got, want, g.Edges())
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	for _, test := range []struct {
+		in           string
+		major, minor int
+		ok           bool
+	}{
+		{"1.17", 1, 17, true},
+		{"1.21.3", 1, 21, true},
+		{"1.22rc1", 1, 22, true},
+		{"2", 0, 0, false},
+		{"x.y", 0, 0, false},
+	} {
+		major, minor, ok := parseGoVersion(test.in)
+		if major != test.major || minor != test.minor || ok != test.ok {
+			t.Errorf("parseGoVersion(%q) = %d, %d, %t; want %d, %d, %t", test.in, major, minor, ok, test.major, test.minor, test.ok)
+		}
+	}
+}
@@ -34,9 +34,14 @@ type goPackage struct {
 	licenseMeta       []*licenses.Metadata // metadata of applicable licenses
 	// v1path is the package path of a package with major version 1 in a given
 	// series.
-	v1path string
-	docs   []*internal.Documentation // doc for different build contexts
-	err    error                     // non-fatal error when loading the package (e.g. documentation is too large)
+	v1path      string
+	docs        []*internal.Documentation // doc for different build contexts
+	testStats   *internal.TestStats       // nil if there are no _test.go files
+	benchmarks  []string                  // names of benchmark functions, sorted
+	fuzzTargets []*internal.FuzzTarget    // sorted by name
+	codeIndex   *internal.CodeIndex
+	examples    []*internal.Example // sorted by name
+	err         error               // non-fatal error when loading the package (e.g. documentation is too large)
 }
 
 // extractPackages returns a slice of packages from a filesystem arranged like a
@@ -47,6 +52,7 @@ type goPackage struct {
 // that they contained .go files but couldn't be processed due to current
 // limitations of this site. The limitations are:
 // * a maximum file size (MaxFileSize)
+// * a maximum total size of the files of a package (MaxPackageSourceSize)
 // * the particular set of build contexts we consider (goEnvs)
 // * whether the import path is valid.
 func extractPackages(ctx context.Context, modulePath, resolvedVersion string, contentDir fs.FS, d *licenses.Detector, sourceInfo *source.Info) (_ []*goPackage, _ []*internal.PackageVersionState, err error) {
@@ -79,6 +85,15 @@ func extractPackages(ctx context.Context, modulePath, resolvedVersion string, co
 		// The map value is a slice of all .go file paths, and no other files.
 		dirs = make(map[string][]string)
 
+		// asmDirs maps each directory to the paths of the assembly (.s)
+		// files it contains. They are used to link functions implemented
+		// in assembly to their source.
+		asmDirs = make(map[string][]string)
+
+		// dirSizes holds the total size of the .go files in each directory,
+		// to check it against MaxPackageSourceSize.
+		dirSizes = make(map[string]int64)
+
 		// modInfo contains all the module information a package in the module
 		// needs to render its documentation, to be populated during phase 1
 		// and used during phase 2.
@@ -119,6 +134,14 @@ func extractPackages(ctx context.Context, modulePath, resolvedVersion string, co
 			// File is in a directory we're not looking to process at this time, so skip it.
 			return nil
 		}
+		if strings.HasSuffix(pathname, ".s") {
+			// Assembly files don't make up a package on their own. Skip those
+			// that are too large to read rather than failing the package.
+			if info, err := d.Info(); err == nil && info.Size() <= MaxFileSize {
+				asmDirs[innerPath] = append(asmDirs[innerPath], pathname)
+			}
+			return nil
+		}
 		if !strings.HasSuffix(pathname, ".go") {
 			// We care about .go files only.
 			return nil
@@ -157,6 +180,21 @@ func extractPackages(ctx context.Context, modulePath, resolvedVersion string, co
 			})
 			return nil
 		}
+		dirSizes[innerPath] += info.Size()
+		if dirSizes[innerPath] > MaxPackageSourceSize {
+			incompleteDirs[innerPath] = true
+			status := derrors.ToStatus(derrors.PackageMaxFileSizeLimitExceeded)
+			err := fmt.Sprintf("Unable to process %s: total size of .go files exceeds max limit %d",
+				innerPath, MaxPackageSourceSize)
+			packageVersionStates = append(packageVersionStates, &internal.PackageVersionState{
+				ModulePath:  modulePath,
+				PackagePath: importPath,
+				Version:     resolvedVersion,
+				Status:      status,
+				Error:       err,
+			})
+			return nil
+		}
 		dirs[innerPath] = append(dirs[innerPath], pathname)
 		if len(dirs) > maxPackagesPerModule {
 			return fmt.Errorf("%d packages found in %q; exceeds limit %d for maxPackagePerModule: %w",
@@ -191,7 +229,7 @@ func extractPackages(ctx context.Context, modulePath, resolvedVersion string, co
 			status error
 			errMsg string
 		)
-		pkg, err := loadPackage(ctx, contentDir, goFiles, innerPath, sourceInfo, modInfo)
+		pkg, err := loadPackage(ctx, contentDir, goFiles, asmDirs[innerPath], innerPath, sourceInfo, modInfo)
 		if bpe := (*BadPackageError)(nil); errors.As(err, &bpe) {
 			log.Infof(ctx, "Error loading %s: %v", innerPath, err)
 			incompleteDirs[innerPath] = true
@@ -6,14 +6,17 @@
 package fetch
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"path"
+	"sort"
 	"strings"
 
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
 )
 
 // extractReadmes returns the file path and contents of all files from r
@@ -78,3 +81,105 @@ func isReadme(file string) bool {
 	ext := path.Ext(base)
 	return !excludedReadmeExts[ext] && strings.EqualFold(strings.TrimSuffix(base, ext), expectedFile)
 }
+
+// migrationGuideNames are the names, compared case-insensitively, of files at
+// the root of a module that explain how to upgrade to it from an earlier
+// major version, in order of preference.
+var migrationGuideNames = []string{"MIGRATION.md", "MIGRATING.md", "UPGRADING.md"}
+
+// findMigrationGuide returns the name of the migration guide at the root of
+// contentDir, or the empty string if there is none.
+func findMigrationGuide(contentDir fs.FS) string {
+	entries, err := fs.ReadDir(contentDir, ".")
+	if err != nil {
+		return ""
+	}
+	for _, name := range migrationGuideNames {
+		for _, e := range entries {
+			if !e.IsDir() && strings.EqualFold(e.Name(), name) {
+				return e.Name()
+			}
+		}
+	}
+	return ""
+}
+
+// guideDirs are the directories at the root of a module that may hold its
+// guides, in order of preference.
+var guideDirs = []string{"docs", "doc"}
+
+// Limits on the guides of a module.
+const (
+	maxGuides        = 100
+	maxGuideFileSize = 1 * megabyte
+)
+
+// extractGuides returns the markdown files in the first of guideDirs at the
+// root of contentDir that has any, sorted by path. Files that are too large
+// are skipped, and at most maxGuides are returned. Since guides are not
+// essential, problems are logged rather than failing the module.
+func extractGuides(ctx context.Context, modulePath string, contentDir fs.FS) []*internal.Readme {
+	for _, dir := range guideDirs {
+		guides, err := readGuides(contentDir, dir)
+		if err != nil {
+			log.Infof(ctx, "%s: reading guides in %s: %v", modulePath, dir, err)
+			continue
+		}
+		if len(guides) > 0 {
+			return guides
+		}
+	}
+	return nil
+}
+
+// errTooManyGuides stops the walk in readGuides once maxGuides are found.
+var errTooManyGuides = errors.New("too many guides")
+
+// readGuides returns the markdown files under dir in contentDir.
+func readGuides(contentDir fs.FS, dir string) (_ []*internal.Readme, err error) {
+	defer derrors.Wrap(&err, "readGuides(%q)", dir)
+
+	info, err := fs.Stat(contentDir, dir)
+	if err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	var guides []*internal.Readme
+	err = fs.WalkDir(contentDir, dir, func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isMarkdownFile(pathname) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxGuideFileSize {
+			return nil
+		}
+		c, err := readFSFile(contentDir, pathname, maxGuideFileSize)
+		if err != nil {
+			return err
+		}
+		guides = append(guides, &internal.Readme{
+			Filepath: pathname,
+			Contents: string(c),
+		})
+		if len(guides) >= maxGuides {
+			return errTooManyGuides
+		}
+		return nil
+	})
+	if err != nil && err != errTooManyGuides {
+		return nil, err
+	}
+	sort.Slice(guides, func(i, j int) bool { return guides[i].Filepath < guides[j].Filepath })
+	return guides, nil
+}
+
+// isMarkdownFile reports whether file has a markdown extension.
+func isMarkdownFile(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+	return ext == ".md" || ext == ".markdown"
+}
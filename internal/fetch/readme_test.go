@@ -6,9 +6,11 @@ package fetch
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
 	"sort"
 	"testing"
+	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal"
@@ -186,3 +188,71 @@ func TestIsReadme(t *testing.T) {
 		}
 	}
 }
+
+func TestFindMigrationGuide(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"none", []string{"README.md", "go.mod"}, ""},
+		{"migration", []string{"README.md", "MIGRATION.md"}, "MIGRATION.md"},
+		{"case insensitive", []string{"Migrating.md"}, "Migrating.md"},
+		{"preference", []string{"UPGRADING.md", "MIGRATION.md"}, "MIGRATION.md"},
+		{"not at root", []string{"docs/MIGRATION.md"}, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			contentDir := fstest.MapFS{}
+			for _, f := range test.files {
+				contentDir[f] = &fstest.MapFile{Data: []byte("x")}
+			}
+			if got := findMigrationGuide(contentDir); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExtractGuides(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"none", []string{"README.md", "go.mod"}, nil},
+		{"docs", []string{"docs/intro.md", "docs/tutorial/b.markdown", "docs/a.md", "docs/image.png"},
+			[]string{"docs/a.md", "docs/intro.md", "docs/tutorial/b.markdown"}},
+		{"doc", []string{"doc/intro.md", "doc/doc.go"}, []string{"doc/intro.md"}},
+		{"prefer docs", []string{"doc/old.md", "docs/new.md"}, []string{"docs/new.md"}},
+		{"docs without markdown", []string{"doc/old.md", "docs/doc.go"}, []string{"doc/old.md"}},
+		{"not at root", []string{"sub/docs/intro.md"}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			contentDir := fstest.MapFS{}
+			for _, f := range test.files {
+				contentDir[f] = &fstest.MapFile{Data: []byte("# " + f)}
+			}
+			var got []string
+			for _, g := range extractGuides(ctx, "example.com/m", contentDir) {
+				if g.Contents != "# "+g.Filepath {
+					t.Errorf("%s: got contents %q", g.Filepath, g.Contents)
+				}
+				got = append(got, g.Filepath)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		contentDir := fstest.MapFS{}
+		for i := 0; i < maxGuides+5; i++ {
+			contentDir[fmt.Sprintf("docs/%03d.md", i)] = &fstest.MapFile{Data: []byte("x")}
+		}
+		if got := len(extractGuides(ctx, "example.com/m", contentDir)); got != maxGuides {
+			t.Errorf("got %d guides, want %d", got, maxGuides)
+		}
+	})
+}
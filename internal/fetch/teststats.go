@@ -0,0 +1,121 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// testFuncs holds the names of some kinds of test functions of a package,
+// sorted.
+type testFuncs struct {
+	benchmarks  []string
+	fuzzTargets []string
+}
+
+// testStats counts the _test.go files in files and the tests, benchmarks,
+// fuzz targets and examples they declare. Build constraints are ignored, and
+// files that do not parse are counted without their functions. It returns nil
+// if there are no _test.go files.
+//
+// It also returns the names of the benchmarks and fuzz targets.
+func testStats(files map[string][]byte) (*internal.TestStats, testFuncs) {
+	var (
+		stats *internal.TestStats
+		funcs testFuncs
+	)
+	fset := token.NewFileSet()
+	for _, name := range sortedNames(files) {
+		if !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if stats == nil {
+			stats = &internal.TestStats{}
+		}
+		stats.NumTestFiles++
+		f, err := parser.ParseFile(fset, name, files[name], parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			switch name := fn.Name.Name; {
+			case isTestFunc(name, "Test"):
+				stats.NumTests++
+			case isTestFunc(name, "Benchmark"):
+				stats.NumBenchmarks++
+				funcs.benchmarks = append(funcs.benchmarks, name)
+			case isTestFunc(name, "Fuzz"):
+				stats.NumFuzzTargets++
+				funcs.fuzzTargets = append(funcs.fuzzTargets, name)
+			case isTestFunc(name, "Example"):
+				stats.NumExamples++
+			}
+		}
+	}
+	funcs.benchmarks = sortedUnique(funcs.benchmarks)
+	funcs.fuzzTargets = sortedUnique(funcs.fuzzTargets)
+	return stats, funcs
+}
+
+// sortedUnique sorts names and removes duplicates, which are declared in files
+// with different build constraints.
+func sortedUnique(names []string) []string {
+	sort.Strings(names)
+	var out []string
+	for i, n := range names {
+		if i == 0 || n != names[i-1] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// isTestFunc reports whether name is the name of a function that go test runs
+// for the given prefix: the prefix followed by nothing or by a character that
+// is not a lower-case letter, so that "Testing" is not a test.
+func isTestFunc(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// fuzzTargets returns the fuzz targets with the given names of the package in
+// the directory dir of contentDir, with the sizes of their seed corpora. The
+// seed corpus of a target is the testdata/fuzz/<name> directory of the
+// package; go test reads it, but not its subdirectories.
+func fuzzTargets(contentDir fs.FS, dir string, names []string) []*internal.FuzzTarget {
+	var targets []*internal.FuzzTarget
+	for _, name := range names {
+		t := &internal.FuzzTarget{Name: name}
+		// A missing directory just means that there is no seed corpus.
+		entries, _ := fs.ReadDir(contentDir, path.Join(dir, "testdata", "fuzz", name))
+		for _, e := range entries {
+			if e.Type().IsRegular() {
+				t.NumSeeds++
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestTestStats(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		files     map[string]string
+		want      *internal.TestStats
+		wantFuncs testFuncs
+	}{
+		{
+			name:  "no tests",
+			files: map[string]string{"p.go": "package p\n\nfunc TestLike() {}\n"},
+			want:  nil,
+		},
+		{
+			name: "tests",
+			files: map[string]string{
+				"p.go": "package p",
+				"p_test.go": `package p
+
+import "testing"
+
+func Test(t *testing.T)           {}
+func TestA(t *testing.T)          {}
+func Test_b(t *testing.T)         {}
+func Testing(t *testing.T)        {}
+func BenchmarkA(b *testing.B)     {}
+func FuzzB(f *testing.F)          {}
+func FuzzA(f *testing.F)          {}
+func (T) TestMethod(t *testing.T) {}
+func helper()                     {}
+
+type T struct{}
+`,
+				"example_test.go": `package p_test
+
+func Example()   {}
+func ExampleT()  {}
+func Examples()  {}
+func ExampleT_M() {}
+`,
+				"bad_test.go": "package p\n\nfunc TestBroken(",
+			},
+			want: &internal.TestStats{
+				NumTestFiles:   3,
+				NumTests:       3,
+				NumBenchmarks:  1,
+				NumFuzzTargets: 2,
+				NumExamples:    3,
+			},
+			wantFuncs: testFuncs{
+				benchmarks:  []string{"BenchmarkA"},
+				fuzzTargets: []string{"FuzzA", "FuzzB"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for name, content := range test.files {
+				files[name] = []byte(content)
+			}
+			got, gotFuncs := testStats(files)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantFuncs, gotFuncs, cmp.AllowUnexported(testFuncs{})); diff != "" {
+				t.Errorf("functions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFuzzTargets(t *testing.T) {
+	contentDir := fstest.MapFS{
+		"p/testdata/fuzz/FuzzA/seed1":        {},
+		"p/testdata/fuzz/FuzzA/seed2":        {},
+		"p/testdata/fuzz/FuzzA/sub/ignored":  {},
+		"p/testdata/fuzz/FuzzOther/seed":     {},
+		"q/testdata/fuzz/FuzzB/other-pkg":    {},
+		"p/testdata/fuzz/FuzzB.notadir/seed": {},
+	}
+	got := fuzzTargets(contentDir, "p", []string{"FuzzA", "FuzzB"})
+	want := []*internal.FuzzTarget{
+		{Name: "FuzzA", NumSeeds: 2},
+		{Name: "FuzzB", NumSeeds: 0},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -62,6 +62,11 @@ func moduleUnits(modulePath string, minfo internal.ModuleInfo,
 			dir.Name = pkg.name
 			dir.Imports = pkg.imports
 			dir.Documentation = pkg.docs
+			dir.TestStats = pkg.testStats
+			dir.Benchmarks = pkg.benchmarks
+			dir.FuzzTargets = pkg.fuzzTargets
+			dir.CodeIndex = pkg.codeIndex
+			dir.Examples = pkg.examples
 			var bcs []internal.BuildContext
 			for _, d := range dir.Documentation {
 				bcs = append(bcs, internal.BuildContext{GOOS: d.GOOS, GOARCH: d.GOARCH})
@@ -243,6 +243,76 @@ func (ds *FetchDataSource) GetUnit(ctx context.Context, um *internal.UnitMeta, f
 	return &u2, nil
 }
 
+// GetModuleReplacements returns the replace directives in the go.mod file of
+// modulePath@version.
+func (ds *FetchDataSource) GetModuleReplacements(ctx context.Context, modulePath, version string) (_ []*internal.ModuleReplacement, err error) {
+	defer derrors.Wrap(&err, "GetModuleReplacements(%q, %q)", modulePath, version)
+
+	m, err := ds.getModule(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return m.Replacements, nil
+}
+
+// GetMigrationGuide returns the name of the migration guide at the root of
+// modulePath@version, or the empty string if it has none.
+func (ds *FetchDataSource) GetMigrationGuide(ctx context.Context, modulePath, version string) (_ string, err error) {
+	defer derrors.Wrap(&err, "GetMigrationGuide(%q, %q)", modulePath, version)
+
+	m, err := ds.getModule(ctx, modulePath, version)
+	if err != nil {
+		return "", err
+	}
+	return m.MigrationGuide, nil
+}
+
+// GetModuleMetadata returns the metadata that the authors of
+// modulePath@version provided in its .pkgsite.yaml file, or nil if it has
+// none.
+func (ds *FetchDataSource) GetModuleMetadata(ctx context.Context, modulePath, version string) (_ *internal.ModuleMetadata, err error) {
+	defer derrors.Wrap(&err, "GetModuleMetadata(%q, %q)", modulePath, version)
+
+	m, err := ds.getModule(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return m.Metadata, nil
+}
+
+// GetModuleGuidePaths returns the file paths of the guides of
+// modulePath@version, sorted.
+func (ds *FetchDataSource) GetModuleGuidePaths(ctx context.Context, modulePath, version string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetModuleGuidePaths(%q, %q)", modulePath, version)
+
+	m, err := ds.getModule(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, g := range m.Guides {
+		paths = append(paths, g.Filepath)
+	}
+	return paths, nil
+}
+
+// GetModuleGuide returns the guide of modulePath@version with the given file
+// path.
+func (ds *FetchDataSource) GetModuleGuide(ctx context.Context, modulePath, version, filePath string) (_ *internal.Readme, err error) {
+	defer derrors.Wrap(&err, "GetModuleGuide(%q, %q, %q)", modulePath, version, filePath)
+
+	m, err := ds.getModule(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range m.Guides {
+		if g.Filepath == filePath {
+			return g, nil
+		}
+	}
+	return nil, derrors.NotFound
+}
+
 // findUnit returns the unit with the given path in m, or nil if none.
 func findUnit(m *internal.Module, path string) *internal.Unit {
 	for _, u := range m.Units {
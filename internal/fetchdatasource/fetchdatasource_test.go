@@ -358,6 +358,7 @@ func TestLocalGetUnitMeta(t *testing.T) {
 					CommitTime:        fetch.LocalCommitTime,
 					IsRedistributable: true,
 					HasGoMod:          true,
+					GoVersion:         "1.12",
 					SourceInfo:        sourceInfo,
 				},
 				IsRedistributable: true,
@@ -375,6 +376,7 @@ func TestLocalGetUnitMeta(t *testing.T) {
 					CommitTime:        fetch.LocalCommitTime,
 					IsRedistributable: true,
 					HasGoMod:          true,
+					GoVersion:         "1.12",
 					SourceInfo:        sourceInfo,
 				},
 				IsRedistributable: true,
@@ -392,6 +394,7 @@ func TestLocalGetUnitMeta(t *testing.T) {
 					Version:           fetch.LocalVersion,
 					CommitTime:        fetch.LocalCommitTime,
 					HasGoMod:          true,
+					GoVersion:         "1.12",
 					SourceInfo:        sourceInfo,
 				},
 				IsRedistributable: true,
@@ -410,6 +413,7 @@ func TestLocalGetUnitMeta(t *testing.T) {
 					CommitTime:        fetch.LocalCommitTime,
 					IsRedistributable: true,
 					HasGoMod:          true,
+					GoVersion:         "1.12",
 					SourceInfo:        sourceInfo,
 				},
 			},
@@ -10,13 +10,13 @@ import (
 	"fmt"
 	"html"
 	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/safehtml/template"
 	"github.com/google/safehtml/template/uncheckedconversions"
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/cookie"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -66,7 +66,7 @@ func (s *Server) servePathNotFoundPage(w http.ResponseWriter, r *http.Request,
 			return
 		}
 
-		if experiment.IsActive(ctx, internal.ExperimentEnableStdFrontendFetch) {
+		if experiment.IsActive(ctx, internal.ExperimentEnableStdFrontendFetch) && !s.readOnly() && s.canFetch(r) {
 			return &serverError{
 				status: http.StatusNotFound,
 				epage: &errorPage{
@@ -78,6 +78,33 @@ func (s *Server) servePathNotFoundPage(w http.ResponseWriter, r *http.Request,
 		return &serverError{status: http.StatusNotFound}
 	}
 
+	// If the path is a prefix of indexed modules, such as a GitHub user or
+	// organization, list those modules instead.
+	if requestedVersion == version.Latest && strings.Contains(fullPath, "/") {
+		served, err := s.servePrefixPage(w, r, db, fullPath)
+		if err != nil {
+			// Log the error, but continue with the usual 404 flow.
+			log.Error(ctx, err)
+		}
+		if served {
+			return nil
+		}
+	}
+
+	// If the path is a package that failed to be processed, explain why.
+	if err := packageProcessingError(ctx, db, fullPath, requestedVersion); err != nil {
+		return err
+	}
+
+	// Fetching is disabled in read-only mode, and for users who may not
+	// fetch modules, so don't offer it.
+	if s.readOnly() || !s.canFetch(r) {
+		if s.redirectToCanonicalPath(w, r, db, fullPath, requestedVersion) {
+			return nil
+		}
+		return errUnitNotFoundWithoutFetch
+	}
+
 	fr, err := previousFetchStatusAndResponse(ctx, db, fullPath, modulePath, requestedVersion)
 	if err != nil {
 		// If an error occurred, it means that we have never tried to fetch
@@ -144,12 +171,18 @@ func (s *Server) servePathNotFoundPage(w http.ResponseWriter, r *http.Request,
 			return pathNotFoundError(ctx, fullPath, requestedVersion)
 		}
 
-		// Redirect to the search result page for an empty directory that is above nested modules.
-		// See https://golang.org/issue/43725 for context.
+		// The path could not be fetched. If it differs from an indexed path
+		// only in case, as for github.com/Sirupsen/logrus, the user probably
+		// meant the indexed one.
+		if s.redirectToCanonicalPath(w, r, db, fullPath, requestedVersion) {
+			return nil
+		}
+
+		// For an empty directory that is above nested modules, list the
+		// modules. See https://golang.org/issue/43725 for context.
 		nm, err := ds.GetNestedModules(ctx, fullPath)
 		if err == nil && len(nm) > 0 {
-			http.Redirect(w, r, "/search?q="+url.QueryEscape(fullPath), http.StatusFound)
-			return nil
+			return nestedModulesError(fullPath, nm)
 		}
 		return &serverError{
 			status: fr.status,
@@ -163,6 +196,151 @@ func (s *Server) servePathNotFoundPage(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+// maxNestedModulesListed is the maximum number of nested modules listed on
+// the page for a directory that only contains nested modules.
+const maxNestedModulesListed = 100
+
+// nestedModulesError returns a page for fullPath, a directory that is not
+// part of any module but contains the nested modules mods, linking to the
+// modules.
+func nestedModulesError(fullPath string, mods []*internal.ModuleInfo) error {
+	data := struct {
+		Path    string
+		Modules []link
+		More    bool
+	}{Path: fullPath}
+	for _, m := range mods {
+		if len(data.Modules) == maxNestedModulesListed {
+			data.More = true
+			break
+		}
+		data.Modules = append(data.Modules, link{
+			Href: constructUnitURL(m.ModulePath, m.ModulePath, version.Latest),
+			Body: m.ModulePath,
+		})
+	}
+	return &serverError{
+		status: http.StatusNotFound,
+		epage: &errorPage{
+			messageTemplate: template.MakeTrustedTemplate(`
+				<h3 class="Error-message">“{{.Path}}” is not a package or module.</h3>
+				<p class="Error-message">
+				  It is a directory containing nested modules. Each nested module has
+				  its own go.mod file and is versioned separately.
+				</p>
+				<ul class="Error-list" data-test-id="nested-modules">
+				  {{range .Modules}}<li><a href="{{.Href}}">{{.Body}}</a></li>{{end}}
+				</ul>
+				{{if .More}}
+				  <p class="Error-message">
+				    To see all the modules, <a href="/search?q={{.Path}}">search for {{.Path}}</a>.
+				  </p>
+				{{end}}`),
+			MessageData: data,
+		},
+	}
+}
+
+// packageErrorReasons holds explanations of the package version state
+// statuses of packages that could not be processed.
+var packageErrorReasons = map[int]string{
+	derrors.ToStatus(derrors.PackageBuildContextNotSupported):  "None of its files are built on any of the platforms (GOOS/GOARCH combinations) that pkg.go.dev supports.",
+	derrors.ToStatus(derrors.PackageMaxImportsLimitExceeded):   "It has too many imports.",
+	derrors.ToStatus(derrors.PackageMaxFileSizeLimitExceeded):  "It contains a file that is too large to process.",
+	derrors.ToStatus(derrors.PackageDocumentationHTMLTooLarge): "Its documentation is too large to display.",
+	derrors.ToStatus(derrors.PackageInvalidContents):           "Its files do not make up a valid Go package. For example, they may fail to parse or declare different package names.",
+	derrors.ToStatus(derrors.PackageBadImportPath):             "Its directory does not form a valid import path.",
+}
+
+// packageProcessingError returns a page explaining why fullPath could not be
+// processed as a package at requestedVersion, using the error details stored
+// when its module was fetched, so that the module author can fix it. It
+// returns nil if there is no record of such a failure.
+func packageProcessingError(ctx context.Context, db *postgres.DB, fullPath, requestedVersion string) error {
+	if requestedVersion != version.Latest && !semver.IsValid(requestedVersion) {
+		return nil
+	}
+	pvs, err := db.GetLatestPackageVersionState(ctx, fullPath, requestedVersion)
+	if err != nil {
+		if !errors.Is(err, derrors.NotFound) {
+			log.Error(ctx, err)
+		}
+		return nil
+	}
+	reason, ok := packageErrorReasons[pvs.Status]
+	if !ok {
+		return nil
+	}
+	return &serverError{
+		status: http.StatusNotFound,
+		epage: &errorPage{
+			messageTemplate: template.MakeTrustedTemplate(`
+				<h3 class="Error-message">{{.Path}}@{{.Version}} could not be processed.</h3>
+				<p class="Error-message">{{.Reason}}</p>
+				{{with .Details}}<pre class="Error-details" data-test-id="processing-error">{{.}}</pre>{{end}}
+				<p class="Error-message">
+				  If you are the author, fix the problem and publish a new version of
+				  <a href="{{.ModuleURL}}">module {{.ModulePath}}</a>.
+				</p>`),
+			MessageData: struct {
+				Path, Version, Reason, Details, ModulePath, ModuleURL string
+			}{
+				Path:       fullPath,
+				Version:    pvs.Version,
+				Reason:     reason,
+				Details:    pvs.Error,
+				ModulePath: pvs.ModulePath,
+				ModuleURL:  constructUnitURL(pvs.ModulePath, pvs.ModulePath, pvs.Version),
+			},
+		},
+	}
+}
+
+// redirectToCanonicalPath redirects to the unit whose path matches fullPath
+// except for case, if it exists at requestedVersion. It reports whether it
+// redirected. The redirect is temporary, since fullPath may be fetched later.
+func (s *Server) redirectToCanonicalPath(w http.ResponseWriter, r *http.Request, db *postgres.DB, fullPath, requestedVersion string) bool {
+	ctx := r.Context()
+	u, err := canonicalPathURL(ctx, db, fullPath, requestedVersion)
+	if err != nil {
+		// Log the error, but continue with the usual 404 flow.
+		log.Error(ctx, err)
+		return false
+	}
+	if u == "" {
+		return false
+	}
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+	return true
+}
+
+// canonicalPathURL returns the URL of the unit whose path matches fullPath
+// except for case, at requestedVersion. It returns the empty string if there
+// is no such unit, if its path is fullPath itself, or if it does not exist
+// at requestedVersion.
+func canonicalPathURL(ctx context.Context, db *postgres.DB, fullPath, requestedVersion string) (string, error) {
+	path, modulePath, err := db.GetCanonicalUnitPath(ctx, fullPath)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if path == fullPath {
+		return "", nil
+	}
+	if _, err := db.GetUnitMeta(ctx, path, modulePath, requestedVersion); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return constructUnitURL(path, modulePath, requestedVersion), nil
+}
+
 // githubRegexp is regex to match a GitHub URL scheme containing a "/blob" or
 // "/tree" element.
 var githubRegexp = regexp.MustCompile(`(blob|tree)(/[^/]+)?`)
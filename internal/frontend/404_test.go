@@ -7,7 +7,9 @@ package frontend
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -224,6 +226,65 @@ func TestPreviousFetchStatusAndResponse_PathExistsAtNonV1(t *testing.T) {
 	}
 }
 
+func TestPackageProcessingError(t *testing.T) {
+	ctx := context.Background()
+	defer postgres.ResetTestDB(testDB, t)
+
+	const modulePath = "example.com/mod"
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		if err := testDB.InsertIndexVersions(ctx, []*internal.IndexVersion{{Path: modulePath, Version: v}}); err != nil {
+			t.Fatal(err)
+		}
+		status := derrors.ToStatus(derrors.PackageInvalidContents)
+		if v == "v1.0.0" {
+			status = derrors.ToStatus(derrors.PackageBadImportPath)
+		}
+		if err := testDB.UpdateModuleVersionState(ctx, &postgres.ModuleVersionStateForUpdate{
+			ModulePath: modulePath,
+			Version:    v,
+			Status:     derrors.ToStatus(derrors.HasIncompletePackages),
+			PackageVersionStates: []*internal.PackageVersionState{
+				{PackagePath: modulePath + "/bad", ModulePath: modulePath, Version: v, Status: status, Error: "bad.go:1:1: expected 'package'"},
+				{PackagePath: modulePath + "/good", ModulePath: modulePath, Version: v, Status: http.StatusOK},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		path, version string
+		wantVersion   string // empty if no error page is expected
+		wantReason    string
+	}{
+		{modulePath + "/bad", version.Latest, "v1.1.0", packageErrorReasons[derrors.ToStatus(derrors.PackageInvalidContents)]},
+		{modulePath + "/bad", "v1.0.0", "v1.0.0", packageErrorReasons[derrors.ToStatus(derrors.PackageBadImportPath)]},
+		{modulePath + "/bad", "master", "", ""},
+		{modulePath + "/good", version.Latest, "", ""},
+		{modulePath + "/missing", version.Latest, "", ""},
+	} {
+		t.Run(test.path+"@"+test.version, func(t *testing.T) {
+			err := packageProcessingError(ctx, testDB, test.path, test.version)
+			if test.wantVersion == "" {
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+				return
+			}
+			var serr *serverError
+			if !errors.As(err, &serr) || serr.status != http.StatusNotFound {
+				t.Fatalf("got %v, want a 404 serverError", err)
+			}
+			got := fmt.Sprintf("%+v", serr.epage.MessageData)
+			for _, want := range []string{test.wantVersion, test.wantReason, "expected 'package'"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("message data %s does not contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
 func TestGithubPathRedirect(t *testing.T) {
 	for _, test := range []struct {
 		path, want string
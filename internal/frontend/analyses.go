@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// AnalysesDetails contains the data for the Analyses tab of a unit page.
+type AnalysesDetails struct {
+	// Analyzers are the analyzers with results for the unit, sorted by name.
+	Analyzers []*AnalyzerResults
+}
+
+// AnalyzerResults are the results of one analyzer.
+type AnalyzerResults struct {
+	Name    string
+	Results []*internal.AnalysisResult
+}
+
+// analysisResultsGetter is implemented by data sources that store the results
+// of analyses.
+type analysisResultsGetter interface {
+	GetAnalysisResults(ctx context.Context, modulePath, version string) ([]*internal.AnalysisResult, error)
+}
+
+// fetchAnalysesDetails returns the AnalysesDetails for um. The page of a
+// module shows all the results of the module version; that of a package
+// shows the results about the package and those about the whole module.
+func fetchAnalysesDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (_ *AnalysesDetails, err error) {
+	defer derrors.Wrap(&err, "fetchAnalysesDetails(ctx, ds, %q, %q)", um.Path, um.Version)
+
+	g, ok := ds.(analysisResultsGetter)
+	if !ok {
+		return &AnalysesDetails{}, nil
+	}
+	results, err := g.GetAnalysisResults(ctx, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	return analysesDetails(results, um), nil
+}
+
+// analysesDetails groups the results that apply to um by analyzer. The
+// results are sorted by analyzer.
+func analysesDetails(results []*internal.AnalysisResult, um *internal.UnitMeta) *AnalysesDetails {
+	d := &AnalysesDetails{}
+	for _, r := range results {
+		if um.Path != um.ModulePath && r.PackagePath != "" && r.PackagePath != um.Path {
+			continue
+		}
+		if n := len(d.Analyzers); n == 0 || d.Analyzers[n-1].Name != r.Analyzer {
+			d.Analyzers = append(d.Analyzers, &AnalyzerResults{Name: r.Analyzer})
+		}
+		a := d.Analyzers[len(d.Analyzers)-1]
+		a.Results = append(a.Results, r)
+	}
+	return d
+}
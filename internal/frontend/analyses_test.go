@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestAnalysesDetails(t *testing.T) {
+	var (
+		lintModule = &internal.AnalysisResult{Analyzer: "lint", Severity: "info", Message: "ok"}
+		lintA      = &internal.AnalysisResult{Analyzer: "lint", PackagePath: "example.com/m/a", Severity: "warning", Message: "a"}
+		lintB      = &internal.AnalysisResult{Analyzer: "lint", PackagePath: "example.com/m/b", Severity: "warning", Message: "b"}
+		policyB    = &internal.AnalysisResult{Analyzer: "policy", PackagePath: "example.com/m/b", Severity: "error", Message: "b"}
+		results    = []*internal.AnalysisResult{lintModule, lintA, lintB, policyB}
+	)
+	for _, test := range []struct {
+		path string
+		want *AnalysesDetails
+	}{
+		{
+			path: "example.com/m",
+			want: &AnalysesDetails{Analyzers: []*AnalyzerResults{
+				{Name: "lint", Results: []*internal.AnalysisResult{lintModule, lintA, lintB}},
+				{Name: "policy", Results: []*internal.AnalysisResult{policyB}},
+			}},
+		},
+		{
+			path: "example.com/m/a",
+			want: &AnalysesDetails{Analyzers: []*AnalyzerResults{
+				{Name: "lint", Results: []*internal.AnalysisResult{lintModule, lintA}},
+			}},
+		},
+	} {
+		um := &internal.UnitMeta{Path: test.path, ModuleInfo: internal.ModuleInfo{ModulePath: "example.com/m"}}
+		got := analysesDetails(results, um)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+		}
+	}
+}
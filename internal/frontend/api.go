@@ -0,0 +1,619 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+const (
+	// defaultLicenseExportLimit is the number of rows returned by the
+	// license export endpoint when no limit is given.
+	defaultLicenseExportLimit = 1000
+	// maxLicenseExportLimit is the maximum number of rows returned by the
+	// license export endpoint in a single response.
+	maxLicenseExportLimit = 10000
+
+	// defaultPackageListLimit is the number of packages returned by the
+	// package list endpoint when no limit is given.
+	defaultPackageListLimit = 1000
+	// maxPackageListLimit is the maximum number of packages returned by the
+	// package list endpoint in a single response.
+	maxPackageListLimit = 10000
+
+	// defaultCompletionLimit is the number of paths returned by the
+	// completion endpoint when no limit is given.
+	defaultCompletionLimit = 50
+	// maxCompletionLimit is the maximum number of paths returned by the
+	// completion endpoint.
+	maxCompletionLimit = 500
+	// completionMaxAge is how long clients and the cache may reuse a
+	// response of the completion endpoint. The set of packages changes
+	// slowly, and a stale list is harmless for completion.
+	completionMaxAge = 6 * time.Hour
+)
+
+// apiErrorHandler is like errorHandler, but it reports errors as plain text
+// instead of serving an HTML error page. It is used for endpoints that are
+// intended to be consumed by programs.
+func (s *Server) apiErrorHandler(f func(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ds := s.getDataSource(r.Context())
+		if err := f(w, r, ds); err != nil {
+			s.serveAPIError(w, r, err)
+		}
+	}
+}
+
+func (s *Server) serveAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	var serr *serverError
+	if !errors.As(err, &serr) {
+		serr = &serverError{status: derrors.ToStatus(err), err: err}
+	}
+	if serr.status == http.StatusInternalServerError {
+		log.Error(ctx, err)
+		s.reportError(ctx, err, w, r)
+	} else {
+		log.Infof(ctx, "returning %d (%s) for error %v", serr.status, http.StatusText(serr.status), err)
+	}
+	if serr.responseText == "" {
+		serr.responseText = http.StatusText(serr.status)
+	}
+	http.Error(w, serr.responseText, serr.status)
+}
+
+// serveJSON writes v to w as JSON.
+func serveJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return serveJSONWithStatus(w, r, http.StatusOK, v)
+}
+
+// serveJSONWithStatus writes v to w as JSON, with the given HTTP status.
+func serveJSONWithStatus(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf(r.Context(), "serveJSON: %v", err)
+	}
+	return nil
+}
+
+// hasBearerToken reports whether r has an Authorization header with one of
+// the given bearer tokens. It is used by the endpoints that accept uploads.
+func hasBearerToken(r *http.Request, tokens []string) bool {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) || len(h) == len(prefix) {
+		return false
+	}
+	token := h[len(prefix):]
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleLicensesResponse is the response of the /api/v1/licenses endpoint.
+type ModuleLicensesResponse struct {
+	ModulePath        string                   `json:"modulePath"`
+	Version           string                   `json:"version"`
+	IsRedistributable bool                     `json:"isRedistributable"`
+	Licenses          []*ModuleLicenseMetadata `json:"licenses"`
+}
+
+// ModuleLicenseMetadata describes a single license file in a
+// ModuleLicensesResponse.
+type ModuleLicenseMetadata struct {
+	FilePath string   `json:"filePath"`
+	Types    []string `json:"types"`
+}
+
+// serveLicensesAPI handles requests for /api/v1/licenses?module=<path>[&version=<version>].
+// It returns the license types of the top-level licenses of the module at the
+// given version, or the latest version if none is provided.
+func (s *Server) serveLicensesAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	modulePath := strings.Trim(r.FormValue("module"), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module query parameter"}
+	}
+	requestedVersion := r.FormValue("version")
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if !isSupportedVersion(modulePath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	if err := checkExcluded(r.Context(), ds, modulePath); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(r.Context(), modulePath, modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	resp := &ModuleLicensesResponse{
+		ModulePath:        um.ModulePath,
+		Version:           um.Version,
+		IsRedistributable: um.ModuleInfo.IsRedistributable,
+		Licenses:          []*ModuleLicenseMetadata{},
+	}
+	for _, l := range um.Licenses {
+		resp.Licenses = append(resp.Licenses, &ModuleLicenseMetadata{FilePath: l.FilePath, Types: l.Types})
+	}
+	return serveJSON(w, r, resp)
+}
+
+// ModuleResponse is the JSON response of the /api/v1/module endpoint.
+type ModuleResponse struct {
+	ModulePath    string `json:"modulePath"`
+	LatestVersion string `json:"latestVersion"`
+	// RecommendedVersion is the version that the owners of the module
+	// recommend, if any.
+	RecommendedVersion string `json:"recommendedVersion,omitempty"`
+	Deprecated         bool   `json:"deprecated"`
+	DeprecationComment string `json:"deprecationComment,omitempty"`
+	// MovedTo is set if the module was renamed or moved.
+	MovedTo *MovedToResponse `json:"movedTo,omitempty"`
+}
+
+// serveModuleAPI handles requests for /api/v1/module?module=<path>.
+// It returns information about the latest version of the module, and the
+// version its owners recommend.
+func (s *Server) serveModuleAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	modulePath := strings.Trim(r.FormValue("module"), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module query parameter"}
+	}
+	if err := checkExcluded(r.Context(), ds, modulePath); err != nil {
+		return err
+	}
+	movedTo, err := movedToResponse(r.Context(), ds, modulePath, moduleAPIURL)
+	if err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(r.Context(), modulePath, modulePath, version.Latest)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) && movedTo != nil {
+			return serveMovedJSON(w, r, movedTo, &ModuleResponse{ModulePath: modulePath, MovedTo: movedTo})
+		}
+		return err
+	}
+	return serveJSON(w, r, &ModuleResponse{
+		ModulePath:         um.ModulePath,
+		LatestVersion:      um.Version,
+		RecommendedVersion: um.RecommendedVersion,
+		Deprecated:         um.Deprecated,
+		DeprecationComment: um.DeprecationComment,
+		MovedTo:            movedTo,
+	})
+}
+
+// PackageResponse is the JSON response of the /api/v1/package endpoint.
+type PackageResponse struct {
+	Path       string `json:"path"`
+	ModulePath string `json:"modulePath"`
+	Version    string `json:"version"`
+	// TestStats is omitted if the package has no tests, or if they were not
+	// counted when the package was fetched.
+	TestStats *PackageTestStats `json:"testStats,omitempty"`
+	// MovedTo is set if the module of the package was renamed or moved.
+	MovedTo *MovedToResponse `json:"movedTo,omitempty"`
+}
+
+// PackageTestStats counts the _test.go files of a package and the test
+// functions they declare.
+type PackageTestStats struct {
+	TestFiles   int `json:"testFiles"`
+	Tests       int `json:"tests"`
+	Benchmarks  int `json:"benchmarks"`
+	FuzzTargets int `json:"fuzzTargets"`
+	Examples    int `json:"examples"`
+}
+
+// servePackageAPI handles requests for /api/v1/package?path=<path>[&version=<version>].
+// It returns information about the package at the given version, or the
+// latest version if none is provided.
+func (s *Server) servePackageAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	pkgPath := strings.Trim(r.FormValue("path"), "/")
+	if pkgPath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing path query parameter"}
+	}
+	requestedVersion := r.FormValue("version")
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if !isSupportedVersion(pkgPath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, pkgPath); err != nil {
+		return err
+	}
+	var (
+		movedTo *MovedToResponse
+		err     error
+	)
+	if requestedVersion == version.Latest {
+		movedTo, err = movedToResponse(ctx, ds, pkgPath, packageAPIURL)
+		if err != nil {
+			return err
+		}
+	}
+	um, err := ds.GetUnitMeta(ctx, pkgPath, internal.UnknownModulePath, requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) && movedTo != nil {
+			return serveMovedJSON(w, r, movedTo, &PackageResponse{Path: pkgPath, MovedTo: movedTo})
+		}
+		return err
+	}
+	if !um.IsPackage() {
+		return &serverError{status: http.StatusNotFound, responseText: "not a package"}
+	}
+	unit, err := ds.GetUnit(ctx, um, internal.WithMain, internal.BuildContext{})
+	if err != nil {
+		return err
+	}
+	resp := &PackageResponse{
+		Path:       um.Path,
+		ModulePath: um.ModulePath,
+		Version:    um.Version,
+		MovedTo:    movedTo,
+	}
+	if ts := unit.TestStats; ts != nil {
+		resp.TestStats = &PackageTestStats{
+			TestFiles:   ts.NumTestFiles,
+			Tests:       ts.NumTests,
+			Benchmarks:  ts.NumBenchmarks,
+			FuzzTargets: ts.NumFuzzTargets,
+			Examples:    ts.NumExamples,
+		}
+	}
+	return serveJSON(w, r, resp)
+}
+
+// serveDocAPI handles requests for
+// /api/v1/doc?path=<path>[&version=<version>][&symbol=<symbol>].
+// It returns the documentation of the package at the given version, or the
+// latest version if none is provided, as plain text in the style of go doc.
+// If a symbol is provided, such as "Client" or "Client.Do", only its
+// documentation is returned.
+func (s *Server) serveDocAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	pkgPath := strings.Trim(r.FormValue("path"), "/")
+	if pkgPath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing path query parameter"}
+	}
+	requestedVersion := r.FormValue("version")
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if !isSupportedVersion(pkgPath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	symbol := r.FormValue("symbol")
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, pkgPath); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, pkgPath, internal.UnknownModulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	if !um.IsPackage() {
+		return &serverError{status: http.StatusNotFound, responseText: "not a package"}
+	}
+	unit, err := ds.GetUnit(ctx, um, internal.WithMain, internal.BuildContext{})
+	if err != nil {
+		return err
+	}
+	unit.Documentation = cleanDocumentation(unit.Documentation)
+	if len(unit.Documentation) == 0 {
+		if !unit.IsRedistributable {
+			return &serverError{status: http.StatusNotFound, responseText: "documentation not displayed due to license restrictions"}
+		}
+		return &serverError{status: http.StatusNotFound, responseText: "no documentation"}
+	}
+	text, err := godoc.RenderTextFromUnit(ctx, unit, symbol)
+	if errors.Is(err, derrors.NotFound) {
+		return &serverError{status: http.StatusNotFound, responseText: fmt.Sprintf("no symbol %q in package %s", symbol, um.Path)}
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write(text); err != nil {
+		log.Errorf(ctx, "serveDocAPI: %v", err)
+	}
+	return nil
+}
+
+// LicenseExportResponse is the JSON response of the /api/v1/licenses/export
+// endpoint.
+type LicenseExportResponse struct {
+	Licenses []*postgres.ExportedLicense `json:"licenses"`
+	// NextPage is the page number of the next page of results, or zero if
+	// this is the last page.
+	NextPage int `json:"nextPage,omitempty"`
+}
+
+// serveLicenseExportAPI handles requests for
+// /api/v1/licenses/export?prefix=<path>[&type=<license type>][&format=csv|json][&page=N][&limit=N].
+// It lists the license types of every license file of every module version
+// at or below the prefix.
+func (s *Server) serveLicenseExportAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	prefix := strings.Trim(r.FormValue("prefix"), "/")
+	if prefix == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix query parameter"}
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("unknown format %q", format)}
+	}
+	params := newPaginationParams(r, defaultLicenseExportLimit)
+	if params.limit > maxLicenseExportLimit {
+		params.limit = maxLicenseExportLimit
+	}
+	// Ask for one extra row so we know whether there is another page.
+	lics, err := db.ExportLicenses(r.Context(), postgres.LicenseExportOptions{
+		ModulePrefix: prefix,
+		LicenseType:  r.FormValue("type"),
+		Limit:        params.limit + 1,
+		Offset:       params.offset(),
+	})
+	if err != nil {
+		return err
+	}
+	resp := &LicenseExportResponse{Licenses: lics}
+	if len(lics) > params.limit {
+		resp.Licenses = lics[:params.limit]
+		resp.NextPage = params.page + 1
+	}
+	if resp.Licenses == nil {
+		resp.Licenses = []*postgres.ExportedLicense{}
+	}
+	if format == "csv" {
+		return serveLicenseExportCSV(w, r, resp)
+	}
+	return serveJSON(w, r, resp)
+}
+
+// serveLicenseExportCSV writes resp to w as CSV, one row per license file.
+// Multiple license types in a single file are separated by spaces. If there
+// is another page of results, its number is provided in the X-Next-Page
+// header.
+func serveLicenseExportCSV(w http.ResponseWriter, r *http.Request, resp *LicenseExportResponse) error {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write([]string{"module_path", "version", "file_path", "types"}); err != nil {
+		return err
+	}
+	for _, l := range resp.Licenses {
+		if err := cw.Write([]string{l.ModulePath, l.Version, l.FilePath, strings.Join(l.Types, " ")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if resp.NextPage != 0 {
+		w.Header().Set("X-Next-Page", fmt.Sprint(resp.NextPage))
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf(r.Context(), "serveLicenseExportCSV: %v", err)
+	}
+	return nil
+}
+
+// PackageListResponse is the JSON response of the /api/v1/list endpoint.
+type PackageListResponse struct {
+	Packages []*postgres.ListedPackage `json:"packages"`
+	// NextPage is the page number of the next page of results, or zero if
+	// this is the last page.
+	NextPage int `json:"nextPage,omitempty"`
+}
+
+// servePackageListAPI handles requests for
+// /api/v1/list?prefix=<path>[&page=N][&limit=N].
+// It lists every indexed package at or below the prefix, at the latest
+// version of its module, ordered by package path.
+func (s *Server) servePackageListAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	prefix := strings.Trim(r.FormValue("prefix"), "/")
+	if prefix == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix query parameter"}
+	}
+	params := newPaginationParams(r, defaultPackageListLimit)
+	if params.limit > maxPackageListLimit {
+		params.limit = maxPackageListLimit
+	}
+	// Ask for one extra row so we know whether there is another page.
+	pkgs, err := db.ListPackages(r.Context(), prefix, params.limit+1, params.offset())
+	if err != nil {
+		return err
+	}
+	resp := &PackageListResponse{Packages: pkgs}
+	if len(pkgs) > params.limit {
+		resp.Packages = pkgs[:params.limit]
+		resp.NextPage = params.page + 1
+	}
+	if resp.Packages == nil {
+		resp.Packages = []*postgres.ListedPackage{}
+	}
+	return serveJSON(w, r, resp)
+}
+
+// serveCompleteAPI handles requests for
+// /api/v1/complete?prefix=<prefix>[&limit=<limit>].
+// It lists the paths of packages that begin with prefix, for shell
+// completion. The response is plain text with one path per line, so that it
+// is cheap to produce and to consume from a shell script.
+func (s *Server) serveCompleteAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	// Keep a trailing slash: "github.com/org/" completes only below org.
+	prefix := strings.TrimLeft(r.FormValue("prefix"), "/")
+	if prefix == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix query parameter"}
+	}
+	limit := newPaginationParams(r, defaultCompletionLimit).limit
+	if limit > maxCompletionLimit {
+		limit = maxCompletionLimit
+	}
+	paths, err := db.CompletePackagePaths(r.Context(), prefix, limit)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, p := range paths {
+		buf.WriteString(p)
+		buf.WriteByte('\n')
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf(r.Context(), "serveCompleteAPI: %v", err)
+	}
+	return nil
+}
+
+// VulnListResponse is the JSON response of the /api/v1/vulns endpoint.
+type VulnListResponse struct {
+	Vulns []*VulnSummary `json:"vulns"`
+}
+
+// serveVulnsAPI handles requests for /api/v1/vulns?prefix=<path>.
+// It lists the vulnerabilities that affect a package at or below the prefix,
+// most recently modified first.
+func (s *Server) serveVulnsAPI(w http.ResponseWriter, r *http.Request, _ internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	if s.vulnClient == nil {
+		return &serverError{status: http.StatusFailedDependency, responseText: "vulnerability data is not available"}
+	}
+	prefix := strings.Trim(r.FormValue("prefix"), "/")
+	if prefix == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix query parameter"}
+	}
+	entries, err := allVulnEntries(s.vulnClient)
+	if err != nil {
+		return err
+	}
+	resp := &VulnListResponse{Vulns: vulnsForPrefix(entries, prefix)}
+	if resp.Vulns == nil {
+		resp.Vulns = []*VulnSummary{}
+	}
+	return serveJSON(w, r, resp)
+}
+
+// APIKeyListResponse is the JSON response of the /api/v1/keys endpoint.
+type APIKeyListResponse struct {
+	Keys []*APIKeyInfo `json:"keys"`
+}
+
+// APIKeyInfo describes an API key, without its secret value.
+type APIKeyInfo struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	Scopes    []string  `json:"scopes"`
+	RateLimit int       `json:"rateLimit,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// RevokedAt is omitted if the key is active.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// serveAPIKeysAPI handles requests for /api/v1/keys.
+// It lists all API keys, including revoked ones. The request must be made
+// with a key that has the admin scope.
+func (s *Server) serveAPIKeysAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	if k := apikey.FromContext(r.Context()); k == nil || !k.HasScope(apikey.ScopeAdmin) {
+		return &serverError{status: http.StatusForbidden, responseText: "an API key with the admin scope is required"}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	keys, err := db.GetAPIKeys(r.Context())
+	if err != nil {
+		return err
+	}
+	resp := &APIKeyListResponse{Keys: []*APIKeyInfo{}}
+	for _, k := range keys {
+		info := &APIKeyInfo{
+			ID:        k.ID,
+			Name:      k.Name,
+			Prefix:    k.Prefix,
+			Scopes:    k.Scopes,
+			RateLimit: k.RateLimit,
+			CreatedAt: k.CreatedAt,
+		}
+		if !k.RevokedAt.IsZero() {
+			t := k.RevokedAt
+			info.RevokedAt = &t
+		}
+		resp.Keys = append(resp.Keys, info)
+	}
+	return serveJSON(w, r, resp)
+}
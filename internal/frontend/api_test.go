@@ -0,0 +1,350 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestLicensesAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "A")
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	get := func(url string) (int, []byte) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		body, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body
+	}
+
+	t.Run("module", func(t *testing.T) {
+		code, body := get("/api/v1/licenses?module=" + sample.ModulePath)
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		var got ModuleLicensesResponse
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := ModuleLicensesResponse{
+			ModulePath:        sample.ModulePath,
+			Version:           "v1.2.3",
+			IsRedistributable: true,
+			Licenses: []*ModuleLicenseMetadata{
+				{FilePath: sample.LicenseFilePath, Types: []string{sample.LicenseType}},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("export json", func(t *testing.T) {
+		code, body := get("/api/v1/licenses/export?prefix=github.com/valid&type=MIT")
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		var got LicenseExportResponse
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := LicenseExportResponse{
+			Licenses: []*postgres.ExportedLicense{
+				{ModulePath: sample.ModulePath, Version: "v1.2.3", FilePath: "LICENSE", Types: []string{"MIT"}},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("export csv", func(t *testing.T) {
+		code, body := get("/api/v1/licenses/export?prefix=" + sample.ModulePath + "&format=csv")
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		want := "module_path,version,file_path,types\n" + sample.ModulePath + ",v1.2.3,LICENSE,MIT\n"
+		if got := string(body); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		code, body := get("/api/v1/list?prefix=github.com/valid/")
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		var got PackageListResponse
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := PackageListResponse{
+			Packages: []*postgres.ListedPackage{
+				{
+					PackagePath: sample.ModulePath + "/A",
+					ModulePath:  sample.ModulePath,
+					Version:     "v1.2.3",
+					Synopsis:    sample.Doc.Synopsis,
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	for _, test := range []struct {
+		url  string
+		want int
+	}{
+		{"/api/v1/licenses", http.StatusBadRequest},
+		{"/api/v1/licenses?module=github.com/no/such/module", http.StatusNotFound},
+		{"/api/v1/licenses/export", http.StatusBadRequest},
+		{"/api/v1/licenses/export?prefix=github.com&format=xml", http.StatusBadRequest},
+		{"/api/v1/list", http.StatusBadRequest},
+	} {
+		if code, _ := get(test.url); code != test.want {
+			t.Errorf("%s: got status %d, want %d", test.url, code, test.want)
+		}
+	}
+}
+
+func TestModuleAPIAndRecommendedVersion(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, v := range []string{"v1.1.0", "v1.2.0"} {
+		m := sample.Module(sample.ModulePath, v, "A")
+		postgres.MustInsertModuleGoMod(ctx, t, testDB, m,
+			"module "+sample.ModulePath+" // Recommended: v1.1.0")
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	t.Run("api", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/module?module="+sample.ModulePath, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		var got ModuleResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := ModuleResponse{
+			ModulePath:         sample.ModulePath,
+			LatestVersion:      "v1.2.0",
+			RecommendedVersion: "v1.1.0",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/"+sample.ModulePath+"@recommended/A?tab=doc", nil))
+		if w.Code != http.StatusFound {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusFound)
+		}
+		want := "/" + sample.ModulePath + "@v1.1.0/A?tab=doc"
+		if got := w.Header().Get("Location"); got != want {
+			t.Errorf("got Location %q, want %q", got, want)
+		}
+	})
+}
+
+func TestPackageAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "A", "B")
+	m.Packages()[0].TestStats = &internal.TestStats{NumTestFiles: 3, NumTests: 42, NumBenchmarks: 3, NumFuzzTargets: 2, NumExamples: 10}
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+		want       *PackageResponse
+	}{
+		{
+			path:       sample.ModulePath + "/A",
+			wantStatus: http.StatusOK,
+			want: &PackageResponse{
+				Path:       sample.ModulePath + "/A",
+				ModulePath: sample.ModulePath,
+				Version:    "v1.2.3",
+				TestStats:  &PackageTestStats{TestFiles: 3, Tests: 42, Benchmarks: 3, FuzzTargets: 2, Examples: 10},
+			},
+		},
+		{
+			path:       sample.ModulePath + "/B",
+			wantStatus: http.StatusOK,
+			want: &PackageResponse{
+				Path:       sample.ModulePath + "/B",
+				ModulePath: sample.ModulePath,
+				Version:    "v1.2.3",
+			},
+		},
+		{
+			path:       sample.ModulePath,
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/package?path="+test.path, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			continue
+		}
+		if test.want == nil {
+			continue
+		}
+		got := &PackageResponse{}
+		if err := json.NewDecoder(w.Body).Decode(got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+		}
+	}
+}
+
+func TestDocAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "A")
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	for _, test := range []struct {
+		query      string
+		wantStatus int
+		want       string
+	}{
+		{
+			query:      "path=" + sample.ModulePath + "/A",
+			wantStatus: http.StatusOK,
+			want:       "package p // import \"" + sample.ModulePath + "/A\"\n\nPackage p is a package.\n\n\nLinks\n\n- pkg.go.dev, https://pkg.go.dev\n\nvar V int\n",
+		},
+		{
+			query:      "path=" + sample.ModulePath + "/A&version=v1.2.3&symbol=V",
+			wantStatus: http.StatusOK,
+			want:       "var V int\n",
+		},
+		{
+			query:      "path=" + sample.ModulePath + "/A&symbol=Nope",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			query:      "path=" + sample.ModulePath,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/doc?"+test.query, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.query, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", test.query, got, want)
+		}
+		if diff := cmp.Diff(test.want, w.Body.String()); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+}
+
+func TestCompleteAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.0.0", "p", "q"))
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/b", "v1.0.0", "p"))
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	for _, test := range []struct {
+		query      string
+		wantStatus int
+		want       string
+	}{
+		{"prefix=github.com/org/", http.StatusOK, "github.com/org/a/p\ngithub.com/org/a/q\ngithub.com/org/b/p\n"},
+		{"prefix=github.com/org/a&limit=1", http.StatusOK, "github.com/org/a/p\n"},
+		{"prefix=github.com/none", http.StatusOK, ""},
+		{"", http.StatusBadRequest, ""},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/complete?"+test.query, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.query, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			if got := w.Header().Get("Cache-Control"); got != "" {
+				t.Errorf("%s: got Cache-Control %q for an error", test.query, got)
+			}
+			continue
+		}
+		if got, want := w.Header().Get("Cache-Control"), "public, max-age=21600"; got != want {
+			t.Errorf("%s: got Cache-Control %q, want %q", test.query, got, want)
+		}
+		if got := w.Body.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestHasBearerToken(t *testing.T) {
+	for _, test := range []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"Bearer ", false},
+		{"Bearer wrong", false},
+		{"secret", false},
+		{"Bearer secret", true},
+	} {
+		r := httptest.NewRequest("POST", "/api/v1/upload", nil)
+		if test.header != "" {
+			r.Header.Set("Authorization", test.header)
+		}
+		if got := hasBearerToken(r, []string{"other", "secret"}); got != test.want {
+			t.Errorf("%q: got %t, want %t", test.header, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+const (
+	// apiCompatAPIPath is the path prefix of the /api/v1/apicompat endpoint.
+	apiCompatAPIPath = "/api/v1/apicompat/"
+
+	// maxAPIBaselineSize is the maximum size of an uploaded API baseline.
+	maxAPIBaselineSize = 32 << 20
+)
+
+// APICompatResponse is the JSON response of the /api/v1/apicompat endpoint.
+// It compares the API of a module version with a baseline.
+type APICompatResponse struct {
+	ModulePath string `json:"modulePath"`
+	Version    string `json:"version"`
+	// BaseVersion is the version of the baseline. It may be empty for an
+	// uploaded baseline.
+	BaseVersion string `json:"baseVersion"`
+	// Compatible reports whether no symbol of the baseline was removed or
+	// changed.
+	Compatible bool         `json:"compatible"`
+	Removed    []*APIChange `json:"removed"`
+	Changed    []*APIChange `json:"changed"`
+	Added      []*APIChange `json:"added"`
+}
+
+// An APIChange is a symbol of a package that differs between the baseline
+// and the compared version.
+type APIChange struct {
+	PackagePath string `json:"packagePath"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	// OldSignature is empty for an added symbol, and NewSignature for a
+	// removed one.
+	OldSignature string `json:"oldSignature,omitempty"`
+	NewSignature string `json:"newSignature,omitempty"`
+	// BuildContexts are the GOOS/GOARCH pairs in which the symbol changed,
+	// or "all/all" if it changed in all of them.
+	BuildContexts []string `json:"buildContexts"`
+}
+
+// serveAPICompatAPI handles requests for
+// /api/v1/apicompat/<module>[@<version>]?base=<version>.
+// It reports whether the module at the given version, or the latest version
+// if none is provided, removed or changed any exported symbol of non-internal
+// packages that the module had at the base version. Instead of a base
+// version, a POST request can provide the baseline in its body, as the JSON
+// response of the /api/v1/symbols endpoint. Continuous integration can use
+// the compatible field of the response to prevent incompatible releases.
+func (s *Server) serveAPICompatAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	modulePath, requestedVersion, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, apiCompatAPIPath), "/"), "@")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module path"}
+	}
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	// Use the query, not the form, so that the body is not parsed as a form.
+	baseVersion := r.URL.Query().Get("base")
+	if r.Method == http.MethodPost {
+		if baseVersion != "" {
+			return &serverError{status: http.StatusBadRequest, responseText: "base query parameter not allowed with an uploaded baseline"}
+		}
+	} else if baseVersion == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing base query parameter"}
+	}
+	for _, v := range []string{requestedVersion, baseVersion} {
+		if v != "" && !isSupportedVersion(modulePath, v) {
+			return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+		}
+	}
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, modulePath); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, modulePath, modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	var base *ModuleSymbolsResponse
+	if r.Method == http.MethodPost {
+		base = &ModuleSymbolsResponse{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBaselineSize)).Decode(base); err != nil {
+			return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("invalid baseline: %v", err)}
+		}
+	} else {
+		bum, err := ds.GetUnitMeta(ctx, modulePath, modulePath, baseVersion)
+		if err != nil {
+			return err
+		}
+		mss, err := db.GetModuleSymbols(ctx, bum.ModulePath, bum.Version)
+		if err != nil {
+			return err
+		}
+		base = &ModuleSymbolsResponse{ModulePath: bum.ModulePath, Version: bum.Version, Symbols: newSymbolResponses(mss)}
+	}
+	mss, err := db.GetModuleSymbols(ctx, um.ModulePath, um.Version)
+	if err != nil {
+		return err
+	}
+	resp := &APICompatResponse{
+		ModulePath:  um.ModulePath,
+		Version:     um.Version,
+		BaseVersion: base.Version,
+	}
+	resp.Removed, resp.Changed, resp.Added = compareAPIs(base.Symbols, newSymbolResponses(mss))
+	resp.Compatible = len(resp.Removed) == 0 && len(resp.Changed) == 0
+	return serveJSON(w, r, resp)
+}
+
+// compareAPIs compares the symbols of the non-internal packages of a module
+// in a baseline with those in another version, in each build context. It
+// returns the symbols that were removed or changed in some build contexts,
+// and those that were added. Each list is sorted by package path and symbol
+// name, and is not nil.
+func compareAPIs(base, current []*SymbolResponse) (removed, changed, added []*APIChange) {
+	type symbolKey struct{ pkg, name, bc string }
+	// byContext returns the symbols of srs in each build context, with
+	// "all/all" expanded to all build contexts.
+	byContext := func(srs []*SymbolResponse) (map[symbolKey]*SymbolResponse, map[symbolKey]bool) {
+		m := map[symbolKey]*SymbolResponse{}
+		names := map[symbolKey]bool{}
+		for _, sr := range srs {
+			if isInternalPath(sr.PackagePath) {
+				continue
+			}
+			names[symbolKey{sr.PackagePath, sr.Name, ""}] = true
+			for _, bc := range sr.BuildContexts {
+				if bc == internal.All+"/"+internal.All {
+					for _, c := range internal.BuildContexts {
+						m[symbolKey{sr.PackagePath, sr.Name, c.GOOS + "/" + c.GOARCH}] = sr
+					}
+				} else {
+					m[symbolKey{sr.PackagePath, sr.Name, bc}] = sr
+				}
+			}
+		}
+		return m, names
+	}
+	baseSymbols, baseNames := byContext(base)
+	currentSymbols, currentNames := byContext(current)
+
+	// Group the build contexts of the same change.
+	type changeKey struct{ pkg, name, oldSig, newSig string }
+	changes := map[changeKey]*APIChange{}
+	var keys []symbolKey
+	for k := range baseSymbols {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := keys[i], keys[j]
+		if ki.pkg != kj.pkg {
+			return ki.pkg < kj.pkg
+		}
+		if ki.name != kj.name {
+			return ki.name < kj.name
+		}
+		return ki.bc < kj.bc
+	})
+	removed, changed, added = []*APIChange{}, []*APIChange{}, []*APIChange{}
+	for _, k := range keys {
+		old := baseSymbols[k]
+		var newSig string
+		if cur := currentSymbols[k]; cur != nil {
+			if cur.Signature == old.Signature {
+				continue
+			}
+			newSig = cur.Signature
+		}
+		ck := changeKey{k.pkg, k.name, old.Signature, newSig}
+		c := changes[ck]
+		if c == nil {
+			c = &APIChange{
+				PackagePath:  k.pkg,
+				Name:         k.name,
+				Kind:         old.Kind,
+				OldSignature: old.Signature,
+				NewSignature: newSig,
+			}
+			changes[ck] = c
+			if newSig == "" {
+				removed = append(removed, c)
+			} else {
+				changed = append(changed, c)
+			}
+		}
+		c.BuildContexts = append(c.BuildContexts, k.bc)
+	}
+	for _, c := range changes {
+		if len(c.BuildContexts) == len(internal.BuildContexts) {
+			c.BuildContexts = []string{internal.All + "/" + internal.All}
+		}
+	}
+	for _, sr := range current {
+		k := symbolKey{sr.PackagePath, sr.Name, ""}
+		if currentNames[k] && !baseNames[k] {
+			added = append(added, &APIChange{
+				PackagePath:   sr.PackagePath,
+				Name:          sr.Name,
+				Kind:          sr.Kind,
+				NewSignature:  sr.Signature,
+				BuildContexts: sr.BuildContexts,
+			})
+		}
+	}
+	sort.SliceStable(added, func(i, j int) bool {
+		if added[i].PackagePath != added[j].PackagePath {
+			return added[i].PackagePath < added[j].PackagePath
+		}
+		return added[i].Name < added[j].Name
+	})
+	return removed, changed, added
+}
+
+// isInternalPath reports whether path has an internal element, so that it
+// can only be imported by packages of the same module.
+func isInternalPath(path string) bool {
+	for _, p := range strings.Split(path, "/") {
+		if p == "internal" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareAPIs(t *testing.T) {
+	const pkg = "example.com/m/p"
+	all := []string{"all/all"}
+	sym := func(name, sig string, bcs ...string) *SymbolResponse {
+		if len(bcs) == 0 {
+			bcs = all
+		}
+		return &SymbolResponse{PackagePath: pkg, Name: name, Kind: "function", Signature: sig, BuildContexts: bcs}
+	}
+	base := []*SymbolResponse{
+		sym("Kept", "func Kept()"),
+		sym("Removed", "func Removed()"),
+		sym("Split", "func Split()"),
+		sym("Changed", "func Changed(int)"),
+		sym("Platform", "func Platform()"),
+		{PackagePath: "example.com/m/internal/q", Name: "Internal", Kind: "function", Signature: "func Internal()", BuildContexts: all},
+	}
+	current := []*SymbolResponse{
+		sym("Kept", "func Kept()"),
+		// The same signature in every build context is not a change.
+		sym("Split", "func Split()", "linux/amd64", "windows/amd64", "darwin/amd64", "js/wasm"),
+		sym("Changed", "func Changed(int64)"),
+		sym("Platform", "func Platform()", "linux/amd64", "darwin/amd64", "js/wasm"),
+		sym("Added", "func Added()"),
+		{PackagePath: "example.com/m/internal/q", Name: "Internal", Kind: "function", Signature: "func Internal(int)", BuildContexts: all},
+	}
+	removed, changed, added := compareAPIs(base, current)
+
+	wantRemoved := []*APIChange{
+		{PackagePath: pkg, Name: "Platform", Kind: "function", OldSignature: "func Platform()", BuildContexts: []string{"windows/amd64"}},
+		{PackagePath: pkg, Name: "Removed", Kind: "function", OldSignature: "func Removed()", BuildContexts: all},
+	}
+	wantChanged := []*APIChange{
+		{PackagePath: pkg, Name: "Changed", Kind: "function", OldSignature: "func Changed(int)", NewSignature: "func Changed(int64)", BuildContexts: all},
+	}
+	wantAdded := []*APIChange{
+		{PackagePath: pkg, Name: "Added", Kind: "function", NewSignature: "func Added()", BuildContexts: all},
+	}
+	if diff := cmp.Diff(wantRemoved, removed); diff != "" {
+		t.Errorf("removed mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantChanged, changed); diff != "" {
+		t.Errorf("changed mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantAdded, added); diff != "" {
+		t.Errorf("added mismatch (-want +got):\n%s", diff)
+	}
+}
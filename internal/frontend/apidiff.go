@@ -0,0 +1,152 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// APIDiffDetails contains the data for the API Diff tab of a package page,
+// which compares the exported symbols of the package at two versions of its
+// module.
+type APIDiffDetails struct {
+	// FromVersion and ToVersion are the compared versions, for display.
+	// FromVersion is empty if there is no earlier release to compare with.
+	FromVersion, ToVersion string
+	FromURL, ToURL         string
+
+	// Removed and Changed are the incompatible changes: the symbols that
+	// were removed, and those whose declaration changed. Added are the
+	// symbols that were added. Each is sorted by name.
+	Added, Removed, Changed []*SymbolDiff
+}
+
+// A SymbolDiff is a symbol that differs between two versions of a package.
+type SymbolDiff struct {
+	Name string
+	Kind string
+	// OldSynopsis and NewSynopsis are the declarations of the symbol in the
+	// two versions. OldSynopsis is empty for an added symbol, and
+	// NewSynopsis for a removed one.
+	OldSynopsis, NewSynopsis string
+}
+
+// fetchAPIDiffDetails compares the API of the package um with its API at
+// fromVersion, or at the previous release of its module if fromVersion is
+// empty.
+func fetchAPIDiffDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, fromVersion string) (_ *APIDiffDetails, err error) {
+	defer derrors.Wrap(&err, "fetchAPIDiffDetails(ctx, ds, %q, %q, %q)", um.Path, um.Version, fromVersion)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		// Other data sources do not store the symbols of packages.
+		return nil, datasourceNotSupportedErr()
+	}
+	d := &APIDiffDetails{
+		ToVersion: displayVersion(um.ModulePath, um.Version, um.Version),
+		ToURL:     constructUnitURL(um.Path, um.ModulePath, um.Version),
+	}
+	if fromVersion == "" {
+		fromVersion, err = previousRelease(ctx, db, um)
+		if err != nil {
+			return nil, err
+		}
+		if fromVersion == "" {
+			return d, nil
+		}
+	} else {
+		// Check that the package exists at fromVersion, so that its API is
+		// not reported as all added.
+		if _, err := db.GetUnitMeta(ctx, um.Path, um.ModulePath, fromVersion); err != nil {
+			if !errors.Is(err, derrors.NotFound) {
+				return nil, err
+			}
+			return nil, &serverError{
+				status: http.StatusNotFound,
+				epage: &errorPage{
+					messageTemplate: template.MakeTrustedTemplate(
+						`<h3 class="Error-message">{{.}} was not found.</h3>`),
+					MessageData: fmt.Sprintf("%s@%s", um.Path, fromVersion),
+				},
+			}
+		}
+	}
+	oldAPI, err := packageAPI(ctx, db, um.Path, um.ModulePath, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := packageAPI(ctx, db, um.Path, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	d.FromVersion = displayVersion(um.ModulePath, fromVersion, fromVersion)
+	d.FromURL = constructUnitURL(um.Path, um.ModulePath, fromVersion)
+	d.Added, d.Removed, d.Changed = symbolDiffs(oldAPI, newAPI)
+	return d, nil
+}
+
+// previousRelease returns the latest release of the module of um that is
+// lower than um.Version, or the empty string if there is none.
+func previousRelease(ctx context.Context, db *postgres.DB, um *internal.UnitMeta) (string, error) {
+	versions, err := db.GetVersionsForPath(ctx, um.Path)
+	if err != nil {
+		return "", err
+	}
+	// The versions are sorted in descending order within each module path.
+	for _, mi := range versions {
+		if mi.ModulePath != um.ModulePath || semver.Compare(mi.Version, um.Version) >= 0 {
+			continue
+		}
+		if t, err := version.ParseType(mi.Version); err == nil && t == version.TypeRelease {
+			return mi.Version, nil
+		}
+	}
+	return "", nil
+}
+
+// symbolDiffs compares two sets of symbols. A symbol is considered changed
+// if its synopsis, which holds its declaration, differs.
+func symbolDiffs(oldAPI, newAPI []*internal.SymbolMeta) (added, removed, changed []*SymbolDiff) {
+	newByName := map[string]*internal.SymbolMeta{}
+	for _, sm := range newAPI {
+		newByName[sm.Name] = sm
+	}
+	oldNames := map[string]bool{}
+	for _, sm := range oldAPI {
+		oldNames[sm.Name] = true
+		n := newByName[sm.Name]
+		switch {
+		case n == nil:
+			removed = append(removed, &SymbolDiff{Name: sm.Name, Kind: symbolKind(sm), OldSynopsis: sm.Synopsis})
+		case n.Synopsis != sm.Synopsis:
+			changed = append(changed, &SymbolDiff{Name: sm.Name, Kind: symbolKind(n), OldSynopsis: sm.Synopsis, NewSynopsis: n.Synopsis})
+		}
+	}
+	for _, sm := range newAPI {
+		if !oldNames[sm.Name] {
+			added = append(added, &SymbolDiff{Name: sm.Name, Kind: symbolKind(sm), NewSynopsis: sm.Synopsis})
+		}
+	}
+	for _, s := range [][]*SymbolDiff{added, removed, changed} {
+		sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	}
+	return added, removed, changed
+}
+
+func symbolKind(sm *internal.SymbolMeta) string {
+	return strings.ToLower(string(sm.Kind))
+}
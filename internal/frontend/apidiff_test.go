@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestSymbolDiffs(t *testing.T) {
+	sym := func(name string, kind internal.SymbolKind, synopsis string) *internal.SymbolMeta {
+		return &internal.SymbolMeta{Name: name, Kind: kind, Synopsis: synopsis}
+	}
+	oldAPI := []*internal.SymbolMeta{
+		sym("F", internal.SymbolKindFunction, "func F()"),
+		sym("G", internal.SymbolKindFunction, "func G(int)"),
+		sym("T", internal.SymbolKindType, "type T struct"),
+		sym("T.M", internal.SymbolKindMethod, "func (T) M()"),
+	}
+	newAPI := []*internal.SymbolMeta{
+		sym("U", internal.SymbolKindType, "type U int"),
+		sym("F", internal.SymbolKindFunction, "func F()"),
+		sym("G", internal.SymbolKindFunction, "func G(context.Context, int)"),
+		sym("T", internal.SymbolKindType, "type T struct"),
+		sym("T.N", internal.SymbolKindMethod, "func (T) N()"),
+	}
+	added, removed, changed := symbolDiffs(oldAPI, newAPI)
+	wantAdded := []*SymbolDiff{
+		{Name: "T.N", Kind: "method", NewSynopsis: "func (T) N()"},
+		{Name: "U", Kind: "type", NewSynopsis: "type U int"},
+	}
+	wantRemoved := []*SymbolDiff{
+		{Name: "T.M", Kind: "method", OldSynopsis: "func (T) M()"},
+	}
+	wantChanged := []*SymbolDiff{
+		{Name: "G", Kind: "function", OldSynopsis: "func G(int)", NewSynopsis: "func G(context.Context, int)"},
+	}
+	for _, test := range []struct {
+		name      string
+		got, want []*SymbolDiff
+	}{
+		{"added", added, wantAdded},
+		{"removed", removed, wantRemoved},
+		{"changed", changed, wantChanged},
+	} {
+		if diff := cmp.Diff(test.want, test.got); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", test.name, diff)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/moddiff"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// archiveSuffix is the suffix of the URL path of a directory's archive, as in
+// /example.com/m@v1.0.0/pkg/-/archive.zip.
+const archiveSuffix = "/-/archive.zip"
+
+// serveArchive serves a zip of the files in one directory of a module, along
+// with the license files that apply to it. The files have the same paths as
+// in the module zip.
+func (s *Server) serveArchive(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	um, m, err := s.unitZip(r, ds, strings.TrimSuffix(r.URL.Path, archiveSuffix))
+	if err != nil {
+		return err
+	}
+	data, err := directoryArchive(m, um)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveName(um)))
+	http.ServeContent(w, r, "", um.CommitTime, bytes.NewReader(data))
+	return nil
+}
+
+// unitZip returns the unit at urlPath, which has the form of a unit page's
+// path, and the zip of its module. It returns a serverError if the files of
+// the unit cannot be served.
+func (s *Server) unitZip(r *http.Request, ds internal.DataSource, urlPath string) (*internal.UnitMeta, *moddiff.Module, error) {
+	if s.moduleZipGetter == nil {
+		return nil, nil, datasourceNotSupportedErr()
+	}
+	um, err := servableUnit(r, ds, urlPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if um.ModulePath == stdlib.ModulePath {
+		return nil, nil, errStdlibFilesNotAvailable
+	}
+	m, err := s.getModuleZip(r.Context(), um.ModulePath, um.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return um, m, nil
+}
+
+// errStdlibFilesNotAvailable is returned for requests for the files of a
+// standard library package, since the proxy does not serve them.
+var errStdlibFilesNotAvailable = &serverError{
+	status: http.StatusNotFound,
+	epage: &errorPage{
+		messageTemplate: template.MakeTrustedTemplate(
+			`<h3 class="Error-message">Files are not available for the standard library.</h3>`),
+	},
+}
+
+// servableUnit returns the unit at urlPath, which has the form of a unit
+// page's path, for a request for data derived from its files. It returns a
+// serverError if the request is not a GET or HEAD, if there is no such unit,
+// or if the unit is not redistributable.
+func servableUnit(r *http.Request, ds internal.DataSource, urlPath string) (*internal.UnitMeta, error) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return nil, &serverError{status: http.StatusMethodNotAllowed}
+	}
+	ctx := r.Context()
+	urlInfo, err := extractURLPathInfo(urlPath)
+	if err != nil {
+		var epage *errorPage
+		if uerr := new(userError); errors.As(err, &uerr) {
+			epage = &errorPage{MessageData: uerr.userMessage}
+		}
+		return nil, &serverError{status: http.StatusBadRequest, err: err, epage: epage}
+	}
+	if !isSupportedVersion(urlInfo.fullPath, urlInfo.requestedVersion) {
+		return nil, invalidVersionError(urlInfo.fullPath, urlInfo.requestedVersion)
+	}
+	if err := checkExcluded(ctx, ds, urlInfo.fullPath); err != nil {
+		return nil, err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, &serverError{status: http.StatusNotFound, err: err}
+		}
+		return nil, err
+	}
+	if !um.IsRedistributable {
+		return nil, &serverError{
+			status: http.StatusForbidden,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(`
+					<h3 class="Error-message">{{.}} is not redistributable.</h3>
+					<p class="Error-message">See the <a href="/license-policy">license policy</a>.</p>`),
+				MessageData: um.Path,
+			},
+		}
+	}
+	return um, nil
+}
+
+// directoryArchive returns a zip of the regular files in the directory of um,
+// not including subdirectories, and of the license files that apply to um.
+func directoryArchive(m *moddiff.Module, um *internal.UnitMeta) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "directoryArchive(%q, %q)", um.Path, um.Version)
+
+	prefix := m.Path + "@" + m.Version + "/"
+	dir := internal.Suffix(um.Path, um.ModulePath)
+	if dir == "" {
+		dir = "."
+	}
+	licenseFiles := map[string]bool{}
+	for _, l := range um.Licenses {
+		licenseFiles[l.FilePath] = true
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, zf := range m.Zip.File {
+		if !zf.Mode().IsRegular() || !strings.HasPrefix(zf.Name, prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(zf.Name, prefix)
+		if path.Dir(rel) != dir && !licenseFiles[rel] {
+			continue
+		}
+		// Copy the compressed data, without decompressing it.
+		if err := zw.Copy(zf); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveName returns the file name for the archive of um, such as
+// "pkg-v1.0.0.zip".
+func archiveName(um *internal.UnitMeta) string {
+	return path.Base(um.Path) + "-" + um.Version + ".zip"
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestServeArchive(t *testing.T) {
+	s, handler, teardown := newTestServer(t, nil, nil)
+	defer teardown()
+	proxyClient, proxyTeardown := proxytest.SetupTestClient(t, testModulesForProxy)
+	defer proxyTeardown()
+	s.moduleZipGetter = ProxyZipGetter(proxyClient)
+	ctx := context.Background()
+	if _, err := FetchAndUpdateState(ctx, testModulePath, testSemver, proxyClient, source.NewClient(sourceTimeout), testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+		wantFiles  []string
+	}{
+		{
+			path:       "/" + testModulePath + "@" + testSemver + "/bar/foo" + archiveSuffix,
+			wantStatus: http.StatusOK,
+			wantFiles: []string{
+				testModulePath + "@" + testSemver + "/LICENSE",
+				testModulePath + "@" + testSemver + "/bar/foo/foo.go",
+			},
+		},
+		{
+			path:       "/" + testModulePath + "/bar/foo" + archiveSuffix,
+			wantStatus: http.StatusOK,
+			wantFiles: []string{
+				testModulePath + "@" + testSemver + "/LICENSE",
+				testModulePath + "@" + testSemver + "/bar/foo/foo.go",
+			},
+		},
+		{
+			path:       "/" + testModulePath + "@" + testSemver + "/nope" + archiveSuffix,
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="foo-v1.5.2.zip"`; got != want {
+			t.Errorf("%s: got Content-Disposition %q, want %q", test.path, got, want)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		var got []string
+		for _, f := range zr.File {
+			got = append(got, f.Name)
+		}
+		sort.Strings(got)
+		if diff := cmp.Diff(test.wantFiles, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+		}
+	}
+}
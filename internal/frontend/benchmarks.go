@@ -0,0 +1,340 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/text/message"
+)
+
+const (
+	// maxBenchmarkUploadSize is the maximum size of an upload of benchmark
+	// results.
+	maxBenchmarkUploadSize = 10 << 20
+
+	// maxBenchmarkVersions is the number of module versions whose results
+	// are shown in the benchmark table of a package.
+	maxBenchmarkVersions = 5
+)
+
+// BenchmarkDetails contains the data for the Benchmarks section of a package
+// page.
+type BenchmarkDetails struct {
+	// Names are the names of the benchmark functions of the package.
+	Names []string
+	// Table holds the uploaded results for the package in recent versions of
+	// its module. It is nil if there are none.
+	Table *BenchmarkTable
+}
+
+// A BenchmarkTable shows the trend of the results of the benchmarks of a
+// package across module versions.
+type BenchmarkTable struct {
+	// Versions are the module versions in the columns of the table, oldest
+	// first.
+	Versions []string
+	Rows     []*BenchmarkRow
+}
+
+// A BenchmarkRow is the row of a BenchmarkTable for one benchmark and unit.
+type BenchmarkRow struct {
+	Name string
+	Unit string
+	// Values are the formatted values for each version of the table. A value
+	// is empty if the version has no result for the benchmark.
+	Values []string
+	// Change is the relative change between the first and the last value,
+	// such as "-12.5%". It is empty if there are fewer than two values.
+	Change string
+}
+
+// fetchBenchmarkDetails returns the BenchmarkDetails for unit, or nil if
+// the package has neither benchmarks nor results. Results are only stored by
+// the postgres data source.
+func fetchBenchmarkDetails(ctx context.Context, ds internal.DataSource, pr *message.Printer, unit *internal.Unit) (_ *BenchmarkDetails, err error) {
+	defer derrors.Wrap(&err, "fetchBenchmarkDetails(ctx, ds, %q, %q)", unit.Path, unit.Version)
+
+	if !unit.IsPackage() {
+		return nil, nil
+	}
+	d := &BenchmarkDetails{Names: unit.Benchmarks}
+	if db, ok := ds.(*postgres.DB); ok {
+		history, err := db.GetBenchmarkHistory(ctx, unit.Path, unit.ModulePath, unit.Version, maxBenchmarkVersions)
+		if err != nil {
+			return nil, err
+		}
+		d.Table = benchmarkTable(pr, history)
+	}
+	if len(d.Names) == 0 && d.Table == nil {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// benchmarkTable returns the table for history, or nil if history is empty.
+func benchmarkTable(pr *message.Printer, history []*postgres.BenchmarkVersion) *BenchmarkTable {
+	if len(history) == 0 {
+		return nil
+	}
+	type key struct{ name, unit string }
+	var (
+		keys   []key
+		values = map[key][]float64{}
+		has    = map[key][]bool{}
+	)
+	t := &BenchmarkTable{}
+	for i, v := range history {
+		t.Versions = append(t.Versions, v.Version)
+		for _, r := range v.Results {
+			k := key{r.Name, r.Unit}
+			if _, ok := values[k]; !ok {
+				keys = append(keys, k)
+				values[k] = make([]float64, len(history))
+				has[k] = make([]bool, len(history))
+			}
+			values[k][i] = r.Value
+			has[k][i] = true
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].unit < keys[j].unit
+	})
+	for _, k := range keys {
+		row := &BenchmarkRow{Name: k.name, Unit: k.unit}
+		var first, last float64
+		n := 0
+		for i, v := range values[k] {
+			if !has[k][i] {
+				row.Values = append(row.Values, "")
+				continue
+			}
+			row.Values = append(row.Values, formatBenchmarkValue(pr, v))
+			if n == 0 {
+				first = v
+			}
+			last = v
+			n++
+		}
+		if n > 1 && first != 0 {
+			row.Change = fmt.Sprintf("%+.1f%%", (last-first)/first*100)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// formatBenchmarkValue formats a benchmark value with three significant
+// digits, or as an integer with digit grouping if it is large.
+func formatBenchmarkValue(pr *message.Printer, v float64) string {
+	if v >= 1000 || v <= -1000 {
+		return pr.Sprintf("%.0f", v)
+	}
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
+
+// BenchmarkUploadResponse is the JSON response of the /api/v1/benchmarks
+// endpoint.
+type BenchmarkUploadResponse struct {
+	ModulePath    string `json:"modulePath"`
+	Version       string `json:"version"`
+	NumPackages   int    `json:"numPackages"`
+	NumBenchmarks int    `json:"numBenchmarks"`
+	NumResults    int    `json:"numResults"`
+}
+
+// serveBenchmarkAPI handles POST requests for
+// /api/v1/benchmarks?module=<path>&version=<version>.
+// The body is the output of go test -bench, in the Go benchmark format, run
+// on packages of the module version. The results replace any earlier ones for
+// the module version, and are displayed on the pages of their packages.
+// Requests must have an Authorization header with one of the configured
+// bearer tokens.
+func (s *Server) serveBenchmarkAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	if !hasBearerToken(r, s.benchmarkTokens) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		return &serverError{status: http.StatusUnauthorized}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	// Use the query, not the form, so that the body is not parsed as a form.
+	q := r.URL.Query()
+	modulePath := strings.Trim(q.Get("module"), "/")
+	requestedVersion := q.Get("version")
+	if modulePath == "" || requestedVersion == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module or version query parameter"}
+	}
+	if !isSupportedVersion(modulePath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	ctx := r.Context()
+	um, err := db.GetUnitMeta(ctx, modulePath, modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	results, err := parseBenchmarkResults(http.MaxBytesReader(w, r.Body, maxBenchmarkUploadSize))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, responseText: err.Error()}
+	}
+	resp := &BenchmarkUploadResponse{
+		ModulePath: um.ModulePath,
+		Version:    um.Version,
+		NumResults: len(results),
+	}
+	var (
+		pkgs       = map[string]bool{}
+		benchmarks = map[string]bool{}
+	)
+	for _, res := range results {
+		if res.PackagePath != um.ModulePath && !strings.HasPrefix(res.PackagePath, um.ModulePath+"/") {
+			return &serverError{
+				status:       http.StatusBadRequest,
+				responseText: fmt.Sprintf("package %q is not in module %q", res.PackagePath, um.ModulePath),
+			}
+		}
+		pkgs[res.PackagePath] = true
+		benchmarks[res.PackagePath+" "+res.Name] = true
+	}
+	if err := db.UpsertBenchmarkResults(ctx, um.ModulePath, um.Version, results); err != nil {
+		return err
+	}
+	resp.NumPackages = len(pkgs)
+	resp.NumBenchmarks = len(benchmarks)
+	return serveJSON(w, r, resp)
+}
+
+// parseBenchmarkResults reads results in the Go benchmark format from r, as
+// described at https://go.googlesource.com/proposal/+/master/design/14313-benchmark-format.md.
+// The package of a result is given by the last "pkg" configuration line
+// before it. Results for the same package, benchmark and unit are averaged,
+// and the GOMAXPROCS suffix of benchmark names is dropped. Lines that are
+// neither configuration lines nor results are ignored.
+//
+// The results are sorted by package, name and unit.
+func parseBenchmarkResults(r io.Reader) (_ []*postgres.BenchmarkResult, err error) {
+	defer derrors.Wrap(&err, "parseBenchmarkResults")
+
+	type key struct{ pkg, name, unit string }
+	var (
+		pkg  string
+		sums = map[key]*postgres.BenchmarkResult{}
+	)
+	scan := bufio.NewScanner(r)
+	for lineNum := 1; scan.Scan(); lineNum++ {
+		line := scan.Text()
+		if k, v, ok := parseBenchmarkConfigLine(line); ok {
+			if k == "pkg" {
+				pkg = v
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !isBenchmarkName(fields[0]) {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		if pkg == "" {
+			return nil, fmt.Errorf("line %d: benchmark result without a pkg line", lineNum)
+		}
+		if len(fields)%2 != 0 {
+			return nil, fmt.Errorf("line %d: missing unit", lineNum)
+		}
+		name := trimGOMAXPROCS(fields[0])
+		for i := 2; i < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", lineNum, fields[i])
+			}
+			k := key{pkg, name, fields[i+1]}
+			s := sums[k]
+			if s == nil {
+				s = &postgres.BenchmarkResult{PackagePath: k.pkg, Name: k.name, Unit: k.unit}
+				sums[k] = s
+			}
+			s.Value += v
+			s.NumRuns++
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	var results []*postgres.BenchmarkResult
+	for _, s := range sums {
+		s.Value /= float64(s.NumRuns)
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		ri, rj := results[i], results[j]
+		if ri.PackagePath != rj.PackagePath {
+			return ri.PackagePath < rj.PackagePath
+		}
+		if ri.Name != rj.Name {
+			return ri.Name < rj.Name
+		}
+		return ri.Unit < rj.Unit
+	})
+	return results, nil
+}
+
+// parseBenchmarkConfigLine parses a configuration line of the benchmark
+// format, "key: value", where key begins with a lower-case letter and
+// contains no spaces.
+func parseBenchmarkConfigLine(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, ":")
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	if r, _ := utf8.DecodeRuneInString(key); !unicode.IsLower(r) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// isBenchmarkName reports whether s is the name of a benchmark in a result
+// line: "Benchmark" followed by nothing or by a character that is not a
+// lower-case letter.
+func isBenchmarkName(s string) bool {
+	const prefix = "Benchmark"
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// trimGOMAXPROCS removes the "-N" suffix that go test adds to benchmark names
+// when GOMAXPROCS is not 1.
+func trimGOMAXPROCS(name string) string {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 || i == len(name)-1 {
+		return name
+	}
+	if _, err := strconv.Atoi(name[i+1:]); err != nil {
+		return name
+	}
+	return name[:i]
+}
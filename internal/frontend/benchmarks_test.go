@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestParseBenchmarkResults(t *testing.T) {
+	const input = `goos: linux
+goarch: amd64
+pkg: example.com/m/a
+cpu: Some CPU @ 2.00GHz
+BenchmarkDecode-8        	    1000	      1200 ns/op	     512 B/op	       3 allocs/op
+BenchmarkDecode-8        	    1000	      1000 ns/op	     512 B/op	       3 allocs/op
+BenchmarkDecode/small-8  	   50000	        20 ns/op
+Benchmarking is not a result 1 2 3
+PASS
+ok  	example.com/m/a	1.234s
+pkg: example.com/m/b
+BenchmarkEncode 	     100	    5e+03 ns/op
+`
+	got, err := parseBenchmarkResults(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*postgres.BenchmarkResult{
+		{PackagePath: "example.com/m/a", Name: "BenchmarkDecode", Unit: "B/op", Value: 512, NumRuns: 2},
+		{PackagePath: "example.com/m/a", Name: "BenchmarkDecode", Unit: "allocs/op", Value: 3, NumRuns: 2},
+		{PackagePath: "example.com/m/a", Name: "BenchmarkDecode", Unit: "ns/op", Value: 1100, NumRuns: 2},
+		{PackagePath: "example.com/m/a", Name: "BenchmarkDecode/small", Unit: "ns/op", Value: 20, NumRuns: 1},
+		{PackagePath: "example.com/m/b", Name: "BenchmarkEncode", Unit: "ns/op", Value: 5000, NumRuns: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{
+		"BenchmarkA-8 100 10 ns/op\n",
+		"pkg: p\nBenchmarkA-8 100 10\n",
+		"pkg: p\nBenchmarkA-8 100 ten ns/op\n",
+	} {
+		if _, err := parseBenchmarkResults(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: got nil error", bad)
+		}
+	}
+}
+
+func TestTrimGOMAXPROCS(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"BenchmarkA", "BenchmarkA"},
+		{"BenchmarkA-8", "BenchmarkA"},
+		{"BenchmarkA/size=-1-16", "BenchmarkA/size=-1"},
+		{"BenchmarkA/x-y", "BenchmarkA/x-y"},
+		{"BenchmarkA-", "BenchmarkA-"},
+	} {
+		if got := trimGOMAXPROCS(test.in); got != test.want {
+			t.Errorf("trimGOMAXPROCS(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestBenchmarkTable(t *testing.T) {
+	pr := message.NewPrinter(language.English)
+	if got := benchmarkTable(pr, nil); got != nil {
+		t.Errorf("got %+v, want nil", got)
+	}
+	result := func(name, unit string, value float64) *postgres.BenchmarkResult {
+		return &postgres.BenchmarkResult{Name: name, Unit: unit, Value: value, NumRuns: 1}
+	}
+	history := []*postgres.BenchmarkVersion{
+		{Version: "v1.0.0", Results: []*postgres.BenchmarkResult{
+			result("BenchmarkA", "ns/op", 2000),
+			result("BenchmarkB", "ns/op", 12.345),
+		}},
+		{Version: "v1.1.0", Results: []*postgres.BenchmarkResult{
+			result("BenchmarkA", "B/op", 64),
+		}},
+		{Version: "v1.2.0", Results: []*postgres.BenchmarkResult{
+			result("BenchmarkA", "ns/op", 1500),
+			result("BenchmarkA", "B/op", 64),
+		}},
+	}
+	got := benchmarkTable(pr, history)
+	want := &BenchmarkTable{
+		Versions: []string{"v1.0.0", "v1.1.0", "v1.2.0"},
+		Rows: []*BenchmarkRow{
+			{Name: "BenchmarkA", Unit: "B/op", Values: []string{"", "64", "64"}, Change: "+0.0%"},
+			{Name: "BenchmarkA", Unit: "ns/op", Values: []string{"2,000", "", "1,500"}, Change: "-25.0%"},
+			{Name: "BenchmarkB", Unit: "ns/op", Values: []string{"12.3", "", ""}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
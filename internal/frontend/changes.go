@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/template"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/moddiff"
+	"golang.org/x/pkgsite/internal/proxy"
+)
+
+const (
+	// maxChangesZipSize is the size of the largest module zip that the changes
+	// page compares. Both zips are held in memory while they are compared.
+	maxChangesZipSize = 10 * 1024 * 1024
+
+	// maxConcurrentChanges is the number of changes pages that a frontend
+	// instance computes at the same time.
+	maxConcurrentChanges = 4
+
+	// changesTTL is how long a changes page is cached. The contents of a
+	// module version never change, so neither does the page.
+	changesTTL = 24 * time.Hour
+)
+
+// ChangesPage contains the data for the page that shows the changes to the
+// files of a module between two versions.
+type ChangesPage struct {
+	basePage
+
+	ModulePath string
+	From, To   string
+	// FromURL and ToURL are the main pages of the module at From and To.
+	FromURL, ToURL string
+
+	Files                             []*ChangedFile
+	NumAdded, NumRemoved, NumModified int
+}
+
+// A ChangedFile is a file on the changes page.
+type ChangedFile struct {
+	*moddiff.File
+	// ID is the anchor of the file's section.
+	ID safehtml.Identifier
+}
+
+// ProxyZipGetter returns a function that gets module zips from the proxy, for
+// ServerConfig.ModuleZipGetter. It does not download zips that are too large
+// to compare.
+func ProxyZipGetter(prox *proxy.Client) func(context.Context, string, string) (*zip.Reader, error) {
+	return func(ctx context.Context, modulePath, version string) (*zip.Reader, error) {
+		size, err := prox.ZipSize(ctx, modulePath, version)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxChangesZipSize {
+			return nil, fmt.Errorf("%s@%s: zip is %d bytes: %w", modulePath, version, size, derrors.ModuleTooLarge)
+		}
+		return prox.Zip(ctx, modulePath, version)
+	}
+}
+
+// serveChanges serves the changes to a module between two versions. The URL
+// has the form /changes/MODULE_PATH?from=VERSION&to=VERSION.
+func (s *Server) serveChanges(w http.ResponseWriter, r *http.Request, _ internal.DataSource) error {
+	if s.moduleZipGetter == nil {
+		return datasourceNotSupportedErr()
+	}
+	modulePath := strings.TrimPrefix(r.URL.Path, "/changes/")
+	from, to := r.FormValue("from"), r.FormValue("to")
+	if modulePath == "" || !semver.IsValid(from) || !semver.IsValid(to) {
+		return &serverError{
+			status: http.StatusBadRequest,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(`
+					<h3 class="Error-message">Bad request.</h3>
+					<p class="Error-message">The URL should have the form
+					  <code>/changes/MODULE_PATH?from=VERSION&amp;to=VERSION</code>, with
+					  full semantic versions.</p>`),
+			},
+		}
+	}
+
+	// Comparing two versions downloads and reads both zips, so limit how
+	// many comparisons run at once.
+	select {
+	case s.changesSem <- struct{}{}:
+		defer func() { <-s.changesSem }()
+	default:
+		return &serverError{
+			status:       http.StatusServiceUnavailable,
+			responseText: "Too many comparisons are in progress. Please try again later.",
+		}
+	}
+
+	ctx := r.Context()
+	fromMod, err := s.getModuleZip(ctx, modulePath, from)
+	if err != nil {
+		return err
+	}
+	toMod, err := s.getModuleZip(ctx, modulePath, to)
+	if err != nil {
+		return err
+	}
+	files, err := moddiff.Diff(fromMod, toMod)
+	if err != nil {
+		return err
+	}
+	page := newChangesPage(modulePath, from, to, files)
+	page.basePage = s.newBasePage(r, fmt.Sprintf("Changes to %s from %s to %s", modulePath, from, to))
+	s.servePage(ctx, w, "changes", page)
+	return nil
+}
+
+// getModuleZip gets the zip of modulePath at version, and converts errors
+// that the user can do something about into serverErrors.
+func (s *Server) getModuleZip(ctx context.Context, modulePath, version string) (*moddiff.Module, error) {
+	zr, err := s.moduleZipGetter(ctx, modulePath, version)
+	switch {
+	case err == nil:
+		return &moddiff.Module{Path: modulePath, Version: version, Zip: zr}, nil
+	case errors.Is(err, derrors.NotFound), errors.Is(err, derrors.InvalidArgument):
+		return nil, &serverError{
+			status: http.StatusNotFound,
+			err:    err,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(`
+					<h3 class="Error-message">{{.}} could not be found.</h3>`),
+				MessageData: modulePath + "@" + version,
+			},
+		}
+	case errors.Is(err, derrors.ModuleTooLarge):
+		return nil, &serverError{
+			status: http.StatusRequestEntityTooLarge,
+			err:    err,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(`
+					<h3 class="Error-message">{{.}} is too large to compare.</h3>`),
+				MessageData: modulePath + "@" + version,
+			},
+		}
+	default:
+		return nil, err
+	}
+}
+
+func newChangesPage(modulePath, from, to string, files []*moddiff.File) *ChangesPage {
+	page := &ChangesPage{
+		ModulePath: modulePath,
+		From:       from,
+		To:         to,
+		FromURL:    constructUnitURL(modulePath, modulePath, from),
+		ToURL:      constructUnitURL(modulePath, modulePath, to),
+	}
+	for i, f := range files {
+		page.Files = append(page.Files, &ChangedFile{
+			File: f,
+			ID:   safehtml.IdentifierFromConstantPrefix("file", strconv.Itoa(i)),
+		})
+		switch f.Kind {
+		case moddiff.Added:
+			page.NumAdded++
+		case moddiff.Removed:
+			page.NumRemoved++
+		case moddiff.Modified:
+			page.NumModified++
+		}
+	}
+	return page
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+)
+
+func TestServeChanges(t *testing.T) {
+	const modulePath = "example.com/changes"
+	modules := []*proxytest.Module{
+		{
+			ModulePath: modulePath,
+			Version:    "v1.0.0",
+			Files: map[string]string{
+				"go.mod":  "module " + modulePath,
+				"a.go":    "package changes\n\nconst A = 1\n",
+				"old.txt": "old\n",
+			},
+		},
+		{
+			ModulePath: modulePath,
+			Version:    "v1.1.0",
+			Files: map[string]string{
+				"go.mod":  "module " + modulePath,
+				"a.go":    "package changes\n\nconst A = 2\n",
+				"new.txt": "new\n",
+			},
+		},
+	}
+	s, handler, teardown := newTestServer(t, nil, nil)
+	defer teardown()
+	proxyClient, proxyTeardown := proxytest.SetupTestClient(t, modules)
+	defer proxyTeardown()
+	s.moduleZipGetter = ProxyZipGetter(proxyClient)
+
+	for _, test := range []struct {
+		url        string
+		wantStatus int
+		want       []string
+	}{
+		{
+			url:        "/changes/" + modulePath + "?from=v1.0.0&to=v1.1.0",
+			wantStatus: http.StatusOK,
+			want: []string{
+				"1 modified, 1 added and 1 removed files",
+				"a.go", "new.txt", "old.txt",
+				"@@ -1,3 +1,3 @@",
+				"const A = 2",
+			},
+		},
+		{
+			url:        "/changes/" + modulePath + "?from=v1.0.0&to=latest",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			url:        "/changes/" + modulePath + "?from=v1.0.0&to=v2.0.0",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.url, w.Code, test.wantStatus)
+			continue
+		}
+		body := w.Body.String()
+		for _, want := range test.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: body does not contain %q", test.url, want)
+			}
+		}
+	}
+}
+
+func TestServeChangesBusy(t *testing.T) {
+	s, handler, teardown := newTestServer(t, nil, nil)
+	defer teardown()
+	s.moduleZipGetter = func(context.Context, string, string) (*zip.Reader, error) {
+		t.Fatal("zip fetched while all comparisons are in progress")
+		return nil, nil
+	}
+	for i := 0; i < maxConcurrentChanges; i++ {
+		s.changesSem <- struct{}{}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/changes/example.com/m?from=v1.0.0&to=v1.1.0", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/lsif"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// codeIndexSuffix is the suffix of the URL path of the LSIF code index of a
+// directory, as in /example.com/m@v1.0.0/pkg/-/index.lsif.
+const codeIndexSuffix = "/-/index.lsif"
+
+// serveCodeIndex serves the code index of the packages in a directory of a
+// module and its subdirectories, in the Language Server Index Format. Code
+// intelligence platforms can load it to provide navigation for the module
+// without building it. Code indexes are only stored by the postgres data
+// source.
+func (s *Server) serveCodeIndex(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return datasourceNotSupportedErr()
+	}
+	um, err := servableUnit(r, ds, strings.TrimSuffix(r.URL.Path, codeIndexSuffix))
+	if err != nil {
+		return err
+	}
+	indexes, err := db.GetCodeIndexes(r.Context(), um.Path, um.ModulePath, um.Version)
+	if err != nil {
+		return err
+	}
+	var pkgs []*lsif.Package
+	for _, ci := range indexes {
+		pkgs = append(pkgs, &lsif.Package{
+			Path:  ci.Path,
+			Dir:   internal.Suffix(ci.Path, um.ModulePath),
+			Index: ci.Index,
+		})
+	}
+	var buf bytes.Buffer
+	if err := lsif.Write(&buf, um.ModulePath, um.Version, pkgs); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", codeIndexName(um)))
+	http.ServeContent(w, r, "", um.CommitTime, bytes.NewReader(buf.Bytes()))
+	return nil
+}
+
+// codeIndexName returns the file name for the code index of um, such as
+// "pkg-v1.0.0.lsif".
+func codeIndexName(um *internal.UnitMeta) string {
+	return path.Base(um.Path) + "-" + um.Version + ".lsif"
+}
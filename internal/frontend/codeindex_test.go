@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestServeCodeIndex(t *testing.T) {
+	_, handler, teardown := newTestServer(t, nil, nil)
+	defer teardown()
+	proxyClient, proxyTeardown := proxytest.SetupTestClient(t, testModulesForProxy)
+	defer proxyTeardown()
+	ctx := context.Background()
+	if _, err := FetchAndUpdateState(ctx, testModulePath, testSemver, proxyClient, source.NewClient(sourceTimeout), testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/" + testModulePath + "@" + testSemver + codeIndexSuffix, http.StatusOK},
+		{"/" + testModulePath + "/bar/foo" + codeIndexSuffix, http.StatusOK},
+		{"/" + testModulePath + "@" + testSemver + "/nope" + codeIndexSuffix, http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			`"label":"metaData"`,
+			`"uri":"file:///` + testModulePath + "@" + testSemver + `/bar/foo/foo.go"`,
+			`"identifier":"` + testModulePath + `/bar/foo:Foo"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: body does not contain %s", test.path, want)
+			}
+		}
+	}
+}
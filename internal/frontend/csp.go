@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxCSPReportSize is the maximum size of a CSP violation report that is
+// read.
+const maxCSPReportSize = 64 * 1024
+
+// cspReport is the body of a violation report sent by a browser for the
+// report-uri directive of a Content-Security-Policy.
+type cspReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		Disposition        string `json:"disposition"`
+	} `json:"csp-report"`
+}
+
+// serveCSPReport stores a sample of the CSP violation reports posted by
+// browsers. Reports are dropped if the data source is not a database.
+func (s *Server) serveCSPReport(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveCSPReport")
+
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok || rand.Float64() >= s.cspReportSampleRate {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	report, err := parseCSPReport(io.LimitReader(r.Body, maxCSPReportSize))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	if err := db.InsertCSPReport(r.Context(), report); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// parseCSPReport parses a CSP violation report. The query and fragment of the
+// document URI are removed, because they may contain user data.
+func parseCSPReport(r io.Reader) (*postgres.CSPReport, error) {
+	var cr cspReport
+	if err := json.NewDecoder(r).Decode(&cr); err != nil {
+		return nil, err
+	}
+	rep := cr.Report
+	if rep.DocumentURI == "" {
+		return nil, fmt.Errorf("missing document-uri")
+	}
+	docURI := rep.DocumentURI
+	if u, err := url.Parse(docURI); err == nil {
+		u.RawQuery = ""
+		u.Fragment = ""
+		docURI = u.String()
+	}
+	disposition := rep.Disposition
+	if disposition == "" {
+		disposition = "enforce"
+	}
+	return &postgres.CSPReport{
+		DocumentURI:        docURI,
+		ViolatedDirective:  rep.ViolatedDirective,
+		EffectiveDirective: rep.EffectiveDirective,
+		BlockedURI:         rep.BlockedURI,
+		SourceFile:         rep.SourceFile,
+		LineNumber:         rep.LineNumber,
+		ColumnNumber:       rep.ColumnNumber,
+		Disposition:        disposition,
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestParseCSPReport(t *testing.T) {
+	const body = `{
+		"csp-report": {
+			"document-uri": "https://pkg.go.dev/search?q=secret#x",
+			"referrer": "",
+			"violated-directive": "script-src-elem",
+			"effective-directive": "script-src-elem",
+			"original-policy": "object-src 'none'; report-uri /csp-report",
+			"blocked-uri": "https://evil.example.com/x.js",
+			"source-file": "https://pkg.go.dev/static/frontend/frontend.js",
+			"line-number": 10,
+			"column-number": 2,
+			"status-code": 200
+		}
+	}`
+	got, err := parseCSPReport(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &postgres.CSPReport{
+		DocumentURI:        "https://pkg.go.dev/search",
+		ViolatedDirective:  "script-src-elem",
+		EffectiveDirective: "script-src-elem",
+		BlockedURI:         "https://evil.example.com/x.js",
+		SourceFile:         "https://pkg.go.dev/static/frontend/frontend.js",
+		LineNumber:         10,
+		ColumnNumber:       2,
+		Disposition:        "enforce",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{"", "{", `{"csp-report": {}}`} {
+		if _, err := parseCSPReport(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseCSPReport(%q): got nil error, want error", bad)
+		}
+	}
+}
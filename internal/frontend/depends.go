@@ -0,0 +1,162 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxImportChainPackages is the maximum number of packages visited by a
+// search for an import chain between two modules.
+const maxImportChainPackages = 20000
+
+// DependsPage contains the data for the page that tells whether a module
+// depends on another through the imports of its packages.
+type DependsPage struct {
+	basePage
+
+	From, To string
+	// Searched reports whether both modules were given, so that the page
+	// shows the result of a search.
+	Searched bool
+	// Chain is a shortest chain of imports from a package of From to a
+	// package of To. It is empty if there is none.
+	Chain       []*ImportChainLink
+	Truncated   bool
+	NumSearched int
+}
+
+// An ImportChainLink is a package in an import chain.
+type ImportChainLink struct {
+	Path string
+	URL  string
+}
+
+// serveDepends serves the page at /depends?from=MODULE&to=MODULE, which
+// tells whether a package of the first module imports a package of the
+// second, directly or indirectly, and shows the shortest such chain of
+// imports. Without both modules, it shows a form.
+func (s *Server) serveDepends(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return datasourceNotSupportedErr()
+	}
+	page := &DependsPage{
+		From: strings.Trim(r.FormValue("from"), "/"),
+		To:   strings.Trim(r.FormValue("to"), "/"),
+	}
+	page.basePage = s.newBasePage(r, "Module dependencies")
+	ctx := r.Context()
+	if page.From != "" && page.To != "" {
+		chain, err := importChain(ctx, db, page.From, page.To)
+		if err != nil {
+			var serr *serverError
+			if errors.As(err, &serr) {
+				serr.epage = &errorPage{
+					messageTemplate: template.MakeTrustedTemplate(`
+						<h3 class="Error-message">{{.}}</h3>`),
+					MessageData: serr.responseText,
+				}
+			}
+			return err
+		}
+		page.Searched = true
+		page.Truncated = chain.Truncated
+		page.NumSearched = chain.NumSearched
+		for _, p := range chain.Packages {
+			page.Chain = append(page.Chain, &ImportChainLink{Path: p, URL: "/" + p})
+		}
+		page.basePage.HTMLTitle = fmt.Sprintf("Does %s depend on %s?", page.From, page.To)
+	}
+	s.servePage(ctx, w, "depends", page)
+	return nil
+}
+
+// DependsResponse is the JSON response of the /api/v1/depends endpoint.
+type DependsResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Depends reports whether a package of From imports a package of To,
+	// directly or indirectly.
+	Depends bool `json:"depends"`
+	// Chain is a shortest chain of imports from a package of From to a
+	// package of To, each package importing the next.
+	Chain []string `json:"chain,omitempty"`
+	// Truncated reports whether the search stopped before visiting all the
+	// packages imported by From. If so, Depends is false but the answer is
+	// unknown.
+	Truncated   bool `json:"truncated"`
+	NumSearched int  `json:"numSearched"`
+}
+
+// serveDependsAPI handles requests for /api/v1/depends?from=<module>&to=<module>.
+// It reports whether a package of the first module imports a package of the
+// second, directly or indirectly, and returns the shortest such chain of
+// imports.
+func (s *Server) serveDependsAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	from := strings.Trim(r.FormValue("from"), "/")
+	to := strings.Trim(r.FormValue("to"), "/")
+	if from == "" || to == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing from or to query parameter"}
+	}
+	chain, err := importChain(r.Context(), db, from, to)
+	if err != nil {
+		return err
+	}
+	return serveJSON(w, r, &DependsResponse{
+		From:        from,
+		To:          to,
+		Depends:     len(chain.Packages) > 0,
+		Chain:       chain.Packages,
+		Truncated:   chain.Truncated,
+		NumSearched: chain.NumSearched,
+	})
+}
+
+// importChain returns the shortest chain of imports from a package of the
+// module from to a package of the module to. Errors that the user can do
+// something about are serverErrors with a response text.
+func importChain(ctx context.Context, db *postgres.DB, from, to string) (*postgres.ImportChain, error) {
+	if from == to {
+		return nil, &serverError{status: http.StatusBadRequest, responseText: "the modules must be different"}
+	}
+	notFound := &serverError{
+		status:       http.StatusNotFound,
+		responseText: fmt.Sprintf("%s or %s could not be found", from, to),
+	}
+	for _, m := range []string{from, to} {
+		if err := checkExcluded(ctx, db, m); err != nil {
+			if errors.As(err, new(*serverError)) {
+				return nil, notFound
+			}
+			return nil, err
+		}
+	}
+	chain, err := db.ShortestImportChain(ctx, from, to, maxImportChainPackages)
+	if errors.Is(err, derrors.NotFound) {
+		notFound.err = err
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
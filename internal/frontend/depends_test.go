@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestDepends(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	imports := map[string][]string{
+		"example.com/a/p": {"example.com/b/q"},
+		"example.com/b/q": {"example.com/c/r"},
+	}
+	for _, m := range []*internal.Module{
+		sample.Module("example.com/a", "v1.0.0", "p"),
+		sample.Module("example.com/b", "v1.0.0", "q"),
+		sample.Module("example.com/c", "v1.0.0", "r"),
+	} {
+		for _, u := range m.Packages() {
+			u.Imports = imports[u.Path]
+		}
+		postgres.MustInsertModule(ctx, t, testDB, m)
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	for _, test := range []struct {
+		query      string
+		wantStatus int
+		want       *DependsResponse
+	}{
+		{
+			query:      "from=example.com/a&to=example.com/c",
+			wantStatus: http.StatusOK,
+			want: &DependsResponse{
+				From:        "example.com/a",
+				To:          "example.com/c",
+				Depends:     true,
+				Chain:       []string{"example.com/a/p", "example.com/b/q", "example.com/c/r"},
+				NumSearched: 3,
+			},
+		},
+		{
+			query:      "from=example.com/c&to=example.com/a",
+			wantStatus: http.StatusOK,
+			want:       &DependsResponse{From: "example.com/c", To: "example.com/a"},
+		},
+		{query: "from=example.com/a&to=example.com/missing", wantStatus: http.StatusNotFound},
+		{query: "from=example.com/a&to=example.com/a", wantStatus: http.StatusBadRequest},
+		{query: "from=example.com/a", wantStatus: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/depends?"+test.query, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.query, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		var got DependsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, &got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/depends?from=example.com/a&to=example.com/c", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `<a href="/example.com/b/q">example.com/b/q</a>`) {
+		t.Errorf("page does not contain the import chain:\n%s", body)
+	}
+}
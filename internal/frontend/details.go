@@ -18,6 +18,7 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/version"
 )
 
 // serveDetails handles requests for package/directory/module details pages. It
@@ -48,6 +49,13 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 		ctx = setExperimentsFromQueryParam(ctx, r)
 	}
 
+	if urlPath, ok := unescapeURLPath(r.URL.Path); ok {
+		url := *r.URL
+		url.Path = urlPath
+		http.Redirect(w, r, url.String(), http.StatusMovedPermanently)
+		return
+	}
+
 	urlInfo, err := extractURLPathInfo(r.URL.Path)
 	if err != nil {
 		var epage *errorPage
@@ -70,6 +78,9 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 	if err := checkExcluded(ctx, ds, urlInfo.fullPath); err != nil {
 		return err
 	}
+	if urlInfo.requestedVersion == version.Recommended {
+		return s.serveRecommendedRedirect(ctx, w, r, ds, urlInfo)
+	}
 	return s.serveUnitPage(ctx, w, r, ds, urlInfo)
 }
 
@@ -0,0 +1,285 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// tabDoc is the name of the main tab of a unit page in the details API.
+const tabDoc = "doc"
+
+// DetailsResponse is the JSON response of the /api/v1/details endpoint. It
+// holds the data of one tab of a unit page: only the field for the requested
+// tab is set.
+type DetailsResponse struct {
+	Path       string                 `json:"path"`
+	ModulePath string                 `json:"modulePath"`
+	Version    string                 `json:"version"`
+	Tab        string                 `json:"tab"`
+	Doc        *DocTabResponse        `json:"doc,omitempty"`
+	Imports    *ImportsTabResponse    `json:"imports,omitempty"`
+	ImportedBy *ImportedByTabResponse `json:"importedBy,omitempty"`
+	Versions   *VersionsTabResponse   `json:"versions,omitempty"`
+	Licenses   *LicensesTabResponse   `json:"licenses,omitempty"`
+}
+
+// DocTabResponse is the data of the main tab of a unit page.
+type DocTabResponse struct {
+	// Name is the package name, or empty if the unit is not a package.
+	Name              string `json:"name,omitempty"`
+	Synopsis          string `json:"synopsis,omitempty"`
+	IsRedistributable bool   `json:"isRedistributable"`
+	// BuildContexts are the GOOS/GOARCH pairs for which the package has
+	// documentation, such as "linux/amd64", or "all/all" if it is the same
+	// for all of them.
+	BuildContexts []string `json:"buildContexts,omitempty"`
+	// Documentation is the documentation of the package for the requested
+	// build context, as plain text in the style of go doc.
+	Documentation string `json:"documentation,omitempty"`
+	// Readme is the unrendered README file of the unit, if any.
+	Readme *ReadmeResponse `json:"readme,omitempty"`
+}
+
+// ReadmeResponse is a README file in a DocTabResponse.
+type ReadmeResponse struct {
+	FilePath string `json:"filePath"`
+	Contents string `json:"contents"`
+}
+
+// ImportsTabResponse lists the imports of a package.
+type ImportsTabResponse struct {
+	// Std are the imported packages of the standard library.
+	Std []string `json:"std"`
+	// Internal are the imported packages of the same module.
+	Internal []string `json:"internal"`
+	// External are the other imported packages.
+	External []string `json:"external"`
+}
+
+// ImportedByTabResponse lists a page of the packages of other modules that
+// import a package.
+type ImportedByTabResponse struct {
+	// Total is the number of importers, which may be more than the number
+	// of packages listed.
+	Total      int      `json:"total"`
+	ImportedBy []string `json:"importedBy"`
+	// NextPage is the number of the next page of importers, or zero if
+	// this is the last page.
+	NextPage int `json:"nextPage,omitempty"`
+}
+
+// VersionsTabResponse lists the versions of the modules that contain a unit,
+// in descending semver order.
+type VersionsTabResponse struct {
+	Versions []*VersionResponse `json:"versions"`
+}
+
+// VersionResponse is a module version in a VersionsTabResponse.
+type VersionResponse struct {
+	ModulePath          string    `json:"modulePath"`
+	Version             string    `json:"version"`
+	CommitTime          time.Time `json:"commitTime"`
+	Retracted           bool      `json:"retracted,omitempty"`
+	RetractionRationale string    `json:"retractionRationale,omitempty"`
+}
+
+// LicensesTabResponse holds the licenses that apply to a unit.
+type LicensesTabResponse struct {
+	Licenses []*LicenseResponse `json:"licenses"`
+}
+
+// LicenseResponse is a license file in a LicensesTabResponse.
+type LicenseResponse struct {
+	FilePath string   `json:"filePath"`
+	Types    []string `json:"types"`
+	Contents string   `json:"contents"`
+}
+
+// serveDetailsAPI handles requests for
+// /api/v1/details?path=<path>[&version=<version>][&tab=<tab>][&GOOS=<goos>&GOARCH=<goarch>].
+// It returns the data shown on a tab of the page of the unit at the given
+// version, or the latest version if none is provided. The tab is one of doc
+// (the default), imports, importedby, versions and licenses. The importedby
+// tab is paginated with the page and limit query parameters.
+func (s *Server) serveDetailsAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	fullPath := strings.Trim(r.FormValue("path"), "/")
+	if fullPath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing path query parameter"}
+	}
+	requestedVersion := r.FormValue("version")
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if !isSupportedVersion(fullPath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	tab := r.FormValue("tab")
+	if tab == "" {
+		tab = tabDoc
+	}
+	pageTab := tab
+	switch tab {
+	case tabDoc:
+		pageTab = tabMain
+	case tabImports, tabImportedBy, tabVersions, tabLicenses:
+	default:
+		return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("unknown tab %q", tab)}
+	}
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, fullPath); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, fullPath, internal.UnknownModulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	if !isValidTabForUnit(pageTab, um) {
+		return &serverError{status: http.StatusNotFound, responseText: fmt.Sprintf("tab %q is not available for %s", tab, um.Path)}
+	}
+	resp := &DetailsResponse{
+		Path:       um.Path,
+		ModulePath: um.ModulePath,
+		Version:    um.Version,
+		Tab:        tab,
+	}
+	switch tab {
+	case tabDoc:
+		bc := internal.BuildContext{GOOS: r.FormValue("GOOS"), GOARCH: r.FormValue("GOARCH")}
+		resp.Doc, err = docTabResponse(ctx, ds, um, bc)
+	case tabImports:
+		resp.Imports, err = importsTabResponse(ctx, ds, um)
+	case tabImportedBy:
+		resp.ImportedBy, err = importedByTabResponse(ctx, ds, um, newPaginationParams(r, defaultImportedByLimit))
+	case tabVersions:
+		resp.Versions, err = versionsTabResponse(ctx, ds, um)
+	case tabLicenses:
+		resp.Licenses, err = licensesTabResponse(ctx, ds, um)
+	}
+	if err != nil {
+		return err
+	}
+	return serveJSON(w, r, resp)
+}
+
+func docTabResponse(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, bc internal.BuildContext) (*DocTabResponse, error) {
+	unit, err := ds.GetUnit(ctx, um, internal.WithMain, bc)
+	if err != nil {
+		return nil, err
+	}
+	resp := &DocTabResponse{
+		Name:              unit.Name,
+		IsRedistributable: unit.IsRedistributable,
+	}
+	for _, b := range unit.BuildContexts {
+		resp.BuildContexts = append(resp.BuildContexts, b.GOOS+"/"+b.GOARCH)
+	}
+	unit.Documentation = cleanDocumentation(unit.Documentation)
+	if len(unit.Documentation) > 0 {
+		resp.Synopsis = unit.Documentation[0].Synopsis
+		text, err := godoc.RenderTextFromUnit(ctx, unit, "")
+		if err != nil {
+			return nil, err
+		}
+		resp.Documentation = string(text)
+	}
+	if unit.Readme != nil {
+		resp.Readme = &ReadmeResponse{FilePath: unit.Readme.Filepath, Contents: unit.Readme.Contents}
+	}
+	return resp, nil
+}
+
+func importsTabResponse(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*ImportsTabResponse, error) {
+	d, err := fetchImportsDetails(ctx, ds, um.Path, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportsTabResponse{
+		Std:      nonNil(d.StdLib),
+		Internal: nonNil(d.InternalImports),
+		External: nonNil(d.ExternalImports),
+	}, nil
+}
+
+func importedByTabResponse(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, params paginationParams) (*ImportedByTabResponse, error) {
+	if _, ok := ds.(*postgres.DB); !ok {
+		return nil, &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	d, err := fetchImportedByDetails(ctx, ds, um.Path, um.ModulePath, params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ImportedByTabResponse{Total: d.Total, ImportedBy: []string{}, NextPage: d.Pagination.NextPage}
+	var add func([]*Section)
+	add = func(sections []*Section) {
+		for _, s := range sections {
+			if s.Subs == nil {
+				resp.ImportedBy = append(resp.ImportedBy, s.Prefix)
+			}
+			add(s.Subs)
+		}
+	}
+	add(d.ImportedBy)
+	return resp, nil
+}
+
+func versionsTabResponse(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*VersionsTabResponse, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil, &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	mis, err := db.GetVersionsForPath(ctx, um.Path)
+	if err != nil {
+		return nil, err
+	}
+	resp := &VersionsTabResponse{Versions: []*VersionResponse{}}
+	for _, mi := range mis {
+		resp.Versions = append(resp.Versions, &VersionResponse{
+			ModulePath:          mi.ModulePath,
+			Version:             mi.Version,
+			CommitTime:          mi.CommitTime,
+			Retracted:           mi.Retracted,
+			RetractionRationale: mi.RetractionRationale,
+		})
+	}
+	return resp, nil
+}
+
+func licensesTabResponse(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*LicensesTabResponse, error) {
+	d, err := fetchLicensesDetails(ctx, ds, um)
+	if err != nil {
+		return nil, err
+	}
+	resp := &LicensesTabResponse{Licenses: []*LicenseResponse{}}
+	for _, l := range d.Licenses {
+		resp.Licenses = append(resp.Licenses, &LicenseResponse{
+			FilePath: l.FilePath,
+			Types:    nonNil(l.Types),
+			Contents: string(l.Contents),
+		})
+	}
+	return resp, nil
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that it is encoded as
+// an empty JSON array rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestDetailsAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, v := range []string{"v1.0.0", "v1.2.3"} {
+		postgres.MustInsertModule(ctx, t, testDB, sample.Module(sample.ModulePath, v, "A"))
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	pkgPath := sample.ModulePath + "/A"
+	for _, test := range []struct {
+		query      string
+		wantStatus int
+		want       *DetailsResponse
+	}{
+		{
+			query:      "path=" + pkgPath + "&tab=imports",
+			wantStatus: http.StatusOK,
+			want: &DetailsResponse{
+				Path:       pkgPath,
+				ModulePath: sample.ModulePath,
+				Version:    "v1.2.3",
+				Tab:        "imports",
+				Imports: &ImportsTabResponse{
+					Std:      []string{"fmt"},
+					Internal: []string{},
+					External: []string{"path/to/bar"},
+				},
+			},
+		},
+		{
+			query:      "path=" + pkgPath + "&tab=importedby",
+			wantStatus: http.StatusOK,
+			want: &DetailsResponse{
+				Path:       pkgPath,
+				ModulePath: sample.ModulePath,
+				Version:    "v1.2.3",
+				Tab:        "importedby",
+				ImportedBy: &ImportedByTabResponse{ImportedBy: []string{}},
+			},
+		},
+		{
+			query:      "path=" + pkgPath + "&tab=versions",
+			wantStatus: http.StatusOK,
+			want: &DetailsResponse{
+				Path:       pkgPath,
+				ModulePath: sample.ModulePath,
+				Version:    "v1.2.3",
+				Tab:        "versions",
+				Versions: &VersionsTabResponse{Versions: []*VersionResponse{
+					{ModulePath: sample.ModulePath, Version: "v1.2.3"},
+					{ModulePath: sample.ModulePath, Version: "v1.0.0"},
+				}},
+			},
+		},
+		{
+			query:      "path=" + sample.ModulePath + "&version=v1.0.0&tab=licenses",
+			wantStatus: http.StatusOK,
+			want: &DetailsResponse{
+				Path:       sample.ModulePath,
+				ModulePath: sample.ModulePath,
+				Version:    "v1.0.0",
+				Tab:        "licenses",
+				Licenses: &LicensesTabResponse{Licenses: []*LicenseResponse{
+					{FilePath: sample.LicenseFilePath, Types: []string{sample.LicenseType}},
+				}},
+			},
+		},
+		{
+			query:      "path=" + sample.ModulePath + "&tab=imports",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			query:      "path=" + pkgPath + "&tab=overview",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			query:      "path=" + pkgPath + "&version=v9.9.9",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			query:      "tab=doc",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/details?"+test.query, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.query, w.Code, test.wantStatus)
+			continue
+		}
+		if test.want == nil {
+			continue
+		}
+		got := &DetailsResponse{}
+		if err := json.NewDecoder(w.Body).Decode(got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got,
+			cmpopts.IgnoreFields(VersionResponse{}, "CommitTime"),
+			cmpopts.IgnoreFields(LicenseResponse{}, "Contents")); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+
+	t.Run("doc", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/details?path="+pkgPath, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		var got DetailsResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Tab != "doc" || got.Doc == nil {
+			t.Fatalf("got tab %q, doc %v; want doc tab", got.Tab, got.Doc)
+		}
+		if got.Doc.Name != "A" || got.Doc.Synopsis != sample.Doc.Synopsis || !got.Doc.IsRedistributable {
+			t.Errorf("got name %q, synopsis %q, redistributable %t", got.Doc.Name, got.Doc.Synopsis, got.Doc.IsRedistributable)
+		}
+		if want := "package p // import \"" + pkgPath + "\"\n"; !strings.HasPrefix(got.Doc.Documentation, want) {
+			t.Errorf("got documentation %q, want prefix %q", got.Doc.Documentation, want)
+		}
+	})
+}
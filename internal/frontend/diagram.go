@@ -0,0 +1,139 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/uncheckedconversions"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/pkgsite/internal/diagram"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// diagramFunc renders the source of a diagram of the given kind to SVG.
+type diagramFunc func(ctx context.Context, kind, source string) ([]byte, error)
+
+// maxDiagrams is the maximum number of diagrams rendered in one document.
+// The others are displayed as code.
+const maxDiagrams = 20
+
+// diagramRenderer is a renderer.NodeRenderer that renders fenced code blocks
+// holding diagrams, such as Mermaid or PlantUML, as images.
+//
+// The rendered SVG cannot go through the HTML sanitizer, so the renderer
+// writes a placeholder with a random nonce for each diagram instead, and
+// insertDiagrams replaces the placeholders after sanitization. The SVG is
+// displayed as an image with a data URL, in which browsers do not run
+// scripts or load external resources.
+type diagramRenderer struct {
+	ctx    context.Context
+	render diagramFunc
+	nonce  string
+	// codeBlock renders fenced code blocks that are not diagrams.
+	codeBlock renderer.NodeRendererFunc
+	// diagrams holds the rendered diagrams, in order.
+	diagrams []*renderedDiagram
+	// failed is set after a diagram fails to render, so that a document
+	// with many diagrams is not slowed down if the renderer is unavailable.
+	failed bool
+	// placeholders are the nodes rendered as placeholders.
+	placeholders map[ast.Node]bool
+}
+
+type renderedDiagram struct {
+	kind string
+	svg  []byte
+}
+
+// newDiagramRenderer returns a diagramRenderer that renders diagrams with
+// render.
+func newDiagramRenderer(ctx context.Context, render diagramFunc) (*diagramRenderer, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	funcs := nodeRendererFuncs{}
+	html.NewRenderer().RegisterFuncs(funcs)
+	return &diagramRenderer{
+		ctx:          ctx,
+		render:       render,
+		nonce:        hex.EncodeToString(b),
+		codeBlock:    funcs[ast.KindFencedCodeBlock],
+		placeholders: map[ast.Node]bool{},
+	}, nil
+}
+
+// nodeRendererFuncs collects the functions registered by a NodeRenderer.
+type nodeRendererFuncs map[ast.NodeKind]renderer.NodeRendererFunc
+
+func (f nodeRendererFuncs) Register(kind ast.NodeKind, fn renderer.NodeRendererFunc) {
+	f[kind] = fn
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *diagramRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		if r.placeholders[node] {
+			return ast.WalkContinue, nil
+		}
+		return r.codeBlock(w, source, node, entering)
+	}
+	n := node.(*ast.FencedCodeBlock)
+	kind := diagram.Kind(string(n.Language(source)))
+	if kind == "" || r.failed || len(r.diagrams) >= maxDiagrams {
+		return r.codeBlock(w, source, node, entering)
+	}
+	var src bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		src.Write(line.Value(source))
+	}
+	svg, err := r.render(r.ctx, kind, src.String())
+	if err != nil {
+		log.Debugf(r.ctx, "rendering %s diagram: %v", kind, err)
+		r.failed = true
+		return r.codeBlock(w, source, node, entering)
+	}
+	r.placeholders[node] = true
+	_, _ = fmt.Fprintf(w, "<p>%s</p>\n", r.placeholder(len(r.diagrams)))
+	r.diagrams = append(r.diagrams, &renderedDiagram{kind: kind, svg: svg})
+	return ast.WalkSkipChildren, nil
+}
+
+// placeholder returns the placeholder for the i'th diagram.
+func (r *diagramRenderer) placeholder(i int) string {
+	return fmt.Sprintf("pkgsite-diagram-%d-%s", i, r.nonce)
+}
+
+// insertDiagrams replaces the placeholders in h, which has been sanitized,
+// with the rendered diagrams.
+func (r *diagramRenderer) insertDiagrams(h safehtml.HTML) safehtml.HTML {
+	if len(r.diagrams) == 0 {
+		return h
+	}
+	var pairs []string
+	for i, d := range r.diagrams {
+		img := fmt.Sprintf(`<img class="Overview-diagram" alt="%s diagram" src="data:image/svg+xml;base64,%s">`,
+			d.kind, base64.StdEncoding.EncodeToString(d.svg))
+		pairs = append(pairs, r.placeholder(i), img)
+	}
+	s := strings.NewReplacer(pairs...).Replace(h.String())
+	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(s)
+}
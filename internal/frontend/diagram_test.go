@@ -0,0 +1,75 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestProcessMarkdownDiagrams(t *testing.T) {
+	ctx := context.Background()
+	const svg = `<svg><script>alert(1)</script></svg>`
+	var rendered []string
+	renderDiagram := func(_ context.Context, kind, source string) ([]byte, error) {
+		if strings.Contains(source, "bad") {
+			return nil, errors.New("syntax error")
+		}
+		rendered = append(rendered, kind+": "+source)
+		return []byte(svg), nil
+	}
+	readme := &internal.Readme{
+		Filepath: "README.md",
+		Contents: "# Title\n\n" +
+			"```mermaid\ngraph TD; A-->B\n```\n\n" +
+			"```go\nfunc main() {}\n```\n\n" +
+			"```puml\nAlice -> Bob\n```\n",
+	}
+	got, err := processMarkdown(ctx, readme, nil, markdownOptions{renderDiagram: renderDiagram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := got.HTML.String()
+	img := `<img class="Overview-diagram" alt="mermaid diagram" src="data:image/svg+xml;base64,` +
+		base64.StdEncoding.EncodeToString([]byte(svg)) + `">`
+	for _, want := range []string{img, `alt="plantuml diagram"`, "<pre><code>func main() {}"} {
+		if !strings.Contains(h, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, h)
+		}
+	}
+	if strings.Contains(h, "<script>") || strings.Contains(h, "pkgsite-diagram") {
+		t.Errorf("HTML contains a script or placeholder:\n%s", h)
+	}
+	wantRendered := []string{"mermaid: graph TD; A-->B\n", "plantuml: Alice -> Bob\n"}
+	if strings.Join(rendered, "|") != strings.Join(wantRendered, "|") {
+		t.Errorf("rendered %q, want %q", rendered, wantRendered)
+	}
+
+	// After a failure, diagrams are displayed as code.
+	rendered = nil
+	readme.Contents = "```mermaid\nbad\n```\n\n```mermaid\ngraph TD; A-->B\n```\n"
+	got, err = processMarkdown(ctx, readme, nil, markdownOptions{renderDiagram: renderDiagram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = got.HTML.String()
+	if !strings.Contains(h, "<pre><code>bad") || strings.Contains(h, "<img") || len(rendered) != 0 {
+		t.Errorf("after a failure, got HTML:\n%s", h)
+	}
+
+	// Without a renderer, diagrams are displayed as code.
+	got, err = processMarkdown(ctx, readme, nil, markdownOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := got.HTML.String(); strings.Contains(h, "<img") {
+		t.Errorf("without a renderer, got HTML:\n%s", h)
+	}
+}
@@ -22,8 +22,28 @@ type Directories struct {
 
 	// Internal contains the top level internal directory for the unit, if any.
 	Internal *Directory
+
+	// HasNestedModules reports whether any of the listed directories is a
+	// nested module.
+	HasNestedModules bool
+
+	// ShowInternal reports whether internal directories below the top level
+	// are listed.
+	ShowInternal bool
+
+	// NumNestedInternal is the number of internal directories below the top
+	// level, whether or not they are listed.
+	NumNestedInternal int
+
+	// InternalToggleURL is the URL of the page with the listing of internal
+	// directories below the top level toggled.
+	InternalToggleURL string
 }
 
+// showInternalParam is the query parameter that causes internal directories
+// below the top level to be listed in the directories section.
+const showInternalParam = "internal"
+
 // Directory is either a nested module or subdirectory of a unit, organized in
 // a two level tree structure. This content is used in the
 // directories section of the unit page.
@@ -49,24 +69,37 @@ type DirectoryInfo struct {
 }
 
 // unitDirectories zips the subdirectories and nested modules together in a two
-// level tree hierarchy.
-func unitDirectories(directories []*DirectoryInfo) *Directories {
+// level tree hierarchy. Internal directories that are not in the top level
+// internal directory are omitted unless showInternal is true, in which case
+// the links to the directories also show them.
+func unitDirectories(directories []*DirectoryInfo, showInternal bool) *Directories {
 	if len(directories) == 0 {
 		return nil
 	}
 	// Organize the subdirectories into a two level tree hierarchy. The first part of
 	// the unit path suffix for a subdirectory becomes the prefix under which matching
 	// subdirectories are grouped.
+	section := &Directories{ShowInternal: showInternal}
 	mappedDirs := make(map[string]*Directory)
 	for _, d := range directories {
 		prefix, _, _ := strings.Cut(d.Suffix, "/")
 
 		// Skip internal directories that are not in the top level internal
-		// directory. For example, foo/internal and foo/internal/bar should
-		// be skipped, but internal/foo should be included.
+		// directory, unless asked to show them. For example, foo/internal
+		// and foo/internal/bar are skipped by default, but internal/foo is
+		// always included.
 		if prefix != "internal" && (strings.HasSuffix(d.Suffix, "/internal") ||
 			strings.Contains(d.Suffix, "/internal/")) {
-			continue
+			section.NumNestedInternal++
+			if !showInternal {
+				continue
+			}
+		}
+		if showInternal && !d.IsModule {
+			d.URL += "?" + showInternalParam
+		}
+		if d.IsModule {
+			section.HasNestedModules = true
 		}
 		if _, ok := mappedDirs[prefix]; !ok {
 			mappedDirs[prefix] = &Directory{Prefix: prefix}
@@ -79,7 +112,6 @@ func unitDirectories(directories []*DirectoryInfo) *Directories {
 		}
 	}
 
-	section := &Directories{}
 	for prefix, dir := range mappedDirs {
 		if prefix == "internal" {
 			section.Internal = dir
@@ -137,7 +137,7 @@ func TestUnitDirectories(t *testing.T) {
 		{Suffix: "httptest", IsModule: true},
 		{Suffix: "pubsub/internal", IsModule: true},
 	}
-	got := unitDirectories(append(subdirectories, nestedModules...))
+	got := unitDirectories(append(subdirectories, nestedModules...), false)
 	want := &Directories{
 		External: []*Directory{
 			{
@@ -172,9 +172,52 @@ func TestUnitDirectories(t *testing.T) {
 				{Suffix: "json"},
 			},
 		},
+		HasNestedModules:  true,
+		NumNestedInternal: 3,
 	}
 
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("unitDirectories mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestUnitDirectoriesShowInternal(t *testing.T) {
+	directories := []*DirectoryInfo{
+		{Suffix: "a", URL: "/m/a"},
+		{Suffix: "a/internal", URL: "/m/a/internal"},
+		{Suffix: "a/internal/b", URL: "/m/a/internal/b"},
+		{Suffix: "c/internal", URL: "/m/c/internal", IsModule: true},
+		{Suffix: "internal/d", URL: "/m/internal/d"},
+	}
+	got := unitDirectories(directories, true)
+	want := &Directories{
+		External: []*Directory{
+			{
+				Prefix: "a",
+				Root:   &DirectoryInfo{Suffix: "a", URL: "/m/a?internal"},
+				Subdirectories: []*DirectoryInfo{
+					{Suffix: "internal", URL: "/m/a/internal?internal"},
+					{Suffix: "internal/b", URL: "/m/a/internal/b?internal"},
+				},
+			},
+			{
+				Prefix: "c",
+				Subdirectories: []*DirectoryInfo{
+					{Suffix: "internal", URL: "/m/c/internal", IsModule: true},
+				},
+			},
+		},
+		Internal: &Directory{
+			Prefix: "internal",
+			Subdirectories: []*DirectoryInfo{
+				{Suffix: "d", URL: "/m/internal/d?internal"},
+			},
+		},
+		HasNestedModules:  true,
+		ShowInternal:      true,
+		NumNestedInternal: 3,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/godoc"
@@ -21,7 +23,8 @@ import (
 )
 
 func renderDocParts(ctx context.Context, u *internal.Unit, docPkg *godoc.Package,
-	nameToVersion map[string]string, bc internal.BuildContext) (_ *dochtml.Parts, err error) {
+	nameToVersion map[string]string, symbolVulns map[string][]dochtml.SymbolVuln,
+	bc internal.BuildContext) (_ *dochtml.Parts, err error) {
 	defer derrors.Wrap(&err, "renderDocParts")
 	defer middleware.ElapsedStat(ctx, "renderDocParts")()
 
@@ -36,10 +39,11 @@ func renderDocParts(ctx context.Context, u *internal.Unit, docPkg *godoc.Package
 	} else if u.Path != u.ModulePath {
 		innerPath = u.Path[len(u.ModulePath)+1:]
 	}
-	return docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, nameToVersion, bc)
+	return docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, nameToVersion, symbolVulns, bc)
 }
 
-// sourceFiles returns the .go files for a package.
+// sourceFiles returns the .go files for a package, along with the assembly
+// files that implement its functions.
 func sourceFiles(u *internal.Unit, docPkg *godoc.Package) []*File {
 	var files []*File
 	for _, f := range docPkg.Files {
@@ -51,10 +55,32 @@ func sourceFiles(u *internal.Unit, docPkg *godoc.Package) []*File {
 			URL:  u.SourceInfo.FileURL(path.Join(internal.Suffix(u.Path, u.ModulePath), f.Name)),
 		})
 	}
+	for _, f := range docPkg.AsmFiles {
+		files = append(files, &File{
+			Name: f.Name,
+			URL:  u.SourceInfo.FileURL(path.Join(internal.Suffix(u.Path, u.ModulePath), f.Name)),
+		})
+	}
 	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
 	return files
 }
 
+// packageEmbeddedFiles returns the files embedded in a package, with the IDs
+// used by the documentation to link to them.
+func packageEmbeddedFiles(u *internal.Unit, docPkg *godoc.Package) []*EmbeddedFile {
+	var files []*EmbeddedFile
+	for i, name := range docPkg.EmbeddedFiles() {
+		files = append(files, &EmbeddedFile{
+			File: File{
+				Name: name,
+				URL:  u.SourceInfo.FileURL(path.Join(internal.Suffix(u.Path, u.ModulePath), name)),
+			},
+			ID: safehtml.IdentifierFromConstantPrefix(godoc.EmbeddedFileIDPrefix, strconv.Itoa(i)),
+		})
+	}
+	return files
+}
+
 // fileSource returns the original filepath in the module zip where the given
 // filePath can be found. For std, the corresponding URL in
 // go.google.source.com/go is returned.
@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// ExamplesDetails contains the data for the Examples tab of a package page.
+type ExamplesDetails struct {
+	Examples []*ExampleDetails
+}
+
+// ExampleDetails is an example shown on the Examples tab.
+type ExampleDetails struct {
+	*internal.Example
+	// ID is the fragment identifier of the example on the page.
+	ID string
+	// Label is the name of the example for display, such as "Client.Do" for
+	// ExampleClient_Do.
+	Label string
+}
+
+// fetchExamplesDetails returns the ExamplesDetails of the package described
+// by um.
+func fetchExamplesDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*ExamplesDetails, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		// Other data sources do not store the examples of packages.
+		return nil, datasourceNotSupportedErr()
+	}
+	exs, err := db.GetExamples(ctx, um.Path, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	d := &ExamplesDetails{}
+	for _, ex := range exs {
+		d.Examples = append(d.Examples, &ExampleDetails{
+			Example: ex,
+			ID:      "example-" + strings.TrimPrefix(strings.TrimPrefix(ex.Name, "Example"), "_"),
+			Label:   exampleLabel(ex.Name),
+		})
+	}
+	return d, nil
+}
+
+// exampleLabel returns the display name of the example function name,
+// following the naming convention of the testing package: "Example" and
+// "Example_suffix" are examples of the package, "ExampleF" of a function or
+// type F, and "ExampleT_M" of a method M of T. A suffix starting with a
+// lower-case letter is shown in parentheses.
+func exampleLabel(name string) string {
+	id := strings.TrimPrefix(name, "Example")
+	var suffix string
+	if i := strings.LastIndexByte(id, '_'); i >= 0 {
+		if r, _ := utf8.DecodeRuneInString(id[i+1:]); unicode.IsLower(r) {
+			id, suffix = id[:i], id[i+1:]
+		}
+	}
+	label := strings.Replace(strings.TrimPrefix(id, "_"), "_", ".", 1)
+	if label == "" {
+		label = "Package"
+	}
+	if suffix != "" {
+		label += " (" + suffix + ")"
+	}
+	return label
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import "testing"
+
+func TestExampleLabel(t *testing.T) {
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"Example", "Package"},
+		{"Example_basic", "Package (basic)"},
+		{"ExampleNew", "New"},
+		{"ExampleNew_withOptions", "New (withOptions)"},
+		{"ExampleClient_Do", "Client.Do"},
+		{"ExampleClient_Do_retry", "Client.Do (retry)"},
+	} {
+		if got := exampleLabel(test.name); got != test.want {
+			t.Errorf("exampleLabel(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
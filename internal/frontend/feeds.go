@@ -0,0 +1,354 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+	"golang.org/x/text/message"
+	"golang.org/x/vuln/osv"
+)
+
+// moduleFeedInfo describes a module feed.
+type moduleFeedInfo struct {
+	// Name is the feed name in the database.
+	Name string
+	// Path is the URL path of the page for the feed.
+	Path        string
+	Title       string
+	Description string
+}
+
+var (
+	newModulesFeed = &moduleFeedInfo{
+		Name:        postgres.NewModulesFeed,
+		Path:        "/new",
+		Title:       "New Modules",
+		Description: "Modules whose first version was indexed in the last 30 days, most recent first.",
+	}
+	trendingModulesFeed = &moduleFeedInfo{
+		Name:        postgres.TrendingModulesFeed,
+		Path:        "/trending",
+		Title:       "Trending Modules",
+		Description: "Modules whose packages gained the most importers in the last 30 days.",
+	}
+)
+
+// ModuleFeedPage contains the data for a page listing the modules of a
+// module feed.
+type ModuleFeedPage struct {
+	basePage
+	Heading     string
+	Description string
+	// AtomURL is the URL of the Atom feed for the page.
+	AtomURL string
+	// Trending reports whether the page lists trending modules, rather than
+	// new ones.
+	Trending bool
+	// Date is the date the list was computed, or the empty string if it
+	// hasn't been.
+	Date    string
+	Modules []*ModuleFeedEntry
+}
+
+// A ModuleFeedEntry is a module listed on a ModuleFeedPage.
+type ModuleFeedEntry struct {
+	ModulePath       string
+	Version          string
+	URL              string
+	IndexedAt        string
+	NumImportedBy    string
+	ImportedByGrowth string
+}
+
+// serveModuleFeed returns a handler for the page listing the modules of f, or
+// for its Atom feed if atom is true. The feeds are computed daily by the
+// worker, so they are only supported by the postgres data source.
+func (s *Server) serveModuleFeed(f *moduleFeedInfo, atom bool) http.HandlerFunc {
+	return s.errorHandler(func(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+		db, ok := ds.(*postgres.DB)
+		if !ok {
+			return datasourceNotSupportedErr()
+		}
+		ctx := r.Context()
+		mods, date, err := db.GetModuleFeed(ctx, f.Name)
+		if err != nil {
+			return err
+		}
+		if atom {
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			return writeAtomFeed(w, f, requestBaseURL(r), mods, date)
+		}
+		page := newModuleFeedPage(message.NewPrinter(middleware.LanguageTag(ctx)), f, mods, date)
+		page.basePage = s.newBasePage(r, f.Title)
+		s.servePage(ctx, w, "feeds", page)
+		return nil
+	})
+}
+
+func newModuleFeedPage(pr *message.Printer, f *moduleFeedInfo, mods []*postgres.FeedModule, date time.Time) *ModuleFeedPage {
+	page := &ModuleFeedPage{
+		Heading:     f.Title,
+		Description: f.Description,
+		AtomURL:     f.Path + ".atom",
+		Trending:    f == trendingModulesFeed,
+	}
+	if date.IsZero() {
+		return page
+	}
+	page.Date = absoluteTime(date)
+	for _, m := range mods {
+		e := &ModuleFeedEntry{
+			ModulePath:    m.ModulePath,
+			Version:       m.Version,
+			URL:           constructUnitURL(m.ModulePath, m.ModulePath, m.Version),
+			NumImportedBy: pr.Sprint(m.NumImportedBy),
+		}
+		if !m.IndexedAt.IsZero() {
+			e.IndexedAt = absoluteTime(m.IndexedAt)
+		}
+		if m.ImportedByGrowth > 0 {
+			e.ImportedByGrowth = pr.Sprintf("+%d", m.ImportedByGrowth)
+		}
+		page.Modules = append(page.Modules, e)
+	}
+	return page
+}
+
+// requestBaseURL returns the scheme and host of r, for building absolute URLs.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Links   []atomLink   `xml:"link"`
+	Updated string       `xml:"updated"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+// writeAtomFeed writes the Atom feed for mods, the modules of f computed on
+// date, to w. baseURL is used to make links absolute.
+func writeAtomFeed(w io.Writer, f *moduleFeedInfo, baseURL string, mods []*postgres.FeedModule, date time.Time) error {
+	feed := &atomFeed{
+		Title: f.Title,
+		ID:    baseURL + f.Path,
+		Links: []atomLink{
+			{Href: baseURL + f.Path},
+			{Href: baseURL + f.Path + ".atom", Rel: "self"},
+		},
+		Updated: date.UTC().Format(time.RFC3339),
+	}
+	for _, m := range mods {
+		u := baseURL + constructUnitURL(m.ModulePath, m.ModulePath, m.Version)
+		e := &atomEntry{
+			Title:   m.ModulePath + " " + m.Version,
+			ID:      u,
+			Link:    atomLink{Href: u},
+			Updated: date.UTC().Format(time.RFC3339),
+		}
+		if f == trendingModulesFeed {
+			e.Summary = fmt.Sprintf("Imported by %d (+%d in the last 30 days)", m.NumImportedBy, m.ImportedByGrowth)
+		} else {
+			e.Updated = m.IndexedAt.UTC().Format(time.RFC3339)
+			e.Summary = fmt.Sprintf("First indexed on %s", absoluteTime(m.IndexedAt))
+		}
+		feed.Entries = append(feed.Entries, e)
+	}
+	return encodeAtomFeed(w, feed)
+}
+
+// encodeAtomFeed writes feed to w as an XML document.
+func encodeAtomFeed(w io.Writer, feed *atomFeed) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
+
+// vulnFeedPathPrefix is the URL path prefix of the Atom feeds of the
+// vulnerabilities affecting a module.
+const vulnFeedPathPrefix = "/feeds/vulns/"
+
+// serveVulnFeed handles requests for /feeds/vulns/<module>.atom.
+// It serves an Atom feed of the vulnerabilities affecting the module, most
+// recently modified first.
+func (s *Server) serveVulnFeed(w http.ResponseWriter, r *http.Request, _ internal.DataSource) error {
+	if s.vulnClient == nil {
+		return &serverError{status: http.StatusNotFound}
+	}
+	modulePath := strings.TrimPrefix(r.URL.Path, vulnFeedPathPrefix)
+	if !strings.HasSuffix(modulePath, ".atom") {
+		return &serverError{status: http.StatusNotFound}
+	}
+	modulePath = strings.Trim(strings.TrimSuffix(modulePath, ".atom"), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest}
+	}
+	entries, err := s.vulnClient.GetByModule(modulePath)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	return writeVulnAtomFeed(w, modulePath, requestBaseURL(r), entries)
+}
+
+// writeVulnAtomFeed writes the Atom feed of entries, the vulnerabilities
+// affecting modulePath, to w. baseURL is used to make links absolute.
+func writeVulnAtomFeed(w io.Writer, modulePath, baseURL string, entries []*osv.Entry) error {
+	entries = append([]*osv.Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Modified.After(entries[j].Modified)
+	})
+	feedPath := vulnFeedPathPrefix + modulePath + ".atom"
+	feed := &atomFeed{
+		Title: "Vulnerabilities in " + modulePath,
+		ID:    baseURL + feedPath,
+		Links: []atomLink{
+			{Href: baseURL + "/" + modulePath},
+			{Href: baseURL + feedPath, Rel: "self"},
+		},
+	}
+	var updated time.Time
+	for _, e := range entries {
+		if e.Modified.After(updated) {
+			updated = e.Modified
+		}
+		u := baseURL + "/vuln/" + e.ID
+		title := e.ID
+		if e.Withdrawn != nil {
+			title += " (withdrawn)"
+		}
+		feed.Entries = append(feed.Entries, &atomEntry{
+			Title:   title,
+			ID:      u,
+			Link:    atomLink{Href: u},
+			Updated: e.Modified.UTC().Format(time.RFC3339),
+			Summary: e.Details,
+		})
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return encodeAtomFeed(w, feed)
+}
+
+const (
+	// versionFeedPathPrefix and versionFeedPathSuffix surround the module
+	// path in the URL path of the Atom feed of the versions of a module.
+	versionFeedPathPrefix = "/feeds/"
+	versionFeedPathSuffix = "/versions.atom"
+
+	// maxVersionFeedEntries is the maximum number of entries in the Atom feed
+	// of the versions of a module.
+	maxVersionFeedEntries = 50
+)
+
+// serveVersionFeed handles requests for /feeds/<module>/versions.atom.
+// It serves an Atom feed of the tagged versions of the module, including
+// those of its other major versions, most recently published first.
+func (s *Server) serveVersionFeed(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return datasourceNotSupportedErr()
+	}
+	modulePath := strings.TrimPrefix(r.URL.Path, versionFeedPathPrefix)
+	if !strings.HasSuffix(modulePath, versionFeedPathSuffix) {
+		return &serverError{status: http.StatusNotFound}
+	}
+	modulePath = strings.Trim(strings.TrimSuffix(modulePath, versionFeedPathSuffix), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest}
+	}
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, modulePath); err != nil {
+		return err
+	}
+	mis, err := db.GetVersionsForPath(ctx, modulePath)
+	if err != nil {
+		return err
+	}
+	var tagged []*internal.ModuleInfo
+	for _, mi := range mis {
+		if !version.IsPseudo(mi.Version) {
+			tagged = append(tagged, mi)
+		}
+	}
+	if len(tagged) == 0 {
+		return &serverError{status: http.StatusNotFound}
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	return writeVersionAtomFeed(w, modulePath, requestBaseURL(r), tagged)
+}
+
+// writeVersionAtomFeed writes the Atom feed of mis, the versions of
+// modulePath, to w. baseURL is used to make links absolute.
+func writeVersionAtomFeed(w io.Writer, modulePath, baseURL string, mis []*internal.ModuleInfo) error {
+	mis = append([]*internal.ModuleInfo(nil), mis...)
+	sort.SliceStable(mis, func(i, j int) bool {
+		return mis[i].CommitTime.After(mis[j].CommitTime)
+	})
+	if len(mis) > maxVersionFeedEntries {
+		mis = mis[:maxVersionFeedEntries]
+	}
+	feedPath := versionFeedPathPrefix + modulePath + versionFeedPathSuffix
+	feed := &atomFeed{
+		Title: "Versions of " + modulePath,
+		ID:    baseURL + feedPath,
+		Links: []atomLink{
+			{Href: baseURL + "/" + modulePath + "?tab=versions"},
+			{Href: baseURL + feedPath, Rel: "self"},
+		},
+	}
+	var updated time.Time
+	for _, mi := range mis {
+		if mi.CommitTime.After(updated) {
+			updated = mi.CommitTime
+		}
+		u := baseURL + constructUnitURL(mi.ModulePath, mi.ModulePath, mi.Version)
+		summary := fmt.Sprintf("Published on %s", absoluteTime(mi.CommitTime))
+		if mi.Retracted {
+			summary += " (retracted)"
+		}
+		feed.Entries = append(feed.Entries, &atomEntry{
+			Title:   mi.ModulePath + " " + mi.Version,
+			ID:      u,
+			Link:    atomLink{Href: u},
+			Updated: mi.CommitTime.UTC().Format(time.RFC3339),
+			Summary: summary,
+		})
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return encodeAtomFeed(w, feed)
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/vuln/osv"
+)
+
+func TestNewModuleFeedPage(t *testing.T) {
+	date := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	mods := []*postgres.FeedModule{
+		{ModulePath: "example.com/a", Version: "v1.2.0", NumImportedBy: 1500, ImportedByGrowth: 1200},
+	}
+	got := newModuleFeedPage(message.NewPrinter(language.English), trendingModulesFeed, mods, date)
+	want := &ModuleFeedPage{
+		Heading:     "Trending Modules",
+		Description: trendingModulesFeed.Description,
+		AtomURL:     "/trending.atom",
+		Trending:    true,
+		Date:        "Mar  2, 2022",
+		Modules: []*ModuleFeedEntry{{
+			ModulePath:       "example.com/a",
+			Version:          "v1.2.0",
+			URL:              "/example.com/a@v1.2.0",
+			NumImportedBy:    "1,500",
+			ImportedByGrowth: "+1,200",
+		}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(ModuleFeedPage{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got = newModuleFeedPage(message.NewPrinter(language.English), newModulesFeed, nil, time.Time{})
+	if got.Date != "" || got.Trending {
+		t.Errorf("got %+v for uncomputed feed", got)
+	}
+}
+
+func TestWriteAtomFeed(t *testing.T) {
+	date := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	mods := []*postgres.FeedModule{
+		{ModulePath: "example.com/a", Version: "v1.0.0", IndexedAt: date.Add(-time.Hour)},
+	}
+	var buf bytes.Buffer
+	if err := writeAtomFeed(&buf, newModulesFeed, "https://pkg.go.dev", mods, date); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<link href="https://pkg.go.dev/new.atom" rel="self"></link>`,
+		`<id>https://pkg.go.dev/example.com/a@v1.0.0</id>`,
+		`<updated>2022-03-01T23:00:00Z</updated>`,
+		`<summary>First indexed on Mar  1, 2022</summary>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("feed does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteVulnAtomFeed(t *testing.T) {
+	date := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	withdrawn := date
+	entries := []*osv.Entry{
+		{ID: "GO-2022-0001", Modified: date.Add(-48 * time.Hour), Details: "old"},
+		{ID: "GO-2022-0002", Modified: date, Withdrawn: &withdrawn, Details: "new"},
+	}
+	var buf bytes.Buffer
+	if err := writeVulnAtomFeed(&buf, "example.com/a", "https://pkg.go.dev", entries); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<title>Vulnerabilities in example.com/a</title>`,
+		`<link href="https://pkg.go.dev/feeds/vulns/example.com/a.atom" rel="self"></link>`,
+		`<updated>2022-03-02T00:00:00Z</updated>`,
+		`<title>GO-2022-0002 (withdrawn)</title>`,
+		`<id>https://pkg.go.dev/vuln/GO-2022-0001</id>`,
+		`<summary>old</summary>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("feed does not contain %q:\n%s", want, got)
+		}
+	}
+	// The most recently modified entry comes first.
+	if strings.Index(got, "GO-2022-0002") > strings.Index(got, "GO-2022-0001") {
+		t.Errorf("entries are not sorted by modification time:\n%s", got)
+	}
+}
+
+func TestWriteVersionAtomFeed(t *testing.T) {
+	date := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	mis := []*internal.ModuleInfo{
+		{ModulePath: "example.com/a", Version: "v1.0.0", CommitTime: date.Add(-48 * time.Hour)},
+		{ModulePath: "example.com/a/v2", Version: "v2.0.0", CommitTime: date, Retracted: true},
+	}
+	var buf bytes.Buffer
+	if err := writeVersionAtomFeed(&buf, "example.com/a", "https://pkg.go.dev", mis); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<title>Versions of example.com/a</title>`,
+		`<link href="https://pkg.go.dev/feeds/example.com/a/versions.atom" rel="self"></link>`,
+		`<updated>2022-03-02T00:00:00Z</updated>`,
+		`<title>example.com/a/v2 v2.0.0</title>`,
+		`<id>https://pkg.go.dev/example.com/a@v1.0.0</id>`,
+		`<summary>Published on Mar  2, 2022 (retracted)</summary>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("feed does not contain %q:\n%s", want, got)
+		}
+	}
+	// The most recently published version comes first.
+	if strings.Index(got, "v2.0.0") > strings.Index(got, "v1.0.0") {
+		t.Errorf("entries are not sorted by publication time:\n%s", got)
+	}
+}
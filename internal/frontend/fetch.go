@@ -102,18 +102,56 @@ func (s *Server) serveFetch(w http.ResponseWriter, r *http.Request, ds internal.
 		// "fetch" package, which does not exist.
 		return &serverError{status: http.StatusNotFound}
 	}
+	if s.readOnly() {
+		return &serverError{
+			status:       http.StatusServiceUnavailable,
+			responseText: "Fetching is unavailable while the site is in read-only mode. Please try again later.",
+		}
+	}
 
 	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/fetch"))
 	if err != nil {
 		return &serverError{status: http.StatusBadRequest}
 	}
+	// If fetching is restricted, check that the user may fetch modules,
+	// and record the request.
+	var user string
+	if s.fetchAuthorizer != nil {
+		user, err = s.authorizeFetch(r)
+		if err != nil {
+			log.Infof(r.Context(), "serveFetch(%q): %v", r.URL.Path, err)
+			s.recordFetchRequest(r.Context(), ds, user, urlInfo, false, http.StatusForbidden)
+			return &serverError{
+				status:       http.StatusForbidden,
+				responseText: "You are not allowed to fetch modules.",
+			}
+		}
+	}
 	status, responseText := s.fetchAndPoll(r.Context(), ds, urlInfo.modulePath, urlInfo.fullPath, urlInfo.requestedVersion)
+	if s.fetchAuthorizer != nil {
+		s.recordFetchRequest(r.Context(), ds, user, urlInfo, true, status)
+	}
 	if status != http.StatusOK {
 		return &serverError{status: status, responseText: responseText}
 	}
 	return nil
 }
 
+// recordFetchRequest records a request to fetch a module in the audit trail.
+// Errors are logged, since they should not prevent fetching.
+func (s *Server) recordFetchRequest(ctx context.Context, ds internal.DataSource, user string, info *urlPathInfo, allowed bool, status int) {
+	err := ds.(*postgres.DB).InsertFetchRequest(ctx, &postgres.FetchRequest{
+		Requester:        user,
+		Path:             info.fullPath,
+		RequestedVersion: info.requestedVersion,
+		Allowed:          allowed,
+		Status:           status,
+	})
+	if err != nil {
+		log.Errorf(ctx, "recordFetchRequest: %v", err)
+	}
+}
+
 type fetchResult struct {
 	modulePath   string
 	goModPath    string
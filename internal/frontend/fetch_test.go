@@ -6,14 +6,19 @@ package frontend
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/failover"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy/proxytest"
 	"golang.org/x/pkgsite/internal/testing/sample"
@@ -223,3 +228,71 @@ func TestCandidateModulePaths(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchReadOnly(t *testing.T) {
+	s, handler, teardown := newTestServer(t, testModulesForProxy, nil)
+	defer teardown()
+	s.failover = failover.NewMonitor(func(context.Context) error { return errors.New("down") }, 1)
+	s.failover.Check(context.Background())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/fetch/"+testModulePath, nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := s.siteBanner(); got != readOnlyBanner {
+		t.Errorf("got banner %q, want %q", got, readOnlyBanner)
+	}
+}
+
+func TestFetchRestricted(t *testing.T) {
+	s, handler, teardown := newTestServer(t, testModulesForProxy, nil)
+	defer teardown()
+	s.fetchAuthorizer = func(r *http.Request) (string, error) {
+		user := r.Header.Get("X-User")
+		if user != "gopher" {
+			return user, errors.New("forbidden")
+		}
+		return user, nil
+	}
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+	for _, test := range []struct {
+		user     string
+		wantCode int
+	}{
+		{"mallory", http.StatusForbidden},
+		{"gopher", http.StatusOK},
+	} {
+		r := httptest.NewRequest("POST", "/fetch/"+testModulePath, nil)
+		r.Header.Set("X-User", test.user)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: got status %d, want %d", test.user, w.Code, test.wantCode)
+		}
+	}
+	// Only authorized users are offered fetching.
+	if r := httptest.NewRequest("GET", "/", nil); s.canFetch(r) {
+		t.Error("canFetch without a user = true, want false")
+	}
+	// So are requests with an API key that has the fetch scope.
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(apikey.NewContext(r.Context(), &apikey.Key{Name: "ci", Scopes: []string{apikey.ScopeFetch}}))
+	if !s.canFetch(r) {
+		t.Error("canFetch with a fetch key = false, want true")
+	}
+
+	got, err := testDB.GetFetchRequests(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*postgres.FetchRequest{
+		{Requester: "gopher", Path: testModulePath, RequestedVersion: version.Latest, Allowed: true, Status: http.StatusOK},
+		{Requester: "mallory", Path: testModulePath, RequestedVersion: version.Latest, Allowed: false, Status: http.StatusForbidden},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(postgres.FetchRequest{}, "RequestedAt")); diff != "" {
+		t.Errorf("fetch requests mismatch (-want, +got):\n%s", diff)
+	}
+}
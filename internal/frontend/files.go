@@ -0,0 +1,209 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/moddiff"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// maxSourceFileSize is the size of the largest file shown on the Files tab.
+// Larger files can still be served raw.
+const maxSourceFileSize = 1024 * 1024
+
+// moduleZipFunc returns the zip of a module version.
+type moduleZipFunc func(ctx context.Context, modulePath, version string) (*moddiff.Module, error)
+
+// FilesDetails contains the data for the Files tab of a unit page, which
+// lists the Go source files in the directory of the unit and shows one of
+// them.
+type FilesDetails struct {
+	Files []*SourceFileLink
+	// File is the file selected with the file query parameter, or nil if
+	// there is none.
+	File *SourceFile
+}
+
+// A SourceFileLink is a file in the list on the Files tab.
+type SourceFileLink struct {
+	Name string
+	Size string
+	URL  string
+	// Selected reports whether the file is the one being shown.
+	Selected bool
+}
+
+// A SourceFile is a Go source file shown on the Files tab.
+type SourceFile struct {
+	Name   string
+	RawURL string
+	// Lines are the highlighted lines of the file. They are empty if the file
+	// is too large to be shown.
+	Lines   []*SourceLine
+	TooLong bool
+}
+
+// A SourceLine is a line of a SourceFile.
+type SourceLine struct {
+	Number int
+	Tokens []*SourceToken
+}
+
+// A SourceToken is a part of a SourceLine. Class is the CSS class used to
+// highlight it, and is empty for plain text.
+type SourceToken struct {
+	Class string
+	Text  string
+}
+
+// fetchFilesDetails returns the FilesDetails of the unit described by um,
+// showing the file named by the file query parameter of r.
+func fetchFilesDetails(ctx context.Context, r *http.Request, getModuleZip moduleZipFunc, um *internal.UnitMeta, requestedVersion string) (*FilesDetails, error) {
+	if getModuleZip == nil {
+		return nil, datasourceNotSupportedErr()
+	}
+	if um.ModulePath == stdlib.ModulePath {
+		return nil, errStdlibFilesNotAvailable
+	}
+	m, err := getModuleZip(ctx, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Join(m.Path+"@"+m.Version, internal.Suffix(um.Path, um.ModulePath))
+	entries, err := fs.ReadDir(m.Zip, dir)
+	if err != nil {
+		return nil, err
+	}
+	unitURL := constructUnitURL(um.Path, um.ModulePath, requestedVersion)
+	selected := r.FormValue("file")
+	d := &FilesDetails{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || path.Ext(e.Name()) != ".go" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		d.Files = append(d.Files, &SourceFileLink{
+			Name:     e.Name(),
+			Size:     formatFileSize(info.Size()),
+			URL:      unitURL + "?tab=" + tabFiles + "&file=" + url.QueryEscape(e.Name()),
+			Selected: e.Name() == selected,
+		})
+		if e.Name() != selected {
+			continue
+		}
+		d.File = &SourceFile{
+			Name:   e.Name(),
+			RawURL: unitURL + rawInfix + e.Name(),
+		}
+		if info.Size() > maxSourceFileSize {
+			d.File.TooLong = true
+			continue
+		}
+		src, err := fs.ReadFile(m.Zip, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		d.File.Lines = highlightGo(src)
+	}
+	if selected != "" && d.File == nil {
+		return nil, &serverError{
+			status: http.StatusNotFound,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(
+					`<h3 class="Error-message">{{.}} is not a Go source file of this package.</h3>`),
+				MessageData: selected,
+			},
+		}
+	}
+	return d, nil
+}
+
+// formatFileSize returns n bytes as a human-readable size.
+func formatFileSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+// highlightGo splits the Go source src into lines, and the lines into tokens
+// with the CSS classes used to highlight comments, literals and keywords.
+// Text that cannot be scanned is left plain.
+func highlightGo(src []byte) []*SourceLine {
+	// The scanner removes carriage returns from comments and raw strings,
+	// which would make their text shorter than in src.
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	lines := []*SourceLine{{Number: 1}}
+	add := func(class, text string) {
+		for {
+			before, after, found := strings.Cut(text, "\n")
+			if before != "" {
+				l := lines[len(lines)-1]
+				l.Tokens = append(l.Tokens, &SourceToken{Class: class, Text: before})
+			}
+			if !found {
+				return
+			}
+			lines = append(lines, &SourceLine{Number: len(lines) + 1})
+			text = after
+		}
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		var class string
+		switch {
+		case tok == token.COMMENT:
+			class = "comment"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "string"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "number"
+		case tok.IsKeyword():
+			class = "keyword"
+			lit = tok.String()
+		default:
+			continue
+		}
+		off := file.Offset(pos)
+		end := off + len(lit)
+		if off < last || end > len(src) {
+			continue
+		}
+		add("", string(src[last:off]))
+		add(class, string(src[off:end]))
+		last = end
+	}
+	add("", string(src[last:]))
+	// Drop the empty line after a final newline.
+	if n := len(lines); n > 1 && len(lines[n-1].Tokens) == 0 {
+		lines = lines[:n-1]
+	}
+	return lines
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/moddiff"
+)
+
+func TestHighlightGo(t *testing.T) {
+	src := "package p\r\n\r\n// F is\n// a function.\nfunc F() string { return `a\nb` + \"c\" }\n"
+	want := []*SourceLine{
+		{Number: 1, Tokens: []*SourceToken{{"keyword", "package"}, {"", " p"}}},
+		{Number: 2},
+		{Number: 3, Tokens: []*SourceToken{{"comment", "// F is"}}},
+		{Number: 4, Tokens: []*SourceToken{{"comment", "// a function."}}},
+		{Number: 5, Tokens: []*SourceToken{
+			{"keyword", "func"}, {"", " F() string { "}, {"keyword", "return"}, {"", " "}, {"string", "`a"},
+		}},
+		{Number: 6, Tokens: []*SourceToken{{"string", "b`"}, {"", " + "}, {"string", `"c"`}, {"", " }"}}},
+	}
+	if diff := cmp.Diff(want, highlightGo([]byte(src))); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestFetchFilesDetails(t *testing.T) {
+	const (
+		modulePath = "example.com/m"
+		version    = "v1.0.0"
+	)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range map[string]string{
+		"go.mod":         "module example.com/m",
+		"p/p.go":         "package p\n",
+		"p/p_test.go":    "package p\n",
+		"p/README.md":    "# p",
+		"p/sub/sub.go":   "package sub\n",
+		"other/other.go": "package other\n",
+	} {
+		w, err := zw.Create(modulePath + "@" + version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	getModuleZip := func(ctx context.Context, modulePath, version string) (*moddiff.Module, error) {
+		return &moddiff.Module{Path: modulePath, Version: version, Zip: zr}, nil
+	}
+	um := &internal.UnitMeta{
+		Path:       modulePath + "/p",
+		ModuleInfo: internal.ModuleInfo{ModulePath: modulePath, Version: version},
+	}
+
+	r := httptest.NewRequest("GET", "/example.com/m@v1.0.0/p?tab=files&file=p.go", nil)
+	got, err := fetchFilesDetails(context.Background(), r, getModuleZip, um, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &FilesDetails{
+		Files: []*SourceFileLink{
+			{Name: "p.go", Size: "10 B", URL: "/example.com/m@v1.0.0/p?tab=files&file=p.go", Selected: true},
+			{Name: "p_test.go", Size: "10 B", URL: "/example.com/m@v1.0.0/p?tab=files&file=p_test.go"},
+		},
+		File: &SourceFile{
+			Name:   "p.go",
+			RawURL: "/example.com/m@v1.0.0/p/-/raw/p.go",
+			Lines:  []*SourceLine{{Number: 1, Tokens: []*SourceToken{{"keyword", "package"}, {"", " p"}}}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	r = httptest.NewRequest("GET", "/example.com/m@v1.0.0/p?tab=files&file=README.md", nil)
+	if _, err := fetchFilesDetails(context.Background(), r, getModuleZip, um, version); err == nil {
+		t.Error("got nil error for a file that is not Go source, want error")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"path"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/text/message"
+)
+
+// A FuzzTarget is a fuzz target of a package, as shown in the Fuzzing section
+// of the package page.
+type FuzzTarget struct {
+	Name string
+	// Seeds describes the size of the seed corpus of the target, such as
+	// "3 seed inputs".
+	Seeds string
+	// CorpusURL is the URL of the seed corpus in the repository of the
+	// module. It is empty if the target has no seed corpus or the repository
+	// is unknown.
+	CorpusURL string
+}
+
+// fuzzTargets returns the fuzz targets of the unit to display.
+func fuzzTargets(pr *message.Printer, unit *internal.Unit) []*FuzzTarget {
+	var targets []*FuzzTarget
+	dir := internal.Suffix(unit.Path, unit.ModulePath)
+	for _, t := range unit.FuzzTargets {
+		ft := &FuzzTarget{Name: t.Name}
+		switch t.NumSeeds {
+		case 0:
+			ft.Seeds = "No seed corpus"
+		case 1:
+			ft.Seeds = "1 seed input"
+		default:
+			ft.Seeds = pr.Sprintf("%d seed inputs", t.NumSeeds)
+		}
+		if t.NumSeeds > 0 {
+			ft.CorpusURL = unit.SourceInfo.DirectoryURL(path.Join(dir, "testdata", "fuzz", t.Name))
+		}
+		targets = append(targets, ft)
+	}
+	return targets
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestFuzzTargets(t *testing.T) {
+	unit := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path: "github.com/some/repo/parser",
+			ModuleInfo: internal.ModuleInfo{
+				ModulePath: "github.com/some/repo",
+				SourceInfo: source.NewGitHubInfo("https://github.com/some/repo", "", "v1.2.3"),
+			},
+		},
+		FuzzTargets: []*internal.FuzzTarget{
+			{Name: "FuzzParse", NumSeeds: 1200},
+			{Name: "FuzzPrint", NumSeeds: 1},
+			{Name: "FuzzRoundTrip"},
+		},
+	}
+	got := fuzzTargets(message.NewPrinter(language.English), unit)
+	want := []*FuzzTarget{
+		{
+			Name:      "FuzzParse",
+			Seeds:     "1,200 seed inputs",
+			CorpusURL: "https://github.com/some/repo/tree/v1.2.3/parser/testdata/fuzz/FuzzParse",
+		},
+		{
+			Name:      "FuzzPrint",
+			Seeds:     "1 seed input",
+			CorpusURL: "https://github.com/some/repo/tree/v1.2.3/parser/testdata/fuzz/FuzzPrint",
+		},
+		{
+			Name:  "FuzzRoundTrip",
+			Seeds: "No seed corpus",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"unicode"
@@ -30,10 +31,25 @@ import (
 type astTransformer struct {
 	info   *source.Info
 	readme *internal.Readme
+	// resolveLink, if non-nil, returns the URL for a link destination that
+	// refers to a page on this site, or the empty string if the destination
+	// should be translated as usual.
+	resolveLink func(dest string) string
 }
 
 // Transform transforms the given AST tree.
 func (g *astTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	headingIDs := map[string]bool{}
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			if id, ok := h.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					headingIDs[string(b)] = true
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
 	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
@@ -44,6 +60,16 @@ func (g *astTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 				v.Destination = []byte(d)
 			}
 		case *ast.Link:
+			if strings.HasPrefix(string(v.Destination), "#") {
+				v.Destination = []byte(headingLink(string(v.Destination[1:]), headingIDs))
+				break
+			}
+			if g.resolveLink != nil {
+				if d := g.resolveLink(string(v.Destination)); d != "" {
+					v.Destination = []byte(d)
+					break
+				}
+			}
 			if d := translateLink(string(v.Destination), g.info, false, g.readme); d != "" {
 				v.Destination = []byte(d)
 			}
@@ -52,6 +78,22 @@ func (g *astTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 	})
 }
 
+// headingLink returns the link to the heading of the document for the
+// fragment of a link, given the ids of its headings. As on GitHub, fragments
+// match heading ids regardless of case and percent-encoding. Fragments that
+// do not match a heading are kept as they are.
+func headingLink(fragment string, headingIDs map[string]bool) string {
+	if f, err := url.PathUnescape(fragment); err == nil {
+		fragment = f
+	}
+	for _, f := range []string{fragment, strings.ToLower(fragment)} {
+		if headingIDs["readme-"+f] {
+			return "#readme-" + f
+		}
+	}
+	return "#readme-" + fragment
+}
+
 // htmlRenderer is a renderer.NodeRenderer implementation that renders
 // pkg.go.dev readme features.
 type htmlRenderer struct {
@@ -176,21 +218,27 @@ func newIDs() parser.IDs {
 	}
 }
 
-// Generate turns heading content from a markdown document into a heading id.
-// First HTML markup and markdown images are stripped then unicode letters
-// and numbers are used to generate the final result. Finally, all heading ids
-// are prefixed with "readme-" to avoid name collisions with other ids on the
-// unit page. Duplicated heading ids are given an incremental suffix. See
-// readme_test.go for examples.
+// Generate turns heading content from a markdown document into a heading id,
+// in the same way as GitHub, so that links to the headings of a README work
+// on both sites. First HTML markup and markdown images are stripped, and the
+// content is lowercased. Then unicode letters, marks, numbers, underscores
+// and hyphens are kept, spaces are replaced by hyphens, and everything else
+// is removed. Finally, all heading ids are prefixed with "readme-" to avoid
+// name collisions with other ids on the unit page. Duplicated heading ids are
+// given an incremental suffix. See readme_test.go for examples.
 func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
 	// Matches strings like `<tag attr="value">Text</tag>` or `[![Text](file.svg)](link.html)`.
 	r := regexp.MustCompile(`(<[^<>]+>|\[\!\[[^\]]+]\([^\)]+\)\]\([^\)]+\))`)
 	str := r.ReplaceAllString(string(value), "")
-	f := func(c rune) bool {
-		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
-	}
-	str = strings.Join(strings.FieldsFunc(str, f), "-")
-	str = strings.ToLower(str)
+	str = strings.Map(func(c rune) rune {
+		switch {
+		case c == ' ':
+			return '-'
+		case c == '-' || unicode.In(c, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			return c
+		}
+		return -1
+	}, strings.ToLower(strings.TrimSpace(str)))
 	if len(str) == 0 {
 		if kind == ast.KindHeading {
 			str = "heading"
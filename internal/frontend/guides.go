@@ -0,0 +1,169 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// guideParam is the query parameter that selects a guide in the Guides tab.
+// Its value is the path of the guide relative to the guide directory, such
+// as "intro.md".
+const guideParam = "guide"
+
+// GuidesDetails contains the data for the Guides tab of a unit page.
+type GuidesDetails struct {
+	// Guides are links to all the guides of the module, in file path order.
+	Guides []*GuideLink
+
+	// Title is the title of the selected guide.
+	Title string
+
+	// HTML is the rendered and sanitized contents of the selected guide.
+	HTML safehtml.HTML
+
+	// Outline holds the headings of the selected guide.
+	Outline []*Heading
+
+	// SourceURL is the URL of the selected guide in the module's repository.
+	SourceURL string
+}
+
+// GuideLink is a link to a guide in the Guides tab.
+type GuideLink struct {
+	Title   string
+	URL     string
+	Current bool
+}
+
+// guidesGetter is implemented by data sources that record the guides of
+// modules.
+type guidesGetter interface {
+	GetModuleGuidePaths(ctx context.Context, modulePath, version string) ([]string, error)
+	GetModuleGuide(ctx context.Context, modulePath, version, filePath string) (*internal.Readme, error)
+}
+
+// fetchGuidesDetails returns the GuidesDetails for the module of um. The
+// guide to display is given by the guide query parameter of r; if it is
+// empty, the index of the guide directory is displayed, or the first guide
+// if there is no index.
+func fetchGuidesDetails(ctx context.Context, r *http.Request, ds internal.DataSource, um *internal.UnitMeta,
+	requestedVersion string, opts markdownOptions) (_ *GuidesDetails, err error) {
+	defer derrors.Wrap(&err, "fetchGuidesDetails(ctx, r, ds, %q, %q)", um.ModulePath, um.Version)
+
+	g, ok := ds.(guidesGetter)
+	if !ok {
+		return &GuidesDetails{}, nil
+	}
+	paths, err := g.GetModuleGuidePaths(ctx, um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return &GuidesDetails{}, nil
+	}
+	// All guides are in the same directory at the root of the module.
+	dir := strings.SplitN(paths[0], "/", 2)[0]
+	isGuide := map[string]bool{}
+	for _, p := range paths {
+		isGuide[p] = true
+	}
+	unitURL := constructUnitURL(um.Path, um.ModulePath, requestedVersion)
+	guideURL := func(filePath string) string {
+		return guidePageURL(unitURL, filePath)
+	}
+
+	current := indexGuide(paths)
+	if name := r.FormValue(guideParam); name != "" {
+		current = path.Join(dir, name)
+		if !isGuide[current] {
+			return nil, &serverError{status: http.StatusNotFound}
+		}
+	}
+	d := &GuidesDetails{}
+	for _, p := range paths {
+		link := &GuideLink{
+			Title:   guideTitle(dir, p),
+			URL:     guideURL(p),
+			Current: p == current,
+		}
+		if link.Current {
+			d.Title = link.Title
+		}
+		d.Guides = append(d.Guides, link)
+	}
+
+	guide, err := g.GetModuleGuide(ctx, um.ModulePath, um.Version, current)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, &serverError{status: http.StatusNotFound}
+		}
+		return nil, err
+	}
+	// Links to other guides are resolved to their pages in the Guides tab;
+	// other relative links are resolved to the repository, as for READMEs.
+	opts.resolveLink = func(dest string) string {
+		u, err := url.Parse(dest)
+		if err != nil || u.IsAbs() || u.Path == "" || path.IsAbs(u.Path) {
+			return ""
+		}
+		p := path.Join(path.Dir(current), u.Path)
+		if !isGuide[p] {
+			return ""
+		}
+		s := guideURL(p)
+		if u.Fragment != "" {
+			s += "#readme-" + u.Fragment
+		}
+		return s
+	}
+	rm, err := processMarkdown(ctx, guide, um.SourceInfo, opts)
+	if err != nil {
+		return nil, err
+	}
+	d.HTML = rm.HTML
+	d.Outline = rm.Outline
+	d.SourceURL = um.SourceInfo.FileURL(current)
+	return d, nil
+}
+
+// guidePageURL returns the URL of the guide at filePath, relative to the
+// module root, in the Guides tab of the unit page at unitURL.
+func guidePageURL(unitURL, filePath string) string {
+	_, name, _ := strings.Cut(filePath, "/")
+	return unitURL + "?tab=" + tabGuides + "&" + guideParam + "=" + url.QueryEscape(name)
+}
+
+// indexGuide returns the guide that is displayed when none is selected: a
+// README or index file at the top of the guide directory, or else the first
+// guide.
+func indexGuide(paths []string) string {
+	for _, p := range paths {
+		if strings.Count(p, "/") != 1 {
+			continue
+		}
+		base := strings.ToLower(strings.TrimSuffix(path.Base(p), path.Ext(p)))
+		if base == "readme" || base == "index" {
+			return p
+		}
+	}
+	return paths[0]
+}
+
+// guideTitle returns the title of the guide at filePath in dir: its path
+// relative to dir, without the extension.
+func guideTitle(dir, filePath string) string {
+	rel := strings.TrimPrefix(filePath, dir+"/")
+	return strings.TrimSuffix(rel, path.Ext(rel))
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/fakedatasource"
+	"golang.org/x/pkgsite/static"
+	thirdparty "golang.org/x/pkgsite/third_party"
+)
+
+func TestGuidesTab(t *testing.T) {
+	m := fakedatasource.NewModule("example.com/m", "v1.0.0").
+		Package("p", "package p").Module()
+	m.Guides = []*internal.Readme{
+		{Filepath: "docs/README.md", Contents: "# Start\n\nSee the [tutorial](tutorial/first.md#setup) and the [code](../p/p.go).\n"},
+		{Filepath: "docs/tutorial/first.md", Contents: "# First steps\n\n## Setup\n\n<script>alert(1)</script>\n"},
+	}
+	ds := fakedatasource.New()
+	ds.InsertModule(m)
+	s, err := NewServer(ServerConfig{
+		DataSourceGetter: func(context.Context) internal.DataSource { return ds },
+		TemplateFS:       template.TrustedFSFromEmbed(static.FS),
+		StaticFS:         static.FS,
+		ThirdPartyFS:     thirdparty.FS,
+		StaticPath:       "../../static",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil, nil)
+	get := func(url string) (int, string) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w.Code, w.Body.String()
+	}
+
+	for _, test := range []struct {
+		url           string
+		want, notWant []string
+	}{
+		{
+			url: "/example.com/m/p",
+			want: []string{
+				`data-test-id="UnitHeader-guides"`,
+				`href="/example.com/m/p?tab=guides"`,
+			},
+		},
+		{
+			url: "/example.com/m/p?tab=guides",
+			want: []string{
+				"Start",
+				// A link to another guide goes to its page. The sanitizer
+				// sorts the query parameters.
+				`href="/example.com/m/p?guide=tutorial%2Ffirst.md&tab=guides#readme-setup"`,
+				`aria-current="page"`,
+			},
+		},
+		{
+			url:     "/example.com/m/p?tab=guides&guide=tutorial%2Ffirst.md",
+			want:    []string{"First steps"},
+			notWant: []string{"<script>alert(1)</script>"},
+		},
+	} {
+		code, body := get(test.url)
+		if code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", test.url, code, http.StatusOK)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: page does not contain %q", test.url, want)
+			}
+		}
+		for _, nw := range test.notWant {
+			if strings.Contains(body, nw) {
+				t.Errorf("%s: page contains %q", test.url, nw)
+			}
+		}
+	}
+
+	if code, _ := get("/example.com/m/p?tab=guides&guide=missing.md"); code != http.StatusNotFound {
+		t.Errorf("missing guide: got status %d, want %d", code, http.StatusNotFound)
+	}
+}
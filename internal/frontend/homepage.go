@@ -8,6 +8,8 @@ import (
 	"context"
 	"math/rand"
 	"net/http"
+
+	"golang.org/x/pkgsite/internal"
 )
 
 // searchTip represents a snippet of text on the homepage demonstrating
@@ -45,12 +47,30 @@ type homepage struct {
 
 	// SearchTips is a collection of search tips to show on the homepage.
 	SearchTips []searchTip
+
+	// Sections are the curated sections of the homepage. If there are any,
+	// they are shown instead of the public homepage content.
+	Sections []*internal.HomepageSection
 }
 
 func (s *Server) serveHomepage(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if s.landingPage != "" {
+		http.Redirect(w, r, s.landingPage, http.StatusFound)
+		return
+	}
 	s.servePage(ctx, w, "homepage", homepage{
 		basePage:   s.newBasePage(r, "pkg.go.dev"),
 		SearchTips: searchTips,
 		TipIndex:   rand.Intn(len(searchTips)),
+		Sections:   s.homepageSections(),
 	})
 }
+
+// homepageSections returns the curated sections of the homepage, or nil if
+// there are none.
+func (s *Server) homepageSections() []*internal.HomepageSection {
+	if s.homepagePoller == nil {
+		return nil
+	}
+	return s.homepagePoller.Current().([]*internal.HomepageSection)
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/static"
+)
+
+func TestServeHomepage(t *testing.T) {
+	newServer := func(cfg *config.Config, sections []*internal.HomepageSection) *Server {
+		t.Helper()
+		s, err := NewServer(ServerConfig{
+			Config:     cfg,
+			TemplateFS: template.TrustedFSFromEmbed(static.FS),
+			StaticFS:   static.FS,
+			HomepageGetter: func(context.Context) ([]*internal.HomepageSection, error) {
+				return sections, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	serve := func(s *Server) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.serveHomepage(context.Background(), w, httptest.NewRequest("GET", "/", nil))
+		return w
+	}
+
+	t.Run("public", func(t *testing.T) {
+		body := serve(newServer(nil, nil)).Body.String()
+		if !strings.Contains(body, "Frequently asked questions") {
+			t.Error("public homepage does not have the FAQ")
+		}
+	})
+	t.Run("sections", func(t *testing.T) {
+		s := newServer(nil, []*internal.HomepageSection{
+			{Kind: internal.HomepagePinned, Title: "Pinned modules", Paths: []string{"corp.com/a"}},
+			{Kind: internal.HomepageRecent, Title: "Recently updated", PathPrefix: "corp.com/"},
+		})
+		body := serve(s).Body.String()
+		for _, want := range []string{"Pinned modules", `href="/corp.com/a"`, "Recently updated", "No packages."} {
+			if !strings.Contains(body, want) {
+				t.Errorf("homepage does not contain %q", want)
+			}
+		}
+		if strings.Contains(body, "Frequently asked questions") {
+			t.Error("curated homepage has the public FAQ")
+		}
+	})
+	t.Run("landing page", func(t *testing.T) {
+		w := serve(newServer(&config.Config{LandingPage: "/corp.com/docs"}, nil))
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/corp.com/docs" {
+			t.Errorf("got %d to %q, want %d to %q", w.Code, w.Header().Get("Location"), http.StatusFound, "/corp.com/docs")
+		}
+	})
+}
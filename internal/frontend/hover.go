@@ -0,0 +1,197 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+const (
+	// maxHoverQueries is the maximum number of queries in a request to the
+	// hover endpoint.
+	maxHoverQueries = 100
+	// hoverMaxAge is how long clients and the cache may reuse a response of
+	// the hover endpoint. Editors mostly ask for the versions in a go.mod
+	// file, whose documentation does not change, and hovers for the latest
+	// version can lag behind a new release for a while.
+	hoverMaxAge = 24 * time.Hour
+)
+
+// HoverResponse is the JSON response of the /api/v1/hover endpoint.
+type HoverResponse struct {
+	// Hovers has one element for each query of the request, in the same
+	// order.
+	Hovers []*Hover `json:"hovers"`
+}
+
+// A Hover summarizes the documentation of a package or symbol, for display
+// in an editor.
+type Hover struct {
+	// Query is the query, as given in the request.
+	Query      string `json:"query"`
+	Path       string `json:"path,omitempty"`
+	ModulePath string `json:"modulePath,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Symbol     string `json:"symbol,omitempty"`
+	// Decl is the declaration of the symbol, or the package clause if there
+	// is no symbol.
+	Decl     string `json:"decl,omitempty"`
+	Synopsis string `json:"synopsis,omitempty"`
+	Doc      string `json:"doc,omitempty"`
+	// URL is the path of the documentation on this site.
+	URL string `json:"url,omitempty"`
+	// Error explains why there is no documentation for the query. The other
+	// fields are empty if it is set.
+	Error string `json:"error,omitempty"`
+}
+
+// hoverPackage identifies a package version in a hover request.
+type hoverPackage struct {
+	path, version string
+}
+
+// serveHoverAPI handles requests for /api/v1/hover?q=<query>&q=<query>...
+// Each query has the form PATH[@VERSION][#SYMBOL], as in the URL of the
+// documentation of a symbol, such as net/http#Client.Do or
+// golang.org/x/mod@v0.5.0/module#Version. It returns a summary of the
+// documentation of each symbol, or of the package if there is no symbol, at
+// the given version, or the latest version if none is provided. Queries that
+// cannot be answered have an error instead of a summary; they do not fail the
+// request.
+//
+// The endpoint is meant for editor extensions that show the documentation of
+// imported identifiers without loading packages locally, so it answers many
+// queries at once, and its responses can be cached.
+func (s *Server) serveHoverAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	queries := r.URL.Query()["q"]
+	if len(queries) == 0 {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing q query parameter"}
+	}
+	if len(queries) > maxHoverQueries {
+		return &serverError{status: http.StatusBadRequest, responseText: "too many queries"}
+	}
+	var (
+		hovers = make([]*Hover, len(queries))
+		pkgs   []hoverPackage
+		byPkg  = map[hoverPackage][]int{} // indexes of the queries for a package
+	)
+	for i, q := range queries {
+		hp, symbol, ok := parseHoverQuery(q)
+		if !ok {
+			hovers[i] = &Hover{Query: q, Error: "invalid query"}
+			continue
+		}
+		hovers[i] = &Hover{Query: q, Symbol: symbol}
+		if _, ok := byPkg[hp]; !ok {
+			pkgs = append(pkgs, hp)
+		}
+		byPkg[hp] = append(byPkg[hp], i)
+	}
+	ctx := r.Context()
+	for _, hp := range pkgs {
+		var symbols []string
+		for _, i := range byPkg[hp] {
+			symbols = append(symbols, hovers[i].Symbol)
+		}
+		um, summaries, errText, err := hoverSummaries(ctx, ds, hp, symbols)
+		if err != nil {
+			return err
+		}
+		for _, i := range byPkg[hp] {
+			h := hovers[i]
+			if errText != "" {
+				h.Error = errText
+				continue
+			}
+			sum, ok := summaries[h.Symbol]
+			if !ok {
+				h.Error = "no such symbol"
+				continue
+			}
+			h.Path = um.Path
+			h.ModulePath = um.ModulePath
+			h.Version = um.Version
+			h.Decl = sum.Decl
+			h.Synopsis = sum.Synopsis
+			h.Doc = sum.Doc
+			h.URL = canonicalURLPath(um.Path, um.ModulePath, hp.version, um.Version)
+			if h.Symbol != "" {
+				h.URL += "#" + h.Symbol
+			}
+		}
+	}
+	return serveJSON(w, r, &HoverResponse{Hovers: hovers})
+}
+
+// parseHoverQuery parses a query of the form PATH[@VERSION][#SYMBOL]. As in
+// the URLs of this site, the version may also follow the module path, as in
+// golang.org/x/mod@v0.5.0/module. It reports whether the query is valid.
+func parseHoverQuery(q string) (_ hoverPackage, symbol string, ok bool) {
+	pathVersion, symbol, _ := strings.Cut(q, "#")
+	pkgPath, requestedVersion, _ := strings.Cut(pathVersion, "@")
+	requestedVersion, suffix, _ := strings.Cut(requestedVersion, "/")
+	pkgPath = strings.Trim(pkgPath+"/"+suffix, "/")
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if pkgPath == "" || !isSupportedVersion(pkgPath, requestedVersion) {
+		return hoverPackage{}, "", false
+	}
+	return hoverPackage{pkgPath, requestedVersion}, symbol, true
+}
+
+// hoverSummaries returns the unit of hp and the summaries of the given
+// symbols of its documentation. If the package has no documentation, it
+// returns an explanation for the response instead.
+func hoverSummaries(ctx context.Context, ds internal.DataSource, hp hoverPackage, symbols []string) (_ *internal.UnitMeta, _ map[string]*godoc.SymbolSummary, errText string, err error) {
+	defer derrors.Wrap(&err, "hoverSummaries(ctx, ds, %q, %q)", hp.path, hp.version)
+
+	const notFound = "not found"
+	if err := checkExcluded(ctx, ds, hp.path); err != nil {
+		var serr *serverError
+		if errors.As(err, &serr) && serr.status == http.StatusNotFound {
+			return nil, nil, notFound, nil
+		}
+		return nil, nil, "", err
+	}
+	um, err := ds.GetUnitMeta(ctx, hp.path, internal.UnknownModulePath, hp.version)
+	if errors.Is(err, derrors.NotFound) {
+		return nil, nil, notFound, nil
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !um.IsPackage() {
+		return nil, nil, "not a package", nil
+	}
+	unit, err := ds.GetUnit(ctx, um, internal.WithMain, internal.BuildContext{})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	unit.Documentation = cleanDocumentation(unit.Documentation)
+	if len(unit.Documentation) == 0 {
+		if !unit.IsRedistributable {
+			return nil, nil, "documentation not displayed due to license restrictions", nil
+		}
+		return nil, nil, "no documentation", nil
+	}
+	summaries, err := godoc.SymbolSummariesFromUnit(ctx, unit, symbols)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return um, summaries, "", nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+func TestHoverAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "A")
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	pkg := sample.ModulePath + "/A"
+	q := url.Values{"q": {
+		pkg + "#V",
+		pkg + "@v1.2.3",
+		pkg + "#Nope",
+		sample.ModulePath,
+		"example.com/missing#F",
+		"@v1.0.0",
+	}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/hover?"+q.Encode(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Cache-Control"), "public, max-age=86400"; got != want {
+		t.Errorf("got Cache-Control %q, want %q", got, want)
+	}
+	var got HoverResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := HoverResponse{Hovers: []*Hover{
+		{
+			Query:      pkg + "#V",
+			Path:       pkg,
+			ModulePath: sample.ModulePath,
+			Version:    "v1.2.3",
+			Symbol:     "V",
+			Decl:       "var V int",
+			URL:        "/" + sample.ModulePath + "@v1.2.3/A#V",
+		},
+		{
+			Query:      pkg + "@v1.2.3",
+			Path:       pkg,
+			ModulePath: sample.ModulePath,
+			Version:    "v1.2.3",
+			Decl:       `package p // import "` + pkg + `"`,
+			Synopsis:   "Package p is a package.",
+			URL:        "/" + sample.ModulePath + "@v1.2.3/A",
+		},
+		{Query: pkg + "#Nope", Symbol: "Nope", Error: "no such symbol"},
+		{Query: sample.ModulePath, Error: "not a package"},
+		{Query: "example.com/missing#F", Symbol: "F", Error: "not found"},
+		{Query: "@v1.0.0", Error: "invalid query"},
+	}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(Hover{}, "Doc")); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, query := range []string{"", "?q=a&" + strings.Repeat("q=a&", maxHoverQueries)} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/hover"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: got status %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestParseHoverQuery(t *testing.T) {
+	for _, test := range []struct {
+		in         string
+		want       hoverPackage
+		wantSymbol string
+		wantOK     bool
+	}{
+		{"net/http", hoverPackage{"net/http", version.Latest}, "", true},
+		{"net/http#Client.Do", hoverPackage{"net/http", version.Latest}, "Client.Do", true},
+		{"golang.org/x/mod@v0.5.0#module.Version", hoverPackage{"golang.org/x/mod", "v0.5.0"}, "module.Version", true},
+		{"golang.org/x/mod@v0.5.0/module#Version", hoverPackage{"golang.org/x/mod/module", "v0.5.0"}, "Version", true},
+		{"example.com/m@master", hoverPackage{"example.com/m", "master"}, "", true},
+		{"example.com/m@bad", hoverPackage{}, "", false},
+		{"#F", hoverPackage{}, "", false},
+	} {
+		got, gotSymbol, gotOK := parseHoverQuery(test.in)
+		if got != test.want || gotSymbol != test.wantSymbol || gotOK != test.wantOK {
+			t.Errorf("parseHoverQuery(%q) = %v, %q, %t, want %v, %q, %t",
+				test.in, got, gotSymbol, gotOK, test.want, test.wantSymbol, test.wantOK)
+		}
+	}
+}
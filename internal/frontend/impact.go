@@ -0,0 +1,130 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxImpactModules is the maximum number of module versions visited by a
+// search for the modules that depend on a range of versions of a module.
+const maxImpactModules = 50000
+
+// ImpactResponse is the JSON response of the /api/v1/impact endpoint.
+type ImpactResponse struct {
+	ModulePath string `json:"modulePath"`
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+	// Modules are the latest versions of the modules that depend on an
+	// affected version, ordered by path.
+	Modules       []*postgres.DependentModule `json:"modules"`
+	NumDirect     int                         `json:"numDirect"`
+	NumTransitive int                         `json:"numTransitive"`
+	// Truncated reports whether the search stopped before visiting all the
+	// dependent modules, so that Modules may be incomplete.
+	Truncated bool `json:"truncated"`
+}
+
+// serveImpactAPI handles requests for
+// /api/v1/impact?module=<path>[&introduced=<version>][&fixed=<version>][&format=csv|json].
+// It lists the indexed modules whose latest version depends on a version of
+// the module that is at least introduced and less than fixed, directly or
+// transitively, to scope the modules affected by a vulnerability. Without
+// introduced or fixed, that end of the range is open.
+func (s *Server) serveImpactAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	modulePath := strings.Trim(r.FormValue("module"), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module query parameter"}
+	}
+	introduced := r.FormValue("introduced")
+	fixed := r.FormValue("fixed")
+	for _, v := range []string{introduced, fixed} {
+		if v != "" && !semver.IsValid(v) {
+			return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("invalid version %q", v)}
+		}
+	}
+	if introduced != "" && fixed != "" && semver.Compare(introduced, fixed) >= 0 {
+		return &serverError{status: http.StatusBadRequest, responseText: "introduced must be less than fixed"}
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("unknown format %q", format)}
+	}
+	impact, err := db.GetDependencyImpact(r.Context(), modulePath, introduced, fixed, maxImpactModules)
+	if err != nil {
+		return err
+	}
+	resp := &ImpactResponse{
+		ModulePath:    modulePath,
+		Introduced:    introduced,
+		Fixed:         fixed,
+		Modules:       impact.Modules,
+		NumDirect:     impact.NumDirect,
+		NumTransitive: impact.NumTransitive,
+		Truncated:     impact.Truncated,
+	}
+	if resp.Modules == nil {
+		resp.Modules = []*postgres.DependentModule{}
+	}
+	if format == "csv" {
+		return serveImpactCSV(w, r, resp)
+	}
+	return serveJSON(w, r, resp)
+}
+
+// serveImpactCSV writes resp to w as CSV, one row per dependent module. The
+// counts of direct and transitive dependents are provided in the
+// X-Num-Direct and X-Num-Transitive headers, and the X-Truncated header is
+// set if the list may be incomplete.
+func serveImpactCSV(w http.ResponseWriter, r *http.Request, resp *ImpactResponse) error {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write([]string{"module_path", "version", "dependency", "required_version", "via"}); err != nil {
+		return err
+	}
+	for _, m := range resp.Modules {
+		dep := "transitive"
+		if m.Direct {
+			dep = "direct"
+		}
+		if err := cw.Write([]string{m.ModulePath, m.Version, dep, m.RequiredVersion, m.Via}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("X-Num-Direct", strconv.Itoa(resp.NumDirect))
+	w.Header().Set("X-Num-Transitive", strconv.Itoa(resp.NumTransitive))
+	if resp.Truncated {
+		w.Header().Set("X-Truncated", "true")
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf(r.Context(), "serveImpactCSV: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestImpactAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	requirements := map[string][]*internal.ModuleRequirement{
+		"example.com/a": {{Path: "example.com/vuln", Version: "v1.1.0"}},
+		"example.com/b": {{Path: "example.com/a", Version: "v1.0.0"}},
+	}
+	for _, path := range []string{"example.com/a", "example.com/b"} {
+		m := sample.Module(path, "v1.0.0", "")
+		m.Requirements = requirements[path]
+		postgres.MustInsertModule(ctx, t, testDB, m)
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	for _, test := range []struct {
+		query      string
+		wantStatus int
+		want       *ImpactResponse
+	}{
+		{
+			query:      "module=example.com/vuln&introduced=v1.0.0&fixed=v1.2.0",
+			wantStatus: http.StatusOK,
+			want: &ImpactResponse{
+				ModulePath: "example.com/vuln",
+				Introduced: "v1.0.0",
+				Fixed:      "v1.2.0",
+				Modules: []*postgres.DependentModule{
+					{ModulePath: "example.com/a", Version: "v1.0.0", Direct: true, RequiredVersion: "v1.1.0"},
+					{ModulePath: "example.com/b", Version: "v1.0.0", RequiredVersion: "v1.1.0", Via: "example.com/a"},
+				},
+				NumDirect:     1,
+				NumTransitive: 1,
+			},
+		},
+		{
+			query:      "module=example.com/vuln&fixed=v1.1.0",
+			wantStatus: http.StatusOK,
+			want: &ImpactResponse{
+				ModulePath: "example.com/vuln",
+				Fixed:      "v1.1.0",
+				Modules:    []*postgres.DependentModule{},
+			},
+		},
+		{query: "fixed=v1.1.0", wantStatus: http.StatusBadRequest},
+		{query: "module=example.com/vuln&fixed=1.1", wantStatus: http.StatusBadRequest},
+		{query: "module=example.com/vuln&introduced=v1.2.0&fixed=v1.1.0", wantStatus: http.StatusBadRequest},
+		{query: "module=example.com/vuln&format=xml", wantStatus: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/impact?"+test.query, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.query, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		var got ImpactResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, &got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/impact?module=example.com/vuln&format=csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("csv: got status %d, want %d", w.Code, http.StatusOK)
+	}
+	want := "module_path,version,dependency,required_version,via\n" +
+		"example.com/a,v1.0.0,direct,v1.1.0,\n" +
+		"example.com/b,v1.0.0,transitive,v1.1.0,example.com/a\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("csv: got\n%s\nwant\n%s", got, want)
+	}
+	if got := w.Header().Get("X-Num-Transitive"); got != "1" {
+		t.Errorf("csv: got X-Num-Transitive %q, want %q", got, "1")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxImportGraphPackages is the maximum number of packages shown on the
+// Dependency Graph tab.
+const maxImportGraphPackages = 500
+
+// ImportGraphDetails contains the data for the Dependency Graph tab of a
+// package page: the transitive imports of the package, as a tree.
+type ImportGraphDetails struct {
+	Root        *postgres.ImportGraphNode
+	NumPackages int
+	// Truncated reports whether the graph has more packages than those
+	// shown.
+	Truncated bool
+}
+
+// fetchImportGraphDetails returns the ImportGraphDetails of the package
+// described by um.
+func fetchImportGraphDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*ImportGraphDetails, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		// The graph is computed from the imports of other modules, which
+		// other data sources do not have.
+		return nil, datasourceNotSupportedErr()
+	}
+	g, err := db.GetImportGraph(ctx, um.Path, um.ModulePath, um.Version, maxImportGraphPackages)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportGraphDetails{
+		Root:        g.Root,
+		NumPackages: g.NumPackages,
+		Truncated:   g.Truncated,
+	}, nil
+}
@@ -6,6 +6,7 @@ package frontend
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"golang.org/x/pkgsite/internal"
@@ -31,6 +32,49 @@ type ImportsDetails struct {
 	// StdLib is an array of packages representing the package's imports
 	// that are in the Go standard library.
 	StdLib []string
+
+	// Replacements maps each path in ExternalImports that is affected by a
+	// replace directive in the module's go.mod file to that directive.
+	Replacements map[string]*internal.ModuleReplacement
+
+	// ExternalModules groups ExternalImports by the modules that provide
+	// them, according to the require directives of the module's go.mod
+	// file. It is empty if the requirements are not known.
+	ExternalModules []*ImportedModule
+}
+
+// An ImportedModule is a module that provides some of the imports of a
+// package.
+type ImportedModule struct {
+	// ModulePath and Version are the module and the version of it that is
+	// required by the go.mod file of the importing module. They are empty
+	// for the imports that no requirement provides.
+	ModulePath string
+	Version    string
+	// Indirect reports whether the requirement is marked as indirect.
+	Indirect bool
+	// URL is the page of the module at Version.
+	URL     string
+	Imports []*ImportedPackage
+}
+
+// An ImportedPackage is an import in an ImportedModule. URL is the page of
+// the package at the version of the module that provides it.
+type ImportedPackage struct {
+	Path string
+	URL  string
+}
+
+// replacementsGetter is implemented by data sources that record the replace
+// directives of go.mod files.
+type replacementsGetter interface {
+	GetModuleReplacements(ctx context.Context, modulePath, version string) ([]*internal.ModuleReplacement, error)
+}
+
+// requirementsGetter is implemented by data sources that record the require
+// directives of go.mod files.
+type requirementsGetter interface {
+	GetModuleRequirements(ctx context.Context, modulePath, version string) ([]*internal.ModuleRequirement, error)
 }
 
 // fetchImportsDetails fetches imports for the package version specified by
@@ -58,14 +102,103 @@ func fetchImportsDetails(ctx context.Context, ds internal.DataSource, pkgPath, m
 		}
 	}
 
+	var replacements map[string]*internal.ModuleReplacement
+	if rg, ok := ds.(replacementsGetter); ok && len(externalImports) > 0 {
+		rs, err := rg.GetModuleReplacements(ctx, modulePath, resolvedVersion)
+		if err != nil {
+			return nil, err
+		}
+		replacements = importReplacements(externalImports, rs)
+	}
+	var externalModules []*ImportedModule
+	if rg, ok := ds.(requirementsGetter); ok && len(externalImports) > 0 {
+		rs, err := rg.GetModuleRequirements(ctx, modulePath, resolvedVersion)
+		if err != nil {
+			return nil, err
+		}
+		externalModules = importedModules(externalImports, rs)
+	}
+
 	return &ImportsDetails{
 		ModulePath:      modulePath,
 		ExternalImports: externalImports,
 		InternalImports: moduleImports,
 		StdLib:          std,
+		Replacements:    replacements,
+		ExternalModules: externalModules,
 	}, nil
 }
 
+// importedModules groups importPaths by the module of the requirements rs
+// that provides them, which is the one with the longest path that is a
+// prefix of the import path. The groups are in the order of the first import
+// of each, and the imports that no requirement provides are grouped last. It
+// returns nil if there are no requirements.
+func importedModules(importPaths []string, rs []*internal.ModuleRequirement) []*ImportedModule {
+	if len(rs) == 0 {
+		return nil
+	}
+	var (
+		mods   []*ImportedModule
+		byPath = map[string]*ImportedModule{}
+		other  *ImportedModule
+	)
+	for _, p := range importPaths {
+		var best *internal.ModuleRequirement
+		for _, r := range rs {
+			if (p == r.Path || strings.HasPrefix(p, r.Path+"/")) && (best == nil || len(r.Path) > len(best.Path)) {
+				best = r
+			}
+		}
+		if best == nil {
+			if other == nil {
+				other = &ImportedModule{}
+			}
+			other.Imports = append(other.Imports, &ImportedPackage{Path: p, URL: "/" + p})
+			continue
+		}
+		m := byPath[best.Path]
+		if m == nil {
+			m = &ImportedModule{
+				ModulePath: best.Path,
+				Version:    best.Version,
+				Indirect:   best.Indirect,
+				URL:        constructUnitURL(best.Path, best.Path, best.Version),
+			}
+			byPath[best.Path] = m
+			mods = append(mods, m)
+		}
+		m.Imports = append(m.Imports, &ImportedPackage{Path: p, URL: constructUnitURL(p, best.Path, best.Version)})
+	}
+	if other != nil {
+		mods = append(mods, other)
+	}
+	return mods
+}
+
+// importReplacements returns a map from each import path that is affected by
+// one of the replace directives rs to the directive. When several directives
+// apply, as with nested modules, the one with the longest path wins, since
+// the import path is provided by the module with the longest matching path.
+func importReplacements(importPaths []string, rs []*internal.ModuleReplacement) map[string]*internal.ModuleReplacement {
+	var m map[string]*internal.ModuleReplacement
+	for _, p := range importPaths {
+		var best *internal.ModuleReplacement
+		for _, r := range rs {
+			if r.Replaces(p) && (best == nil || len(r.OldPath) > len(best.OldPath)) {
+				best = r
+			}
+		}
+		if best != nil {
+			if m == nil {
+				m = map[string]*internal.ModuleReplacement{}
+			}
+			m[p] = best
+		}
+	}
+	return m
+}
+
 // ImportedByDetails contains information for the collection of packages that
 // import a given package.
 type ImportedByDetails struct {
@@ -78,86 +211,157 @@ type ImportedByDetails struct {
 	ImportedBy []*Section
 
 	// NumImportedByDisplay is the display text at the top of the imported by
-	// tab section, which shows the imported by count and package limit.
+	// tab section, which shows the imported by count.
 	NumImportedByDisplay string
 
-	// Total is the total number of importers.
+	// Total is the total number of importers, of which ImportedBy holds one
+	// page.
 	Total int
+
+	// Pagination is the position of ImportedBy among all importers.
+	Pagination pagination
+
+	// MajorVersions breaks down the importers of the package by the major
+	// version of its module that they import. It is empty unless the module
+	// has more than one major version.
+	MajorVersions []*MajorVersionImporters
 }
 
-var (
-	// importedByLimit is the maximum number of importers displayed on the imported
-	// by page.
-	// Variable for testing.
-	importedByLimit = 20001
+// MajorVersionImporters counts the importers of the package in one major
+// version of its module.
+type MajorVersionImporters struct {
+	// MajorVersion is the major version, such as "v2". Modules whose path
+	// has no major version suffix are "v1".
+	MajorVersion string
+	// URL is the imported-by tab of the package in that major version.
+	URL         string
+	NumPackages int
+	NumModules  int
+	// Current reports whether this is the major version of the page.
+	Current bool
+}
+
+const (
+	// defaultImportedByLimit is the default number of importers displayed on
+	// a page of the imported by tab.
+	defaultImportedByLimit = 1000
+
+	// maxImportedByLimit is the maximum number of importers displayed on a
+	// page of the imported by tab.
+	maxImportedByLimit = 10000
 )
 
-// fetchImportedByDetails fetches importers for the package version specified by
-// path and version from the database and returns a ImportedByDetails.
-func fetchImportedByDetails(ctx context.Context, ds internal.DataSource, pkgPath, modulePath string) (*ImportedByDetails, error) {
+// maxImportedByCount is the largest number of importers that are counted.
+// Packages with more importers display the number as "100,000+". It is a
+// variable for testing.
+var maxImportedByCount = 10 * maxImportedByLimit
+
+// fetchImportedByDetails fetches the page of importers described by params
+// of the package version specified by path and version from the database and
+// returns a ImportedByDetails.
+func fetchImportedByDetails(ctx context.Context, ds internal.DataSource, pkgPath, modulePath string, params paginationParams) (*ImportedByDetails, error) {
 	db, ok := ds.(*postgres.DB)
 	if !ok {
 		// The proxydatasource does not support the imported by page.
 		return nil, datasourceNotSupportedErr()
 	}
 
-	importedBy, err := db.GetImportedBy(ctx, pkgPath, modulePath, importedByLimit)
+	if params.limit > maxImportedByLimit {
+		params.limit = maxImportedByLimit
+	}
+	importedBy, err := db.GetImportedBy(ctx, pkgPath, modulePath, params.limit, params.offset())
+	if err != nil {
+		return nil, err
+	}
+	// Count one more importer than the maximum, to know whether there are
+	// more.
+	numImportedBy, err := db.CountImportedBy(ctx, pkgPath, modulePath, maxImportedByCount+1)
 	if err != nil {
 		return nil, err
 	}
-	numImportedBy := len(importedBy)
+	capped := numImportedBy > maxImportedByCount
+	if capped {
+		numImportedBy = maxImportedByCount
+	}
 	numImportedBySearch, err := db.GetImportedByCount(ctx, pkgPath, modulePath)
 	if err != nil {
 		return nil, err
 	}
-	if numImportedBy < importedByLimit && numImportedBySearch > numImportedBy {
-		// Unless we hit the limit, numImportedBySearch should never be greater
-		// than numImportedBy. If that happens, log an error so that we can
-		// debug, but continue with generating the page fo the user.
+	if numImportedBySearch > numImportedBy && !capped {
+		// numImportedBySearch should never be greater than numImportedBy. If
+		// that happens, log an error so that we can debug, but continue with
+		// generating the page for the user.
 		log.Errorf(ctx, "pkg %q, module %q: search_documents.num_imported_by %d > numImportedBy %d from imports unique, which shouldn't happen",
 			pkgPath, modulePath, numImportedBySearch, numImportedBy)
 	}
-
-	if numImportedBy >= importedByLimit {
-		importedBy = importedBy[:importedByLimit-1]
-	}
 	sections := Sections(importedBy, nextPrefixAccount)
 
-	// Display the number of importers, taking into account the number we
-	// actually retrieved, the limit on that number, and the imported-by count
-	// in the search_documents table.
+	// Display the number of importers, taking into account the imported-by
+	// count in the search_documents table.
 	pr := message.NewPrinter(middleware.LanguageTag(ctx))
-	var (
-		display string
-		pkgword = "package"
-	)
-	if numImportedBy > 1 {
-		pkgword = "packages"
-	}
+	var display string
 	switch {
-	// If there are more importers than the limit, and the search number is
-	// greater, use the search number and indicate that we're displaying fewer.
-	case numImportedBy >= importedByLimit && numImportedBySearch > numImportedBy:
-		display = pr.Sprintf("%d (displaying %d %s)", numImportedBySearch, importedByLimit-1, pkgword)
-	// If we've exceeded the limit but the search number is smaller, we don't
-	// know the true number, so say so.
-	case numImportedBy >= importedByLimit:
-		display = pr.Sprintf("%d (displaying more than %d %s, including internal and invalid packages)", numImportedBySearch, importedByLimit-1, pkgword)
-	// If we haven't exceeded the limit and we have more than the search number,
-	// then display both numbers so users coming from the search page won't see
-	// a mismatch.
+	case capped:
+		display = pr.Sprintf("%d+", numImportedBy)
 	case numImportedBy > numImportedBySearch:
-		display = pr.Sprintf("%d (displaying %d %s, including internal and invalid packages)", numImportedBySearch, numImportedBy, pkgword)
-	// Otherwise, we have all the packages, and the search number is either
-	// wrong (perhaps it hasn't been recomputed yet) or it is the same as the
-	// retrieved number. In that case, just display the retrieved number.
+		// Display both numbers so users coming from the search page won't
+		// see a mismatch.
+		pkgword := "package"
+		if numImportedBy > 1 {
+			pkgword = "packages"
+		}
+		display = pr.Sprintf("%d (%d %s, including internal and invalid packages)", numImportedBySearch, numImportedBy, pkgword)
 	default:
+		// The search number is either wrong (perhaps it hasn't been
+		// recomputed yet) or it is the same as the counted number. In that
+		// case, just display the counted number.
 		display = pr.Sprint(numImportedBy)
 	}
+	// Past the counted importers, keep offering the next page as long as
+	// pages are full.
+	total := numImportedBy
+	if end := params.offset() + len(importedBy); capped && len(importedBy) == params.limit && end >= total {
+		total = end + 1
+	}
+	var majors []*MajorVersionImporters
+	if modulePath != stdlib.ModulePath {
+		majors, err = importedByMajorVersions(ctx, db, pkgPath, modulePath)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &ImportedByDetails{
 		ModulePath:           modulePath,
 		ImportedBy:           sections,
 		NumImportedByDisplay: display,
 		Total:                numImportedBy,
+		Pagination:           newPagination(params, len(importedBy), total),
+		MajorVersions:        majors,
 	}, nil
 }
+
+// importedByMajorVersions returns the number of importers of the package at
+// pkgPath in each major version of its module, so that maintainers can see
+// how many importers still use an older major version. It returns nil if the
+// module has only one major version.
+func importedByMajorVersions(ctx context.Context, db *postgres.DB, pkgPath, modulePath string) ([]*MajorVersionImporters, error) {
+	mvs, err := db.GetImportedByMajorVersions(ctx, pkgPath, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(mvs) < 2 {
+		return nil, nil
+	}
+	var majors []*MajorVersionImporters
+	for _, mv := range mvs {
+		_, n := internal.SeriesPathAndMajorVersion(mv.ModulePath)
+		majors = append(majors, &MajorVersionImporters{
+			MajorVersion: fmt.Sprintf("v%d", n),
+			URL:          "/" + mv.PackagePath + "?tab=importedby",
+			NumPackages:  mv.NumPackages,
+			NumModules:   mv.NumModules,
+			Current:      mv.ModulePath == modulePath,
+		})
+	}
+	return majors, nil
+}
@@ -6,10 +6,13 @@ package frontend
 
 import (
 	"context"
+	"fmt"
+	"net/http/httptest"
 	"path"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/testing/sample"
@@ -115,7 +118,7 @@ func TestFetchImportedByDetails(t *testing.T) {
 			pkg: pkg2,
 			wantDetails: &ImportedByDetails{
 				ImportedBy:           []*Section{{Prefix: pkg3.Path, NumLines: 0}},
-				NumImportedByDisplay: "0 (displaying 1 package, including internal and invalid packages)",
+				NumImportedByDisplay: "0 (1 package, including internal and invalid packages)",
 				Total:                1,
 			},
 		},
@@ -126,7 +129,7 @@ func TestFetchImportedByDetails(t *testing.T) {
 					{Prefix: pkg2.Path, NumLines: 0},
 					{Prefix: pkg3.Path, NumLines: 0},
 				},
-				NumImportedByDisplay: "0 (displaying 2 packages, including internal and invalid packages)",
+				NumImportedByDisplay: "0 (2 packages, including internal and invalid packages)",
 				Total:                2,
 			},
 		},
@@ -142,14 +145,65 @@ func TestFetchImportedByDetails(t *testing.T) {
 	}
 }
 
-func TestFetchImportedByDetails_ExceedsLimit(t *testing.T) {
+func TestFetchImportedByDetails_Pagination(t *testing.T) {
 	defer postgres.ResetTestDB(testDB, t)
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
 
-	old := importedByLimit
-	importedByLimit = 3
-	defer func() { importedByLimit = old }()
+	m := sample.Module("m.com/a", sample.VersionString, "foo")
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	for _, mod := range []string{"m1.com/a", "m2.com/a", "m3.com/a"} {
+		m2 := sample.Module(mod, sample.VersionString, "p")
+		m2.Packages()[0].Imports = []string{"m.com/a/foo"}
+		postgres.MustInsertModule(ctx, t, testDB, m2)
+	}
+	pkg := m.Packages()[0]
+	for _, test := range []struct {
+		page           int
+		wantImportedBy []*Section
+		wantPrev       int
+		wantNext       int
+	}{
+		{
+			page:           1,
+			wantImportedBy: []*Section{{Prefix: "m1.com/a/p"}, {Prefix: "m2.com/a/p"}},
+			wantNext:       2,
+		},
+		{
+			page:           2,
+			wantImportedBy: []*Section{{Prefix: "m3.com/a/p"}},
+			wantPrev:       1,
+		},
+	} {
+		t.Run(fmt.Sprint(test.page), func(t *testing.T) {
+			r := httptest.NewRequest("GET", fmt.Sprintf("/m.com/a/foo?tab=importedby&limit=2&page=%d", test.page), nil)
+			got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath, newPaginationParams(r, defaultImportedByLimit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.wantImportedBy, got.ImportedBy); diff != "" {
+				t.Errorf("ImportedBy mismatch (-want +got):\n%s", diff)
+			}
+			if want := "0 (3 packages, including internal and invalid packages)"; got.NumImportedByDisplay != want {
+				t.Errorf("NumImportedByDisplay = %q, want %q", got.NumImportedByDisplay, want)
+			}
+			p := got.Pagination
+			if got.Total != 3 || p.TotalCount != 3 || p.PrevPage != test.wantPrev || p.NextPage != test.wantNext {
+				t.Errorf("got Total %d, TotalCount %d, PrevPage %d, NextPage %d; want 3, 3, %d, %d",
+					got.Total, p.TotalCount, p.PrevPage, p.NextPage, test.wantPrev, test.wantNext)
+			}
+		})
+	}
+}
+
+func TestFetchImportedByDetails_Capped(t *testing.T) {
+	defer postgres.ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	old := maxImportedByCount
+	maxImportedByCount = 2
+	defer func() { maxImportedByCount = old }()
 
 	m := sample.Module("m.com/a", sample.VersionString, "foo")
 	postgres.MustInsertModule(ctx, t, testDB, m)
@@ -158,27 +212,131 @@ func TestFetchImportedByDetails_ExceedsLimit(t *testing.T) {
 		m2.Packages()[0].Imports = []string{"m.com/a/foo"}
 		postgres.MustInsertModule(ctx, t, testDB, m2)
 	}
+	pkg := m.Packages()[0]
+	for _, test := range []struct {
+		page     int
+		wantNext int
+	}{
+		{page: 1, wantNext: 2},
+		{page: 2, wantNext: 0},
+	} {
+		t.Run(fmt.Sprint(test.page), func(t *testing.T) {
+			r := httptest.NewRequest("GET", fmt.Sprintf("/m.com/a/foo?tab=importedby&limit=2&page=%d", test.page), nil)
+			got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath, newPaginationParams(r, defaultImportedByLimit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "2+"; got.NumImportedByDisplay != want {
+				t.Errorf("NumImportedByDisplay = %q, want %q", got.NumImportedByDisplay, want)
+			}
+			if got.Total != 2 || got.Pagination.NextPage != test.wantNext {
+				t.Errorf("got Total %d, NextPage %d; want 2, %d", got.Total, got.Pagination.NextPage, test.wantNext)
+			}
+		})
+	}
+}
+
+func TestFetchImportedByDetails_MajorVersions(t *testing.T) {
+	defer postgres.ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	v1 := sample.Module("m.com/a", "v1.0.0", "foo")
+	v2 := sample.Module("m.com/a/v2", "v2.0.0", "foo")
+	postgres.MustInsertModule(ctx, t, testDB, v1)
+	postgres.MustInsertModule(ctx, t, testDB, v2)
+	for _, mod := range []string{"m1.com/a", "m2.com/a"} {
+		m := sample.Module(mod, sample.VersionString, "p")
+		m.Packages()[0].Imports = []string{"m.com/a/foo"}
+		postgres.MustInsertModule(ctx, t, testDB, m)
+	}
+	m3 := sample.Module("m3.com/a", sample.VersionString, "p")
+	m3.Packages()[0].Imports = []string{"m.com/a/v2/foo"}
+	postgres.MustInsertModule(ctx, t, testDB, m3)
+
 	wantDetails := &ImportedByDetails{
-		ModulePath: "m.com/a",
-		ImportedBy: []*Section{
-			{Prefix: "m1.com/a/p"},
-			{Prefix: "m2.com/a/p"},
+		ImportedBy:           []*Section{{Prefix: "m3.com/a/p"}},
+		NumImportedByDisplay: "0 (1 package, including internal and invalid packages)",
+		Total:                1,
+		MajorVersions: []*MajorVersionImporters{
+			{MajorVersion: "v1", URL: "/m.com/a/foo?tab=importedby", NumPackages: 2, NumModules: 2},
+			{MajorVersion: "v2", URL: "/m.com/a/v2/foo?tab=importedby", NumPackages: 1, NumModules: 1, Current: true},
 		},
-
-		NumImportedByDisplay: "0 (displaying more than 2 packages, including internal and invalid packages)",
-		Total:                3,
 	}
-	checkFetchImportedByDetails(ctx, t, m.Packages()[0], wantDetails)
+	checkFetchImportedByDetails(ctx, t, v2.Packages()[0], wantDetails)
 }
 
 func checkFetchImportedByDetails(ctx context.Context, t *testing.T, pkg *internal.Unit, wantDetails *ImportedByDetails) {
-	got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath)
+	r := httptest.NewRequest("GET", "/"+pkg.Path+"?tab=importedby", nil)
+	got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath, newPaginationParams(r, defaultImportedByLimit))
 	if err != nil {
 		t.Fatalf("fetchImportedByDetails(ctx, db, %q) = %v err = %v, want %v",
 			pkg.Path, got, err, wantDetails)
 	}
 	wantDetails.ModulePath = pkg.ModulePath
-	if diff := cmp.Diff(wantDetails, got); diff != "" {
+	if diff := cmp.Diff(wantDetails, got, cmpopts.IgnoreFields(ImportedByDetails{}, "Pagination")); diff != "" {
 		t.Errorf("fetchImportedByDetails(ctx, db, %q) mismatch (-want +got):\n%s", pkg.Path, diff)
 	}
 }
+
+func TestImportReplacements(t *testing.T) {
+	a := &internal.ModuleReplacement{OldPath: "example.com/a", NewPath: "../a"}
+	nested := &internal.ModuleReplacement{OldPath: "example.com/a/nested", NewPath: "example.com/fork", NewVersion: "v1.0.0"}
+	got := importReplacements(
+		[]string{"example.com/a", "example.com/a/p", "example.com/a/nested/q", "example.com/ab", "example.com/other"},
+		[]*internal.ModuleReplacement{a, nested})
+	want := map[string]*internal.ModuleReplacement{
+		"example.com/a":          a,
+		"example.com/a/p":        a,
+		"example.com/a/nested/q": nested,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if got := importReplacements([]string{"example.com/other"}, []*internal.ModuleReplacement{a}); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestImportedModules(t *testing.T) {
+	rs := []*internal.ModuleRequirement{
+		{Path: "example.com/a", Version: "v1.2.0"},
+		{Path: "example.com/a/nested", Version: "v0.1.0", Indirect: true},
+		{Path: "example.com/b", Version: "v1.0.0"},
+	}
+	got := importedModules(
+		[]string{"example.com/a", "example.com/a/nested/q", "example.com/ab", "example.com/a/p", "example.com/other"},
+		rs)
+	want := []*ImportedModule{
+		{
+			ModulePath: "example.com/a",
+			Version:    "v1.2.0",
+			URL:        "/example.com/a@v1.2.0",
+			Imports: []*ImportedPackage{
+				{Path: "example.com/a", URL: "/example.com/a@v1.2.0"},
+				{Path: "example.com/a/p", URL: "/example.com/a@v1.2.0/p"},
+			},
+		},
+		{
+			ModulePath: "example.com/a/nested",
+			Version:    "v0.1.0",
+			Indirect:   true,
+			URL:        "/example.com/a/nested@v0.1.0",
+			Imports: []*ImportedPackage{
+				{Path: "example.com/a/nested/q", URL: "/example.com/a/nested@v0.1.0/q"},
+			},
+		},
+		{
+			Imports: []*ImportedPackage{
+				{Path: "example.com/ab", URL: "/example.com/ab"},
+				{Path: "example.com/other", URL: "/example.com/other"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if got := importedModules([]string{"example.com/a"}, nil); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
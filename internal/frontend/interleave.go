@@ -0,0 +1,193 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/sync/errgroup"
+)
+
+// SearchClickPath is the path that clicks on interleaved search results are
+// posted to.
+const SearchClickPath = "/search-click"
+
+// interleavedRanking is the alternative ranking that is compared with the
+// default one when the search-interleaving experiment is active.
+var interleavedRanking = postgres.RankingRecentPopularity
+
+// interleaving describes an interleaved search results page.
+type interleaving struct {
+	// ImpressionID is a random ID for the page, which allows the clicks on
+	// it to be compared without identifying the user or the query.
+	ImpressionID string
+	Ranking      string
+}
+
+// shouldInterleave reports whether the package search results for r should
+// interleave the default and alternative rankings.
+func shouldInterleave(r *http.Request) bool {
+	return experiment.IsActive(r.Context(), internal.ExperimentSearchInterleaving) &&
+		searchMode(r) == searchModePackage
+}
+
+// searchInterleaved runs the search with both the default ranking and the
+// alternative one, and returns their interleaved results along with the team
+// of each.
+func searchInterleaved(ctx context.Context, db *postgres.DB, q string, opts postgres.SearchOptions) (_ []*postgres.SearchResult, teams []string, err error) {
+	defer derrors.Wrap(&err, "searchInterleaved(ctx, db, %q, %q)", q, interleavedRanking)
+
+	var control, treatment []*postgres.SearchResult
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		control, err = db.Search(gctx, q, opts)
+		return err
+	})
+	g.Go(func() error {
+		topts := opts
+		topts.Ranking = interleavedRanking
+		var err error
+		treatment, err = db.Search(gctx, q, topts)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	results, teams := interleave(control, treatment, opts.MaxResults, func() bool { return mrand.Intn(2) == 0 })
+	return results, teams, nil
+}
+
+// interleave merges the control and treatment rankings into a list of at most
+// n results using team-draft interleaving: in each round, the ranking that
+// contributed fewer results so far, or a random one if both contributed the
+// same number, picks its highest-ranked result that is not already in the
+// list. Clicks on the merged list then tell which ranking users prefer,
+// without the bias of showing one ranking first.
+//
+// Results are compared by module path, since search results are grouped by
+// module. The number of results is taken from the control ranking.
+// controlFirst breaks ties.
+func interleave(control, treatment []*postgres.SearchResult, n int, controlFirst func() bool) (results []*postgres.SearchResult, teams []string) {
+	seen := map[string]bool{}
+	var i, j, nControl, nTreatment int
+	for len(results) < n {
+		for i < len(control) && seen[control[i].ModulePath] {
+			i++
+		}
+		for j < len(treatment) && seen[treatment[j].ModulePath] {
+			j++
+		}
+		if i == len(control) && j == len(treatment) {
+			break
+		}
+		var pickControl bool
+		switch {
+		case i == len(control):
+			pickControl = false
+		case j == len(treatment):
+			pickControl = true
+		case nControl != nTreatment:
+			pickControl = nControl < nTreatment
+		default:
+			pickControl = controlFirst()
+		}
+		var r *postgres.SearchResult
+		if pickControl {
+			r = control[i]
+			nControl++
+			teams = append(teams, postgres.TeamControl)
+		} else {
+			r = treatment[j]
+			nTreatment++
+			teams = append(teams, postgres.TeamTreatment)
+		}
+		seen[r.ModulePath] = true
+		results = append(results, r)
+	}
+	if len(control) > 0 {
+		for _, r := range results {
+			r.NumResults = control[0].NumResults
+		}
+	}
+	return results, teams
+}
+
+// newImpressionID returns a random ID for an interleaved results page.
+func newImpressionID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+var impressionIDRegexp = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// serveSearchClick stores a click on a result of an interleaved search
+// results page. Clicks are sent by the search page with navigator.sendBeacon,
+// so the response body is ignored. They are dropped if the data source is not
+// a database.
+func (s *Server) serveSearchClick(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveSearchClick")
+
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 1024)
+	click, err := parseSearchClick(r)
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	if err := db.InsertSearchClick(r.Context(), click); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// parseSearchClick parses and validates the form of a search click request.
+func parseSearchClick(r *http.Request) (_ *postgres.SearchClick, err error) {
+	defer derrors.Wrap(&err, "parseSearchClick")
+
+	if err := r.ParseForm(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	c := &postgres.SearchClick{
+		ImpressionID: r.FormValue("impression"),
+		Ranking:      r.FormValue("ranking"),
+		Team:         r.FormValue("team"),
+	}
+	if !impressionIDRegexp.MatchString(c.ImpressionID) {
+		return nil, derrors.InvalidArgument
+	}
+	if _, ok := postgres.SearchRankings[c.Ranking]; !ok {
+		return nil, derrors.InvalidArgument
+	}
+	if c.Team != postgres.TeamControl && c.Team != postgres.TeamTreatment {
+		return nil, derrors.InvalidArgument
+	}
+	c.Position, err = strconv.Atoi(r.FormValue("position"))
+	if err != nil || c.Position < 0 || c.Position >= maxSearchPageSize {
+		return nil, derrors.InvalidArgument
+	}
+	return c, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestInterleave(t *testing.T) {
+	results := func(mods ...string) []*postgres.SearchResult {
+		var rs []*postgres.SearchResult
+		for _, m := range mods {
+			rs = append(rs, &postgres.SearchResult{ModulePath: m})
+		}
+		return rs
+	}
+	const (
+		c  = postgres.TeamControl
+		tr = postgres.TeamTreatment
+	)
+	for _, test := range []struct {
+		name               string
+		control, treatment []string
+		n                  int
+		controlFirst       bool
+		wantMods           []string
+		wantTeams          []string
+	}{
+		{
+			name:         "control first",
+			control:      []string{"a", "b", "c"},
+			treatment:    []string{"b", "d", "a"},
+			n:            10,
+			controlFirst: true,
+			wantMods:     []string{"a", "b", "c", "d"},
+			wantTeams:    []string{c, tr, c, tr},
+		},
+		{
+			name:      "treatment first",
+			control:   []string{"a", "b", "c"},
+			treatment: []string{"b", "d", "a"},
+			n:         10,
+			wantMods:  []string{"b", "a", "d", "c"},
+			wantTeams: []string{tr, c, tr, c},
+		},
+		{
+			name:         "limit",
+			control:      []string{"a", "b", "c"},
+			treatment:    []string{"d", "e", "f"},
+			n:            3,
+			controlFirst: true,
+			wantMods:     []string{"a", "d", "b"},
+			wantTeams:    []string{c, tr, c},
+		},
+		{
+			name:         "one ranking exhausted",
+			control:      []string{"a"},
+			treatment:    []string{"a", "b", "c"},
+			n:            10,
+			controlFirst: true,
+			wantMods:     []string{"a", "b", "c"},
+			wantTeams:    []string{c, tr, tr},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotTeams := interleave(results(test.control...), results(test.treatment...), test.n,
+				func() bool { return test.controlFirst })
+			var gotMods []string
+			for _, r := range got {
+				gotMods = append(gotMods, r.ModulePath)
+			}
+			if diff := cmp.Diff(test.wantMods, gotMods); diff != "" {
+				t.Errorf("modules mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantTeams, gotTeams); diff != "" {
+				t.Errorf("teams mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseSearchClick(t *testing.T) {
+	for _, test := range []struct {
+		body    string
+		want    *postgres.SearchClick
+		wantErr bool
+	}{
+		{
+			body: "impression=0123456789abcdef&ranking=damped-popularity&team=treatment&position=3",
+			want: &postgres.SearchClick{
+				ImpressionID: "0123456789abcdef",
+				Ranking:      postgres.RankingDampedPopularity,
+				Team:         postgres.TeamTreatment,
+				Position:     3,
+			},
+		},
+		{body: "impression=xyz&ranking=damped-popularity&team=control&position=0", wantErr: true},
+		{body: "impression=0123456789abcdef&ranking=unknown&team=control&position=0", wantErr: true},
+		{body: "impression=0123456789abcdef&ranking=damped-popularity&team=other&position=0", wantErr: true},
+		{body: "impression=0123456789abcdef&ranking=damped-popularity&team=control&position=-1", wantErr: true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, SearchClickPath, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		got, err := parseSearchClick(req)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error %t", test.body, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q: mismatch (-want, +got):\n%s", test.body, diff)
+		}
+	}
+}
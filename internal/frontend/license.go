@@ -7,12 +7,16 @@ package frontend
 import (
 	"bytes"
 	"context"
+	"path"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/stdlib"
 )
 
 // License contains information used for a single license section.
@@ -25,6 +29,68 @@ type License struct {
 // LicensesDetails contains license information for a package or module.
 type LicensesDetails struct {
 	Licenses []License
+
+	// Groups holds the same licenses as Licenses, grouped by the directory
+	// that contains the license file.
+	Groups []*LicenseGroup
+}
+
+// LicenseGroup is a set of licenses whose files are in the same directory of
+// a module. A license file applies to the directory containing it and all of
+// that directory's subdirectories.
+type LicenseGroup struct {
+	// Dir is the directory containing the license files, relative to the
+	// module root. It is empty for the module root.
+	Dir string
+
+	// Path is the full path of the directory, starting with the module path.
+	Path string
+
+	Anchor   safehtml.Identifier
+	Licenses []License
+}
+
+// IsChoice reports whether the licenses of the group are offered as
+// alternatives. Several license files in a directory usually all apply, as
+// with a LICENSE and a PATENTS file, so they are only presented as
+// alternatives when they have a recognized dual-license layout.
+func (g *LicenseGroup) IsChoice() bool {
+	var mds []*licenses.Metadata
+	for _, l := range g.Licenses {
+		mds = append(mds, l.Metadata)
+	}
+	return isDualLicense(mds)
+}
+
+// namedLicenseFileRx matches the name of a license file that is named after
+// the license it contains, like LICENSE-MIT or LICENSE_APACHE-2.0.txt. The
+// submatch is the name of the license.
+var namedLicenseFileRx = regexp.MustCompile(`(?i)^(?:licen[cs]e|copying)[-_.](.+?)(?:\.(?:md|markdown|txt))?$`)
+
+// isDualLicense reports whether the license files mds, which are in the same
+// directory, have the layout of a dual license, like LICENSE-MIT and
+// LICENSE-APACHE: there are several files, and each contains a single
+// license and is named after it.
+func isDualLicense(mds []*licenses.Metadata) bool {
+	if len(mds) < 2 {
+		return false
+	}
+	for _, md := range mds {
+		m := namedLicenseFileRx.FindStringSubmatch(path.Base(md.FilePath))
+		if m == nil || len(md.Types) != 1 {
+			return false
+		}
+		if !strings.HasPrefix(normalizeLicenseName(md.Types[0]), normalizeLicenseName(m[1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLicenseName returns name in upper case without punctuation, so
+// that "Apache-2.0" matches "APACHE2".
+func normalizeLicenseName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", "_", "", ".", "").Replace(name))
 }
 
 // LicenseMetadata contains license metadata that is used in the package
@@ -32,6 +98,12 @@ type LicensesDetails struct {
 type LicenseMetadata struct {
 	Type   string
 	Anchor safehtml.Identifier
+
+	// IsAlternative reports whether this license comes from a different file
+	// in the same directory as the previous license, and the files of that
+	// directory have the layout of a dual license, making it an alternative
+	// to that license rather than an additional one.
+	IsAlternative bool
 }
 
 // fetchLicensesDetails fetches license data for the package version specified by
@@ -41,7 +113,48 @@ func fetchLicensesDetails(ctx context.Context, ds internal.DataSource, um *inter
 	if err != nil {
 		return nil, err
 	}
-	return &LicensesDetails{Licenses: transformLicenses(um.ModulePath, um.Version, u.LicenseContents)}, nil
+	lics := transformLicenses(um.ModulePath, um.Version, u.LicenseContents)
+	return &LicensesDetails{
+		Licenses: lics,
+		Groups:   groupLicenses(um.ModulePath, lics),
+	}, nil
+}
+
+// groupLicenses groups licenses by the directory of their file path. The
+// groups are in the order in which their directories first appear in lics.
+func groupLicenses(modulePath string, lics []License) []*LicenseGroup {
+	var groups []*LicenseGroup
+	byDir := map[string]*LicenseGroup{}
+	for _, l := range lics {
+		dir := licenseDir(l.FilePath)
+		g, ok := byDir[dir]
+		if !ok {
+			g = &LicenseGroup{
+				Dir:  dir,
+				Path: path.Join(modulePath, dir),
+			}
+			if modulePath == stdlib.ModulePath {
+				g.Path = dir
+			}
+			byDir[dir] = g
+			groups = append(groups, g)
+		}
+		g.Licenses = append(g.Licenses, l)
+	}
+	for i, g := range groups {
+		g.Anchor = safehtml.IdentifierFromConstantPrefix("licdir", strconv.Itoa(i))
+	}
+	return groups
+}
+
+// licenseDir returns the directory of the license file at filePath, or the
+// empty string if the file is at the module root.
+func licenseDir(filePath string) string {
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return ""
+	}
+	return dir
 }
 
 // transformLicenses transforms licenses.License into a License
@@ -73,12 +186,21 @@ func transformLicenseMetadata(dbLicenses []*licenses.Metadata) []LicenseMetadata
 		filePaths = append(filePaths, l.FilePath)
 	}
 	anchors := licenseAnchors(filePaths)
+	byDir := map[string][]*licenses.Metadata{}
+	for _, l := range dbLicenses {
+		dir := licenseDir(l.FilePath)
+		byDir[dir] = append(byDir[dir], l)
+	}
 	for i, l := range dbLicenses {
 		anchor := anchors[i]
-		for _, typ := range l.Types {
+		dir := licenseDir(l.FilePath)
+		for j, typ := range l.Types {
 			mds = append(mds, LicenseMetadata{
 				Type:   typ,
 				Anchor: anchor,
+				// Only the first type from a file is an alternative to the
+				// types of the previous file.
+				IsAlternative: j == 0 && i > 0 && licenseDir(dbLicenses[i-1].FilePath) == dir && isDualLicense(byDir[dir]),
 			})
 		}
 	}
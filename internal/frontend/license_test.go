@@ -43,6 +43,82 @@ func TestLicenseAnchors(t *testing.T) {
 	}
 }
 
+func TestGroupLicenses(t *testing.T) {
+	lic := func(filePath, typ string) License {
+		return License{License: &licenses.License{Metadata: &licenses.Metadata{FilePath: filePath, Types: []string{typ}}}}
+	}
+	lics := []License{
+		lic("vendor/x/LICENSE", "BSD-3-Clause"),
+		lic("vendor/x/PATENTS", "BSD-3-Clause"),
+		lic("LICENSE-APACHE", "Apache-2.0"),
+		lic("LICENSE-MIT", "MIT"),
+	}
+	got := groupLicenses("example.com/m", lics)
+	type group struct {
+		Dir, Path, Anchor string
+		Files             []string
+		IsChoice          bool
+	}
+	var gotGroups []group
+	for _, g := range got {
+		gg := group{Dir: g.Dir, Path: g.Path, Anchor: g.Anchor.String(), IsChoice: g.IsChoice()}
+		for _, l := range g.Licenses {
+			gg.Files = append(gg.Files, l.FilePath)
+		}
+		gotGroups = append(gotGroups, gg)
+	}
+	want := []group{
+		{Dir: "vendor/x", Path: "example.com/m/vendor/x", Anchor: "licdir-0", Files: []string{"vendor/x/LICENSE", "vendor/x/PATENTS"}},
+		{Dir: "", Path: "example.com/m", Anchor: "licdir-1", Files: []string{"LICENSE-APACHE", "LICENSE-MIT"}, IsChoice: true},
+	}
+	if diff := cmp.Diff(want, gotGroups); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsDualLicense(t *testing.T) {
+	md := func(filePath string, types ...string) *licenses.Metadata {
+		return &licenses.Metadata{FilePath: filePath, Types: types}
+	}
+	for _, test := range []struct {
+		name string
+		mds  []*licenses.Metadata
+		want bool
+	}{
+		{"single", []*licenses.Metadata{md("LICENSE-MIT", "MIT")}, false},
+		{"apache and mit", []*licenses.Metadata{md("LICENSE-APACHE", "Apache-2.0"), md("LICENSE-MIT", "MIT")}, true},
+		{"versions and extensions", []*licenses.Metadata{md("LICENCE_APACHE2.txt", "Apache-2.0"), md("license.mit.md", "MIT")}, true},
+		{"license and patents", []*licenses.Metadata{md("LICENSE", "BSD-3-Clause"), md("PATENTS", "BSD-3-Clause")}, false},
+		{"third party", []*licenses.Metadata{md("LICENSE-MIT", "MIT"), md("LICENSE-THIRD-PARTY", "BSD-3-Clause")}, false},
+		{"misnamed", []*licenses.Metadata{md("LICENSE-MIT", "MIT"), md("LICENSE-APACHE", "BSD-3-Clause")}, false},
+		{"several types", []*licenses.Metadata{md("LICENSE-MIT", "MIT", "ISC"), md("LICENSE-APACHE", "Apache-2.0")}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isDualLicense(test.mds); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTransformLicenseMetadataAlternatives(t *testing.T) {
+	got := transformLicenseMetadata([]*licenses.Metadata{
+		{Types: []string{"BSD-3-Clause"}, FilePath: "a/LICENSE"},
+		{Types: []string{"BSD-3-Clause"}, FilePath: "a/PATENTS"},
+		{Types: []string{"Apache-2.0"}, FilePath: "LICENSE-APACHE"},
+		{Types: []string{"MIT"}, FilePath: "LICENSE-MIT"},
+	})
+	var gotAlts []bool
+	for _, md := range got {
+		gotAlts = append(gotAlts, md.IsAlternative)
+	}
+	// BSD-3-Clause, BSD-3-Clause, Apache-2.0 or MIT
+	want := []bool{false, false, false, true}
+	if diff := cmp.Diff(want, gotAlts); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestFetchLicensesDetails(t *testing.T) {
 	testModule := sample.Module(sample.ModulePath, "v1.2.3", "A/B")
 	stdlibModule := sample.Module(stdlib.ModulePath, "v1.13.0", "cmd/go")
@@ -139,8 +215,11 @@ func TestFetchLicensesDetails(t *testing.T) {
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			wantDetails := &LicensesDetails{Licenses: transformLicenses(
-				test.modulePath, test.version, test.want)}
+			wantLicenses := transformLicenses(test.modulePath, test.version, test.want)
+			wantDetails := &LicensesDetails{
+				Licenses: wantLicenses,
+				Groups:   groupLicenses(test.modulePath, wantLicenses),
+			}
 			got, err := fetchLicensesDetails(ctx, testDB, &internal.UnitMeta{
 				Path: test.fullPath,
 				ModuleInfo: internal.ModuleInfo{
@@ -7,6 +7,9 @@ package frontend
 import (
 	"context"
 	"errors"
+	"net/url"
+	"path"
+	"strings"
 
 	"github.com/google/safehtml"
 	"github.com/google/safehtml/template"
@@ -62,6 +65,20 @@ type MainDetails struct {
 	// is not supported when using a datasource proxy.
 	ImportedByCount string
 
+	// TestStats summarizes the tests of the package, as in "Tests: 42 ·
+	// Benchmarks: 3 · Fuzz: 2 · Examples: 10". It is empty if the package has
+	// no tests, or if they were not counted.
+	TestStats string
+
+	// FuzzTargets are the fuzz targets of the package, listed in the Fuzzing
+	// section.
+	FuzzTargets []*FuzzTarget
+
+	// Benchmarks are the benchmarks of the package and their uploaded
+	// results, listed in the Benchmarks section. It is nil if the package has
+	// neither.
+	Benchmarks *BenchmarkDetails
+
 	DocBody       safehtml.HTML
 	DocOutline    safehtml.HTML
 	MobileOutline safehtml.HTML
@@ -77,9 +94,30 @@ type MainDetails struct {
 	// BuildContexts holds the values for build contexts available for the doc.
 	BuildContexts []internal.BuildContext
 
-	// SourceFiles contains .go files for the package.
+	// SourceFiles contains .go files for the package, and the .s files
+	// implementing its functions.
 	SourceFiles []*File
 
+	// EmbeddedFiles contains the files embedded in the package by
+	// //go:embed directives.
+	EmbeddedFiles []*EmbeddedFile
+
+	// SymbolNotices explains why symbols that were in other versions of the
+	// package are not in its documentation.
+	SymbolNotices []*SymbolNotice
+
+	// DocTruncated reports whether the documentation is incomplete because
+	// loading it exceeded a resource limit.
+	DocTruncated bool
+
+	// Migration describes how to upgrade to the latest major version of the
+	// module, if it is later than the module of the unit.
+	Migration *Migration
+
+	// Metadata is the information that the authors of the module provided
+	// in its .pkgsite.yaml file. It is nil if there is none.
+	Metadata *ModuleMetadata
+
 	// RepositoryURL is the URL to the repository containing the package.
 	RepositoryURL string
 
@@ -105,8 +143,16 @@ type File struct {
 	URL  string
 }
 
+// EmbeddedFile is a file embedded in a package. The documentation links to
+// it by its ID.
+type EmbeddedFile struct {
+	File
+	ID safehtml.Identifier
+}
+
 func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta,
-	requestedVersion string, expandReadme bool, bc internal.BuildContext) (_ *MainDetails, err error) {
+	requestedVersion string, expandReadme, showInternal bool, bc internal.BuildContext,
+	getVulnEntries vulnEntriesFunc, mdOpts markdownOptions) (_ *MainDetails, err error) {
 	defer middleware.ElapsedStat(ctx, "fetchMainDetails")()
 
 	unit, err := ds.GetUnit(ctx, um, internal.WithMain, bc)
@@ -121,7 +167,8 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 	if err != nil {
 		return nil, err
 	}
-	readme, err := readmeContent(ctx, unit)
+	mdOpts.resolveLink = readmeLinkResolver(ctx, ds, unit, requestedVersion)
+	readme, err := readmeContent(ctx, unit, mdOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +176,8 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 		docParts           = &dochtml.Parts{}
 		docLinks, modLinks []link
 		files              []*File
+		embeddedFiles      []*EmbeddedFile
+		symbolNoticeList   []*SymbolNotice
 		synopsis           string
 		goos, goarch       string
 		buildContexts      []internal.BuildContext
@@ -159,7 +208,12 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 			return nil, err
 		}
 
-		docParts, err = getHTML(ctx, unit, docPkg, unit.SymbolHistory, bc)
+		symbolVulns, err := vulnsForSymbols(um.ModulePath, um.Version, um.Path, getVulnEntries)
+		if err != nil {
+			// Vulnerability annotations are not essential to the page.
+			log.Errorf(ctx, "fetchMainDetails(%q, %q, %q): %v", um.Path, um.ModulePath, um.Version, err)
+		}
+		docParts, err = getHTML(ctx, unit, docPkg, unit.SymbolHistory, symbolVulns, bc)
 		// If err  is ErrTooLarge, then docBody will have an appropriate message.
 		if err != nil && !errors.Is(err, dochtml.ErrTooLarge) {
 			return nil, err
@@ -169,7 +223,12 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 		}
 		end = middleware.ElapsedStat(ctx, "sourceFiles")
 		files = sourceFiles(unit, docPkg)
+		embeddedFiles = packageEmbeddedFiles(unit, docPkg)
 		end()
+		symbolNoticeList, err = symbolNotices(ctx, ds, unit, doc)
+		if err != nil {
+			return nil, err
+		}
 	}
 	// If the unit is not a module, fetch the module readme to extract its
 	// links.
@@ -195,10 +254,26 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 	}
 	isTaggedVersion := versionType != version.TypePseudo
 	isStableVersion := semver.Major(um.Version) != "v0" && versionType == version.TypeRelease
+	directories := unitDirectories(append(subdirectories, nestedModules...), showInternal)
+	if directories != nil {
+		directories.InternalToggleURL = constructUnitURL(um.Path, um.ModulePath,
+			linkVersion(um.ModulePath, requestedVersion, um.Version))
+		if !showInternal {
+			directories.InternalToggleURL += "?" + showInternalParam
+		}
+	}
 	pr := message.NewPrinter(middleware.LanguageTag(ctx))
+	benchmarks, err := fetchBenchmarkDetails(ctx, ds, pr, unit)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := fetchModuleMetadata(ctx, ds, um, requestedVersion)
+	if err != nil {
+		return nil, err
+	}
 	return &MainDetails{
 		ExpandReadme:      expandReadme,
-		Directories:       unitDirectories(append(subdirectories, nestedModules...)),
+		Directories:       directories,
 		Licenses:          transformLicenseMetadata(um.Licenses),
 		CommitTime:        absoluteTime(um.CommitTime),
 		Readme:            readme.HTML,
@@ -213,11 +288,18 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 		GOARCH:            goarch,
 		BuildContexts:     buildContexts,
 		SourceFiles:       files,
+		EmbeddedFiles:     embeddedFiles,
+		SymbolNotices:     symbolNoticeList,
+		DocTruncated:      doc != nil && doc.Truncated,
 		RepositoryURL:     um.SourceInfo.RepoURL(),
 		SourceURL:         um.SourceInfo.DirectoryURL(internal.Suffix(um.Path, um.ModulePath)),
 		MobileOutline:     docParts.MobileOutline,
 		NumImports:        pr.Sprint(unit.NumImports),
 		ImportedByCount:   pr.Sprint(unit.NumImportedBy),
+		TestStats:         formatTestStats(pr, unit.TestStats),
+		FuzzTargets:       fuzzTargets(pr, unit),
+		Benchmarks:        benchmarks,
+		Metadata:          metadata,
 		IsPackage:         unit.IsPackage(),
 		ModFileURL:        um.SourceInfo.ModuleURL() + "/go.mod",
 		IsTaggedVersion:   isTaggedVersion,
@@ -225,6 +307,16 @@ func fetchMainDetails(ctx context.Context, ds internal.DataSource, um *internal.
 	}, nil
 }
 
+// formatTestStats returns a one-line summary of ts, or the empty string if ts
+// is nil.
+func formatTestStats(pr *message.Printer, ts *internal.TestStats) string {
+	if ts == nil {
+		return ""
+	}
+	return pr.Sprintf("Tests: %d · Benchmarks: %d · Fuzz: %d · Examples: %d",
+		ts.NumTests, ts.NumBenchmarks, ts.NumFuzzTargets, ts.NumExamples)
+}
+
 func cleanDocumentation(docs []*internal.Documentation) []*internal.Documentation {
 	// If there is more than one row but the first is all/all, ignore the others.
 	// Should never happen;  temporary fix until the DB is cleaned up.
@@ -250,24 +342,97 @@ func cleanDocumentation(docs []*internal.Documentation) []*internal.Documentatio
 }
 
 // readmeContent renders the readme to html and collects the headings
-// into an outline.
-func readmeContent(ctx context.Context, u *internal.Unit) (_ *Readme, err error) {
+// into an outline, with the given options.
+func readmeContent(ctx context.Context, u *internal.Unit, opts markdownOptions) (_ *Readme, err error) {
 	defer derrors.Wrap(&err, "readmeContent(%q, %q, %q)", u.Path, u.ModulePath, u.Version)
 	defer middleware.ElapsedStat(ctx, "readmeContent")()
 	if !u.IsRedistributable {
 		return &Readme{}, nil
 	}
-	return ProcessReadme(ctx, u)
+	if opts.resolveLink == nil && opts.renderDiagram == nil && opts.proxyImage == nil {
+		return ProcessReadme(ctx, u)
+	}
+	return processMarkdown(ctx, u.Readme, u.SourceInfo, opts)
+}
+
+// readmeLinkResolver returns a function for markdownOptions.resolveLink that
+// resolves relative links in the README of u to files of its module to their
+// pages on this site: guides to the Guides tab, and the directories of
+// packages in the module, and their READMEs, to the unit pages of the
+// packages. It returns nil if u has no README.
+func readmeLinkResolver(ctx context.Context, ds internal.DataSource, u *internal.Unit, requestedVersion string) func(string) string {
+	if u.Readme == nil {
+		return nil
+	}
+	isUnit := map[string]bool{u.Path: true}
+	for _, p := range u.Subdirectories {
+		isUnit[p.Path] = true
+	}
+	isGuide := map[string]bool{}
+	if g, ok := ds.(guidesGetter); ok {
+		paths, err := g.GetModuleGuidePaths(ctx, u.ModulePath, u.Version)
+		if err != nil {
+			// Links to guides are resolved to the repository instead.
+			log.Errorf(ctx, "readmeLinkResolver(%q): %v", u.Path, err)
+		}
+		for _, p := range paths {
+			isGuide[p] = true
+		}
+	}
+	// unitPath returns the import path of the directory at dir, relative to
+	// the module root.
+	unitPath := func(dir string) string {
+		if dir == "." {
+			return u.ModulePath
+		}
+		return u.ModulePath + "/" + dir
+	}
+	return func(dest string) string {
+		du, err := url.Parse(dest)
+		if err != nil || du.IsAbs() || du.Host != "" || du.Path == "" || path.IsAbs(du.Path) {
+			return ""
+		}
+		p := path.Join(path.Dir(u.Readme.Filepath), du.Path)
+		if p == ".." || strings.HasPrefix(p, "../") {
+			return ""
+		}
+		var s string
+		switch {
+		case isGuide[p]:
+			s = guidePageURL(constructUnitURL(u.Path, u.ModulePath, requestedVersion), p)
+		case isUnit[unitPath(p)]:
+			s = constructUnitURL(unitPath(p), u.ModulePath, requestedVersion)
+		case isReadmeFile(p) && isUnit[unitPath(path.Dir(p))]:
+			s = constructUnitURL(unitPath(path.Dir(p)), u.ModulePath, requestedVersion)
+			if du.Fragment == "" {
+				s += "#section-readme"
+			}
+		default:
+			return ""
+		}
+		if du.Fragment != "" {
+			s += "#readme-" + du.Fragment
+		}
+		return s
+	}
+}
+
+// isReadmeFile reports whether the base name of file, without its extension,
+// is README, ignoring case.
+func isReadmeFile(file string) bool {
+	base := path.Base(file)
+	return strings.EqualFold(strings.TrimSuffix(base, path.Ext(base)), "README")
 }
 
 const missingDocReplacement = `<p>Documentation is missing.</p>`
 
 func getHTML(ctx context.Context, u *internal.Unit, docPkg *godoc.Package,
-	nameToVersion map[string]string, bc internal.BuildContext) (_ *dochtml.Parts, err error) {
+	nameToVersion map[string]string, symbolVulns map[string][]dochtml.SymbolVuln,
+	bc internal.BuildContext) (_ *dochtml.Parts, err error) {
 	defer derrors.Wrap(&err, "getHTML(%s)", u.Path)
 
 	if len(u.Documentation[0].Source) > 0 {
-		return renderDocParts(ctx, u, docPkg, nameToVersion, bc)
+		return renderDocParts(ctx, u, docPkg, nameToVersion, symbolVulns, bc)
 	}
 	log.Errorf(ctx, "unit %s (%s@%s) missing documentation source", u.Path, u.ModulePath, u.Version)
 	return &dochtml.Parts{Body: template.MustParseAndExecuteToHTML(missingDocReplacement)}, nil
@@ -11,7 +11,10 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/fakedatasource"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 func TestGetImportedByCount(t *testing.T) {
@@ -63,3 +66,51 @@ func TestGetImportedByCount(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatTestStats(t *testing.T) {
+	pr := message.NewPrinter(language.English)
+	if got := formatTestStats(pr, nil); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+	ts := &internal.TestStats{NumTestFiles: 30, NumTests: 1042, NumBenchmarks: 3, NumFuzzTargets: 2, NumExamples: 10}
+	want := "Tests: 1,042 · Benchmarks: 3 · Fuzz: 2 · Examples: 10"
+	if got := formatTestStats(pr, ts); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadmeLinkResolver(t *testing.T) {
+	m := fakedatasource.NewModule("example.com/m", "v1.0.0").Module()
+	m.Guides = []*internal.Readme{{Filepath: "docs/intro.md", Contents: "# Intro\n"}}
+	ds := fakedatasource.New()
+	ds.InsertModule(m)
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path:       "example.com/m",
+			ModuleInfo: internal.ModuleInfo{ModulePath: "example.com/m", Version: "v1.0.0"},
+		},
+		Readme: &internal.Readme{Filepath: "README.md"},
+		Subdirectories: []*internal.PackageMeta{
+			{Path: "example.com/m"},
+			{Path: "example.com/m/cmd/tool"},
+		},
+	}
+	resolve := readmeLinkResolver(context.Background(), ds, u, "v1.0.0")
+	for _, test := range []struct {
+		dest, want string
+	}{
+		{"cmd/tool", "/example.com/m@v1.0.0/cmd/tool"},
+		{"./cmd/tool/#usage", "/example.com/m@v1.0.0/cmd/tool#readme-usage"},
+		{"cmd/tool/README.md", "/example.com/m@v1.0.0/cmd/tool#section-readme"},
+		{"docs/intro.md", "/example.com/m@v1.0.0?tab=guides&guide=intro.md"},
+		{"cmd/tool/main.go", ""},
+		{"cmd/other", ""},
+		{"../other/README.md", ""},
+		{"https://example.com/m/cmd/tool", ""},
+		{"/cmd/tool", ""},
+	} {
+		if got := resolve(test.dest); got != test.want {
+			t.Errorf("resolve(%q) = %q, want %q", test.dest, got, test.want)
+		}
+	}
+}
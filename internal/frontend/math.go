@@ -0,0 +1,225 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"html"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/texmath"
+)
+
+// mathExtension is a goldmark extension for LaTeX math, written as in
+// GitHub markdown: $...$ for inline math, $$...$$ for display math, and
+// fenced code blocks with the language "math". Math is rendered as MathML
+// with the texmath package; formulas that it does not support are displayed
+// as code.
+type mathExtension struct {
+	ctx context.Context
+}
+
+// Extend implements goldmark.Extender.
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(mathParser{}, 150)),
+		parser.WithASTTransformers(util.Prioritized(mathBlockTransformer{}, 100)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&mathRenderer{ctx: e.ctx}, 100),
+	))
+}
+
+// kindMath is the ast.NodeKind of mathNodes.
+var kindMath = ast.NewNodeKind("Math")
+
+// mathNode is an inline node holding a formula.
+type mathNode struct {
+	ast.BaseInline
+	tex     string
+	display bool
+}
+
+// Kind implements ast.Node.Kind.
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+// Dump implements ast.Node.Dump.
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.tex}, nil)
+}
+
+// kindMathBlock is the ast.NodeKind of mathBlocks.
+var kindMathBlock = ast.NewNodeKind("MathBlock")
+
+// mathBlock is a block node holding a formula, from a fenced code block.
+type mathBlock struct {
+	ast.BaseBlock
+	tex string
+}
+
+// Kind implements ast.Node.Kind.
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+// Dump implements ast.Node.Dump.
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.tex}, nil)
+}
+
+// mathParser is a parser.InlineParser for $...$ and $$...$$.
+//
+// To avoid mistaking prices and shell variables for math, an inline formula
+// must not start or end with a space, and its closing $ must not be followed
+// by a digit, as in pandoc. Inline formulas cannot span lines; display
+// formulas can.
+type mathParser struct{}
+
+// Trigger implements parser.InlineParser.Trigger.
+func (mathParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse implements parser.InlineParser.Parse.
+func (mathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if bytes.HasPrefix(line, []byte("$$")) {
+		return parseDisplayMath(block)
+	}
+	if len(line) < 3 || isMathSpace(line[1]) {
+		return nil
+	}
+	for i := 2; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			// Skip the escaped character.
+			i++
+		case '$':
+			if isMathSpace(line[i-1]) || i+1 < len(line) && '0' <= line[i+1] && line[i+1] <= '9' {
+				continue
+			}
+			block.Advance(i + 1)
+			return &mathNode{tex: string(line[1:i])}
+		}
+	}
+	return nil
+}
+
+// parseDisplayMath parses a formula in $$, which may span lines.
+func parseDisplayMath(block text.Reader) ast.Node {
+	l, pos := block.Position()
+	block.Advance(2)
+	var tex []byte
+	for {
+		line, _ := block.PeekLine()
+		if line == nil {
+			block.SetPosition(l, pos)
+			return nil
+		}
+		if i := bytes.Index(line, []byte("$$")); i >= 0 {
+			tex = append(tex, line[:i]...)
+			block.Advance(i + 2)
+			return &mathNode{tex: string(tex), display: true}
+		}
+		tex = append(tex, line...)
+		block.AdvanceLine()
+	}
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// mathBlockTransformer is a parser.ASTTransformer that replaces fenced code
+// blocks with the language "math" by mathBlocks.
+type mathBlockTransformer struct{}
+
+// Transform implements parser.ASTTransformer.Transform.
+func (mathBlockTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if b, ok := n.(*ast.FencedCodeBlock); ok && entering && string(b.Language(reader.Source())) == "math" {
+			blocks = append(blocks, b)
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, b := range blocks {
+		var tex bytes.Buffer
+		for i := 0; i < b.Lines().Len(); i++ {
+			line := b.Lines().At(i)
+			tex.Write(line.Value(reader.Source()))
+		}
+		b.Parent().ReplaceChild(b.Parent(), b, &mathBlock{tex: tex.String()})
+	}
+}
+
+// mathRenderer is a renderer.NodeRenderer for mathNodes and mathBlocks.
+type mathRenderer struct {
+	ctx context.Context
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, r.renderMath)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+func (r *mathRenderer) renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathNode)
+	h, err := texmath.ToMathML(n.tex, n.display)
+	if err != nil {
+		log.Debugf(r.ctx, "rendering math: %v", err)
+		delim := "$"
+		if n.display {
+			delim = "$$"
+		}
+		_, _ = w.WriteString("<code>" + html.EscapeString(delim+n.tex+delim) + "</code>")
+		return ast.WalkSkipChildren, nil
+	}
+	_, _ = w.WriteString(h.String())
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathBlock)
+	h, err := texmath.ToMathML(n.tex, true)
+	if err != nil {
+		log.Debugf(r.ctx, "rendering math: %v", err)
+		_, _ = w.WriteString("<pre><code>" + html.EscapeString(n.tex) + "</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+	_, _ = w.WriteString("<p>" + h.String() + "</p>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// allowMathML allows the MathML elements and attributes produced by the
+// texmath package in the HTML sanitized by p.
+func allowMathML(p *bluemonday.Policy) {
+	p.AllowNoAttrs().OnElements("math", "semantics", "annotation", "mi", "mn",
+		"mo", "mtext", "mspace", "mrow", "mfrac", "msqrt", "mroot", "msub",
+		"msup", "msubsup", "munder", "mover", "munderover", "mtable", "mtr", "mtd")
+	p.AllowAttrs("display").OnElements("math")
+	p.AllowAttrs("encoding").OnElements("annotation")
+	p.AllowAttrs("mathvariant").OnElements("mi")
+	p.AllowAttrs("largeop", "movablelimits", "form", "stretchy", "fence").OnElements("mo")
+	p.AllowAttrs("width").OnElements("mspace")
+	p.AllowAttrs("linethickness").OnElements("mfrac")
+	p.AllowAttrs("accent").OnElements("mover")
+	p.AllowAttrs("accentunder").OnElements("munder")
+	p.AllowAttrs("displaystyle").OnElements("mtable")
+	p.AllowAttrs("columnalign").OnElements("mtd")
+}
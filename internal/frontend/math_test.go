@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestProcessMarkdownMath(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		contents string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "inline",
+			contents: "The area is $\\pi r^2$.",
+			want: []string{
+				`<p>The area is <math><semantics><mrow><mi>π</mi><msup><mi>r</mi><mn>2</mn></msup></mrow>` +
+					`<annotation encoding="application/x-tex">\pi r^2</annotation></semantics></math>.</p>`,
+			},
+		},
+		{
+			name:     "display",
+			contents: "$$\n\\frac{a}{b}\n$$\n",
+			want:     []string{`<math display="block"><semantics><mfrac><mi>a</mi><mi>b</mi></mfrac>`},
+		},
+		{
+			name:     "fenced",
+			contents: "```math\nx_1\n```\n",
+			want:     []string{`<p><math display="block"><semantics><msub><mi>x</mi><mn>1</mn></msub>`},
+		},
+		{
+			name:     "prices",
+			contents: "It costs $5, or $10 with shipping.",
+			want:     []string{"It costs $5, or $10 with shipping."},
+			notWant:  []string{"<math"},
+		},
+		{
+			name:     "shell",
+			contents: "Run `echo $HOME`, or $ go test.",
+			notWant:  []string{"<math"},
+		},
+		{
+			name:     "unsupported",
+			contents: "See $\\unknown{x}$.",
+			want:     []string{`<code>$\unknown{x}$</code>`},
+			notWant:  []string{"<math"},
+		},
+		{
+			name:     "unsupported fenced",
+			contents: "```math\n\\unknown\n```\n",
+			want:     []string{"<pre><code>\\unknown\n</code></pre>"},
+		},
+		{
+			name:     "no MathML injection",
+			contents: `<math><mi href="javascript:alert(1)">x</mi><mglyph src="x"></mglyph></math>`,
+			notWant:  []string{"javascript", "mglyph"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			readme := &internal.Readme{Filepath: "README.md", Contents: test.contents}
+			got, err := processMarkdown(context.Background(), readme, nil, markdownOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := got.HTML.String()
+			for _, w := range test.want {
+				if !strings.Contains(h, w) {
+					t.Errorf("HTML does not contain %q:\n%s", w, h)
+				}
+			}
+			for _, w := range test.notWant {
+				if strings.Contains(h, w) {
+					t.Errorf("HTML contains %q:\n%s", w, h)
+				}
+			}
+		})
+	}
+}
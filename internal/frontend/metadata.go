@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// ModuleMetadata is the information that the authors of a module provide in
+// its .pkgsite.yaml file. It is displayed in the Project section of a unit
+// page.
+type ModuleMetadata struct {
+	Description string
+	Homepage    string
+	Logo        string
+	Tags        []string
+	// EntryPoints are links to the packages that the authors recommend
+	// starting from.
+	EntryPoints []link
+}
+
+// moduleMetadataGetter is implemented by data sources that record the
+// metadata of modules.
+type moduleMetadataGetter interface {
+	GetModuleMetadata(ctx context.Context, modulePath, version string) (*internal.ModuleMetadata, error)
+}
+
+// fetchModuleMetadata returns the metadata of the module of um, or nil if it
+// has none or ds does not record it.
+func fetchModuleMetadata(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, requestedVersion string) (_ *ModuleMetadata, err error) {
+	defer derrors.Wrap(&err, "fetchModuleMetadata(ctx, ds, %q, %q)", um.ModulePath, um.Version)
+
+	g, ok := ds.(moduleMetadataGetter)
+	if !ok {
+		return nil, nil
+	}
+	md, err := g.GetModuleMetadata(ctx, um.ModulePath, um.Version)
+	if errors.Is(err, derrors.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if md == nil {
+		return nil, nil
+	}
+	m := &ModuleMetadata{
+		Description: md.Description,
+		Homepage:    md.Homepage,
+		Logo:        md.Logo,
+		Tags:        md.Tags,
+	}
+	lv := linkVersion(um.ModulePath, requestedVersion, um.Version)
+	for _, p := range md.EntryPoints {
+		m.EntryPoints = append(m.EntryPoints, link{
+			Href: constructUnitURL(p, um.ModulePath, lv),
+			Body: p,
+		})
+	}
+	return m, nil
+}
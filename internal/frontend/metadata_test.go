@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleMetadataOnUnitPage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module("example.com/m", "v1.0.0", "p")
+	m.Metadata = &internal.ModuleMetadata{
+		Description: "A module for testing.",
+		Homepage:    "https://example.com/home",
+		Tags:        []string{"testing"},
+		EntryPoints: []string{"example.com/m/p"},
+	}
+	postgres.MustInsertModule(ctx, t, testDB, m)
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/example.com/m", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`data-test-id="meta-project"`,
+		"A module for testing.",
+		`href="https://example.com/home"`,
+		">testing</span>",
+		`href="/example.com/m/p"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// maxMigrationSymbols is the maximum number of removed or changed symbols
+// listed in the Migration section of a unit page.
+const maxMigrationSymbols = 20
+
+// A Migration describes how to upgrade from the module of a unit to the
+// latest major version of the module. It is displayed in the Migration
+// section of the unit page.
+type Migration struct {
+	// MajorVersion is the latest major version, such as "v2".
+	MajorVersion string
+
+	// URL is the URL of the unit in the latest major version, or of the
+	// module if the unit does not exist there.
+	URL string
+
+	// GuideName and GuideURL are the name and URL of the migration guide of
+	// the latest major version, if it has one.
+	GuideName string
+	GuideURL  string
+
+	// APIDiff compares the API of the package at the last release of its
+	// module with the API at the first release of the latest major version.
+	// It is nil if the unit is not a package in both, or there are no such
+	// releases.
+	APIDiff *APIDiff
+}
+
+// An APIDiff summarizes the differences between the exported symbols of two
+// versions of a package.
+type APIDiff struct {
+	FromVersion, ToVersion string
+	FromURL, ToURL         string
+
+	// Removed and Changed hold up to maxMigrationSymbols names of the symbols
+	// that were removed, or whose declaration changed. NumRemoved and
+	// NumChanged are their total numbers, and MoreRemoved and MoreChanged the
+	// numbers that are not listed.
+	Removed, Changed         []string
+	NumRemoved, NumChanged   int
+	MoreRemoved, MoreChanged int
+
+	// NumAdded is the number of symbols that were added.
+	NumAdded int
+}
+
+// migrationGuideGetter is implemented by data sources that record the
+// migration guides of modules.
+type migrationGuideGetter interface {
+	GetMigrationGuide(ctx context.Context, modulePath, version string) (string, error)
+}
+
+// fetchMigration returns the Migration for um, given information about the
+// latest versions of the unit. It returns nil if latest.MajorModulePath is
+// not a later major version of um's module.
+func fetchMigration(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, latest internal.LatestInfo) (_ *Migration, err error) {
+	defer derrors.Wrap(&err, "fetchMigration(ctx, ds, %q, %q)", um.Path, um.ModulePath)
+
+	major := internal.MajorVersionForModule(latest.MajorModulePath)
+	if major == "" || major == internal.MajorVersionForModule(um.ModulePath) {
+		return nil, nil
+	}
+	m := &Migration{
+		MajorVersion: major,
+		URL:          "/" + latest.MajorUnitPath,
+	}
+	if g, ok := ds.(migrationGuideGetter); ok {
+		mm, err := ds.GetUnitMeta(ctx, latest.MajorModulePath, latest.MajorModulePath, version.Latest)
+		if err != nil && !errors.Is(err, derrors.NotFound) {
+			return nil, err
+		}
+		if err == nil {
+			m.GuideName, err = g.GetMigrationGuide(ctx, mm.ModulePath, mm.Version)
+			if err != nil && !errors.Is(err, derrors.NotFound) {
+				return nil, err
+			}
+			if m.GuideName != "" {
+				m.GuideURL = mm.SourceInfo.FileURL(m.GuideName)
+			}
+		}
+	}
+	// The unit is in the latest major version if its path there is not just
+	// the module path.
+	newPath := latest.MajorUnitPath
+	if !um.IsPackage() || (newPath == latest.MajorModulePath && um.Path != um.ModulePath) {
+		return m, nil
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return m, nil
+	}
+	m.APIDiff, err = fetchAPIDiff(ctx, db, um, newPath, latest.MajorModulePath)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fetchAPIDiff compares the API of the package um at the last release of its
+// module with the API of the package newPath at the first release of
+// newModulePath. It returns nil if either module has no releases.
+func fetchAPIDiff(ctx context.Context, db *postgres.DB, um *internal.UnitMeta, newPath, newModulePath string) (*APIDiff, error) {
+	// The versions of the package in all major versions of its module, most
+	// recent first within each module path.
+	versions, err := db.GetVersionsForPath(ctx, um.Path)
+	if err != nil {
+		return nil, err
+	}
+	var from, to string
+	for _, mi := range versions {
+		if t, err := version.ParseType(mi.Version); err != nil || t != version.TypeRelease {
+			continue
+		}
+		switch mi.ModulePath {
+		case um.ModulePath:
+			if from == "" {
+				from = mi.Version
+			}
+		case newModulePath:
+			to = mi.Version
+		}
+	}
+	if from == "" || to == "" {
+		return nil, nil
+	}
+	oldAPI, err := packageAPI(ctx, db, um.Path, um.ModulePath, from)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := packageAPI(ctx, db, newPath, newModulePath, to)
+	if err != nil {
+		return nil, err
+	}
+	d := diffAPI(oldAPI, newAPI)
+	d.FromVersion = displayVersion(um.ModulePath, from, from)
+	d.ToVersion = displayVersion(newModulePath, to, to)
+	d.FromURL = constructUnitURL(um.Path, um.ModulePath, from)
+	d.ToURL = constructUnitURL(newPath, newModulePath, to)
+	return d, nil
+}
+
+// packageAPI returns the symbols of the package at path in
+// modulePath@v. It uses the documentation for all build contexts if
+// there is one, and otherwise the documentation for linux/amd64.
+func packageAPI(ctx context.Context, db *postgres.DB, path, modulePath, v string) ([]*internal.SymbolMeta, error) {
+	for _, bc := range []internal.BuildContext{internal.BuildContextAll, internal.BuildContextLinux} {
+		sms, err := db.GetDocumentationSymbols(ctx, path, modulePath, v, bc)
+		if err != nil {
+			return nil, err
+		}
+		if len(sms) > 0 {
+			return sms, nil
+		}
+	}
+	return nil, nil
+}
+
+// diffAPI compares two sets of symbols. A symbol is considered changed if its
+// synopsis, which holds its declaration, differs.
+func diffAPI(oldAPI, newAPI []*internal.SymbolMeta) *APIDiff {
+	added, removed, changed := symbolDiffs(oldAPI, newAPI)
+	d := &APIDiff{
+		Removed:    symbolNames(removed),
+		Changed:    symbolNames(changed),
+		NumRemoved: len(removed),
+		NumChanged: len(changed),
+		NumAdded:   len(added),
+	}
+	if len(d.Removed) > maxMigrationSymbols {
+		d.Removed = d.Removed[:maxMigrationSymbols]
+		d.MoreRemoved = d.NumRemoved - maxMigrationSymbols
+	}
+	if len(d.Changed) > maxMigrationSymbols {
+		d.Changed = d.Changed[:maxMigrationSymbols]
+		d.MoreChanged = d.NumChanged - maxMigrationSymbols
+	}
+	return d
+}
+
+func symbolNames(sds []*SymbolDiff) []string {
+	var names []string
+	for _, sd := range sds {
+		names = append(names, sd.Name)
+	}
+	return names
+}
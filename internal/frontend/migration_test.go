@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestDiffAPI(t *testing.T) {
+	sym := func(name, synopsis string) *internal.SymbolMeta {
+		return &internal.SymbolMeta{Name: name, Synopsis: synopsis}
+	}
+	oldAPI := []*internal.SymbolMeta{
+		sym("F", "func F()"),
+		sym("G", "func G(int)"),
+		sym("T", "type T struct"),
+		sym("T.M", "func (T) M()"),
+	}
+	newAPI := []*internal.SymbolMeta{
+		sym("F", "func F()"),
+		sym("G", "func G(context.Context, int)"),
+		sym("T", "type T struct"),
+		sym("T.N", "func (T) N()"),
+		sym("U", "type U int"),
+	}
+	got := diffAPI(oldAPI, newAPI)
+	want := &APIDiff{
+		Removed:    []string{"T.M"},
+		NumRemoved: 1,
+		Changed:    []string{"G"},
+		NumChanged: 1,
+		NumAdded:   2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestDiffAPILimit(t *testing.T) {
+	var oldAPI []*internal.SymbolMeta
+	for i := 0; i < maxMigrationSymbols+5; i++ {
+		oldAPI = append(oldAPI, &internal.SymbolMeta{Name: fmt.Sprintf("F%02d", i)})
+	}
+	got := diffAPI(oldAPI, nil)
+	if len(got.Removed) != maxMigrationSymbols || got.NumRemoved != maxMigrationSymbols+5 || got.MoreRemoved != 5 {
+		t.Errorf("got %d removed of %d (%d more), want %d of %d (5 more)",
+			len(got.Removed), got.NumRemoved, got.MoreRemoved, maxMigrationSymbols, maxMigrationSymbols+5)
+	}
+	if got.Removed[0] != "F00" {
+		t.Errorf("got first removed %q, want F00", got.Removed[0])
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/namespace"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const (
+	// namespaceClaimWindow is the period over which the number of claims is
+	// limited.
+	namespaceClaimWindow = 24 * time.Hour
+	// maxPendingNamespaceClaims is the number of unverified claims of a
+	// prefix that can be made in namespaceClaimWindow.
+	maxPendingNamespaceClaims = 5
+	// maxNamespaceClaimsPerKey is the number of claims that can be made with
+	// an API key in namespaceClaimWindow. Claims made without a key share one
+	// limit.
+	maxNamespaceClaimsPerKey = 20
+)
+
+// NamespaceClaimResponse is the JSON response of the
+// /api/v1/namespaces/claim endpoint.
+type NamespaceClaimResponse struct {
+	*namespace.Challenge
+	// Instructions explains how to meet the challenge.
+	Instructions string `json:"instructions"`
+}
+
+// serveNamespaceClaimAPI handles POST requests for
+// /api/v1/namespaces/claim?prefix=<path>.
+// It records a new claim of the module path prefix, and returns the
+// challenge that the claimant must meet to verify it. Once it is met, the
+// claimant calls /api/v1/namespaces/verify with the token of the claim.
+// The number of claims of each prefix and by each API key is limited.
+func (s *Server) serveNamespaceClaimAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	prefix := strings.Trim(r.URL.Query().Get("prefix"), "/")
+	if prefix == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix query parameter"}
+	}
+	token, err := namespace.NewToken()
+	if err != nil {
+		return err
+	}
+	c, err := namespace.NewChallenge(prefix, token)
+	if errors.Is(err, derrors.InvalidArgument) {
+		return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("%q cannot be claimed", prefix)}
+	}
+	if err != nil {
+		return err
+	}
+	ctx := r.Context()
+	var keyID int
+	if k := apikey.FromContext(ctx); k != nil {
+		keyID = k.ID
+	}
+	byPrefix, byKey, err := db.CountNamespaceClaims(ctx, c.Prefix, keyID, time.Now().Add(-namespaceClaimWindow))
+	if err != nil {
+		return err
+	}
+	if byPrefix >= maxPendingNamespaceClaims || byKey >= maxNamespaceClaimsPerKey {
+		return &serverError{status: http.StatusTooManyRequests, responseText: "too many recent claims; try again later"}
+	}
+	if err := db.InsertNamespaceClaim(ctx, c.Prefix, c.Token, keyID); err != nil {
+		return err
+	}
+	return serveJSON(w, r, &NamespaceClaimResponse{
+		Challenge:    c,
+		Instructions: challengeInstructions(c),
+	})
+}
+
+// challengeInstructions returns a description of what the claimant must do
+// to meet c.
+func challengeInstructions(c *namespace.Challenge) string {
+	days := int(namespace.VerificationTTL.Hours() / 24)
+	if c.Method == namespace.MethodRepo {
+		return fmt.Sprintf("Create a public repository at %s, then verify the claim. Keep the repository: the claim is checked again every %d days, and expires if it is gone.", c.Name, days)
+	}
+	return fmt.Sprintf("Add a DNS TXT record for %s with the value %q, then verify the claim. Keep the record: the claim is checked again every %d days, and expires if it is gone.", c.Name, c.Value, days)
+}
+
+// NamespaceVerifyResponse is the JSON response of the
+// /api/v1/namespaces/verify endpoint.
+type NamespaceVerifyResponse struct {
+	Prefix string `json:"prefix"`
+	// Verified reports whether the claim is verified. If it is not, the
+	// challenge was not met, and verification can be requested again.
+	Verified bool   `json:"verified"`
+	Method   string `json:"method,omitempty"`
+	// ExpiresAt is when the verification expires. It is omitted if the
+	// claim is not verified.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// serveNamespaceVerifyAPI handles POST requests for
+// /api/v1/namespaces/verify?prefix=<path>&token=<token>.
+// It checks whether the challenge of the claim has been met, and if so,
+// marks the claim as verified for namespace.VerificationTTL, so that the
+// modules under the prefix are displayed as coming from a verified publisher.
+// A claim whose verification has expired is checked again.
+func (s *Server) serveNamespaceVerifyAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	if s.namespaceVerifier == nil {
+		return &serverError{status: http.StatusFailedDependency, responseText: "verification is not configured"}
+	}
+	q := r.URL.Query()
+	prefix := strings.Trim(q.Get("prefix"), "/")
+	token := q.Get("token")
+	if prefix == "" || token == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing prefix or token query parameter"}
+	}
+	ctx := r.Context()
+	claim, err := db.GetNamespaceClaim(ctx, prefix, token)
+	if errors.Is(err, derrors.NotFound) {
+		return &serverError{status: http.StatusNotFound, responseText: "no such claim"}
+	}
+	if err != nil {
+		return err
+	}
+	resp := &NamespaceVerifyResponse{Prefix: prefix}
+	if claim.Verified(time.Now()) {
+		resp.Verified = true
+		resp.Method = claim.Method
+		resp.ExpiresAt = &claim.ExpiresAt
+		return serveJSON(w, r, resp)
+	}
+	c, err := namespace.NewChallenge(prefix, token)
+	if err != nil {
+		return err
+	}
+	verified, err := s.namespaceVerifier(ctx, c)
+	if err != nil {
+		return &serverError{
+			status:       http.StatusBadGateway,
+			responseText: "the challenge could not be checked; try again later",
+			err:          err,
+		}
+	}
+	if verified {
+		expiresAt := time.Now().Add(namespace.VerificationTTL)
+		if err := db.VerifyNamespaceClaim(ctx, prefix, token, c.Method, expiresAt); err != nil {
+			return err
+		}
+		resp.Verified = true
+		resp.Method = c.Method
+		resp.ExpiresAt = &expiresAt
+	}
+	return serveJSON(w, r, resp)
+}
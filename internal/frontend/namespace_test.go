@@ -0,0 +1,102 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/namespace"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestNamespaceAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("example.com/org/m", "v1.0.0", "p"))
+	s, handler, _ := newTestServer(t, nil, nil)
+	// The challenge is met if the token has been published.
+	published := map[string]bool{}
+	s.namespaceVerifier = func(_ context.Context, c *namespace.Challenge) (bool, error) {
+		return published[c.Token], nil
+	}
+
+	post := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", url, nil))
+		return w
+	}
+
+	w := post("/api/v1/namespaces/claim?prefix=example.com/org")
+	if w.Code != http.StatusOK {
+		t.Fatalf("claim: got status %d, want %d", w.Code, http.StatusOK)
+	}
+	var claim NamespaceClaimResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &claim); err != nil {
+		t.Fatal(err)
+	}
+	if claim.Method != namespace.MethodDNS || claim.Name != "_pkgsite-verify.example.com" || claim.Token == "" {
+		t.Errorf("claim: got %+v, want a DNS challenge for example.com", claim.Challenge)
+	}
+	if got := post("/api/v1/namespaces/claim?prefix=std").Code; got != http.StatusBadRequest {
+		t.Errorf("claim of std: got status %d, want %d", got, http.StatusBadRequest)
+	}
+
+	verify := func(token string) *NamespaceVerifyResponse {
+		t.Helper()
+		w := post("/api/v1/namespaces/verify?prefix=example.com/org&token=" + token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("verify: got status %d, want %d", w.Code, http.StatusOK)
+		}
+		var resp NamespaceVerifyResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return &resp
+	}
+	badgeShown := func() bool {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/example.com/org/m/p", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unit page: got status %d, want %d", w.Code, http.StatusOK)
+		}
+		return strings.Contains(w.Body.String(), "Verified publisher")
+	}
+
+	if resp := verify(claim.Token); resp.Verified {
+		t.Error("before publishing the token: got Verified = true")
+	}
+	if badgeShown() {
+		t.Error("before verification: the badge is shown")
+	}
+	published[claim.Token] = true
+	if resp := verify(claim.Token); !resp.Verified || resp.Method != namespace.MethodDNS || resp.ExpiresAt == nil {
+		t.Errorf("after publishing the token: got %+v, want verified by DNS until a time", resp)
+	}
+	if !badgeShown() {
+		t.Error("after verification: the badge is not shown")
+	}
+	if got := post("/api/v1/namespaces/verify?prefix=example.com/org&token=bad").Code; got != http.StatusNotFound {
+		t.Errorf("verify with a bad token: got status %d, want %d", got, http.StatusNotFound)
+	}
+
+	// Only a few unverified claims of a prefix can be made.
+	for i := 0; i < maxPendingNamespaceClaims; i++ {
+		if got := post("/api/v1/namespaces/claim?prefix=example.com/other").Code; got != http.StatusOK {
+			t.Fatalf("claim %d: got status %d, want %d", i, got, http.StatusOK)
+		}
+	}
+	if got := post("/api/v1/namespaces/claim?prefix=example.com/other").Code; got != http.StatusTooManyRequests {
+		t.Errorf("claim over the limit: got status %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
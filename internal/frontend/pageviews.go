@@ -0,0 +1,139 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// pageViewFlushInterval is how often page view counts are written to the
+// database.
+const pageViewFlushInterval = time.Minute
+
+// pageViewCounter counts the views of package pages in memory, and
+// periodically records the counts. Nothing about the viewers is kept.
+type pageViewCounter struct {
+	record func(context.Context, []*postgres.PageView) error
+
+	mu     sync.Mutex
+	counts map[postgres.PageView]int // keys have zero Views
+}
+
+func newPageViewCounter(record func(context.Context, []*postgres.PageView) error) *pageViewCounter {
+	return &pageViewCounter{record: record, counts: map[postgres.PageView]int{}}
+}
+
+// add counts a view of tab of the page of pkgPath, on the current day.
+func (c *pageViewCounter) add(pkgPath, tab string) {
+	k := postgres.PageView{
+		Day:         time.Now().UTC().Truncate(24 * time.Hour),
+		PackagePath: pkgPath,
+		Tab:         tab,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[k]++
+}
+
+// flush records the views counted since the last flush. If recording fails,
+// the views are dropped, since the counts need not be exact.
+func (c *pageViewCounter) flush(ctx context.Context) {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = map[postgres.PageView]int{}
+	c.mu.Unlock()
+
+	var pvs []*postgres.PageView
+	for k, n := range counts {
+		pv := k
+		pv.Views = n
+		pvs = append(pvs, &pv)
+	}
+	if err := c.record(ctx, pvs); err != nil {
+		log.Errorf(ctx, "recording page views: %v", err)
+	}
+}
+
+// start flushes the counts every interval, until ctx is done.
+func (c *pageViewCounter) start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.flush(ctx)
+			}
+		}
+	}()
+}
+
+// countPageViews returns a handler that serves unit pages with h, and counts
+// the views of those that are served successfully. It must wrap the cache
+// of pages, so that pages served from the cache are counted as well. Views
+// of paths that are not packages are dropped when they are recorded. The
+// mirrored copies of shadowed requests are not counted.
+func (s *Server) countPageViews(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middleware.IsMirrored(r.Context()) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		sw := &statusResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		if r.Method != http.MethodGet || (sw.status != 0 && sw.status != http.StatusOK) {
+			return
+		}
+		if pkgPath, tab, ok := pageViewOf(r); ok {
+			s.pageViews.add(pkgPath, tab)
+		}
+	})
+}
+
+// pageViewOf returns the path and tab of the unit page requested by r. It
+// returns false if r is not for a unit page.
+func pageViewOf(r *http.Request) (pkgPath, tab string, ok bool) {
+	if r.URL.Path == "/" {
+		return "", "", false
+	}
+	info, err := extractURLPathInfo(r.URL.Path)
+	if err != nil {
+		return "", "", false
+	}
+	tab = r.FormValue("tab")
+	if tab == "" {
+		tab = tabMain
+		if info.compareVersion != "" {
+			tab = tabAPIDiff
+		}
+	}
+	if _, ok := unitTabLookup[tab]; !ok {
+		return "", "", false
+	}
+	return info.fullPath, tab, true
+}
+
+// statusResponseWriter is an http.ResponseWriter that remembers the status
+// of the response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestPageViewCounter(t *testing.T) {
+	var got []*postgres.PageView
+	c := newPageViewCounter(func(_ context.Context, pvs []*postgres.PageView) error {
+		got = append(got, pvs...)
+		return nil
+	})
+	c.add("example.com/m/a", tabMain)
+	c.add("example.com/m/a", tabMain)
+	c.add("example.com/m/a", tabImports)
+	c.add("example.com/m/b", tabMain)
+	c.flush(context.Background())
+
+	sort.Slice(got, func(i, j int) bool {
+		if got[i].PackagePath != got[j].PackagePath {
+			return got[i].PackagePath < got[j].PackagePath
+		}
+		return got[i].Tab < got[j].Tab
+	})
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	want := []*postgres.PageView{
+		{Day: day, PackagePath: "example.com/m/a", Tab: tabMain, Views: 2},
+		{Day: day, PackagePath: "example.com/m/a", Tab: tabImports, Views: 1},
+		{Day: day, PackagePath: "example.com/m/b", Tab: tabMain, Views: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The counts are reset after a flush.
+	got = nil
+	c.flush(context.Background())
+	if len(got) != 0 {
+		t.Errorf("got %d page views after flushing, want 0", len(got))
+	}
+}
+
+func TestCountPageViews(t *testing.T) {
+	var got []*postgres.PageView
+	s := &Server{pageViews: newPageViewCounter(func(_ context.Context, pvs []*postgres.PageView) error {
+		got = append(got, pvs...)
+		return nil
+	})}
+	h := s.countPageViews(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	for _, target := range []string{
+		"/example.com/m/a",
+		"/example.com/m/a@v1.0.0?tab=imports",
+		"/example.com/m/a?tab=unknown",
+		"/missing/a",
+		"/",
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	s.pageViews.flush(context.Background())
+
+	sort.Slice(got, func(i, j int) bool { return got[i].Tab < got[j].Tab })
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	want := []*postgres.PageView{
+		{Day: day, PackagePath: "example.com/m/a", Tab: tabMain, Views: 1},
+		{Day: day, PackagePath: "example.com/m/a", Tab: tabImports, Views: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCountMirroredRequests(t *testing.T) {
+	var (
+		gotViews    []*postgres.PageView
+		gotSearches []*postgres.SearchQuery
+	)
+	s := &Server{
+		pageViews: newPageViewCounter(func(_ context.Context, pvs []*postgres.PageView) error {
+			gotViews = append(gotViews, pvs...)
+			return nil
+		}),
+		searchQueries: newSearchQueryCounter(func(_ context.Context, sqs []*postgres.SearchQuery) error {
+			gotSearches = append(gotSearches, sqs...)
+			return nil
+		}),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/search", s.countSearches(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.FormValue("q")
+		setSearchResult(r.Context(), &searchResult{Query: q, Mode: searchModePackage, ResultCount: 1, TopResultPath: q})
+	}), nil, nil))
+	mux.Handle("/", s.countPageViews(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+	// Mirror every request to the same handler, as the frontend does when
+	// no shadow handler is configured.
+	done := make(chan struct{}, 1)
+	h := middleware.Shadow(middleware.ShadowConfig{
+		Rate:   1,
+		Report: func(context.Context, *middleware.ShadowResult) { done <- struct{}{} },
+	})(mux)
+	for _, target := range []string{"/example.com/m/a", "/search?q=a"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: timed out waiting for the mirrored request", target)
+		}
+	}
+	s.pageViews.flush(context.Background())
+	s.searchQueries.flush(context.Background())
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	wantViews := []*postgres.PageView{{Day: day, PackagePath: "example.com/m/a", Tab: tabMain, Views: 1}}
+	if diff := cmp.Diff(wantViews, gotViews); diff != "" {
+		t.Errorf("page views mismatch (-want, +got):\n%s", diff)
+	}
+	wantSearches := []*postgres.SearchQuery{{Day: day, Query: "a", Mode: searchModePackage, Searches: 1, ResultCount: 1, TopResultPath: "a"}}
+	if diff := cmp.Diff(wantSearches, gotSearches); diff != "" {
+		t.Errorf("searches mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+	"golang.org/x/text/message"
+)
+
+// defaultPrefixLimit is the default number of modules on a page listing the
+// modules under a path prefix.
+const defaultPrefixLimit = 50
+
+// PrefixPage contains the data for a page listing the modules under a path
+// prefix that is not itself a module or package, such as a GitHub user or
+// organization.
+type PrefixPage struct {
+	basePage
+	Prefix     string
+	Modules    []*PrefixModule
+	Pagination pagination
+}
+
+// A PrefixModule is a module listed on a PrefixPage.
+type PrefixModule struct {
+	ModulePath    string
+	Version       string
+	URL           string
+	Synopsis      string
+	CommitTime    string
+	NumImportedBy string
+	NumPackages   int
+}
+
+// servePrefixPage serves a page listing the modules under prefix, if there
+// are any. It reports whether the page was served.
+func (s *Server) servePrefixPage(w http.ResponseWriter, r *http.Request, db *postgres.DB, prefix string) (bool, error) {
+	ctx := r.Context()
+	params := newPaginationParams(r, defaultPrefixLimit)
+	if params.limit > maxSearchPageSize {
+		params.limit = maxSearchPageSize
+	}
+	mods, total, err := db.GetModulesWithPrefix(ctx, prefix, params.limit, params.offset())
+	if err != nil {
+		return false, err
+	}
+	if len(mods) == 0 {
+		return false, nil
+	}
+	page := newPrefixPage(message.NewPrinter(middleware.LanguageTag(ctx)), prefix, mods)
+	page.Pagination = newPagination(params, len(mods), total)
+	page.basePage = s.newBasePage(r, prefix)
+	s.servePage(ctx, w, "prefix", page)
+	return true, nil
+}
+
+func newPrefixPage(pr *message.Printer, prefix string, mods []*postgres.PrefixModule) *PrefixPage {
+	page := &PrefixPage{Prefix: prefix}
+	for _, m := range mods {
+		page.Modules = append(page.Modules, &PrefixModule{
+			ModulePath:    m.ModulePath,
+			Version:       m.Version,
+			URL:           constructUnitURL(m.ModulePath, m.ModulePath, version.Latest),
+			Synopsis:      m.Synopsis,
+			CommitTime:    elapsedTime(m.CommitTime),
+			NumImportedBy: pr.Sprint(m.NumImportedBy),
+			NumPackages:   m.NumPackages,
+		})
+	}
+	return page
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/static"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestPrefixPage(t *testing.T) {
+	mods := []*postgres.PrefixModule{
+		{
+			ModulePath:    "github.com/org/a",
+			Version:       "v1.2.0",
+			CommitTime:    time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC),
+			Synopsis:      "Package a does things.",
+			NumImportedBy: 1500,
+			NumPackages:   3,
+		},
+	}
+	page := newPrefixPage(message.NewPrinter(language.English), "github.com/org", mods)
+	want := []*PrefixModule{{
+		ModulePath:    "github.com/org/a",
+		Version:       "v1.2.0",
+		URL:           "/github.com/org/a",
+		Synopsis:      "Package a does things.",
+		CommitTime:    "Mar  2, 2022",
+		NumImportedBy: "1,500",
+		NumPackages:   3,
+	}}
+	if diff := cmp.Diff(want, page.Modules); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	s, err := NewServer(ServerConfig{
+		TemplateFS: template.TrustedFSFromEmbed(static.FS),
+		StaticFS:   static.FS,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/github.com/org?page=2&limit=1", nil)
+	params := newPaginationParams(r, defaultPrefixLimit)
+	page.Pagination = newPagination(params, len(mods), 3)
+	page.basePage = s.newBasePage(r, page.Prefix)
+	buf, err := s.renderPage(context.Background(), "prefix", page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(buf)
+	for _, w := range []string{
+		"3 modules under github.com/org",
+		`href="/github.com/org/a"`,
+		"Package a does things.",
+		"Imported by <strong>1,500</strong>",
+		"3 packages",
+		`href="/github.com/org?limit=1&amp;page=1"`,
+		`href="/github.com/org?limit=1&amp;page=3"`,
+	} {
+		if !strings.Contains(body, w) {
+			t.Errorf("page does not contain %q", w)
+		}
+	}
+}
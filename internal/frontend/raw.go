@@ -0,0 +1,80 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/moddiff"
+)
+
+// rawInfix separates the path of a unit from the name of one of its files in
+// the URL path of a raw file, as in /example.com/m@v1.0.0/pkg/-/raw/pkg.go.
+const rawInfix = "/-/raw/"
+
+// maxRawFileSize is the size of the largest file served raw.
+const maxRawFileSize = 10 * 1024 * 1024
+
+// rawImageTypes are the content types of the images that are served as such.
+// Other files that are not text are served as application/octet-stream. SVG
+// is not included, because it can contain scripts.
+var rawImageTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+}
+
+// serveRaw serves the contents of a file in the directory of a unit. Text
+// files, including HTML and JavaScript, are served as text/plain, so that
+// browsers never run them.
+func (s *Server) serveRaw(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	unitPath, name, _ := strings.Cut(r.URL.Path, rawInfix)
+	// Only files directly in the unit's directory are served, because files
+	// in subdirectories may belong to other units, with other licenses.
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return &serverError{status: http.StatusNotFound}
+	}
+	um, m, err := s.unitZip(r, ds, unitPath)
+	if err != nil {
+		return err
+	}
+	filePath := path.Join(m.Path+"@"+m.Version, internal.Suffix(um.Path, um.ModulePath), name)
+	info, err := fs.Stat(m.Zip, filePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return &serverError{status: http.StatusNotFound, err: err}
+	}
+	if info.Size() > maxRawFileSize {
+		return &serverError{status: http.StatusRequestEntityTooLarge}
+	}
+	data, err := fs.ReadFile(m.Zip, filePath)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", rawContentType(name, data))
+	// Forbid scripts and other active content, in case a browser ignores
+	// the content type.
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	http.ServeContent(w, r, "", um.CommitTime, bytes.NewReader(data))
+	return nil
+}
+
+// rawContentType returns the content type for serving the file with the
+// given name and contents.
+func rawContentType(name string, data []byte) string {
+	if t, ok := rawImageTypes[strings.ToLower(path.Ext(name))]; ok {
+		return t
+	}
+	if moddiff.IsText(data) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestRawContentType(t *testing.T) {
+	for _, test := range []struct {
+		name, data, want string
+	}{
+		{"go.mod", "module example.com/m\n", "text/plain; charset=utf-8"},
+		{"index.html", "<script>alert(1)</script>", "text/plain; charset=utf-8"},
+		{"logo.png", "\x89PNG\r\n\x1a\n", "image/png"},
+		{"logo.svg", "<svg><script>alert(1)</script></svg>", "text/plain; charset=utf-8"},
+		{"data.bin", "\x00\x01\x02", "application/octet-stream"},
+	} {
+		if got := rawContentType(test.name, []byte(test.data)); got != test.want {
+			t.Errorf("rawContentType(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestServeRaw(t *testing.T) {
+	s, handler, teardown := newTestServer(t, nil, nil)
+	defer teardown()
+	proxyClient, proxyTeardown := proxytest.SetupTestClient(t, testModulesForProxy)
+	defer proxyTeardown()
+	s.moduleZipGetter = ProxyZipGetter(proxyClient)
+	ctx := context.Background()
+	if _, err := FetchAndUpdateState(ctx, testModulePath, testSemver, proxyClient, source.NewClient(sourceTimeout), testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			path:       "/" + testModulePath + "@" + testSemver + "/bar/foo" + rawInfix + "foo.go",
+			wantStatus: http.StatusOK,
+			wantBody:   "// Package foo\npackage foo\n\nconst Foo = 42",
+		},
+		{
+			path:       "/" + testModulePath + "@" + testSemver + rawInfix + "README.md",
+			wantStatus: http.StatusOK,
+			wantBody:   "This is a readme",
+		},
+		{
+			// Files in subdirectories are served from their own directory.
+			path:       "/" + testModulePath + "@" + testSemver + rawInfix + "bar/foo/foo.go",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			path:       "/" + testModulePath + "@" + testSemver + rawInfix + "nope.go",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			continue
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+		if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", test.path, got, want)
+		}
+		if got := w.Body.String(); got != test.wantBody {
+			t.Errorf("%s: got body %q, want %q", test.path, got, test.wantBody)
+		}
+	}
+}
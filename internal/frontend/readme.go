@@ -7,6 +7,7 @@ package frontend
 import (
 	"bytes"
 	"context"
+	"strings"
 
 	"github.com/google/safehtml"
 	"github.com/google/safehtml/template"
@@ -20,6 +21,8 @@ import (
 	goldmarkHtml "github.com/yuin/goldmark/renderer/html"
 	gmtext "github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
@@ -70,6 +73,27 @@ func ProcessReadme(ctx context.Context, u *internal.Unit) (_ *Readme, err error)
 }
 
 func processReadme(ctx context.Context, readme *internal.Readme, sourceInfo *source.Info) (frontendReadme *Readme, err error) {
+	return processMarkdown(ctx, readme, sourceInfo, markdownOptions{})
+}
+
+// markdownOptions are the options of processMarkdown.
+type markdownOptions struct {
+	// resolveLink, if non-nil, is called on the destination of each link
+	// first. If it returns a non-empty string, that is used as the
+	// destination instead of the translated one.
+	resolveLink func(dest string) string
+
+	// renderDiagram, if non-nil, is used to render diagrams in fenced code
+	// blocks as images.
+	renderDiagram diagramFunc
+
+	// proxyImage, if non-nil, returns the URL at which an external image is
+	// served by the image proxy, or the empty string if it cannot be.
+	proxyImage func(imageURL string) string
+}
+
+// processMarkdown is like processReadme, with options.
+func processMarkdown(ctx context.Context, readme *internal.Readme, sourceInfo *source.Info, opts markdownOptions) (frontendReadme *Readme, err error) {
 	if readme == nil || readme.Contents == "" {
 		return &Readme{}, nil
 	}
@@ -103,8 +127,9 @@ func processReadme(ctx context.Context, readme *internal.Readme, sourceInfo *sou
 			// before it is rendered.
 			parser.WithASTTransformers(
 				util.Prioritized(&astTransformer{
-					info:   sourceInfo,
-					readme: readme,
+					info:        sourceInfo,
+					readme:      readme,
+					resolveLink: opts.resolveLink,
 				}, astTransformerPriority),
 				// Extract links after we have transformed the URLs.
 				util.Prioritized(el, astTransformerPriority+1),
@@ -115,8 +140,9 @@ func processReadme(ctx context.Context, readme *internal.Readme, sourceInfo *sou
 		// fine since we process the contents using bluemonday after.
 		goldmark.WithRendererOptions(goldmarkHtml.WithUnsafe(), goldmarkHtml.WithXHTML()),
 		goldmark.WithExtensions(
-			extension.GFM, // Support Github Flavored Markdown.
-			emoji.Emoji,   // Support Github markdown emoji markup.
+			extension.GFM,            // Support Github Flavored Markdown.
+			emoji.Emoji,              // Support Github markdown emoji markup.
+			&mathExtension{ctx: ctx}, // Support Github markdown math.
 		),
 	)
 	gdMarkdown.Renderer().AddOptions(
@@ -124,6 +150,16 @@ func processReadme(ctx context.Context, readme *internal.Readme, sourceInfo *sou
 			util.Prioritized(newHTMLRenderer(sourceInfo, readme), 100),
 		),
 	)
+	var dr *diagramRenderer
+	if opts.renderDiagram != nil {
+		dr, err = newDiagramRenderer(ctx, opts.renderDiagram)
+		if err != nil {
+			return nil, err
+		}
+		gdMarkdown.Renderer().AddOptions(
+			renderer.WithNodeRenderers(util.Prioritized(dr, 100)),
+		)
+	}
 	contents := []byte(readme.Contents)
 	gdParser := gdMarkdown.Parser()
 	reader := gmtext.NewReader(contents)
@@ -150,8 +186,15 @@ func processReadme(ctx context.Context, readme *internal.Readme, sourceInfo *sou
 		log.Debugf(ctx, "gdRenderer.Render: %v", err)
 		return &Readme{}, nil
 	}
+	h := sanitizeHTML(&b)
+	if opts.proxyImage != nil {
+		h = proxyImages(h, opts.proxyImage)
+	}
+	if dr != nil {
+		h = dr.insertDiagrams(h)
+	}
 	return &Readme{
-		HTML:    sanitizeHTML(&b),
+		HTML:    h,
 		Outline: et.Headings,
 		Links:   el.links,
 	}, nil
@@ -170,7 +213,38 @@ func sanitizeHTML(b *bytes.Buffer) safehtml.HTML {
 		// Needed to preserve github styles heading font-sizes
 		p.AllowAttrs("class").OnElements(h)
 	}
+	allowMathML(p)
 
 	s := string(p.SanitizeBytes(b.Bytes()))
 	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(s)
 }
+
+// proxyImages replaces the sources of the images in h, which has been
+// sanitized, with the URLs returned by proxyImage. Images for which it
+// returns the empty string are left alone.
+func proxyImages(h safehtml.HTML, proxyImage func(string) string) safehtml.HTML {
+	z := html.NewTokenizer(strings.NewReader(h.String()))
+	var b strings.Builder
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			if tok := z.Token(); tok.DataAtom == atom.Img {
+				for i, a := range tok.Attr {
+					if a.Key == "src" {
+						if u := proxyImage(a.Val); u != "" {
+							tok.Attr[i].Val = u
+						}
+					}
+				}
+				b.WriteString(tok.String())
+				continue
+			}
+		}
+		b.WriteString(raw)
+	}
+	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(b.String())
+}
@@ -417,16 +417,34 @@ func TestReadme(t *testing.T) {
 				Filepath: "README.md",
 				Contents: "# Heading 😎\n## 👾\n## Heading 🚀\n# Heading",
 			},
-			wantHTML: `<h3 class="h1" id="readme-heading">Heading 😎</h3>` + "\n" +
-				`<h4 class="h2" id="readme-heading-1">👾</h4>` + "\n" +
-				`<h4 class="h2" id="readme-heading-2">Heading 🚀</h4>` + "\n" +
-				`<h3 class="h1" id="readme-heading-3">Heading</h3>`,
+			wantHTML: `<h3 class="h1" id="readme-heading-">Heading 😎</h3>` + "\n" +
+				`<h4 class="h2" id="readme-heading">👾</h4>` + "\n" +
+				`<h4 class="h2" id="readme-heading--1">Heading 🚀</h4>` + "\n" +
+				`<h3 class="h1" id="readme-heading-1">Heading</h3>`,
 			wantOutline: []*Heading{
-				{Level: 1, Text: "Heading 😎", ID: "readme-heading", Children: []*Heading{
-					{Level: 2, Text: "👾", ID: "readme-heading-1"},
-					{Level: 2, Text: "Heading 🚀", ID: "readme-heading-2"},
+				{Level: 1, Text: "Heading 😎", ID: "readme-heading-", Children: []*Heading{
+					{Level: 2, Text: "👾", ID: "readme-heading"},
+					{Level: 2, Text: "Heading 🚀", ID: "readme-heading--1"},
 				}},
-				{Level: 1, Text: "Heading", ID: "readme-heading-3"},
+				{Level: 1, Text: "Heading", ID: "readme-heading-1"},
+			},
+		},
+		{
+			name: "ids and fragment links are as on GitHub",
+			unit: unit,
+			readme: &internal.Readme{
+				Filepath: "README.md",
+				Contents: "# Installation & Setup\n# v1.2 Release_Notes\n" +
+					"[a](#installation--setup) [b](#V12-Release_Notes) [c](#missing)",
+			},
+			wantHTML: `<h3 class="h1" id="readme-installation--setup">Installation &amp; Setup</h3>` + "\n" +
+				`<h3 class="h1" id="readme-v12-release_notes">v1.2 Release_Notes</h3>` + "\n" +
+				`<p><a href="#readme-installation--setup" rel="nofollow">a</a> ` +
+				`<a href="#readme-v12-release_notes" rel="nofollow">b</a> ` +
+				`<a href="#readme-missing" rel="nofollow">c</a></p>`,
+			wantOutline: []*Heading{
+				{Level: 1, Text: "Installation & Setup", ID: "readme-installation--setup"},
+				{Level: 1, Text: "v1.2 Release_Notes", ID: "readme-v12-release_notes"},
 			},
 		},
 	} {
@@ -561,6 +579,36 @@ func TestReadmeLinks(t *testing.T) {
 	}
 }
 
+func TestReadmeProxyImages(t *testing.T) {
+	readme := &internal.Readme{
+		Filepath: "README.md",
+		Contents: "![logo](https://example.com/logo.png)\n\n" +
+			`<p><img src="http://example.com/a.png?x=1&amp;y=2"></p>` + "\n\n" +
+			"![local](logo.png) and [a link](https://example.com/logo.png)\n",
+	}
+	proxyImage := func(u string) string {
+		if !strings.HasPrefix(u, "http") {
+			return ""
+		}
+		return "/ipx/" + strings.TrimPrefix(strings.TrimPrefix(u, "http://"), "https://")
+	}
+	got, err := processMarkdown(context.Background(), readme, nil, markdownOptions{proxyImage: proxyImage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := got.HTML.String()
+	for _, want := range []string{
+		`<img src="/ipx/example.com/logo.png" alt="logo"/>`,
+		`<img src="/ipx/example.com/a.png?x=1&amp;y=2">`,
+		`<img src="logo.png" alt="local"/>`,
+		`<a href="https://example.com/logo.png" rel="nofollow">a link</a>`,
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, h)
+		}
+	}
+}
+
 // unindent removes indentation from s. It assumes that s starts with an initial
 // newline followed by one or more indented lines.
 func unindent(s string) string {
@@ -6,12 +6,16 @@ package frontend
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/version"
 )
 
 // handlePackageDetailsRedirect redirects all redirects to "/pkg" to "/".
@@ -48,3 +52,118 @@ func stdlibPathForShortcut(ctx context.Context, db *postgres.DB, shortcut string
 	// No matches, or ambiguous.
 	return "", nil
 }
+
+// ModuleRedirectPage contains the data for the page shown instead of the page
+// of a unit whose module was renamed or moved. It links to the corresponding
+// path in the new module, and to the page of the requested path.
+type ModuleRedirectPage struct {
+	basePage
+	Redirect *internal.ModuleRedirect
+	// FromPath is the requested path, and ToPath the corresponding path in
+	// the new module.
+	FromPath, ToPath string
+	ToURL            string
+	// ContinueURL is the URL of the page of FromPath, without this one.
+	ContinueURL string
+}
+
+// serveModuleRedirectPage serves a ModuleRedirectPage for the unit page
+// described by info, if its module was renamed or moved. It reports whether
+// the page was served.
+//
+// The page is only served for the main tab of the latest version, so that
+// links to a specific version or tab of the old module keep working, and not
+// at all if the "redirect" query parameter is "off".
+func (s *Server) serveModuleRedirectPage(w http.ResponseWriter, r *http.Request, ds internal.DataSource, info *urlPathInfo) (_ bool, err error) {
+	defer derrors.Wrap(&err, "serveModuleRedirectPage(w, r, ds, %v)", info)
+
+	if info.requestedVersion != version.Latest || r.FormValue("tab") != "" || r.FormValue("redirect") == "off" || s.shouldServeJSON(r) {
+		return false, nil
+	}
+	// Only the postgres data source stores redirects.
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return false, nil
+	}
+	ctx := r.Context()
+	mr, err := db.GetModuleRedirect(ctx, info.fullPath)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	u := *r.URL
+	q := u.Query()
+	q.Set("redirect", "off")
+	u.RawQuery = q.Encode()
+	toPath := mr.RedirectedPath(info.fullPath)
+	page := &ModuleRedirectPage{
+		basePage:    s.newBasePage(r, info.fullPath),
+		Redirect:    mr,
+		FromPath:    info.fullPath,
+		ToPath:      toPath,
+		ToURL:       "/" + toPath,
+		ContinueURL: u.String(),
+	}
+	s.servePage(ctx, w, "redirect", page)
+	return true, nil
+}
+
+// MovedToResponse describes, in an API response, the new location of a path
+// whose module was renamed or moved.
+type MovedToResponse struct {
+	// Status is always 301 (Moved Permanently). The API responds with it
+	// when the old path is not known; otherwise it responds as usual,
+	// with this metadata.
+	Status int    `json:"status"`
+	Path   string `json:"path"`
+	// ModulePath is the path of the new module.
+	ModulePath string `json:"modulePath"`
+	// Location is the URL of the same endpoint for Path.
+	Location string `json:"location"`
+	Source   string `json:"source"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// moduleAPIURL and packageAPIURL return the URLs of the module and package
+// API endpoints for path.
+func moduleAPIURL(path string) string {
+	return "/api/v1/module?" + url.Values{"module": {path}}.Encode()
+}
+
+func packageAPIURL(path string) string {
+	return "/api/v1/package?" + url.Values{"path": {path}}.Encode()
+}
+
+// movedToResponse returns the new location of fullPath, with its URL at the
+// endpoint returned by apiURL, or nil if its module was not moved.
+func movedToResponse(ctx context.Context, ds internal.DataSource, fullPath string, apiURL func(string) string) (*MovedToResponse, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil, nil
+	}
+	mr, err := db.GetModuleRedirect(ctx, fullPath)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	p := mr.RedirectedPath(fullPath)
+	return &MovedToResponse{
+		Status:     http.StatusMovedPermanently,
+		Path:       p,
+		ModulePath: mr.NewModulePath,
+		Location:   apiURL(p),
+		Source:     mr.Source,
+		Reason:     mr.Reason,
+	}, nil
+}
+
+// serveMovedJSON responds with a 301 status that points to the location in
+// m, and v as the body.
+func serveMovedJSON(w http.ResponseWriter, r *http.Request, m *MovedToResponse, v interface{}) error {
+	w.Header().Set("Location", m.Location)
+	return serveJSONWithStatus(w, r, http.StatusMovedPermanently, v)
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleRedirects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module(sample.ModulePath, "v1.0.0", "A"))
+	for _, mr := range []*internal.ModuleRedirect{
+		{OldModulePath: sample.ModulePath, NewModulePath: "example.com/new", Source: internal.RedirectAdmin, Reason: "renamed"},
+		{OldModulePath: "example.com/gone", NewModulePath: "example.com/gone2", Source: internal.RedirectAdmin},
+	} {
+		if err := testDB.InsertModuleRedirect(ctx, mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	t.Run("pages", func(t *testing.T) {
+		for _, test := range []struct {
+			path      string
+			wantMoved bool
+		}{
+			{"/" + sample.ModulePath + "/A", true},
+			{"/" + sample.ModulePath + "/A?redirect=off", false},
+			{"/" + sample.ModulePath + "/A?tab=versions", false},
+			{"/" + sample.ModulePath + "@v1.0.0/A", false},
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: got status %d, want %d", test.path, w.Code, http.StatusOK)
+			}
+			body := w.Body.String()
+			gotMoved := strings.Contains(body, `data-test-id="redirect-to"`)
+			if gotMoved != test.wantMoved {
+				t.Errorf("%s: got redirect page %t, want %t", test.path, gotMoved, test.wantMoved)
+			}
+			if gotMoved && !strings.Contains(body, `href="/example.com/new/A"`) {
+				t.Errorf("%s: missing link to the new path", test.path)
+			}
+		}
+	})
+
+	t.Run("api", func(t *testing.T) {
+		for _, test := range []struct {
+			path         string
+			wantStatus   int
+			wantLocation string
+			want         *MovedToResponse
+		}{
+			{
+				path:       "/api/v1/module?module=" + sample.ModulePath,
+				wantStatus: http.StatusOK,
+				want: &MovedToResponse{Status: http.StatusMovedPermanently, Path: "example.com/new",
+					ModulePath: "example.com/new", Location: "/api/v1/module?module=example.com%2Fnew",
+					Source: internal.RedirectAdmin, Reason: "renamed"},
+			},
+			{
+				path:         "/api/v1/package?path=example.com/gone/p",
+				wantStatus:   http.StatusMovedPermanently,
+				wantLocation: "/api/v1/package?path=example.com%2Fgone2%2Fp",
+				want: &MovedToResponse{Status: http.StatusMovedPermanently, Path: "example.com/gone2/p",
+					ModulePath: "example.com/gone2", Location: "/api/v1/package?path=example.com%2Fgone2%2Fp",
+					Source: internal.RedirectAdmin},
+			},
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+			if w.Code != test.wantStatus {
+				t.Fatalf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			}
+			if got := w.Header().Get("Location"); got != test.wantLocation {
+				t.Errorf("%s: got Location %q, want %q", test.path, got, test.wantLocation)
+			}
+			var got struct{ MovedTo *MovedToResponse }
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got.MovedTo); diff != "" {
+				t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+			}
+		}
+	})
+}
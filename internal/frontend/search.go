@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
 	"sort"
@@ -22,9 +23,11 @@ import (
 	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/postgres/search"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
 	"golang.org/x/text/message"
@@ -103,11 +106,31 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 	if s.vulnClient != nil {
 		getVulnEntries = s.vulnClient.GetByModule
 	}
-	page, err := fetchSearchPage(ctx, db, cq, symbol, pageParams, mode == searchModeSymbol, getVulnEntries)
+	// Correct misspelled single-term package searches, unless the user asked
+	// to search for the query as typed. If the correction is uncertain, it
+	// is only suggested.
+	var corrected, suggested string
+	if mode == searchModePackage && r.FormValue(noCorrectParam) == "" {
+		c, confident := correctSpelling(s.searchVocabulary(), cq)
+		if confident {
+			corrected = c
+		} else {
+			suggested = c
+		}
+	}
+	query := cq
+	if corrected != "" {
+		query = corrected
+	}
+	stdFilter := searchStdFilter(r)
+	goVersion := searchGoVersion(r)
+	hasFuzz := searchHasFuzz(r)
+	page, err := fetchSearchPage(ctx, db, query, symbol, stdFilter, goVersion, hasFuzz, pageParams, mode == searchModeSymbol, getVulnEntries)
 	if err != nil {
 		// Instead of returning a 500, return a 408, since symbol searches may
 		// timeout for very popular symbols.
-		if mode == searchModeSymbol && strings.Contains(err.Error(), "i/o timeout") {
+		if mode == searchModeSymbol && (strings.Contains(err.Error(), "i/o timeout") ||
+			strings.Contains(err.Error(), context.DeadlineExceeded.Error())) {
 			return &serverError{
 				status: http.StatusRequestTimeout,
 				epage: &errorPage{
@@ -118,8 +141,23 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 		}
 		return fmt.Errorf("fetchSearchPage(ctx, db, %q): %v", cq, err)
 	}
+	// Count only the first page of results, so that paging through them
+	// does not count as more searches.
+	if pageParams.page == 1 {
+		sr := &searchResult{Query: cq, Mode: mode, ResultCount: page.Pagination.TotalCount}
+		if len(page.Results) > 0 {
+			sr.TopResultPath = page.Results[0].PackagePath
+		}
+		setSearchResult(ctx, sr)
+	}
 	page.basePage = s.newBasePage(r, fmt.Sprintf("%s - Search Results", cq))
 	page.SearchMode = mode
+	page.CorrectedQuery = corrected
+	page.SuggestedQuery = suggested
+	page.PackageTabQuery = withFuzzOperator(withGoVersionOperator(withStdOperator(page.PackageTabQuery, stdFilter), goVersion), hasFuzz)
+	page.StdFilterChips = stdFilterChips(rawSearchQuery(r), mode, stdFilter)
+	page.GoVersionChip = goVersionChip(rawSearchQuery(r), mode, goVersion)
+	page.FuzzChip = fuzzChip(rawSearchQuery(r), mode, hasFuzz)
 	if s.shouldServeJSON(r) {
 		return s.serveJSONPage(w, r, page)
 	}
@@ -153,12 +191,30 @@ const (
 	// by symbols.
 	searchModeSymbol = "symbol"
 
+	// stdOperator is the prefix of the search query operators that restrict
+	// results to the standard library (std:only) or exclude it
+	// (std:exclude).
+	stdOperator = "std:"
+
+	// goVersionOperator is the prefix of the search query operator that
+	// restricts results to APIs available at a Go version, like go:1.18.
+	goVersionOperator = "go:"
+
+	// fuzzOperator is the search query operator that restricts package
+	// results to packages with fuzz targets.
+	fuzzOperator = "has:fuzz"
+
 	// symbolSearchFilter is a filter that can be used to indicate that the query
 	// contains a symbol. For example, searching for "#unmarshal json" indicates
 	// that unmarshal is a symbol.
 	symbolSearchFilter = "#"
 )
 
+// symbolSearchTimeout is the time allowed to the queries of a symbol search
+// that run in parallel. If some of them take longer, the results of the
+// others are shown, with a note that they may be incomplete.
+const symbolSearchTimeout = 10 * time.Second
+
 // SearchPage contains all of the data that the search template needs to
 // populate.
 type SearchPage struct {
@@ -170,6 +226,31 @@ type SearchPage struct {
 
 	Pagination pagination
 	Results    []*SearchResult
+
+	// StdFilterChips toggle the std filters of the query.
+	StdFilterChips []*searchChip
+
+	// GoVersionChip removes the Go version operator from the query, if it
+	// has one.
+	GoVersionChip *searchChip
+
+	// FuzzChip toggles the fuzz operator of the query. It is nil for symbol
+	// searches without the operator, since they ignore it.
+	FuzzChip *searchChip
+
+	// CorrectedQuery is the spelling correction of the query that the
+	// results are for, if any.
+	CorrectedQuery string
+
+	// SuggestedQuery is a possible spelling correction of the query, if any.
+	SuggestedQuery string
+
+	// Interleaving is set if the results interleave two rankings.
+	Interleaving *interleaving
+
+	// Incomplete reports whether some queries of the search timed out, so
+	// that the results may be incomplete.
+	Incomplete bool
 }
 
 // SearchResult contains data needed to display a single search result.
@@ -187,6 +268,7 @@ type SearchResult struct {
 	Symbols        *subResult
 	SameModule     *subResult // package paths in the same module
 	OtherMajor     *subResult // package paths in lower major versions
+	SameRepo       *subResult // best package paths of other modules in the same repo
 	SymbolName     string
 	SymbolKind     string
 	SymbolSynopsis string
@@ -194,6 +276,40 @@ type SearchResult struct {
 	SymbolGOARCH   string
 	SymbolLink     string
 	Vulns          []Vuln
+	// Team is the ranking that contributed the result to an interleaved
+	// results page: postgres.TeamControl or postgres.TeamTreatment.
+	Team string
+	// Snippet is shown instead of Synopsis if it is set. It highlights the
+	// terms that match the query.
+	Snippet []*snippetPart
+}
+
+// A snippetPart is a part of a search result snippet that either matches
+// the query or doesn't.
+type snippetPart struct {
+	Text  string
+	Match bool
+}
+
+// snippetParts splits a postgres.SearchResult.Snippet into parts, or returns
+// nil if it is empty.
+func snippetParts(snippet string) []*snippetPart {
+	var parts []*snippetPart
+	for snippet != "" {
+		before, rest, found := strings.Cut(snippet, postgres.SnippetStart)
+		if before != "" {
+			parts = append(parts, &snippetPart{Text: before})
+		}
+		if !found {
+			break
+		}
+		match, after, _ := strings.Cut(rest, postgres.SnippetEnd)
+		if match != "" {
+			parts = append(parts, &snippetPart{Text: match, Match: true})
+		}
+		snippet = after
+	}
+	return parts
 }
 
 type subResult struct {
@@ -203,26 +319,46 @@ type subResult struct {
 
 // fetchSearchPage fetches data matching the search query from the database and
 // returns a SearchPage.
-func fetchSearchPage(ctx context.Context, db *postgres.DB, cq, symbol string,
+func fetchSearchPage(ctx context.Context, db *postgres.DB, cq, symbol, stdFilter, goVersion string, hasFuzz bool,
 	pageParams paginationParams, searchSymbols bool, getVulnEntries vulnEntriesFunc) (*SearchPage, error) {
 	maxResultCount := maxSearchOffset + pageParams.limit
 
 	// Pageless search: always start from the beginning.
 	offset := 0
-	dbresults, err := db.Search(ctx, cq, postgres.SearchOptions{
+	opts := postgres.SearchOptions{
 		MaxResults:     pageParams.limit,
 		Offset:         offset,
 		MaxResultCount: maxResultCount,
 		SearchSymbols:  searchSymbols,
 		SymbolFilter:   symbol,
-	})
+		StdFilter:      stdFilter,
+		GoVersion:      goVersion,
+		HasFuzzTargets: hasFuzz,
+		Snippets:       experiment.IsActive(ctx, internal.ExperimentSearchSnippets),
+		SymbolTimeout:  symbolSearchTimeout,
+	}
+	var (
+		dbresults []*postgres.SearchResult
+		teams     []string
+		il        *interleaving
+		err       error
+	)
+	if !searchSymbols && experiment.IsActive(ctx, internal.ExperimentSearchInterleaving) {
+		dbresults, teams, err = searchInterleaved(ctx, db, cq, opts)
+		il = &interleaving{ImpressionID: newImpressionID(), Ranking: interleavedRanking}
+	} else {
+		dbresults, err = db.Search(ctx, cq, opts)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	var results []*SearchResult
-	for _, r := range dbresults {
+	for i, r := range dbresults {
 		sr := newSearchResult(r, searchSymbols, message.NewPrinter(middleware.LanguageTag(ctx)))
+		if teams != nil {
+			sr.Team = teams[i]
+		}
 		results = append(results, sr)
 	}
 
@@ -230,9 +366,13 @@ func fetchSearchPage(ctx context.Context, db *postgres.DB, cq, symbol string,
 		addVulns(results, getVulnEntries)
 	}
 
-	var numResults int
+	var (
+		numResults int
+		incomplete bool
+	)
 	if len(dbresults) > 0 {
 		numResults = int(dbresults[0].NumResults)
+		incomplete = dbresults[0].Incomplete
 	}
 
 	numPageResults := 0
@@ -250,6 +390,8 @@ func fetchSearchPage(ctx context.Context, db *postgres.DB, cq, symbol string,
 		PackageTabQuery: cq,
 		Results:         results,
 		Pagination:      pgs,
+		Interleaving:    il,
+		Incomplete:      incomplete,
 	}
 	return sp, nil
 }
@@ -274,6 +416,7 @@ func newSearchResult(r *postgres.SearchResult, searchSymbols bool, pr *message.P
 		Version:        r.Version,
 		ChipText:       chipText,
 		Synopsis:       r.Synopsis,
+		Snippet:        snippetParts(r.Snippet),
 		DisplayVersion: displayVersion(r.ModulePath, r.Version, r.Version),
 		Licenses:       r.Licenses,
 		CommitTime:     elapsedTime(r.CommitTime),
@@ -283,6 +426,7 @@ func newSearchResult(r *postgres.SearchResult, searchSymbols bool, pr *message.P
 		// prefer to show a tagged, lower major version over an untagged
 		// higher major version.
 		OtherMajor: modulePaths("Other major versions:", r.OtherMajor),
+		SameRepo:   repoPackagePaths("Other modules in this repository:", r.SameRepo),
 	}
 	if searchSymbols {
 		sr.SymbolName = r.SymbolName
@@ -358,20 +502,199 @@ func searchMode(r *http.Request) string {
 	return searchModePackage
 }
 
-// searchQueryAndFilters returns the search query, trimmed of any filters, and
-// the array of words that had a filter prefix.
+// searchQueryAndFilters returns the search query, trimmed of any filters and
+// std, Go version or fuzz operators, and the array of words that had a filter
+// prefix.
 func searchQueryAndFilters(r *http.Request) (string, []string) {
-	words := strings.Fields(rawSearchQuery(r))
-	var filters []string
-	for i := range words {
-		if strings.HasPrefix(words[i], symbolSearchFilter) {
-			words[i] = strings.TrimLeft(words[i], symbolSearchFilter)
-			filters = append(filters, words[i])
+	var words, filters []string
+	for _, w := range strings.Fields(rawSearchQuery(r)) {
+		if _, ok := parseStdOperator(w); ok {
+			continue
+		}
+		if _, ok := parseGoVersionOperator(w); ok {
+			continue
+		}
+		if isFuzzOperator(w) {
+			continue
 		}
+		if strings.HasPrefix(w, symbolSearchFilter) {
+			w = strings.TrimLeft(w, symbolSearchFilter)
+			filters = append(filters, w)
+		}
+		words = append(words, w)
 	}
 	return strings.Join(words, " "), filters
 }
 
+// searchStdFilter returns the std filter of the last std operator in the
+// search query, or the empty string if there is none.
+func searchStdFilter(r *http.Request) string {
+	var filter string
+	for _, w := range strings.Fields(rawSearchQuery(r)) {
+		if f, ok := parseStdOperator(w); ok {
+			filter = f
+		}
+	}
+	return filter
+}
+
+// parseStdOperator reports whether word is a std operator, like "std:only",
+// and returns its postgres.SearchOptions.StdFilter.
+func parseStdOperator(word string) (string, bool) {
+	if len(word) < len(stdOperator) || !strings.EqualFold(word[:len(stdOperator)], stdOperator) {
+		return "", false
+	}
+	switch f := strings.ToLower(word[len(stdOperator):]); f {
+	case postgres.StdOnly, postgres.StdExclude:
+		return f, true
+	}
+	return "", false
+}
+
+// withStdOperator returns the search query q with its std operators replaced
+// by one for the std filter f, or removed if f is empty.
+func withStdOperator(q, f string) string {
+	var words []string
+	for _, w := range strings.Fields(q) {
+		if _, ok := parseStdOperator(w); !ok {
+			words = append(words, w)
+		}
+	}
+	if f != "" {
+		words = append(words, stdOperator+f)
+	}
+	return strings.Join(words, " ")
+}
+
+// searchGoVersion returns the Go version of the last Go version operator in
+// the search query, or the empty string if there is none.
+func searchGoVersion(r *http.Request) string {
+	var v string
+	for _, w := range strings.Fields(rawSearchQuery(r)) {
+		if gv, ok := parseGoVersionOperator(w); ok {
+			v = gv
+		}
+	}
+	return v
+}
+
+// parseGoVersionOperator reports whether word is a Go version operator, like
+// "go:1.18" or "go:go1.18", and returns its Go version, like "1.18".
+func parseGoVersionOperator(word string) (string, bool) {
+	if len(word) < len(goVersionOperator) || !strings.EqualFold(word[:len(goVersionOperator)], goVersionOperator) {
+		return "", false
+	}
+	v := strings.TrimPrefix(strings.ToLower(word[len(goVersionOperator):]), "go")
+	if stdlib.VersionForTag("go"+v) == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// withGoVersionOperator returns the search query q with its Go version
+// operators replaced by one for the Go version v, or removed if v is empty.
+func withGoVersionOperator(q, v string) string {
+	var words []string
+	for _, w := range strings.Fields(q) {
+		if _, ok := parseGoVersionOperator(w); !ok {
+			words = append(words, w)
+		}
+	}
+	if v != "" {
+		words = append(words, goVersionOperator+v)
+	}
+	return strings.Join(words, " ")
+}
+
+// searchHasFuzz reports whether the search query has a fuzz operator.
+func searchHasFuzz(r *http.Request) bool {
+	for _, w := range strings.Fields(rawSearchQuery(r)) {
+		if isFuzzOperator(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFuzzOperator reports whether word is the fuzz operator, in any case.
+func isFuzzOperator(word string) bool {
+	return strings.EqualFold(word, fuzzOperator)
+}
+
+// withFuzzOperator returns the search query q with one fuzz operator if on is
+// true, or none otherwise.
+func withFuzzOperator(q string, on bool) string {
+	var words []string
+	for _, w := range strings.Fields(q) {
+		if !isFuzzOperator(w) {
+			words = append(words, w)
+		}
+	}
+	if on {
+		words = append(words, fuzzOperator)
+	}
+	return strings.Join(words, " ")
+}
+
+// searchChip is a toggle that changes the search query.
+type searchChip struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+// stdFilterChips returns the chips that toggle the std filters of the search
+// query q in the given mode, where the current filter is current.
+func stdFilterChips(q, mode, current string) []*searchChip {
+	var chips []*searchChip
+	for _, c := range []struct{ label, filter string }{
+		{"Standard library", postgres.StdOnly},
+		{"Third-party", postgres.StdExclude},
+	} {
+		f := c.filter
+		if f == current {
+			// Clicking on the active chip removes the filter.
+			f = ""
+		}
+		v := url.Values{"q": {withStdOperator(q, f)}, "m": {mode}}
+		chips = append(chips, &searchChip{
+			Label:  c.label,
+			URL:    "/search?" + v.Encode(),
+			Active: c.filter == current,
+		})
+	}
+	return chips
+}
+
+// goVersionChip returns the chip that removes the Go version v from the
+// search query q in the given mode, or nil if v is empty.
+func goVersionChip(q, mode, v string) *searchChip {
+	if v == "" {
+		return nil
+	}
+	u := url.Values{"q": {withGoVersionOperator(q, "")}, "m": {mode}}
+	return &searchChip{
+		Label:  "Available in Go " + v,
+		URL:    "/search?" + u.Encode(),
+		Active: true,
+	}
+}
+
+// fuzzChip returns the chip that toggles the fuzz operator of the search
+// query q in the given mode, where active reports whether q has it. It returns
+// nil for symbol searches without the operator.
+func fuzzChip(q, mode string, active bool) *searchChip {
+	if mode == searchModeSymbol && !active {
+		return nil
+	}
+	u := url.Values{"q": {withFuzzOperator(q, !active)}, "m": {mode}}
+	return &searchChip{
+		Label:  "Has fuzz targets",
+		URL:    "/search?" + u.Encode(),
+		Active: active,
+	}
+}
+
 // rawSearchQuery returns the exact search query by the user.
 func rawSearchQuery(r *http.Request) string {
 	return strings.TrimSpace(r.FormValue("q"))
@@ -385,6 +708,11 @@ func rawSearchMode(r *http.Request) string {
 // shouldDefaultToSymbolSearch reports whether the symbol search mode should
 // default to symbol search mode based on the input.
 func shouldDefaultToSymbolSearch(q string) bool {
+	// A search like "golang.org/x/net/http2 Framer" is for a symbol in the
+	// package or module with that path.
+	if _, symbol, ok := search.ParsePathScopedQuery(q); ok {
+		return isCapitalized(symbol)
+	}
 	if len(strings.Fields(q)) != 1 {
 		return false
 	}
@@ -441,6 +769,22 @@ func packagePaths(heading string, rs []*postgres.SearchResult) *subResult {
 	}
 }
 
+// repoPackagePaths returns links to the packages in rs, which are each the
+// best match from a module in the same repository.
+func repoPackagePaths(heading string, rs []*postgres.SearchResult) *subResult {
+	if len(rs) == 0 {
+		return nil
+	}
+	var links []link
+	for _, r := range rs {
+		links = append(links, link{Href: r.PackagePath, Body: r.PackagePath})
+	}
+	return &subResult{
+		Heading: heading,
+		Links:   links,
+	}
+}
+
 func modulePaths(heading string, modulePathToMajor map[string]int) *subResult {
 	if len(modulePathToMajor) == 0 {
 		return nil
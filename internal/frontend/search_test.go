@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -63,6 +64,109 @@ func TestSearchQueryAndMode(t *testing.T) {
 	}
 }
 
+func TestSearchStdFilter(t *testing.T) {
+	for _, test := range []struct {
+		q, wantQuery, wantFilter string
+	}{
+		{"json", "json", ""},
+		{"json std:only", "json", postgres.StdOnly},
+		{"STD:Exclude json", "json", postgres.StdExclude},
+		{"json std:only std:exclude", "json", postgres.StdExclude},
+		{"json std:other", "json std:other", ""},
+		{"#Marshal std:only", "Marshal", postgres.StdOnly},
+	} {
+		r := httptest.NewRequest("GET", "/search?"+url.Values{"q": {test.q}}.Encode(), nil)
+		gotQuery, _ := searchQueryAndFilters(r)
+		gotFilter := searchStdFilter(r)
+		if gotQuery != test.wantQuery || gotFilter != test.wantFilter {
+			t.Errorf("%q: got query %q and filter %q, want %q and %q",
+				test.q, gotQuery, gotFilter, test.wantQuery, test.wantFilter)
+		}
+	}
+}
+
+func TestStdFilterChips(t *testing.T) {
+	got := stdFilterChips("json std:only", searchModePackage, postgres.StdOnly)
+	want := []*searchChip{
+		{Label: "Standard library", URL: "/search?m=package&q=json", Active: true},
+		{Label: "Third-party", URL: "/search?m=package&q=json+std%3Aexclude"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestSearchGoVersion(t *testing.T) {
+	for _, test := range []struct {
+		q, wantQuery, wantVersion string
+	}{
+		{"json", "json", ""},
+		{"json go:1.18", "json", "1.18"},
+		{"GO:go1.21.3 json", "json", "1.21.3"},
+		{"json go:1.16 go:1.18", "json", "1.18"},
+		{"json go:latest", "json go:latest", ""},
+		{"json go:1.0", "json go:1.0", ""},
+		{"#Marshal go:1.18 std:only", "Marshal", "1.18"},
+	} {
+		r := httptest.NewRequest("GET", "/search?"+url.Values{"q": {test.q}}.Encode(), nil)
+		gotQuery, _ := searchQueryAndFilters(r)
+		gotVersion := searchGoVersion(r)
+		if gotQuery != test.wantQuery || gotVersion != test.wantVersion {
+			t.Errorf("%q: got query %q and version %q, want %q and %q",
+				test.q, gotQuery, gotVersion, test.wantQuery, test.wantVersion)
+		}
+	}
+}
+
+func TestGoVersionChip(t *testing.T) {
+	if got := goVersionChip("json", searchModePackage, ""); got != nil {
+		t.Errorf("got %+v, want nil", got)
+	}
+	got := goVersionChip("json go:1.18 std:only", searchModeSymbol, "1.18")
+	want := &searchChip{Label: "Available in Go 1.18", URL: "/search?m=symbol&q=json+std%3Aonly", Active: true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestSearchHasFuzz(t *testing.T) {
+	for _, test := range []struct {
+		q, wantQuery string
+		wantFuzz     bool
+	}{
+		{"json", "json", false},
+		{"json has:fuzz", "json", true},
+		{"HAS:Fuzz json std:only", "json", true},
+		{"json has:tests", "json has:tests", false},
+	} {
+		r := httptest.NewRequest("GET", "/search?"+url.Values{"q": {test.q}}.Encode(), nil)
+		gotQuery, _ := searchQueryAndFilters(r)
+		gotFuzz := searchHasFuzz(r)
+		if gotQuery != test.wantQuery || gotFuzz != test.wantFuzz {
+			t.Errorf("%q: got query %q and fuzz %t, want %q and %t",
+				test.q, gotQuery, gotFuzz, test.wantQuery, test.wantFuzz)
+		}
+	}
+}
+
+func TestFuzzChip(t *testing.T) {
+	for _, test := range []struct {
+		q, mode string
+		active  bool
+		want    *searchChip
+	}{
+		{"json", searchModePackage, false, &searchChip{Label: "Has fuzz targets", URL: "/search?m=package&q=json+has%3Afuzz"}},
+		{"json has:fuzz", searchModePackage, true, &searchChip{Label: "Has fuzz targets", URL: "/search?m=package&q=json", Active: true}},
+		{"json", searchModeSymbol, false, nil},
+		{"json has:fuzz", searchModeSymbol, true, &searchChip{Label: "Has fuzz targets", URL: "/search?m=symbol&q=json", Active: true}},
+	} {
+		got := fuzzChip(test.q, test.mode, test.active)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q, %s: mismatch (-want, +got):\n%s", test.q, test.mode, diff)
+		}
+	}
+}
+
 func TestFetchSearchPage(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -231,7 +335,7 @@ func TestFetchSearchPage(t *testing.T) {
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := fetchSearchPage(ctx, testDB, test.query, "", paginationParams{limit: 20, page: 1}, false, getVulnEntries)
+			got, err := fetchSearchPage(ctx, testDB, test.query, "", "", "", false, paginationParams{limit: 20, page: 1}, false, getVulnEntries)
 			if err != nil {
 				t.Fatalf("fetchSearchPage(db, %q): %v", test.query, err)
 			}
@@ -314,6 +418,31 @@ func TestNewSearchResult(t *testing.T) {
 				NumImportedBy:  "0",
 			},
 		},
+		{
+			name: "same repo",
+			tag:  language.English,
+			in: postgres.SearchResult{
+				Name:        "gopls",
+				PackagePath: "golang.org/x/tools/gopls",
+				ModulePath:  "golang.org/x/tools/gopls",
+				Version:     "v0.9.0",
+				SameRepo: []*postgres.SearchResult{
+					{PackagePath: "golang.org/x/tools/go/packages", ModulePath: "golang.org/x/tools"},
+				},
+			},
+			want: SearchResult{
+				Name:           "gopls",
+				PackagePath:    "golang.org/x/tools/gopls",
+				ModulePath:     "golang.org/x/tools/gopls",
+				Version:        "v0.9.0",
+				DisplayVersion: "v0.9.0",
+				NumImportedBy:  "0",
+				SameRepo: &subResult{
+					Heading: "Other modules in this repository:",
+					Links:   []link{{Href: "golang.org/x/tools/go/packages", Body: "golang.org/x/tools/go/packages"}},
+				},
+			},
+		},
 		{
 			name: "German",
 			tag:  language.German,
@@ -498,6 +627,9 @@ func TestShouldDefaultToSymbolSearch(t *testing.T) {
 		{"yaml.v2", false},
 		{"gopkg.in", false},
 		{"Unmarshal", true},
+		{"golang.org/x/net/http2 Framer", true},
+		{"net/http Client.Do", true},
+		{"github.com/foo/bar baz", false},
 	} {
 		t.Run(test.q, func(t *testing.T) {
 			got := shouldDefaultToSymbolSearch(test.q)
@@ -507,3 +639,28 @@ func TestShouldDefaultToSymbolSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestSnippetParts(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want []*snippetPart
+	}{
+		{"", nil},
+		{"no match", []*snippetPart{{Text: "no match"}}},
+		{
+			"Package " + postgres.SnippetStart + "mux" + postgres.SnippetEnd + " implements a " +
+				postgres.SnippetStart + "router" + postgres.SnippetEnd,
+			[]*snippetPart{
+				{Text: "Package "},
+				{Text: "mux", Match: true},
+				{Text: " implements a "},
+				{Text: "router", Match: true},
+			},
+		},
+	} {
+		got := snippetParts(test.in)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.in, diff)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const (
+	// searchEmbedPath is the path of the search widget.
+	searchEmbedPath = "/search-embed"
+
+	// maxSearchEmbedResults is the number of results shown by the search
+	// widget.
+	maxSearchEmbedResults = 10
+)
+
+// SearchEmbedPage holds the data of the search widget, a small search form
+// and list of results that other sites can show in a frame.
+type SearchEmbedPage struct {
+	AppVersionLabel string
+	Query           string
+	// PathPrefix restricts the results to the packages at or below it.
+	PathPrefix string
+	Results    []*SearchEmbedResult
+}
+
+// A SearchEmbedResult is a package listed by the search widget.
+type SearchEmbedResult struct {
+	PackagePath string
+	URL         string
+	Synopsis    string
+}
+
+// serveSearchEmbed handles requests for /search-embed?q=<query>[&prefix=<path>].
+// It serves a standalone HTML page without scripts that lists the packages
+// matching the query, restricted to those at or below the prefix path if one
+// is provided. Unlike other pages, it can be framed by the sites configured
+// in SearchEmbedFrameAncestors.
+func (s *Server) serveSearchEmbed(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return datasourceNotSupportedErr()
+	}
+	q := rawSearchQuery(r)
+	if !utf8.ValidString(q) || len(q) > maxSearchQueryLength {
+		return &serverError{status: http.StatusBadRequest}
+	}
+	prefix := strings.Trim(r.FormValue("prefix"), "/")
+	if prefix != "" && module.CheckImportPath(prefix) != nil {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid prefix"}
+	}
+	ctx := r.Context()
+	page := &SearchEmbedPage{
+		AppVersionLabel: s.appVersionLabel,
+		Query:           q,
+		PathPrefix:      prefix,
+	}
+	if q != "" {
+		results, err := db.Search(ctx, q, postgres.SearchOptions{
+			MaxResults: maxSearchEmbedResults,
+			PathPrefix: prefix,
+		})
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			page.Results = append(page.Results, &SearchEmbedResult{
+				PackagePath: res.PackagePath,
+				URL:         "/" + res.PackagePath,
+				Synopsis:    res.Synopsis,
+			})
+		}
+	}
+	tmpl, err := s.findTemplate("search-embed")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "search-embed", page); err != nil {
+		log.Errorf(ctx, "Error executing template %q: %v", "search-embed", err)
+		return err
+	}
+	setSearchEmbedHeaders(w.Header(), s.searchEmbedFrameAncestors)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// setSearchEmbedHeaders replaces the security headers set by the
+// middleware, which forbid framing, with a policy that allows the given
+// frame ancestors to frame the page, and forbids scripts.
+func setSearchEmbedHeaders(h http.Header, frameAncestors []string) {
+	ancestors := "'none'"
+	if len(frameAncestors) > 0 {
+		ancestors = strings.Join(frameAncestors, " ")
+	}
+	h.Del("X-Frame-Options")
+	h.Del("Content-Security-Policy-Report-Only")
+	h.Set("Content-Security-Policy", strings.Join([]string{
+		"default-src 'none'",
+		"style-src 'self'",
+		"img-src 'self'",
+		"form-action 'self'",
+		"base-uri 'none'",
+		"frame-ancestors " + ancestors,
+	}, "; "))
+}
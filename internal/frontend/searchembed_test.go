@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSetSearchEmbedHeaders(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		frameAncestors []string
+		want           string
+	}{
+		{
+			name: "no ancestors",
+			want: "default-src 'none'; style-src 'self'; img-src 'self'; form-action 'self'; base-uri 'none'; frame-ancestors 'none'",
+		},
+		{
+			name:           "ancestors",
+			frameAncestors: []string{"https://a.example.com", "https://b.example.com"},
+			want:           "default-src 'none'; style-src 'self'; img-src 'self'; form-action 'self'; base-uri 'none'; frame-ancestors https://a.example.com https://b.example.com",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("X-Frame-Options", "deny")
+			h.Set("Content-Security-Policy", "object-src 'none'")
+			setSearchEmbedHeaders(h, test.frameAncestors)
+			if got := h.Get("Content-Security-Policy"); got != test.want {
+				t.Errorf("Content-Security-Policy = %q, want %q", got, test.want)
+			}
+			if got := h.Get("X-Frame-Options"); got != "" {
+				t.Errorf("X-Frame-Options = %q, want it removed", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	icache "golang.org/x/pkgsite/internal/cache"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/xcontext"
+)
+
+const (
+	// searchQueryFlushInterval is how often search query counts are written
+	// to the database.
+	searchQueryFlushInterval = time.Minute
+
+	// maxLoggedQueryLength is the length in bytes to which search queries are
+	// truncated before they are counted.
+	maxLoggedQueryLength = 100
+)
+
+// searchQueryCounter counts searches in memory, per normalized query and
+// mode, and periodically records the counts. Nothing about the searchers is
+// kept.
+type searchQueryCounter struct {
+	record func(context.Context, []*postgres.SearchQuery) error
+
+	mu      sync.Mutex
+	queries map[searchQueryKey]*postgres.SearchQuery
+}
+
+type searchQueryKey struct {
+	day         time.Time
+	query, mode string
+}
+
+func newSearchQueryCounter(record func(context.Context, []*postgres.SearchQuery) error) *searchQueryCounter {
+	return &searchQueryCounter{record: record, queries: map[searchQueryKey]*postgres.SearchQuery{}}
+}
+
+// add counts a search for query in mode on the current day, which had
+// resultCount results, the first of which was topResultPath.
+func (c *searchQueryCounter) add(query, mode string, resultCount int, topResultPath string) {
+	query = normalizeLoggedQuery(query)
+	if query == "" {
+		return
+	}
+	k := searchQueryKey{
+		day:   time.Now().UTC().Truncate(24 * time.Hour),
+		query: query,
+		mode:  mode,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sq := c.queries[k]
+	if sq == nil {
+		sq = &postgres.SearchQuery{Day: k.day, Query: k.query, Mode: k.mode}
+		c.queries[k] = sq
+	}
+	sq.Searches++
+	if resultCount == 0 {
+		sq.ZeroResultSearches++
+	}
+	sq.ResultCount = resultCount
+	sq.TopResultPath = topResultPath
+}
+
+// flush records the searches counted since the last flush. If recording
+// fails, the searches are dropped, since the counts need not be exact.
+func (c *searchQueryCounter) flush(ctx context.Context) {
+	c.mu.Lock()
+	queries := c.queries
+	c.queries = map[searchQueryKey]*postgres.SearchQuery{}
+	c.mu.Unlock()
+
+	var sqs []*postgres.SearchQuery
+	for _, sq := range queries {
+		sqs = append(sqs, sq)
+	}
+	if err := c.record(ctx, sqs); err != nil {
+		log.Errorf(ctx, "recording search queries: %v", err)
+	}
+}
+
+// start flushes the counts every interval, until ctx is done.
+func (c *searchQueryCounter) start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.flush(ctx)
+			}
+		}
+	}()
+}
+
+// normalizeLoggedQuery returns q in lower case, with runs of white space
+// replaced by a single space, and truncated to maxLoggedQueryLength bytes,
+// so that searches that differ only in those ways are counted together.
+func normalizeLoggedQuery(q string) string {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if len(q) <= maxLoggedQueryLength {
+		return q
+	}
+	q = q[:maxLoggedQueryLength]
+	// Don't cut a rune in half.
+	for len(q) > 0 && !utf8.ValidString(q) {
+		q = q[:len(q)-1]
+	}
+	return q
+}
+
+// A searchResult describes the first page of results of a search, so that
+// the search can be counted when the page is served from the cache.
+type searchResult struct {
+	Query         string
+	Mode          string
+	ResultCount   int
+	TopResultPath string
+}
+
+// searchResultKeyPrefix prefixes the URL of a search page to form the cache
+// key of its searchResult.
+const searchResultKeyPrefix = "search-result:"
+
+// searchResultHolder receives the searchResult of a search page from
+// serveSearch, and stores it next to the page in the cache.
+type searchResultHolder struct {
+	cache *icache.Cache // may be nil
+	key   string
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	result *searchResult
+}
+
+type searchResultHolderKey struct{}
+
+// setSearchResult records sr as the result of the search page being served
+// with ctx. It is also called when a stale page is refreshed in the
+// background, after the request has been counted, so that the cached result
+// is kept up to date.
+func setSearchResult(ctx context.Context, sr *searchResult) {
+	h, ok := ctx.Value(searchResultHolderKey{}).(*searchResultHolder)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	h.result = sr
+	h.mu.Unlock()
+	if h.cache == nil {
+		return
+	}
+	data, err := json.Marshal(sr)
+	if err != nil {
+		log.Errorf(ctx, "searchResult: %v", err)
+		return
+	}
+	putCtx, cancel := context.WithTimeout(xcontext.Detach(ctx), time.Second)
+	defer cancel()
+	if err := h.cache.Put(putCtx, h.key, data, h.ttl); err != nil {
+		log.Warningf(ctx, "cache set %q: %v", h.key, err)
+	}
+}
+
+// countSearches returns a handler that serves search pages with h, and
+// counts the searches of those that are served successfully. It must wrap
+// the cache of search pages, so that pages served from the cache are
+// counted as well. The result of each search is stored in c, if it is not
+// nil, for ttl(r), and read back when the page is served from the cache.
+// The mirrored copies of shadowed requests are not counted.
+func (s *Server) countSearches(h http.Handler, c *icache.Cache, ttl func(*http.Request) time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middleware.IsMirrored(r.Context()) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		holder := &searchResultHolder{cache: c, key: searchResultKeyPrefix + r.URL.String()}
+		if c != nil {
+			// Keep the result as long as the page it describes.
+			holder.ttl = ttl(r)
+		}
+		sw := &statusResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), searchResultHolderKey{}, holder)))
+		if r.Method != http.MethodGet || (sw.status != 0 && sw.status != http.StatusOK) {
+			return
+		}
+		holder.mu.Lock()
+		sr := holder.result
+		holder.mu.Unlock()
+		if sr != nil {
+			s.searchQueries.add(sr.Query, sr.Mode, sr.ResultCount, sr.TopResultPath)
+			return
+		}
+		if c == nil {
+			return
+		}
+		// The page was served from the cache. Look up its result without
+		// delaying the response.
+		ctx := xcontext.Detach(r.Context())
+		go func() {
+			getCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			data, err := c.Get(getCtx, holder.key)
+			if err != nil {
+				log.Infof(ctx, "cache get(%q): %v", holder.key, err)
+				return
+			}
+			if data == nil {
+				return
+			}
+			var sr searchResult
+			if err := json.Unmarshal(data, &sr); err != nil {
+				log.Errorf(ctx, "searchResult: %v", err)
+				return
+			}
+			s.searchQueries.add(sr.Query, sr.Mode, sr.ResultCount, sr.TopResultPath)
+		}()
+	})
+}
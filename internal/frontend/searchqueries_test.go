@@ -0,0 +1,131 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/go-cmp/cmp"
+	icache "golang.org/x/pkgsite/internal/cache"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestSearchQueryCounter(t *testing.T) {
+	var got []*postgres.SearchQuery
+	c := newSearchQueryCounter(func(_ context.Context, sqs []*postgres.SearchQuery) error {
+		got = append(got, sqs...)
+		return nil
+	})
+	c.add("YAML", searchModePackage, 40, "gopkg.in/yaml.v2")
+	c.add("  yaml ", searchModePackage, 41, "gopkg.in/yaml.v3")
+	c.add("frob  nicate", searchModePackage, 0, "")
+	c.add("frob nicate", searchModeSymbol, 0, "")
+	c.add(" ", searchModePackage, 0, "")
+	c.flush(context.Background())
+
+	sort.Slice(got, func(i, j int) bool {
+		if got[i].Query != got[j].Query {
+			return got[i].Query < got[j].Query
+		}
+		return got[i].Mode < got[j].Mode
+	})
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	want := []*postgres.SearchQuery{
+		{Day: day, Query: "frob nicate", Mode: searchModePackage, Searches: 1, ZeroResultSearches: 1},
+		{Day: day, Query: "frob nicate", Mode: searchModeSymbol, Searches: 1, ZeroResultSearches: 1},
+		{Day: day, Query: "yaml", Mode: searchModePackage, Searches: 2, ResultCount: 41, TopResultPath: "gopkg.in/yaml.v3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The counts are reset after a flush.
+	got = nil
+	c.flush(context.Background())
+	if len(got) != 0 {
+		t.Errorf("got %d search queries after flushing, want 0", len(got))
+	}
+}
+
+func TestNormalizeLoggedQuery(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"  Hello\tWorld\n", "hello world"},
+		{strings.Repeat("a", 150), strings.Repeat("a", maxLoggedQueryLength)},
+		// A two-byte rune that would be cut in half is dropped.
+		{strings.Repeat("a", maxLoggedQueryLength-1) + "é", strings.Repeat("a", maxLoggedQueryLength-1)},
+	} {
+		if got := normalizeLoggedQuery(test.in); got != test.want {
+			t.Errorf("normalizeLoggedQuery(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestCountSearches(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	var got []*postgres.SearchQuery
+	s := &Server{searchQueries: newSearchQueryCounter(func(_ context.Context, sqs []*postgres.SearchQuery) error {
+		got = append(got, sqs...)
+		return nil
+	})}
+	// The first request for a page computes it, and later ones are served
+	// from the cache, without calling serveSearch.
+	served := map[string]bool{}
+	h := s.countSearches(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.FormValue("q")
+		if q == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if served[r.URL.String()] {
+			return
+		}
+		served[r.URL.String()] = true
+		setSearchResult(r.Context(), &searchResult{Query: q, Mode: searchModePackage, ResultCount: len(q) - 1, TopResultPath: q})
+	}), icache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()})), func(*http.Request) time.Duration { return time.Hour })
+
+	for _, target := range []string{"/search?q=ab", "/search?q=ab", "/search?q=a", "/search?q=fail"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	// Searches served from the cache are counted in the background.
+	sq := searchQueryKey{day: time.Now().UTC().Truncate(24 * time.Hour), query: "ab", mode: searchModePackage}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		s.searchQueries.mu.Lock()
+		n := s.searchQueries.queries[sq].Searches
+		s.searchQueries.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("got %d searches for %q, want 2", n, sq.query)
+		}
+	}
+	s.searchQueries.flush(context.Background())
+
+	sort.Slice(got, func(i, j int) bool { return got[i].Query < got[j].Query })
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	want := []*postgres.SearchQuery{
+		{Day: day, Query: "a", Mode: searchModePackage, Searches: 1, ZeroResultSearches: 1, TopResultPath: "a"},
+		{Day: day, Query: "ab", Mode: searchModePackage, Searches: 2, ResultCount: 1, TopResultPath: "ab"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -6,6 +6,7 @@
 package frontend
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"errors"
@@ -26,14 +27,22 @@ import (
 	"github.com/google/safehtml"
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
+	icache "golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/failover"
+	"golang.org/x/pkgsite/internal/fault"
 	"golang.org/x/pkgsite/internal/godoc/dochtml"
+	"golang.org/x/pkgsite/internal/imageproxy"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/memory"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/namespace"
+	"golang.org/x/pkgsite/internal/poller"
+	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/queue"
 	"golang.org/x/pkgsite/internal/static"
 	"golang.org/x/pkgsite/internal/version"
@@ -60,6 +69,30 @@ type Server struct {
 	vulnClient           vulnc.Client
 	versionID            string
 	instanceID           string
+	cspReportSampleRate  float64
+	supportContact       string
+	landingPage          string
+	mirrorUpstreamURL    string
+	canonicalHost        string
+	versionedCanonical   bool
+	vulnReportTokens     []string
+	benchmarkTokens      []string
+	// searchEmbedFrameAncestors are the sources of the pages that may embed
+	// the search widget.
+	searchEmbedFrameAncestors []string
+	bannerPoller              *poller.Poller
+	homepagePoller            *poller.Poller
+	vocabularyPoller          *poller.Poller
+	faultInjector             *fault.Injector
+	failover                  *failover.Monitor
+	moduleZipGetter           func(context.Context, string, string) (*zip.Reader, error)
+	namespaceVerifier         func(context.Context, *namespace.Challenge) (bool, error)
+	renderDiagram             diagramFunc
+	imageProxy                *imageproxy.Proxy
+	fetchAuthorizer           func(*http.Request) (string, error)
+	pageViews                 *pageViewCounter
+	searchQueries             *searchQueryCounter
+	changesSem                chan struct{}
 
 	mu        sync.Mutex // Protects all fields below
 	templates map[string]*template.Template
@@ -80,6 +113,56 @@ type ServerConfig struct {
 	StaticPath           string // used only for dynamic loading in dev mode
 	ReportingClient      *errorreporting.Client
 	VulndbClient         vulnc.Client
+	// BannerGetter returns the message of the site-wide banner, or the empty
+	// string if there is none. It is polled, so changes take effect within a
+	// minute. It may be nil.
+	BannerGetter func(context.Context) (string, error)
+	// HomepageGetter returns the curated sections of the homepage. If there
+	// are any, they replace the public homepage content. It is polled, so
+	// changes take effect within a minute. It may be nil.
+	HomepageGetter func(context.Context) ([]*internal.HomepageSection, error)
+	// VocabularyGetter returns up to limit terms of the search vocabulary,
+	// mapped to the number of packages they appear in. It is used to
+	// correct misspelled search queries, and is polled hourly. It may be nil.
+	VocabularyGetter func(ctx context.Context, limit int) (map[string]int, error)
+	// FaultInjector, if non-nil, is served at /_debug/fault so that the
+	// faults it injects can be changed while the server runs.
+	FaultInjector *fault.Injector
+	// Failover, if non-nil, reports whether the primary database is down.
+	// While it is, the site is read-only: fetching is disabled, and a banner
+	// says so. Its status is served at /_status.
+	Failover *failover.Monitor
+	// ModuleZipGetter returns the zip of a module version. It is used to
+	// show the changes between versions of a module at /changes/, and to
+	// serve the archives of directories. It may be nil, in which case
+	// neither is supported.
+	ModuleZipGetter func(ctx context.Context, modulePath, version string) (*zip.Reader, error)
+	// NamespaceVerifier reports whether the claimant of a module path prefix
+	// has met its challenge, as namespace.Verifier.Verify does. It may be
+	// nil, in which case claims cannot be verified.
+	NamespaceVerifier func(ctx context.Context, c *namespace.Challenge) (bool, error)
+	// DiagramRenderer renders the source of a Mermaid or PlantUML diagram
+	// in a README or guide to SVG, as diagram.Renderer.Render does. It may
+	// be nil, in which case diagrams are displayed as code.
+	DiagramRenderer func(ctx context.Context, kind, source string) ([]byte, error)
+	// ImageProxy serves the external images in READMEs and guides. It may be
+	// nil, in which case images are loaded from their hosts.
+	ImageProxy *imageproxy.Proxy
+	// FetchAuthorizer returns the user who made a request, and a non-nil
+	// error if they may not fetch modules, as oidc.Authorizer.Authorize
+	// does. It may be nil, in which case anyone may fetch modules. If it is
+	// not, fetch requests are recorded in the database.
+	FetchAuthorizer func(r *http.Request) (user string, err error)
+	// PageViewRecorder adds counts of views of package pages to the
+	// database, as postgres.DB.AddPageViews does. Views are counted in
+	// memory and recorded every minute. It may be nil, in which case views
+	// are not counted.
+	PageViewRecorder func(ctx context.Context, pvs []*postgres.PageView) error
+	// SearchQueryRecorder adds counts of searches to the database, as
+	// postgres.DB.AddSearchQueries does. Searches are counted in memory and
+	// recorded every minute. It may be nil, in which case searches are not
+	// counted.
+	SearchQueryRecorder func(ctx context.Context, sqs []*postgres.SearchQuery) error
 }
 
 // NewServer creates a new Server for the given database and template directory.
@@ -103,6 +186,14 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 		reportingClient:      scfg.ReportingClient,
 		fileMux:              http.NewServeMux(),
 		vulnClient:           scfg.VulndbClient,
+		faultInjector:        scfg.FaultInjector,
+		failover:             scfg.Failover,
+		moduleZipGetter:      scfg.ModuleZipGetter,
+		namespaceVerifier:    scfg.NamespaceVerifier,
+		renderDiagram:        scfg.DiagramRenderer,
+		imageProxy:           scfg.ImageProxy,
+		fetchAuthorizer:      scfg.FetchAuthorizer,
+		changesSem:           make(chan struct{}, maxConcurrentChanges),
 	}
 	if scfg.Config != nil {
 		s.appVersionLabel = scfg.Config.AppVersionLabel()
@@ -110,6 +201,60 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 		s.serveStats = scfg.Config.ServeStats
 		s.versionID = scfg.Config.VersionID
 		s.instanceID = scfg.Config.InstanceID
+		s.cspReportSampleRate = scfg.Config.CSP.ReportSampleRate
+		s.supportContact = scfg.Config.SupportContact
+		s.landingPage = scfg.Config.LandingPage
+		s.mirrorUpstreamURL = scfg.Config.MirrorUpstreamURL
+		s.canonicalHost = scfg.Config.CanonicalHost
+		s.versionedCanonical = scfg.Config.VersionedCanonical
+		s.vulnReportTokens = scfg.Config.VulnReportTokens
+		s.benchmarkTokens = scfg.Config.BenchmarkTokens
+		s.searchEmbedFrameAncestors = scfg.Config.SearchEmbedFrameAncestors
+	}
+	if scfg.BannerGetter != nil {
+		s.bannerPoller = poller.New("",
+			func(ctx context.Context) (interface{}, error) {
+				return scfg.BannerGetter(ctx)
+			},
+			func(err error) {
+				log.Errorf(context.Background(), "getting site banner: %v", err)
+			})
+		ctx := context.Background()
+		s.bannerPoller.Poll(ctx)
+		s.bannerPoller.Start(ctx, time.Minute)
+	}
+	if scfg.HomepageGetter != nil {
+		s.homepagePoller = poller.New([]*internal.HomepageSection(nil),
+			func(ctx context.Context) (interface{}, error) {
+				return scfg.HomepageGetter(ctx)
+			},
+			func(err error) {
+				log.Errorf(context.Background(), "getting homepage sections: %v", err)
+			})
+		ctx := context.Background()
+		s.homepagePoller.Poll(ctx)
+		s.homepagePoller.Start(ctx, time.Minute)
+	}
+	if scfg.VocabularyGetter != nil {
+		s.vocabularyPoller = poller.New(map[string]int(nil),
+			func(ctx context.Context) (interface{}, error) {
+				return scfg.VocabularyGetter(ctx, maxVocabularySize)
+			},
+			func(err error) {
+				log.Errorf(context.Background(), "getting search vocabulary: %v", err)
+			})
+		// The vocabulary is large, so don't wait for it to start serving.
+		ctx := context.Background()
+		go s.vocabularyPoller.Poll(ctx)
+		s.vocabularyPoller.Start(ctx, time.Hour)
+	}
+	if scfg.PageViewRecorder != nil {
+		s.pageViews = newPageViewCounter(scfg.PageViewRecorder)
+		s.pageViews.start(context.Background(), pageViewFlushInterval)
+	}
+	if scfg.SearchQueryRecorder != nil {
+		s.searchQueries = newSearchQueryCounter(scfg.SearchQueryRecorder)
+		s.searchQueries.start(context.Background(), searchQueryFlushInterval)
 	}
 	errorPageBytes, err := s.renderErrorPage(context.Background(), http.StatusInternalServerError, "error", nil)
 	if err != nil {
@@ -124,14 +269,58 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 // cache.
 func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.Client, authValues []string) {
 	var (
-		detailHandler http.Handler = s.errorHandler(s.serveDetails)
-		fetchHandler  http.Handler = s.errorHandler(s.serveFetch)
-		searchHandler http.Handler = s.errorHandler(s.serveSearch)
+		detailHandler   http.Handler = s.errorHandler(s.serveDetails)
+		fetchHandler    http.Handler = s.errorHandler(s.serveFetch)
+		searchHandler   http.Handler = s.errorHandler(s.serveSearch)
+		completeHandler http.Handler = s.apiErrorHandler(s.serveCompleteAPI)
+		hoverHandler    http.Handler = s.apiErrorHandler(s.serveHoverAPI)
+		changesHandler  http.Handler = s.errorHandler(s.serveChanges)
 	)
 	if redisClient != nil {
-		detailHandler = middleware.Cache("details", redisClient, detailsTTL, authValues)(detailHandler)
-		searchHandler = middleware.Cache("search", redisClient, searchTTL, authValues)(searchHandler)
+		detailHandler = middleware.Cache("details", redisClient, detailsTTL, detailsStaleWindow, authValues)(detailHandler)
+		completeHandler = middleware.Cache("complete", redisClient, middleware.TTL(completionMaxAge), nil, authValues)(completeHandler)
+		hoverHandler = middleware.Cache("hover", redisClient, middleware.TTL(hoverMaxAge), nil, authValues)(hoverHandler)
+		changesHandler = middleware.Cache("changes", redisClient, middleware.TTL(changesTTL), nil, authValues)(changesHandler)
+		cachedSearchHandler := middleware.Cache("search", redisClient, searchTTL, nil, authValues)(searchHandler)
+		uncachedSearchHandler := searchHandler
+		searchHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Interleaved results pages have their own impression ID, and
+			// must not be served to requests outside the experiment.
+			if shouldInterleave(r) {
+				uncachedSearchHandler.ServeHTTP(w, r)
+				return
+			}
+			cachedSearchHandler.ServeHTTP(w, r)
+		})
+	}
+	if s.searchQueries != nil {
+		var c *icache.Cache
+		if redisClient != nil {
+			c = icache.New(redisClient)
+		}
+		searchHandler = s.countSearches(searchHandler, c, searchTTL)
+	}
+	// Archives, raw files and code indexes are never cached, because the
+	// cache does not store the response headers.
+	pageHandler := detailHandler
+	if s.pageViews != nil {
+		pageHandler = s.countPageViews(pageHandler)
 	}
+	archiveHandler := s.errorHandler(s.serveArchive)
+	rawHandler := s.errorHandler(s.serveRaw)
+	codeIndexHandler := s.errorHandler(s.serveCodeIndex)
+	detailHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, archiveSuffix):
+			archiveHandler.ServeHTTP(w, r)
+		case strings.Contains(r.URL.Path, rawInfix):
+			rawHandler.ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, codeIndexSuffix):
+			codeIndexHandler.ServeHTTP(w, r)
+		default:
+			pageHandler.ServeHTTP(w, r)
+		}
+	})
 	// Each AppEngine instance is created in response to a start request, which
 	// is an empty HTTP GET request to /_ah/start when scaling is set to manual
 	// or basic, and /_ah/warmup when scaling is automatic and min_instances is
@@ -157,10 +346,28 @@ func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.C
 	handle("/play/fmt", http.HandlerFunc(s.handleFmt))
 	handle("/play/share", http.HandlerFunc(s.proxyPlayground))
 	handle("/search", searchHandler)
+	handle(SearchClickPath, s.errorHandler(s.serveSearchClick))
 	handle("/search-help", s.staticPageHandler("search-help", "Search Help"))
+	handle(searchEmbedPath, s.errorHandler(s.serveSearchEmbed))
 	handle("/license-policy", s.licensePolicyHandler())
 	handle("/about", s.aboutHandler())
+	handle("/stats", s.errorHandler(s.serveCorpusStats))
+	handle("/changes/", changesHandler)
+	handle("/depends", s.errorHandler(s.serveDepends))
+	if s.failover != nil {
+		handle("/_status", s.failover.Handler())
+	}
+	handle("/new", s.serveModuleFeed(newModulesFeed, false))
+	handle("/new.atom", s.serveModuleFeed(newModulesFeed, true))
+	handle("/trending", s.serveModuleFeed(trendingModulesFeed, false))
+	handle("/trending.atom", s.serveModuleFeed(trendingModulesFeed, true))
+	handle(vulnFeedPathPrefix, s.errorHandler(s.serveVulnFeed))
+	handle(versionFeedPathPrefix, s.errorHandler(s.serveVersionFeed))
 	handle("/badge/", http.HandlerFunc(s.badgeHandler))
+	if s.imageProxy != nil {
+		// The proxy caches images itself, with their content types.
+		handle(imageproxy.PathPrefix, s.imageProxy)
+	}
 	handle("/styleguide", http.HandlerFunc(s.errorHandler(s.serveStyleGuide)))
 	handle("/C", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Package "C" is a special case: redirect to /cmd/cgo.
@@ -171,6 +378,26 @@ func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.C
 	handle("/files/", http.StripPrefix("/files", s.fileMux))
 	handle("/vuln", http.HandlerFunc(s.handleVulnRedirect))
 	handle("/vuln/", http.StripPrefix("/vuln", s.errorHandler(s.serveVuln)))
+	handle(middleware.CSPReportPath, s.errorHandler(s.serveCSPReport))
+	handle(apiCompatAPIPath, s.apiErrorHandler(s.serveAPICompatAPI))
+	handle("/api/v1/complete", withMaxAge(completionMaxAge, completeHandler))
+	handle("/api/v1/depends", s.apiErrorHandler(s.serveDependsAPI))
+	handle("/api/v1/details", s.apiErrorHandler(s.serveDetailsAPI))
+	handle("/api/v1/doc", s.apiErrorHandler(s.serveDocAPI))
+	handle("/api/v1/hover", withMaxAge(hoverMaxAge, hoverHandler))
+	handle("/api/v1/impact", s.apiErrorHandler(s.serveImpactAPI))
+	handle("/api/v1/keys", s.apiErrorHandler(s.serveAPIKeysAPI))
+	handle("/api/v1/licenses", s.apiErrorHandler(s.serveLicensesAPI))
+	handle("/api/v1/licenses/export", s.apiErrorHandler(s.serveLicenseExportAPI))
+	handle("/api/v1/list", s.apiErrorHandler(s.servePackageListAPI))
+	handle("/api/v1/module", s.apiErrorHandler(s.serveModuleAPI))
+	handle("/api/v1/namespaces/claim", s.apiErrorHandler(s.serveNamespaceClaimAPI))
+	handle("/api/v1/namespaces/verify", s.apiErrorHandler(s.serveNamespaceVerifyAPI))
+	handle("/api/v1/package", s.apiErrorHandler(s.servePackageAPI))
+	handle(symbolsAPIPath, s.apiErrorHandler(s.serveSymbolsAPI))
+	handle("/api/v1/vulns", s.apiErrorHandler(s.serveVulnsAPI))
+	handle("/api/v1/vulnreports", s.apiErrorHandler(s.serveVulnReportAPI))
+	handle("/api/v1/benchmarks", s.apiErrorHandler(s.serveBenchmarkAPI))
 	handle("/", detailHandler)
 	if s.serveStats {
 		handle("/detail-stats/",
@@ -183,6 +410,12 @@ func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.C
 		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(`User-agent: *
 Disallow: /search?*
 Disallow: /fetch/*
+Disallow: /changes/*
+Disallow: /depends?*
+Disallow: /*/-/archive.zip
+Disallow: /*/-/raw/*
+Disallow: /*/-/index.lsif
+Disallow: /ipx/*
 Sitemap: https://pkg.go.dev/sitemap/index.xml
 `))
 	}))
@@ -212,6 +445,10 @@ func (s *Server) installDebugHandlers(handle func(string, http.Handler)) {
 	handle("/_debug/pprof/symbol", ifDebug(hpprof.Symbol))
 	handle("/_debug/pprof/trace", ifDebug(hpprof.Trace))
 
+	if s.faultInjector != nil {
+		handle("/_debug/fault", ifDebug(s.faultInjector.Handler().ServeHTTP))
+	}
+
 	handle("/_debug/info", ifDebug(func(w http.ResponseWriter, r *http.Request) {
 		row := func(a, b string) {
 			fmt.Fprintf(w, "<tr><td>%s</td> <td>%s</td></tr>\n", a, b)
@@ -282,6 +519,9 @@ const (
 	symbolSearchTTL = 24 * time.Hour
 	// slowSymbolSearchTTL is for symbol searches that are known to be slow.
 	slowSymbolSearchTTL = 14 * 24 * time.Hour
+	// latestStaleWindow is how long a latest-version page may be served
+	// after its TTL, while it is refreshed in the background.
+	latestStaleWindow = 1 * time.Hour
 )
 
 var crawlers = []string{
@@ -313,12 +553,26 @@ func detailsTTLForPath(ctx context.Context, urlPath, tab string) time.Duration {
 	if info.requestedVersion == version.Latest {
 		return shortTTL
 	}
-	if tab == "importedby" || tab == "versions" {
+	if tab == "importedby" || tab == "versions" || tab == "security" {
 		return defaultTTL
 	}
 	return longTTL
 }
 
+// detailsStaleWindow returns the time a details page may be served stale. Only
+// latest-version pages, whose TTL is short because a newer version may be
+// processed at any time, are served stale.
+func detailsStaleWindow(r *http.Request) time.Duration {
+	if r.URL.Path == "/" {
+		return 0
+	}
+	info, err := parseDetailsURLPath(r.URL.Path)
+	if err != nil || info.requestedVersion != version.Latest {
+		return 0
+	}
+	return latestStaleWindow
+}
+
 var slowSymbolSearches = map[string]bool{
 	"new":                            true,
 	"version":                        true,
@@ -354,6 +608,40 @@ func searchTTL(r *http.Request) time.Duration {
 	return symbolSearchTTL
 }
 
+// withMaxAge returns a handler that lets clients and shared caches reuse the
+// successful responses of h for maxAge. The header is set here rather than
+// in h because the response cache does not store headers.
+func withMaxAge(maxAge time.Duration, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&maxAgeWriter{ResponseWriter: w, maxAge: maxAge}, r)
+	})
+}
+
+// maxAgeWriter sets a Cache-Control header on a response if its status is
+// 200 OK.
+type maxAgeWriter struct {
+	http.ResponseWriter
+	maxAge      time.Duration
+	wroteHeader bool
+}
+
+func (w *maxAgeWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status == http.StatusOK {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(w.maxAge.Seconds())))
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *maxAgeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 // TagRoute categorizes incoming requests to the frontend for use in
 // monitoring.
 func TagRoute(route string, r *http.Request) string {
@@ -419,6 +707,10 @@ type basePage struct {
 	// SearchModeSymbol is the value of const searchModeSymbol. It is used in
 	// the search bar dropdown.
 	SearchModeSymbol string
+
+	// Banner is a message for all users, such as an announcement of
+	// maintenance, shown at the top of the page.
+	Banner string
 }
 
 // licensePolicyPage is used to generate the static license policy page.
@@ -462,7 +754,58 @@ func (s *Server) newBasePage(r *http.Request, title string) basePage {
 		// indicates that we should use heuristics to determine whether the
 		// user wants to search for symbols or packages.
 		SearchMode: "",
+		Banner:     s.siteBanner(),
+	}
+}
+
+// readOnlyBanner is the site-wide banner shown in read-only mode.
+const readOnlyBanner = "pkg.go.dev is in read-only mode because of a database outage. " +
+	"Pages may be out of date, and new modules cannot be fetched."
+
+// readOnly reports whether the site is in read-only mode, because its
+// primary database is down.
+func (s *Server) readOnly() bool {
+	return s.failover.Degraded()
+}
+
+// canFetch reports whether the user who made r may fetch modules.
+func (s *Server) canFetch(r *http.Request) bool {
+	if s.fetchAuthorizer == nil {
+		return true
 	}
+	_, err := s.authorizeFetch(r)
+	return err == nil
+}
+
+// authorizeFetch returns the user who made r if they may fetch modules.
+// Requests made with an API key that has the fetch scope are authorized as
+// the key.
+func (s *Server) authorizeFetch(r *http.Request) (user string, err error) {
+	if k := apikey.FromContext(r.Context()); k != nil && k.HasScope(apikey.ScopeFetch) {
+		return "apikey:" + k.Name, nil
+	}
+	return s.fetchAuthorizer(r)
+}
+
+// siteBanner returns the message of the site-wide banner, or the empty string
+// if there is none. In read-only mode, it says so instead.
+func (s *Server) siteBanner() string {
+	if s.readOnly() {
+		return readOnlyBanner
+	}
+	if s.bannerPoller == nil {
+		return ""
+	}
+	return s.bannerPoller.Current().(string)
+}
+
+// searchVocabulary returns the search vocabulary, or nil if it is not
+// available.
+func (s *Server) searchVocabulary() map[string]int {
+	if s.vocabularyPoller == nil {
+		return nil
+	}
+	return s.vocabularyPoller.Current().(map[string]int)
 }
 
 // errorPage contains fields for rendering a HTTP error page.
@@ -471,6 +814,14 @@ type errorPage struct {
 	templateName    string
 	messageTemplate template.TrustedTemplate
 	MessageData     interface{}
+
+	// RequestID identifies the request in the logs, for support requests.
+	RequestID string
+
+	// SupportContact is the operator contact for help, and SupportURL is a
+	// link to it. They are empty if no contact is configured.
+	SupportContact string
+	SupportURL     string
 }
 
 // PanicHandler returns an http.HandlerFunc that can be used in HTTP
@@ -574,6 +925,8 @@ func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status i
 			basePage: s.newBasePage(r, ""),
 		}
 	}
+	page.RequestID, _, _ = strings.Cut(log.TraceID(r.Context()), "/")
+	page.SupportContact, page.SupportURL = s.supportContact, supportURL(s.supportContact)
 	buf, err := s.renderErrorPage(r.Context(), status, template, page)
 	if err != nil {
 		log.Errorf(r.Context(), "s.renderErrorPage(w, %d, %v): %v", status, page, err)
@@ -587,6 +940,18 @@ func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status i
 	}
 }
 
+// supportURL returns a link to contact, which is either an email address or a
+// URL.
+func supportURL(contact string) string {
+	if contact == "" {
+		return ""
+	}
+	if strings.Contains(contact, "@") && !strings.Contains(contact, "://") {
+		return "mailto:" + contact
+	}
+	return contact
+}
+
 // renderErrorPage executes error.tmpl with the given errorPage
 func (s *Server) renderErrorPage(ctx context.Context, status int, templateName string, page *errorPage) ([]byte, error) {
 	statusInfo := fmt.Sprintf("%d %s", status, http.StatusText(status))
@@ -712,18 +1077,32 @@ func parsePageTemplates(fsys template.TrustedFS) (map[string]*template.Template,
 	htmlSets := [][]string{
 		{"about"},
 		{"badge"},
+		{"changes"},
+		{"depends"},
 		{"error"},
+		{"feeds"},
 		{"fetch"},
 		{"homepage"},
 		{"license-policy"},
+		{"prefix"},
+		{"redirect"},
 		{"search"},
+		{"search-embed"},
 		{"search-help"},
+		{"stats"},
 		{"styleguide"},
 		{"subrepo"},
+		{"unit/analyses", "unit"},
+		{"unit/apidiff", "unit"},
+		{"unit/depgraph", "unit"},
+		{"unit/examples", "unit"},
+		{"unit/files", "unit"},
+		{"unit/guides", "unit"},
 		{"unit/importedby", "unit"},
 		{"unit/imports", "unit"},
 		{"unit/licenses", "unit"},
 		{"unit/main", "unit"},
+		{"unit/security", "unit"},
 		{"unit/versions", "unit"},
 		{"vuln"},
 		{"vuln/list", "vuln"},
@@ -750,6 +1129,15 @@ func parsePageTemplates(fsys template.TrustedFS) (map[string]*template.Template,
 	return templates, nil
 }
 
+// markdownOptions returns the options for rendering READMEs and guides.
+func (s *Server) markdownOptions() markdownOptions {
+	opts := markdownOptions{renderDiagram: s.renderDiagram}
+	if s.imageProxy != nil {
+		opts.proxyImage = s.imageProxy.URL
+	}
+	return opts
+}
+
 func (s *Server) staticHandler() http.Handler {
 	// In dev mode compile TypeScript files into minified JavaScript files
 	// and rebuild them on file changes.
@@ -10,7 +10,6 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -675,6 +674,12 @@ func serverTestCases() []serverTestCase {
 			urlPath:        "/github.com/golang/tools/go/packages",
 			wantStatusCode: http.StatusNotFound,
 		},
+		{
+			name:           "case-encoded package path",
+			urlPath:        "/github.com/!valid/module_name@v1.0.0/foo",
+			wantStatusCode: http.StatusMovedPermanently,
+			wantLocation:   "/github.com/Valid/module_name@v1.0.0/foo",
+		},
 		{
 			name:           "static",
 			urlPath:        "/static/",
@@ -887,6 +892,26 @@ func serverTestCases() []serverTestCase {
 					in("li:nth-child(1) a", href("/fmt"), hasText("fmt")),
 					in("li:nth-child(2) a", href("/path/to/bar"), hasText("path/to/bar")))),
 		},
+		{
+			name:           "package at version dependency graph tab",
+			urlPath:        fmt.Sprintf("/%s@%s/%s?tab=depgraph", sample.ModulePath, sample.VersionString, sample.Suffix),
+			wantStatusCode: http.StatusOK,
+			want: in(".DepGraph-list",
+				in("li:nth-child(1) a", href("/fmt"), hasText("fmt")),
+				in("li:nth-child(2) a", href("/path/to/bar"), hasText("path/to/bar"))),
+		},
+		{
+			name:           "package at version API diff tab",
+			urlPath:        fmt.Sprintf("/%s@%s/%s?tab=apidiff", sample.ModulePath, sample.VersionString, sample.Suffix),
+			wantStatusCode: http.StatusOK,
+			want:           in(`[data-test-id="gopher-message"]`, hasText(`no earlier release`)),
+		},
+		{
+			name:           "package at version examples tab",
+			urlPath:        fmt.Sprintf("/%s@%s/%s?tab=examples", sample.ModulePath, sample.VersionString, sample.Suffix),
+			wantStatusCode: http.StatusOK,
+			want:           in(`[data-test-id="Examples-empty"]`, hasText(`no examples`)),
+		},
 		{
 			name:           "package at version imported by tab",
 			urlPath:        fmt.Sprintf("/%s@%s/%s?tab=importedby", sample.ModulePath, sample.VersionString, sample.Suffix),
@@ -1438,6 +1463,73 @@ func TestServer404Redirect(t *testing.T) {
 	}
 }
 
+func TestServer404CaseRedirect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	defer postgres.ResetTestDB(testDB, t)
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("github.com/valid/module_name", "v1.0.0", "foo"))
+	// The mis-cased paths were fetched, and not found.
+	for _, vm := range []struct{ path, version string }{
+		{"github.com/Valid/Module_Name/Foo", version.Latest},
+		{"github.com/Valid/module_name/foo", "v1.0.0"},
+		{"github.com/Valid/module_name/foo", "v1.1.0"},
+	} {
+		if err := testDB.UpsertVersionMap(ctx, &internal.VersionMap{
+			ModulePath:       vm.path,
+			RequestedVersion: vm.version,
+			ResolvedVersion:  vm.version,
+			Status:           http.StatusNotFound,
+			GoModPath:        vm.path,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, handler, _ := newTestServer(t, nil, nil)
+	for _, test := range []struct {
+		name, path   string
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name:         "wrong case",
+			path:         "/github.com/Valid/Module_Name/Foo?tab=imports",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/github.com/valid/module_name/foo?tab=imports",
+		},
+		{
+			name:         "wrong case at version",
+			path:         "/github.com/Valid/module_name@v1.0.0/foo",
+			wantStatus:   http.StatusFound,
+			wantLocation: "/github.com/valid/module_name@v1.0.0/foo",
+		},
+		{
+			// The indexed path does not have the version.
+			name:       "wrong case at missing version",
+			path:       "/github.com/Valid/module_name@v1.1.0/foo",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			// The path was never fetched, so fetching it is offered.
+			name:       "wrong case not fetched",
+			path:       "/github.com/VALID/module_name/foo",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+			if w.Code != test.wantStatus {
+				t.Errorf("%q: got status code = %d, want %d", test.path, w.Code, test.wantStatus)
+			}
+			if got := w.Result().Header.Get("Location"); got != test.wantLocation {
+				t.Errorf("%q: got location %q, want %q", test.path, got, test.wantLocation)
+			}
+		})
+	}
+}
+
 func findCookie(name string, cookies []*http.Cookie) *http.Cookie {
 	for _, c := range cookies {
 		if c.Name == name {
@@ -1492,6 +1584,36 @@ func TestDetailsTTL(t *testing.T) {
 	}
 }
 
+func TestDetailsStaleWindow(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		want time.Duration
+	}{
+		{"/", 0},
+		{"/host.com/module/suffix", latestStaleWindow},
+		{"/host.com/module@v1.2.3/suffix", 0},
+	} {
+		if got := detailsStaleWindow(mustRequest(test.path, t)); got != test.want {
+			t.Errorf("detailsStaleWindow(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestSupportURL(t *testing.T) {
+	for _, test := range []struct {
+		contact, want string
+	}{
+		{"", ""},
+		{"help@example.com", "mailto:help@example.com"},
+		{"https://example.com/support", "https://example.com/support"},
+		{"https://example.com/u@x", "https://example.com/u@x"},
+	} {
+		if got := supportURL(test.contact); got != test.want {
+			t.Errorf("supportURL(%q) = %q, want %q", test.contact, got, test.want)
+		}
+	}
+}
+
 func TestTagRoute(t *testing.T) {
 	mustRequest := func(url string) *http.Request {
 		req, err := http.NewRequest("GET", url, nil)
@@ -1573,25 +1695,47 @@ func TestCheckTemplates(t *testing.T) {
 		typeval interface{}
 	}{
 		{"badge", nil, badgePage{}},
+		{"changes", nil, ChangesPage{}},
+		{"depends", nil, DependsPage{}},
 		// error.tmpl omitted because relies on an associated "message" template
 		// that's parsed on demand; see renderErrorPage above.
+		{"feeds", nil, ModuleFeedPage{}},
 		{"fetch", nil, errorPage{}},
 		{"homepage", nil, homepage{}},
 		{"license-policy", nil, licensePolicyPage{}},
+		{"redirect", nil, ModuleRedirectPage{}},
 		{"search", nil, SearchPage{}},
+		{"search-embed", []string{"search-embed"}, SearchEmbedPage{}},
 		{"search-help", nil, basePage{}},
+		{"stats", nil, StatsPage{}},
+		{"stats", []string{"stats-table"}, StatsTable{}},
 		{"unit/main", nil, UnitPage{}},
 		{
 			"unit/main",
 			[]string{"unit-outline", "unit-readme", "unit-doc", "unit-files", "unit-directories"},
 			MainDetails{},
 		},
+		{"unit/main", []string{"unit-migration"}, Migration{}},
+		{"unit/depgraph", nil, UnitPage{}},
+		{"unit/depgraph", []string{"depgraph"}, ImportGraphDetails{}},
+		{"unit/depgraph", []string{"depgraph-node"}, postgres.ImportGraphNode{}},
+		{"unit/apidiff", nil, UnitPage{}},
+		{"unit/apidiff", []string{"apidiff"}, APIDiffDetails{}},
+		{"unit/examples", nil, UnitPage{}},
+		{"unit/examples", []string{"examples"}, ExamplesDetails{}},
+		{"unit/files", nil, UnitPage{}},
+		{"unit/files", []string{"files"}, FilesDetails{}},
+		{"unit/guides", nil, UnitPage{}},
+		{"unit/guides", []string{"guides"}, GuidesDetails{}},
 		{"unit/importedby", nil, UnitPage{}},
 		{"unit/importedby", []string{"importedby"}, ImportedByDetails{}},
 		{"unit/imports", nil, UnitPage{}},
 		{"unit/imports", []string{"imports"}, ImportsDetails{}},
 		{"unit/licenses", nil, UnitPage{}},
 		{"unit/licenses", []string{"licenses"}, LicensesDetails{}},
+		{"unit/security", nil, UnitPage{}},
+		{"unit/security", []string{"security"}, SecurityDetails{}},
+		{"unit/security", []string{"security-finding"}, postgres.VulnFinding{}},
 		{"unit/versions", nil, UnitPage{}},
 		{"unit/versions", []string{"versions"}, VersionsDetails{}},
 		{"vuln", nil, basePage{}},
@@ -1622,7 +1766,7 @@ func TestCheckTemplates(t *testing.T) {
 	}
 }
 
-func TestEmptyDirectoryBetweenNestedModulesRedirect(t *testing.T) {
+func TestEmptyDirectoryBetweenNestedModules(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
 	defer postgres.ResetTestDB(testDB, t)
@@ -1641,14 +1785,16 @@ func TestEmptyDirectoryBetweenNestedModulesRedirect(t *testing.T) {
 	}
 
 	_, handler, _ := newTestServer(t, nil, nil)
+	nestedModule := sample.ModulePath + "/missing/dir/c"
 	for _, test := range []struct {
-		name, path   string
-		wantStatus   int
-		wantLocation string
+		name, path string
+		wantStatus int
+		want       htmlcheck.Checker
 	}{
-		{"want 404 for unknown version of module", sample.ModulePath + "@v0.5.0", http.StatusNotFound, ""},
-		{"want 404 for never fetched directory", notInsertedPath, http.StatusNotFound, ""},
-		{"want 302 for previously fetched directory", missingPath, http.StatusFound, "/search?q=" + url.PathEscape(missingPath)},
+		{"want 404 for unknown version of module", sample.ModulePath + "@v0.5.0", http.StatusNotFound, nil},
+		{"want 404 for never fetched directory", notInsertedPath, http.StatusNotFound, nil},
+		{"want nested modules for previously fetched directory", missingPath, http.StatusNotFound,
+			in(`[data-test-id="nested-modules"] a`, href("/"+nestedModule), hasText(nestedModule))},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
@@ -1656,8 +1802,15 @@ func TestEmptyDirectoryBetweenNestedModulesRedirect(t *testing.T) {
 			if w.Code != test.wantStatus {
 				t.Errorf("%q: got status code = %d, want %d", "/"+test.path, w.Code, test.wantStatus)
 			}
-			if got := w.Header().Get("Location"); got != test.wantLocation {
-				t.Errorf("got location = %q, want %q", got, test.wantLocation)
+			if test.want == nil {
+				return
+			}
+			doc, err := html.Parse(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := test.want(doc); err != nil {
+				t.Error(err)
 			}
 		})
 	}
@@ -1693,3 +1846,24 @@ func TestInstallFS(t *testing.T) {
 		t.Errorf("body does not contain %q", want)
 	}
 }
+
+func TestWithMaxAge(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		h := withMaxAge(time.Hour, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				http.Error(w, "nope", status)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		want := ""
+		if status == http.StatusOK {
+			want = "public, max-age=3600"
+		}
+		if got := w.Header().Get("Cache-Control"); got != want {
+			t.Errorf("status %d: got Cache-Control %q, want %q", status, got, want)
+		}
+	}
+}
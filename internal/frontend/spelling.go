@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"strings"
+)
+
+const (
+	// maxVocabularySize is the maximum number of terms of the search
+	// vocabulary that are loaded to correct queries. The terms that appear
+	// in the most packages are loaded.
+	maxVocabularySize = 500_000
+
+	// minCorrectionPackages is the minimum number of packages that a term
+	// must appear in to be used as a correction.
+	minCorrectionPackages = 10
+
+	// correctionConfidenceRatio is how many more packages the best
+	// correction of a term must appear in than the next best one, for it to
+	// replace the term in the query instead of only being suggested.
+	correctionConfidenceRatio = 4
+
+	// noCorrectParam is the query param that disables spelling correction.
+	// It is also used in the search template.
+	noCorrectParam = "nocorrect"
+)
+
+// vocabularyAlphabet is the set of characters that can appear in terms of
+// the search vocabulary, other than at the start.
+const vocabularyAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789_-"
+
+// correctSpelling returns a correction of the single-term search query q
+// using vocab, which maps terms to the number of packages they appear in, and
+// whether the correction is confident enough to search for it instead of q.
+// It returns the empty string if q is not a single term, is in the
+// vocabulary, or has no correction.
+//
+// Corrections are the terms of the vocabulary within an edit distance of one
+// of q, preferring those that appear in more packages.
+func correctSpelling(vocab map[string]int, q string) (correction string, confident bool) {
+	term := strings.ToLower(q)
+	if len(term) < 4 || strings.ContainsAny(term, " /.") || vocab[term] > 0 {
+		return "", false
+	}
+	var best, second int
+	for _, c := range edits(term) {
+		n := vocab[c]
+		if n < minCorrectionPackages || c == correction {
+			continue
+		}
+		switch {
+		case n > best:
+			second = best
+			best, correction = n, c
+		case n > second:
+			second = n
+		}
+	}
+	if correction == "" {
+		return "", false
+	}
+	return correction, best >= correctionConfidenceRatio*second
+}
+
+// edits returns the strings at an edit distance of one of term: term with
+// one character deleted, replaced or inserted, or two adjacent characters
+// swapped. The result may contain duplicates.
+func edits(term string) []string {
+	var es []string
+	for i := 0; i <= len(term); i++ {
+		head, tail := term[:i], term[i:]
+		if len(tail) > 0 {
+			es = append(es, head+tail[1:])
+		}
+		if len(tail) > 1 {
+			es = append(es, head+tail[1:2]+tail[:1]+tail[2:])
+		}
+		for _, c := range vocabularyAlphabet {
+			if len(tail) > 0 && tail[0] != byte(c) {
+				es = append(es, head+string(c)+tail[1:])
+			}
+			es = append(es, head+string(c)+tail)
+		}
+	}
+	return es
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import "testing"
+
+func TestCorrectSpelling(t *testing.T) {
+	vocab := map[string]int{
+		"kubernetes": 5000,
+		"yaml":       800,
+		"protobuf":   1200,
+		"logrus":     300,
+		"logs":       200,
+		"logr":       90,
+		"rare":       3,
+	}
+	for _, test := range []struct {
+		q             string
+		want          string
+		wantConfident bool
+	}{
+		{"kubernetse", "kubernetes", true}, // transposition
+		{"kubrnetes", "kubernetes", true},  // deletion
+		{"protobuff", "protobuf", true},    // insertion
+		{"Protpbuf", "protobuf", true},     // substitution, case
+		{"yaml", "", false},                // in the vocabulary
+		{"rase", "", false},                // correction too rare
+		{"logz", "logs", false},            // ambiguous: logs or logr
+		{"kubernetes client", "", false},   // not a single term
+		{"gihub.com/foo", "", false},       // a path
+		{"xml", "", false},                 // too short
+		{"qwertyuiop", "", false},          // no correction
+	} {
+		got, gotConfident := correctSpelling(vocab, test.q)
+		if got != test.want || gotConfident != test.wantConfident {
+			t.Errorf("correctSpelling(%q) = %q, %t; want %q, %t", test.q, got, gotConfident, test.want, test.wantConfident)
+		}
+	}
+	if got, _ := correctSpelling(nil, "kubernetse"); got != "" {
+		t.Errorf("correctSpelling with no vocabulary = %q, want empty", got)
+	}
+}
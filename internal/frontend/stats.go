@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"net/http"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/text/message"
+)
+
+// numStatsDays is the number of daily rollups shown on the stats page.
+const numStatsDays = 30
+
+// StatsPage contains the data for the corpus statistics page.
+type StatsPage struct {
+	basePage
+
+	// Date is the date of the most recent rollup, or the empty string if
+	// no rollup has been computed yet.
+	Date string
+
+	NumModules  string
+	NumVersions string
+	NumPackages string
+
+	// VersionsProcessed has a row for each of the recent days, most recent
+	// first.
+	VersionsProcessed *StatsTable
+	MostImported      *StatsTable
+	Licenses          *StatsTable
+}
+
+// A StatsTable is a table of counts on the stats page.
+type StatsTable struct {
+	Rows []*StatsRow
+	// Max is the largest count in the table.
+	Max int
+}
+
+// A StatsRow is a row in a StatsTable.
+type StatsRow struct {
+	Label string
+	// URL is the link for Label, if any.
+	URL          string
+	Count        int
+	DisplayCount string
+}
+
+func (t *StatsTable) add(pr *message.Printer, label, url string, count int) {
+	t.Rows = append(t.Rows, &StatsRow{
+		Label:        label,
+		URL:          url,
+		Count:        count,
+		DisplayCount: pr.Sprint(count),
+	})
+	if count > t.Max {
+		t.Max = count
+	}
+}
+
+// serveCorpusStats serves the page of corpus-wide statistics. The statistics
+// are read from the rollups computed nightly by the worker, so the page only
+// supports the postgres data source.
+func (s *Server) serveCorpusStats(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return datasourceNotSupportedErr()
+	}
+	ctx := r.Context()
+	stats, err := db.GetCorpusStats(ctx, numStatsDays)
+	if err != nil {
+		return err
+	}
+	page := newStatsPage(message.NewPrinter(middleware.LanguageTag(ctx)), stats)
+	page.basePage = s.newBasePage(r, "Ecosystem Statistics")
+	s.servePage(ctx, w, "stats", page)
+	return nil
+}
+
+// newStatsPage returns the stats page for stats, the rollups for recent days,
+// most recent first.
+func newStatsPage(pr *message.Printer, stats []*postgres.CorpusStats) *StatsPage {
+	page := &StatsPage{
+		VersionsProcessed: &StatsTable{},
+		MostImported:      &StatsTable{},
+		Licenses:          &StatsTable{},
+	}
+	if len(stats) == 0 {
+		return page
+	}
+	latest := stats[0]
+	page.Date = latest.Date.Format("Jan 2, 2006")
+	page.NumModules = pr.Sprint(latest.NumModules)
+	page.NumVersions = pr.Sprint(latest.NumVersions)
+	page.NumPackages = pr.Sprint(latest.NumPackages)
+	for _, s := range stats {
+		page.VersionsProcessed.add(pr, s.Date.Format("Jan 2, 2006"), "", s.VersionsProcessed)
+	}
+	for _, ic := range latest.MostImported {
+		page.MostImported.add(pr, ic.Path, "/"+ic.Path, ic.NumImportedBy)
+	}
+	for _, lc := range latest.LicenseCounts {
+		page.Licenses.add(pr, lc.Type, "", lc.NumModules)
+	}
+	return page
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestNewStatsPage(t *testing.T) {
+	day := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	stats := []*postgres.CorpusStats{
+		{
+			Date:              day,
+			NumModules:        1200,
+			NumVersions:       34000,
+			NumPackages:       5600,
+			VersionsProcessed: 10,
+			MostImported: []*postgres.ImportedByCount{
+				{Path: "github.com/a/b", NumImportedBy: 2500},
+				{Path: "github.com/c/d", NumImportedBy: 7},
+			},
+			LicenseCounts: []*postgres.LicenseCount{{Type: "MIT", NumModules: 900}},
+		},
+		{Date: day.AddDate(0, 0, -1), VersionsProcessed: 1500},
+	}
+	got := newStatsPage(message.NewPrinter(language.English), stats)
+	want := &StatsPage{
+		Date:        "Mar 2, 2022",
+		NumModules:  "1,200",
+		NumVersions: "34,000",
+		NumPackages: "5,600",
+		VersionsProcessed: &StatsTable{
+			Rows: []*StatsRow{
+				{Label: "Mar 2, 2022", Count: 10, DisplayCount: "10"},
+				{Label: "Mar 1, 2022", Count: 1500, DisplayCount: "1,500"},
+			},
+			Max: 1500,
+		},
+		MostImported: &StatsTable{
+			Rows: []*StatsRow{
+				{Label: "github.com/a/b", URL: "/github.com/a/b", Count: 2500, DisplayCount: "2,500"},
+				{Label: "github.com/c/d", URL: "/github.com/c/d", Count: 7, DisplayCount: "7"},
+			},
+			Max: 2500,
+		},
+		Licenses: &StatsTable{
+			Rows: []*StatsRow{{Label: "MIT", Count: 900, DisplayCount: "900"}},
+			Max:  900,
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(StatsPage{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got = newStatsPage(message.NewPrinter(language.English), nil)
+	if got.Date != "" || len(got.VersionsProcessed.Rows) != 0 {
+		t.Errorf("got %+v for no stats, want empty page", got)
+	}
+}
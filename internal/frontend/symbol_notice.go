@@ -0,0 +1,144 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// A SymbolNotice explains why a symbol that appears in the history of a
+// package is not in the documentation being viewed. The anchor ID of a
+// symbol is its name, so deep links to it from other versions of the package
+// can be resolved using the notice.
+type SymbolNotice struct {
+	// ID is the anchor ID of the missing symbol.
+	ID string
+
+	// NewID is the anchor ID of the symbol that replaced the missing one on
+	// this page, if any.
+	NewID string
+
+	// URL is the URL of the documentation that contains the symbol, if any.
+	URL string
+
+	// Message describes what happened to the symbol.
+	Message string
+}
+
+// symbolNotices returns notices for the symbols that were in some version of
+// the package but are not in its documentation for doc's build context. It
+// only supports the postgres data source, and returns nil for others.
+func symbolNotices(ctx context.Context, ds internal.DataSource, unit *internal.Unit, doc *internal.Documentation) (_ []*SymbolNotice, err error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok || doc == nil || len(unit.SymbolHistory) == 0 {
+		return nil, nil
+	}
+	sms, err := db.GetDocumentationSymbols(ctx, unit.Path, unit.ModulePath, unit.Version, doc.BuildContext())
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]*internal.SymbolMeta{}
+	for _, sm := range sms {
+		current[sm.Name] = sm
+	}
+	var removed bool
+	for name, since := range unit.SymbolHistory {
+		if current[name] == nil && semver.Compare(since, unit.Version) <= 0 {
+			removed = true
+			break
+		}
+	}
+	// Only read the full history, which is needed to detect renames, if
+	// some symbol was removed.
+	var sh *internal.SymbolHistory
+	if removed {
+		sh, err = db.GetSymbolHistory(ctx, unit.Path, unit.ModulePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return computeSymbolNotices(unit, current, sh), nil
+}
+
+// computeSymbolNotices returns the notices for the symbols in
+// unit.SymbolHistory that are not in current, sorted by ID. sh is used to
+// detect renamed symbols; it may be nil.
+func computeSymbolNotices(unit *internal.Unit, current map[string]*internal.SymbolMeta, sh *internal.SymbolHistory) []*SymbolNotice {
+	var notices []*SymbolNotice
+	for name, since := range unit.SymbolHistory {
+		if current[name] != nil {
+			continue
+		}
+		n := &SymbolNotice{ID: name}
+		if semver.Compare(since, unit.Version) > 0 {
+			n.URL = constructUnitURL(unit.Path, unit.ModulePath, since) + "#" + name
+			n.Message = fmt.Sprintf("%s was added in %s.", name, displayVersion(unit.ModulePath, since, since))
+		} else if newName := renamedTo(name, since, unit.SymbolHistory, current, sh); newName != "" {
+			n.NewID = newName
+			newSince := unit.SymbolHistory[newName]
+			n.Message = fmt.Sprintf("%s was renamed to %s in %s.", name, newName, displayVersion(unit.ModulePath, newSince, newSince))
+		} else {
+			n.Message = fmt.Sprintf("%s was removed from this package before %s.", name, displayVersion(unit.ModulePath, unit.Version, unit.Version))
+		}
+		notices = append(notices, n)
+	}
+	sort.Slice(notices, func(i, j int) bool { return notices[i].ID < notices[j].ID })
+	return notices
+}
+
+// renamedTo returns the name of the symbol in current that the removed
+// symbol name, introduced at version since, was renamed to, or the empty
+// string if there is none. A symbol is considered to be a rename of name if
+// it was introduced later, has the same kind and parent, and its synopsis is
+// the same as the synopsis of name, with the name replaced.
+func renamedTo(name, since string, nameToVersion map[string]string, current map[string]*internal.SymbolMeta, sh *internal.SymbolHistory) string {
+	if sh == nil {
+		return ""
+	}
+	var old *internal.SymbolMeta
+	for sm := range sh.SymbolsAtVersion(since)[name] {
+		sm := sm
+		old = &sm
+		break
+	}
+	if old == nil {
+		return ""
+	}
+	var candidates []string
+	for newName, sm := range current {
+		if sm.Kind != old.Kind || sm.ParentName != old.ParentName ||
+			semver.Compare(nameToVersion[newName], since) <= 0 {
+			continue
+		}
+		if replaceIdent(old.Synopsis, shortName(name), shortName(newName)) == sm.Synopsis {
+			candidates = append(candidates, newName)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return candidates[0]
+}
+
+// shortName returns the name of a symbol without its parent, so that the
+// short name of the method "T.M" is "M".
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// replaceIdent replaces the occurrences of the identifier old in s with new.
+func replaceIdent(s, old, new string) string {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(old) + `\b`)
+	return re.ReplaceAllLiteralString(s, new)
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestComputeSymbolNotices(t *testing.T) {
+	fn := func(name, synopsis string) internal.SymbolMeta {
+		return internal.SymbolMeta{
+			Name:     name,
+			Synopsis: synopsis,
+			Section:  internal.SymbolSectionFunctions,
+			Kind:     internal.SymbolKindFunction,
+		}
+	}
+	method := func(name, parent, synopsis string) internal.SymbolMeta {
+		return internal.SymbolMeta{
+			Name:       name,
+			ParentName: parent,
+			Synopsis:   synopsis,
+			Section:    internal.SymbolSectionTypes,
+			Kind:       internal.SymbolKindMethod,
+		}
+	}
+	sh := internal.NewSymbolHistory()
+	for _, s := range []struct {
+		sm internal.SymbolMeta
+		v  string
+	}{
+		{fn("Old", "func Old(x int) error"), "v1.0.0"},
+		{fn("Gone", "func Gone()"), "v1.0.0"},
+		{method("T.Get", "T", "func (t *T) Get() T"), "v1.0.0"},
+		{fn("New", "func New(x int) error"), "v1.5.0"},
+		{method("T.Fetch", "T", "func (t *T) Fetch() T"), "v1.5.0"},
+		{fn("Future", "func Future()"), "v2.0.0"},
+	} {
+		sh.AddSymbol(s.sm, s.v, internal.BuildContextAll)
+	}
+	newFn := fn("New", "func New(x int) error")
+	fetch := method("T.Fetch", "T", "func (t *T) Fetch() T")
+	current := map[string]*internal.SymbolMeta{
+		"New":     &newFn,
+		"T.Fetch": &fetch,
+	}
+	unit := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path: "example.com/m/p",
+			ModuleInfo: internal.ModuleInfo{
+				ModulePath: "example.com/m",
+				Version:    "v1.6.0",
+			},
+		},
+		SymbolHistory: map[string]string{
+			"Old":     "v1.0.0",
+			"Gone":    "v1.0.0",
+			"T.Get":   "v1.0.0",
+			"New":     "v1.5.0",
+			"T.Fetch": "v1.5.0",
+			"Future":  "v2.0.0",
+		},
+	}
+	got := computeSymbolNotices(unit, current, sh)
+	want := []*SymbolNotice{
+		{
+			ID:      "Future",
+			URL:     "/example.com/m@v2.0.0/p#Future",
+			Message: "Future was added in v2.0.0.",
+		},
+		{
+			ID:      "Gone",
+			Message: "Gone was removed from this package before v1.6.0.",
+		},
+		{
+			ID:      "Old",
+			NewID:   "New",
+			Message: "Old was renamed to New in v1.5.0.",
+		},
+		{
+			ID:      "T.Get",
+			NewID:   "T.Fetch",
+			Message: "T.Get was renamed to T.Fetch in v1.5.0.",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without the full history, renames cannot be detected.
+	got = computeSymbolNotices(unit, current, nil)
+	if got[2].NewID != "" || got[2].Message != "Old was removed from this package before v1.6.0." {
+		t.Errorf("without history: got %+v", got[2])
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// symbolsAPIPath is the path prefix of the /api/v1/symbols endpoint.
+const symbolsAPIPath = "/api/v1/symbols/"
+
+// ModuleSymbolsResponse is the JSON response of the /api/v1/symbols
+// endpoint.
+type ModuleSymbolsResponse struct {
+	ModulePath string            `json:"modulePath"`
+	Version    string            `json:"version"`
+	Symbols    []*SymbolResponse `json:"symbols"`
+}
+
+// SymbolResponse is an exported symbol of a package, such as a function, a
+// type or a method written as "Type.Method".
+type SymbolResponse struct {
+	PackagePath string `json:"packagePath"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	// Parent is the type that a method, field or associated function or
+	// value belongs to.
+	Parent    string `json:"parent,omitempty"`
+	Signature string `json:"signature"`
+	// Synopsis is the first sentence of the doc comment of the symbol, in
+	// the default build context of its package.
+	Synopsis string `json:"synopsis,omitempty"`
+	// BuildContexts are the GOOS/GOARCH pairs, such as "linux/amd64", for
+	// which the package has the symbol with this signature, or "all/all"
+	// if it is the same for all of them.
+	BuildContexts []string `json:"buildContexts"`
+}
+
+// serveSymbolsAPI handles requests for
+// /api/v1/symbols/<module>[@<version>][?format=ndjson].
+// It returns the exported symbols of all packages of the module at the given
+// version, or the latest version if none is provided, so that tools can
+// track the API of modules over time. With format=ndjson, the response has
+// one SymbolResponse per line instead of a single JSON object.
+func (s *Server) serveSymbolsAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	modulePath, requestedVersion, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, symbolsAPIPath), "/"), "@")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module path"}
+	}
+	if requestedVersion == "" {
+		requestedVersion = version.Latest
+	}
+	if !isSupportedVersion(modulePath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "ndjson" {
+		return &serverError{status: http.StatusBadRequest, responseText: fmt.Sprintf("unknown format %q", format)}
+	}
+	ctx := r.Context()
+	if err := checkExcluded(ctx, ds, modulePath); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, modulePath, modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	mss, err := db.GetModuleSymbols(ctx, um.ModulePath, um.Version)
+	if err != nil {
+		return err
+	}
+	symbols, err := symbolResponses(ctx, ds, um, mss)
+	if err != nil {
+		return err
+	}
+	if format == "ndjson" {
+		return serveNDJSON(w, r, symbols)
+	}
+	return serveJSON(w, r, &ModuleSymbolsResponse{
+		ModulePath: um.ModulePath,
+		Version:    um.Version,
+		Symbols:    symbols,
+	})
+}
+
+// symbolResponses converts the symbols of the module of um to
+// SymbolResponses, adding the synopses of their doc comments.
+func symbolResponses(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, mss []*postgres.ModuleSymbol) ([]*SymbolResponse, error) {
+	symbols := newSymbolResponses(mss)
+	byPackage := map[string][]*SymbolResponse{}
+	var pkgPaths []string
+	for _, sr := range symbols {
+		if _, ok := byPackage[sr.PackagePath]; !ok {
+			pkgPaths = append(pkgPaths, sr.PackagePath)
+		}
+		byPackage[sr.PackagePath] = append(byPackage[sr.PackagePath], sr)
+	}
+	for _, p := range pkgPaths {
+		if err := addSymbolSynopses(ctx, ds, um, p, byPackage[p]); err != nil {
+			return nil, err
+		}
+	}
+	return symbols, nil
+}
+
+// newSymbolResponses converts mss to SymbolResponses, without synopses.
+func newSymbolResponses(mss []*postgres.ModuleSymbol) []*SymbolResponse {
+	symbols := []*SymbolResponse{}
+	for _, ms := range mss {
+		sr := &SymbolResponse{
+			PackagePath: ms.PackagePath,
+			Name:        ms.Name,
+			Kind:        strings.ToLower(string(ms.Kind)),
+			Signature:   ms.Synopsis,
+		}
+		if ms.ParentName != ms.Name {
+			sr.Parent = ms.ParentName
+		}
+		for _, bc := range ms.BuildContexts {
+			sr.BuildContexts = append(sr.BuildContexts, bc.GOOS+"/"+bc.GOARCH)
+		}
+		symbols = append(symbols, sr)
+	}
+	return symbols
+}
+
+// addSymbolSynopses sets the synopses of the given symbols of the package
+// at pkgPath in the module of um, from its documentation for the default
+// build context.
+func addSymbolSynopses(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta, pkgPath string, symbols []*SymbolResponse) error {
+	pum := *um
+	pum.Path = pkgPath
+	unit, err := ds.GetUnit(ctx, &pum, internal.WithMain, internal.BuildContext{})
+	if err != nil {
+		return err
+	}
+	unit.Documentation = cleanDocumentation(unit.Documentation)
+	if len(unit.Documentation) == 0 {
+		return nil
+	}
+	var names []string
+	for _, sr := range symbols {
+		names = append(names, sr.Name)
+	}
+	summaries, err := godoc.SymbolSummariesFromUnit(ctx, unit, names)
+	if err != nil {
+		return err
+	}
+	for _, sr := range symbols {
+		if s := summaries[sr.Name]; s != nil {
+			sr.Synopsis = s.Synopsis
+		}
+	}
+	return nil
+}
+
+// serveNDJSON writes each element of vs to w as a line of JSON.
+func serveNDJSON[T any](w http.ResponseWriter, r *http.Request, vs []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, v := range vs {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf(r.Context(), "serveNDJSON: %v", err)
+	}
+	return nil
+}
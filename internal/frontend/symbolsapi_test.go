@@ -0,0 +1,133 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSymbolsAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	const src = `
+		// Package a is a package.
+		package a
+
+		// Function does nothing. It returns nil.
+		func Function() error { return nil }
+	`
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		m := sample.Module(sample.ModulePath, v, "a")
+		doc := sample.Documentation(internal.All, internal.All, src)
+		doc.API = []*internal.Symbol{sample.Function}
+		if v == "v1.1.0" {
+			doc.API = append(doc.API, sample.Variable)
+		}
+		m.Packages()[0].Documentation = []*internal.Documentation{doc}
+		postgres.MustInsertModule(ctx, t, testDB, m)
+	}
+	_, handler, _ := newTestServer(t, nil, nil)
+
+	pkgPath := sample.ModulePath + "/a"
+	function := &SymbolResponse{
+		PackagePath:   pkgPath,
+		Name:          "Function",
+		Kind:          "function",
+		Signature:     "func Function() error",
+		Synopsis:      "Function does nothing.",
+		BuildContexts: []string{"all/all"},
+	}
+	variable := &SymbolResponse{
+		PackagePath:   pkgPath,
+		Name:          "Variable",
+		Kind:          "variable",
+		Signature:     "var Variable",
+		BuildContexts: []string{"all/all"},
+	}
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+		want       *ModuleSymbolsResponse
+	}{
+		{
+			path:       sample.ModulePath + "@v1.0.0",
+			wantStatus: http.StatusOK,
+			want: &ModuleSymbolsResponse{
+				ModulePath: sample.ModulePath,
+				Version:    "v1.0.0",
+				Symbols:    []*SymbolResponse{function},
+			},
+		},
+		{
+			path:       sample.ModulePath,
+			wantStatus: http.StatusOK,
+			want: &ModuleSymbolsResponse{
+				ModulePath: sample.ModulePath,
+				Version:    "v1.1.0",
+				Symbols:    []*SymbolResponse{function, variable},
+			},
+		},
+		{
+			path:       sample.ModulePath + "@v9.9.9",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			path:       sample.ModulePath + "@v1.0.0?format=xml",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			path:       "",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", symbolsAPIPath+test.path, nil))
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantStatus)
+			continue
+		}
+		if test.want == nil {
+			continue
+		}
+		got := &ModuleSymbolsResponse{}
+		if err := json.NewDecoder(w.Body).Decode(got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+		}
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", symbolsAPIPath+sample.ModulePath+"@v1.1.0?format=ndjson", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		var got []*SymbolResponse
+		for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+			sr := &SymbolResponse{}
+			if err := json.Unmarshal([]byte(line), sr); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, sr)
+		}
+		if diff := cmp.Diff([]*SymbolResponse{function, variable}, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
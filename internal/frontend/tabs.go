@@ -39,6 +39,13 @@ const (
 	tabImports    = "imports"
 	tabImportedBy = "importedby"
 	tabLicenses   = "licenses"
+	tabSecurity   = "security"
+	tabGuides     = "guides"
+	tabAnalyses   = "analyses"
+	tabDepGraph   = "depgraph"
+	tabAPIDiff    = "apidiff"
+	tabExamples   = "examples"
+	tabFiles      = "files"
 )
 
 var (
@@ -63,6 +70,34 @@ var (
 			Name:         tabLicenses,
 			TemplateName: "unit/licenses",
 		},
+		{
+			Name:         tabSecurity,
+			TemplateName: "unit/security",
+		},
+		{
+			Name:         tabGuides,
+			TemplateName: "unit/guides",
+		},
+		{
+			Name:         tabAnalyses,
+			TemplateName: "unit/analyses",
+		},
+		{
+			Name:         tabDepGraph,
+			TemplateName: "unit/depgraph",
+		},
+		{
+			Name:         tabAPIDiff,
+			TemplateName: "unit/apidiff",
+		},
+		{
+			Name:         tabExamples,
+			TemplateName: "unit/examples",
+		},
+		{
+			Name:         tabFiles,
+			TemplateName: "unit/files",
+		},
 	}
 	unitTabLookup = make(map[string]TabSettings, len(unitTabs))
 )
@@ -76,21 +111,36 @@ func init() {
 // fetchDetailsForPackage returns tab details by delegating to the correct detail
 // handler.
 func fetchDetailsForUnit(ctx context.Context, r *http.Request, tab string, ds internal.DataSource, um *internal.UnitMeta,
-	requestedVersion string, bc internal.BuildContext,
-	getVulnEntries vulnEntriesFunc) (_ interface{}, err error) {
+	requestedVersion, compareVersion string, bc internal.BuildContext,
+	getVulnEntries vulnEntriesFunc, getModuleZip moduleZipFunc, mdOpts markdownOptions) (_ interface{}, err error) {
 	defer derrors.Wrap(&err, "fetchDetailsForUnit(r, %q, ds, um=%q,%q,%q)", tab, um.Path, um.ModulePath, um.Version)
 	switch tab {
 	case tabMain:
 		_, expandReadme := r.URL.Query()["readme"]
-		return fetchMainDetails(ctx, ds, um, requestedVersion, expandReadme, bc)
+		_, showInternal := r.URL.Query()[showInternalParam]
+		return fetchMainDetails(ctx, ds, um, requestedVersion, expandReadme, showInternal, bc, getVulnEntries, mdOpts)
 	case tabVersions:
 		return fetchVersionsDetails(ctx, ds, um, getVulnEntries)
 	case tabImports:
 		return fetchImportsDetails(ctx, ds, um.Path, um.ModulePath, um.Version)
 	case tabImportedBy:
-		return fetchImportedByDetails(ctx, ds, um.Path, um.ModulePath)
+		return fetchImportedByDetails(ctx, ds, um.Path, um.ModulePath, newPaginationParams(r, defaultImportedByLimit))
 	case tabLicenses:
 		return fetchLicensesDetails(ctx, ds, um)
+	case tabSecurity:
+		return fetchSecurityDetails(ctx, ds, um)
+	case tabGuides:
+		return fetchGuidesDetails(ctx, r, ds, um, requestedVersion, mdOpts)
+	case tabAnalyses:
+		return fetchAnalysesDetails(ctx, ds, um)
+	case tabDepGraph:
+		return fetchImportGraphDetails(ctx, ds, um)
+	case tabAPIDiff:
+		return fetchAPIDiffDetails(ctx, ds, um, compareVersion)
+	case tabExamples:
+		return fetchExamplesDetails(ctx, ds, um)
+	case tabFiles:
+		return fetchFilesDetails(ctx, r, getModuleZip, um, requestedVersion)
 	}
 	return nil, fmt.Errorf("BUG: unable to fetch details: unknown tab %q", tab)
 }
@@ -19,6 +19,7 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
 )
@@ -46,6 +47,13 @@ type UnitPage struct {
 	// the canonical URL path for that unit would be /my.module@v1.5.2/pkg
 	CanonicalURLPath string
 
+	// CanonicalURL is the URL of the rel=canonical link of the page. If
+	// empty, the page has no such link.
+	CanonicalURL string
+
+	// NoIndex reports whether search engines should not index the page.
+	NoIndex bool
+
 	// The version string formatted for display.
 	DisplayVersion string
 
@@ -60,10 +68,21 @@ type UnitPage struct {
 	// version in relationship to the latest version of the unit.
 	LatestMinorClass string
 
+	// RecommendedVersion is the version of the module that its owners
+	// recommend instead of the latest version, formatted for display, and
+	// RecommendedURL is the URL of the unit at that version. They are empty
+	// if the owners don't recommend a version, or the page is for it.
+	RecommendedVersion string
+	RecommendedURL     string
+
 	// Information about the latest major version of the module.
 	LatestMajorVersion    string
 	LatestMajorVersionURL string
 
+	// MigrationURL is the URL of the section of the page that explains how
+	// to upgrade to the latest major version, if there is one.
+	MigrationURL string
+
 	// PageType is the type of page (pkg, cmd, dir, std, or mod).
 	PageType string
 
@@ -91,6 +110,26 @@ type UnitPage struct {
 
 	// DepsDevURL holds the full URL to this module version on deps.dev.
 	DepsDevURL string
+
+	// VerifiedNamespace is the longest prefix of the module path whose owner
+	// has verified control of it, or empty if there is none. If it is set,
+	// the page shows a verified publisher badge.
+	VerifiedNamespace string
+
+	// GuidesURL is the URL of the Guides tab, or empty if the module has no
+	// guides.
+	GuidesURL string
+
+	// AnalysesURL is the URL of the Analyses tab, or empty if no analyses
+	// have been run on the module version.
+	AnalysesURL string
+
+	// MirrorUpstreamURL is the URL of the page on the site that this one
+	// mirrors, or empty if it is not a mirror. MirrorIndexTime is when the
+	// module version was processed by the mirror, or empty if it is not
+	// known.
+	MirrorUpstreamURL string
+	MirrorIndexTime   string
 }
 
 // serveUnitPage serves a unit page for a path.
@@ -101,8 +140,15 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 
 	tab := r.FormValue("tab")
 	if tab == "" {
-		// Default to details tab when there is no tab param.
+		// Default to details tab when there is no tab param, or to the API
+		// diff tab if the URL compares two versions.
 		tab = tabMain
+		if info.compareVersion != "" {
+			tab = tabAPIDiff
+		}
+	}
+	if info.compareVersion != "" && tab != tabAPIDiff {
+		return &serverError{status: http.StatusBadRequest}
 	}
 	// Redirect to clean URL path when tab param is invalid.
 	if _, ok := unitTabLookup[tab]; !ok {
@@ -110,6 +156,10 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 		return nil
 	}
 
+	if served, err := s.serveModuleRedirectPage(w, r, ds, info); served || err != nil {
+		return err
+	}
+
 	um, err := ds.GetUnitMeta(ctx, info.fullPath, info.modulePath, info.requestedVersion)
 	if err != nil {
 		if !errors.Is(err, derrors.NotFound) {
@@ -130,7 +180,11 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	if s.vulnClient != nil {
 		getVulnEntries = s.vulnClient.GetByModule
 	}
-	d, err := fetchDetailsForUnit(ctx, r, tab, ds, um, info.requestedVersion, bc, getVulnEntries)
+	var getModuleZip moduleZipFunc
+	if s.moduleZipGetter != nil {
+		getModuleZip = s.getModuleZip
+	}
+	d, err := fetchDetailsForUnit(ctx, r, tab, ds, um, info.requestedVersion, info.compareVersion, bc, getVulnEntries, getModuleZip, s.markdownOptions())
 	if err != nil {
 		return err
 	}
@@ -139,7 +193,7 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	}
 
 	recordVersionTypeMetric(ctx, info.requestedVersion)
-	if _, ok := internal.DefaultBranches[info.requestedVersion]; ok {
+	if _, ok := internal.DefaultBranches[info.requestedVersion]; ok && !s.readOnly() && s.canFetch(r) {
 		// Since path@master is a moving target, we don't want it to be stale.
 		// As a result, we enqueue every request of path@master to the frontend
 		// task queue, which will initiate a fetch request depending on the
@@ -205,6 +259,12 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 		DepsDevURL:            makeDepsDevURL(),
 	}
 
+	page.CanonicalURL, page.NoIndex = s.canonicalLink(um, info.requestedVersion)
+	if rv := um.RecommendedVersion; rv != "" && rv != um.Version {
+		page.RecommendedVersion = displayVersion(um.ModulePath, rv, rv)
+		page.RecommendedURL = constructUnitURL(um.Path, um.ModulePath, rv)
+	}
+
 	// Show the banner if there was no error getting the latest major version,
 	// and it is different from the major version of the current module path.
 	latestMajor := internal.MajorVersionForModule(latestInfo.MajorModulePath)
@@ -216,6 +276,66 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	main, ok := d.(*MainDetails)
 	if ok {
 		page.MetaDescription = metaDescription(main.DocSynopsis)
+		if page.LatestMajorVersion != "" {
+			// The Migration section is not essential, so don't fail the page
+			// if it can't be computed.
+			main.Migration, err = fetchMigration(ctx, ds, um, latestInfo)
+			if err != nil {
+				log.Errorf(ctx, "serveUnitPage(%q): %v", um.Path, err)
+			}
+			if main.Migration != nil {
+				page.MigrationURL = "#section-migration"
+			}
+		}
+	}
+
+	// Verified namespaces are only stored by the postgres data source. The
+	// badge is not essential, so don't fail the page if it can't be found.
+	if db, ok := ds.(*postgres.DB); ok {
+		page.VerifiedNamespace, err = db.GetVerifiedNamespace(ctx, um.ModulePath)
+		if err != nil {
+			log.Errorf(ctx, "serveUnitPage(%q): %v", um.Path, err)
+		}
+	}
+
+	// On a mirror, link to the upstream page and say when the module version
+	// was processed, so that readers know that the page may be out of date.
+	if s.mirrorUpstreamURL != "" {
+		page.MirrorUpstreamURL = s.mirrorUpstreamURL + constructUnitURL(um.Path, um.ModulePath, lv)
+		if db, ok := ds.(*postgres.DB); ok {
+			mvs, err := db.GetModuleVersionState(ctx, um.ModulePath, um.Version)
+			switch {
+			case errors.Is(err, derrors.NotFound):
+				// The module version was not fetched by the worker, so when
+				// it was processed is unknown.
+			case err != nil:
+				log.Errorf(ctx, "serveUnitPage(%q): %v", um.Path, err)
+			case mvs.LastProcessedAt != nil:
+				page.MirrorIndexTime = mvs.LastProcessedAt.In(time.UTC).Format("Jan _2, 2006 15:04 UTC")
+			}
+		}
+	}
+
+	// Guides are not essential either.
+	if g, ok := ds.(guidesGetter); ok {
+		paths, err := g.GetModuleGuidePaths(ctx, um.ModulePath, um.Version)
+		if err != nil {
+			log.Errorf(ctx, "serveUnitPage(%q): %v", um.Path, err)
+		}
+		if len(paths) > 0 {
+			page.GuidesURL = page.URLPath + "?tab=" + tabGuides
+		}
+	}
+
+	// So are analyses.
+	if g, ok := ds.(analysisResultsGetter); ok {
+		results, err := g.GetAnalysisResults(ctx, um.ModulePath, um.Version)
+		if err != nil {
+			log.Errorf(ctx, "serveUnitPage(%q): %v", um.Path, err)
+		}
+		if len(analysesDetails(results, um).Analyzers) > 0 {
+			page.AnalysesURL = page.URLPath + "?tab=" + tabAnalyses
+		}
 	}
 
 	// Get vulnerability information.
@@ -226,6 +346,57 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	return nil
 }
 
+// serveRecommendedRedirect redirects a request for a unit at the
+// "recommended" version to the page of the unit at the version that the owners
+// of its module recommend, or at the latest version if they don't recommend
+// one.
+func (s *Server) serveRecommendedRedirect(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	ds internal.DataSource, info *urlPathInfo) (err error) {
+	defer derrors.Wrap(&err, "serveRecommendedRedirect(ctx, w, r, ds, %v)", info)
+
+	um, err := ds.GetUnitMeta(ctx, info.fullPath, info.modulePath, version.Latest)
+	if err != nil {
+		if !errors.Is(err, derrors.NotFound) {
+			return err
+		}
+		return s.servePathNotFoundPage(w, r, ds, info.fullPath, info.modulePath, version.Latest)
+	}
+	v := version.Latest
+	if um.RecommendedVersion != "" {
+		v = um.RecommendedVersion
+	}
+	u := *r.URL
+	u.Path = constructUnitURL(um.Path, um.ModulePath, v)
+	http.Redirect(w, r, u.String(), http.StatusFound)
+	return nil
+}
+
+// defaultCanonicalHost is the host of rel=canonical links if the server
+// has no canonical host configured.
+const defaultCanonicalHost = "pkg.go.dev"
+
+// canonicalLink returns the URL of the rel=canonical link for the page of um
+// at requestedVersion, and whether the page should not be indexed.
+//
+// Pages for a pseudo-version are not indexed. They have no canonical link,
+// because pointing one at another page would contradict the noindex.
+// Otherwise, the link is the unversioned URL of the unit, unless the server
+// is configured to use the versioned URL for pages of a specific version.
+func (s *Server) canonicalLink(um *internal.UnitMeta, requestedVersion string) (url string, noIndex bool) {
+	if requestedVersion != version.Latest && version.IsPseudo(um.Version) {
+		return "", true
+	}
+	host := s.canonicalHost
+	if host == "" {
+		host = defaultCanonicalHost
+	}
+	p := "/" + um.Path
+	if s.versionedCanonical {
+		p = constructUnitURL(um.Path, um.ModulePath, requestedVersion)
+	}
+	return "https://" + host + p, false
+}
+
 func latestMinorClass(version string, latest internal.LatestInfo) string {
 	c := "DetailsHeader-badge"
 	switch {
@@ -258,10 +429,10 @@ func metaDescription(synopsis string) safehtml.HTML {
 // isValidTabForUnit reports whether the tab is valid for the given unit.
 // It is assumed that tab is a key in unitTabLookup.
 func isValidTabForUnit(tab string, um *internal.UnitMeta) bool {
-	if tab == tabLicenses && !um.IsRedistributable {
+	if (tab == tabLicenses || tab == tabFiles) && !um.IsRedistributable {
 		return false
 	}
-	if !um.IsPackage() && (tab == tabImports || tab == tabImportedBy) {
+	if !um.IsPackage() && (tab == tabImports || tab == tabImportedBy || tab == tabDepGraph || tab == tabAPIDiff || tab == tabExamples) {
 		return false
 	}
 	return true
@@ -5,10 +5,21 @@
 package frontend
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/fakedatasource"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/static"
+	thirdparty "golang.org/x/pkgsite/third_party"
 )
 
 func TestUnitURLPath(t *testing.T) {
@@ -69,6 +80,45 @@ func TestCanonicalURLPath(t *testing.T) {
 	}
 }
 
+func TestCanonicalLink(t *testing.T) {
+	const pseudo = "v0.0.0-20200101000000-000000000000"
+	for _, test := range []struct {
+		name             string
+		host             string
+		versioned        bool
+		path, modulePath string
+		requested        string
+		resolved         string
+		wantURL          string
+		wantNoIndex      bool
+	}{
+		{"latest", "", false, "m.com/p", "m.com", "latest", "v1.2.3", "https://pkg.go.dev/m.com/p", false},
+		{"versioned", "", false, "m.com/p", "m.com", "v1.2.3", "v1.2.3", "https://pkg.go.dev/m.com/p", false},
+		{"versioned host", "example.com", false, "m.com/p", "m.com", "v1.2.3", "v1.2.3", "https://example.com/m.com/p", false},
+		{"versioned canonical", "", true, "m.com/p", "m.com", "v1.2.3", "v1.2.3", "https://pkg.go.dev/m.com@v1.2.3/p", false},
+		{"versioned canonical latest", "", true, "m.com/p", "m.com", "latest", "v1.2.3", "https://pkg.go.dev/m.com/p", false},
+		{"std", "", true, "math", "std", "go1.2.3", "v1.2.3", "https://pkg.go.dev/math@go1.2.3", false},
+		{"latest pseudo", "", false, "m.com/p", "m.com", "latest", pseudo, "https://pkg.go.dev/m.com/p", false},
+		{"pseudo", "", false, "m.com/p", "m.com", pseudo, pseudo, "", true},
+		{"branch", "", true, "m.com/p", "m.com", "master", pseudo, "", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{canonicalHost: test.host, versionedCanonical: test.versioned}
+			um := &internal.UnitMeta{
+				Path: test.path,
+				ModuleInfo: internal.ModuleInfo{
+					ModulePath: test.modulePath,
+					Version:    test.resolved,
+				},
+			}
+			gotURL, gotNoIndex := s.canonicalLink(um, test.requested)
+			if gotURL != test.wantURL || gotNoIndex != test.wantNoIndex {
+				t.Errorf("got (%q, %t), want (%q, %t)", gotURL, gotNoIndex, test.wantURL, test.wantNoIndex)
+			}
+		})
+	}
+}
+
 func TestIsValidTab(t *testing.T) {
 	testTabs := []string{
 		tabMain,
@@ -76,6 +126,8 @@ func TestIsValidTab(t *testing.T) {
 		tabImports,
 		tabImportedBy,
 		tabLicenses,
+		tabSecurity,
+		tabGuides,
 	}
 	for _, test := range []struct {
 		name     string
@@ -85,27 +137,27 @@ func TestIsValidTab(t *testing.T) {
 		{
 			name:     "module",
 			um:       sample.UnitMeta(sample.ModulePath, sample.ModulePath, sample.VersionString, "", true),
-			wantTabs: []string{tabMain, tabVersions, tabLicenses},
+			wantTabs: []string{tabMain, tabVersions, tabLicenses, tabSecurity, tabGuides},
 		},
 		{
 			name:     "directory",
 			um:       sample.UnitMeta(sample.ModulePath+"/go", sample.ModulePath, sample.VersionString, "", true),
-			wantTabs: []string{tabMain, tabVersions, tabLicenses},
+			wantTabs: []string{tabMain, tabVersions, tabLicenses, tabSecurity, tabGuides},
 		},
 		{
 			name:     "package",
 			um:       sample.UnitMeta(sample.ModulePath+"/go/packages", sample.ModulePath, sample.VersionString, "packages", true),
-			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy, tabLicenses},
+			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy, tabLicenses, tabSecurity, tabGuides},
 		},
 		{
 			name:     "command",
 			um:       sample.UnitMeta(sample.ModulePath+"/cmd", sample.ModulePath, sample.VersionString, "main", true),
-			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy, tabLicenses},
+			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy, tabLicenses, tabSecurity, tabGuides},
 		},
 		{
 			name:     "non-redist pkg",
 			um:       sample.UnitMeta(sample.ModulePath+"/go/packages", sample.ModulePath, sample.VersionString, "packages", false),
-			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy},
+			wantTabs: []string{tabMain, tabVersions, tabImports, tabImportedBy, tabSecurity, tabGuides},
 		},
 	} {
 		validTabs := map[string]bool{}
@@ -147,3 +199,128 @@ func TestMetaDescription(t *testing.T) {
 		}
 	}
 }
+
+func TestServeUnitPageFakeDataSource(t *testing.T) {
+	ds := fakedatasource.New()
+	ds.InsertModule(fakedatasource.NewModule("example.com/m", "v1.0.0").
+		Package("p", `
+			// Package p does things.
+			package p
+
+			// Hello says hello.
+			func Hello() string { return "hello" }
+		`).Module())
+	s, err := NewServer(ServerConfig{
+		DataSourceGetter: func(context.Context) internal.DataSource { return ds },
+		TemplateFS:       template.TrustedFSFromEmbed(static.FS),
+		StaticFS:         static.FS,
+		ThirdPartyFS:     thirdparty.FS,
+		StaticPath:       "../../static",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil, nil)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/example.com/m/p", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	body, err := io.ReadAll(w.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Package p does things.", "func Hello"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
+
+func TestServeUnitPageMirror(t *testing.T) {
+	ds := fakedatasource.New()
+	ds.InsertModule(fakedatasource.NewModule("example.com/m", "v1.0.0").
+		Package("p", "package p").Module())
+	s, err := NewServer(ServerConfig{
+		Config:           &config.Config{MirrorUpstreamURL: "https://pkg.go.dev"},
+		DataSourceGetter: func(context.Context) internal.DataSource { return ds },
+		TemplateFS:       template.TrustedFSFromEmbed(static.FS),
+		StaticFS:         static.FS,
+		ThirdPartyFS:     thirdparty.FS,
+		StaticPath:       "../../static",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil, nil)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/example.com/m/p", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`data-test-id="UnitHeader-mirrorBanner"`,
+		`href="https://pkg.go.dev/example.com/m@v1.0.0/p"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
+
+func TestServeUnitPageMirrorProcessedTime(t *testing.T) {
+	ctx := context.Background()
+	defer postgres.ResetTestDB(testDB, t)
+
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("example.com/fetched", "v1.0.0", "p"))
+	postgres.MustInsertModule(ctx, t, testDB, sample.Module("example.com/inserted", "v1.0.0", "p"))
+	if err := testDB.InsertIndexVersions(ctx, []*internal.IndexVersion{{Path: "example.com/fetched", Version: "v1.0.0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpdateModuleVersionState(ctx, &postgres.ModuleVersionStateForUpdate{
+		ModulePath: "example.com/fetched",
+		Version:    "v1.0.0",
+		Status:     http.StatusOK,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(ServerConfig{
+		Config:           &config.Config{MirrorUpstreamURL: "https://pkg.go.dev"},
+		DataSourceGetter: func(context.Context) internal.DataSource { return testDB },
+		TemplateFS:       template.TrustedFSFromEmbed(static.FS),
+		StaticFS:         static.FS,
+		ThirdPartyFS:     thirdparty.FS,
+		StaticPath:       "../../static",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil, nil)
+
+	for _, test := range []struct {
+		url  string
+		want bool
+	}{
+		// The page of the latest version shows when the resolved version was
+		// processed.
+		{"/example.com/fetched/p", true},
+		{"/example.com/fetched@v1.0.0/p", true},
+		// A module version that was not fetched has no processing time.
+		{"/example.com/inserted/p", false},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", test.url, w.Code, http.StatusOK)
+		}
+		if got := strings.Contains(w.Body.String(), "last processed on"); got != test.want {
+			t.Errorf("%s: processing time shown = %t, want %t", test.url, got, test.want)
+		}
+	}
+}
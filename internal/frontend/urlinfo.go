@@ -32,6 +32,11 @@ type urlPathInfo struct {
 	// of the following: "latest", "master", a Go version tag, or a semantic
 	// version.
 	requestedVersion string
+	// compareVersion is the version that the page compares with
+	// requestedVersion, if the version in the URL has the form
+	// COMPARE...REQUESTED, as in /path@v1.2.0...v1.3.0. It is only used by
+	// the API diff tab.
+	compareVersion string
 }
 
 type userError struct {
@@ -52,10 +57,50 @@ func (e *userError) Unwrap() error {
 func extractURLPathInfo(urlPath string) (_ *urlPathInfo, err error) {
 	defer derrors.Wrap(&err, "extractURLPathInfo(%q)", urlPath)
 
+	urlPath, compareVersion, compare := cutCompareVersion(urlPath)
+	var info *urlPathInfo
 	if m, _, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "@"); stdlib.Contains(m) {
-		return parseStdLibURLPath(urlPath)
+		info, err = parseStdLibURLPath(urlPath)
+		if err == nil && compare {
+			if v := stdlib.VersionForTag(compareVersion); v != "" {
+				compareVersion = v
+			}
+		}
+	} else {
+		info, err = parseDetailsURLPath(urlPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if compare {
+		if !semver.IsValid(compareVersion) {
+			return nil, &userError{
+				err:         fmt.Errorf("invalid compare version: %q", compareVersion),
+				userMessage: fmt.Sprintf("%q is not a valid version", compareVersion),
+			}
+		}
+		info.compareVersion = compareVersion
 	}
-	return parseDetailsURLPath(urlPath)
+	return info, nil
+}
+
+// cutCompareVersion removes the COMPARE... prefix from a version of the form
+// COMPARE...REQUESTED in urlPath. It returns the rest of urlPath, COMPARE,
+// and whether urlPath had such a version.
+func cutCompareVersion(urlPath string) (_, compareVersion string, found bool) {
+	before, rest, found := strings.Cut(urlPath, "@")
+	if !found {
+		return urlPath, "", false
+	}
+	v, suffix, _ := strings.Cut(rest, "/")
+	compareVersion, requested, found := strings.Cut(v, "...")
+	if !found {
+		return urlPath, "", false
+	}
+	if suffix != "" {
+		requested += "/" + suffix
+	}
+	return before + "@" + requested, compareVersion, true
 }
 
 // parseDetailsURLPath parses a URL path that refers (or may refer) to something
@@ -164,6 +209,45 @@ func parseStdLibURLPath(urlPath string) (_ *urlPathInfo, err error) {
 	return info, nil
 }
 
+// unescapeURLPath decodes the import path and version of urlPath if they use
+// the case encoding of module proxies and the module cache, in which an
+// upper-case letter is written as '!' followed by the lower-case letter, as in
+// github.com/!sirupsen/logrus. It reports whether urlPath was encoded.
+func unescapeURLPath(urlPath string) (_ string, ok bool) {
+	if !strings.Contains(urlPath, "!") {
+		return "", false
+	}
+	p, rest, hasVersion := strings.Cut(strings.TrimPrefix(urlPath, "/"), "@")
+	var v, suffix string
+	if hasVersion {
+		v, suffix, _ = strings.Cut(rest, "/")
+	}
+	fullPath := p
+	if suffix != "" {
+		fullPath += "/" + suffix
+	}
+	// Unescape the path as a whole, since the suffix alone is not a valid
+	// module path. Each "!x" decodes to a single letter, so the module part
+	// of the result is shorter by the number of '!'s in p.
+	up, err := module.UnescapePath(fullPath)
+	if err != nil {
+		return "", false
+	}
+	n := len(p) - strings.Count(p, "!")
+	u := "/" + up[:n]
+	if hasVersion {
+		uv, err := module.UnescapeVersion(v)
+		if err != nil {
+			return "", false
+		}
+		u += "@" + uv
+		if suffix != "" {
+			u += up[n:]
+		}
+	}
+	return u, true
+}
+
 // isValidPath reports whether a requested path could be a valid unit.
 func isValidPath(fullPath string) bool {
 	if err := module.CheckImportPath(fullPath); err != nil {
@@ -213,7 +297,7 @@ func isSupportedVersion(fullPath, requestedVersion string) bool {
 	if _, ok := internal.DefaultBranches[requestedVersion]; ok {
 		return !stdlib.Contains(fullPath) || requestedVersion == "master"
 	}
-	return requestedVersion == version.Latest || semver.IsValid(requestedVersion)
+	return requestedVersion == version.Latest || requestedVersion == version.Recommended || semver.IsValid(requestedVersion)
 }
 
 func setExperimentsFromQueryParam(ctx context.Context, r *http.Request) context.Context {
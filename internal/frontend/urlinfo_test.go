@@ -94,6 +94,26 @@ func TestExtractURLPathInfo(t *testing.T) {
 				requestedVersion: "v1.14.0",
 			},
 		},
+		{
+			name: "package comparing versions",
+			url:  "/github.com/hashicorp/vault@v1.0.3...v1.1.0/api",
+			want: &urlPathInfo{
+				modulePath:       "github.com/hashicorp/vault",
+				fullPath:         "github.com/hashicorp/vault/api",
+				requestedVersion: "v1.1.0",
+				compareVersion:   "v1.0.3",
+			},
+		},
+		{
+			name: "stdlib comparing versions",
+			url:  "/net/http@go1.14...go1.15",
+			want: &urlPathInfo{
+				modulePath:       stdlib.ModulePath,
+				fullPath:         "net/http",
+				requestedVersion: "v1.15.0",
+				compareVersion:   "v1.14.0",
+			},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			got, err := extractURLPathInfo(test.url)
@@ -137,6 +157,16 @@ func TestExtractURLPathInfo_Errors(t *testing.T) {
 			url:     "/net@go1.14/http",
 			wantErr: true,
 		},
+		{
+			name:    "invalid compare version",
+			url:     "/github.com/hashicorp/vault/api@master...v1.0.3",
+			wantErr: true,
+		},
+		{
+			name:    "missing compare version",
+			url:     "/github.com/hashicorp/vault/api@...v1.0.3",
+			wantErr: true,
+		},
 	}
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
@@ -207,6 +237,26 @@ func TestIsValidPath(t *testing.T) {
 	}
 }
 
+func TestUnescapeURLPath(t *testing.T) {
+	for _, test := range []struct {
+		urlPath string
+		want    string // empty if urlPath is not encoded
+	}{
+		{"/github.com/sirupsen/logrus", ""},
+		{"/github.com/!sirupsen/logrus", "/github.com/Sirupsen/logrus"},
+		{"/github.com/!sirupsen/logrus@v1.0.0", "/github.com/Sirupsen/logrus@v1.0.0"},
+		{"/github.com/!azure/go-sdk@v1.0.0/!storage/blob", "/github.com/Azure/go-sdk@v1.0.0/Storage/blob"},
+		{"/github.com/a/b@v1.0.0-!r!c1", "/github.com/a/b@v1.0.0-RC1"},
+		{"/github.com/!Sirupsen/logrus", ""},
+		{"/github.com/sirupsen/logrus!", ""},
+	} {
+		got, ok := unescapeURLPath(test.urlPath)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("unescapeURLPath(%q) = %q, %t, want %q", test.urlPath, got, ok, test.want)
+		}
+	}
+}
+
 func TestIsSupportedVersion(t *testing.T) {
 	tests := []struct {
 		path, version string
@@ -215,6 +265,7 @@ func TestIsSupportedVersion(t *testing.T) {
 		{sample.ModulePath, "v1.2.3", true},
 		{sample.ModulePath, "v1.2.bad", false},
 		{sample.ModulePath, "latest", true},
+		{sample.ModulePath, "recommended", true},
 		{sample.ModulePath, "master", true},
 		{sample.ModulePath, "main", true},
 		{"net/http", "v1.2.3", true}, // isSupportedVersion expects the goTag is already converted to semver
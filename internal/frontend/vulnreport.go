@@ -0,0 +1,232 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxVulnReportSize is the maximum size of an uploaded govulncheck report.
+const maxVulnReportSize = 10 << 20
+
+// SecurityDetails contains the data for the Security tab of a unit page,
+// which displays the govulncheck report uploaded for the module version.
+type SecurityDetails struct {
+	// HasReport reports whether a report was uploaded.
+	HasReport bool
+	// UploadedAt is the date the report was uploaded.
+	UploadedAt string
+	// Reachable are the vulnerabilities whose vulnerable symbols are called
+	// by the module, and Unreachable those that are only imported or
+	// required.
+	Reachable, Unreachable []*postgres.VulnFinding
+}
+
+// fetchSecurityDetails returns the SecurityDetails for the module version of
+// um. Reports are only stored by the postgres data source.
+func fetchSecurityDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (_ *SecurityDetails, err error) {
+	defer derrors.Wrap(&err, "fetchSecurityDetails(ctx, ds, %q, %q)", um.ModulePath, um.Version)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &SecurityDetails{}, nil
+	}
+	r, err := db.GetVulnReport(ctx, um.ModulePath, um.Version)
+	if errors.Is(err, derrors.NotFound) {
+		return &SecurityDetails{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &SecurityDetails{
+		HasReport:  true,
+		UploadedAt: absoluteTime(r.UploadedAt),
+	}
+	for _, f := range r.Findings {
+		if len(f.Symbols) > 0 {
+			d.Reachable = append(d.Reachable, f)
+		} else {
+			d.Unreachable = append(d.Unreachable, f)
+		}
+	}
+	return d, nil
+}
+
+// VulnReportUploadResponse is the JSON response of the /api/v1/vulnreports
+// endpoint.
+type VulnReportUploadResponse struct {
+	ModulePath   string `json:"modulePath"`
+	Version      string `json:"version"`
+	NumFindings  int    `json:"numFindings"`
+	NumReachable int    `json:"numReachable"`
+}
+
+// serveVulnReportAPI handles POST requests for
+// /api/v1/vulnreports?module=<path>&version=<version>.
+// The body is the output of govulncheck -json, run on the module version.
+// The report replaces any earlier one for the module version, and is
+// displayed on the Security tab of its pages. Requests must have an
+// Authorization header with one of the configured bearer tokens.
+func (s *Server) serveVulnReportAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error {
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	if !hasBearerToken(r, s.vulnReportTokens) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		return &serverError{status: http.StatusUnauthorized}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return &serverError{status: http.StatusFailedDependency, responseText: "not supported by this datasource"}
+	}
+	// Use the query, not the form, so that the body is not parsed as a form.
+	q := r.URL.Query()
+	modulePath := strings.Trim(q.Get("module"), "/")
+	requestedVersion := q.Get("version")
+	if modulePath == "" || requestedVersion == "" {
+		return &serverError{status: http.StatusBadRequest, responseText: "missing module or version query parameter"}
+	}
+	if !isSupportedVersion(modulePath, requestedVersion) {
+		return &serverError{status: http.StatusBadRequest, responseText: "invalid version"}
+	}
+	ctx := r.Context()
+	um, err := db.GetUnitMeta(ctx, modulePath, modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	findings, err := parseGovulncheckJSON(http.MaxBytesReader(w, r.Body, maxVulnReportSize))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, responseText: err.Error()}
+	}
+	if err := db.UpsertVulnReport(ctx, &postgres.VulnReport{
+		ModulePath: um.ModulePath,
+		Version:    um.Version,
+		Findings:   findings,
+	}); err != nil {
+		return err
+	}
+	resp := &VulnReportUploadResponse{
+		ModulePath:  um.ModulePath,
+		Version:     um.Version,
+		NumFindings: len(findings),
+	}
+	for _, f := range findings {
+		if len(f.Symbols) > 0 {
+			resp.NumReachable++
+		}
+	}
+	return serveJSON(w, r, resp)
+}
+
+// govulncheckMessage is a message in the output of govulncheck -json, which
+// is a stream of JSON objects. Only the fields needed for a report are
+// decoded.
+type govulncheckMessage struct {
+	OSV     *govulncheckOSV     `json:"osv"`
+	Finding *govulncheckFinding `json:"finding"`
+}
+
+type govulncheckOSV struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+}
+
+type govulncheckFinding struct {
+	OSV          string `json:"osv"`
+	FixedVersion string `json:"fixed_version"`
+	// Trace starts at the vulnerable symbol, package or module, and ends at
+	// the scanned code.
+	Trace []*govulncheckFrame `json:"trace"`
+}
+
+type govulncheckFrame struct {
+	Module   string `json:"module"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+}
+
+// parseGovulncheckJSON reads the output of govulncheck -json from r and
+// returns one finding per vulnerability, reachable ones first.
+func parseGovulncheckJSON(r io.Reader) (_ []*postgres.VulnFinding, err error) {
+	defer derrors.Wrap(&err, "parseGovulncheckJSON")
+
+	var (
+		summaries = map[string]string{}
+		byID      = map[string]*postgres.VulnFinding{}
+		findings  []*postgres.VulnFinding
+	)
+	dec := json.NewDecoder(r)
+	for {
+		var m govulncheckMessage
+		if err := dec.Decode(&m); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid govulncheck output: %v", err)
+		}
+		if m.OSV != nil {
+			summaries[m.OSV.ID] = m.OSV.Summary
+		}
+		if m.Finding == nil || m.Finding.OSV == "" || len(m.Finding.Trace) == 0 {
+			continue
+		}
+		f := byID[m.Finding.OSV]
+		if f == nil {
+			f = &postgres.VulnFinding{ID: m.Finding.OSV}
+			byID[f.ID] = f
+			findings = append(findings, f)
+		}
+		// The first frame is the vulnerable one.
+		frame := m.Finding.Trace[0]
+		f.Module = frame.Module
+		if m.Finding.FixedVersion != "" {
+			f.FixedVersion = m.Finding.FixedVersion
+		}
+		if frame.Package != "" {
+			f.Packages = appendUnique(f.Packages, frame.Package)
+		}
+		if frame.Function != "" {
+			name := frame.Function
+			if frame.Receiver != "" {
+				name = strings.TrimPrefix(frame.Receiver, "*") + "." + name
+			}
+			f.Symbols = appendUnique(f.Symbols, name)
+		}
+	}
+	for _, f := range findings {
+		f.Summary = summaries[f.ID]
+		sort.Strings(f.Packages)
+		sort.Strings(f.Symbols)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		ri, rj := len(findings[i].Symbols) > 0, len(findings[j].Symbols) > 0
+		if ri != rj {
+			return ri
+		}
+		return findings[i].ID < findings[j].ID
+	})
+	return findings, nil
+}
+
+// appendUnique appends s to ss if it is not already there.
+func appendUnique(ss []string, s string) []string {
+	for _, x := range ss {
+		if x == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
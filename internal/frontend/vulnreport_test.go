@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestParseGovulncheckJSON(t *testing.T) {
+	const input = `
+{"config": {"scanner_name": "govulncheck"}}
+{"osv": {"id": "GO-2022-0001", "summary": "Crash in Parse"}}
+{"osv": {"id": "GO-2022-0002", "summary": "Leak in Dial"}}
+{"finding": {"osv": "GO-2022-0001", "fixed_version": "v1.4.2", "trace": [{"module": "example.com/dep", "version": "v1.4.0"}]}}
+{"finding": {"osv": "GO-2022-0001", "fixed_version": "v1.4.2", "trace": [{"module": "example.com/dep", "version": "v1.4.0", "package": "example.com/dep/parse"}]}}
+{"finding": {"osv": "GO-2022-0001", "fixed_version": "v1.4.2", "trace": [
+	{"module": "example.com/dep", "version": "v1.4.0", "package": "example.com/dep/parse", "function": "Parse", "receiver": "*Parser"},
+	{"module": "example.com/m", "package": "example.com/m", "function": "main"}]}}
+{"finding": {"osv": "GO-2022-0001", "fixed_version": "v1.4.2", "trace": [
+	{"module": "example.com/dep", "version": "v1.4.0", "package": "example.com/dep/parse", "function": "Parse", "receiver": "*Parser"},
+	{"module": "example.com/m", "package": "example.com/m", "function": "run"}]}}
+{"finding": {"osv": "GO-2022-0002", "trace": [{"module": "example.com/net", "version": "v0.1.0", "package": "example.com/net/dial"}]}}
+`
+	got, err := parseGovulncheckJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*postgres.VulnFinding{
+		{
+			ID:           "GO-2022-0001",
+			Summary:      "Crash in Parse",
+			Module:       "example.com/dep",
+			FixedVersion: "v1.4.2",
+			Packages:     []string{"example.com/dep/parse"},
+			Symbols:      []string{"Parser.Parse"},
+		},
+		{
+			ID:       "GO-2022-0002",
+			Summary:  "Leak in Dial",
+			Module:   "example.com/net",
+			Packages: []string{"example.com/net/dial"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	if _, err := parseGovulncheckJSON(strings.NewReader(`{"finding": `)); err == nil {
+		t.Error("got nil error for truncated input")
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
+	"time"
 
 	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/sync/errgroup"
 	vulnc "golang.org/x/vuln/client"
@@ -65,6 +68,42 @@ func vulnsForPackage(modulePath, version, packagePath string, getVulnEntries vul
 	return vulns, nil
 }
 
+// vulnsForSymbols returns the vulnerabilities that affect the symbols of the
+// package at packagePath, at the given version of the module, keyed by
+// symbol name.
+func vulnsForSymbols(modulePath, version, packagePath string, getVulnEntries vulnEntriesFunc) (_ map[string][]dochtml.SymbolVuln, err error) {
+	defer derrors.Wrap(&err, "vulnsForSymbols(%q, %q, %q)", modulePath, version, packagePath)
+
+	if getVulnEntries == nil {
+		return nil, nil
+	}
+	entries, err := getVulnEntries(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string][]dochtml.SymbolVuln{}
+	for _, e := range entries {
+		vuln, ok := entryVuln(e, packagePath, version)
+		if !ok {
+			continue
+		}
+		sv := dochtml.SymbolVuln{
+			ID:           vuln.ID,
+			URL:          "/vuln/" + vuln.ID,
+			FixedVersion: vuln.FixedVersion,
+		}
+		for _, a := range e.Affected {
+			if a.Package.Name != packagePath {
+				continue
+			}
+			for _, s := range a.EcosystemSpecific.Symbols {
+				m[s] = append(m[s], sv)
+			}
+		}
+	}
+	return m, nil
+}
+
 // VulnListPage holds the information for a page that lists all vuln entries.
 type VulnListPage struct {
 	basePage
@@ -80,30 +119,91 @@ type VulnPage struct {
 func entryVuln(e *osv.Entry, packagePath, version string) (Vuln, bool) {
 	for _, a := range e.Affected {
 		if (packagePath == "" || a.Package.Name == packagePath) && a.Ranges.AffectsSemver(version) {
-			// Choose the latest fixed version, if any.
-			var fixed string
-			for _, r := range a.Ranges {
-				if r.Type == osv.TypeGit {
-					continue
-				}
-				for _, re := range r.Events {
-					if re.Fixed != "" && (fixed == "" || semver.Compare(re.Fixed, fixed) > 0) {
-						fixed = re.Fixed
-					}
-				}
-			}
-			fixed = addVersionPrefix(fixed, packagePath)
 			return Vuln{
 				ID:      e.ID,
 				Details: e.Details,
 				// TODO(golang/go#48223): handle stdlib versions
-				FixedVersion: fixed,
+				FixedVersion: addVersionPrefix(latestFixedVersion(a), packagePath),
 			}, true
 		}
 	}
 	return Vuln{}, false
 }
 
+// latestFixedVersion returns the latest version of a that fixes the
+// vulnerability, without a "v" prefix, or the empty string if there is none.
+func latestFixedVersion(a osv.Affected) string {
+	var fixed string
+	for _, r := range a.Ranges {
+		if r.Type == osv.TypeGit {
+			continue
+		}
+		for _, re := range r.Events {
+			if re.Fixed != "" && (fixed == "" || semver.Compare(re.Fixed, fixed) > 0) {
+				fixed = re.Fixed
+			}
+		}
+	}
+	return fixed
+}
+
+// A VulnSummary describes a vulnerability in the response of the
+// /api/v1/vulns endpoint.
+type VulnSummary struct {
+	ID        string     `json:"id"`
+	Aliases   []string   `json:"aliases,omitempty"`
+	Published time.Time  `json:"published"`
+	Modified  time.Time  `json:"modified"`
+	Withdrawn *time.Time `json:"withdrawn,omitempty"`
+	Details   string     `json:"details"`
+	// Packages are the affected packages at or below the requested prefix.
+	Packages []*VulnPackage `json:"packages"`
+}
+
+// A VulnPackage is a package affected by a vulnerability.
+type VulnPackage struct {
+	Path string `json:"path"`
+	// FixedVersion is the latest version that fixes the vulnerability, if any.
+	FixedVersion string `json:"fixedVersion,omitempty"`
+}
+
+// vulnsForPrefix returns summaries of the entries that affect a package at
+// or below prefix, most recently modified first.
+func vulnsForPrefix(entries []*osv.Entry, prefix string) []*VulnSummary {
+	var vs []*VulnSummary
+	for _, e := range entries {
+		var pkgs []*VulnPackage
+		for _, a := range e.Affected {
+			if a.Package.Name != prefix && !strings.HasPrefix(a.Package.Name, prefix+"/") {
+				continue
+			}
+			pkgs = append(pkgs, &VulnPackage{
+				Path:         a.Package.Name,
+				FixedVersion: addVersionPrefix(latestFixedVersion(a), a.Package.Name),
+			})
+		}
+		if len(pkgs) == 0 {
+			continue
+		}
+		vs = append(vs, &VulnSummary{
+			ID:        e.ID,
+			Aliases:   e.Aliases,
+			Published: e.Published,
+			Modified:  e.Modified,
+			Withdrawn: e.Withdrawn,
+			Details:   e.Details,
+			Packages:  pkgs,
+		})
+	}
+	sort.SliceStable(vs, func(i, j int) bool {
+		if !vs[i].Modified.Equal(vs[j].Modified) {
+			return vs[i].Modified.After(vs[j].Modified)
+		}
+		return vs[i].ID > vs[j].ID
+	})
+	return vs
+}
+
 func (s *Server) serveVuln(w http.ResponseWriter, r *http.Request, _ internal.DataSource) error {
 	switch r.URL.Path {
 	case "/", "/list":
@@ -145,6 +245,16 @@ func newVulnPage(client vulnc.Client, id string) (*VulnPage, error) {
 }
 
 func newVulnListPage(client vulnc.Client) (*VulnListPage, error) {
+	entries, err := allVulnEntries(client)
+	if err != nil {
+		return nil, err
+	}
+	return &VulnListPage{Entries: entries}, nil
+}
+
+// allVulnEntries returns all the entries of the vulnerability database, from
+// most to least recent ID.
+func allVulnEntries(client vulnc.Client) ([]*osv.Entry, error) {
 	const concurrency = 4
 
 	ids, err := client.ListIDs()
@@ -174,7 +284,7 @@ func newVulnListPage(client vulnc.Client) (*VulnListPage, error) {
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	return &VulnListPage{Entries: entries}, nil
+	return entries, nil
 }
 
 func addVersionPrefix(semver, packagePath string) (res string) {
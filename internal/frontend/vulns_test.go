@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
 	vulnc "golang.org/x/vuln/client"
 	"golang.org/x/vuln/osv"
 )
@@ -57,6 +59,82 @@ func TestVulnsForPackage(t *testing.T) {
 	}
 }
 
+func TestVulnsForSymbols(t *testing.T) {
+	e := &osv.Entry{
+		ID: "GO-1990-01",
+		Affected: []osv.Affected{{
+			Package: osv.Package{Name: "bad.com/p"},
+			Ranges: []osv.AffectsRange{{
+				Type:   osv.TypeSemver,
+				Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.2.3"}},
+			}},
+			EcosystemSpecific: osv.EcosystemSpecific{Symbols: []string{"F", "T.M"}},
+		}},
+	}
+	get := func(string) ([]*osv.Entry, error) { return []*osv.Entry{e}, nil }
+
+	got, err := vulnsForSymbols("bad.com", "v1.0.0", "bad.com/p", get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv := dochtml.SymbolVuln{ID: "GO-1990-01", URL: "/vuln/GO-1990-01", FixedVersion: "v1.2.3"}
+	want := map[string][]dochtml.SymbolVuln{"F": {sv}, "T.M": {sv}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Neither a fixed version nor another package is affected.
+	for _, test := range []struct{ version, pkg string }{
+		{"v1.2.3", "bad.com/p"},
+		{"v1.0.0", "bad.com/q"},
+	} {
+		got, err := vulnsForSymbols("bad.com", test.version, test.pkg, get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("%s@%s: got %v, want none", test.pkg, test.version, got)
+		}
+	}
+}
+
+func TestVulnsForPrefix(t *testing.T) {
+	date := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+	affected := func(pkg, fixed string) osv.Affected {
+		return osv.Affected{
+			Package: osv.Package{Name: pkg},
+			Ranges: []osv.AffectsRange{{
+				Type:   osv.TypeSemver,
+				Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: fixed}},
+			}},
+		}
+	}
+	entries := []*osv.Entry{
+		{ID: "GO-1990-01", Modified: date, Affected: []osv.Affected{affected("example.com/org/a", "1.2.3")}},
+		{ID: "GO-1990-02", Modified: date.Add(time.Hour), Affected: []osv.Affected{
+			affected("example.com/other", "1.0.0"),
+			affected("example.com/org/b/p", "2.0.1"),
+		}},
+		{ID: "GO-1990-03", Modified: date, Affected: []osv.Affected{affected("example.com/organization", "1.0.0")}},
+	}
+	got := vulnsForPrefix(entries, "example.com/org")
+	want := []*VulnSummary{
+		{
+			ID:       "GO-1990-02",
+			Modified: date.Add(time.Hour),
+			Packages: []*VulnPackage{{Path: "example.com/org/b/p", FixedVersion: "v2.0.1"}},
+		},
+		{
+			ID:       "GO-1990-01",
+			Modified: date,
+			Packages: []*VulnPackage{{Path: "example.com/org/a", FixedVersion: "v1.2.3"}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
 var testEntries = []*osv.Entry{
 	{ID: "GO-1990-01", Details: "a"},
 	{ID: "GO-1990-02", Details: "b"},
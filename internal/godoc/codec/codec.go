@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 )
 
 // An Encoder encodes Go values into a sequence of bytes.
@@ -342,6 +343,23 @@ func (d *Decoder) StartList() int {
 	}
 }
 
+// An orderedKey is a type of map key that can be sorted.
+type orderedKey interface {
+	~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// SortedKeys returns the keys of m in increasing order. Generated map encoders
+// use it so that encoding the same map always produces the same bytes.
+func SortedKeys[K orderedKey, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
 //////////////// Struct Support
 
 // StartStruct should be called before encoding a struct pointer. The isNil
@@ -482,7 +482,8 @@ func init() {
 // Template body for a map type.
 // A nil map is encoded as a zero.
 // A map of size N is encoded as a list of length 2N, containing alternating
-// keys and values.
+// keys and values, in increasing order of keys so that the encoding is
+// deterministic.
 //
 // In the decode function, we declare a variable v to hold the decoded map value
 // rather than decoding directly into m[v]. This is necessary for decode
@@ -496,7 +497,8 @@ func encode_«$funcName»(e *codec.Encoder, m «$goName») {
 		return
 	}
 	e.StartList(2*len(m))
-	for k, v := range m {
+	for _, k := range codec.SortedKeys(m) {
+		v := m[k]
 		«encodeStmt .KeyType "k"»
 		«encodeStmt .ElType "v"»
 	}
@@ -16,7 +16,8 @@ func encode_map_string_bool(e *codec.Encoder, m map[string]bool) {
 		return
 	}
 	e.StartList(2 * len(m))
-	for k, v := range m {
+	for _, k := range codec.SortedKeys(m) {
+		v := m[k]
 		e.EncodeString(k)
 		e.EncodeBool(v)
 	}
@@ -46,6 +46,27 @@ type ModuleInfo struct {
 	ModulePackages map[string]bool
 }
 
+// A FileLink is a link to a file of the package, or to a location in it.
+type FileLink struct {
+	File string // path of the file relative to the package directory
+	URL  string // may be empty
+}
+
+// EmbeddedFiles describes the files embedded in a variable by //go:embed
+// directives.
+type EmbeddedFiles struct {
+	Patterns []string   // patterns of the directives
+	Files    []FileLink // may be truncated
+	NumFiles int        // total number of embedded files
+}
+
+// A SymbolVuln is a vulnerability that affects a symbol of the package.
+type SymbolVuln struct {
+	ID           string // vulndb ID, such as "GO-2021-0001"
+	URL          string // may be empty
+	FixedVersion string // empty if there is no fix
+}
+
 // RenderOptions are options for Render.
 type RenderOptions struct {
 	// FileLinkFunc optionally specifies a function that
@@ -57,6 +78,18 @@ type RenderOptions struct {
 	FileLinkFunc     func(file string) (url string)
 	SourceLinkFunc   func(ast.Node) string
 	SinceVersionFunc func(name string) string
+	// AsmLinksFunc optionally specifies a function that returns links to
+	// the assembly implementations of the function with the given name.
+	// For methods, name is of the form "Type.Method".
+	AsmLinksFunc func(name string) []FileLink
+	// EmbeddedFilesFunc optionally specifies a function that returns the
+	// files embedded in the package-level variable with the given name, or
+	// nil if it has no //go:embed directives.
+	EmbeddedFilesFunc func(name string) *EmbeddedFiles
+	// VulnsFunc optionally specifies a function that returns the
+	// vulnerabilities affecting the symbol with the given name at the
+	// version being rendered. For methods, name is of the form "Type.Method".
+	VulnsFunc func(name string) []SymbolVuln
 	// ModInfo optionally specifies information about the module the package
 	// belongs to in order to render module-related documentation.
 	ModInfo      *ModuleInfo
@@ -272,6 +305,50 @@ func renderInfo(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Re
 	sourceLink := func(name string, node ast.Node) safehtml.HTML {
 		return linkHTML(name, opt.SourceLinkFunc(node), "Documentation-source")
 	}
+	asmLinks := func(name string) []safehtml.HTML {
+		if opt.AsmLinksFunc == nil {
+			return nil
+		}
+		var links []safehtml.HTML
+		for _, l := range opt.AsmLinksFunc(name) {
+			links = append(links, linkHTML(l.File, l.URL, "Documentation-asmSource"))
+		}
+		return links
+	}
+	embeddedFiles := func(decl ast.Decl) []*embedsData {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR || opt.EmbeddedFilesFunc == nil {
+			return nil
+		}
+		var data []*embedsData
+		for _, spec := range gd.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				ef := opt.EmbeddedFilesFunc(name.Name)
+				if ef == nil {
+					continue
+				}
+				d := &embedsData{Name: name.Name, Patterns: ef.Patterns, More: ef.NumFiles - len(ef.Files)}
+				for _, f := range ef.Files {
+					d.Files = append(d.Files, linkHTML(f.File, f.URL, "Documentation-embeddedFile"))
+				}
+				data = append(data, d)
+			}
+		}
+		return data
+	}
+	symbolVulns := func(name string) []*vulnData {
+		if opt.VulnsFunc == nil {
+			return nil
+		}
+		var data []*vulnData
+		for _, v := range opt.VulnsFunc(name) {
+			data = append(data, &vulnData{
+				Link:         linkHTML(v.ID, v.URL, "Documentation-vulnLink"),
+				FixedVersion: v.FixedVersion,
+			})
+		}
+		return data
+	}
 	sinceVersion := func(name string) safehtml.HTML {
 		return safehtml.HTMLEscaped(opt.SinceVersionFunc(name))
 	}
@@ -284,6 +361,9 @@ func renderInfo(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Re
 		"render_code":              r.CodeHTML,
 		"file_link":                fileLink,
 		"source_link":              sourceLink,
+		"asm_links":                asmLinks,
+		"embedded_files":           embeddedFiles,
+		"symbol_vulns":             symbolVulns,
 		"since_version":            sinceVersion,
 	}
 	examples := collectExamples(p)
@@ -297,6 +377,20 @@ func renderInfo(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Re
 	return funcs, data, r.Links
 }
 
+// embedsData holds the data for rendering the files embedded in a variable.
+type embedsData struct {
+	Name     string
+	Patterns []string
+	Files    []safehtml.HTML
+	More     int // number of embedded files not in Files
+}
+
+// vulnData holds the data for rendering a vulnerability that affects a symbol.
+type vulnData struct {
+	Link         safehtml.HTML
+	FixedVersion string
+}
+
 // executeToHTMLWithLimit executes tmpl on data and returns the result as a safehtml.HTML.
 // It returns an error if the size of the result exceeds limit.
 func executeToHTMLWithLimit(tmpl *template.Template, data interface{}, limit int64) (safehtml.HTML, error) {
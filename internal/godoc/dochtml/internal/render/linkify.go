@@ -23,6 +23,7 @@ import (
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal/godoc/internal/doc"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/texmath"
 )
 
 /*
@@ -86,12 +87,18 @@ func (r *Renderer) declHTML(doc string, decl ast.Decl, extractLinks bool) (out s
 func (r *Renderer) formatDocHTML(doc string, extractLinks bool) safehtml.HTML {
 	var els []docElement
 	inLinks := false
+	// Headings with the same title get IDs distinguished by their order of
+	// appearance, so that each anchor is unique and stable across renderings.
+	seenIDs := map[string]int{}
 	for _, blk := range docToBlocks(doc) {
 		var el docElement
 		switch blk := blk.(type) {
 		case *paragraph:
 			if inLinks {
 				r.links = append(r.links, parseLinks(blk.lines)...)
+			} else if m, ok := mathHTML(blk.lines); ok {
+				el.Body = m
+				els = append(els, el)
 			} else {
 				el.Body = r.linesToHTML(blk.lines, false)
 				els = append(els, el)
@@ -112,6 +119,12 @@ func (r *Renderer) formatDocHTML(doc string, extractLinks bool) safehtml.HTML {
 				el.IsHeading = true
 				el.Title = blk.title
 				id := badAnchorRx.ReplaceAllString(blk.title, "_")
+				if n := seenIDs[id]; n > 0 {
+					seenIDs[id]++
+					id = fmt.Sprintf("%s_%d", id, n)
+				} else {
+					seenIDs[id] = 1
+				}
 				el.ID = safehtml.IdentifierFromConstantPrefix("hdr", id)
 				els = append(els, el)
 			}
@@ -159,6 +172,29 @@ func parseLink(line string) *Link {
 	}
 }
 
+// mathHTML returns the MathML for a paragraph that is a LaTeX formula
+// between $$ delimiters, such as
+//
+//	$$ e^{i\pi} + 1 = 0 $$
+//
+// It reports false if the paragraph is not a formula, or if the formula is
+// not supported by the texmath package, so that it is displayed as text.
+func mathHTML(lines []string) (safehtml.HTML, bool) {
+	s := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(s) < 4 || !strings.HasPrefix(s, "$$") || !strings.HasSuffix(s, "$$") {
+		return safehtml.HTML{}, false
+	}
+	tex := s[2 : len(s)-2]
+	if strings.Contains(tex, "$$") {
+		return safehtml.HTML{}, false
+	}
+	h, err := texmath.ToMathML(tex, true)
+	if err != nil {
+		return safehtml.HTML{}, false
+	}
+	return h, true
+}
+
 func (r *Renderer) linesToHTML(lines []string, pre bool) safehtml.HTML {
 	newline := safehtml.HTMLEscaped("\n")
 	htmls := make([]safehtml.HTML, 0, 2*len(lines))
@@ -82,6 +82,22 @@ The Go Project
 Go is an open source project.`,
 			want: `<p>Documentation.
 </p><h4 id="hdr-The_Go_Project">The Go Project <a class="Documentation-idLink" href="#hdr-The_Go_Project">¶</a></h4><p>Go is an open source project.
+</p>`,
+		},
+		{
+			name: "duplicate headers get unique ids",
+			doc: `Documentation.
+
+Usage
+
+Run it.
+
+Usage
+
+Run it again.`,
+			want: `<p>Documentation.
+</p><h4 id="hdr-Usage">Usage <a class="Documentation-idLink" href="#hdr-Usage">¶</a></h4><p>Run it.
+</p><h4 id="hdr-Usage_1">Usage <a class="Documentation-idLink" href="#hdr-Usage_1">¶</a></h4><p>Run it again.
 </p>`,
 		},
 		{
@@ -157,6 +173,23 @@ TLSUnique contains the tls-unique channel binding value (see RFC
 			    [].join() // returns ''`,
 			want: `<p>Join` + "\n" + `</p><pre>[].join() // returns &#39;&#39;` + "\n" + `</pre>`,
 		},
+		{
+			name: "math",
+			doc: `The sum is
+
+$$
+\sum_i x_i
+$$
+
+and costs $$ to compute.
+
+$$ \unknown $$`,
+			want: `<p>The sum is` + "\n" + `</p>` +
+				`<p><math display="block"><semantics><mrow><munder><mo largeop="true" movablelimits="true">∑</mo><mi>i</mi></munder><msub><mi>x</mi><mi>i</mi></msub></mrow>` +
+				`<annotation encoding="application/x-tex">\sum_i x_i</annotation></semantics></math></p>` +
+				`<p>and costs $$ to compute.` + "\n" + `</p>` +
+				`<p>$$ \unknown $$` + "\n" + `</p>`,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			extractLinks := test.extractLinks
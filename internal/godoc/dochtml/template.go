@@ -5,6 +5,7 @@
 package dochtml
 
 import (
+	"go/ast"
 	"path"
 	"reflect"
 	"sync"
@@ -59,6 +60,9 @@ var tmpl = map[string]interface{}{
 	"render_code":              (*render.Renderer)(nil).CodeHTML,
 	"file_link":                func() string { return "" },
 	"source_link":              func(string, interface{}) string { return "" },
+	"asm_links":                func(string) []safehtml.HTML { return nil },
+	"embedded_files":           func(ast.Decl) []*embedsData { return nil },
+	"symbol_vulns":             func(string) []*vulnData { return nil },
 	"since_version":            func(string) safehtml.HTML { return safehtml.HTML{} },
 	"play_url":                 func(*doc.Example) string { return "" },
 	"safe_id":                  render.SafeGoID,
@@ -3493,7 +3493,7 @@ func init() {
 		})
 }
 
-// Fields of encPackage: GOOS GOARCH Files ModulePackagePaths
+// Fields of encPackage: GOOS GOARCH Files ModulePackagePaths AsmFiles Embeds
 
 func encode_encPackage(e *codec.Encoder, x *encPackage) {
 	if !e.StartStruct(x == nil, x) {
@@ -3508,6 +3508,14 @@ func encode_encPackage(e *codec.Encoder, x *encPackage) {
 		e.EncodeUint(3)
 		encode_map_string_bool(e, x.ModulePackagePaths)
 	}
+	if x.AsmFiles != nil {
+		e.EncodeUint(4)
+		encode_slice_AsmFile(e, x.AsmFiles)
+	}
+	if x.Embeds != nil {
+		e.EncodeUint(5)
+		encode_slice_Embed(e, x.Embeds)
+	}
 	e.EndStruct()
 }
 
@@ -3532,6 +3540,10 @@ func decode_encPackage(d *codec.Decoder, p **encPackage) {
 			decode_slice_File(d, &x.Files)
 		case 3:
 			decode_map_string_bool(d, &x.ModulePackagePaths)
+		case 4:
+			decode_slice_AsmFile(d, &x.AsmFiles)
+		case 5:
+			decode_slice_Embed(d, &x.Embeds)
 		default:
 			d.UnknownField("encPackage", n)
 		}
@@ -3802,7 +3814,8 @@ func encode_map_string_ast_Object(e *codec.Encoder, m map[string]*ast.Object) {
 		return
 	}
 	e.StartList(2 * len(m))
-	for k, v := range m {
+	for _, k := range codec.SortedKeys(m) {
+		v := m[k]
 		e.EncodeString(k)
 		encode_ast_Object(e, v)
 	}
@@ -3870,7 +3883,8 @@ func encode_map_string_bool(e *codec.Encoder, m map[string]bool) {
 		return
 	}
 	e.StartList(2 * len(m))
-	for k, v := range m {
+	for _, k := range codec.SortedKeys(m) {
+		v := m[k]
 		e.EncodeString(k)
 		e.EncodeBool(v)
 	}
@@ -3899,6 +3913,64 @@ func init() {
 		func(d *codec.Decoder) interface{} { var x map[string]bool; decode_map_string_bool(d, &x); return x })
 }
 
+func encode_slice_AsmFile(e *codec.Encoder, s []*AsmFile) {
+	if s == nil {
+		e.EncodeNil()
+		return
+	}
+	e.StartList(len(s))
+	for _, x := range s {
+		encode_AsmFile(e, x)
+	}
+}
+
+func decode_slice_AsmFile(d *codec.Decoder, p *[]*AsmFile) {
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]*AsmFile, n)
+	for i := 0; i < n; i++ {
+		decode_AsmFile(d, &s[i])
+	}
+	*p = s
+}
+
+func init() {
+	codec.Register([]*AsmFile(nil),
+		func(e *codec.Encoder, x interface{}) { encode_slice_AsmFile(e, x.([]*AsmFile)) },
+		func(d *codec.Decoder) interface{} { var x []*AsmFile; decode_slice_AsmFile(d, &x); return x })
+}
+
+func encode_slice_Embed(e *codec.Encoder, s []*Embed) {
+	if s == nil {
+		e.EncodeNil()
+		return
+	}
+	e.StartList(len(s))
+	for _, x := range s {
+		encode_Embed(e, x)
+	}
+}
+
+func decode_slice_Embed(d *codec.Decoder, p *[]*Embed) {
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]*Embed, n)
+	for i := 0; i < n; i++ {
+		decode_Embed(d, &s[i])
+	}
+	*p = s
+}
+
+func init() {
+	codec.Register([]*Embed(nil),
+		func(e *codec.Encoder, x interface{}) { encode_slice_Embed(e, x.([]*Embed)) },
+		func(d *codec.Decoder) interface{} { var x []*Embed; decode_slice_Embed(d, &x); return x })
+}
+
 // Fields of File: Name AST
 
 func encode_File(e *codec.Encoder, x *File) {
@@ -3954,6 +4026,128 @@ func init() {
 		})
 }
 
+// Fields of AsmFile: Name Funcs
+
+func encode_AsmFile(e *codec.Encoder, x *AsmFile) {
+	if !e.StartStruct(x == nil, x) {
+		return
+	}
+	if x.Name != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.Name)
+	}
+	if x.Funcs != nil {
+		e.EncodeUint(1)
+		encode_map_string_int(e, x.Funcs)
+	}
+	e.EndStruct()
+}
+
+func decode_AsmFile(d *codec.Decoder, p **AsmFile) {
+	proceed, ref := d.StartStruct()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*AsmFile)
+		return
+	}
+	var x AsmFile
+	d.StoreRef(&x)
+	for {
+		n := d.NextStructField()
+		if n < 0 {
+			break
+		}
+		switch n {
+		case 0:
+			x.Name = d.DecodeString()
+		case 1:
+			decode_map_string_int(d, &x.Funcs)
+		default:
+			d.UnknownField("AsmFile", n)
+		}
+		*p = &x
+	}
+}
+
+func init() {
+	codec.Register(&AsmFile{},
+		func(e *codec.Encoder, x interface{}) { encode_AsmFile(e, x.(*AsmFile)) },
+		func(d *codec.Decoder) interface{} {
+			var x *AsmFile
+			decode_AsmFile(d, &x)
+			return x
+		})
+}
+
+// Fields of Embed: Name Patterns Files NumFiles
+
+func encode_Embed(e *codec.Encoder, x *Embed) {
+	if !e.StartStruct(x == nil, x) {
+		return
+	}
+	if x.Name != "" {
+		e.EncodeUint(0)
+		e.EncodeString(x.Name)
+	}
+	if x.Patterns != nil {
+		e.EncodeUint(1)
+		encode_slice_string(e, x.Patterns)
+	}
+	if x.Files != nil {
+		e.EncodeUint(2)
+		encode_slice_string(e, x.Files)
+	}
+	if x.NumFiles != 0 {
+		e.EncodeUint(3)
+		e.EncodeInt(int64(x.NumFiles))
+	}
+	e.EndStruct()
+}
+
+func decode_Embed(d *codec.Decoder, p **Embed) {
+	proceed, ref := d.StartStruct()
+	if !proceed {
+		return
+	}
+	if ref != nil {
+		*p = ref.(*Embed)
+		return
+	}
+	var x Embed
+	d.StoreRef(&x)
+	for {
+		n := d.NextStructField()
+		if n < 0 {
+			break
+		}
+		switch n {
+		case 0:
+			x.Name = d.DecodeString()
+		case 1:
+			decode_slice_string(d, &x.Patterns)
+		case 2:
+			decode_slice_string(d, &x.Files)
+		case 3:
+			x.NumFiles = int(d.DecodeInt())
+		default:
+			d.UnknownField("Embed", n)
+		}
+		*p = &x
+	}
+}
+
+func init() {
+	codec.Register(&Embed{},
+		func(e *codec.Encoder, x interface{}) { encode_Embed(e, x.(*Embed)) },
+		func(d *codec.Decoder) interface{} {
+			var x *Embed
+			decode_Embed(d, &x)
+			return x
+		})
+}
+
 // Fields of ast_File: Doc Package Name Decls Scope Imports Unresolved Comments
 
 func encode_ast_File(e *codec.Encoder, x *ast.File) {
@@ -4045,6 +4239,71 @@ func init() {
 		})
 }
 
+func encode_map_string_int(e *codec.Encoder, m map[string]int) {
+	if m == nil {
+		e.EncodeNil()
+		return
+	}
+	e.StartList(2 * len(m))
+	for _, k := range codec.SortedKeys(m) {
+		v := m[k]
+		e.EncodeString(k)
+		e.EncodeInt(int64(v))
+	}
+}
+
+func decode_map_string_int(d *codec.Decoder, p *map[string]int) {
+	n2 := d.StartList()
+	if n2 < 0 {
+		return
+	}
+	n := n2 / 2
+	m := make(map[string]int, n)
+	var k string
+	var v int
+	for i := 0; i < n; i++ {
+		k = d.DecodeString()
+		v = int(d.DecodeInt())
+		m[k] = v
+	}
+	*p = m
+}
+
+func init() {
+	codec.Register(map[string]int(nil),
+		func(e *codec.Encoder, x interface{}) { encode_map_string_int(e, x.(map[string]int)) },
+		func(d *codec.Decoder) interface{} { var x map[string]int; decode_map_string_int(d, &x); return x })
+}
+
+func encode_slice_string(e *codec.Encoder, s []string) {
+	if s == nil {
+		e.EncodeNil()
+		return
+	}
+	e.StartList(len(s))
+	for _, x := range s {
+		e.EncodeString(x)
+	}
+}
+
+func decode_slice_string(d *codec.Decoder, p *[]string) {
+	n := d.StartList()
+	if n < 0 {
+		return
+	}
+	s := make([]string, n)
+	for i := 0; i < n; i++ {
+		s[i] = d.DecodeString()
+	}
+	*p = s
+}
+
+func init() {
+	codec.Register([]string(nil),
+		func(e *codec.Encoder, x interface{}) { encode_slice_string(e, x.([]string)) },
+		func(d *codec.Decoder) interface{} { var x []string; decode_slice_string(d, &x); return x })
+}
+
 func encode_slice_ast_Decl(e *codec.Encoder, s []ast.Decl) {
 	if s == nil {
 		e.EncodeNil()
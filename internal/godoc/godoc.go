@@ -8,6 +8,8 @@ package godoc
 import (
 	"go/ast"
 	"go/token"
+	"regexp"
+	"sort"
 	"strings"
 
 	"golang.org/x/pkgsite/internal/godoc/dochtml"
@@ -39,6 +41,8 @@ type Package struct {
 type encPackage struct { // fields that can be directly encoded
 	Files              []*File
 	ModulePackagePaths map[string]bool
+	AsmFiles           []*AsmFile
+	Embeds             []*Embed
 }
 
 // A File contains everything needed about a source file to render documentation.
@@ -47,6 +51,48 @@ type File struct {
 	AST  *ast.File
 }
 
+// An AsmFile is an assembly source file of the package.
+type AsmFile struct {
+	Name string // file name relative to the package directory
+	// Funcs maps the names of the functions implemented in the file to the
+	// line of their TEXT directive. Methods are named "Type.Method".
+	Funcs map[string]int
+}
+
+// An Embed describes the files embedded in a variable by //go:embed
+// directives.
+type Embed struct {
+	Name     string   // name of the variable
+	Patterns []string // patterns of the directives, in order
+	// Files holds the paths of the embedded files relative to the package
+	// directory, sorted. It may be truncated; NumFiles is the total number of
+	// embedded files.
+	Files    []string
+	NumFiles int
+}
+
+// EmbeddedFileIDPrefix is the prefix of the HTML ids of embedded files
+// listed on the unit page.
+const EmbeddedFileIDPrefix = "embedded-file"
+
+// EmbeddedFiles returns the sorted paths of all the files embedded in the
+// package. The position of a file in the result determines its HTML id:
+// the file at index i has id EmbeddedFileIDPrefix + "-" + i.
+func (p *Package) EmbeddedFiles() []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, e := range p.Embeds {
+		for _, f := range e.Files {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
 // NewPackage returns a new Package with the given fset and set of module package paths.
 func NewPackage(fset *token.FileSet, modPaths map[string]bool) *Package {
 	return &Package{
@@ -72,6 +118,40 @@ func (p *Package) AddFile(f *ast.File, removeNodes bool) {
 	})
 }
 
+// AddAsmFile adds the assembly file with the given name and contents to the
+// Package, recording the functions of package pkgName that it implements.
+func (p *Package) AddAsmFile(name, pkgName string, contents []byte) {
+	funcs := asmFuncs(pkgName, contents)
+	if len(funcs) == 0 {
+		return
+	}
+	p.AsmFiles = append(p.AsmFiles, &AsmFile{Name: name, Funcs: funcs})
+}
+
+// asmTextRegexp matches a TEXT directive in an assembly file, capturing the
+// package qualifier and the name of the symbol.
+var asmTextRegexp = regexp.MustCompile(`^\s*TEXT\s+([\w/.]*)·([^\s(]+|\(\*?\w+\)\.\w+)\(SB\)`)
+
+// asmFuncs returns a map from the names of the package's functions defined in
+// the assembly source to the line of their definition.
+// Symbols qualified with a package other than pkgName are ignored.
+func asmFuncs(pkgName string, contents []byte) map[string]int {
+	funcs := map[string]int{}
+	for i, line := range strings.Split(string(contents), "\n") {
+		m := asmTextRegexp.FindStringSubmatch(line)
+		if m == nil || (m[1] != "" && m[1] != pkgName) {
+			continue
+		}
+		// Methods are written either as ·T·M or as ·(*T).M.
+		name := strings.ReplaceAll(m[2], "·", ".")
+		name = strings.NewReplacer("(*", "", "(", "", ")", "").Replace(name)
+		if _, ok := funcs[name]; !ok {
+			funcs[name] = i + 1
+		}
+	}
+	return funcs
+}
+
 // removeUnusedASTNodes removes parts of the AST not needed for documentation.
 // It doesn't remove unexported consts, vars or types, although it probably could.
 func removeUnusedASTNodes(pf *ast.File) {
@@ -110,3 +110,34 @@ func (t) U()
 		t.Errorf("mismatch (-want, +got):\n%s", diff)
 	}
 }
+
+func TestAsmFuncs(t *testing.T) {
+	const src = `#include "textflag.h"
+
+// func Sum(xs []int) int
+TEXT ·Sum(SB),NOSPLIT,$0-32
+	RET
+
+TEXT p·Max(SB),NOSPLIT,$0
+	RET
+
+TEXT ·T·M(SB),NOSPLIT,$0
+	RET
+
+TEXT ·(*T).N(SB),NOSPLIT,$0
+	RET
+
+TEXT other·Min(SB),NOSPLIT,$0
+	RET
+`
+	got := asmFuncs("p", []byte(src))
+	want := map[string]int{
+		"Sum": 4,
+		"Max": 7,
+		"T.M": 10,
+		"T.N": 13,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -142,7 +142,8 @@ func (p *Package) docPackage(innerPath string, modInfo *ModuleInfo) (_ *doc.Pack
 
 // renderOptions returns a RenderOptions for p.
 func (p *Package) renderOptions(innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo,
-	nameToVersion map[string]string, bc internal.BuildContext) dochtml.RenderOptions {
+	nameToVersion map[string]string, symbolVulns map[string][]dochtml.SymbolVuln,
+	bc internal.BuildContext) dochtml.RenderOptions {
 	sourceLinkFunc := func(n ast.Node) string {
 		if sourceInfo == nil {
 			return ""
@@ -160,13 +161,55 @@ func (p *Package) renderOptions(innerPath string, sourceInfo *source.Info, modIn
 		return sourceInfo.FileURL(path.Join(innerPath, filename))
 	}
 
+	asmLinksFunc := func(name string) []dochtml.FileLink {
+		var links []dochtml.FileLink
+		for _, f := range p.AsmFiles {
+			line, ok := f.Funcs[name]
+			if !ok {
+				continue
+			}
+			l := dochtml.FileLink{File: f.Name}
+			if sourceInfo != nil {
+				l.URL = sourceInfo.LineURL(path.Join(innerPath, f.Name), line)
+			}
+			links = append(links, l)
+		}
+		return links
+	}
+
+	// Embedded files link to their entry in the list of files on the unit
+	// page; see EmbeddedFiles.
+	embedIDs := map[string]int{}
+	for i, f := range p.EmbeddedFiles() {
+		embedIDs[f] = i
+	}
+	embeddedFilesFunc := func(name string) *dochtml.EmbeddedFiles {
+		for _, e := range p.Embeds {
+			if e.Name != name {
+				continue
+			}
+			ef := &dochtml.EmbeddedFiles{Patterns: e.Patterns, NumFiles: e.NumFiles}
+			for _, f := range e.Files {
+				ef.Files = append(ef.Files, dochtml.FileLink{
+					File: f,
+					URL:  fmt.Sprintf("#%s-%d", EmbeddedFileIDPrefix, embedIDs[f]),
+				})
+			}
+			return ef
+		}
+		return nil
+	}
+
 	return dochtml.RenderOptions{
-		FileLinkFunc:     fileLinkFunc,
-		SourceLinkFunc:   sourceLinkFunc,
-		ModInfo:          modInfo,
-		SinceVersionFunc: sinceVersionFunc(modInfo.ModulePath, nameToVersion),
-		Limit:            int64(MaxDocumentationHTML),
-		BuildContext:     bc,
+		FileLinkFunc:      fileLinkFunc,
+		SourceLinkFunc:    sourceLinkFunc,
+		AsmLinksFunc:      asmLinksFunc,
+		EmbeddedFilesFunc: embeddedFilesFunc,
+		ModInfo:           modInfo,
+		SinceVersionFunc:  sinceVersionFunc(modInfo.ModulePath, nameToVersion),
+		VulnsFunc:         func(name string) []dochtml.SymbolVuln { return symbolVulns[name] },
+		Limit:             int64(MaxDocumentationHTML),
+		BuildContext:      bc,
 	}
 }
 
@@ -213,10 +256,12 @@ func sinceVersionFunc(modulePath string, nameToVersion map[string]string) func(n
 }
 
 // Render renders the documentation for the package.
+// symbolVulns maps the names of symbols to the vulnerabilities that affect
+// them; it may be nil.
 // Rendering destroys p's AST; do not call any methods of p after it returns.
 func (p *Package) Render(ctx context.Context, innerPath string,
 	sourceInfo *source.Info, modInfo *ModuleInfo, nameToVersion map[string]string,
-	bc internal.BuildContext) (_ *dochtml.Parts, err error) {
+	symbolVulns map[string][]dochtml.SymbolVuln, bc internal.BuildContext) (_ *dochtml.Parts, err error) {
 	p.renderCalled = true
 
 	d, err := p.docPackage(innerPath, modInfo)
@@ -224,7 +269,7 @@ func (p *Package) Render(ctx context.Context, innerPath string,
 		return nil, err
 	}
 
-	opts := p.renderOptions(innerPath, sourceInfo, modInfo, nameToVersion, bc)
+	opts := p.renderOptions(innerPath, sourceInfo, modInfo, nameToVersion, symbolVulns, bc)
 	parts, err := dochtml.Render(ctx, p.Fset, d, opts)
 	if errors.Is(err, ErrTooLarge) {
 		return &dochtml.Parts{Body: template.MustParseAndExecuteToHTML(DocTooLargeReplacement)}, nil
@@ -254,5 +299,5 @@ func RenderFromUnit(ctx context.Context, u *internal.Unit,
 	} else if u.Path != u.ModulePath {
 		innerPath = u.Path[len(u.ModulePath)+1:]
 	}
-	return docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, nil, bc)
+	return docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, nil, nil, bc)
 }
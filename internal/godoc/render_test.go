@@ -119,7 +119,7 @@ func TestRenderParts_SinceVersion(t *testing.T) {
 		// TF is a method.
 		"T.M": "v1.4.0",
 	}
-	parts, err := p.Render(ctx, "p", si, mi, nameToVersion, internal.BuildContext{})
+	parts, err := p.Render(ctx, "p", si, mi, nameToVersion, nil, internal.BuildContext{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -158,6 +158,86 @@ func TestRenderParts_SinceVersion(t *testing.T) {
 	}
 }
 
+func TestRender_AsmLinks(t *testing.T) {
+	dochtml.LoadTemplates(templateFS)
+	ctx := context.Background()
+	si := source.NewGitHubInfo("a.com/M", "", "abcde")
+	mi := &ModuleInfo{
+		ModulePath:      "a.com/M",
+		ResolvedVersion: "v1.2.3",
+		ModulePackages:  nil,
+	}
+
+	p, err := packageForDir(filepath.Join("testdata", "p"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.AddAsmFile("f_amd64.s", "p", []byte("TEXT ·F(SB),$0\n\tRET\n\nTEXT ·T·M(SB),$0\n\tRET\n"))
+	// Make sure the assembly files survive encoding.
+	bytes, err := p.Encode(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = DecodePackage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := p.Render(ctx, "p", si, mi, nil, nil, internal.BuildContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := parts.Body.String()
+	for _, want := range []string{
+		`<a class="Documentation-asmSource" href="a.com/M/blob/abcde/p/f_amd64.s#L1">f_amd64.s</a>`,
+		`<a class="Documentation-asmSource" href="a.com/M/blob/abcde/p/f_amd64.s#L4">f_amd64.s</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %s", want)
+		}
+	}
+}
+
+func TestRender_EmbeddedFiles(t *testing.T) {
+	dochtml.LoadTemplates(templateFS)
+	ctx := context.Background()
+	si := source.NewGitHubInfo("a.com/M", "", "abcde")
+	mi := &ModuleInfo{
+		ModulePath:      "a.com/M",
+		ResolvedVersion: "v1.2.3",
+		ModulePackages:  nil,
+	}
+
+	p, err := packageForDir(filepath.Join("testdata", "p"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// V is a package-level variable in testdata/p.
+	p.Embeds = []*Embed{{
+		Name:     "V",
+		Patterns: []string{"static/*"},
+		Files:    []string{"static/b.css", "static/a.html"},
+		NumFiles: 3,
+	}}
+	if got, want := p.EmbeddedFiles(), []string{"static/a.html", "static/b.css"}; !cmp.Equal(got, want) {
+		t.Errorf("EmbeddedFiles() = %v, want %v", got, want)
+	}
+	parts, err := p.Render(ctx, "p", si, mi, nil, nil, internal.BuildContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := parts.Body.String()
+	for _, want := range []string{
+		`<code>static/*</code>`,
+		`<a class="Documentation-embeddedFile" href="#embedded-file-0">static/a.html</a>`,
+		`<a class="Documentation-embeddedFile" href="#embedded-file-1">static/b.css</a>`,
+		`<li>and 1 more</li>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %s", want)
+		}
+	}
+}
+
 func TestCleanImports(t *testing.T) {
 	importPath := "a/b/c"
 	for _, test := range []struct {
@@ -191,3 +271,33 @@ func TestCleanImports(t *testing.T) {
 		}
 	}
 }
+
+func TestRender_Vulns(t *testing.T) {
+	dochtml.LoadTemplates(templateFS)
+	ctx := context.Background()
+	mi := &ModuleInfo{
+		ModulePath:      "a.com/M",
+		ResolvedVersion: "v1.2.3",
+		ModulePackages:  nil,
+	}
+
+	p, err := packageForDir(filepath.Join("testdata", "p"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbolVulns := map[string][]dochtml.SymbolVuln{
+		"T.M": {{ID: "GO-1990-01", URL: "/vuln/GO-1990-01", FixedVersion: "v1.4.2"}},
+	}
+	parts, err := p.Render(ctx, "p", nil, mi, nil, symbolVulns, internal.BuildContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := parts.Body.String()
+	want := `Affected by <a class="Documentation-vulnLink" href="/vuln/GO-1990-01">GO-1990-01</a> in this version, fixed in v1.4.2.`
+	if !strings.Contains(body, want) {
+		t.Errorf("body does not contain %s", want)
+	}
+	if n := strings.Count(body, "Documentation-vulnLink"); n != 1 {
+		t.Errorf("got %d vulnerability annotations, want 1", n)
+	}
+}
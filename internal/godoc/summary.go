@@ -0,0 +1,80 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godoc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// A SymbolSummary is a short description of a package or one of its symbols,
+// such as an editor shows when hovering over an identifier.
+type SymbolSummary struct {
+	// Decl is the declaration of the symbol, without its doc comment or
+	// function body, or the package clause for a package.
+	Decl string
+	// Synopsis is the first sentence of the doc comment.
+	Synopsis string
+	// Doc is the doc comment, as plain text.
+	Doc string
+}
+
+// SymbolSummaries returns the summaries of the package, for the empty symbol,
+// and of the named symbols, which are written as for RenderText. Symbols that
+// do not exist are omitted from the result.
+//
+// SymbolSummaries destroys p's AST; do not call any methods of p after it
+// returns.
+func (p *Package) SymbolSummaries(ctx context.Context, innerPath string, modInfo *ModuleInfo, symbols []string) (_ map[string]*SymbolSummary, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.SymbolSummaries(%q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath)
+
+	p.renderCalled = true
+	d, err := p.docPackage(innerPath, modInfo)
+	if err != nil {
+		return nil, err
+	}
+	summaries := map[string]*SymbolSummary{}
+	for _, name := range symbols {
+		if _, ok := summaries[name]; ok {
+			continue
+		}
+		if name == "" {
+			summaries[name] = &SymbolSummary{
+				Decl:     fmt.Sprintf("package %s // import %q", d.Name, d.ImportPath),
+				Synopsis: doc.Synopsis(d.Doc),
+				Doc:      d.Doc,
+			}
+			continue
+		}
+		decl, comment, _ := lookupSymbol(d, name)
+		if decl == nil {
+			continue
+		}
+		tr := &textRenderer{fset: p.Fset}
+		tr.decl(decl, "")
+		summaries[name] = &SymbolSummary{
+			Decl:     string(bytes.TrimSuffix(tr.buf.Bytes(), []byte("\n"))),
+			Synopsis: doc.Synopsis(comment),
+			Doc:      comment,
+		}
+	}
+	return summaries, nil
+}
+
+// SymbolSummariesFromUnit is a convenience function that first decodes the
+// source in the unit, which must exist, and then calls SymbolSummaries.
+func SymbolSummariesFromUnit(ctx context.Context, u *internal.Unit, symbols []string) (_ map[string]*SymbolSummary, err error) {
+	docPkg, err := DecodePackage(u.Documentation[0].Source)
+	if err != nil {
+		return nil, err
+	}
+	innerPath, modInfo := unitInnerPath(u)
+	return docPkg.SymbolSummaries(ctx, innerPath, modInfo, symbols)
+}
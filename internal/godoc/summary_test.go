@@ -0,0 +1,52 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godoc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSymbolSummaries(t *testing.T) {
+	p := textTestPackage(t)
+	got, err := p.SymbolSummaries(context.Background(), "p",
+		&ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0"},
+		[]string{"", "Max", "Green", "Client.Get", "Color", "Nope", "Max"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*SymbolSummary{
+		"": {
+			Decl:     `package p // import "example.com/m/p"`,
+			Synopsis: "Package p is for testing RenderText.",
+			Doc:      "Package p is for testing RenderText.\n\nIt has a second paragraph.\n",
+		},
+		"Max": {
+			Decl:     "const Max = 10",
+			Synopsis: "Max is the maximum.",
+			Doc:      "Max is the maximum.\n",
+		},
+		"Green": {
+			Decl:     "const (\n\tRed   Color = iota // red\n\tGreen              // green\n)",
+			Synopsis: "Colors.",
+			Doc:      "Colors.\n",
+		},
+		"Client.Get": {
+			Decl:     "func (c *Client) Get(path string) (string, error)",
+			Synopsis: "Get gets the path.",
+			Doc:      "Get gets the path.\n",
+		},
+		"Color": {
+			Decl:     "type Color int",
+			Synopsis: "Color is a color.",
+			Doc:      "Color is a color.\n",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
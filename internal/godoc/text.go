@@ -0,0 +1,291 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godoc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+const (
+	// textWidth is the width to which RenderText wraps doc comments.
+	textWidth = 80
+	// textIndent is the indentation of doc comments of symbols, and of the
+	// declarations associated with a type.
+	textIndent = "    "
+)
+
+// RenderText renders the documentation for the package as plain text, in the
+// style of the go doc command. If symbol is empty, it renders the package
+// comment and a summary of the exported declarations. Otherwise it renders
+// the declaration and doc comment of the symbol, which is the name of a
+// constant, variable, function or type, or a method written as "Type.Method".
+// It returns a NotFound error if there is no such symbol.
+//
+// Rendering destroys p's AST; do not call any methods of p after it returns.
+func (p *Package) RenderText(ctx context.Context, innerPath string, modInfo *ModuleInfo, symbol string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.RenderText(%q, %q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath, symbol)
+
+	p.renderCalled = true
+	d, err := p.docPackage(innerPath, modInfo)
+	if err != nil {
+		return nil, err
+	}
+	tr := &textRenderer{fset: p.Fset}
+	if symbol == "" {
+		tr.packageSummary(d)
+	} else if !tr.symbol(d, symbol) {
+		return nil, fmt.Errorf("%w: no symbol %q in package %s", derrors.NotFound, symbol, d.ImportPath)
+	}
+	return tr.buf.Bytes(), nil
+}
+
+// textPrinter prints declarations as gofmt does.
+var textPrinter = &printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+type textRenderer struct {
+	fset *token.FileSet
+	buf  bytes.Buffer
+}
+
+// packageSummary writes the package clause, the package comment and one
+// entry per exported declaration, as go doc does for a package.
+func (tr *textRenderer) packageSummary(d *doc.Package) {
+	fmt.Fprintf(&tr.buf, "package %s // import %q\n\n", d.Name, d.ImportPath)
+	if d.Doc != "" {
+		doc.ToText(&tr.buf, d.Doc, "", textIndent, textWidth)
+		tr.buf.WriteString("\n")
+	}
+	section := func(write func()) {
+		n := tr.buf.Len()
+		write()
+		if tr.buf.Len() > n {
+			tr.buf.WriteString("\n")
+		}
+	}
+	section(func() {
+		for _, v := range d.Consts {
+			tr.decl(v.Decl, "")
+		}
+	})
+	section(func() {
+		for _, v := range d.Vars {
+			tr.decl(v.Decl, "")
+		}
+	})
+	section(func() {
+		for _, f := range d.Funcs {
+			tr.decl(f.Decl, "")
+		}
+	})
+	section(func() {
+		for _, t := range d.Types {
+			tr.decl(elideTypeBody(t.Decl), "")
+			for _, v := range t.Consts {
+				tr.decl(v.Decl, textIndent)
+			}
+			for _, v := range t.Vars {
+				tr.decl(v.Decl, textIndent)
+			}
+			for _, f := range t.Funcs {
+				tr.decl(f.Decl, textIndent)
+			}
+		}
+	})
+	// Remove the blank line after the last section.
+	if b := tr.buf.Bytes(); bytes.HasSuffix(b, []byte("\n\n")) {
+		tr.buf.Truncate(len(b) - 1)
+	}
+}
+
+// symbol writes the documentation for the named symbol of d, and reports
+// whether it exists.
+func (tr *textRenderer) symbol(d *doc.Package, name string) bool {
+	decl, comment, t := lookupSymbol(d, name)
+	switch {
+	case t != nil:
+		tr.typ(t)
+	case decl != nil:
+		tr.documented(decl, comment)
+	default:
+		return false
+	}
+	return true
+}
+
+// lookupSymbol returns the declaration and doc comment of the named symbol
+// of d, which is the name of a constant, variable, function or type, or a
+// method written as "Type.Method". If the symbol is a type, it also returns
+// its doc.Type. It returns a nil declaration if there is no such symbol.
+func lookupSymbol(d *doc.Package, name string) (_ ast.Decl, comment string, _ *doc.Type) {
+	if typeName, method, ok := strings.Cut(name, "."); ok {
+		for _, t := range d.Types {
+			if t.Name != typeName {
+				continue
+			}
+			for _, m := range t.Methods {
+				if m.Name == method {
+					return m.Decl, m.Doc, nil
+				}
+			}
+		}
+		return nil, "", nil
+	}
+	for _, v := range append(d.Consts, d.Vars...) {
+		if contains(v.Names, name) {
+			return v.Decl, v.Doc, nil
+		}
+	}
+	for _, f := range d.Funcs {
+		if f.Name == name {
+			return f.Decl, f.Doc, nil
+		}
+	}
+	for _, t := range d.Types {
+		if t.Name == name {
+			return t.Decl, t.Doc, t
+		}
+		for _, v := range append(t.Consts, t.Vars...) {
+			if contains(v.Names, name) {
+				return v.Decl, v.Doc, nil
+			}
+		}
+		for _, f := range t.Funcs {
+			if f.Name == name {
+				return f.Decl, f.Doc, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// typ writes the full declaration and doc comment of t, followed by the
+// declarations associated with it.
+func (tr *textRenderer) typ(t *doc.Type) {
+	tr.documented(t.Decl, t.Doc)
+	n := tr.buf.Len()
+	for _, v := range t.Consts {
+		tr.decl(v.Decl, "")
+	}
+	for _, v := range t.Vars {
+		tr.decl(v.Decl, "")
+	}
+	for _, f := range t.Funcs {
+		tr.decl(f.Decl, "")
+	}
+	for _, m := range t.Methods {
+		tr.decl(m.Decl, "")
+	}
+	if tr.buf.Len() > n {
+		// Separate the associated declarations from the doc comment.
+		b := append([]byte("\n"), tr.buf.Bytes()[n:]...)
+		tr.buf.Truncate(n)
+		tr.buf.Write(b)
+	}
+}
+
+// documented writes decl followed by its indented doc comment.
+func (tr *textRenderer) documented(decl ast.Decl, comment string) {
+	tr.decl(decl, "")
+	if comment != "" {
+		doc.ToText(&tr.buf, comment, textIndent, textIndent+"\t", textWidth-len(textIndent))
+	}
+}
+
+// decl writes decl without its doc comment or function body, with each
+// line prefixed by indent.
+func (tr *textRenderer) decl(decl ast.Decl, indent string) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		fd := *d
+		fd.Doc = nil
+		fd.Body = nil
+		decl = &fd
+	case *ast.GenDecl:
+		gd := *d
+		gd.Doc = nil
+		decl = &gd
+	}
+	var b bytes.Buffer
+	if err := textPrinter.Fprint(&b, tr.fset, decl); err != nil {
+		fmt.Fprintf(&b, "<%v>", err)
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		tr.buf.WriteString(indent)
+		tr.buf.WriteString(line)
+		tr.buf.WriteString("\n")
+	}
+}
+
+// elideTypeBody returns a copy of decl in which the fields of structs and the
+// methods of interfaces are replaced by "...", as in go doc's package summary.
+func elideTypeBody(decl *ast.GenDecl) *ast.GenDecl {
+	gd := *decl
+	gd.Specs = nil
+	for _, s := range decl.Specs {
+		ts, ok := s.(*ast.TypeSpec)
+		if !ok {
+			gd.Specs = append(gd.Specs, s)
+			continue
+		}
+		c := *ts
+		switch ts.Type.(type) {
+		case *ast.StructType:
+			c.Type = &ast.Ident{Name: "struct{ ... }"}
+		case *ast.InterfaceType:
+			c.Type = &ast.Ident{Name: "interface{ ... }"}
+		}
+		c.Doc = nil
+		c.Comment = nil
+		gd.Specs = append(gd.Specs, &c)
+	}
+	return &gd
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTextFromUnit is a convenience function that first decodes the source
+// in the unit, which must exist, and then calls RenderText.
+func RenderTextFromUnit(ctx context.Context, u *internal.Unit, symbol string) (_ []byte, err error) {
+	docPkg, err := DecodePackage(u.Documentation[0].Source)
+	if err != nil {
+		return nil, err
+	}
+	innerPath, modInfo := unitInnerPath(u)
+	return docPkg.RenderText(ctx, innerPath, modInfo, symbol)
+}
+
+// unitInnerPath returns the path of u relative to its module, and the
+// ModuleInfo of its module.
+func unitInnerPath(u *internal.Unit) (string, *ModuleInfo) {
+	modInfo := &ModuleInfo{
+		ModulePath:      u.ModulePath,
+		ResolvedVersion: u.Version,
+	}
+	var innerPath string
+	if u.ModulePath == stdlib.ModulePath {
+		innerPath = u.Path
+	} else if u.Path != u.ModulePath {
+		innerPath = u.Path[len(u.ModulePath)+1:]
+	}
+	return innerPath, modInfo
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godoc
+
+import (
+	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+const textTestSource = `// Package p is for testing RenderText.
+//
+// It has a second paragraph.
+package p
+
+// Max is the maximum.
+const Max = 10
+
+// Colors.
+const (
+	Red   Color = iota // red
+	Green              // green
+)
+
+// DefaultClient is the default client.
+var DefaultClient = &Client{}
+
+// Do does it.
+func Do(n int) error { return nil }
+
+func unexported() {}
+
+// A Client talks.
+type Client struct {
+	// Name is the name.
+	Name string
+	secret int
+}
+
+// NewClient returns a client.
+func NewClient() *Client { return nil }
+
+// Get gets the path.
+func (c *Client) Get(path string) (string, error) { return "", nil }
+
+func (c *Client) hidden() {}
+
+// Color is a color.
+type Color int
+
+// Getter gets.
+type Getter interface {
+	Get(string) (string, error)
+}
+`
+
+func TestRenderText(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		symbol string
+		want   string
+	}{
+		{
+			symbol: "",
+			want: `package p // import "example.com/m/p"
+
+Package p is for testing RenderText.
+
+It has a second paragraph.
+
+const Max = 10
+
+var DefaultClient = &Client{}
+
+func Do(n int) error
+
+type Client struct{ ... }
+    func NewClient() *Client
+type Color int
+    const (
+    	Red   Color = iota // red
+    	Green              // green
+    )
+type Getter interface{ ... }
+`,
+		},
+		{
+			symbol: "Do",
+			want: `func Do(n int) error
+    Do does it.
+`,
+		},
+		{
+			symbol: "Green",
+			want: `const (
+	Red   Color = iota // red
+	Green              // green
+)
+    Colors.
+`,
+		},
+		{
+			symbol: "Client",
+			want: `type Client struct {
+	// Name is the name.
+	Name string
+	// contains filtered or unexported fields
+}
+    A Client talks.
+
+func NewClient() *Client
+func (c *Client) Get(path string) (string, error)
+`,
+		},
+		{
+			symbol: "Client.Get",
+			want: `func (c *Client) Get(path string) (string, error)
+    Get gets the path.
+`,
+		},
+	} {
+		t.Run(test.symbol, func(t *testing.T) {
+			p := textTestPackage(t)
+			got, err := p.RenderText(ctx, "p", &ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0"}, test.symbol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, string(got)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, symbol := range []string{"unexported", "Client.hidden", "Nope", "Nope.Get"} {
+		p := textTestPackage(t)
+		_, err := p.RenderText(ctx, "p", &ModuleInfo{ModulePath: "example.com/m", ResolvedVersion: "v1.0.0"}, symbol)
+		if !errors.Is(err, derrors.NotFound) {
+			t.Errorf("%s: got %v, want NotFound", symbol, err)
+		}
+	}
+}
+
+func textTestPackage(t *testing.T) *Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", textTestSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPackage(fset, nil)
+	p.AddFile(f, true)
+	return p
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+// Kinds of homepage sections.
+const (
+	// HomepagePinned is a section of pinned modules.
+	HomepagePinned = "pinned"
+	// HomepageCategory is a curated category of packages.
+	HomepageCategory = "category"
+	// HomepageRecent is a section of the most recently updated packages
+	// under a path prefix.
+	HomepageRecent = "recent"
+)
+
+// HomepageSectionKinds are the valid values of HomepageSection.Kind.
+var HomepageSectionKinds = []string{HomepagePinned, HomepageCategory, HomepageRecent}
+
+// A HomepageSection is a curated section of the homepage. Private instances
+// of pkgsite can use them to replace the public homepage content with links
+// to their own modules and packages.
+type HomepageSection struct {
+	ID int
+	// Position orders the sections on the homepage, in increasing order.
+	Position int
+	// Kind is one of HomepageSectionKinds.
+	Kind        string
+	Title       string
+	Description string
+	// Paths are the module or package paths listed in the section. For
+	// HomepageRecent sections, they are computed from PathPrefix.
+	Paths []string
+	// PathPrefix is the prefix of the package paths listed in a
+	// HomepageRecent section.
+	PathPrefix string
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package imageproxy serves the external images referenced by READMEs from
+// this site, and caches them.
+//
+// Proxying images avoids mixed-content warnings for images served over
+// HTTP, keeps third parties from tracking the visitors of a page, and keeps
+// images visible for a while after their host goes down.
+//
+// The URLs of proxied images are signed, so that the proxy only fetches
+// images that appear in pages of this site.
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// PathPrefix is the path at which the proxy is served.
+const PathPrefix = "/ipx/"
+
+const (
+	// fetchTimeout is the maximum time to fetch one image.
+	fetchTimeout = 10 * time.Second
+
+	// maxImageSize is the maximum size of an image. Larger images are not
+	// served.
+	maxImageSize = 5 * 1024 * 1024
+
+	// maxCacheSize is the maximum total size of the cached images.
+	maxCacheSize = 256 * 1024 * 1024
+
+	// maxCachedImages is the maximum number of cached images and failures.
+	maxCachedImages = 10000
+
+	// cacheTTL is how long an image is served from the cache before it is
+	// fetched again. If fetching it fails, the cached image is still served.
+	cacheTTL = 24 * time.Hour
+
+	// failureTTL is how long a failure to fetch an image is remembered.
+	failureTTL = 10 * time.Minute
+
+	// maxAge is the max-age of the responses, for browser caches.
+	maxAge = 24 * time.Hour
+)
+
+// contentTypes are the content types of the images that are served.
+var contentTypes = map[string]bool{
+	"image/bmp":                true,
+	"image/gif":                true,
+	"image/jpeg":               true,
+	"image/png":                true,
+	"image/svg+xml":            true,
+	"image/vnd.microsoft.icon": true,
+	"image/webp":               true,
+	"image/x-icon":             true,
+}
+
+// A Proxy fetches, caches and serves images. It is safe for concurrent use.
+type Proxy struct {
+	key    []byte
+	client *http.Client
+	cache  *lru.Cache
+	// cacheSize is the total size of the images in cache.
+	cacheSize int64
+}
+
+// image is a fetched image.
+type image struct {
+	contentType string
+	data        []byte
+}
+
+// cacheEntry is the result of fetching an image.
+type cacheEntry struct {
+	img     *image
+	err     error
+	expires time.Time
+}
+
+// New returns a Proxy that signs URLs with key and fetches images with
+// client, which should be created with NewClient.
+func New(key []byte, client *http.Client) (*Proxy, error) {
+	if len(key) < 16 {
+		return nil, errors.New("key must be at least 16 bytes")
+	}
+	p := &Proxy{key: key, client: client}
+	cache, err := lru.NewWithEvict(maxCachedImages, func(_, v interface{}) {
+		if img := v.(*cacheEntry).img; img != nil {
+			atomic.AddInt64(&p.cacheSize, -int64(len(img.data)))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.cache = cache
+	return p, nil
+}
+
+// NewClient returns an HTTP client for fetching images. It only connects to
+// public IP addresses on the standard HTTP ports, so that the proxy cannot be
+// used to reach internal services.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{Timeout: fetchTimeout, Control: checkAddress}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return &http.Client{Transport: t}
+}
+
+// checkAddress is a net.Dialer.Control function that rejects connections to
+// addresses that are not public, or to ports other than 80 and 443. It is
+// called after name resolution, so it cannot be bypassed with DNS.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if port != "80" && port != "443" {
+		return fmt.Errorf("port %s is not allowed", port)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
+
+// URL returns the path at which the proxy serves the image at imageURL, or
+// the empty string if imageURL is not an absolute http or https URL.
+func (p *Proxy) URL(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return PathPrefix + p.sign(imageURL) + "/" + base64.RawURLEncoding.EncodeToString([]byte(imageURL))
+}
+
+// sign returns the signature of imageURL.
+func (p *Proxy) sign(imageURL string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(imageURL))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ServeHTTP serves the image whose URL is in the path of r, which must have
+// been returned by p.URL.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	sig, enc, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	b, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(p.sign(string(b)))) {
+		http.NotFound(w, r)
+		return
+	}
+	img, err := p.get(r.Context(), string(b))
+	if err != nil {
+		log.Infof(r.Context(), "imageproxy: %v", err)
+		http.Error(w, "image not available", http.StatusBadGateway)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", img.contentType)
+	h.Set("Content-Length", strconv.Itoa(len(img.data)))
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	// SVG images can contain scripts, which must not run if an image is
+	// opened directly.
+	h.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	h.Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write(img.data)
+}
+
+// get returns the image at imageURL, from the cache if possible.
+func (p *Proxy) get(ctx context.Context, imageURL string) (*image, error) {
+	var stale *image
+	if v, ok := p.cache.Get(imageURL); ok {
+		e := v.(*cacheEntry)
+		if time.Now().Before(e.expires) {
+			return e.img, e.err
+		}
+		stale = e.img
+	}
+	img, err := p.fetch(ctx, imageURL)
+	if err != nil {
+		if stale != nil {
+			// Keep serving the image while its host is down.
+			log.Infof(ctx, "imageproxy: serving stale image: %v", err)
+			p.add(imageURL, &cacheEntry{img: stale, expires: time.Now().Add(failureTTL)})
+			return stale, nil
+		}
+		// Don't remember failures caused by the caller.
+		if ctx.Err() == nil {
+			p.add(imageURL, &cacheEntry{err: err, expires: time.Now().Add(failureTTL)})
+		}
+		return nil, err
+	}
+	p.add(imageURL, &cacheEntry{img: img, expires: time.Now().Add(cacheTTL)})
+	return img, nil
+}
+
+// add adds e to the cache, and evicts the least recently used entries until
+// the cached images fit in maxCacheSize.
+func (p *Proxy) add(imageURL string, e *cacheEntry) {
+	// Remove the old entry first, so that its size is subtracted.
+	p.cache.Remove(imageURL)
+	if e.img != nil {
+		atomic.AddInt64(&p.cacheSize, int64(len(e.img.data)))
+	}
+	p.cache.Add(imageURL, e)
+	for atomic.LoadInt64(&p.cacheSize) > maxCacheSize && p.cache.Len() > 0 {
+		p.cache.RemoveOldest()
+	}
+}
+
+// fetch fetches the image at imageURL, and checks that it is one.
+func (p *Proxy) fetch(ctx context.Context, imageURL string) (_ *image, err error) {
+	defer derrors.Wrap(&err, "fetch(%q)", imageURL)
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	ct, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if !contentTypes[ct] {
+		return nil, fmt.Errorf("unsupported content type %q", ct)
+	}
+	if resp.ContentLength > maxImageSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+	if err := checkContent(ct, data); err != nil {
+		return nil, err
+	}
+	return &image{contentType: ct, data: data}, nil
+}
+
+// checkContent checks that data looks like an image of type ct, so that other
+// content is not served as an image.
+func checkContent(ct string, data []byte) error {
+	if ct == "image/svg+xml" {
+		if !bytes.Contains(data, []byte("<svg")) {
+			return errors.New("content is not an SVG image")
+		}
+		return nil
+	}
+	if got := http.DetectContentType(data); !strings.HasPrefix(got, "image/") {
+		return fmt.Errorf("content of type %s is %s", ct, got)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package imageproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const png = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+func TestProxy(t *testing.T) {
+	hits := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, png)
+		case "/badge.svg":
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			io.WriteString(w, `<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html></html>")
+		case "/fake.png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, "<html><script></script></html>")
+		case "/large.png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, png+strings.Repeat("x", maxImageSize))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p, err := New([]byte("0123456789abcdef"), ts.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	for _, test := range []struct {
+		path     string
+		wantCode int
+		wantType string
+	}{
+		{"/logo.png", http.StatusOK, "image/png"},
+		{"/badge.svg", http.StatusOK, "image/svg+xml"},
+		{"/page.html", http.StatusBadGateway, ""},
+		{"/fake.png", http.StatusBadGateway, ""},
+		{"/large.png", http.StatusBadGateway, ""},
+		{"/missing.png", http.StatusBadGateway, ""},
+	} {
+		w := get(p.URL(ts.URL + test.path))
+		if w.Code != test.wantCode {
+			t.Errorf("%s: got status %d, want %d", test.path, w.Code, test.wantCode)
+			continue
+		}
+		if test.wantCode != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != test.wantType {
+			t.Errorf("%s: got content type %q, want %q", test.path, got, test.wantType)
+		}
+		if got := w.Header().Get("Content-Security-Policy"); !strings.Contains(got, "sandbox") {
+			t.Errorf("%s: got Content-Security-Policy %q", test.path, got)
+		}
+	}
+
+	// Images and failures are cached.
+	get(p.URL(ts.URL + "/logo.png"))
+	get(p.URL(ts.URL + "/missing.png"))
+	if hits["/logo.png"] != 1 || hits["/missing.png"] != 1 {
+		t.Errorf("got hits %v, want one for each image", hits)
+	}
+
+	// Stale images are served if the host is down.
+	u := ts.URL + "/logo.png"
+	v, _ := p.cache.Get(u)
+	p.add(u, &cacheEntry{img: v.(*cacheEntry).img, expires: time.Now().Add(-time.Minute)})
+	ts.Close()
+	if w := get(p.URL(u)); w.Code != http.StatusOK || w.Body.String() != png {
+		t.Errorf("stale image: got status %d, body %q", w.Code, w.Body)
+	}
+
+	// Requests must be signed.
+	path := p.URL(u)
+	if w := get(strings.Replace(path, PathPrefix, PathPrefix+"x", 1)); w.Code != http.StatusNotFound {
+		t.Errorf("bad signature: got status %d, want 404", w.Code)
+	}
+	if w := get(PathPrefix + "abc"); w.Code != http.StatusNotFound {
+		t.Errorf("malformed path: got status %d, want 404", w.Code)
+	}
+}
+
+func TestURL(t *testing.T) {
+	p, err := New([]byte("0123456789abcdef"), http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{"/logo.png", "data:image/png;base64,AAAA", "javascript:alert(1)", "https://"} {
+		if got := p.URL(u); got != "" {
+			t.Errorf("URL(%q) = %q, want empty", u, got)
+		}
+	}
+	if got := p.URL("https://example.com/logo.png"); !strings.HasPrefix(got, PathPrefix) {
+		t.Errorf("got %q, want a path under %s", got, PathPrefix)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	for _, test := range []struct {
+		address string
+		ok      bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2001:4860:4860::8888]:80", true},
+		{"8.8.8.8:8080", false},
+		{"127.0.0.1:80", false},
+		{"10.1.2.3:443", false},
+		{"169.254.169.254:80", false},
+		{"[::1]:443", false},
+		{"0.0.0.0:80", false},
+	} {
+		err := checkAddress("tcp", test.address, nil)
+		if got := err == nil; got != test.ok {
+			t.Errorf("checkAddress(%q) = %v, want ok=%t", test.address, err, test.ok)
+		}
+	}
+}
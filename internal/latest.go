@@ -6,9 +6,11 @@ package internal
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 )
 
@@ -23,6 +25,8 @@ type LatestModuleVersions struct {
 	GoModFile          *modfile.File // of raw
 	Deprecated         bool
 	deprecationComment string
+	recommendedVersion string
+	movedTo            string
 }
 
 func NewLatestModuleVersions(modulePath, raw, cooked, good string, modBytes []byte) (*LatestModuleVersions, error) {
@@ -40,6 +44,8 @@ func NewLatestModuleVersions(modulePath, raw, cooked, good string, modBytes []by
 		GoModFile:          modFile,
 		Deprecated:         dep,
 		deprecationComment: comment,
+		recommendedVersion: recommendedVersion(modulePath, modFile),
+		movedTo:            deprecationMovedTo(modulePath, comment),
 	}, nil
 }
 
@@ -62,10 +68,99 @@ func isDeprecated(mf *modfile.File) (bool, string) {
 	return false, ""
 }
 
+// movedToRx matches the phrases of a deprecation comment that introduce the
+// replacement of a module, followed by the word after them.
+var movedToRx = regexp.MustCompile(`(?i)\b(?:use|moved to|replaced by|superseded by|in favou?r of|migrate to)\s+(\S+)`)
+
+// deprecationMovedTo returns the module path that the deprecation comment of
+// modulePath names as its replacement, or the empty string if there is none.
+// The replacement must follow an explicit phrase such as "use" or "moved to",
+// and be a valid module path other than modulePath, ignoring quotes,
+// punctuation and URL schemes, as in
+//
+//	// Deprecated: use `example.com/new` instead.
+//
+// Links to other pages, such as "see https://github.com/owner/repo/issues/1",
+// are ignored. The returned path may still not be a module; callers should
+// check that it is before relying on it.
+func deprecationMovedTo(modulePath, comment string) string {
+	for _, m := range movedToRx.FindAllStringSubmatch(comment, -1) {
+		w := strings.Trim(m[1], "`'\"()[]<>,.;:!?")
+		w = strings.TrimPrefix(w, "https://")
+		w = strings.TrimPrefix(w, "http://")
+		w, _, _ = strings.Cut(w, "@")
+		w = strings.TrimSuffix(w, "/")
+		if w == modulePath || !strings.Contains(w, "/") {
+			continue
+		}
+		if module.CheckPath(w) == nil {
+			return w
+		}
+	}
+	return ""
+}
+
+// recommendedVersion returns the version that the go.mod file recommends
+// instead of the latest version, if any. Module owners recommend a version
+// with a "Recommended:" comment before or next to the module declaration,
+// such as
+//
+//	// Recommended: v1.4.2
+//	module example.com/m
+//
+// The version is ignored if it is not a valid version of the module, or if the
+// go.mod file retracts it.
+func recommendedVersion(modulePath string, mf *modfile.File) string {
+	const prefix = "Recommended:"
+
+	if mf.Module == nil {
+		return ""
+	}
+	for _, comment := range append(mf.Module.Syntax.Before, mf.Module.Syntax.Suffix...) {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Token, "//"))
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		v := strings.TrimSpace(text[len(prefix):])
+		if semver.Canonical(v) != v {
+			return ""
+		}
+		if _, pathMajor, ok := module.SplitPathVersion(modulePath); !ok || module.CheckPathMajor(v, pathMajor) != nil {
+			return ""
+		}
+		if r, _ := isRetracted(mf, v); r {
+			return ""
+		}
+		return v
+	}
+	return ""
+}
+
+// RecommendedVersion returns the version that the owners of the module
+// recommend instead of the latest version, or the empty string if they don't
+// recommend one.
+func (li *LatestModuleVersions) RecommendedVersion() string {
+	return li.recommendedVersion
+}
+
+// DeprecationComment returns the text of the deprecation comment of the
+// module, or the empty string if it is not deprecated.
+func (li *LatestModuleVersions) DeprecationComment() string {
+	return li.deprecationComment
+}
+
+// MovedTo returns the module path that the deprecation comment of the module
+// names as its replacement, or the empty string if the module is not
+// deprecated or the comment doesn't name one.
+func (li *LatestModuleVersions) MovedTo() string {
+	return li.movedTo
+}
+
 // PopulateModuleInfo uses the LatestModuleVersions to populate fields of the given module.
 func (li *LatestModuleVersions) PopulateModuleInfo(mi *ModuleInfo) {
 	mi.Deprecated = li.Deprecated
 	mi.DeprecationComment = li.deprecationComment
+	mi.RecommendedVersion = li.recommendedVersion
 	mi.Retracted, mi.RetractionRationale = isRetracted(li.GoModFile, mi.Version)
 }
 
@@ -91,3 +91,54 @@ func TestIsRetracted(t *testing.T) {
 		}
 	}
 }
+
+func TestRecommendedVersion(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		modulePath string
+		file       string
+		want       string
+	}{
+		{"no comment", "m", "module m", ""},
+		{"comment", "m", "// Recommended: v1.4.2\nmodule m", "v1.4.2"},
+		{"suffix", "m", "module m // Recommended: v1.4.2", "v1.4.2"},
+		{"invalid", "m", "// Recommended: latest\nmodule m", ""},
+		{"not canonical", "m", "// Recommended: v1.4\nmodule m", ""},
+		{"wrong major", "m", "// Recommended: v2.0.0\nmodule m", ""},
+		{"major", "m/v2", "// Recommended: v2.0.0\nmodule m/v2", "v2.0.0"},
+		{"retracted", "m", "// Recommended: v1.4.2\nmodule m\nretract v1.4.2", ""},
+	} {
+		mf, err := modfile.Parse("test", []byte(test.file), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := recommendedVersion(test.modulePath, mf); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDeprecationMovedTo(t *testing.T) {
+	const modulePath = "example.com/old"
+	for _, test := range []struct {
+		comment string
+		want    string
+	}{
+		{"", ""},
+		{"no longer maintained.", ""},
+		{"use example.com/new instead.", "example.com/new"},
+		{"Use `example.com/new/v2`.", "example.com/new/v2"},
+		{"moved to https://github.com/owner/new.", "github.com/owner/new"},
+		{"use example.com/new@v1.2.0", "example.com/new"},
+		{"example.com/old is replaced by example.com/new", "example.com/new"},
+		{"see the README/CHANGELOG", ""},
+		{"see https://github.com/owner/repo/issues/12", ""},
+		{"see https://go.dev/blog/module-compatibility for details.", ""},
+		{"read the announcement at https://blog.example.com/2022/01/moving.", ""},
+		{"github.com/owner/repo is no longer maintained.", ""},
+	} {
+		if got := deprecationMovedTo(modulePath, test.comment); got != test.want {
+			t.Errorf("deprecationMovedTo(%q, %q) = %q, want %q", modulePath, test.comment, got, test.want)
+		}
+	}
+}
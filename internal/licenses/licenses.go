@@ -49,6 +49,10 @@ const (
 
 	// unknownLicenseType is for text in a license file that's not recognized.
 	unknownLicenseType = "UNKNOWN"
+
+	// ProprietaryLicenseType is for a license file that an operator has
+	// reviewed and found not to contain an open source license.
+	ProprietaryLicenseType = "Proprietary"
 )
 
 // maxLicenseSize is the maximum allowable size (in bytes) for a license file.
@@ -67,6 +71,16 @@ type Metadata struct {
 	Coverage licensecheck.Coverage
 }
 
+// IsUnknown reports whether the license file did not match any known license.
+func (m *Metadata) IsUnknown() bool {
+	for _, t := range m.Types {
+		if t == unknownLicenseType {
+			return true
+		}
+	}
+	return false
+}
+
 // A License is a classified license file path and its contents.
 type License struct {
 	*Metadata
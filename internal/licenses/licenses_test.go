@@ -332,6 +332,7 @@ func TestRedistributable(t *testing.T) {
 	}{
 		{nil, false},
 		{[]string{unknownLicenseType}, false},
+		{[]string{ProprietaryLicenseType}, false},
 		{[]string{"MIT"}, true},
 		{[]string{"MIT", "Unlicense"}, true},
 		{[]string{"MIT", "JSON"}, true},
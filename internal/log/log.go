@@ -56,6 +56,12 @@ func NewContextWithTraceID(ctx context.Context, traceID string) context.Context
 	return context.WithValue(ctx, traceIDKey{}, traceID)
 }
 
+// TraceID returns the trace ID of ctx, or the empty string if there is none.
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
 // NewContextWithLabel creates anew context from ctx that adds a label that will
 // appear in the log entry.
 func NewContextWithLabel(ctx context.Context, key, value string) context.Context {
@@ -0,0 +1,266 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lsif writes code indexes in the Language Server Index Format, as
+// described at https://microsoft.github.io/language-server-protocol/specifications/lsif/0.4.0/specification/.
+//
+// An index is a sequence of JSON objects, one per line, each of which is a
+// vertex or an edge of a graph. Code intelligence platforms use it to answer
+// go-to-definition, find-references and hover requests without compiling the
+// code.
+package lsif
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// Version is the version of LSIF that Write produces.
+const Version = "0.4.3"
+
+// monikerScheme is the scheme of the monikers of symbols, which identify
+// them across indexes. It is the one used by other Go indexers.
+const monikerScheme = "gomod"
+
+// A Package is a package to include in an index.
+type Package struct {
+	// Path is the import path of the package.
+	Path string
+	// Dir is the directory of the package relative to the module root.
+	Dir   string
+	Index *internal.CodeIndex
+}
+
+// Write writes an index of the packages of the version of the module to w.
+// The URIs of its documents have the form
+// file:///<modulePath>@<version>/<dir>/<file>. Definitions in the packages
+// are given export monikers, and references to declarations outside of them
+// are given import monikers.
+func Write(w io.Writer, modulePath, version string, pkgs []*Package) error {
+	bw := bufio.NewWriter(w)
+	e := &emitter{
+		enc:     json.NewEncoder(bw),
+		symbols: map[symbolKey]*symbol{},
+	}
+	e.write(modulePath, version, pkgs)
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+type symbolKey struct {
+	pkg, name string
+}
+
+// A symbol is a package-level declaration with definitions or references in
+// the index.
+type symbol struct {
+	key        symbolKey
+	resultSet  int
+	defs, refs []location
+	hover      string
+	defined    bool
+}
+
+// A location is a range in a document.
+type location struct {
+	document, rng int
+}
+
+type emitter struct {
+	enc     *json.Encoder
+	err     error
+	lastID  int
+	symbols map[symbolKey]*symbol
+	order   []*symbol
+}
+
+func (e *emitter) write(modulePath, version string, pkgs []*Package) {
+	root := "file:///" + modulePath + "@" + version
+	e.vertex("metaData", map[string]interface{}{
+		"version":          Version,
+		"projectRoot":      root,
+		"positionEncoding": "utf-16",
+		"toolInfo":         map[string]string{"name": "pkgsite"},
+	})
+	project := e.vertex("project", map[string]interface{}{"kind": "go"})
+	var documents []int
+	for _, p := range pkgs {
+		if p.Index == nil {
+			continue
+		}
+		for _, f := range p.Index.Files {
+			uri := root + "/" + strings.TrimPrefix(p.Dir+"/"+f.Name, "/")
+			documents = append(documents, e.document(p.Path, uri, f))
+		}
+	}
+	if len(documents) > 0 {
+		e.edge("contains", project, map[string]interface{}{"inVs": documents})
+	}
+
+	packageInfo := e.vertex("packageInformation", map[string]interface{}{
+		"name":    modulePath,
+		"manager": monikerScheme,
+		"version": version,
+	})
+	for _, s := range e.order {
+		e.results(s)
+		kind := "import"
+		if s.defined {
+			kind = "export"
+		}
+		moniker := e.vertex("moniker", map[string]interface{}{
+			"scheme":     monikerScheme,
+			"identifier": s.key.pkg + ":" + s.key.name,
+			"kind":       kind,
+		})
+		e.edge("moniker", s.resultSet, map[string]interface{}{"inV": moniker})
+		if kind == "export" {
+			e.edge("packageInformation", moniker, map[string]interface{}{"inV": packageInfo})
+		}
+	}
+}
+
+// document writes the document with the given URI for the file f of the
+// package pkg, and its ranges.
+func (e *emitter) document(pkg, uri string, f *internal.CodeIndexFile) int {
+	doc := e.vertex("document", map[string]interface{}{"uri": uri, "languageId": "go"})
+	var ranges []int
+	for _, d := range f.Defs {
+		s := e.symbol(symbolKey{pkg, d.Name})
+		rng := e.rangeVertex(d.Range, s)
+		ranges = append(ranges, rng)
+		s.defs = append(s.defs, location{doc, rng})
+		s.defined = true
+		if s.hover == "" {
+			s.hover = hover(d)
+		}
+	}
+	for _, r := range f.Refs {
+		s := e.symbol(symbolKey{r.Package, r.Name})
+		rng := e.rangeVertex(r.Range, s)
+		ranges = append(ranges, rng)
+		s.refs = append(s.refs, location{doc, rng})
+	}
+	if len(ranges) > 0 {
+		e.edge("contains", doc, map[string]interface{}{"inVs": ranges})
+	}
+	return doc
+}
+
+// symbol returns the symbol for key, writing its result set if it is new.
+func (e *emitter) symbol(key symbolKey) *symbol {
+	s := e.symbols[key]
+	if s == nil {
+		s = &symbol{key: key, resultSet: e.vertex("resultSet", nil)}
+		e.symbols[key] = s
+		e.order = append(e.order, s)
+	}
+	return s
+}
+
+// rangeVertex writes a range vertex for r, linked to the result set of s.
+func (e *emitter) rangeVertex(r internal.CodeRange, s *symbol) int {
+	id := e.vertex("range", map[string]interface{}{
+		"start": position{r[0], r[1]},
+		"end":   position{r[2], r[3]},
+	})
+	e.edge("next", id, map[string]interface{}{"inV": s.resultSet})
+	return id
+}
+
+// results writes the definition, hover and reference results of s.
+func (e *emitter) results(s *symbol) {
+	if len(s.defs) > 0 {
+		def := e.vertex("definitionResult", nil)
+		e.edge("textDocument/definition", s.resultSet, map[string]interface{}{"inV": def})
+		e.items(def, s.defs, "")
+	}
+	if s.hover != "" {
+		h := e.vertex("hoverResult", map[string]interface{}{
+			"result": map[string]interface{}{
+				"contents": map[string]string{"kind": "markdown", "value": s.hover},
+			},
+		})
+		e.edge("textDocument/hover", s.resultSet, map[string]interface{}{"inV": h})
+	}
+	ref := e.vertex("referenceResult", nil)
+	e.edge("textDocument/references", s.resultSet, map[string]interface{}{"inV": ref})
+	e.items(ref, s.defs, "definitions")
+	e.items(ref, s.refs, "references")
+}
+
+// items writes one item edge from outV for each document of locs. The
+// property, if not empty, distinguishes definitions from references in a
+// reference result.
+func (e *emitter) items(outV int, locs []location, property string) {
+	byDoc := map[int][]int{}
+	var docs []int
+	for _, l := range locs {
+		if _, ok := byDoc[l.document]; !ok {
+			docs = append(docs, l.document)
+		}
+		byDoc[l.document] = append(byDoc[l.document], l.rng)
+	}
+	sort.Ints(docs)
+	for _, d := range docs {
+		props := map[string]interface{}{"inVs": byDoc[d], "document": d}
+		if property != "" {
+			props["property"] = property
+		}
+		e.edge("item", outV, props)
+	}
+}
+
+// hover returns the Markdown hover text for the definition d.
+func hover(d *internal.CodeIndexDef) string {
+	var b strings.Builder
+	b.WriteString("```go\n")
+	b.WriteString(d.Decl)
+	b.WriteString("\n```")
+	if d.Doc != "" {
+		b.WriteString("\n\n---\n\n")
+		b.WriteString(d.Doc)
+	}
+	return b.String()
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// vertex writes a vertex with the given label and properties, and returns its
+// ID.
+func (e *emitter) vertex(label string, props map[string]interface{}) int {
+	return e.element("vertex", label, props)
+}
+
+// edge writes an edge with the given label from outV, with the given
+// properties, which include its in-vertices.
+func (e *emitter) edge(label string, outV int, props map[string]interface{}) {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props["outV"] = outV
+	e.element("edge", label, props)
+}
+
+func (e *emitter) element(typ, label string, props map[string]interface{}) int {
+	e.lastID++
+	m := map[string]interface{}{"id": e.lastID, "type": typ, "label": label}
+	for k, v := range props {
+		m[k] = v
+	}
+	if e.err == nil {
+		e.err = e.enc.Encode(m)
+	}
+	return e.lastID
+}
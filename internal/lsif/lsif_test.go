@@ -0,0 +1,110 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestWrite(t *testing.T) {
+	pkgs := []*Package{{
+		Path: "example.com/m/p",
+		Dir:  "p",
+		Index: &internal.CodeIndex{Files: []*internal.CodeIndexFile{{
+			Name: "p.go",
+			Defs: []*internal.CodeIndexDef{{Name: "F", Range: internal.CodeRange{2, 5, 2, 6}, Decl: "func F()", Doc: "F does nothing.\n"}},
+			Refs: []*internal.CodeIndexRef{
+				{Package: "fmt", Name: "Println", Range: internal.CodeRange{3, 5, 3, 12}},
+				{Package: "example.com/m/p", Name: "F", Range: internal.CodeRange{4, 1, 4, 2}},
+			},
+		}}},
+	}}
+	var buf bytes.Buffer
+	if err := Write(&buf, "example.com/m", "v1.0.0", pkgs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decode the elements, and check that every edge refers to earlier
+	// vertices.
+	var elems []map[string]interface{}
+	byID := map[int]map[string]interface{}{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e map[string]interface{}
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		elems = append(elems, e)
+		id := int(e["id"].(float64))
+		if byID[id] != nil {
+			t.Fatalf("duplicate id %d", id)
+		}
+		byID[id] = e
+		if e["type"] != "edge" {
+			continue
+		}
+		ids := []interface{}{e["outV"], e["inV"], e["document"]}
+		if vs, ok := e["inVs"].([]interface{}); ok {
+			ids = append(ids, vs...)
+		}
+		for _, v := range ids {
+			if v == nil {
+				continue
+			}
+			if w := byID[int(v.(float64))]; w == nil || w["type"] != "vertex" {
+				t.Fatalf("edge %v refers to %v, which is not an earlier vertex", e, v)
+			}
+		}
+	}
+	if got := elems[0]["label"]; got != "metaData" {
+		t.Fatalf("first element is %q, want metaData", got)
+	}
+
+	// next returns the vertex that the edge with the given label from v
+	// leads to.
+	next := func(v map[string]interface{}, label string) map[string]interface{} {
+		for _, e := range elems {
+			if e["label"] == label && e["outV"] == v["id"] {
+				return byID[int(e["inV"].(float64))]
+			}
+		}
+		t.Fatalf("no %s edge from %v", label, v)
+		return nil
+	}
+	var (
+		uris     []string
+		monikers = map[string]string{}
+		hovers   []string
+	)
+	for _, e := range elems {
+		switch e["label"] {
+		case "document":
+			uris = append(uris, e["uri"].(string))
+		case "range":
+			rs := next(e, "next")
+			m := next(rs, "moniker")
+			monikers[m["identifier"].(string)] = m["kind"].(string)
+		case "hoverResult":
+			c := e["result"].(map[string]interface{})["contents"].(map[string]interface{})
+			hovers = append(hovers, c["value"].(string))
+		}
+	}
+	if diff := cmp.Diff([]string{"file:///example.com/m@v1.0.0/p/p.go"}, uris); diff != "" {
+		t.Errorf("documents mismatch (-want, +got):\n%s", diff)
+	}
+	wantMonikers := map[string]string{"example.com/m/p:F": "export", "fmt:Println": "import"}
+	if diff := cmp.Diff(wantMonikers, monikers); diff != "" {
+		t.Errorf("monikers mismatch (-want, +got):\n%s", diff)
+	}
+	wantHovers := []string{"```go\nfunc F()\n```\n\n---\n\nF does nothing.\n"}
+	if diff := cmp.Diff(wantHovers, hovers); diff != "" {
+		t.Errorf("hovers mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	rrate "github.com/go-redis/redis_rate/v9"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+var (
+	keyAPIKeyID     = tag.MustNewKey("apikey.id")
+	keyAPIKeyResult = tag.MustNewKey("apikey.result")
+	apiKeyRequests  = stats.Int64(
+		"go-discovery/apikey_request_count",
+		"The result of an API key check.",
+		stats.UnitDimensionless,
+	)
+	// APIKeyRequestCount is a counter of API requests, by key and by
+	// whether they were allowed.
+	APIKeyRequestCount = &view.View{
+		Name:        "go-discovery/apikey/request_count",
+		Measure:     apiKeyRequests,
+		Aggregation: view.Count(),
+		Description: "API requests, by key and result",
+		TagKeys:     []tag.Key{keyAPIKeyID, keyAPIKeyResult},
+	}
+)
+
+func recordAPIKeyMetric(ctx context.Context, k *apikey.Key, result string) {
+	id := "none"
+	if k != nil {
+		id = strconv.Itoa(k.ID)
+	}
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyAPIKeyID, id),
+		tag.Upsert(keyAPIKeyResult, result),
+	}, apiKeyRequests.M(1))
+}
+
+// APIKeys authenticates requests to the API with the keys in their X-API-Key
+// headers, checks that the keys have the scopes that the requested paths
+// need, and limits the rate of requests of each key. getKey returns the
+// active key with the given hash, or a NotFound error. The rates are kept in
+// client; if it is nil, they are not limited.
+//
+// The contexts of requests with a valid key carry it. Requests without a key
+// are allowed if settings.Required is false, except those that need the admin
+// scope. Requests to fetch modules never need a key, since the frontend makes
+// them for users.
+func APIKeys(settings config.APIKeySettings, getKey func(context.Context, []byte) (*apikey.Key, error), client *redis.Client) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			scope := apikey.ScopeForPath(r.URL.Path)
+			if scope == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			key := apikey.FromRequest(r)
+			if key == "" {
+				if scope == apikey.ScopeAdmin || (settings.Required && scope != apikey.ScopeFetch) {
+					recordAPIKeyMetric(ctx, nil, "missing")
+					http.Error(w, "An API key is required.", http.StatusUnauthorized)
+					return
+				}
+				recordAPIKeyMetric(ctx, nil, "anonymous")
+				h.ServeHTTP(w, r)
+				return
+			}
+			k, err := getKey(ctx, apikey.Hash(key))
+			if err != nil {
+				if errors.Is(err, derrors.NotFound) {
+					recordAPIKeyMetric(ctx, nil, "invalid")
+					http.Error(w, "The API key is not valid.", http.StatusUnauthorized)
+					return
+				}
+				log.Errorf(ctx, "APIKeys: %v", err)
+				recordAPIKeyMetric(ctx, nil, "error")
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !k.HasScope(scope) {
+				recordAPIKeyMetric(ctx, k, "forbidden")
+				http.Error(w, "The API key does not have the "+scope+" scope.", http.StatusForbidden)
+				return
+			}
+			limit := k.RateLimit
+			if limit == 0 {
+				limit = settings.DefaultRateLimit
+			}
+			if client != nil && limit > 0 && !allowAPIKey(ctx, client, k, limit) {
+				recordAPIKeyMetric(ctx, k, "limited")
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			recordAPIKeyMetric(ctx, k, "allowed")
+			h.ServeHTTP(w, r.WithContext(apikey.NewContext(ctx, k)))
+		})
+	}
+}
+
+// allowAPIKey reports whether a request with k is within its rate limit of
+// limit requests per minute. It fails open if redis does not respond.
+func allowAPIKey(ctx context.Context, client *redis.Client, k *apikey.Key, limit int) bool {
+	res, err := rrate.NewLimiter(client.WithTimeout(15*time.Millisecond)).Allow(ctx, "apikey:"+strconv.Itoa(k.ID), rrate.PerMinute(limit))
+	if err != nil {
+		log.Warningf(ctx, "APIKeys: redis limiter: %v", err)
+		return true
+	}
+	return res.Allowed > 0
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestAPIKeys(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer c.Close()
+
+	keys := map[string]*apikey.Key{
+		"reader":  {ID: 1, Name: "reader", Scopes: []string{apikey.ScopeRead}, RateLimit: 2},
+		"admin":   {ID: 2, Name: "admin", Scopes: []string{apikey.ScopeAdmin}},
+		"fetcher": {ID: 3, Name: "fetcher", Scopes: []string{apikey.ScopeFetch}},
+	}
+	getKey := func(_ context.Context, hash []byte) (*apikey.Key, error) {
+		for key, k := range keys {
+			if bytes.Equal(apikey.Hash(key), hash) {
+				return k, nil
+			}
+		}
+		return nil, derrors.NotFound
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if k := apikey.FromContext(r.Context()); k != nil {
+			w.Header().Set("X-Key-Name", k.Name)
+		}
+	})
+
+	for _, test := range []struct {
+		name     string
+		required bool
+		path     string
+		key      string
+		wantCode int
+		wantKey  string
+	}{
+		{"no key", false, "/api/v1/package", "", http.StatusOK, ""},
+		{"required", true, "/api/v1/package", "", http.StatusUnauthorized, ""},
+		{"required fetch", true, "/fetch/example.com/m", "", http.StatusOK, ""},
+		{"not API", true, "/example.com/m", "", http.StatusOK, ""},
+		{"admin without key", false, "/api/v1/keys", "", http.StatusUnauthorized, ""},
+		{"unknown key", false, "/api/v1/package", "bad", http.StatusUnauthorized, ""},
+		{"reader", false, "/api/v1/package", "reader", http.StatusOK, "reader"},
+		{"reader search", false, "/api/v1/complete", "reader", http.StatusForbidden, ""},
+		{"reader limited", false, "/api/v1/package", "reader", http.StatusOK, "reader"},
+		{"reader over limit", false, "/api/v1/package", "reader", http.StatusTooManyRequests, ""},
+		{"admin", false, "/api/v1/keys", "admin", http.StatusOK, "admin"},
+		{"fetcher", false, "/fetch/example.com/m", "fetcher", http.StatusOK, "fetcher"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			settings := config.APIKeySettings{Required: test.required}
+			mw := APIKeys(settings, getKey, c)
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.key != "" {
+				r.Header.Set(apikey.Header, test.key)
+			}
+			w := httptest.NewRecorder()
+			mw(handler).ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Errorf("got status %d, want %d", w.Code, test.wantCode)
+			}
+			if got := w.Header().Get("X-Key-Name"); got != test.wantKey {
+				t.Errorf("got key %q, want %q", got, test.wantKey)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -24,6 +25,7 @@ import (
 	"golang.org/x/pkgsite/internal/cookie"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/xcontext"
 )
 
 var (
@@ -93,6 +95,10 @@ type cache struct {
 	cache      *icache.Cache
 	delegate   http.Handler
 	expirer    Expirer
+	stale      StaleWindow
+
+	mu         sync.Mutex
+	refreshing map[string]bool // keys being refreshed in the background
 }
 
 // An Expirer computes the TTL that should be used when caching a page.
@@ -105,14 +111,31 @@ func TTL(ttl time.Duration) Expirer {
 	}
 }
 
+// A StaleWindow computes how long a page may still be served after its TTL
+// has passed. A stale page is served immediately, and refreshed in the
+// background for later requests.
+type StaleWindow func(r *http.Request) time.Duration
+
+// CacheStateHeader is the response header that says how a request was served
+// by the cache: one of "hit", "stale", "miss" or "bypass".
+const CacheStateHeader = "X-Go-Discovery-Cache"
+
+// cacheClock returns the current time. It is a variable for testing.
+var cacheClock = time.Now
+
 // Cache returns a new Middleware that caches every request.
 // The name of the cache is used only for metrics.
 // The expirer is a func that is used to map a new request to its TTL.
+// If stale is non-nil, it maps a request to the time its page may be served
+// stale, while it is revalidated.
 // authHeader is the header key used by the cache to know that a
 // request should bypass the cache.
 // authValues is the set of values that could be set on the authHeader in
 // order to bypass the cache.
-func Cache(name string, client *redis.Client, expirer Expirer, authValues []string) Middleware {
+func Cache(name string, client *redis.Client, expirer Expirer, stale StaleWindow, authValues []string) Middleware {
+	if stale == nil {
+		stale = func(*http.Request) time.Duration { return 0 }
+	}
 	return func(h http.Handler) http.Handler {
 		return &cache{
 			name:       name,
@@ -120,6 +143,8 @@ func Cache(name string, client *redis.Client, expirer Expirer, authValues []stri
 			cache:      icache.New(client),
 			delegate:   h,
 			expirer:    expirer,
+			stale:      stale,
+			refreshing: map[string]bool{},
 		}
 	}
 }
@@ -129,31 +154,58 @@ func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	authVal := r.Header.Get(config.BypassCacheAuthHeader)
 	for _, wantVal := range c.authValues {
 		if authVal == wantVal {
+			w.Header().Set(CacheStateHeader, "bypass")
 			c.delegate.ServeHTTP(w, r)
 			return
 		}
 	}
 	// If the flash cookie is set, bypass the cache.
 	if _, err := r.Cookie(cookie.AlternativeModuleFlash); err == nil {
+		w.Header().Set(CacheStateHeader, "bypass")
+		c.delegate.ServeHTTP(w, r)
+		return
+	}
+	// Shadowed requests are compared with their shadow, so both must be
+	// computed.
+	if isShadowed(r.Context()) {
 		c.delegate.ServeHTTP(w, r)
 		return
 	}
 	ctx := r.Context()
 	key := r.URL.String()
 	start := time.Now()
-	reader, hit := c.get(ctx, key)
+	reader, cachedAt, hit := c.get(ctx, key)
 	recordCacheResult(ctx, c.name, hit, time.Since(start))
 	if hit {
+		// Pages cached without a time in their gzip header, such as those
+		// cached before the stale window was added, are treated as fresh.
+		// They were stored with the TTL alone, so they expire on time.
+		ttl := c.expirer(r)
+		if !cachedAt.IsZero() && cacheClock().Sub(cachedAt) > ttl && c.stale(r) > 0 {
+			w.Header().Set(CacheStateHeader, "stale")
+			c.refresh(ctx, key, r)
+		} else {
+			w.Header().Set(CacheStateHeader, "hit")
+		}
 		log.Debugf(ctx, "serving %q from cache", key)
 		if _, err := io.Copy(w, reader); err != nil {
 			log.Errorf(ctx, "error copying zip bytes: %v", err)
 		}
 		return
 	}
+	w.Header().Set(CacheStateHeader, "miss")
+	c.serveAndPut(ctx, key, w, r)
+}
+
+// serveAndPut serves r with the delegate handler, and caches the response
+// if it is successful.
+func (c *cache) serveAndPut(ctx context.Context, key string, w http.ResponseWriter, r *http.Request) {
 	rec := newRecorder(w)
 	c.delegate.ServeHTTP(rec, r)
 	if rec.bufErr == nil && (rec.statusCode == 0 || rec.statusCode == http.StatusOK) {
-		ttl := c.expirer(r)
+		// Keep the page for the stale window after its TTL. Its age is
+		// recorded in the gzip header, so get can tell whether it is stale.
+		ttl := c.expirer(r) + c.stale(r)
 		if TestMode {
 			c.put(ctx, key, rec, ttl)
 		} else {
@@ -162,7 +214,50 @@ func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
+// refresh serves r again in the background and caches the response, unless
+// the page for key is already being refreshed.
+func (c *cache) refresh(ctx context.Context, key string, r *http.Request) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	// The request is reused by the server once the handler returns, so
+	// copy it. The refresh must outlive the request, so detach its context.
+	rctx := xcontext.Detach(ctx)
+	rr := r.Clone(rctx)
+	f := func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		rctx, cancel := context.WithTimeout(rctx, time.Minute)
+		defer cancel()
+		log.Debugf(rctx, "refreshing stale page %q", key)
+		c.serveAndPut(rctx, key, &discardResponseWriter{header: http.Header{}}, rr.WithContext(rctx))
+	}
+	if TestMode {
+		f()
+	} else {
+		go f()
+	}
+}
+
+// discardResponseWriter is an http.ResponseWriter that discards the response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+// get returns the page for key, and the time it was cached.
+func (c *cache) get(ctx context.Context, key string) (io.Reader, time.Time, bool) {
 	// Set a short timeout for redis requests, so that we can quickly
 	// fall back to un-cached serving if redis is unavailable.
 	getCtx, cancelGet := context.WithTimeout(ctx, 100*time.Millisecond)
@@ -176,18 +271,18 @@ func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
 			log.Infof(ctx, "cache get(%q): %v", key, err)
 		}
 		recordCacheError(ctx, c.name, "GET")
-		return nil, false
+		return nil, time.Time{}, false
 	}
 	if val == nil {
-		return nil, false
+		return nil, time.Time{}, false
 	}
 	zr, err := gzip.NewReader(bytes.NewReader(val))
 	if err != nil {
 		log.Errorf(ctx, "cache: gzip.NewReader: %v", err)
 		recordCacheError(ctx, c.name, "UNZIP")
-		return nil, false
+		return nil, time.Time{}, false
 	}
-	return zr, true
+	return zr, zr.Header.ModTime, true
 }
 
 func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl time.Duration) {
@@ -207,6 +302,7 @@ func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl tim
 func newRecorder(w http.ResponseWriter) *cacheRecorder {
 	buf := &bytes.Buffer{}
 	zw := gzip.NewWriter(buf)
+	zw.ModTime = cacheClock()
 	return &cacheRecorder{ResponseWriter: w, buf: buf, zipWriter: zw}
 }
 
@@ -5,6 +5,8 @@
 package middleware
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -44,7 +46,7 @@ func TestCache(t *testing.T) {
 
 	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
 	mux := http.NewServeMux()
-	mux.Handle("/A", Cache("A", c, TTL(1*time.Minute), []string{"yes"})(handler))
+	mux.Handle("/A", Cache("A", c, TTL(1*time.Minute), nil, []string{"yes"})(handler))
 	mux.Handle("/B", handler)
 	ts := httptest.NewServer(mux)
 	view.Register(CacheResultCount)
@@ -178,3 +180,103 @@ func TestCache(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	TestMode = true
+	now := time.Now()
+	defer func(f func() time.Time) { cacheClock = f }(cacheClock)
+	cacheClock = func() time.Time { return now }
+
+	var body string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	stale := func(*http.Request) time.Duration { return time.Hour }
+	ts := httptest.NewServer(Cache("swr", c, TTL(time.Minute), stale, nil)(handler))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		label     string
+		advance   time.Duration
+		body      string
+		wantBody  string
+		wantState string
+	}{
+		{"first request", 0, "1", "1", "miss"},
+		{"fresh", 30 * time.Second, "2", "1", "hit"},
+		// The stale page is served, and refreshed with body 3.
+		{"stale", time.Minute, "3", "1", "stale"},
+		{"refreshed", 0, "4", "3", "hit"},
+		// Past the stale window, the page has expired.
+		{"expired", 2 * time.Hour, "5", "5", "miss"},
+	} {
+		now = now.Add(test.advance)
+		s.FastForward(test.advance)
+		body = test.body
+		resp, err := ts.Client().Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.wantBody {
+			t.Errorf("[%s] got body %q, want %q", test.label, got, test.wantBody)
+		}
+		if got := resp.Header.Get(CacheStateHeader); got != test.wantState {
+			t.Errorf("[%s] got cache state %q, want %q", test.label, got, test.wantState)
+		}
+	}
+}
+
+func TestCacheWithoutTime(t *testing.T) {
+	TestMode = true
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new")
+	})
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// Cache a page without a time in its gzip header.
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("/", buf.String()); err != nil {
+		t.Fatal(err)
+	}
+	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	stale := func(*http.Request) time.Duration { return time.Hour }
+	ts := httptest.NewServer(Cache("notime", c, TTL(time.Minute), stale, nil)(handler))
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "old" {
+		t.Errorf("got body %q, want %q", got, "old")
+	}
+	if got, want := resp.Header.Get(CacheStateHeader), "hit"; got != want {
+		t.Errorf("got cache state %q, want %q", got, want)
+	}
+}
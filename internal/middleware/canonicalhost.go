@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HealthCheckPaths are the path prefixes that are served on any host and
+// scheme by CanonicalHost by default, because load balancers and App Engine
+// send health checks to them directly.
+var HealthCheckPaths = []string{"/_ah/", "/healthz"}
+
+// CanonicalHost returns a Middleware that redirects requests for other hosts,
+// or made over plain HTTP, to the same URL on https://host. A request is
+// considered to be made over HTTPS if it was received over TLS, or if the
+// X-Forwarded-Proto header set by a load balancer says so.
+//
+// If hstsMaxAge is positive, HTTPS responses get a Strict-Transport-Security
+// header with that max-age in seconds.
+//
+// Requests whose path starts with one of exemptPaths are never redirected.
+// If host is empty, CanonicalHost only sets the HSTS header.
+func CanonicalHost(host string, hstsMaxAge int, exemptPaths []string) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range exemptPaths {
+				if strings.HasPrefix(r.URL.Path, p) {
+					h.ServeHTTP(w, r)
+					return
+				}
+			}
+			https := isHTTPS(r)
+			if host != "" && (!https || !strings.EqualFold(r.Host, host)) {
+				u := *r.URL
+				u.Scheme = "https"
+				u.Host = host
+				// Use a permanent redirect that preserves the method for
+				// requests other than GET and HEAD.
+				code := http.StatusMovedPermanently
+				if r.Method != http.MethodGet && r.Method != http.MethodHead {
+					code = http.StatusPermanentRedirect
+				}
+				http.Redirect(w, r, u.String(), code)
+				return
+			}
+			if https && hstsMaxAge > 0 {
+				w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", hstsMaxAge))
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isHTTPS reports whether r was made over HTTPS.
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	// The header may contain a list if there are several proxies; the first
+	// one is the scheme of the original request.
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := CanonicalHost("pkg.example.com", 31536000, HealthCheckPaths)(handler)
+
+	const hsts = "max-age=31536000; includeSubDomains"
+	for _, test := range []struct {
+		name, method, url, proto string
+		wantStatus               int
+		wantLocation, wantHSTS   string
+	}{
+		{
+			name:       "canonical https",
+			url:        "https://pkg.example.com/net/http",
+			wantStatus: http.StatusOK,
+			wantHSTS:   hsts,
+		},
+		{
+			name:       "canonical behind load balancer",
+			url:        "http://pkg.example.com/net/http",
+			proto:      "https",
+			wantStatus: http.StatusOK,
+			wantHSTS:   hsts,
+		},
+		{
+			name:         "plain http",
+			url:          "http://pkg.example.com/net/http?tab=doc",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "https://pkg.example.com/net/http?tab=doc",
+		},
+		{
+			name:         "alternate host",
+			url:          "https://www.pkg.example.com/net/http",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "https://pkg.example.com/net/http",
+		},
+		{
+			name:         "post keeps method",
+			method:       http.MethodPost,
+			url:          "http://pkg.example.com/fetch/net/http",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://pkg.example.com/fetch/net/http",
+		},
+		{
+			name:       "health check",
+			url:        "http://10.0.0.1/_ah/health",
+			wantStatus: http.StatusOK,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, test.url, nil)
+			if test.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", test.proto)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			resp := w.Result()
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+			if got := resp.Header.Get("Location"); got != test.wantLocation {
+				t.Errorf("got Location %q, want %q", got, test.wantLocation)
+			}
+			if got := resp.Header.Get("Strict-Transport-Security"); got != test.wantHSTS {
+				t.Errorf("got Strict-Transport-Security %q, want %q", got, test.wantHSTS)
+			}
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/errorreporting"
@@ -33,6 +35,9 @@ type ExperimentGetter func(context.Context) ([]*internal.Experiment, error)
 // experiment source.
 type Experimenter struct {
 	p *poller.Poller
+	// trustedProxies is the number of proxies in front of the server that
+	// append the address of their client to the X-Forwarded-For header.
+	trustedProxies int
 }
 
 // NewExperimenter returns an Experimenter for use in the middleware. The
@@ -46,6 +51,7 @@ func NewExperimenter(ctx context.Context, pollEvery time.Duration, getter Experi
 		return nil, err
 	}
 	e := &Experimenter{
+		trustedProxies: 1,
 		p: poller.New(
 			initial,
 			func(ctx context.Context) (interface{}, error) {
@@ -65,6 +71,19 @@ func NewExperimenter(ctx context.Context, pollEvery time.Duration, getter Experi
 	return e, nil
 }
 
+// SetTrustedProxies sets the number of proxies in front of the server that
+// append the address of their client to the X-Forwarded-For header, such as
+// load balancers. The address appended by the outermost of them is matched
+// against the allowlists of experiments, since the entries before it are set
+// by the client. The default is 1, for the last entry. It must be called
+// before the Experimenter is used.
+func (e *Experimenter) SetTrustedProxies(n int) {
+	if n < 1 {
+		n = 1
+	}
+	e.trustedProxies = n
+}
+
 // Experiment returns a new Middleware that sets active experiments for each
 // incoming request.
 func Experiment(e *Experimenter) Middleware {
@@ -97,7 +116,7 @@ func (e *Experimenter) setExperimentsForRequest(r *http.Request) *http.Request {
 	snapshot := e.p.Current().([]*internal.Experiment)
 	var exps []string
 	for _, exp := range snapshot {
-		if shouldSetExperiment(r, exp) {
+		if shouldSetExperiment(r, exp, e.trustedProxies) {
 			exps = append(exps, exp.Name)
 		}
 	}
@@ -106,12 +125,16 @@ func (e *Experimenter) setExperimentsForRequest(r *http.Request) *http.Request {
 }
 
 // shouldSetExperiment reports whether a given request should be enrolled in
-// the experiment, based on the ip. e.Name, and e.Rollout.
+// the experiment, based on the ip. e.Name, e.Rollout and e.Allowlist.
+// trustedProxies is as for Experimenter.SetTrustedProxies.
 //
-// Requests from empty ip addresses are never enrolled.
+// Requests from empty ip addresses are never enrolled, unless allowlisted.
 // All requests from the same IP will be enrolled in the same set of
 // experiments.
-func shouldSetExperiment(r *http.Request, e *internal.Experiment) bool {
+func shouldSetExperiment(r *http.Request, e *internal.Experiment, trustedProxies int) bool {
+	if len(e.Allowlist) > 0 && inAllowlist(forwardedClientIP(r.Header.Get("X-Forwarded-For"), trustedProxies), e.Allowlist) {
+		return true
+	}
 	if e.Rollout == 0 {
 		return false
 	}
@@ -126,3 +149,36 @@ func shouldSetExperiment(r *http.Request, e *internal.Experiment) bool {
 	fmt.Fprintf(h, "%s %s", ip, e.Name)
 	return uint(h.Sum32())%100 < e.Rollout
 }
+
+// forwardedClientIP returns the address of the client of the outermost of
+// trustedProxies proxies, which is the trustedProxies-th entry from the end
+// of the X-Forwarded-For header value s. It returns nil if there is no such
+// entry, or it is not an IP address.
+func forwardedClientIP(s string, trustedProxies int) net.IP {
+	if s == "" || trustedProxies < 1 {
+		return nil
+	}
+	addrs := strings.Split(s, ",")
+	if len(addrs) < trustedProxies {
+		return nil
+	}
+	return net.ParseIP(strings.TrimSpace(addrs[len(addrs)-trustedProxies]))
+}
+
+// inAllowlist reports whether ip matches an IP address or CIDR range in
+// allowlist. Invalid entries in allowlist are ignored.
+func inAllowlist(ip net.IP, allowlist []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, a := range allowlist {
+		if strings.Contains(a, "/") {
+			if _, n, err := net.ParseCIDR(a); err == nil && n.Contains(ip) {
+				return true
+			}
+		} else if aip := net.ParseIP(a); aip != nil && aip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -106,7 +106,7 @@ func TestShouldSetExperiment(t *testing.T) {
 					t.Fatal(err)
 				}
 				req.Header.Add("X-Forwarded-For", ip)
-				if shouldSetExperiment(req, test) {
+				if shouldSetExperiment(req, test, 1) {
 					inExperiment++
 				}
 			}
@@ -123,3 +123,37 @@ func TestShouldSetExperiment(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldSetExperimentAllowlist(t *testing.T) {
+	exp := &internal.Experiment{
+		Name:      "test",
+		Rollout:   0,
+		Allowlist: []string{"1.2.3.4", "10.0.0.0/8", "bad-entry", "2001:db8::/32"},
+	}
+	for _, test := range []struct {
+		forwardedFor   string
+		trustedProxies int
+		want           bool
+	}{
+		{"1.2.3.4", 1, true},
+		{"1.2.3.5", 1, false},
+		{"5.6.7.8, 10.20.30.40", 1, true},
+		// The first entry is set by the client.
+		{"10.20.30.40, 5.6.7.8", 1, false},
+		{"1.2.3.4, 10.20.30.40, 5.6.7.8", 2, true},
+		{"1.2.3.4, 5.6.7.8, 10.20.30.40", 2, false},
+		{"1.2.3.4", 2, false},
+		{"2001:db8::1", 1, true},
+		{"", 1, false},
+		{"bad-entry", 1, false},
+	} {
+		req, err := http.NewRequest("GET", "http://foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("X-Forwarded-For", test.forwardedFor)
+		if got := shouldSetExperiment(req, exp, test.trustedProxies); got != test.want {
+			t.Errorf("%q, %d trusted proxies: got %t, want %t", test.forwardedFor, test.trustedProxies, got, test.want)
+		}
+	}
+}
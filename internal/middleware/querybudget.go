@@ -0,0 +1,158 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// QueryStatsHeader is the response header that reports the database queries
+// issued for a request. It is only set for requests that carry one of the
+// auth values in the config.BypassCacheAuthHeader header.
+const QueryStatsHeader = "X-Go-Discovery-DB-Queries"
+
+var (
+	keyQueryBudgetRoute = tag.MustNewKey("query_budget.route")
+	queryBudgetExceeded = stats.Int64(
+		"go-discovery/query_budget/exceeded_count",
+		"Requests that exceeded their database query budget.",
+		stats.UnitDimensionless,
+	)
+	// QueryBudgetExceededCount is a counter of requests that exceeded their
+	// query budget, by route.
+	QueryBudgetExceededCount = &view.View{
+		Name:        "go-discovery/query_budget/exceeded_count",
+		Measure:     queryBudgetExceeded,
+		Aggregation: view.Count(),
+		Description: "requests over their query budget, by route",
+		TagKeys:     []tag.Key{keyQueryBudgetRoute},
+	}
+)
+
+// A QueryBudget bounds the database work done for a single request. A zero
+// field means no bound.
+type QueryBudget struct {
+	MaxQueries  int
+	MaxDuration time.Duration
+}
+
+// exceeded reports whether count queries taking d in total exceed b.
+func (b QueryBudget) exceeded(count int, d time.Duration) bool {
+	return (b.MaxQueries > 0 && count > b.MaxQueries) ||
+		(b.MaxDuration > 0 && d > b.MaxDuration)
+}
+
+// QueryBudgets returns a Middleware that tracks the number and cumulative
+// duration of the database queries issued for each request, to make N+1
+// query regressions visible.
+//
+// budgets maps path prefixes to the budget of the routes they serve; the
+// longest matching prefix applies. Requests that go over their budget are
+// logged and counted by route. Requests from admins, identified by
+// authValues, get the statistics in the QueryStatsHeader response header.
+func QueryBudgets(budgets map[string]QueryBudget, authValues []string) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			qs := database.QueryStatsFromContext(ctx)
+			if qs == nil {
+				ctx, qs = database.NewContextWithQueryStats(ctx)
+			}
+			if isAdmin(r, authValues) {
+				w = &queryStatsResponseWriter{ResponseWriter: w, qs: qs}
+			}
+			h.ServeHTTP(w, r.WithContext(ctx))
+
+			route, budget, ok := budgetFor(budgets, r.URL.Path)
+			if !ok {
+				return
+			}
+			count, d, slowest := qs.Get()
+			if budget.exceeded(count, d) {
+				log.Warningf(ctx, "%s exceeded the query budget of %q: %d queries in %s, slowest %s (budget: %d queries in %s)",
+					r.URL.Path, route, count, d, slowest, budget.MaxQueries, budget.MaxDuration)
+				stats.RecordWithTags(ctx, []tag.Mutator{
+					tag.Upsert(keyQueryBudgetRoute, route),
+				}, queryBudgetExceeded.M(1))
+			}
+		})
+	}
+}
+
+// budgetFor returns the longest prefix of path in budgets, and its budget.
+func budgetFor(budgets map[string]QueryBudget, path string) (prefix string, b QueryBudget, ok bool) {
+	for p, pb := range budgets {
+		if strings.HasPrefix(path, p) && (!ok || len(p) > len(prefix)) {
+			prefix, b, ok = p, pb, true
+		}
+	}
+	return prefix, b, ok
+}
+
+func isAdmin(r *http.Request, authValues []string) bool {
+	v := r.Header.Get(config.BypassCacheAuthHeader)
+	if v == "" {
+		return false
+	}
+	for _, av := range authValues {
+		if v == av {
+			return true
+		}
+	}
+	return false
+}
+
+// queryStatsResponseWriter is an http.ResponseWriter that adds the query
+// statistics collected so far to the response headers when they are written.
+// Pages are rendered after their data is read, so that is nearly all of
+// them.
+type queryStatsResponseWriter struct {
+	http.ResponseWriter
+	qs          *database.QueryStats
+	wroteHeader bool
+}
+
+func (w *queryStatsResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		count, d, slowest := w.qs.Get()
+		w.Header().Set(QueryStatsHeader, fmt.Sprintf("count=%d; duration=%dms; slowest=%dms",
+			count, d.Milliseconds(), slowest.Milliseconds()))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *queryStatsResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// queryStatsPayload returns the query statistics of ctx to add to a request
+// log entry, or nil if there are none.
+func queryStatsPayload(ctx context.Context) map[string]interface{} {
+	qs := database.QueryStatsFromContext(ctx)
+	if qs == nil {
+		return nil
+	}
+	count, d, slowest := qs.Get()
+	return map[string]interface{}{
+		"count":      count,
+		"durationMs": d.Milliseconds(),
+		"slowestMs":  slowest.Milliseconds(),
+	}
+}
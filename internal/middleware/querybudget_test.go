@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/database"
+)
+
+func TestQueryBudgets(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qs := database.QueryStatsFromContext(r.Context())
+		if qs == nil {
+			t.Fatal("no query stats in context")
+		}
+		qs.Add(2 * time.Millisecond)
+		qs.Add(5 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+	mw := QueryBudgets(map[string]QueryBudget{"/": {MaxQueries: 10}}, []string{"secret"})
+	ts := httptest.NewServer(mw(handler))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		auth string
+		want string
+	}{
+		{"", ""},
+		{"wrong", ""},
+		{"secret", "count=2; duration=7ms; slowest=5ms"},
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.auth != "" {
+			req.Header.Set(config.BypassCacheAuthHeader, test.auth)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get(QueryStatsHeader); got != test.want {
+			t.Errorf("auth %q: got header %q, want %q", test.auth, got, test.want)
+		}
+	}
+}
+
+func TestBudgetFor(t *testing.T) {
+	budgets := map[string]QueryBudget{
+		"/":       {MaxQueries: 50},
+		"/search": {MaxQueries: 20},
+	}
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"/", "/"},
+		{"/github.com/a/b", "/"},
+		{"/search", "/search"},
+		{"/search-help", "/search"},
+	} {
+		got, _, ok := budgetFor(budgets, test.path)
+		if !ok || got != test.want {
+			t.Errorf("budgetFor(%q) = %q, %t; want %q, true", test.path, got, ok, test.want)
+		}
+	}
+	if _, _, ok := budgetFor(budgets, "x"); ok {
+		t.Error("budgetFor(\"x\") found a budget, want none")
+	}
+}
+
+func TestQueryBudgetExceeded(t *testing.T) {
+	b := QueryBudget{MaxQueries: 2, MaxDuration: time.Second}
+	for _, test := range []struct {
+		count int
+		d     time.Duration
+		want  bool
+	}{
+		{2, time.Second, false},
+		{3, time.Millisecond, true},
+		{1, 2 * time.Second, true},
+	} {
+		if got := b.exceeded(test.count, test.d); got != test.want {
+			t.Errorf("exceeded(%d, %s) = %t, want %t", test.count, test.d, got, test.want)
+		}
+	}
+	if (QueryBudget{}).exceeded(1000, time.Hour) {
+		t.Error("zero budget was exceeded")
+	}
+}
@@ -6,6 +6,8 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,6 +15,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/logging"
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/log"
 )
 
@@ -59,6 +62,12 @@ type handler struct {
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	traceID := r.Header.Get("X-Cloud-Trace-Context")
+	if traceID == "" {
+		// Outside of GCP, there is no trace header. Make up an ID, so
+		// that the request can still be identified, for example from the
+		// error page.
+		traceID = newRequestID()
+	}
 	severity := logging.Info
 	if r.Method == http.MethodGet && r.URL.Path == "/healthz" {
 		severity = logging.Debug
@@ -72,7 +81,8 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Trace:    traceID,
 	})
 	w2 := &responseWriter{ResponseWriter: w}
-	h.delegate.ServeHTTP(w2, r.WithContext(log.NewContextWithTraceID(r.Context(), traceID)))
+	ctx, _ := database.NewContextWithQueryStats(log.NewContextWithTraceID(r.Context(), traceID))
+	h.delegate.ServeHTTP(w2, r.WithContext(ctx))
 	s := severity
 	if w2.status == http.StatusServiceUnavailable {
 		// load shedding is a warning, not an error
@@ -80,21 +90,34 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else if w2.status >= 500 {
 		s = logging.Error
 	}
+	payload := map[string]interface{}{
+		"requestType": "request end",
+		"isRobot":     isRobot(r.Header.Get("User-Agent")),
+	}
+	if qs := queryStatsPayload(ctx); qs != nil {
+		payload["dbQueries"] = qs
+	}
 	h.logger.Log(logging.Entry{
 		HTTPRequest: &logging.HTTPRequest{
 			Request: r,
 			Status:  translateStatus(w2.status),
 			Latency: time.Since(start),
 		},
-		Payload: map[string]interface{}{
-			"requestType": "request end",
-			"isRobot":     isRobot(r.Header.Get("User-Agent")),
-		},
+		Payload:  payload,
 		Severity: s,
 		Trace:    traceID,
 	})
 }
 
+// newRequestID returns a random ID for a request.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
 var browserAgentPrefixes = []string{
 	"MobileSafari/",
 	"Mozilla/",
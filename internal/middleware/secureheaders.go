@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"golang.org/x/pkgsite/internal/config"
 )
 
 var scriptHashes = []string{
@@ -32,23 +34,46 @@ var scriptHashes = []string{
 	"'sha256-rEbn/zvLCsDDvDrVWQuUkKGEQsjQjFvIvJK4NVIMqZ4='",
 }
 
+// CSPReportPath is the path that browsers send Content-Security-Policy
+// violation reports to.
+const CSPReportPath = "/csp-report"
+
+// defaultCSP is the default Content-Security-Policy.
+var defaultCSP = []string{
+	// Disallow plugin content: pkg.go.dev does not use it.
+	"object-src 'none'",
+	// Disallow <base> URIs, which prevents attackers from changing the
+	// locations of scripts loaded from relative URLs. The site doesn’t have
+	// a <base> tag anyway.
+	"base-uri 'none'",
+	fmt.Sprintf("script-src 'unsafe-inline' 'strict-dynamic' https: http: %s",
+		strings.Join(scriptHashes, " ")),
+}
+
 // SecureHeaders adds a content-security-policy and other security-related
 // headers to all responses.
-func SecureHeaders(enableCSP bool) Middleware {
+//
+// The policy is the default policy with the directives in csp.Directives.
+// If csp.ReportOnlyDirectives is not empty, they are added to the policy to
+// form a report-only policy. If csp.ReportSampleRate is positive, browsers
+// are asked to send violation reports to CSPReportPath.
+func SecureHeaders(enableCSP bool, csp config.CSPSettings) Middleware {
+	var report []string
+	if csp.ReportSampleRate > 0 {
+		report = []string{"report-uri " + CSPReportPath}
+	}
+	policy := contentSecurityPolicy(defaultCSP, csp.Directives, report)
+	var reportOnlyPolicy string
+	if len(csp.ReportOnlyDirectives) > 0 {
+		reportOnlyPolicy = contentSecurityPolicy(defaultCSP, csp.Directives, csp.ReportOnlyDirectives, report)
+	}
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			csp := []string{
-				// Disallow plugin content: pkg.go.dev does not use it.
-				"object-src 'none'",
-				// Disallow <base> URIs, which prevents attackers from changing the
-				// locations of scripts loaded from relative URLs. The site doesn’t have
-				// a <base> tag anyway.
-				"base-uri 'none'",
-				fmt.Sprintf("script-src 'unsafe-inline' 'strict-dynamic' https: http: %s",
-					strings.Join(scriptHashes, " ")),
-			}
 			if enableCSP {
-				w.Header().Set("Content-Security-Policy", strings.Join(csp, "; "))
+				w.Header().Set("Content-Security-Policy", policy)
+				if reportOnlyPolicy != "" {
+					w.Header().Set("Content-Security-Policy-Report-Only", reportOnlyPolicy)
+				}
 			}
 			// Don't allow frame embedding.
 			w.Header().Set("X-Frame-Options", "deny")
@@ -59,3 +84,27 @@ func SecureHeaders(enableCSP bool) Middleware {
 		})
 	}
 }
+
+// contentSecurityPolicy combines lists of directives into a policy. A
+// directive replaces an earlier one with the same name.
+func contentSecurityPolicy(lists ...[]string) string {
+	var directives []string
+	index := map[string]int{} // from directive name to index in directives
+	for _, list := range lists {
+		for _, d := range list {
+			d = strings.TrimSpace(d)
+			name, _, _ := strings.Cut(d, " ")
+			if name == "" {
+				continue
+			}
+			name = strings.ToLower(name)
+			if i, ok := index[name]; ok {
+				directives[i] = d
+			} else {
+				index[name] = len(directives)
+				directives = append(directives, d)
+			}
+		}
+	}
+	return strings.Join(directives, "; ")
+}
@@ -8,12 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"golang.org/x/pkgsite/internal/config"
 )
 
 func TestSecureHeaders(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 	enableCSP := true
-	mw := SecureHeaders(enableCSP)
+	mw := SecureHeaders(enableCSP, config.CSPSettings{})
 	ts := httptest.NewServer(mw(handler))
 	defer ts.Close()
 	resp, err := ts.Client().Get(ts.URL)
@@ -33,3 +35,24 @@ func TestSecureHeaders(t *testing.T) {
 		}
 	}
 }
+
+func TestSecureHeadersPolicy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mw := SecureHeaders(true, config.CSPSettings{
+		Directives:           []string{"img-src 'self' https:", "base-uri 'self'"},
+		ReportOnlyDirectives: []string{"script-src 'self'"},
+		ReportSampleRate:     0.1,
+	})
+	w := httptest.NewRecorder()
+	mw(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	const base = "object-src 'none'; base-uri 'self'; "
+	wantPolicy := base + defaultCSP[2] + "; img-src 'self' https:; report-uri /csp-report"
+	if got := w.Header().Get("Content-Security-Policy"); got != wantPolicy {
+		t.Errorf("got policy\n%q\nwant\n%q", got, wantPolicy)
+	}
+	wantReportOnly := base + "script-src 'self'; img-src 'self' https:; report-uri /csp-report"
+	if got := w.Header().Get("Content-Security-Policy-Report-Only"); got != wantReportOnly {
+		t.Errorf("got report-only policy\n%q\nwant\n%q", got, wantReportOnly)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/dcensus"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/xcontext"
+)
+
+var (
+	keyShadowResult = tag.MustNewKey("shadow.result")
+	keyShadowSide   = tag.MustNewKey("shadow.side")
+	shadowResults   = stats.Int64(
+		"go-discovery/shadow/result_count",
+		"The result of comparing a request with its shadow.",
+		stats.UnitDimensionless,
+	)
+	shadowLatency = stats.Float64(
+		"go-discovery/shadow/latency",
+		"Latency of shadowed requests.",
+		stats.UnitMilliseconds,
+	)
+
+	// ShadowResultCount is a counter of shadow comparisons, by whether the
+	// responses matched.
+	ShadowResultCount = &view.View{
+		Name:        "go-discovery/shadow/result_count",
+		Measure:     shadowResults,
+		Aggregation: view.Count(),
+		Description: "shadow results, by match, mismatch or dropped",
+		TagKeys:     []tag.Key{keyShadowResult},
+	}
+	// ShadowLatency is the latency distribution of shadowed requests, by
+	// whether they were served by the primary or the shadow.
+	ShadowLatency = &view.View{
+		Name:        "go-discovery/shadow/latency",
+		Measure:     shadowLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+		Description: "shadowed request latency, by primary or shadow",
+		TagKeys:     []tag.Key{keyShadowSide},
+	}
+)
+
+// ShadowConfig configures the Shadow middleware.
+type ShadowConfig struct {
+	// Rate is the fraction of GET requests that are mirrored, between 0
+	// and 1.
+	Rate float64
+
+	// Handler serves the mirrored requests. If it is nil, the handler being
+	// wrapped is used, so that only the experiments below differ.
+	Handler http.Handler
+
+	// Experiments are turned on for mirrored requests, in addition to the
+	// experiments of the original request. This is how an alternate query
+	// implementation behind an experiment is selected.
+	Experiments []string
+
+	// Timeout bounds the time spent serving a mirrored request. The default
+	// is one minute.
+	Timeout time.Duration
+
+	// MaxInFlight is the maximum number of mirrored requests that are served
+	// at once. Requests sampled while that many are in flight are not
+	// mirrored. The default is 10.
+	MaxInFlight int
+
+	// Report is called with the result of every comparison. If it is nil,
+	// divergences are logged.
+	Report func(context.Context, *ShadowResult)
+}
+
+// ShadowResult is the outcome of serving a request both normally and with
+// the shadow configuration.
+type ShadowResult struct {
+	URL     string
+	Primary ShadowResponse
+	Shadow  ShadowResponse
+}
+
+// Match reports whether the primary and shadow responses are the same.
+func (r *ShadowResult) Match() bool {
+	return r.Primary.StatusCode == r.Shadow.StatusCode && r.Primary.Hash == r.Shadow.Hash
+}
+
+// ShadowResponse summarizes one of the responses of a shadowed request.
+type ShadowResponse struct {
+	StatusCode int
+	Size       int
+	Hash       [sha256.Size]byte
+	Latency    time.Duration
+}
+
+// shadowKey is the type of the context key that marks shadowed requests.
+type shadowKey struct{}
+
+// mirrorKey is the type of the context key that marks the mirrored copies of
+// shadowed requests.
+type mirrorKey struct{}
+
+// isShadowed reports whether the request with ctx is being compared with a
+// shadow, or is the mirrored copy. Such requests bypass the cache, so that
+// both sides are computed.
+func isShadowed(ctx context.Context) bool {
+	return ctx.Value(shadowKey{}) != nil || IsMirrored(ctx)
+}
+
+// IsMirrored reports whether the request with ctx is the mirrored copy of a
+// shadowed request. Its response is discarded, so handlers should not record
+// anything for it, such as page views, that the original request already
+// records.
+func IsMirrored(ctx context.Context) bool {
+	return ctx.Value(mirrorKey{}) != nil
+}
+
+// Shadow returns a Middleware that mirrors a sample of read requests to an
+// alternate configuration, to de-risk rewrites of the queries behind a page.
+// After the original request has been served, the mirrored request is served
+// asynchronously and its response discarded. The status codes and bodies of
+// both responses are compared, and divergences and latencies are reported.
+//
+// The mirrored request has the values of the original request context, so
+// that the CSP nonce and other per-request data match. Shadow must therefore
+// come after SecureHeaders and Experiment in a chain. Handlers that record
+// requests, such as counters of page views, must skip mirrored requests; see
+// IsMirrored.
+func Shadow(cfg ShadowConfig) Middleware {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute
+	}
+	if cfg.MaxInFlight == 0 {
+		cfg.MaxInFlight = 10
+	}
+	if cfg.Report == nil {
+		cfg.Report = logShadowResult
+	}
+	sem := make(chan struct{}, cfg.MaxInFlight)
+	return func(h http.Handler) http.Handler {
+		shadow := cfg.Handler
+		if shadow == nil {
+			shadow = h
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || cfg.Rate <= 0 || rand.Float64() >= cfg.Rate {
+				h.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), shadowKey{}, true)
+			pw := newShadowResponseWriter(w)
+			h.ServeHTTP(pw, r.WithContext(ctx))
+			res := &ShadowResult{URL: r.URL.String(), Primary: pw.response()}
+
+			select {
+			case sem <- struct{}{}:
+			default:
+				recordShadowResult(ctx, "dropped")
+				return
+			}
+			// Copy the request now, because the server may reuse it once
+			// the handler returns.
+			sr := r.Clone(ctx)
+			go func() {
+				defer func() { <-sem }()
+				defer func() {
+					if e := recover(); e != nil {
+						log.Errorf(ctx, "shadow: panic serving %s: %v", res.URL, e)
+					}
+				}()
+				sctx, cancel := context.WithTimeout(xcontext.Detach(ctx), cfg.Timeout)
+				defer cancel()
+				// Count the queries of the mirrored request separately, so
+				// that they don't add to those of the original request.
+				sctx, _ = database.NewContextWithQueryStats(sctx)
+				sctx = context.WithValue(sctx, mirrorKey{}, true)
+				sctx = experiment.NewContext(sctx, append(experiment.FromContext(ctx).Active(), cfg.Experiments...)...)
+				sw := newShadowResponseWriter(nil)
+				shadow.ServeHTTP(sw, sr.WithContext(sctx))
+				res.Shadow = sw.response()
+
+				if res.Match() {
+					recordShadowResult(ctx, "match")
+				} else {
+					recordShadowResult(ctx, "mismatch")
+				}
+				recordShadowLatency(ctx, "primary", res.Primary.Latency)
+				recordShadowLatency(ctx, "shadow", res.Shadow.Latency)
+				cfg.Report(ctx, res)
+			}()
+		})
+	}
+}
+
+func logShadowResult(ctx context.Context, res *ShadowResult) {
+	if res.Match() {
+		return
+	}
+	log.Warningf(ctx, "shadow: responses for %s diverged: primary status %d, size %d, latency %s; shadow status %d, size %d, latency %s",
+		res.URL,
+		res.Primary.StatusCode, res.Primary.Size, res.Primary.Latency,
+		res.Shadow.StatusCode, res.Shadow.Size, res.Shadow.Latency)
+}
+
+func recordShadowResult(ctx context.Context, result string) {
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyShadowResult, result),
+	}, shadowResults.M(1))
+}
+
+func recordShadowLatency(ctx context.Context, side string, latency time.Duration) {
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyShadowSide, side),
+	}, dcensus.MDur(shadowLatency, latency))
+}
+
+// shadowResponseWriter is an http.ResponseWriter that hashes the response
+// it is given. If it wraps another ResponseWriter, the response is also
+// written to it; otherwise it is discarded.
+type shadowResponseWriter struct {
+	w          http.ResponseWriter
+	header     http.Header
+	start      time.Time
+	statusCode int
+	size       int
+	hasher     hash.Hash
+}
+
+func newShadowResponseWriter(w http.ResponseWriter) *shadowResponseWriter {
+	sw := &shadowResponseWriter{w: w, start: time.Now(), hasher: sha256.New()}
+	if w != nil {
+		sw.header = w.Header()
+	} else {
+		sw.header = http.Header{}
+	}
+	return sw
+}
+
+// Header implements http.ResponseWriter.Header.
+func (s *shadowResponseWriter) Header() http.Header { return s.header }
+
+// WriteHeader implements http.ResponseWriter.WriteHeader.
+func (s *shadowResponseWriter) WriteHeader(statusCode int) {
+	if s.statusCode != 0 {
+		return
+	}
+	s.statusCode = statusCode
+	if s.w != nil {
+		s.w.WriteHeader(statusCode)
+	}
+}
+
+// Write implements http.ResponseWriter.Write.
+func (s *shadowResponseWriter) Write(data []byte) (int, error) {
+	if s.statusCode == 0 {
+		s.WriteHeader(http.StatusOK)
+	}
+	s.size += len(data)
+	s.hasher.Write(data)
+	if s.w != nil {
+		return s.w.Write(data)
+	}
+	return len(data), nil
+}
+
+func (s *shadowResponseWriter) response() ShadowResponse {
+	r := ShadowResponse{
+		StatusCode: s.statusCode,
+		Size:       s.size,
+		Latency:    time.Since(s.start),
+	}
+	if r.StatusCode == 0 {
+		r.StatusCode = http.StatusOK
+	}
+	copy(r.Hash[:], s.hasher.Sum(nil))
+	return r
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+func TestShadow(t *testing.T) {
+	const exp = "new-query"
+	// The handler serves a different page under the experiment for /diff.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isShadowed(r.Context()) {
+			http.Error(w, "not shadowed", http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/diff" && experiment.IsActive(r.Context(), exp) {
+			fmt.Fprint(w, "new")
+			return
+		}
+		fmt.Fprint(w, "old")
+	})
+	results := make(chan *ShadowResult, 1)
+	mw := Shadow(ShadowConfig{
+		Rate:        1,
+		Experiments: []string{exp},
+		Report: func(_ context.Context, res *ShadowResult) {
+			results <- res
+		},
+	})
+	ts := httptest.NewServer(mw(handler))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		path      string
+		wantMatch bool
+	}{
+		{"/same", true},
+		{"/diff", false},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			resp, err := ts.Client().Get(ts.URL + test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			select {
+			case res := <-results:
+				if got := res.Match(); got != test.wantMatch {
+					t.Errorf("got match %t, want %t (%+v)", got, test.wantMatch, res)
+				}
+				if res.Primary.Size != 3 || res.Shadow.Size != 3 {
+					t.Errorf("got sizes %d and %d, want 3", res.Primary.Size, res.Shadow.Size)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for shadow result")
+			}
+		})
+	}
+}
+
+func TestShadowQueryStats(t *testing.T) {
+	stats := make(chan *database.QueryStats, 2)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats <- database.QueryStatsFromContext(r.Context())
+	})
+	done := make(chan struct{})
+	mw := Shadow(ShadowConfig{
+		Rate:   1,
+		Report: func(context.Context, *ShadowResult) { close(done) },
+	})
+	ctx, primary := database.NewContextWithQueryStats(context.Background())
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	mw(handler).ServeHTTP(httptest.NewRecorder(), r)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for shadow result")
+	}
+	if got := <-stats; got != primary {
+		t.Error("primary request does not have the query stats of its context")
+	}
+	if got := <-stats; got == nil || got == primary {
+		t.Errorf("shadow request has query stats %p, want new ones (primary %p)", got, primary)
+	}
+}
+
+func TestShadowSkipsWrites(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isShadowed(r.Context()) {
+			t.Errorf("%s request was shadowed", r.Method)
+		}
+	})
+	mw := Shadow(ShadowConfig{
+		Rate: 1,
+		Report: func(context.Context, *ShadowResult) {
+			t.Error("unexpected shadow report")
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/fetch/a.com", nil)
+	mw(handler).ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moddiff
+
+import "fmt"
+
+const (
+	// numContextLines is the number of unchanged lines shown around each
+	// change.
+	numContextLines = 3
+
+	// maxEdits is the largest number of inserted and deleted lines that is
+	// computed for a file. The time and memory needed grow with its square.
+	maxEdits = 2000
+)
+
+// An Op is the operation on a line in a diff.
+type Op byte
+
+const (
+	Equal  Op = ' '
+	Insert Op = '+'
+	Delete Op = '-'
+)
+
+func (o Op) String() string { return string(o) }
+
+// A Line is a line of a hunk.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// A Hunk is a group of changed lines and their context. Line numbers start
+// at 1. If a side has no lines, its start is the number of the line before
+// the hunk, as in a unified diff.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// Header returns the header of h in a unified diff, such as
+// "@@ -1,4 +1,5 @@".
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// diffLines returns the hunks that turn a into b. It reports false if the
+// lines differ by more than maxEdits insertions and deletions.
+func diffLines(a, b []string) ([]*Hunk, bool) {
+	lines, ok := editScript(a, b)
+	if !ok {
+		return nil, false
+	}
+	return hunks(lines), true
+}
+
+// editScript returns every line of a and b in order, marked with how it
+// changes, using the algorithm in Myers, "An O(ND) Difference Algorithm and
+// Its Variations" (1986).
+func editScript(a, b []string) ([]Line, bool) {
+	// Lines in common at the start and end are usually most of a file, and
+	// are cheap to find.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	mid, ok := myers(a[pre:len(a)-suf], b[pre:len(b)-suf])
+	if !ok {
+		return nil, false
+	}
+	var lines []Line
+	for _, l := range a[:pre] {
+		lines = append(lines, Line{Equal, l})
+	}
+	lines = append(lines, mid...)
+	for _, l := range a[len(a)-suf:] {
+		lines = append(lines, Line{Equal, l})
+	}
+	return lines, true
+}
+
+// myers returns the edit script of a and b, or false if it has more than
+// maxEdits insertions and deletions.
+func myers(a, b []string) ([]Line, bool) {
+	n, m := len(a), len(b)
+	// v[k+offset] is the furthest x reached on diagonal k = x-y. trace[d]
+	// holds v[-d..d] after step d, for backtracking.
+	offset := maxEdits + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= maxEdits; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset] // down: insert b[y-1]
+			} else {
+				x = v[k-1+offset] + 1 // right: delete a[x-1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(a, b, trace), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrack follows trace back from the end of a and b, and returns the edit
+// script.
+func backtrack(a, b []string, trace [][]int) []Line {
+	var rev []Line
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // v[-(d-1)..d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, Line{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			rev = append(rev, Line{Insert, b[y-1]})
+			y--
+		} else {
+			rev = append(rev, Line{Delete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		rev = append(rev, Line{Equal, a[x-1]})
+		x--
+		y--
+	}
+	lines := make([]Line, len(rev))
+	for i, l := range rev {
+		lines[len(rev)-1-i] = l
+	}
+	return lines
+}
+
+// hunks groups the changes in lines into hunks, with numContextLines of
+// context around each change. Changes whose context overlaps are in the same
+// hunk.
+func hunks(lines []Line) []*Hunk {
+	var (
+		hs       []*Hunk
+		h        *Hunk
+		oldLine  = 1 // number of the next old line
+		newLine  = 1 // number of the next new line
+		lastEdit = -1
+	)
+	// nextEdit[i] is the index of the first change at or after i.
+	nextEdit := make([]int, len(lines)+1)
+	nextEdit[len(lines)] = len(lines) + numContextLines + 1
+	for i := len(lines) - 1; i >= 0; i-- {
+		nextEdit[i] = nextEdit[i+1]
+		if lines[i].Op != Equal {
+			nextEdit[i] = i
+		}
+	}
+	for i, l := range lines {
+		inHunk := (lastEdit >= 0 && i-lastEdit <= numContextLines) || nextEdit[i]-i <= numContextLines
+		if inHunk {
+			if h == nil {
+				h = &Hunk{OldStart: oldLine, NewStart: newLine}
+				hs = append(hs, h)
+			}
+			h.Lines = append(h.Lines, l)
+			if l.Op != Insert {
+				h.OldLines++
+			}
+			if l.Op != Delete {
+				h.NewLines++
+			}
+		} else {
+			h = nil
+		}
+		if l.Op != Equal {
+			lastEdit = i
+		}
+		if l.Op != Insert {
+			oldLine++
+		}
+		if l.Op != Delete {
+			newLine++
+		}
+	}
+	for _, h := range hs {
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+	}
+	return hs
+}
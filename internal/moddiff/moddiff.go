@@ -0,0 +1,198 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package moddiff compares the files of two versions of a module.
+package moddiff
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// MaxFileSize is the largest file whose contents are compared. Larger files
+// are reported as modified if their contents differ, but without hunks.
+const MaxFileSize = 1 << 20
+
+// A Module is a version of a module, with the zip of its files as served by
+// the module proxy.
+type Module struct {
+	Path    string
+	Version string
+	Zip     *zip.Reader
+}
+
+// A Kind is the kind of change to a file.
+type Kind int
+
+const (
+	Added Kind = iota
+	Removed
+	Modified
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// A File is a file that differs between two versions of a module.
+type File struct {
+	// Path is the path of the file relative to the module root, with
+	// slashes.
+	Path string
+	Kind Kind
+	// Binary reports whether either version of the file is not text. Binary
+	// files have no hunks.
+	Binary bool
+	// TooLarge reports whether the file, or the difference between its
+	// versions, is too large to show. Such files have no hunks.
+	TooLarge bool
+	// Hunks are the changed lines of a text file, with context, as in a
+	// unified diff.
+	Hunks []*Hunk
+}
+
+// Diff returns the files that differ between from and to, sorted by path.
+func Diff(from, to *Module) (_ []*File, err error) {
+	defer derrors.Wrap(&err, "Diff(%s@%s, %s@%s)", from.Path, from.Version, to.Path, to.Version)
+
+	oldFiles, err := zipFiles(from)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := zipFiles(to)
+	if err != nil {
+		return nil, err
+	}
+	var files []*File
+	for path, zf := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			f, err := fileChange(path, Removed, zf, nil)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+		}
+	}
+	for path, zf := range newFiles {
+		kind, oldf := Added, (*zip.File)(nil)
+		if f, ok := oldFiles[path]; ok {
+			kind, oldf = Modified, f
+		}
+		f, err := fileChange(path, kind, oldf, zf)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// zipFiles returns the regular files in the zip of m, keyed by their path
+// relative to the module root.
+func zipFiles(m *Module) (map[string]*zip.File, error) {
+	prefix := m.Path + "@" + m.Version + "/"
+	files := map[string]*zip.File{}
+	for _, zf := range m.Zip.File {
+		if !strings.HasPrefix(zf.Name, prefix) {
+			return nil, fmt.Errorf("expected file %q to have prefix %q", zf.Name, prefix)
+		}
+		if zf.Mode().IsRegular() {
+			files[strings.TrimPrefix(zf.Name, prefix)] = zf
+		}
+	}
+	return files, nil
+}
+
+// fileChange returns the change of kind to the file at path, given its old and
+// new versions, either of which may be nil. It returns nil if the two versions
+// have the same contents.
+func fileChange(path string, kind Kind, oldf, newf *zip.File) (*File, error) {
+	f := &File{Path: path, Kind: kind}
+	if (oldf != nil && oldf.UncompressedSize64 > MaxFileSize) ||
+		(newf != nil && newf.UncompressedSize64 > MaxFileSize) {
+		if kind == Modified && oldf.CRC32 == newf.CRC32 && oldf.UncompressedSize64 == newf.UncompressedSize64 {
+			return nil, nil
+		}
+		f.TooLarge = true
+		return f, nil
+	}
+	oldData, err := readFile(oldf)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := readFile(newf)
+	if err != nil {
+		return nil, err
+	}
+	if kind == Modified && bytes.Equal(oldData, newData) {
+		return nil, nil
+	}
+	if !IsText(oldData) || !IsText(newData) {
+		f.Binary = true
+		return f, nil
+	}
+	hunks, ok := diffLines(splitLines(oldData), splitLines(newData))
+	if !ok {
+		f.TooLarge = true
+		return f, nil
+	}
+	f.Hunks = hunks
+	return f, nil
+}
+
+// readFile returns the contents of zf, or nil if zf is nil.
+func readFile(zf *zip.File) (_ []byte, err error) {
+	if zf == nil {
+		return nil, nil
+	}
+	defer derrors.Wrap(&err, "readFile(%q)", zf.Name)
+	r, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+}
+
+// IsText reports whether data looks like text: valid UTF-8 without NUL bytes
+// near the start, which is how git tells text from binary files.
+func IsText(data []byte) bool {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	return bytes.IndexByte(head, 0) < 0 && utf8.Valid(data)
+}
+
+// splitLines splits data into lines without their line terminators.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moddiff
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// unified formats hunks as the body of a unified diff.
+func unified(hunks []*Hunk) string {
+	var b strings.Builder
+	for _, h := range hunks {
+		fmt.Fprintln(&b, h.Header())
+		for _, l := range h.Lines {
+			fmt.Fprintf(&b, "%c%s\n", l.Op, l.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestDiffLines(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "same",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "add to empty",
+			old:  "",
+			new:  "a\nb\n",
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "change in middle",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			old:  "a\n1\n2\n3\n4\n5\n6\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\nB\n",
+			want: "@@ -1,8 +1,8 @@\n-a\n+A\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+B\n",
+		},
+		{
+			name: "distant changes",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "insert and delete",
+			old:  "a\nb\nc\nd\n",
+			new:  "a\nc\nd\ne\n",
+			want: "@@ -1,4 +1,4 @@\n a\n-b\n c\n d\n+e\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			hunks, ok := diffLines(splitLines([]byte(test.old)), splitLines([]byte(test.new)))
+			if !ok {
+				t.Fatal("diff too large")
+			}
+			if diff := cmp.Diff(test.want, unified(hunks)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffLinesTooLarge(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxEdits; i++ {
+		a = append(a, fmt.Sprint("a", i))
+		b = append(b, fmt.Sprint("b", i))
+	}
+	if _, ok := diffLines(a, b); ok {
+		t.Error("got ok, want too large")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := newModule(t, "example.com/m", "v1.0.0", map[string]string{
+		"go.mod":     "module example.com/m\n",
+		"a.go":       "package m\n\nconst A = 1\n",
+		"removed.go": "package m\n",
+		"logo.png":   "\x89PNG\x00\x01",
+	})
+	to := newModule(t, "example.com/m", "v1.1.0", map[string]string{
+		"go.mod":   "module example.com/m\n",
+		"a.go":     "package m\n\nconst A = 2\n",
+		"b/b.go":   "package b\n",
+		"logo.png": "\x89PNG\x00\x02",
+	})
+	files, err := Diff(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, fmt.Sprintf("%s %s binary=%t\n%s", f.Kind, f.Path, f.Binary, unified(f.Hunks)))
+	}
+	want := []string{
+		"modified a.go binary=false\n@@ -1,3 +1,3 @@\n package m\n \n-const A = 1\n+const A = 2\n",
+		"added b/b.go binary=false\n@@ -0,0 +1 @@\n+package b\n",
+		"modified logo.png binary=true\n",
+		"removed removed.go binary=false\n@@ -1 +0,0 @@\n-package m\n",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func newModule(t *testing.T, path, version string, contents map[string]string) *Module {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range contents {
+		w, err := zw.Create(path + "@" + version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Module{Path: path, Version: version, Zip: zr}
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package namespace verifies that the claimant of a module path prefix, such
+// as example.com or github.com/org, controls it, so that the modules under
+// the prefix can be marked as coming from a verified publisher.
+//
+// A claim has a random token. For a prefix on a code hosting site, the
+// claimant proves control by creating a repository named after the token
+// under the prefix's owner. For other prefixes, the claimant adds a DNS TXT
+// record with the token to the domain of the prefix. A verification expires
+// after VerificationTTL, so the claimant must keep meeting the challenge for
+// the claim to be verified again.
+package namespace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Verification methods.
+const (
+	MethodDNS  = "dns"
+	MethodRepo = "repo"
+)
+
+const (
+	// dnsRecordPrefix is the label before the domain of a prefix in the name
+	// of the TXT record for a claim.
+	dnsRecordPrefix = "_pkgsite-verify."
+	// dnsValuePrefix is the prefix of the value of the TXT record.
+	dnsValuePrefix = "pkgsite-verify="
+	// repoPrefix is the prefix of the name of the repository for a claim.
+	repoPrefix = "pkgsite-verify-"
+	// checkTimeout is how long a verification waits for DNS or the code host.
+	checkTimeout = 10 * time.Second
+)
+
+// VerificationTTL is how long the verification of a claim lasts.
+const VerificationTTL = 90 * 24 * time.Hour
+
+// codeHosts are the hosts on which a prefix is verified with a repository
+// instead of DNS, because their owners are the users and organizations in the
+// first element of the path.
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+}
+
+// A Challenge tells the claimant of a prefix how to prove control of it.
+type Challenge struct {
+	Prefix string `json:"prefix"`
+	Token  string `json:"token"`
+	Method string `json:"method"`
+	// For the DNS method, Name is the name of the TXT record to create and
+	// Value its value. For the repo method, Name is the URL of the
+	// repository to create, and Value is empty.
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// NewToken returns a new random token for a claim.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewChallenge returns the challenge for the claim of prefix with token. It
+// returns an InvalidArgument error if the prefix cannot be claimed.
+func NewChallenge(prefix, token string) (_ *Challenge, err error) {
+	defer derrors.Wrap(&err, "NewChallenge(%q)", prefix)
+
+	if err := module.CheckPath(prefix); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, derrors.InvalidArgument)
+	}
+	host, rest, _ := strings.Cut(prefix, "/")
+	c := &Challenge{Prefix: prefix, Token: token}
+	if codeHosts[host] {
+		owner, _, _ := strings.Cut(rest, "/")
+		if owner == "" {
+			return nil, fmt.Errorf("prefixes on %s must include an owner: %w", host, derrors.InvalidArgument)
+		}
+		c.Method = MethodRepo
+		c.Name = fmt.Sprintf("https://%s/%s/%s%s", host, owner, repoPrefix, token)
+		return c, nil
+	}
+	c.Method = MethodDNS
+	c.Name = dnsRecordPrefix + host
+	c.Value = dnsValuePrefix + token
+	return c, nil
+}
+
+// A Verifier checks challenges.
+type Verifier struct {
+	lookupTXT  func(ctx context.Context, name string) ([]string, error)
+	httpClient *http.Client
+}
+
+// NewVerifier returns a Verifier that looks up TXT records with the default
+// resolver, and checks repositories with client.
+func NewVerifier(client *http.Client) *Verifier {
+	return &Verifier{
+		lookupTXT:  net.DefaultResolver.LookupTXT,
+		httpClient: client,
+	}
+}
+
+// Verify reports whether the claimant has met challenge c.
+func (v *Verifier) Verify(ctx context.Context, c *Challenge) (_ bool, err error) {
+	defer derrors.Wrap(&err, "Verify(ctx, %q, %q)", c.Prefix, c.Method)
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	switch c.Method {
+	case MethodDNS:
+		values, err := v.lookupTXT(ctx, c.Name)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, val := range values {
+			if strings.TrimSpace(val) == c.Value {
+				return true, nil
+			}
+		}
+		return false, nil
+	case MethodRepo:
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.Name, nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := v.httpClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return true, nil
+		case http.StatusNotFound:
+			return false, nil
+		default:
+			return false, fmt.Errorf("%s: %s", c.Name, resp.Status)
+		}
+	default:
+		return false, fmt.Errorf("unknown method %q", c.Method)
+	}
+}
+
+// Prefixes returns the prefixes of modulePath that can be claimed, from the
+// shortest to the longest, including modulePath itself.
+func Prefixes(modulePath string) []string {
+	var prefixes []string
+	for i := 0; i < len(modulePath); i++ {
+		if modulePath[i] == '/' {
+			prefixes = append(prefixes, modulePath[:i])
+		}
+	}
+	return append(prefixes, modulePath)
+}
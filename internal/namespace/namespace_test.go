@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package namespace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestNewChallenge(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		want   *Challenge
+	}{
+		{
+			prefix: "example.com/org",
+			want: &Challenge{
+				Prefix: "example.com/org",
+				Token:  "abc",
+				Method: MethodDNS,
+				Name:   "_pkgsite-verify.example.com",
+				Value:  "pkgsite-verify=abc",
+			},
+		},
+		{
+			prefix: "github.com/org/repo",
+			want: &Challenge{
+				Prefix: "github.com/org/repo",
+				Token:  "abc",
+				Method: MethodRepo,
+				Name:   "https://github.com/org/pkgsite-verify-abc",
+			},
+		},
+	} {
+		got, err := NewChallenge(test.prefix, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", test.prefix, diff)
+		}
+	}
+
+	for _, prefix := range []string{"github.com", "no spaces.com", "std", ""} {
+		if _, err := NewChallenge(prefix, "abc"); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("%q: got error %v, want InvalidArgument", prefix, err)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestVerify(t *testing.T) {
+	v := &Verifier{
+		lookupTXT: func(_ context.Context, name string) ([]string, error) {
+			switch name {
+			case "_pkgsite-verify.example.com":
+				return []string{"other", "pkgsite-verify=good"}, nil
+			default:
+				return nil, &net.DNSError{Name: name, IsNotFound: true}
+			}
+		},
+		httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			status := http.StatusNotFound
+			if r.URL.Path == "/org/pkgsite-verify-good" {
+				status = http.StatusOK
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})},
+	}
+	ctx := context.Background()
+	for _, test := range []struct {
+		prefix, token string
+		want          bool
+	}{
+		{"example.com/org", "good", true},
+		{"example.com/org", "bad", false},
+		{"other.com", "good", false},
+		{"github.com/org/repo", "good", true},
+		{"github.com/org/repo", "bad", false},
+	} {
+		c, err := NewChallenge(test.prefix, test.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := v.Verify(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("Verify(%q, %q) = %t, want %t", test.prefix, test.token, got, test.want)
+		}
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	got := Prefixes("github.com/org/repo")
+	want := []string{"github.com", "github.com/org", "github.com/org/repo"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -14,12 +14,20 @@ func (m *Module) RemoveNonRedistributableData() {
 	for _, d := range m.Units {
 		d.RemoveNonRedistributableData()
 	}
+	if !m.IsRedistributable {
+		if m.Metadata != nil {
+			m.Metadata.Description = ""
+		}
+		m.Guides = nil
+	}
 }
 
 func (u *Unit) RemoveNonRedistributableData() {
 	if !u.IsRedistributable {
 		u.Readme = nil
 		u.Documentation = nil
+		u.CodeIndex = nil
+		u.Examples = nil
 	}
 }
 
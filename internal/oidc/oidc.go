@@ -0,0 +1,438 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package oidc verifies OpenID Connect ID tokens, and authorizes requests by
+// the groups of the users they identify.
+//
+// It is meant for private instances that are behind an authenticating proxy,
+// which logs users in with an OpenID Connect provider and passes their ID
+// tokens on in a request header.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// clockSkew is the difference between the clocks of the provider and
+	// the server that is tolerated when checking the times in a token.
+	clockSkew = time.Minute
+
+	// minRefreshInterval is the minimum time between two fetches of the
+	// provider's keys, so that tokens with unknown key IDs cannot be used to
+	// make the server fetch them constantly.
+	minRefreshInterval = time.Minute
+
+	// maxResponseSize is the maximum size of the provider's configuration
+	// and keys.
+	maxResponseSize = 1 << 20
+)
+
+// ErrUnauthenticated is returned for requests without a valid ID token.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrForbidden is returned for requests whose users are not in an authorized
+// group.
+var ErrForbidden = errors.New("forbidden")
+
+// A Verifier verifies the ID tokens issued by an OpenID Connect provider for
+// a client. It is safe for concurrent use.
+type Verifier struct {
+	issuer   string
+	audience string
+	client   *http.Client
+	jwksURL  string
+
+	// group coalesces concurrent fetches of the keys, which are made without
+	// holding mu.
+	group singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // by key ID
+	fetchedAt time.Time
+}
+
+// NewVerifier returns a Verifier for the tokens that the provider at issuer
+// issues for the client with ID audience. It reads the provider's
+// configuration and keys with client.
+func NewVerifier(ctx context.Context, issuer, audience string, client *http.Client) (_ *Verifier, err error) {
+	defer derrors.Wrap(&err, "NewVerifier(ctx, %q, %q)", issuer, audience)
+
+	var cfg struct {
+		Issuer  string `json:"issuer"`
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Issuer != issuer {
+		return nil, fmt.Errorf("provider has issuer %q", cfg.Issuer)
+	}
+	if cfg.JWKSURL == "" {
+		return nil, errors.New("provider has no jwks_uri")
+	}
+	v := &Verifier{issuer: issuer, audience: audience, client: client, jwksURL: cfg.JWKSURL}
+	if err := v.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Claims are the claims of an ID token.
+type Claims map[string]interface{}
+
+// String returns the value of the claim name if it is a string, and the empty
+// string otherwise.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns the value of the claim name if it is a string or a list of
+// strings.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var ss []string
+		for _, x := range v {
+			if s, ok := x.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	}
+	return nil
+}
+
+// User returns the user identified by the claims: their email address if
+// there is one, or else their subject.
+func (c Claims) User() string {
+	if e := c.String("email"); e != "" {
+		return e
+	}
+	return c.String("sub")
+}
+
+// Verify checks the signature, issuer, audience and times of the ID token, and
+// returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (_ Claims, err error) {
+	defer derrors.Wrap(&err, "Verify")
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims checks the issuer, audience and times of claims.
+func (v *Verifier) checkClaims(claims Claims, now time.Time) error {
+	if iss := claims.String("iss"); iss != v.issuer {
+		return fmt.Errorf("token has issuer %q", iss)
+	}
+	found := false
+	for _, aud := range claims.Strings("aud") {
+		if aud == v.audience {
+			found = true
+		}
+	}
+	if !found {
+		return errors.New("token is not for this audience")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiration time")
+	}
+	if now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+// key returns the provider's key with ID kid. If there is none, it fetches
+// the keys again, because the provider may have rotated them.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k, ok, canRefresh := v.lookupKey(kid)
+	if ok {
+		return k, nil
+	}
+	if canRefresh {
+		if err := v.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+		if k, ok, _ := v.lookupKey(kid); ok {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key ID %q", kid)
+}
+
+// lookupKey returns the key with ID kid, if there is one, and whether the
+// keys may be fetched again.
+func (v *Verifier) lookupKey(kid string) (_ crypto.PublicKey, ok, canRefresh bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	k, ok := v.keys[kid]
+	return k, ok, time.Since(v.fetchedAt) >= minRefreshInterval
+}
+
+// refreshKeys fetches the provider's keys, unless they were fetched less than
+// minRefreshInterval ago. The keys are replaced only after the fetch, so that
+// requests with known keys are not blocked by it.
+func (v *Verifier) refreshKeys(ctx context.Context) error {
+	_, err, _ := v.group.Do("keys", func() (interface{}, error) {
+		if _, _, canRefresh := v.lookupKey(""); !canRefresh {
+			// Another call fetched them since this one checked.
+			return nil, nil
+		}
+		keys, err := v.fetchKeys(ctx)
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.fetchedAt = time.Now()
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		return nil, nil
+	})
+	return err
+}
+
+// fetchKeys fetches the provider's signing keys, by key ID.
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, v.client, v.jwksURL, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		pub, err := k.publicKey()
+		if err != nil {
+			// Skip keys that can't be used to verify tokens, such as
+			// encryption keys.
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+// A jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Elliptic curve keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey returns the public key k, if it is an RSA or P-256 signing key.
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key use %q", k.Use)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifySignature checks that sig is the signature of signed with key, using
+// the algorithm alg. Only RS256 and ES256 are supported.
+func verifySignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	h := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA key")
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, h[:], sig)
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an ECDSA key")
+		}
+		if len(sig) != 64 {
+			return errors.New("bad signature length")
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, h[:], r, s) {
+			return errors.New("bad signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+// An Authorizer authorizes requests whose ID tokens are valid and name one of
+// a set of groups.
+type Authorizer struct {
+	// Verifier verifies the ID tokens.
+	Verifier *Verifier
+	// Header is the request header with the ID token. If it is
+	// "Authorization", the token is a bearer token.
+	Header string
+	// GroupsClaim is the claim with the groups of the user, such as
+	// "groups".
+	GroupsClaim string
+	// Groups are the authorized groups. If there are none, all users with
+	// a valid ID token are authorized.
+	Groups []string
+}
+
+// Authorize returns the user who made r. It returns an error wrapping
+// ErrUnauthenticated if r has no valid ID token, or ErrForbidden if the user
+// is not in an authorized group.
+func (a *Authorizer) Authorize(r *http.Request) (user string, err error) {
+	token := r.Header.Get(a.Header)
+	if strings.EqualFold(a.Header, "Authorization") {
+		const prefix = "bearer "
+		if len(token) < len(prefix) || !strings.EqualFold(token[:len(prefix)], prefix) {
+			return "", fmt.Errorf("%w: no bearer token", ErrUnauthenticated)
+		}
+		token = token[len(prefix):]
+	}
+	if token == "" {
+		return "", fmt.Errorf("%w: no ID token", ErrUnauthenticated)
+	}
+	claims, err := a.Verifier.Verify(r.Context(), token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	user = claims.User()
+	if len(a.Groups) == 0 {
+		return user, nil
+	}
+	for _, g := range claims.Strings(a.GroupsClaim) {
+		for _, ag := range a.Groups {
+			if g == ag {
+				return user, nil
+			}
+		}
+	}
+	return user, fmt.Errorf("%w: %s is not in an authorized group", ErrForbidden, user)
+}
+
+// getJSON decodes the JSON response to a GET request for u into v.
+func getJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a token into v.
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// decodeInt decodes a base64url-encoded big-endian integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
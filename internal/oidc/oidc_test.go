@@ -0,0 +1,232 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const audience = "pkgsite"
+
+// testProvider is an OpenID Connect provider with an RSA key and an ECDSA
+// key.
+type testProvider struct {
+	*httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{rsaKey: rsaKey, ecKey: ecKey}
+	enc := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{}
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			v = map[string]string{"issuer": p.URL, "jwks_uri": p.URL + "/keys"}
+		case "/keys":
+			v = map[string]interface{}{"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa", "use": "sig", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec", "crv": "P-256", "x": enc(ecKey.X.Bytes()), "y": enc(ecKey.Y.Bytes())},
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}))
+	return p
+}
+
+// token returns a token with claims, signed with the key kid.
+func (p *testProvider) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	alg := map[string]string{"rsa": "RS256", "ec": "ES256"}[kid]
+	signed := enc(map[string]string{"alg": alg, "kid": kid}) + "." + enc(claims)
+	h := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch kid {
+	case "rsa":
+		s, err := rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, h[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case "ec":
+		r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, h[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (p *testProvider) claims(extra map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"iss":   p.URL,
+		"aud":   audience,
+		"sub":   "123",
+		"email": "gopher@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.Close()
+	ctx := context.Background()
+	v, err := NewVerifier(ctx, p.URL, audience, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kid := range []string{"rsa", "ec"} {
+		claims, err := v.Verify(ctx, p.token(t, kid, p.claims(nil)))
+		if err != nil {
+			t.Fatalf("%s: %v", kid, err)
+		}
+		if got, want := claims.User(), "gopher@example.com"; got != want {
+			t.Errorf("%s: got user %q, want %q", kid, got, want)
+		}
+	}
+
+	for _, test := range []struct {
+		name  string
+		token string
+	}{
+		{"expired", p.token(t, "rsa", p.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		{"not yet valid", p.token(t, "rsa", p.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()}))},
+		{"other audience", p.token(t, "rsa", p.claims(map[string]interface{}{"aud": []string{"other"}}))},
+		{"other issuer", p.token(t, "ec", p.claims(map[string]interface{}{"iss": "https://example.com"}))},
+		{"tampered", p.token(t, "rsa", p.claims(nil))[1:]},
+		{"malformed", "abc"},
+	} {
+		if _, err := v.Verify(ctx, test.token); err == nil {
+			t.Errorf("%s: got no error", test.name)
+		}
+	}
+}
+
+// roundTripFunc is an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestVerifyDuringRefresh(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.Close()
+	ctx := context.Background()
+	v, err := NewVerifier(ctx, p.URL, audience, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Block the next fetch of the keys.
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	transport := p.Client().Transport
+	v.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		close(fetching)
+		<-release
+		return transport.RoundTrip(r)
+	})}
+	v.fetchedAt = time.Time{}
+
+	done := make(chan error)
+	go func() {
+		_, err := v.Verify(ctx, unknownKeyToken(t, p))
+		done <- err
+	}()
+	<-fetching
+	// A token with a known key is verified while the keys are fetched.
+	if _, err := v.Verify(ctx, p.token(t, "ec", p.claims(nil))); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-done; err == nil {
+		t.Error("got no error for an unknown key ID")
+	}
+}
+
+// unknownKeyToken returns a token whose key ID the provider doesn't have.
+func unknownKeyToken(t *testing.T, p *testProvider) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	return enc(map[string]string{"alg": "RS256", "kid": "other"}) + "." + enc(p.claims(nil)) + ".c2ln"
+}
+
+func TestAuthorize(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.Close()
+	v, err := NewVerifier(context.Background(), p.URL, audience, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Authorizer{Verifier: v, Header: "Authorization", GroupsClaim: "groups", Groups: []string{"fetchers"}}
+
+	for _, test := range []struct {
+		name     string
+		header   string
+		wantUser string
+		wantErr  error
+	}{
+		{"member", "Bearer " + p.token(t, "rsa", p.claims(map[string]interface{}{"groups": []string{"eng", "fetchers"}})), "gopher@example.com", nil},
+		{"not a member", "Bearer " + p.token(t, "rsa", p.claims(map[string]interface{}{"groups": "eng"})), "gopher@example.com", ErrForbidden},
+		{"no groups", "Bearer " + p.token(t, "ec", p.claims(nil)), "gopher@example.com", ErrForbidden},
+		{"no token", "", "", ErrUnauthenticated},
+		{"bad token", "Bearer abc", "", ErrUnauthenticated},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/fetch/example.com/m", nil)
+		if test.header != "" {
+			r.Header.Set("Authorization", test.header)
+		}
+		user, err := a.Authorize(r)
+		if user != test.wantUser || !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", test.name, user, err, test.wantUser, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// UpsertAnalysisResults stores results as the results of analyzer for
+// modulePath@version, replacing any earlier ones. It returns a NotFound error
+// if the module version is not in the database.
+func (db *DB) UpsertAnalysisResults(ctx context.Context, modulePath, version, analyzer string, results []*internal.AnalysisResult) (err error) {
+	defer derrors.WrapStack(&err, "UpsertAnalysisResults(ctx, %q, %q, %q)", modulePath, version, analyzer)
+
+	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		var moduleID int
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM modules WHERE module_path = $1 AND version = $2`,
+			modulePath, version).Scan(&moduleID)
+		if err == sql.ErrNoRows {
+			return derrors.NotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM analysis_results WHERE module_id = $1 AND analyzer = $2`,
+			moduleID, analyzer); err != nil {
+			return err
+		}
+		var values []interface{}
+		for _, r := range results {
+			values = append(values, moduleID, analyzer, r.PackagePath, r.Severity, r.Message, r.URL)
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		cols := []string{"module_id", "analyzer", "package_path", "severity", "message", "url"}
+		return tx.BulkInsert(ctx, "analysis_results", cols, values, "")
+	})
+}
+
+// GetAnalysisResults returns the analysis results of modulePath@version,
+// sorted by analyzer and package path, in the order they were stored within
+// those.
+func (db *DB) GetAnalysisResults(ctx context.Context, modulePath, version string) (_ []*internal.AnalysisResult, err error) {
+	defer derrors.WrapStack(&err, "GetAnalysisResults(ctx, %q, %q)", modulePath, version)
+
+	var results []*internal.AnalysisResult
+	err = db.db.RunQuery(ctx, `
+		SELECT r.analyzer, r.package_path, r.severity, r.message, r.url
+		FROM analysis_results r
+		INNER JOIN modules m ON m.id = r.module_id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY r.analyzer, r.package_path, r.id`,
+		func(rows *sql.Rows) error {
+			var r internal.AnalysisResult
+			if err := rows.Scan(&r.Analyzer, &r.PackagePath, &r.Severity, &r.Message, &r.URL); err != nil {
+				return err
+			}
+			results = append(results, &r)
+			return nil
+		}, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestAnalysisResults(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module("example.com/m", "v1.0.0", "a")
+	MustInsertModule(ctx, t, testDB, m)
+
+	lint := []*internal.AnalysisResult{
+		{PackagePath: "example.com/m/a", Severity: "warning", Message: "exported func without comment"},
+		{Severity: "info", Message: "looks fine", URL: "https://example.com/lint"},
+	}
+	policy := []*internal.AnalysisResult{
+		{Severity: "error", Message: "license not allowed"},
+	}
+	for _, c := range []struct {
+		analyzer string
+		results  []*internal.AnalysisResult
+	}{
+		{"lint", lint},
+		{"policy", policy},
+		// Results replace the earlier ones of the same analyzer.
+		{"policy", policy[:0]},
+		{"policy", policy},
+	} {
+		if err := testDB.UpsertAnalysisResults(ctx, m.ModulePath, m.Version, c.analyzer, c.results); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetAnalysisResults(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.AnalysisResult{
+		{Analyzer: "lint", Severity: "info", Message: "looks fine", URL: "https://example.com/lint"},
+		{Analyzer: "lint", PackagePath: "example.com/m/a", Severity: "warning", Message: "exported func without comment"},
+		{Analyzer: "policy", Severity: "error", Message: "license not allowed"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	err = testDB.UpsertAnalysisResults(ctx, m.ModulePath, "v9.9.9", "lint", lint)
+	if !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// InsertAPIKey adds the API key k, whose hash is hash, and returns its ID.
+// The ID and times of k are ignored.
+func (db *DB) InsertAPIKey(ctx context.Context, k *apikey.Key, hash []byte) (_ int, err error) {
+	defer derrors.WrapStack(&err, "InsertAPIKey(ctx, %q)", k.Name)
+
+	var id int
+	err = db.db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, key_hash, key_prefix, scopes, rate_limit)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		k.Name, hash, k.Prefix, pq.Array(k.Scopes), k.RateLimit).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// RevokeAPIKey revokes the API key with the given ID. It returns a NotFound
+// error if there is no such active key.
+func (db *DB) RevokeAPIKey(ctx context.Context, id int) (err error) {
+	defer derrors.WrapStack(&err, "RevokeAPIKey(ctx, %d)", id)
+
+	n, err := db.db.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// GetAPIKey returns the active API key whose hash is hash. It returns a
+// NotFound error if there is none.
+func (db *DB) GetAPIKey(ctx context.Context, hash []byte) (_ *apikey.Key, err error) {
+	defer derrors.WrapStack(&err, "GetAPIKey(ctx)")
+
+	k, err := scanAPIKey(db.db.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`,
+		hash).Scan)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// GetAPIKeys returns all API keys, including revoked ones, by ID.
+func (db *DB) GetAPIKeys(ctx context.Context) (_ []*apikey.Key, err error) {
+	defer derrors.WrapStack(&err, "GetAPIKeys(ctx)")
+
+	var keys []*apikey.Key
+	err = db.db.RunQuery(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		ORDER BY id`,
+		func(rows *sql.Rows) error {
+			k, err := scanAPIKey(rows.Scan)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, k)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+const apiKeyColumns = `id, name, key_prefix, scopes, rate_limit, created_at, revoked_at`
+
+func scanAPIKey(scan func(dest ...interface{}) error) (*apikey.Key, error) {
+	var (
+		k         apikey.Key
+		revokedAt sql.NullTime
+	)
+	if err := scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.RateLimit, &k.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	k.RevokedAt = revokedAt.Time
+	return &k, nil
+}
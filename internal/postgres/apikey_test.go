@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/apikey"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestAPIKeys(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	want := &apikey.Key{Name: "ci", Prefix: "pkgsite_abcdef", Scopes: []string{"read", "fetch"}, RateLimit: 60}
+	id, err := testDB.InsertAPIKey(ctx, want, apikey.Hash("pkgsite_abcdefg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.ID = id
+	got, err := testDB.GetAPIKey(ctx, apikey.Hash("pkgsite_abcdefg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := cmpopts.IgnoreFields(apikey.Key{}, "CreatedAt")
+	if diff := cmp.Diff(want, got, opt); diff != "" {
+		t.Errorf("GetAPIKey mismatch (-want, +got):\n%s", diff)
+	}
+	if _, err := testDB.GetAPIKey(ctx, apikey.Hash("other")); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetAPIKey(other): got %v, want NotFound", err)
+	}
+
+	if err := testDB.RevokeAPIKey(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.GetAPIKey(ctx, apikey.Hash("pkgsite_abcdefg")); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetAPIKey after revocation: got %v, want NotFound", err)
+	}
+	if err := testDB.RevokeAPIKey(ctx, id); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("RevokeAPIKey twice: got %v, want NotFound", err)
+	}
+	keys, err := testDB.GetAPIKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].RevokedAt.IsZero() {
+		t.Errorf("GetAPIKeys: got %+v, want one revoked key", keys)
+	}
+}
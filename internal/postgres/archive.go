@@ -0,0 +1,311 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+// GetModuleVersionsUpdatedSince returns the module versions that were
+// inserted or updated since the given time, sorted by module path and
+// version.
+func (db *DB) GetModuleVersionsUpdatedSince(ctx context.Context, since time.Time) (_ []internal.Modver, err error) {
+	defer derrors.WrapStack(&err, "GetModuleVersionsUpdatedSince(ctx, %s)", since)
+
+	var mvs []internal.Modver
+	err = db.db.RunQuery(ctx, `
+		SELECT module_path, version
+		FROM modules
+		WHERE updated_at >= $1
+		ORDER BY module_path, sort_version`,
+		func(rows *sql.Rows) error {
+			var mv internal.Modver
+			if err := rows.Scan(&mv.Path, &mv.Version); err != nil {
+				return err
+			}
+			mvs = append(mvs, mv)
+			return nil
+		}, since)
+	if err != nil {
+		return nil, err
+	}
+	return mvs, nil
+}
+
+// GetModule returns modulePath@version with all its processed data: its
+// licenses, its units with their documentation in every build context and
+// their symbols, and the contents of its go.mod file. InsertModule can insert
+// the result into another database without fetching the module again.
+// It returns a NotFound error if the module version is not in the database.
+func (db *DB) GetModule(ctx context.Context, modulePath, version string) (_ *internal.Module, err error) {
+	defer derrors.WrapStack(&err, "GetModule(ctx, %q, %q)", modulePath, version)
+
+	var moduleID int
+	err = db.db.QueryRow(ctx, `SELECT id FROM modules WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(&moduleID)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	mi, err := db.GetModuleInfo(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	m := &internal.Module{ModuleInfo: *mi}
+	if m.MigrationGuide, err = db.GetMigrationGuide(ctx, modulePath, version); err != nil {
+		return nil, err
+	}
+	if m.Metadata, err = db.GetModuleMetadata(ctx, modulePath, version); err != nil {
+		return nil, err
+	}
+	if m.Replacements, err = db.GetModuleReplacements(ctx, modulePath, version); err != nil {
+		return nil, err
+	}
+	if m.Requirements, err = getModuleRequirements(ctx, db.db, moduleID); err != nil {
+		return nil, err
+	}
+	if m.Guides, err = getModuleGuides(ctx, db.db, moduleID); err != nil {
+		return nil, err
+	}
+	if m.Licenses, err = db.getAllModuleLicenses(ctx, moduleID); err != nil {
+		return nil, err
+	}
+	if m.Units, err = db.getModuleUnits(ctx, m, moduleID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// getAllModuleLicenses returns the licenses of the module with the given ID,
+// including those in subdirectories.
+func (db *DB) getAllModuleLicenses(ctx context.Context, moduleID int) (_ []*licenses.License, err error) {
+	defer derrors.WrapStack(&err, "getAllModuleLicenses(ctx, %d)", moduleID)
+
+	rows, err := db.db.Query(ctx, `
+		SELECT types, file_path, contents, coverage
+		FROM licenses
+		WHERE module_id = $1
+		ORDER BY file_path`, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return collectLicenses(rows, db.bypassLicenseCheck)
+}
+
+func getModuleRequirements(ctx context.Context, db *database.DB, moduleID int) (_ []*internal.ModuleRequirement, err error) {
+	defer derrors.WrapStack(&err, "getModuleRequirements(ctx, %d)", moduleID)
+
+	var rs []*internal.ModuleRequirement
+	err = db.RunQuery(ctx, `
+		SELECT path, version, indirect
+		FROM module_requirements
+		WHERE module_id = $1
+		ORDER BY path`,
+		func(rows *sql.Rows) error {
+			var r internal.ModuleRequirement
+			if err := rows.Scan(&r.Path, &r.Version, &r.Indirect); err != nil {
+				return err
+			}
+			rs = append(rs, &r)
+			return nil
+		}, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func getModuleGuides(ctx context.Context, db *database.DB, moduleID int) (_ []*internal.Readme, err error) {
+	defer derrors.WrapStack(&err, "getModuleGuides(ctx, %d)", moduleID)
+
+	var guides []*internal.Readme
+	err = db.RunQuery(ctx, `
+		SELECT file_path, contents
+		FROM module_guides
+		WHERE module_id = $1
+		ORDER BY file_path`,
+		func(rows *sql.Rows) error {
+			var g internal.Readme
+			if err := rows.Scan(&g.Filepath, &g.Contents); err != nil {
+				return err
+			}
+			guides = append(guides, &g)
+			return nil
+		}, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	return guides, nil
+}
+
+// getModuleUnits returns the units of m, which has the given ID, with all
+// their data.
+func (db *DB) getModuleUnits(ctx context.Context, m *internal.Module, moduleID int) (_ []*internal.Unit, err error) {
+	defer derrors.WrapStack(&err, "getModuleUnits(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	var (
+		units   []*internal.Unit
+		unitIDs []int
+	)
+	err = db.db.RunQuery(ctx, `
+		SELECT
+			u.id,
+			p.path,
+			u.name,
+			u.redistributable,
+			u.license_types,
+			u.license_paths,
+			u.num_test_files,
+			COALESCE(u.num_tests, 0),
+			COALESCE(u.num_benchmarks, 0),
+			COALESCE(u.num_fuzz_targets, 0),
+			COALESCE(u.num_examples, 0),
+			u.benchmarks,
+			r.file_path,
+			r.contents
+		FROM units u
+		INNER JOIN paths p ON p.id = u.path_id
+		LEFT JOIN readmes r ON r.unit_id = u.id
+		WHERE u.module_id = $1
+		ORDER BY p.path`,
+		func(rows *sql.Rows) error {
+			var (
+				id                         int
+				u                          = &internal.Unit{}
+				licenseTypes, licensePaths []string
+				numTestFiles               sql.NullInt64
+				ts                         internal.TestStats
+				r                          internal.Readme
+			)
+			if err := rows.Scan(&id, &u.Path, &u.Name, &u.IsRedistributable,
+				pq.Array(&licenseTypes), pq.Array(&licensePaths),
+				&numTestFiles, &ts.NumTests, &ts.NumBenchmarks, &ts.NumFuzzTargets, &ts.NumExamples,
+				pq.Array(&u.Benchmarks),
+				database.NullIsEmpty(&r.Filepath), database.NullIsEmpty(&r.Contents)); err != nil {
+				return err
+			}
+			lics, err := zipLicenseMetadata(licenseTypes, licensePaths)
+			if err != nil {
+				return err
+			}
+			u.Licenses = lics
+			u.ModuleInfo = m.ModuleInfo
+			if numTestFiles.Valid {
+				ts.NumTestFiles = int(numTestFiles.Int64)
+				u.TestStats = &ts
+			}
+			if r.Filepath != "" {
+				u.Readme = &r
+			}
+			units = append(units, u)
+			unitIDs = append(unitIDs, id)
+			return nil
+		}, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range units {
+		id := unitIDs[i]
+		if u.Imports, err = db.getImports(ctx, id); err != nil {
+			return nil, err
+		}
+		if u.FuzzTargets, err = getFuzzTargets(ctx, db.db, id); err != nil {
+			return nil, err
+		}
+		if u.CodeIndex, err = getCodeIndex(ctx, db.db, id); err != nil {
+			return nil, err
+		}
+		if u.Documentation, err = db.getAllDocumentation(ctx, u, id); err != nil {
+			return nil, err
+		}
+	}
+	return units, nil
+}
+
+func getCodeIndex(ctx context.Context, db *database.DB, unitID int) (_ *internal.CodeIndex, err error) {
+	defer derrors.WrapStack(&err, "getCodeIndex(ctx, %d)", unitID)
+
+	var index *internal.CodeIndex
+	err = db.QueryRow(ctx, `SELECT contents FROM code_indexes WHERE unit_id = $1`, unitID).Scan(jsonbScanner{&index})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// getAllDocumentation returns the documentation of u, which has the given
+// ID, in every build context, with its API.
+func (db *DB) getAllDocumentation(ctx context.Context, u *internal.Unit, unitID int) (_ []*internal.Documentation, err error) {
+	defer derrors.WrapStack(&err, "getAllDocumentation(ctx, %q)", u.Path)
+
+	var docs []*internal.Documentation
+	err = db.db.RunQuery(ctx, `
+		SELECT goos, goarch, synopsis, source, COALESCE(truncated, false), content_hash
+		FROM documentation
+		WHERE unit_id = $1
+		ORDER BY goos, goarch`,
+		func(rows *sql.Rows) error {
+			var d internal.Documentation
+			if err := rows.Scan(&d.GOOS, &d.GOARCH, &d.Synopsis, &d.Source, &d.Truncated,
+				database.NullIsEmpty(&d.ContentHash)); err != nil {
+				return err
+			}
+			docs = append(docs, &d)
+			return nil
+		}, unitID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		sms, err := db.GetDocumentationSymbols(ctx, u.Path, u.ModulePath, u.Version,
+			internal.BuildContext{GOOS: d.GOOS, GOARCH: d.GOARCH})
+		if err != nil {
+			return nil, err
+		}
+		d.API = symbolTree(sms, d.GOOS, d.GOARCH)
+	}
+	return docs, nil
+}
+
+// symbolTree returns the symbols of an API, as stored with their parents,
+// with the children of each type under it, as they are when a package is
+// loaded.
+func symbolTree(sms []*internal.SymbolMeta, goos, goarch string) []*internal.Symbol {
+	var (
+		api    []*internal.Symbol
+		byName = map[string]*internal.Symbol{}
+	)
+	for _, sm := range sms {
+		if sm.ParentName == sm.Name {
+			s := &internal.Symbol{SymbolMeta: *sm, GOOS: goos, GOARCH: goarch}
+			s.ParentName = ""
+			api = append(api, s)
+			byName[s.Name] = s
+		}
+	}
+	for _, sm := range sms {
+		if sm.ParentName == sm.Name {
+			continue
+		}
+		if p := byName[sm.ParentName]; p != nil {
+			p.Children = append(p.Children, sm)
+		}
+	}
+	return api
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetModule(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	start := time.Now().Add(-time.Minute)
+	m := sample.Module("example.com/m", "v1.2.3", "a", "a/b")
+	MustInsertModule(ctx, t, testDB, m)
+
+	mvs, err := testDB.GetModuleVersionsUpdatedSince(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []internal.Modver{{Path: "example.com/m", Version: "v1.2.3"}}; !cmp.Equal(mvs, want) {
+		t.Errorf("GetModuleVersionsUpdatedSince: got %v, want %v", mvs, want)
+	}
+	mvs, err = testDB.GetModuleVersionsUpdatedSince(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mvs) != 0 {
+		t.Errorf("GetModuleVersionsUpdatedSince(future): got %v, want none", mvs)
+	}
+
+	got, err := testDB.GetModule(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ModulePath != m.ModulePath || got.Version != m.Version {
+		t.Errorf("got %s@%s, want %s@%s", got.ModulePath, got.Version, m.ModulePath, m.Version)
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(licenses.Metadata{}, "Coverage", "OldCoverage"),
+		cmpopts.IgnoreFields(licenses.License{}, "Contents"),
+	}
+	if diff := cmp.Diff(m.Licenses, got.Licenses, opts...); diff != "" {
+		t.Errorf("licenses mismatch (-want +got):\n%s", diff)
+	}
+	if len(got.Units) != len(m.Units) {
+		t.Fatalf("got %d units, want %d", len(got.Units), len(m.Units))
+	}
+	for i, u := range got.Units {
+		want := m.Units[i]
+		if u.Path != want.Path {
+			t.Errorf("unit %d: got path %q, want %q", i, u.Path, want.Path)
+		}
+		if diff := cmp.Diff(want.Imports, u.Imports, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%s: imports mismatch (-want +got):\n%s", u.Path, diff)
+		}
+		if len(u.Documentation) != len(want.Documentation) {
+			t.Errorf("%s: got %d documentation rows, want %d", u.Path, len(u.Documentation), len(want.Documentation))
+			continue
+		}
+		for j, d := range u.Documentation {
+			wd := want.Documentation[j]
+			if d.Synopsis != wd.Synopsis || string(d.Source) != string(wd.Source) {
+				t.Errorf("%s: documentation %d differs", u.Path, j)
+			}
+			if diff := cmp.Diff(symbolNames(wd.API), symbolNames(d.API)); diff != "" {
+				t.Errorf("%s: API mismatch (-want +got):\n%s", u.Path, diff)
+			}
+		}
+	}
+
+	if _, err := testDB.GetModule(ctx, "example.com/m", "v9.9.9"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetModule(missing): got %v, want NotFound", err)
+	}
+}
+
+func symbolNames(api []*internal.Symbol) []string {
+	var names []string
+	for _, s := range api {
+		names = append(names, s.Name)
+		for _, c := range s.Children {
+			names = append(names, s.Name+"/"+c.Name)
+		}
+	}
+	return names
+}
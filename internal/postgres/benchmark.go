@@ -0,0 +1,113 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A BenchmarkResult summarizes the runs of one benchmark of a package in a
+// module version, for one unit of measurement.
+type BenchmarkResult struct {
+	PackagePath string
+	// Name is the name of the benchmark, without the GOMAXPROCS suffix, such
+	// as "BenchmarkDecode/small".
+	Name string
+	// Unit is the unit of measurement, such as "ns/op".
+	Unit string
+	// Value is the mean of the values of the runs.
+	Value   float64
+	NumRuns int
+}
+
+// BenchmarkVersion holds the benchmark results of a package in one version of
+// its module.
+type BenchmarkVersion struct {
+	Version string
+	Results []*BenchmarkResult
+}
+
+// UpsertBenchmarkResults stores results as the benchmark results of
+// modulePath@version, replacing any earlier ones. It returns a NotFound error
+// if the module version is not in the database.
+func (db *DB) UpsertBenchmarkResults(ctx context.Context, modulePath, version string, results []*BenchmarkResult) (err error) {
+	defer derrors.WrapStack(&err, "UpsertBenchmarkResults(ctx, %q, %q)", modulePath, version)
+
+	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		var moduleID int
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM modules WHERE module_path = $1 AND version = $2`,
+			modulePath, version).Scan(&moduleID)
+		if err == sql.ErrNoRows {
+			return derrors.NotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM benchmark_results WHERE module_id = $1`, moduleID); err != nil {
+			return err
+		}
+		var values []interface{}
+		for _, r := range results {
+			values = append(values, moduleID, r.PackagePath, r.Name, r.Unit, r.Value, r.NumRuns)
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		cols := []string{"module_id", "package_path", "name", "unit", "value", "num_runs"}
+		return tx.BulkInsert(ctx, "benchmark_results", cols, values, "")
+	})
+}
+
+// GetBenchmarkHistory returns the benchmark results of the package at
+// pkgPath in version and in the earlier versions of modulePath, for the
+// maxVersions most recent versions that have results, oldest first. The
+// results of each version are sorted by name and unit.
+func (db *DB) GetBenchmarkHistory(ctx context.Context, pkgPath, modulePath, version string, maxVersions int) (_ []*BenchmarkVersion, err error) {
+	defer derrors.WrapStack(&err, "GetBenchmarkHistory(ctx, %q, %q, %q)", pkgPath, modulePath, version)
+
+	query := `
+		WITH versions AS (
+			SELECT m.id, m.version, m.sort_version
+			FROM modules m
+			WHERE m.module_path = $2
+			AND m.sort_version <= (
+				SELECT sort_version FROM modules WHERE module_path = $2 AND version = $3)
+			AND EXISTS (
+				SELECT 1 FROM benchmark_results b
+				WHERE b.module_id = m.id AND b.package_path = $1)
+			ORDER BY m.sort_version DESC
+			LIMIT $4
+		)
+		SELECT v.version, b.name, b.unit, b.value, b.num_runs
+		FROM benchmark_results b
+		INNER JOIN versions v ON v.id = b.module_id
+		WHERE b.package_path = $1
+		ORDER BY v.sort_version, b.name, b.unit`
+	var history []*BenchmarkVersion
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var (
+			v string
+			r = BenchmarkResult{PackagePath: pkgPath}
+		)
+		if err := rows.Scan(&v, &r.Name, &r.Unit, &r.Value, &r.NumRuns); err != nil {
+			return err
+		}
+		if len(history) == 0 || history[len(history)-1].Version != v {
+			history = append(history, &BenchmarkVersion{Version: v})
+		}
+		bv := history[len(history)-1]
+		bv.Results = append(bv.Results, &r)
+		return nil
+	}, pkgPath, modulePath, version, maxVersions)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
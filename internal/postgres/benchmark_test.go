@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestBenchmarkResults(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, v := range []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0"} {
+		MustInsertModule(ctx, t, testDB, sample.Module(sample.ModulePath, v, "a"))
+	}
+	pkg := sample.ModulePath + "/a"
+
+	if err := testDB.UpsertBenchmarkResults(ctx, "example.com/missing", "v1.0.0", nil); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+
+	result := func(name string, value float64) *BenchmarkResult {
+		return &BenchmarkResult{PackagePath: pkg, Name: name, Unit: "ns/op", Value: value, NumRuns: 5}
+	}
+	for _, u := range []struct {
+		version string
+		results []*BenchmarkResult
+	}{
+		{"v1.0.0", []*BenchmarkResult{result("BenchmarkA", 10)}},
+		{"v1.1.0", []*BenchmarkResult{result("BenchmarkA", 99)}},
+		// Uploading again replaces the results.
+		{"v1.1.0", []*BenchmarkResult{result("BenchmarkA", 12), result("BenchmarkB", 3)}},
+		{"v1.3.0", []*BenchmarkResult{result("BenchmarkA", 8)}},
+	} {
+		if err := testDB.UpsertBenchmarkResults(ctx, sample.ModulePath, u.version, u.results); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		version     string
+		maxVersions int
+		want        []*BenchmarkVersion
+	}{
+		{
+			version:     "v1.2.0",
+			maxVersions: 5,
+			want: []*BenchmarkVersion{
+				{Version: "v1.0.0", Results: []*BenchmarkResult{result("BenchmarkA", 10)}},
+				{Version: "v1.1.0", Results: []*BenchmarkResult{result("BenchmarkA", 12), result("BenchmarkB", 3)}},
+			},
+		},
+		{
+			version:     "v1.3.0",
+			maxVersions: 2,
+			want: []*BenchmarkVersion{
+				{Version: "v1.1.0", Results: []*BenchmarkResult{result("BenchmarkA", 12), result("BenchmarkB", 3)}},
+				{Version: "v1.3.0", Results: []*BenchmarkResult{result("BenchmarkA", 8)}},
+			},
+		},
+	} {
+		got, err := testDB.GetBenchmarkHistory(ctx, pkg, sample.ModulePath, test.version, test.maxVersions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", test.version, diff)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/xcontext"
+)
+
+// coalesceTimeout bounds the time of a coalesced call, which does not end
+// when the requests waiting for it are canceled.
+const coalesceTimeout = time.Minute
+
+// coalesce calls fn and returns its result. Concurrent calls with the same
+// key share a single call of fn, so that a burst of identical requests, such
+// as for a popular package after a release, results in a single query.
+//
+// Since the result is shared, callers must copy it before modifying it.
+// The experiments active in ctx are part of the key, because they may change
+// the result.
+//
+// fn is called with a context that is not canceled when ctx is, since other
+// callers may be waiting for it, but a caller stops waiting when its own ctx
+// is done.
+func (db *DB) coalesce(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	exps := experiment.FromContext(ctx).Active()
+	sort.Strings(exps)
+	key += " " + strings.Join(exps, ",")
+	ch := db.group.DoChan(key, func() (interface{}, error) {
+		fctx, cancel := context.WithTimeout(xcontext.Detach(ctx), coalesceTimeout)
+		defer cancel()
+		return fn(fctx)
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			log.Debugf(ctx, "coalesced %s", key)
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
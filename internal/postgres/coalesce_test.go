@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+func TestCoalesce(t *testing.T) {
+	db := &DB{}
+	ctx := context.Background()
+
+	// Concurrent calls with the same key share a single call.
+	var calls int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func(context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return "result", nil
+	}
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	call := func(i int) {
+		defer wg.Done()
+		v, err := db.coalesce(ctx, "k", fn)
+		if err != nil {
+			t.Error(err)
+		}
+		results[i] = v
+	}
+	wg.Add(n)
+	go call(0)
+	<-started
+	for i := 1; i < n; i++ {
+		go call(i)
+	}
+	// Give the other calls time to join the first one.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+	for _, r := range results {
+		if r != "result" {
+			t.Errorf("got %v, want %q", r, "result")
+		}
+	}
+
+	// A caller whose context is done stops waiting.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	block := make(chan struct{})
+	defer close(block)
+	_, err := db.coalesce(cctx, "blocked", func(context.Context) (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	// Experiments are part of the key.
+	var keys []string
+	record := func(ctx context.Context) (interface{}, error) {
+		keys = append(keys, "x")
+		return nil, nil
+	}
+	db.coalesce(ctx, "e", record)
+	db.coalesce(experiment.NewContext(ctx, "exp"), "e", record)
+	if len(keys) != 2 {
+		t.Errorf("got %d calls, want 2", len(keys))
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A PackageCodeIndex is the code index of a package.
+type PackageCodeIndex struct {
+	Path  string
+	Index *internal.CodeIndex
+}
+
+// GetCodeIndexes returns the code indexes of the packages of
+// modulePath@version whose path is dirPath or begins with dirPath + "/",
+// sorted by path.
+func (db *DB) GetCodeIndexes(ctx context.Context, dirPath, modulePath, version string) (_ []*PackageCodeIndex, err error) {
+	defer derrors.WrapStack(&err, "GetCodeIndexes(ctx, %q, %q, %q)", dirPath, modulePath, version)
+
+	query := `
+		SELECT p.path, c.contents
+		FROM code_indexes c
+		INNER JOIN units u ON u.id = c.unit_id
+		INNER JOIN paths p ON p.id = u.path_id
+		INNER JOIN modules m ON m.id = u.module_id
+		WHERE m.module_path = $1
+		AND m.version = $2
+		AND (p.path = $3 OR p.path LIKE $4)
+		ORDER BY p.path`
+	var indexes []*PackageCodeIndex
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var pi PackageCodeIndex
+		if err := rows.Scan(&pi.Path, jsonbScanner{&pi.Index}); err != nil {
+			return err
+		}
+		indexes = append(indexes, &pi)
+		return nil
+	}, modulePath, version, dirPath, escapeLike(dirPath)+"/%")
+	if err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetCodeIndexes(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	index := func(name string) *internal.CodeIndex {
+		return &internal.CodeIndex{Files: []*internal.CodeIndexFile{{
+			Name: "file.go",
+			Defs: []*internal.CodeIndexDef{{Name: name, Range: internal.CodeRange{2, 5, 2, 6}, Decl: "func " + name + "()"}},
+			Refs: []*internal.CodeIndexRef{{Package: "fmt", Name: "Println", Range: internal.CodeRange{3, 5, 3, 12}}},
+		}}}
+	}
+	m := sample.Module(sample.ModulePath, sample.VersionString, "a", "a/b", "a_c")
+	byPath := map[string]*internal.CodeIndex{}
+	for _, u := range m.Packages() {
+		u.CodeIndex = index(u.Name)
+		byPath[u.Path] = u.CodeIndex
+	}
+	MustInsertModule(ctx, t, testDB, m)
+
+	for _, test := range []struct {
+		dir  string
+		want []string
+	}{
+		{sample.ModulePath, []string{sample.ModulePath + "/a", sample.ModulePath + "/a/b", sample.ModulePath + "/a_c"}},
+		{sample.ModulePath + "/a", []string{sample.ModulePath + "/a", sample.ModulePath + "/a/b"}},
+		{sample.ModulePath + "/a/b", []string{sample.ModulePath + "/a/b"}},
+		{sample.ModulePath + "/x", nil},
+	} {
+		got, err := testDB.GetCodeIndexes(ctx, test.dir, sample.ModulePath, sample.VersionString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []*PackageCodeIndex
+		for _, p := range test.want {
+			want = append(want, &PackageCodeIndex{Path: p, Index: byPath[p]})
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", test.dir, diff)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// CorpusStats is a daily rollup of statistics about the whole corpus.
+type CorpusStats struct {
+	Date time.Time
+
+	// NumModules is the number of modules with a good version.
+	NumModules int
+	// NumVersions is the number of module versions in the database.
+	NumVersions int
+	// NumPackages is the number of distinct package paths.
+	NumPackages int
+	// VersionsProcessed is the number of module versions that were processed
+	// on Date, including versions that were processed again.
+	VersionsProcessed int
+
+	// MostImported are the most imported packages, most imported first.
+	MostImported []*ImportedByCount
+	// LicenseCounts are the license types of the latest good versions of
+	// all modules, most common first.
+	LicenseCounts []*LicenseCount
+}
+
+// ImportedByCount is the number of packages that import a package.
+type ImportedByCount struct {
+	Path          string
+	NumImportedBy int
+}
+
+// LicenseCount is the number of modules that have a license of a type.
+type LicenseCount struct {
+	Type       string
+	NumModules int
+}
+
+// numMostImported is the number of packages in CorpusStats.MostImported.
+const numMostImported = 25
+
+// ComputeCorpusStats computes the corpus statistics and stores them as the
+// rollup for date, replacing any existing rollup for that date. It is meant to
+// be run after date has ended, so that the number of versions processed on
+// that date is complete. That number is counted as versions are processed, so
+// it does not change if they are processed again later.
+func (db *DB) ComputeCorpusStats(ctx context.Context, date time.Time) (err error) {
+	defer derrors.WrapStack(&err, "ComputeCorpusStats(ctx, %s)", date.Format("2006-01-02"))
+
+	_, err = db.db.Exec(ctx, latestGoodModules+`
+		INSERT INTO corpus_stats (
+			date,
+			num_modules,
+			num_versions,
+			num_packages,
+			versions_processed,
+			most_imported,
+			license_counts
+		)
+		SELECT
+			$1::date,
+			(SELECT count(*) FROM latest),
+			(SELECT count(*) FROM modules),
+			(SELECT count(*) FROM search_documents),
+			coalesce((
+				SELECT num_processed
+				FROM daily_processed_versions
+				WHERE date = $1::date
+			), 0),
+			(
+				SELECT coalesce(json_agg(json_build_object(
+					'Path', package_path,
+					'NumImportedBy', imported_by_count)
+					ORDER BY imported_by_count DESC, package_path), '[]')::jsonb
+				FROM (
+					SELECT package_path, imported_by_count
+					FROM search_documents
+					WHERE imported_by_count > 0
+					ORDER BY imported_by_count DESC, package_path
+					LIMIT $2
+				) s
+			),
+			(
+				SELECT coalesce(json_agg(json_build_object(
+					'Type', type,
+					'NumModules', n)
+					ORDER BY n DESC, type), '[]')::jsonb
+				FROM (
+					SELECT t.type, count(DISTINCT l.module_id) AS n
+					FROM latest
+					INNER JOIN licenses l ON l.module_id = latest.id
+					CROSS JOIN unnest(l.types) AS t(type)
+					GROUP BY t.type
+				) c
+			)
+		ON CONFLICT (date) DO UPDATE SET
+			num_modules = excluded.num_modules,
+			num_versions = excluded.num_versions,
+			num_packages = excluded.num_packages,
+			versions_processed = excluded.versions_processed,
+			most_imported = excluded.most_imported,
+			license_counts = excluded.license_counts,
+			created_at = CURRENT_TIMESTAMP`,
+		date.Format("2006-01-02"), numMostImported)
+	return err
+}
+
+// GetCorpusStats returns the rollups for the most recent n dates, most recent
+// first.
+func (db *DB) GetCorpusStats(ctx context.Context, n int) (_ []*CorpusStats, err error) {
+	defer derrors.WrapStack(&err, "GetCorpusStats(ctx, %d)", n)
+
+	query := `
+		SELECT
+			date,
+			num_modules,
+			num_versions,
+			num_packages,
+			versions_processed,
+			most_imported,
+			license_counts
+		FROM corpus_stats
+		ORDER BY date DESC
+		LIMIT $1`
+	var stats []*CorpusStats
+	collect := func(rows *sql.Rows) error {
+		var s CorpusStats
+		if err := rows.Scan(&s.Date, &s.NumModules, &s.NumVersions, &s.NumPackages, &s.VersionsProcessed,
+			jsonbScanner{&s.MostImported}, jsonbScanner{&s.LicenseCounts}); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		stats = append(stats, &s)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, n); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
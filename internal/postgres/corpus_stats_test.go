@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestCorpusStats(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/a", "v1.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/a", "v1.1.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/b", "v1.0.0", "p", "q"))
+
+	day1 := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	if _, err := testDB.db.Exec(ctx, `
+		INSERT INTO daily_processed_versions (date, num_processed) VALUES ($1, 7)`,
+		day2.Format("2006-01-02")); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []time.Time{day1, day2, day2} {
+		if err := testDB.ComputeCorpusStats(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetCorpusStats(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CorpusStats{
+		NumModules:        2,
+		NumVersions:       3,
+		NumPackages:       3,
+		VersionsProcessed: 7,
+		MostImported:      []*ImportedByCount{},
+		LicenseCounts:     []*LicenseCount{{Type: sample.LicenseType, NumModules: 2}},
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d rollups, want 2", len(got))
+	}
+	if !got[0].Date.Equal(day2) || !got[1].Date.Equal(day1) {
+		t.Errorf("got dates %s, %s; want %s, %s", got[0].Date, got[1].Date, day2, day1)
+	}
+	if diff := cmp.Diff(want, got[0], cmpopts.IgnoreFields(CorpusStats{}, "Date")); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got[1].VersionsProcessed != 0 {
+		t.Errorf("got %d versions processed on %s, want 0", got[1].VersionsProcessed, day1)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A CSPReport is a Content-Security-Policy violation report sent by a
+// browser.
+type CSPReport struct {
+	DocumentURI        string
+	ViolatedDirective  string
+	EffectiveDirective string
+	BlockedURI         string
+	SourceFile         string
+	LineNumber         int
+	ColumnNumber       int
+	Disposition        string
+}
+
+// InsertCSPReport stores r in the csp_reports table.
+func (db *DB) InsertCSPReport(ctx context.Context, r *CSPReport) (err error) {
+	defer derrors.WrapStack(&err, "InsertCSPReport(ctx, %q)", r.DocumentURI)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO csp_reports (
+			document_uri, violated_directive, effective_directive, blocked_uri,
+			source_file, line_number, column_number, disposition)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		r.DocumentURI, r.ViolatedDirective, r.EffectiveDirective, r.BlockedURI,
+		r.SourceFile, r.LineNumber, r.ColumnNumber, r.Disposition)
+	return err
+}
+
+// A CSPViolation summarizes the stored reports of the same violation.
+type CSPViolation struct {
+	EffectiveDirective string
+	BlockedURI         string
+	Disposition        string
+	// Reports is the number of stored reports of the violation, and
+	// DocumentURI the page of one of them.
+	Reports     int
+	DocumentURI string
+	LastSeen    time.Time
+}
+
+// GetCSPViolations returns the limit violations with the most reports stored
+// since the given time, most reported first.
+func (db *DB) GetCSPViolations(ctx context.Context, since time.Time, limit int) (_ []*CSPViolation, err error) {
+	defer derrors.WrapStack(&err, "GetCSPViolations(ctx, %s, %d)", since, limit)
+
+	var vs []*CSPViolation
+	collect := func(rows *sql.Rows) error {
+		var v CSPViolation
+		if err := rows.Scan(&v.EffectiveDirective, &v.BlockedURI, &v.Disposition,
+			&v.Reports, &v.DocumentURI, &v.LastSeen); err != nil {
+			return err
+		}
+		vs = append(vs, &v)
+		return nil
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT effective_directive, blocked_uri, disposition,
+			COUNT(*), MIN(document_uri), MAX(created_at)
+		FROM csp_reports
+		WHERE created_at >= $1
+		GROUP BY effective_directive, blocked_uri, disposition
+		ORDER BY 4 DESC, 1, 2, 3
+		LIMIT $2`, collect, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// DeleteCSPReports deletes the reports stored before the given time, and
+// returns the number of reports deleted.
+func (db *DB) DeleteCSPReports(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer derrors.WrapStack(&err, "DeleteCSPReports(ctx, %s)", before)
+
+	return db.db.Exec(ctx, `DELETE FROM csp_reports WHERE created_at < $1`, before)
+}
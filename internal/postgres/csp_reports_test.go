@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestInsertCSPReport(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	want := &CSPReport{
+		DocumentURI:        "https://pkg.go.dev/net/http",
+		ViolatedDirective:  "script-src",
+		EffectiveDirective: "script-src-elem",
+		BlockedURI:         "inline",
+		SourceFile:         "https://pkg.go.dev/static/frontend/frontend.js",
+		LineNumber:         12,
+		ColumnNumber:       3,
+		Disposition:        "report",
+	}
+	if err := testDB.InsertCSPReport(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	var got []*CSPReport
+	err := testDB.db.RunQuery(ctx, `
+		SELECT document_uri, violated_directive, effective_directive, blocked_uri,
+			source_file, line_number, column_number, disposition
+		FROM csp_reports`, func(rows *sql.Rows) error {
+		var r CSPReport
+		if err := rows.Scan(&r.DocumentURI, &r.ViolatedDirective, &r.EffectiveDirective, &r.BlockedURI,
+			&r.SourceFile, &r.LineNumber, &r.ColumnNumber, &r.Disposition); err != nil {
+			return err
+		}
+		got = append(got, &r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*CSPReport{want}, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCSPViolations(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, r := range []*CSPReport{
+		{DocumentURI: "https://pkg.go.dev/a", EffectiveDirective: "script-src-elem", BlockedURI: "inline", Disposition: "report"},
+		{DocumentURI: "https://pkg.go.dev/b", EffectiveDirective: "script-src-elem", BlockedURI: "inline", Disposition: "report"},
+		{DocumentURI: "https://pkg.go.dev/c", EffectiveDirective: "img-src", BlockedURI: "https://example.com", Disposition: "enforce"},
+	} {
+		if err := testDB.InsertCSPReport(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An old report is not summarized, and is deleted.
+	if _, err := testDB.db.Exec(ctx, `
+		INSERT INTO csp_reports (
+			document_uri, violated_directive, effective_directive, blocked_uri,
+			source_file, line_number, column_number, disposition, created_at)
+		VALUES ('https://pkg.go.dev/old', '', 'img-src', 'data', '', 0, 0, 'enforce', $1)`,
+		time.Now().AddDate(0, 0, -40)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetCSPViolations(ctx, time.Now().AddDate(0, 0, -30), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*CSPViolation{
+		{EffectiveDirective: "script-src-elem", BlockedURI: "inline", Disposition: "report", Reports: 2, DocumentURI: "https://pkg.go.dev/a"},
+		{EffectiveDirective: "img-src", BlockedURI: "https://example.com", Disposition: "enforce", Reports: 1, DocumentURI: "https://pkg.go.dev/c"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(CSPViolation{}, "LastSeen")); diff != "" {
+		t.Errorf("GetCSPViolations mismatch (-want, +got):\n%s", diff)
+	}
+
+	n, err := testDB.DeleteCSPReports(ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("DeleteCSPReports deleted %d reports, want 1", n)
+	}
+}
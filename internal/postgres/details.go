@@ -11,7 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 
+	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -70,14 +72,13 @@ func (db *DB) GetNestedModules(ctx context.Context, modulePath string) (_ []*int
 	return modules, nil
 }
 
-// GetImportedBy fetches and returns all of the packages that import the
-// package with path.
+// GetImportedBy returns a page of the packages of other modules that import
+// the package with path, sorted by path. The page has at most limit paths,
+// starting at offset.
 // The returned error may be checked with derrors.IsInvalidArgument to
 // determine if it resulted from an invalid package path or version.
-//
-// Instead of supporting pagination, this query runs with a limit.
-func (db *DB) GetImportedBy(ctx context.Context, pkgPath, modulePath string, limit int) (paths []string, err error) {
-	defer derrors.WrapStack(&err, "GetImportedBy(ctx, %q, %q)", pkgPath, modulePath)
+func (db *DB) GetImportedBy(ctx context.Context, pkgPath, modulePath string, limit, offset int) (paths []string, err error) {
+	defer derrors.WrapStack(&err, "GetImportedBy(ctx, %q, %q, %d, %d)", pkgPath, modulePath, limit, offset)
 	defer middleware.ElapsedStat(ctx, "GetImportedBy")()
 
 	if pkgPath == "" {
@@ -94,9 +95,45 @@ func (db *DB) GetImportedBy(ctx context.Context, pkgPath, modulePath string, lim
 			from_module_path <> $2
 		ORDER BY
 			from_path
-		LIMIT $3`
+		LIMIT $3
+		OFFSET $4`
 
-	return database.Collect1[string](ctx, db.db, query, pkgPath, modulePath, limit)
+	return database.Collect1[string](ctx, db.db, query, pkgPath, modulePath, limit, offset)
+}
+
+// CountImportedBy returns the number of packages of other modules that
+// import the package with path, which is the number of paths that
+// GetImportedBy pages through, but no more than limit. Unlike
+// GetImportedByCount, it includes internal and invalid packages, and is
+// always up to date.
+func (db *DB) CountImportedBy(ctx context.Context, pkgPath, modulePath string, limit int) (_ int, err error) {
+	defer derrors.WrapStack(&err, "CountImportedBy(ctx, %q, %q, %d)", pkgPath, modulePath, limit)
+	defer middleware.ElapsedStat(ctx, "CountImportedBy")()
+
+	if pkgPath == "" {
+		return 0, fmt.Errorf("pkgPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	// Limit the paths that are counted, so that popular packages don't
+	// count millions of rows on every page of importers.
+	query := `
+		SELECT
+			COUNT(*)
+		FROM (
+			SELECT
+				DISTINCT from_path
+			FROM
+				imports_unique
+			WHERE
+				to_path = $1
+			AND
+				from_module_path <> $2
+			LIMIT $3
+		) p`
+	var n int
+	if err := db.db.QueryRow(ctx, query, pkgPath, modulePath, limit).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
 }
 
 // GetImportedByCount returns the number of packages that import pkgPath.
@@ -126,6 +163,84 @@ func (db *DB) GetImportedByCount(ctx context.Context, pkgPath, modulePath string
 	}
 }
 
+// MajorVersionImporters counts the importers of a package in one major
+// version of a module.
+type MajorVersionImporters struct {
+	// ModulePath is the path of the major version of the module, such as
+	// example.com/m/v2.
+	ModulePath string
+	// PackagePath is the path of the package in that major version.
+	PackagePath string
+	// NumPackages and NumModules are the numbers of packages and modules that
+	// import PackagePath, outside of the major versions of the module.
+	NumPackages int
+	NumModules  int
+}
+
+// GetImportedByMajorVersions returns the importers of the package at pkgPath
+// in each major version of its module modulePath, ordered by major version.
+// The major version that a package imports is known from the path it
+// imports, so the counts come from the imports_unique table, where package
+// paths are those of the latest version of each module. Importers in any
+// major version of the module itself are not counted.
+func (db *DB) GetImportedByMajorVersions(ctx context.Context, pkgPath, modulePath string) (_ []*MajorVersionImporters, err error) {
+	defer derrors.WrapStack(&err, "GetImportedByMajorVersions(ctx, %q, %q)", pkgPath, modulePath)
+	defer middleware.ElapsedStat(ctx, "GetImportedByMajorVersions")()
+
+	modulePaths, err := database.Collect1[string](ctx, db.db, `
+		SELECT DISTINCT module_path FROM modules WHERE series_path = $1`,
+		internal.SeriesPathForModule(modulePath))
+	if err != nil {
+		return nil, err
+	}
+	suffix := internal.Suffix(pkgPath, modulePath)
+	var (
+		mvs      []*MajorVersionImporters
+		pkgPaths []string
+		byPath   = map[string]*MajorVersionImporters{}
+	)
+	for _, mp := range modulePaths {
+		p := mp
+		if suffix != "" {
+			p += "/" + suffix
+		}
+		mv := &MajorVersionImporters{ModulePath: mp, PackagePath: p}
+		mvs = append(mvs, mv)
+		pkgPaths = append(pkgPaths, p)
+		byPath[p] = mv
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT to_path, COUNT(DISTINCT from_path), COUNT(DISTINCT from_module_path)
+		FROM imports_unique
+		WHERE to_path = ANY($1) AND NOT from_module_path = ANY($2)
+		GROUP BY to_path`,
+		func(rows *sql.Rows) error {
+			var (
+				p  string
+				np int
+				nm int
+			)
+			if err := rows.Scan(&p, &np, &nm); err != nil {
+				return err
+			}
+			byPath[p].NumPackages = np
+			byPath[p].NumModules = nm
+			return nil
+		}, pq.Array(pkgPaths), pq.Array(modulePaths))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(mvs, func(i, j int) bool {
+		_, mi := internal.SeriesPathAndMajorVersion(mvs[i].ModulePath)
+		_, mj := internal.SeriesPathAndMajorVersion(mvs[j].ModulePath)
+		if mi != mj {
+			return mi < mj
+		}
+		return mvs[i].ModulePath < mvs[j].ModulePath
+	})
+	return mvs, nil
+}
+
 // GetModuleInfo fetches a module version from the database with the primary key
 // (module_path, version).
 func (db *DB) GetModuleInfo(ctx context.Context, modulePath, resolvedVersion string) (_ *internal.ModuleInfo, err error) {
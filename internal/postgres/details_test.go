@@ -273,17 +273,78 @@ func TestGetImportedBy(t *testing.T) {
 				MustInsertModule(ctx, t, testDB, v)
 			}
 
-			gotImportedBy, err := testDB.GetImportedBy(ctx, test.path, test.modulePath, 100)
+			gotImportedBy, err := testDB.GetImportedBy(ctx, test.path, test.modulePath, 100, 0)
 			if err != nil {
 				t.Fatal(err)
 			}
 			if diff := cmp.Diff(test.wantImportedBy, gotImportedBy); diff != "" {
 				t.Errorf("testDB.GetImportedBy(%q, %q) mismatch (-want +got):\n%s", test.path, test.modulePath, diff)
 			}
+			if len(test.wantImportedBy) > 1 {
+				// The second page of one path.
+				gotImportedBy, err := testDB.GetImportedBy(ctx, test.path, test.modulePath, 1, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(test.wantImportedBy[1:2], gotImportedBy); diff != "" {
+					t.Errorf("testDB.GetImportedBy(%q, %q, 1, 1) mismatch (-want +got):\n%s", test.path, test.modulePath, diff)
+				}
+			}
+			gotCount, err := testDB.CountImportedBy(ctx, test.path, test.modulePath, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := len(test.wantImportedBy); gotCount != want {
+				t.Errorf("testDB.CountImportedBy(%q, %q, 100) = %d, want %d", test.path, test.modulePath, gotCount, want)
+			}
+			if len(test.wantImportedBy) > 1 {
+				gotCount, err := testDB.CountImportedBy(ctx, test.path, test.modulePath, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if gotCount != 1 {
+					t.Errorf("testDB.CountImportedBy(%q, %q, 1) = %d, want 1", test.path, test.modulePath, gotCount)
+				}
+			}
 		})
 	}
 }
 
+func TestGetImportedByMajorVersions(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx := context.Background()
+
+	var (
+		v1    = sample.Module("example.com/m", "v1.5.0", "p")
+		v2    = sample.Module("example.com/m/v2", "v2.0.0", "p")
+		a     = sample.Module("example.com/a", "v1.0.0", "x", "y")
+		b     = sample.Module("example.com/b", "v1.0.0", "z")
+		other = sample.Module("example.com/other", "v1.0.0", "p")
+	)
+	// v2 imports v1, which is not counted.
+	v2.Packages()[0].Imports = []string{"example.com/m/p"}
+	a.Packages()[0].Imports = []string{"example.com/m/p"}
+	a.Packages()[1].Imports = []string{"example.com/m/p"}
+	b.Packages()[0].Imports = []string{"example.com/m/v2/p", "example.com/other/p"}
+	for _, m := range []*internal.Module{v1, v2, a, b, other} {
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	got, err := testDB.GetImportedByMajorVersions(ctx, "example.com/m/v2/p", "example.com/m/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*MajorVersionImporters{
+		{ModulePath: "example.com/m", PackagePath: "example.com/m/p", NumPackages: 2, NumModules: 1},
+		{ModulePath: "example.com/m/v2", PackagePath: "example.com/m/v2/p", NumPackages: 1, NumModules: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestJSONBScanner(t *testing.T) {
 	t.Parallel()
 	type S struct{ A int }
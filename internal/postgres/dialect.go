@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/postgres/search"
+)
+
+// This file holds the alternatives to statements that CockroachDB does not
+// accept. See doc/postgres.md for what is and isn't supported on CockroachDB.
+
+// isCockroachDB reports whether ddb is connected to CockroachDB.
+func isCockroachDB(ddb *database.DB) bool {
+	return ddb.Dialect() == database.CockroachDB
+}
+
+// crdbTSQueryExpr replaces tsQueryExpr on CockroachDB, which has neither
+// websearch_to_tsquery nor ts_rewrite, so the query is parsed as plain text
+// and synonyms are not expanded. The synonym query $4 must still appear in
+// the statement, because CockroachDB rejects placeholders whose type it can't
+// infer.
+const crdbTSQueryExpr = `plainto_tsquery(concat($1, left($4::text, 0)))`
+
+// crdbSearchQuery rewrites the deep search query q for CockroachDB.
+func crdbSearchQuery(q string) string {
+	return strings.ReplaceAll(q, tsQueryExpr, crdbTSQueryExpr)
+}
+
+// crdbUpsertSearchStatement replaces upsertSearchStatement on CockroachDB,
+// which has neither custom text search configurations nor the hll extension.
+// Path tokens and symbols are indexed with the "simple" configuration, and
+// the hll columns, which only the popular search function reads, are zero.
+var crdbUpsertSearchStatement = crdbSearchTokensReplacer.Replace(upsertSearchStatement)
+
+// crdbShadowSearchStatement replaces shadowSearchStatement on CockroachDB.
+var crdbShadowSearchStatement = crdbSearchTokensReplacer.Replace(shadowSearchStatement)
+
+var crdbSearchTokensReplacer = strings.NewReplacer(
+	fmt.Sprintf("TO_TSVECTOR('%s',", search.SymbolTextSearchConfiguration), "TO_TSVECTOR('simple',",
+	"TO_TSVECTOR('path_tokens',", "TO_TSVECTOR('simple',",
+	fmt.Sprintf("hll_hash(p1.path) & (%d - 1)", hllRegisterCount), "0",
+	"hll_zeros(hll_hash(p1.path))", "0",
+)
+
+// License search expressions for PostgreSQL and CockroachDB, which has
+// neither websearch_to_tsquery nor ts_headline. On CockroachDB, the headline
+// is the start of the file.
+const (
+	licenseTSQueryExpr      = `websearch_to_tsquery('english', $1)`
+	crdbLicenseTSQueryExpr  = `plainto_tsquery('english', $1)`
+	licenseHeadlineExpr     = `ts_headline('english', left(r.contents, 100000), websearch_to_tsquery('english', $1), 'StartSel=**, StopSel=**, MaxFragments=2')`
+	crdbLicenseHeadlineExpr = `left(r.contents, 200)`
+)
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/postgres/search"
+)
+
+func TestCockroachDBStatements(t *testing.T) {
+	// Each alternative statement must differ from the original, and must not
+	// use what CockroachDB lacks.
+	for _, test := range []struct {
+		name, pg, crdb string
+		unsupported    []string
+	}{
+		{
+			"upsert search document",
+			upsertSearchStatement,
+			crdbUpsertSearchStatement,
+			[]string{"hll_hash", "'path_tokens'", "'" + search.SymbolTextSearchConfiguration + "'"},
+		},
+		{
+			"deep search",
+			"WHERE tsv_search_tokens @@ " + tsQueryExpr + " AND " + scoreExpr,
+			crdbSearchQuery("WHERE tsv_search_tokens @@ " + tsQueryExpr + " AND " + scoreExpr),
+			[]string{"websearch_to_tsquery", "ts_rewrite"},
+		},
+	} {
+		if test.pg == test.crdb {
+			t.Errorf("%s: CockroachDB statement is the same as the PostgreSQL one", test.name)
+		}
+		for _, u := range test.unsupported {
+			if strings.Contains(test.crdb, u) {
+				t.Errorf("%s: CockroachDB statement contains %q", test.name, u)
+			}
+		}
+		if !strings.Contains(test.crdb, "$4") {
+			t.Errorf("%s: CockroachDB statement does not use $4", test.name)
+		}
+	}
+}
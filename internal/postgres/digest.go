@@ -0,0 +1,83 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A DigestVersion is a version listed by GetDigestVersions.
+type DigestVersion struct {
+	ModulePath string
+	Version    string
+	CommitTime time.Time
+	// PreviousModulePath and PreviousVersion identify the highest earlier
+	// version in the same series, which may belong to an earlier major
+	// version of the module. They are empty if there is none.
+	PreviousModulePath string
+	PreviousVersion    string
+}
+
+// GetDigestVersions returns the release and prerelease versions of the
+// modules at or under any of prefixes that were committed at or after since,
+// ordered by module path and version. Excluded modules are omitted.
+func (db *DB) GetDigestVersions(ctx context.Context, prefixes []string, since time.Time) (_ []*DigestVersion, err error) {
+	defer derrors.WrapStack(&err, "GetDigestVersions(ctx, %q, %s)", prefixes, since.Format(time.RFC3339))
+
+	query := `
+		SELECT m.module_path, m.version, m.commit_time, p.module_path, p.version
+		FROM modules m
+		LEFT JOIN LATERAL (
+			SELECT module_path, version
+			FROM modules
+			WHERE series_path = m.series_path
+			AND version_type != 'pseudo'
+			AND sort_version < m.sort_version
+			ORDER BY sort_version DESC
+			LIMIT 1
+		) p ON true
+		WHERE m.version_type != 'pseudo'
+		AND m.commit_time >= $2
+		AND EXISTS (
+			SELECT 1 FROM unnest($1::text[]) prefix
+			WHERE m.module_path = prefix OR m.module_path LIKE prefix || '/%'
+		)
+		ORDER BY m.module_path, m.sort_version`
+	var (
+		versions []*DigestVersion
+		excluded = map[string]bool{}
+	)
+	collect := func(rows *sql.Rows) error {
+		var (
+			v                     DigestVersion
+			prevPath, prevVersion sql.NullString
+		)
+		if err := rows.Scan(&v.ModulePath, &v.Version, &v.CommitTime, &prevPath, &prevVersion); err != nil {
+			return err
+		}
+		v.PreviousModulePath = prevPath.String
+		v.PreviousVersion = prevVersion.String
+		if _, ok := excluded[v.ModulePath]; !ok {
+			ex, err := db.IsExcluded(ctx, v.ModulePath)
+			if err != nil {
+				return err
+			}
+			excluded[v.ModulePath] = ex
+		}
+		if !excluded[v.ModulePath] {
+			versions = append(versions, &v)
+		}
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, pq.Array(prefixes), since); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
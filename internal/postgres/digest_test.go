@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetDigestVersions(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	since := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, m := range []struct {
+		path, version string
+		time          time.Time
+	}{
+		{"github.com/org/a", "v1.0.0", since.AddDate(0, 0, -30)},
+		{"github.com/org/a", "v1.1.0", since.AddDate(0, 0, 1)},
+		{"github.com/org/a", "v1.1.1-0.20220602000000-abcdefabcdef", since.AddDate(0, 0, 2)},
+		{"github.com/org/a/v2", "v2.0.0", since.AddDate(0, 0, 3)},
+		{"github.com/org/b", "v0.1.0", since.AddDate(0, 0, 4)},
+		{"github.com/org/c", "v1.0.0", since.AddDate(0, 0, 4)},
+		{"github.com/other/d", "v1.0.0", since.AddDate(0, 0, 4)},
+	} {
+		mod := sample.Module(m.path, m.version, "p")
+		mod.CommitTime = m.time
+		MustInsertModule(ctx, t, testDB, mod)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, "github.com/org/c", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetDigestVersions(ctx, []string{"github.com/org"}, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*DigestVersion{
+		{ModulePath: "github.com/org/a", Version: "v1.1.0", PreviousModulePath: "github.com/org/a", PreviousVersion: "v1.0.0"},
+		{ModulePath: "github.com/org/a/v2", Version: "v2.0.0", PreviousModulePath: "github.com/org/a", PreviousVersion: "v1.1.0"},
+		{ModulePath: "github.com/org/b", Version: "v0.1.0"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(DigestVersion{}, "CommitTime")); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetDocCacheEntry returns the contents of the doc cache entry with the given
+// key, and marks it as used. It returns nil if there is no such entry.
+func (db *DB) GetDocCacheEntry(ctx context.Context, key string) (_ []byte, err error) {
+	defer derrors.WrapStack(&err, "GetDocCacheEntry(ctx, %q)", key)
+
+	var contents []byte
+	err = db.db.QueryRow(ctx, `
+		UPDATE doc_cache
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE key = $1
+		RETURNING contents`, key).Scan(&contents)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// PutDocCacheEntry inserts a doc cache entry with the given key, produced by
+// the given renderer version from the contents of a version of modulePath.
+// If there already is an entry with the key, it is marked as used.
+func (db *DB) PutDocCacheEntry(ctx context.Context, key string, rendererVersion int, modulePath string, contents []byte) (err error) {
+	defer derrors.WrapStack(&err, "PutDocCacheEntry(ctx, %q, %d, %q)", key, rendererVersion, modulePath)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO doc_cache (key, renderer_version, module_path, contents)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET used_at = CURRENT_TIMESTAMP`,
+		key, rendererVersion, modulePath, contents)
+	return err
+}
+
+// DeleteDocCacheEntries deletes the doc cache entries produced by renderer
+// versions older than rendererVersion, and those that have not been used
+// since the given time. It returns the number of entries deleted.
+func (db *DB) DeleteDocCacheEntries(ctx context.Context, rendererVersion int, unusedSince time.Time) (_ int64, err error) {
+	defer derrors.WrapStack(&err, "DeleteDocCacheEntries(ctx, %d, %s)", rendererVersion, unusedSince)
+
+	return db.db.Exec(ctx, `
+		DELETE FROM doc_cache
+		WHERE renderer_version < $1 OR used_at < $2`,
+		rendererVersion, unusedSince)
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDocCache(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	get := func(key string) string {
+		t.Helper()
+		got, err := testDB.GetDocCacheEntry(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(got)
+	}
+
+	if got := get("k1"); got != "" {
+		t.Errorf("got %q before Put, want nothing", got)
+	}
+	for _, e := range []struct {
+		key      string
+		version  int
+		contents string
+	}{
+		{"k1", 1, "old"},
+		{"k2", 2, "new"},
+		// An existing entry is not replaced.
+		{"k2", 2, "other"},
+	} {
+		if err := testDB.PutDocCacheEntry(ctx, e.key, e.version, "example.com/m", []byte(e.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := get("k1"), "old"; got != want {
+		t.Errorf("k1: got %q, want %q", got, want)
+	}
+	if got, want := get("k2"), "new"; got != want {
+		t.Errorf("k2: got %q, want %q", got, want)
+	}
+
+	n, err := testDB.DeleteDocCacheEntries(ctx, 2, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d entries, want 1", n)
+	}
+	if got := get("k1"); got != "" {
+		t.Errorf("k1: got %q after delete, want nothing", got)
+	}
+	if _, err := testDB.DeleteDocCacheEntries(ctx, 2, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("k2"); got != "" {
+		t.Errorf("k2: got %q after delete, want nothing", got)
+	}
+}
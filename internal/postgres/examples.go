@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetExamples returns the examples of the package pkgPath in
+// modulePath@version, sorted by name.
+func (db *DB) GetExamples(ctx context.Context, pkgPath, modulePath, version string) (_ []*internal.Example, err error) {
+	defer derrors.WrapStack(&err, "GetExamples(ctx, %q, %q, %q)", pkgPath, modulePath, version)
+
+	query := `
+		SELECT e.name, e.doc, e.code, e.output, e.runnable
+		FROM examples e
+		INNER JOIN units u ON u.id = e.unit_id
+		INNER JOIN paths p ON p.id = u.path_id
+		INNER JOIN modules m ON m.id = u.module_id
+		WHERE p.path = $1
+		AND m.module_path = $2
+		AND m.version = $3
+		ORDER BY e.name`
+	var exs []*internal.Example
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var e internal.Example
+		if err := rows.Scan(&e.Name, &e.Doc, &e.Code, &e.Output, &e.Runnable); err != nil {
+			return err
+		}
+		exs = append(exs, &e)
+		return nil
+	}, pkgPath, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return exs, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetExamples(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	exs := []*internal.Example{
+		{Name: "Example", Doc: "Package example.\n", Code: "package main\n\nfunc main() {}\n", Output: "hi\n", Runnable: true},
+		{Name: "ExampleT", Code: "x := T{}"},
+	}
+	m := sample.Module(sample.ModulePath, sample.VersionString, "a", "b")
+	m.Packages()[0].Examples = exs
+	MustInsertModule(ctx, t, testDB, m)
+
+	for _, test := range []struct {
+		pkgPath string
+		want    []*internal.Example
+	}{
+		{sample.ModulePath + "/a", exs},
+		{sample.ModulePath + "/b", nil},
+	} {
+		got, err := testDB.GetExamples(ctx, test.pkgPath, sample.ModulePath, sample.VersionString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", test.pkgPath, diff)
+		}
+	}
+}
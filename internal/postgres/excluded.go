@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"golang.org/x/pkgsite/internal/database"
@@ -35,6 +36,17 @@ func (db *DB) IsExcluded(ctx context.Context, path string) (_ bool, err error) {
 	return false, nil
 }
 
+// isExcludedExpr returns an expression that is true if the module path in
+// column col matches an excluded prefix, as in IsExcluded.
+func isExcludedExpr(col string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1
+		FROM excluded_prefixes e
+		WHERE %[1]s = e.prefix
+		OR left(%[1]s, length(rtrim(e.prefix, '/')) + 1) = rtrim(e.prefix, '/') || '/'
+	)`, col)
+}
+
 // InsertExcludedPrefix inserts prefix into the excluded_prefixes table.
 //
 // For real-time administration (e.g. DOS prevention), use the dbadmin tool.
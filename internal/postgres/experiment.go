@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetExperiments fetches all experiments in the database, sorted by name.
+func (db *DB) GetExperiments(ctx context.Context) (_ []*internal.Experiment, err error) {
+	defer derrors.WrapStack(&err, "GetExperiments(ctx)")
+
+	query := `
+		SELECT name, rollout, description, allowlist
+		FROM experiments
+		ORDER BY name`
+	var experiments []*internal.Experiment
+	collect := func(rows *sql.Rows) error {
+		var e internal.Experiment
+		if err := rows.Scan(&e.Name, &e.Rollout, &e.Description, pq.Array(&e.Allowlist)); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		experiments = append(experiments, &e)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect); err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// UpsertExperiment inserts e into the experiments table, replacing any
+// experiment with the same name.
+func (db *DB) UpsertExperiment(ctx context.Context, e *internal.Experiment) (err error) {
+	defer derrors.WrapStack(&err, "UpsertExperiment(ctx, %v)", e)
+
+	if e.Name == "" {
+		return fmt.Errorf("missing experiment name: %w", derrors.InvalidArgument)
+	}
+	if e.Rollout > 100 {
+		return fmt.Errorf("rollout %d is more than 100: %w", e.Rollout, derrors.InvalidArgument)
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO experiments (name, rollout, description, allowlist)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name)
+		DO UPDATE SET
+			rollout = excluded.rollout,
+			description = excluded.description,
+			allowlist = excluded.allowlist`,
+		e.Name, e.Rollout, e.Description, pq.Array(e.Allowlist))
+	return err
+}
+
+// RemoveExperiment removes the experiment with the given name. It returns a
+// NotFound error if there is no such experiment.
+func (db *DB) RemoveExperiment(ctx context.Context, name string) (err error) {
+	defer derrors.WrapStack(&err, "RemoveExperiment(ctx, %q)", name)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM experiments WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
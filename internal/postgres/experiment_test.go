@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestExperiments(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	e1 := &internal.Experiment{Name: "b", Rollout: 1, Description: "B"}
+	e2 := &internal.Experiment{Name: "a", Rollout: 0, Description: "A", Allowlist: []string{"1.2.3.4", "10.0.0.0/8"}}
+	for _, e := range []*internal.Experiment{e1, e2} {
+		if err := testDB.UpsertExperiment(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e1.Rollout = 50
+	if err := testDB.UpsertExperiment(ctx, e1); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpsertExperiment(ctx, &internal.Experiment{Name: "c", Rollout: 101}); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+
+	got, err := testDB.GetExperiments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*internal.Experiment{e2, e1}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := testDB.RemoveExperiment(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.RemoveExperiment(ctx, "a"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+	got, err = testDB.GetExperiments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*internal.Experiment{e1}, got); diff != "" {
+		t.Errorf("after removal mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// latestGoodModules selects the id, path and version of the latest good
+// version of each module that is not excluded. The exported tables describe
+// only those versions.
+var latestGoodModules = `
+	WITH latest AS (
+		SELECT m.id, m.module_path, m.version
+		FROM latest_module_versions l
+		INNER JOIN paths p ON p.id = l.module_path_id
+		INNER JOIN modules m ON m.module_path = p.path AND m.version = l.good_version
+		WHERE l.good_version != ''
+			AND NOT ` + isExcludedExpr("m.module_path") + `
+	)`
+
+// exportQueries maps the names of the exported tables to the queries that
+// produce their rows. Each row is denormalized, so that it can be analyzed
+// without joining it with other tables.
+var exportQueries = map[string]string{
+	"modules": latestGoodModules + `
+		SELECT
+			m.module_path,
+			m.version,
+			m.commit_time,
+			m.series_path,
+			m.version_type,
+			m.source_info->>'RepoURL' AS repo_url,
+			m.redistributable,
+			m.has_go_mod,
+			m.incompatible
+		FROM latest
+		INNER JOIN modules m ON m.id = latest.id
+		ORDER BY m.module_path`,
+	"packages": latestGoodModules + `
+		SELECT
+			p.path,
+			latest.module_path,
+			latest.version,
+			u.name,
+			u.license_types,
+			u.redistributable,
+			d.synopsis
+		FROM latest
+		INNER JOIN units u ON u.module_id = latest.id
+		INNER JOIN paths p ON p.id = u.path_id
+		LEFT JOIN LATERAL (
+			SELECT synopsis
+			FROM documentation
+			WHERE unit_id = u.id
+			ORDER BY goos = 'all' DESC, goos = 'linux' DESC
+			LIMIT 1
+		) d ON true
+		WHERE u.name != ''
+		ORDER BY p.path`,
+	"imports": latestGoodModules + `
+		SELECT
+			p1.path AS from_path,
+			latest.module_path AS from_module_path,
+			latest.version AS from_version,
+			p2.path AS to_path
+		FROM latest
+		INNER JOIN units u ON u.module_id = latest.id
+		INNER JOIN paths p1 ON p1.id = u.path_id
+		INNER JOIN imports i ON i.unit_id = u.id
+		INNER JOIN paths p2 ON p2.id = i.to_path_id
+		ORDER BY p1.path, p2.path`,
+	"symbols": latestGoodModules + `
+		SELECT DISTINCT
+			p.path AS package_path,
+			latest.module_path,
+			latest.version,
+			s1.name AS symbol_name,
+			s2.name AS parent_symbol_name,
+			ps.section,
+			ps.type,
+			ps.synopsis
+		FROM latest
+		INNER JOIN units u ON u.module_id = latest.id
+		INNER JOIN paths p ON p.id = u.path_id
+		INNER JOIN documentation d ON d.unit_id = u.id
+		INNER JOIN documentation_symbols ds ON ds.documentation_id = d.id
+		INNER JOIN package_symbols ps ON ps.id = ds.package_symbol_id
+		INNER JOIN symbol_names s1 ON s1.id = ps.symbol_name_id
+		INNER JOIN symbol_names s2 ON s2.id = ps.parent_symbol_name_id
+		ORDER BY p.path, s1.name`,
+	"licenses": latestGoodModules + `
+		SELECT
+			latest.module_path,
+			latest.version,
+			l.file_path,
+			l.types
+		FROM latest
+		INNER JOIN licenses l ON l.module_id = latest.id
+		ORDER BY latest.module_path, l.file_path`,
+}
+
+// ExportTables are the names of the tables that ExportTable can export.
+var ExportTables = []string{"modules", "packages", "imports", "symbols", "licenses"}
+
+// ExportTable writes the rows of the named table, which must be one of
+// ExportTables, to w as newline-delimited JSON, a format that BigQuery and
+// most other analysis tools can load directly. The tables describe the latest
+// good version of each module. ExportTable returns the number of rows written.
+func (db *DB) ExportTable(ctx context.Context, name string, w io.Writer) (n int, err error) {
+	defer derrors.WrapStack(&err, "ExportTable(ctx, %q)", name)
+
+	q, ok := exportQueries[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown table %q: %w", name, derrors.InvalidArgument)
+	}
+	// Let the database encode the rows, so that we don't need a Go type for
+	// each table.
+	query := fmt.Sprintf(`SELECT row_to_json(r) FROM (%s) r`, q)
+	err = db.db.RunQueryIncrementally(ctx, query, 10000, func(rows *sql.Rows) error {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+		if _, err := w.Write(append(row, '\n')); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestExportTable(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		MustInsertModule(ctx, t, testDB, sample.Module(sample.ModulePath, v, "a"))
+	}
+	// Excluded modules are not exported.
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/excluded", "v1.0.0", "a"))
+	if err := testDB.InsertExcludedPrefix(ctx, "example.com/excluded", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		table string
+		field string
+		want  []interface{}
+	}{
+		{"modules", "version", []interface{}{"v1.1.0"}},
+		{"packages", "path", []interface{}{sample.ModulePath + "/a"}},
+		{"licenses", "file_path", []interface{}{sample.LicenseFilePath}},
+	} {
+		t.Run(test.table, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := testDB.ExportTable(ctx, test.table, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(test.want) {
+				t.Errorf("got %d rows, want %d", n, len(test.want))
+			}
+			var got []interface{}
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var row map[string]interface{}
+				if err := dec.Decode(&row); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, row[test.field])
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", test.field, diff)
+			}
+		})
+	}
+
+	if _, err := testDB.ExportTable(ctx, "paths", ioutil.Discard); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+}
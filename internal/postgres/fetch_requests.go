@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A FetchRequest is a request to fetch a module from the frontend, as
+// recorded in the audit trail.
+type FetchRequest struct {
+	RequestedAt time.Time
+	// Requester is the user who made the request, or empty if they were not
+	// authenticated.
+	Requester        string
+	Path             string
+	RequestedVersion string
+	// Allowed is whether the user was allowed to fetch modules, and Status
+	// the HTTP status of the response.
+	Allowed bool
+	Status  int
+}
+
+// InsertFetchRequest records r in the audit trail of fetch requests. The
+// time of r is set by the database.
+func (db *DB) InsertFetchRequest(ctx context.Context, r *FetchRequest) (err error) {
+	defer derrors.WrapStack(&err, "InsertFetchRequest(ctx, %q, %q)", r.Path, r.RequestedVersion)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO fetch_requests (requester, path, requested_version, allowed, status)
+		VALUES ($1, $2, $3, $4, $5)`,
+		r.Requester, r.Path, r.RequestedVersion, r.Allowed, r.Status)
+	return err
+}
+
+// GetFetchRequests returns the fetch requests made since the given time, most
+// recent first.
+func (db *DB) GetFetchRequests(ctx context.Context, since time.Time) (_ []*FetchRequest, err error) {
+	defer derrors.WrapStack(&err, "GetFetchRequests(ctx, %s)", since)
+
+	var rs []*FetchRequest
+	err = db.db.RunQuery(ctx, `
+		SELECT requested_at, requester, path, requested_version, allowed, status
+		FROM fetch_requests
+		WHERE requested_at >= $1
+		ORDER BY requested_at DESC, id DESC`,
+		func(rows *sql.Rows) error {
+			r := &FetchRequest{}
+			if err := rows.Scan(&r.RequestedAt, &r.Requester, &r.Path, &r.RequestedVersion, &r.Allowed, &r.Status); err != nil {
+				return err
+			}
+			rs = append(rs, r)
+			return nil
+		}, since)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFetchRequests(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	start := time.Now().Add(-time.Minute)
+	want := []*FetchRequest{
+		{Requester: "gopher@example.com", Path: "example.com/m", RequestedVersion: "latest", Allowed: true, Status: 200},
+		{Requester: "", Path: "example.com/n", RequestedVersion: "v1.0.0", Allowed: false, Status: 403},
+	}
+	for _, r := range want {
+		if err := testDB.InsertFetchRequest(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := testDB.GetFetchRequests(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Most recent first.
+	want[0], want[1] = want[1], want[0]
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(FetchRequest{}, "RequestedAt")); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	for _, r := range got {
+		if r.RequestedAt.Before(start) {
+			t.Errorf("%s: requested at %s, before %s", r.Path, r.RequestedAt, start)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetModuleGuidePaths returns the file paths of the guides of
+// modulePath@version, sorted. It returns an empty slice if the module has no
+// guides, or is not in the database.
+func (db *DB) GetModuleGuidePaths(ctx context.Context, modulePath, version string) (_ []string, err error) {
+	defer derrors.WrapStack(&err, "GetModuleGuidePaths(ctx, %q, %q)", modulePath, version)
+
+	return database.Collect1[string](ctx, db.db, `
+		SELECT g.file_path
+		FROM module_guides g
+		INNER JOIN modules m ON m.id = g.module_id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY g.file_path`,
+		modulePath, version)
+}
+
+// GetModuleGuide returns the guide of modulePath@version with the given file
+// path. It returns a NotFound error if there is none.
+func (db *DB) GetModuleGuide(ctx context.Context, modulePath, version, filePath string) (_ *internal.Readme, err error) {
+	defer derrors.WrapStack(&err, "GetModuleGuide(ctx, %q, %q, %q)", modulePath, version, filePath)
+
+	g := &internal.Readme{Filepath: filePath}
+	err = db.db.QueryRow(ctx, `
+		SELECT g.contents
+		FROM module_guides g
+		INNER JOIN modules m ON m.id = g.module_id
+		WHERE m.module_path = $1 AND m.version = $2 AND g.file_path = $3`,
+		modulePath, version, filePath).Scan(&g.Contents)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
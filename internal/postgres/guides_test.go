@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleGuides(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	m.Guides = []*internal.Readme{
+		{Filepath: "docs/intro.md", Contents: "# Intro"},
+		{Filepath: "docs/tutorial.md", Contents: "# Tutorial"},
+	}
+	MustInsertModule(ctx, t, testDB, m)
+
+	gotPaths, err := testDB.GetModuleGuidePaths(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"docs/intro.md", "docs/tutorial.md"}, gotPaths); diff != "" {
+		t.Errorf("paths mismatch (-want, +got):\n%s", diff)
+	}
+	got, err := testDB.GetModuleGuide(ctx, m.ModulePath, m.Version, "docs/tutorial.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m.Guides[1], got); diff != "" {
+		t.Errorf("guide mismatch (-want, +got):\n%s", diff)
+	}
+	if _, err := testDB.GetModuleGuide(ctx, m.ModulePath, m.Version, "docs/other.md"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v for missing guide, want NotFound", err)
+	}
+
+	// Reinserting the module replaces its guides.
+	m.Guides = m.Guides[:1]
+	MustInsertModule(ctx, t, testDB, m)
+	gotPaths, err = testDB.GetModuleGuidePaths(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"docs/intro.md"}, gotPaths); diff != "" {
+		t.Errorf("paths after reinsert mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// numRecentHomepagePackages is the number of packages listed in a
+// HomepageRecent section.
+const numRecentHomepagePackages = 10
+
+// GetHomepageSections returns the curated sections of the homepage, in order.
+// The paths of HomepageRecent sections are the most recently updated
+// packages under their prefix.
+func (db *DB) GetHomepageSections(ctx context.Context) (_ []*internal.HomepageSection, err error) {
+	defer derrors.WrapStack(&err, "GetHomepageSections(ctx)")
+
+	var sections []*internal.HomepageSection
+	collect := func(rows *sql.Rows) error {
+		var s internal.HomepageSection
+		if err := rows.Scan(&s.ID, &s.Position, &s.Kind, &s.Title, &s.Description,
+			pq.Array(&s.Paths), &s.PathPrefix); err != nil {
+			return err
+		}
+		sections = append(sections, &s)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, `
+		SELECT id, position, kind, title, description, paths, path_prefix
+		FROM homepage_sections
+		ORDER BY position, id`, collect); err != nil {
+		return nil, err
+	}
+	for _, s := range sections {
+		if s.Kind != internal.HomepageRecent {
+			continue
+		}
+		s.Paths, err = database.Collect1[string](ctx, db.db, `
+			SELECT package_path
+			FROM search_documents
+			WHERE left(package_path, length($1)) = $1
+			ORDER BY commit_time DESC, package_path
+			LIMIT $2`, s.PathPrefix, numRecentHomepagePackages)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+// InsertHomepageSection adds a curated section to the homepage, and returns
+// its ID.
+func (db *DB) InsertHomepageSection(ctx context.Context, s *internal.HomepageSection) (_ int, err error) {
+	defer derrors.WrapStack(&err, "InsertHomepageSection(ctx, %q)", s.Title)
+
+	if s.Kind == internal.HomepageRecent && s.PathPrefix == "" {
+		return 0, fmt.Errorf("recent section without a path prefix: %w", derrors.InvalidArgument)
+	}
+	paths := s.Paths
+	if paths == nil {
+		paths = []string{}
+	}
+	var id int
+	err = db.db.QueryRow(ctx, `
+		INSERT INTO homepage_sections (position, kind, title, description, paths, path_prefix)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		s.Position, s.Kind, s.Title, s.Description, pq.Array(paths), s.PathPrefix).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// DeleteHomepageSection removes the homepage section with the given ID.
+func (db *DB) DeleteHomepageSection(ctx context.Context, id int) (err error) {
+	defer derrors.WrapStack(&err, "DeleteHomepageSection(ctx, %d)", id)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM homepage_sections WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
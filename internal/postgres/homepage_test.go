@@ -0,0 +1,86 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestHomepageSections(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range []*internal.Module{
+		sample.Module("corp.com/a", "v1.0.0", "x"),
+		sample.Module("corp.com/b", "v1.0.0", "y"),
+		sample.Module("other.com/c", "v1.0.0", "z"),
+	} {
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	sections := []*internal.HomepageSection{
+		{Position: 2, Kind: internal.HomepageRecent, Title: "Recently updated", PathPrefix: "corp.com/"},
+		{Position: 1, Kind: internal.HomepagePinned, Title: "Pinned", Paths: []string{"corp.com/a"}},
+		{Position: 1, Kind: internal.HomepageCategory, Title: "Databases", Description: "Database clients.",
+			Paths: []string{"corp.com/b/y"}},
+	}
+	for _, s := range sections {
+		id, err := testDB.InsertHomepageSection(ctx, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.ID = id
+	}
+	if _, err := testDB.InsertHomepageSection(ctx, &internal.HomepageSection{Kind: internal.HomepageRecent, Title: "No prefix"}); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+
+	got, err := testDB.GetHomepageSections(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d sections, want 3", len(got))
+	}
+	want := []*internal.HomepageSection{sections[1], sections[2]}
+	if diff := cmp.Diff(want, got[:2], cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	recent := got[2]
+	if recent.ID != sections[0].ID || len(recent.Paths) == 0 {
+		t.Fatalf("got recent section %+v, want paths for section %d", recent, sections[0].ID)
+	}
+	for _, p := range recent.Paths {
+		if !strings.HasPrefix(p, "corp.com/") {
+			t.Errorf("recent section has %q, which is not under corp.com/", p)
+		}
+	}
+
+	if err := testDB.DeleteHomepageSection(ctx, sections[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.DeleteHomepageSection(ctx, sections[1].ID); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+	got, err = testDB.GetHomepageSections(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d sections after deleting one, want 2", len(got))
+	}
+}
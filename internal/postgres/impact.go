@@ -0,0 +1,199 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/lib/pq"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A DependentModule is the latest version of a module whose dependency graph
+// includes an affected version of another module.
+type DependentModule struct {
+	ModulePath string `json:"modulePath"`
+	Version    string `json:"version"`
+	// Direct reports whether the go.mod file of the module requires the
+	// affected module without an "// indirect" comment.
+	Direct bool `json:"direct"`
+	// RequiredVersion is the affected version that the module depends on.
+	RequiredVersion string `json:"requiredVersion"`
+	// Via is the path of the module through which the module depends on the
+	// affected module, if its go.mod file does not list it.
+	Via string `json:"via,omitempty"`
+}
+
+// A DependencyImpact is the set of modules that depend on a range of
+// versions of a module.
+type DependencyImpact struct {
+	// Modules are the dependent modules, ordered by path.
+	Modules       []*DependentModule
+	NumDirect     int
+	NumTransitive int
+	// Truncated reports whether the search stopped after reaching its
+	// maximum number of module versions, so that Modules may be incomplete.
+	Truncated bool
+}
+
+// dependencyNode is a module version reached by the search of
+// GetDependencyImpact.
+type dependencyNode struct {
+	path, version   string
+	requiredVersion string
+	direct          bool
+	via             string
+}
+
+// GetDependencyImpact returns the modules whose latest good version depends
+// on a version of modulePath that is at least introduced and less than
+// fixed. An empty introduced or fixed leaves that end of the range open.
+// At most maxModules module versions are visited.
+//
+// The search starts from the module versions whose go.mod files require an
+// affected version, directly or with an "// indirect" comment. It then
+// follows the require directives of other modules to those versions,
+// breadth first, with one query per level. Module versions that require an
+// unaffected version of modulePath are not followed, because minimal version
+// selection picks that version for them. As for the go command, the result
+// is exact for modules at go 1.17 or later, which list all their
+// dependencies in their go.mod files.
+func (db *DB) GetDependencyImpact(ctx context.Context, modulePath, introduced, fixed string, maxModules int) (_ *DependencyImpact, err error) {
+	defer derrors.WrapStack(&err, "GetDependencyImpact(ctx, %q, %q, %q, %d)", modulePath, introduced, fixed, maxModules)
+
+	var (
+		visited  = map[string]*dependencyNode{} // keyed by path@version
+		frontier []*dependencyNode
+		// unaffected holds the module versions that require a version of
+		// modulePath outside the range.
+		unaffected = map[string]bool{}
+	)
+	err = db.db.RunQuery(ctx, `
+		SELECT m.module_path, m.version, r.version, r.indirect
+		FROM module_requirements r
+		INNER JOIN modules m ON m.id = r.module_id
+		WHERE r.path = $1
+		ORDER BY m.module_path, m.version`,
+		func(rows *sql.Rows) error {
+			var (
+				n        dependencyNode
+				indirect bool
+			)
+			if err := rows.Scan(&n.path, &n.version, &n.requiredVersion, &indirect); err != nil {
+				return err
+			}
+			key := n.path + "@" + n.version
+			if !inVersionRange(n.requiredVersion, introduced, fixed) {
+				unaffected[key] = true
+				return nil
+			}
+			n.direct = !indirect
+			visited[key] = &n
+			frontier = append(frontier, &n)
+			return nil
+		}, modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := &DependencyImpact{}
+	for len(frontier) > 0 && !impact.Truncated {
+		var (
+			paths, versions []string
+			byKey           = map[string]*dependencyNode{}
+		)
+		for _, n := range frontier {
+			paths = append(paths, n.path)
+			versions = append(versions, n.version)
+			byKey[n.path+"@"+n.version] = n
+		}
+		var next []*dependencyNode
+		err := db.db.RunQuery(ctx, `
+			SELECT m.module_path, m.version, r.path, r.version
+			FROM module_requirements r
+			INNER JOIN unnest($1::text[], $2::text[]) AS f(path, version)
+				ON r.path = f.path AND r.version = f.version
+			INNER JOIN modules m ON m.id = r.module_id
+			ORDER BY m.module_path, m.version, r.path`,
+			func(rows *sql.Rows) error {
+				var path, version, reqPath, reqVersion string
+				if err := rows.Scan(&path, &version, &reqPath, &reqVersion); err != nil {
+					return err
+				}
+				key := path + "@" + version
+				if path == modulePath || unaffected[key] || visited[key] != nil {
+					return nil
+				}
+				if len(visited) >= maxModules {
+					impact.Truncated = true
+					return nil
+				}
+				n := &dependencyNode{
+					path:            path,
+					version:         version,
+					requiredVersion: byKey[reqPath+"@"+reqVersion].requiredVersion,
+					via:             reqPath,
+				}
+				visited[key] = n
+				next = append(next, n)
+				return nil
+			}, pq.Array(paths), pq.Array(versions))
+		if err != nil {
+			return nil, err
+		}
+		frontier = next
+	}
+
+	// Report only the latest good version of each module.
+	var paths []string
+	for _, n := range visited {
+		paths = append(paths, n.path)
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT p.path, l.good_version
+		FROM latest_module_versions l
+		INNER JOIN paths p ON p.id = l.module_path_id
+		WHERE p.path = ANY($1) AND l.good_version != ''`,
+		func(rows *sql.Rows) error {
+			var path, version string
+			if err := rows.Scan(&path, &version); err != nil {
+				return err
+			}
+			n := visited[path+"@"+version]
+			if n == nil || path == modulePath {
+				return nil
+			}
+			impact.Modules = append(impact.Modules, &DependentModule{
+				ModulePath:      n.path,
+				Version:         n.version,
+				Direct:          n.direct,
+				RequiredVersion: n.requiredVersion,
+				Via:             n.via,
+			})
+			if n.direct {
+				impact.NumDirect++
+			} else {
+				impact.NumTransitive++
+			}
+			return nil
+		}, pq.Array(paths))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(impact.Modules, func(i, j int) bool {
+		return impact.Modules[i].ModulePath < impact.Modules[j].ModulePath
+	})
+	return impact, nil
+}
+
+// inVersionRange reports whether v is at least introduced and less than
+// fixed. An empty introduced or fixed leaves that end of the range open.
+func inVersionRange(v, introduced, fixed string) bool {
+	return (introduced == "" || semver.Compare(v, introduced) >= 0) &&
+		(fixed == "" || semver.Compare(v, fixed) < 0)
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetDependencyImpact(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const vuln = "example.com/vuln"
+	insert := func(modulePath, version string, latest bool, reqs ...*internal.ModuleRequirement) {
+		m := sample.Module(modulePath, version, "")
+		m.Requirements = reqs
+		if latest {
+			MustInsertModule(ctx, t, testDB, m)
+		} else {
+			MustInsertModuleNotLatest(ctx, t, testDB, m)
+		}
+	}
+	// a requires an affected version directly.
+	insert("example.com/a", "v1.0.0", true, &internal.ModuleRequirement{Path: vuln, Version: "v1.1.0"})
+	// b lists an affected version as indirect.
+	insert("example.com/b", "v1.0.0", true, &internal.ModuleRequirement{Path: vuln, Version: "v1.2.0", Indirect: true})
+	// c does not list vuln, but requires a.
+	insert("example.com/c", "v1.0.0", true, &internal.ModuleRequirement{Path: "example.com/a", Version: "v1.0.0"})
+	// d requires a, but also a fixed version of vuln.
+	insert("example.com/d", "v1.0.0", true,
+		&internal.ModuleRequirement{Path: "example.com/a", Version: "v1.0.0"},
+		&internal.ModuleRequirement{Path: vuln, Version: "v1.3.0"})
+	// The latest version of e requires a fixed version; an older one does not.
+	insert("example.com/e", "v1.0.0", false, &internal.ModuleRequirement{Path: vuln, Version: "v1.0.0"})
+	insert("example.com/e", "v1.1.0", true, &internal.ModuleRequirement{Path: vuln, Version: "v1.3.0"})
+	// f requires a version before the range.
+	insert("example.com/f", "v1.0.0", true, &internal.ModuleRequirement{Path: vuln, Version: "v0.9.0"})
+
+	got, err := testDB.GetDependencyImpact(ctx, vuln, "v1.0.0", "v1.3.0", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DependencyImpact{
+		Modules: []*DependentModule{
+			{ModulePath: "example.com/a", Version: "v1.0.0", Direct: true, RequiredVersion: "v1.1.0"},
+			{ModulePath: "example.com/b", Version: "v1.0.0", RequiredVersion: "v1.2.0"},
+			{ModulePath: "example.com/c", Version: "v1.0.0", RequiredVersion: "v1.1.0", Via: "example.com/a"},
+		},
+		NumDirect:     1,
+		NumTransitive: 2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// An open range includes every version.
+	got, err = testDB.GetDependencyImpact(ctx, vuln, "", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := len(got.Modules), 6; g != w {
+		t.Errorf("open range: got %d modules, want %d", g, w)
+	}
+
+	// The search stops after maxModules module versions.
+	got, err = testDB.GetDependencyImpact(ctx, vuln, "v1.0.0", "v1.3.0", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Truncated {
+		t.Error("got Truncated = false, want true")
+	}
+}
+
+func TestInVersionRange(t *testing.T) {
+	for _, test := range []struct {
+		v, introduced, fixed string
+		want                 bool
+	}{
+		{"v1.0.0", "", "", true},
+		{"v1.0.0", "v1.0.0", "", true},
+		{"v0.9.0", "v1.0.0", "", false},
+		{"v1.2.0", "", "v1.2.0", false},
+		{"v1.1.9", "v1.0.0", "v1.2.0", true},
+		{"v0.0.0-20220101000000-abcdefabcdef", "", "v0.1.0", true},
+	} {
+		if got := inVersionRange(test.v, test.introduced, test.fixed); got != test.want {
+			t.Errorf("inVersionRange(%q, %q, %q) = %t, want %t", test.v, test.introduced, test.fixed, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// An ImportGraph is the transitive import graph of a package, as a tree
+// rooted at the package.
+type ImportGraph struct {
+	Root *ImportGraphNode
+	// NumPackages is the number of distinct packages in the graph, other
+	// than the root.
+	NumPackages int
+	// Truncated reports whether the graph was cut off after reaching its
+	// maximum number of packages. If it was, some packages are not
+	// expanded.
+	Truncated bool
+}
+
+// An ImportGraphNode is a package in an ImportGraph.
+type ImportGraphNode struct {
+	Path string
+	// Imports are the packages imported by the package, sorted by path. The
+	// imports of a package are listed only once in the graph, at the node
+	// closest to the root; they are empty at its other nodes, which are
+	// marked as repeated. They are also empty for the packages of the
+	// standard library and for those that were not reached.
+	Imports  []*ImportGraphNode
+	Repeated bool
+}
+
+// GetImportGraph returns the transitive import graph of the package at
+// pkgPath in the given module version, visiting at most maxPackages
+// packages. The imports of the packages of the module come from the same
+// version; those of the packages of other modules come from their latest
+// version, like the imported-by counts. The imports of packages of the
+// standard library are not followed.
+//
+// The graph is built by a breadth-first search over the imports table, with
+// one query per level.
+func (db *DB) GetImportGraph(ctx context.Context, pkgPath, modulePath, version string, maxPackages int) (_ *ImportGraph, err error) {
+	defer derrors.WrapStack(&err, "GetImportGraph(ctx, %q, %q, %q, %d)", pkgPath, modulePath, version, maxPackages)
+
+	// imports maps each expanded package to its imports.
+	imports := map[string][]string{}
+	// parent maps each reached package to the package whose node lists its
+	// imports.
+	parent := map[string]string{pkgPath: ""}
+	frontier := []string{pkgPath}
+	graph := &ImportGraph{}
+	for len(frontier) > 0 {
+		levelImports, err := getModuleVersionImports(ctx, db.db, frontier, modulePath, version)
+		if err != nil {
+			return nil, err
+		}
+		var rest []string
+		for _, p := range frontier {
+			if _, ok := levelImports[p]; !ok {
+				rest = append(rest, p)
+			}
+		}
+		latestImports, err := getLatestImports(ctx, db.db, rest)
+		if err != nil {
+			return nil, err
+		}
+		for p, imps := range latestImports {
+			levelImports[p] = imps
+		}
+
+		var next []string
+		for _, p := range frontier {
+			imps := levelImports[p]
+			sort.Strings(imps)
+			imports[p] = imps
+			for _, imp := range imps {
+				if _, ok := parent[imp]; !ok {
+					parent[imp] = p
+					if !stdlib.Contains(imp) {
+						next = append(next, imp)
+					}
+				}
+			}
+		}
+		if len(next) > 0 && len(parent) >= maxPackages {
+			graph.Truncated = true
+			break
+		}
+		frontier = next
+	}
+	graph.NumPackages = len(parent) - 1
+	graph.Root = importGraphNode(pkgPath, imports, parent)
+	return graph, nil
+}
+
+// importGraphNode returns the node of the package at path, with its imports
+// expanded if it is the first node for the package.
+func importGraphNode(path string, imports map[string][]string, parent map[string]string) *ImportGraphNode {
+	n := &ImportGraphNode{Path: path}
+	for _, imp := range imports[path] {
+		if parent[imp] == path {
+			n.Imports = append(n.Imports, importGraphNode(imp, imports, parent))
+		} else {
+			n.Imports = append(n.Imports, &ImportGraphNode{Path: imp, Repeated: true})
+		}
+	}
+	return n
+}
+
+// getModuleVersionImports returns a map from the paths in pkgPaths that are
+// packages of the given module version to their imports.
+func getModuleVersionImports(ctx context.Context, db *database.DB, pkgPaths []string, modulePath, version string) (map[string][]string, error) {
+	pathToImports := map[string][]string{}
+	err := db.RunQuery(ctx, `
+		SELECT p.path, tp.path
+		FROM units u
+		INNER JOIN modules m ON m.id = u.module_id
+		INNER JOIN paths p ON p.id = u.path_id
+		LEFT JOIN imports i ON i.unit_id = u.id
+		LEFT JOIN paths tp ON tp.id = i.to_path_id
+		WHERE m.module_path = $1 AND m.version = $2 AND p.path = ANY($3)`,
+		collectImports(pathToImports), modulePath, version, pq.Array(pkgPaths))
+	if err != nil {
+		return nil, err
+	}
+	return pathToImports, nil
+}
+
+// getLatestImports returns a map from the paths in pkgPaths that are in
+// search_documents to the imports of the package in the latest version of
+// its module.
+func getLatestImports(ctx context.Context, db *database.DB, pkgPaths []string) (map[string][]string, error) {
+	pathToImports := map[string][]string{}
+	if len(pkgPaths) == 0 {
+		return pathToImports, nil
+	}
+	err := db.RunQuery(ctx, `
+		SELECT sd.package_path, tp.path
+		FROM search_documents sd
+		INNER JOIN modules m ON m.module_path = sd.module_path AND m.version = sd.version
+		INNER JOIN units u ON u.module_id = m.id AND u.path_id = sd.package_path_id
+		LEFT JOIN imports i ON i.unit_id = u.id
+		LEFT JOIN paths tp ON tp.id = i.to_path_id
+		WHERE sd.package_path = ANY($1)`,
+		collectImports(pathToImports), pq.Array(pkgPaths))
+	if err != nil {
+		return nil, err
+	}
+	return pathToImports, nil
+}
+
+// collectImports returns a function that adds rows of package paths and
+// nullable import paths to pathToImports.
+func collectImports(pathToImports map[string][]string) func(*sql.Rows) error {
+	return func(rows *sql.Rows) error {
+		var from, to string
+		if err := rows.Scan(&from, database.NullIsEmpty(&to)); err != nil {
+			return err
+		}
+		imps := pathToImports[from]
+		if to != "" {
+			imps = append(imps, to)
+		}
+		pathToImports[from] = imps
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetImportGraph(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	imports := map[string][]string{
+		"example.com/a/p":  {"fmt", "example.com/b/q"},
+		"example.com/b/q":  {"example.com/c/r", "example.com/b/q2"},
+		"example.com/b/q2": {"example.com/d/s"},
+		"example.com/c/r":  {"example.com/d/s"},
+		"example.com/d/s":  nil,
+	}
+	for _, m := range []*internal.Module{
+		sample.Module("example.com/a", "v1.0.0", "p"),
+		sample.Module("example.com/b", "v1.0.0", "q", "q2"),
+		sample.Module("example.com/c", "v1.0.0", "r"),
+		sample.Module("example.com/d", "v1.0.0", "s"),
+	} {
+		for _, u := range m.Packages() {
+			u.Imports = imports[u.Path]
+		}
+		MustInsertModule(ctx, t, testDB, m)
+	}
+	// The graph of a version uses the imports of that version of its module.
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/a", "v1.1.0", "p"))
+
+	for _, test := range []struct {
+		name        string
+		maxPackages int
+		want        *ImportGraph
+	}{
+		{
+			name:        "complete",
+			maxPackages: 100,
+			want: &ImportGraph{
+				Root: &ImportGraphNode{Path: "example.com/a/p", Imports: []*ImportGraphNode{
+					{Path: "example.com/b/q", Imports: []*ImportGraphNode{
+						{Path: "example.com/b/q2", Imports: []*ImportGraphNode{
+							{Path: "example.com/d/s"},
+						}},
+						{Path: "example.com/c/r", Imports: []*ImportGraphNode{
+							{Path: "example.com/d/s", Repeated: true},
+						}},
+					}},
+					{Path: "fmt"},
+				}},
+				NumPackages: 5,
+			},
+		},
+		{
+			name:        "truncated",
+			maxPackages: 2,
+			want: &ImportGraph{
+				Root: &ImportGraphNode{Path: "example.com/a/p", Imports: []*ImportGraphNode{
+					{Path: "example.com/b/q"},
+					{Path: "fmt"},
+				}},
+				NumPackages: 2,
+				Truncated:   true,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := testDB.GetImportGraph(ctx, "example.com/a/p", "example.com/a", "v1.0.0", test.maxPackages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
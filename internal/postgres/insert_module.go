@@ -102,6 +102,15 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module, lmv *internal.
 		if err := insertLicenses(ctx, tx, m, moduleID); err != nil {
 			return err
 		}
+		if err := insertModuleReplacements(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
+		if err := insertModuleRequirements(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
+		if err := insertModuleGuides(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
 		pathToUnitID, pathToDocs, err := db.insertUnits(ctx, tx, m, moduleID, pathToID)
 		if err != nil {
 			return err
@@ -234,6 +243,13 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 	if err != nil {
 		return 0, err
 	}
+	var metadataJSON []byte
+	if m.Metadata != nil {
+		metadataJSON, err = json.Marshal(m.Metadata)
+		if err != nil {
+			return 0, err
+		}
+	}
 	var moduleID int
 	err = db.QueryRow(ctx,
 		`INSERT INTO modules(
@@ -246,13 +262,21 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 			source_info,
 			redistributable,
 			has_go_mod,
-			incompatible)
-		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+			incompatible,
+			go_version,
+			go_version_sort,
+			migration_guide,
+			metadata)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
 		ON CONFLICT
 			(module_path, version)
 		DO UPDATE SET
 			source_info=excluded.source_info,
-			redistributable=excluded.redistributable
+			redistributable=excluded.redistributable,
+			go_version=excluded.go_version,
+			go_version_sort=excluded.go_version_sort,
+			migration_guide=excluded.migration_guide,
+			metadata=excluded.metadata
 		RETURNING id`,
 		m.ModulePath,
 		m.Version,
@@ -264,6 +288,10 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 		m.IsRedistributable,
 		m.HasGoMod,
 		version.IsIncompatible(m.Version),
+		sql.NullString{String: m.GoVersion, Valid: m.GoVersion != ""},
+		goVersionSortKey(m.GoVersion),
+		sql.NullString{String: m.MigrationGuide, Valid: m.MigrationGuide != ""},
+		metadataJSON,
 	).Scan(&moduleID)
 	if err != nil {
 		return 0, err
@@ -299,6 +327,63 @@ func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, mo
 	return nil
 }
 
+// insertModuleReplacements replaces the rows of the module_replacements table
+// for the module with m.Replacements.
+func insertModuleReplacements(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.WrapStack(&err, "insertModuleReplacements(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	if _, err := db.Exec(ctx, `DELETE FROM module_replacements WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, r := range m.Replacements {
+		values = append(values, moduleID, r.OldPath, r.OldVersion, r.NewPath, r.NewVersion)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := []string{"module_id", "old_path", "old_version", "new_path", "new_version"}
+	return db.BulkInsert(ctx, "module_replacements", cols, values, database.OnConflictDoNothing)
+}
+
+// insertModuleRequirements replaces the rows of the module_requirements table
+// for the module with m.Requirements.
+func insertModuleRequirements(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.WrapStack(&err, "insertModuleRequirements(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	if _, err := db.Exec(ctx, `DELETE FROM module_requirements WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, r := range m.Requirements {
+		values = append(values, moduleID, r.Path, r.Version, r.Indirect)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := []string{"module_id", "path", "version", "indirect"}
+	return db.BulkInsert(ctx, "module_requirements", cols, values, database.OnConflictDoNothing)
+}
+
+// insertModuleGuides replaces the rows of the module_guides table for the
+// module with its guides.
+func insertModuleGuides(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.WrapStack(&err, "insertModuleGuides(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	if _, err := db.Exec(ctx, `DELETE FROM module_guides WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, g := range m.Guides {
+		values = append(values, moduleID, g.Filepath, makeValidUnicode(g.Contents))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := []string{"module_id", "file_path", "contents"}
+	return db.BulkInsert(ctx, "module_guides", cols, values, database.OnConflictDoNothing)
+}
+
 // insertImportsUnique inserts and removes rows from the imports_unique table. It should only
 // be called if the given module's version is the latest.
 func insertImportsUnique(ctx context.Context, tx *database.DB, m *internal.Module) (err error) {
@@ -378,6 +463,15 @@ func (pdb *DB) insertUnits(ctx context.Context, tx *database.DB,
 			return nil, nil, fmt.Errorf("no entry in paths table for %q; should be impossible", u.Path)
 		}
 		pathIDToPath[pathID] = u.Path
+		// The test counts are NULL if the unit has no tests.
+		var numTestFiles, numTests, numBenchmarks, numFuzzTargets, numExamples interface{}
+		if ts := u.TestStats; ts != nil {
+			numTestFiles = ts.NumTestFiles
+			numTests = ts.NumTests
+			numBenchmarks = ts.NumBenchmarks
+			numFuzzTargets = ts.NumFuzzTargets
+			numExamples = ts.NumExamples
+		}
 		unitValues = append(unitValues,
 			pathID,
 			moduleID,
@@ -386,6 +480,12 @@ func (pdb *DB) insertUnits(ctx context.Context, tx *database.DB,
 			pq.Array(licenseTypes),
 			pq.Array(licensePaths),
 			u.IsRedistributable,
+			numTestFiles,
+			numTests,
+			numBenchmarks,
+			numFuzzTargets,
+			numExamples,
+			pq.Array(u.Benchmarks),
 		)
 		if u.Readme != nil {
 			pathToReadme[u.Path] = u.Readme
@@ -423,6 +523,15 @@ func (pdb *DB) insertUnits(ctx context.Context, tx *database.DB,
 	if err := insertImports(ctx, tx, paths, pathToUnitID, pathToImports); err != nil {
 		return nil, nil, err
 	}
+	if err := insertFuzzTargets(ctx, tx, m.Units, pathToUnitID); err != nil {
+		return nil, nil, err
+	}
+	if err := insertCodeIndexes(ctx, tx, m.Units, pathToUnitID); err != nil {
+		return nil, nil, err
+	}
+	if err := insertExamples(ctx, tx, m.Units, pathToUnitID); err != nil {
+		return nil, nil, err
+	}
 	return pathToUnitID, pathToPkgDocs, nil
 }
 
@@ -459,6 +568,12 @@ func insertUnits(ctx context.Context, db *database.DB, unitValues []interface{})
 		"license_types",
 		"license_paths",
 		"redistributable",
+		"num_test_files",
+		"num_tests",
+		"num_benchmarks",
+		"num_fuzz_targets",
+		"num_examples",
+		"benchmarks",
 	}
 	uniqueUnitCols := []string{"path_id", "module_id"}
 	returningUnitCols := []string{"id", "path_id"}
@@ -506,7 +621,7 @@ func insertDocs(ctx context.Context, db *database.DB,
 					if doc.GOOS == "" || doc.GOARCH == "" {
 						ch <- database.RowItem{Err: errors.New("empty GOOS or GOARCH")}
 					}
-					ch <- database.RowItem{Values: []interface{}{unitID, doc.GOOS, doc.GOARCH, doc.Synopsis, doc.Source}}
+					ch <- database.RowItem{Values: []interface{}{unitID, doc.GOOS, doc.GOARCH, doc.Synopsis, doc.Source, doc.Truncated, doc.ContentHash}}
 				}
 			}
 			close(ch)
@@ -515,7 +630,7 @@ func insertDocs(ctx context.Context, db *database.DB,
 	}
 
 	uniqueCols := []string{"unit_id", "goos", "goarch"}
-	docCols := append(uniqueCols, "synopsis", "source")
+	docCols := append(uniqueCols, "synopsis", "source", "truncated", "content_hash")
 	return db.CopyUpsert(ctx, "documentation",
 		docCols, database.CopyFromChan(generateRows()), uniqueCols, "id")
 }
@@ -599,6 +714,89 @@ func insertImports(ctx context.Context, tx *database.DB,
 	return tx.BulkUpsert(ctx, "imports", importCols, importValues, importCols)
 }
 
+// insertFuzzTargets replaces the rows of the fuzz_targets table for units with
+// their fuzz targets.
+func insertFuzzTargets(ctx context.Context, db *database.DB, units []*internal.Unit, pathToUnitID map[string]int) (err error) {
+	defer derrors.WrapStack(&err, "insertFuzzTargets")
+
+	var (
+		unitIDs []int
+		values  []interface{}
+	)
+	for _, u := range units {
+		unitID := pathToUnitID[u.Path]
+		unitIDs = append(unitIDs, unitID)
+		for _, f := range u.FuzzTargets {
+			values = append(values, unitID, f.Name, f.NumSeeds)
+		}
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM fuzz_targets WHERE unit_id = ANY($1)`, pq.Array(unitIDs)); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := []string{"unit_id", "name", "num_seeds"}
+	return db.BulkInsert(ctx, "fuzz_targets", cols, values, database.OnConflictDoNothing)
+}
+
+// insertCodeIndexes replaces the rows of the code_indexes table for units with
+// their code indexes.
+func insertCodeIndexes(ctx context.Context, db *database.DB, units []*internal.Unit, pathToUnitID map[string]int) (err error) {
+	defer derrors.WrapStack(&err, "insertCodeIndexes")
+
+	var (
+		unitIDs []int
+		values  []interface{}
+	)
+	for _, u := range units {
+		unitID := pathToUnitID[u.Path]
+		unitIDs = append(unitIDs, unitID)
+		if u.CodeIndex == nil {
+			continue
+		}
+		contents, err := json.Marshal(u.CodeIndex)
+		if err != nil {
+			return fmt.Errorf("marshalling code index of %q: %v", u.Path, err)
+		}
+		values = append(values, unitID, contents)
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM code_indexes WHERE unit_id = ANY($1)`, pq.Array(unitIDs)); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return db.BulkInsert(ctx, "code_indexes", []string{"unit_id", "contents"}, values, database.OnConflictDoNothing)
+}
+
+// insertExamples replaces the rows of the examples table for units with
+// their examples.
+func insertExamples(ctx context.Context, db *database.DB, units []*internal.Unit, pathToUnitID map[string]int) (err error) {
+	defer derrors.WrapStack(&err, "insertExamples")
+
+	var (
+		unitIDs []int
+		values  []interface{}
+	)
+	for _, u := range units {
+		unitID := pathToUnitID[u.Path]
+		unitIDs = append(unitIDs, unitID)
+		for _, e := range u.Examples {
+			values = append(values, unitID, e.Name, makeValidUnicode(e.Doc), makeValidUnicode(e.Code),
+				makeValidUnicode(e.Output), e.Runnable)
+		}
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM examples WHERE unit_id = ANY($1)`, pq.Array(unitIDs)); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := []string{"unit_id", "name", "doc", "code", "output", "runnable"}
+	return db.BulkInsert(ctx, "examples", cols, values, database.OnConflictDoNothing)
+}
+
 func insertReadmes(ctx context.Context, db *database.DB,
 	paths []string,
 	pathToUnitID map[string]int,
@@ -780,6 +978,12 @@ func lock(ctx context.Context, tx *database.DB, modulePath string) (err error) {
 	if !database.QueryLoggingDisabled {
 		log.Debugf(ctx, "locking %s (%d) ...", modulePath, h)
 	}
+	if isCockroachDB(tx) {
+		// CockroachDB has no advisory locks. It runs every transaction at
+		// the serializable isolation level, so concurrent inserts of the
+		// same module conflict, and all but one are retried.
+		return nil
+	}
 	// See https://www.postgresql.org/docs/11/functions-admin.html#FUNCTIONS-ADVISORY-LOCKS.
 	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, h); err != nil {
 		return err
@@ -0,0 +1,162 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// A LicenseReview is a license file that did not match any known license.
+// An operator can review it and decide which license types apply to it.
+type LicenseReview struct {
+	ModulePath string
+	FilePath   string
+	// ContentsHash identifies the contents of the file, so that a decision
+	// about one version of a file doesn't apply to a different one.
+	ContentsHash string
+	// Version is the most recently processed version containing the file.
+	Version string
+	// Sample is a prefix of the contents of the file.
+	Sample string
+	// Types is nil until the file has been reviewed.
+	Types      []string
+	ReviewedBy string
+	ReviewedAt time.Time
+	CreatedAt  time.Time
+}
+
+// IsReviewed reports whether an operator has decided on the license types of
+// the file.
+func (r *LicenseReview) IsReviewed() bool {
+	return r.Types != nil
+}
+
+// InsertUnknownLicense adds an unknown license file to the review queue. If
+// the file is already present, only its version and sample are updated; a
+// previous review decision is kept.
+func (db *DB) InsertUnknownLicense(ctx context.Context, r *LicenseReview) (err error) {
+	defer derrors.WrapStack(&err, "InsertUnknownLicense(ctx, %q, %q, %q)", r.ModulePath, r.Version, r.FilePath)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO license_reviews (module_path, file_path, contents_hash, version, sample)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (module_path, file_path, contents_hash)
+		DO UPDATE SET
+			version = excluded.version,
+			sample = excluded.sample`,
+		r.ModulePath, r.FilePath, r.ContentsHash, r.Version, r.Sample)
+	return err
+}
+
+// GetLicenseReviews returns all license reviews for modulePath, whether or
+// not they have been reviewed.
+func (db *DB) GetLicenseReviews(ctx context.Context, modulePath string) (_ []*LicenseReview, err error) {
+	defer derrors.WrapStack(&err, "GetLicenseReviews(ctx, %q)", modulePath)
+	return db.getLicenseReviews(ctx, `WHERE module_path = $1 ORDER BY file_path, created_at`, modulePath)
+}
+
+// GetPendingLicenseReviews returns up to limit license files that have not
+// been reviewed, oldest first.
+func (db *DB) GetPendingLicenseReviews(ctx context.Context, limit int) (_ []*LicenseReview, err error) {
+	defer derrors.WrapStack(&err, "GetPendingLicenseReviews(ctx, %d)", limit)
+	return db.getLicenseReviews(ctx, `WHERE types IS NULL ORDER BY created_at LIMIT $1`, limit)
+}
+
+func (db *DB) getLicenseReviews(ctx context.Context, where string, args ...interface{}) ([]*LicenseReview, error) {
+	query := `
+		SELECT
+			module_path,
+			file_path,
+			contents_hash,
+			version,
+			sample,
+			types,
+			reviewed_by,
+			reviewed_at,
+			created_at
+		FROM license_reviews ` + where
+	var rs []*LicenseReview
+	collect := func(rows *sql.Rows) error {
+		var (
+			r          LicenseReview
+			reviewedBy sql.NullString
+			reviewedAt sql.NullTime
+		)
+		if err := rows.Scan(&r.ModulePath, &r.FilePath, &r.ContentsHash, &r.Version, &r.Sample,
+			pq.Array(&r.Types), &reviewedBy, &reviewedAt, &r.CreatedAt); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		r.ReviewedBy = reviewedBy.String
+		r.ReviewedAt = reviewedAt.Time
+		rs = append(rs, &r)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, args...); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// ReviewLicense records an operator's decision that the given license file
+// has the given license types. To mark a file as not having an open source
+// license, use licenses.ProprietaryLicenseType.
+//
+// Since the decision may change whether the module is redistributable, all
+// successfully processed versions of the module are marked for reprocessing.
+// It returns a NotFound error if the file is not in the review queue.
+func (db *DB) ReviewLicense(ctx context.Context, modulePath, filePath, contentsHash string, types []string, user string) (err error) {
+	defer derrors.WrapStack(&err, "ReviewLicense(ctx, %q, %q, %q, %v, %q)", modulePath, filePath, contentsHash, types, user)
+
+	if len(types) == 0 {
+		return fmt.Errorf("no license types: %w", derrors.InvalidArgument)
+	}
+	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		n, err := tx.Exec(ctx, `
+			UPDATE license_reviews
+			SET
+				types = $4,
+				reviewed_by = $5,
+				reviewed_at = CURRENT_TIMESTAMP
+			WHERE
+				module_path = $1
+				AND file_path = $2
+				AND contents_hash = $3`,
+			modulePath, filePath, contentsHash, pq.Array(types), user)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return derrors.NotFound
+		}
+		n, err = tx.Exec(ctx, `
+			UPDATE module_version_states
+			SET
+				status = (
+					CASE WHEN status=200 THEN 520
+						 WHEN status=290 THEN 521
+						 END
+					),
+				next_processed_after = CURRENT_TIMESTAMP,
+				last_processed_at = NULL
+			WHERE
+				module_path = $1
+				AND (status = 200 OR status = 290)`,
+			modulePath)
+		if err != nil {
+			return err
+		}
+		log.Infof(ctx, "reviewed license %s in %s as %v; %d module versions marked for reprocessing",
+			filePath, modulePath, types, n)
+		return nil
+	})
+}
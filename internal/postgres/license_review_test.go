@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+func TestLicenseReviews(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const modulePath = "example.com/m"
+	r1 := &LicenseReview{ModulePath: modulePath, FilePath: "LICENSE", ContentsHash: "h1", Version: "v1.0.0", Sample: "some text"}
+	r2 := &LicenseReview{ModulePath: modulePath, FilePath: "LICENSE", ContentsHash: "h2", Version: "v1.1.0", Sample: "other text"}
+	for _, r := range []*LicenseReview{r1, r2} {
+		if err := testDB.InsertUnknownLicense(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Inserting again updates the version.
+	r1.Version = "v1.0.1"
+	if err := testDB.InsertUnknownLicense(ctx, r1); err != nil {
+		t.Fatal(err)
+	}
+
+	ignore := cmpopts.IgnoreFields(LicenseReview{}, "CreatedAt", "ReviewedAt")
+	got, err := testDB.GetPendingLicenseReviews(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*LicenseReview{r1, r2}, got, ignore); diff != "" {
+		t.Errorf("pending mismatch (-want +got):\n%s", diff)
+	}
+
+	types := []string{licenses.ProprietaryLicenseType}
+	if err := testDB.ReviewLicense(ctx, modulePath, "LICENSE", "h1", types, "user"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.ReviewLicense(ctx, modulePath, "LICENSE", "h3", types, "user"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+
+	got, err = testDB.GetPendingLicenseReviews(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*LicenseReview{r2}, got, ignore); diff != "" {
+		t.Errorf("pending after review mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = testDB.GetLicenseReviews(ctx, modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1.Types = types
+	r1.ReviewedBy = "user"
+	if diff := cmp.Diff([]*LicenseReview{r1, r2}, got, ignore); diff != "" {
+		t.Errorf("all mismatch (-want +got):\n%s", diff)
+	}
+	if got[0].ReviewedAt.IsZero() {
+		t.Error("ReviewedAt not set after review")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A LicenseSearchResult is a license file whose contents match a license
+// search.
+type LicenseSearchResult struct {
+	ModulePath string
+	// Version is the latest version of the module with a matching file at
+	// FilePath.
+	Version  string
+	FilePath string
+	Types    []string
+	// Headline is an excerpt of the file around the matching text, with
+	// matches surrounded by "**".
+	Headline string
+}
+
+// SearchLicenses returns up to limit license files whose contents match
+// query, which has the syntax of a web search: quoted phrases, "or" and
+// negation with "-" are supported. Only the latest matching version of each
+// file is returned, and files of excluded modules are not returned. Results
+// are sorted by module path and file path.
+//
+// Licenses that have not been backfilled by BackfillLicenseSearch are not
+// searched.
+//
+// SearchLicenses is intended for compliance queries by operators, not for
+// use on the frontend.
+func (db *DB) SearchLicenses(ctx context.Context, query string, limit int) (_ []*LicenseSearchResult, err error) {
+	defer derrors.WrapStack(&err, "SearchLicenses(ctx, %q, %d)", query, limit)
+
+	headline, tsQuery := licenseHeadlineExpr, licenseTSQueryExpr
+	if isCockroachDB(db.db) {
+		headline, tsQuery = crdbLicenseHeadlineExpr, crdbLicenseTSQueryExpr
+	}
+	// Skip a match if a later version of the module has a matching file at the
+	// same path, instead of picking the latest one with DISTINCT ON, so that
+	// the limit applies before all matches are collected. Compute the
+	// headlines in the outer query, so that it is only done for the results
+	// that are returned.
+	q := fmt.Sprintf(`
+		SELECT
+			r.module_path,
+			r.version,
+			r.file_path,
+			r.types,
+			%[1]s
+		FROM (
+			SELECT
+				m.module_path,
+				m.version,
+				l.file_path,
+				l.types,
+				l.contents
+			FROM licenses l
+			INNER JOIN modules m ON m.id = l.module_id
+			WHERE l.tsv_contents @@ %[2]s
+			AND NOT EXISTS (
+				SELECT 1
+				FROM licenses l2
+				INNER JOIN modules m2 ON m2.id = l2.module_id
+				WHERE m2.module_path = m.module_path
+				AND l2.file_path = l.file_path
+				AND m2.sort_version > m.sort_version
+				AND l2.tsv_contents @@ %[2]s
+			)
+			AND NOT `+isExcludedExpr("m.module_path")+`
+			ORDER BY m.module_path, l.file_path
+			LIMIT $2
+		) r
+		ORDER BY r.module_path, r.file_path`, headline, tsQuery)
+	var results []*LicenseSearchResult
+	collect := func(rows *sql.Rows) error {
+		var r LicenseSearchResult
+		if err := rows.Scan(&r.ModulePath, &r.Version, &r.FilePath, pq.Array(&r.Types), &r.Headline); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		results = append(results, &r)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, q, collect, query, limit); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BackfillLicenseSearch computes the search tokens of up to limit licenses
+// that were inserted before license search was added, and returns the number
+// of licenses it updated. Licenses inserted since then get their tokens from a
+// trigger.
+func (db *DB) BackfillLicenseSearch(ctx context.Context, limit int) (n int64, err error) {
+	defer derrors.WrapStack(&err, "BackfillLicenseSearch(ctx, %d)", limit)
+
+	return db.db.Exec(ctx, `
+		UPDATE licenses l
+		SET tsv_contents = to_tsvector('english', left(l.contents, 100000))
+		FROM (
+			SELECT module_id, file_path
+			FROM licenses
+			WHERE tsv_contents IS NULL
+			LIMIT $1
+		) p
+		WHERE l.module_id = p.module_id
+		AND l.file_path = p.file_path`, limit)
+}
+
+// CountLicenseSearchPending returns the number of licenses without search
+// tokens.
+func (db *DB) CountLicenseSearchPending(ctx context.Context) (n int, err error) {
+	defer derrors.WrapStack(&err, "CountLicenseSearchPending(ctx)")
+
+	err = db.db.QueryRow(ctx, `SELECT COUNT(*) FROM licenses WHERE tsv_contents IS NULL`).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSearchLicenses(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const patentText = "Each contributor grants you a patent license to make and use the software."
+	for _, m := range []struct {
+		path, version, contents string
+	}{
+		{"example.com/a", "v1.0.0", patentText},
+		{"example.com/a", "v1.1.0", patentText},
+		{"example.com/b", "v1.0.0", "Use is restricted to the following field of use: research."},
+		{"example.com/c", "v1.0.0", "Permission is hereby granted, free of charge."},
+		{"example.com/x", "v1.0.0", patentText},
+	} {
+		mod := sample.Module(m.path, m.version)
+		mod.Licenses = []*licenses.License{{
+			Metadata: sample.LicenseMetadata()[0],
+			Contents: []byte(m.contents),
+		}}
+		MustInsertModule(ctx, t, testDB, mod)
+	}
+
+	if err := testDB.InsertExcludedPrefix(ctx, "example.com/x", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	ignore := cmpopts.IgnoreFields(LicenseSearchResult{}, "Headline")
+	for _, test := range []struct {
+		query string
+		want  []*LicenseSearchResult
+	}{
+		{
+			query: "patent",
+			want: []*LicenseSearchResult{
+				{ModulePath: "example.com/a", Version: "v1.1.0", FilePath: "LICENSE", Types: []string{"MIT"}},
+			},
+		},
+		{
+			query: `"field of use"`,
+			want: []*LicenseSearchResult{
+				{ModulePath: "example.com/b", Version: "v1.0.0", FilePath: "LICENSE", Types: []string{"MIT"}},
+			},
+		},
+		{
+			query: "patent or research",
+			want: []*LicenseSearchResult{
+				{ModulePath: "example.com/a", Version: "v1.1.0", FilePath: "LICENSE", Types: []string{"MIT"}},
+				{ModulePath: "example.com/b", Version: "v1.0.0", FilePath: "LICENSE", Types: []string{"MIT"}},
+			},
+		},
+		{
+			query: `"use of the field"`,
+			want:  nil,
+		},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := testDB.SearchLicenses(ctx, test.query, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, ignore); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	got, err := testDB.SearchLicenses(ctx, "patent", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "**patent**"; len(got) != 1 || !strings.Contains(got[0].Headline, want) {
+		t.Errorf("got %+v, want a headline containing %q", got, want)
+	}
+}
+
+func TestBackfillLicenseSearch(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, path := range []string{"example.com/a", "example.com/b", "example.com/c"} {
+		mod := sample.Module(path, sample.VersionString)
+		mod.Licenses = []*licenses.License{{
+			Metadata: sample.LicenseMetadata()[0],
+			Contents: []byte("Each contributor grants you a patent license."),
+		}}
+		MustInsertModule(ctx, t, testDB, mod)
+	}
+	// Simulate licenses inserted before license search was added.
+	if _, err := testDB.db.Exec(ctx, `UPDATE licenses SET tsv_contents = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	check := func(wantPending, wantResults int) {
+		t.Helper()
+		pending, err := testDB.CountLicenseSearchPending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending != wantPending {
+			t.Errorf("got %d pending, want %d", pending, wantPending)
+		}
+		got, err := testDB.SearchLicenses(ctx, "patent", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != wantResults {
+			t.Errorf("got %d results, want %d", len(got), wantResults)
+		}
+	}
+	check(3, 0)
+	for _, want := range []int64{2, 1, 0} {
+		n, err := testDB.BackfillLicenseSearch(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("got %d licenses backfilled, want %d", n, want)
+		}
+	}
+	check(0, 3)
+}
@@ -185,3 +185,82 @@ func compareLicenses(i, j *licenses.Metadata) bool {
 	}
 	return i.FilePath < j.FilePath
 }
+
+// LicenseExportOptions are the options for ExportLicenses.
+type LicenseExportOptions struct {
+	// ModulePrefix restricts the export to the module with this path and
+	// any modules whose paths are below it. It must be non-empty.
+	ModulePrefix string
+
+	// LicenseType, if non-empty, restricts the export to license files that
+	// contain a license of this type, for example "MIT".
+	LicenseType string
+
+	// Limit is the maximum number of rows to return.
+	Limit int
+
+	// Offset is the number of rows to skip.
+	Offset int
+}
+
+// ExportedLicense describes a license file in a module version. It
+// contains only metadata, not the contents of the file.
+type ExportedLicense struct {
+	ModulePath string   `json:"modulePath"`
+	Version    string   `json:"version"`
+	FilePath   string   `json:"filePath"`
+	Types      []string `json:"types"`
+}
+
+// ExportLicenses returns the license types of every license file in every
+// module version whose module path is opts.ModulePrefix or begins with
+// opts.ModulePrefix + "/". Results are ordered by module path, then by
+// descending version, then by file path.
+func (db *DB) ExportLicenses(ctx context.Context, opts LicenseExportOptions) (_ []*ExportedLicense, err error) {
+	defer derrors.WrapStack(&err, "ExportLicenses(ctx, %+v)", opts)
+	defer middleware.ElapsedStat(ctx, "ExportLicenses")()
+
+	if opts.ModulePrefix == "" {
+		return nil, fmt.Errorf("empty module prefix: %w", derrors.InvalidArgument)
+	}
+	if opts.Limit <= 0 || opts.Offset < 0 {
+		return nil, fmt.Errorf("bad limit or offset: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			m.module_path,
+			m.version,
+			l.file_path,
+			l.types
+		FROM
+			licenses l
+		INNER JOIN
+			modules m
+		ON
+			m.id = l.module_id
+		WHERE
+			(m.module_path = $1 OR left(m.module_path, length($1) + 1) = $1 || '/')
+		AND
+			($2 = '' OR $2 = ANY(l.types))
+		AND NOT ` + isExcludedExpr("m.module_path") + `
+		ORDER BY
+			m.module_path,
+			m.sort_version DESC,
+			l.file_path
+		LIMIT $3
+		OFFSET $4`
+
+	var lics []*ExportedLicense
+	collect := func(rows *sql.Rows) error {
+		var l ExportedLicense
+		if err := rows.Scan(&l.ModulePath, &l.Version, &l.FilePath, pq.Array(&l.Types)); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		lics = append(lics, &l)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, opts.ModulePrefix, opts.LicenseType, opts.Limit, opts.Offset); err != nil {
+		return nil, err
+	}
+	return lics, nil
+}
@@ -232,3 +232,86 @@ func makeModuleNonRedistributable(m *internal.Module) {
 		m.Units[i].IsRedistributable = false
 	}
 }
+
+func TestExportLicenses(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	insert := func(modulePath, version string, lics ...*licenses.Metadata) {
+		m := sample.Module(modulePath, version, "")
+		m.Licenses = nil
+		for _, md := range lics {
+			m.Licenses = append(m.Licenses, &licenses.License{Metadata: md, Contents: []byte(`Lorem Ipsum`)})
+		}
+		m.Units[0].Licenses = lics
+		MustInsertModule(ctx, t, testDB, m)
+	}
+	mit := &licenses.Metadata{Types: []string{"MIT"}, FilePath: "LICENSE"}
+	apache := &licenses.Metadata{Types: []string{"Apache-2.0"}, FilePath: "LICENSE"}
+	bsd := &licenses.Metadata{Types: []string{"BSD-3-Clause"}, FilePath: "third_party/LICENSE"}
+	insert("example.org/a", "v1.0.0", mit)
+	insert("example.org/a", "v1.1.0", apache, bsd)
+	insert("example.org/b", "v0.1.0", mit)
+	insert("example.org/ab", "v0.1.0", mit)
+	insert("example.org/ab/c", "v0.1.0", mit)
+	insert("example.org/x", "v0.1.0", mit)
+	if err := testDB.InsertExcludedPrefix(ctx, "example.org/x", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		opts LicenseExportOptions
+		want []*ExportedLicense
+	}{
+		{
+			name: "module",
+			opts: LicenseExportOptions{ModulePrefix: "example.org/a", Limit: 10},
+			want: []*ExportedLicense{
+				{"example.org/a", "v1.1.0", "LICENSE", []string{"Apache-2.0"}},
+				{"example.org/a", "v1.1.0", "third_party/LICENSE", []string{"BSD-3-Clause"}},
+				{"example.org/a", "v1.0.0", "LICENSE", []string{"MIT"}},
+			},
+		},
+		{
+			name: "prefix with type",
+			opts: LicenseExportOptions{ModulePrefix: "example.org", LicenseType: "MIT", Limit: 10},
+			want: []*ExportedLicense{
+				{"example.org/a", "v1.0.0", "LICENSE", []string{"MIT"}},
+				{"example.org/ab", "v0.1.0", "LICENSE", []string{"MIT"}},
+				{"example.org/ab/c", "v0.1.0", "LICENSE", []string{"MIT"}},
+				{"example.org/b", "v0.1.0", "LICENSE", []string{"MIT"}},
+			},
+		},
+		{
+			// "_" is not a wildcard.
+			name: "prefix with underscore",
+			opts: LicenseExportOptions{ModulePrefix: "example.org/a_", Limit: 10},
+		},
+		{
+			name: "excluded",
+			opts: LicenseExportOptions{ModulePrefix: "example.org/x", Limit: 10},
+		},
+		{
+			name: "paginated",
+			opts: LicenseExportOptions{ModulePrefix: "example.org", Limit: 2, Offset: 2},
+			want: []*ExportedLicense{
+				{"example.org/a", "v1.0.0", "LICENSE", []string{"MIT"}},
+				{"example.org/ab", "v0.1.0", "LICENSE", []string{"MIT"}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := testDB.ExportLicenses(ctx, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
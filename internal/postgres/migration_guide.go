@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetMigrationGuide returns the name of the migration guide at the root of
+// modulePath@version, or the empty string if it has none. It returns a
+// NotFound error if the module version is not in the database.
+func (db *DB) GetMigrationGuide(ctx context.Context, modulePath, version string) (_ string, err error) {
+	defer derrors.WrapStack(&err, "GetMigrationGuide(ctx, %q, %q)", modulePath, version)
+
+	var guide string
+	err = db.db.QueryRow(ctx, `
+		SELECT migration_guide
+		FROM modules
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(database.NullIsEmpty(&guide))
+	if err == sql.ErrNoRows {
+		return "", derrors.NotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return guide, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetMigrationGuide(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	MustInsertModule(ctx, t, testDB, m)
+	got, err := testDB.GetMigrationGuide(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("got %q, want no guide", got)
+	}
+
+	m.MigrationGuide = "MIGRATION.md"
+	MustInsertModule(ctx, t, testDB, m)
+	got, err = testDB.GetMigrationGuide(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != m.MigrationGuide {
+		t.Errorf("got %q, want %q", got, m.MigrationGuide)
+	}
+
+	if _, err := testDB.GetMigrationGuide(ctx, m.ModulePath, "v9.9.9"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v for missing version, want NotFound", err)
+	}
+}
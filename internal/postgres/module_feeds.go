@@ -0,0 +1,201 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// The names of the module feeds.
+const (
+	// NewModulesFeed lists the modules whose first version was indexed
+	// recently, most recent first.
+	NewModulesFeed = "new"
+	// TrendingModulesFeed lists the modules whose imported-by count grew the
+	// most over the last 30 days.
+	TrendingModulesFeed = "trending"
+)
+
+const (
+	// feedDays is the number of days over which the feeds are computed.
+	feedDays = 30
+	// feedLength is the maximum number of modules in a feed.
+	feedLength = 100
+)
+
+// A FeedModule is a module listed in a module feed.
+type FeedModule struct {
+	ModulePath string
+	// Version is the latest good version of the module.
+	Version string
+	// IndexedAt is the time the first version of the module was indexed. It
+	// is only set for NewModulesFeed.
+	IndexedAt time.Time
+	// NumImportedBy is the largest imported-by count of the module's
+	// packages.
+	NumImportedBy int
+	// ImportedByGrowth is the increase in NumImportedBy over the last 30
+	// days. It is only set for TrendingModulesFeed.
+	ImportedByGrowth int
+}
+
+// ComputeModuleFeeds snapshots the imported-by counts of all modules for
+// date, and computes the module feeds for date from the snapshots. Existing
+// feeds for date are replaced. Excluded modules are left out of the feeds. It
+// is meant to be run once a day.
+func (db *DB) ComputeModuleFeeds(ctx context.Context, date time.Time) (err error) {
+	defer derrors.WrapStack(&err, "ComputeModuleFeeds(ctx, %s)", date.Format("2006-01-02"))
+
+	d := date.Format("2006-01-02")
+	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO module_imported_by_snapshots (date, module_path, imported_by_count)
+			SELECT $1::date, module_path, max(imported_by_count)
+			FROM search_documents
+			WHERE imported_by_count > 0
+			GROUP BY module_path
+			ON CONFLICT (date, module_path)
+			DO UPDATE SET imported_by_count = excluded.imported_by_count`, d); err != nil {
+			return err
+		}
+		// Keep twice as many snapshots as are needed, in case the job
+		// doesn't run for a while.
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM module_imported_by_snapshots WHERE date < $1::date - $2::integer`,
+			d, 2*feedDays); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM module_feeds WHERE date = $1::date OR date < $1::date - $2::integer`,
+			d, feedDays); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO module_feeds (date, feed, rank, module_path, version,
+				indexed_at, imported_by_count, imported_by_growth)
+			SELECT
+				$1::date,
+				$2,
+				row_number() OVER (ORDER BY f.indexed_at DESC, f.module_path),
+				f.module_path,
+				l.good_version,
+				f.indexed_at,
+				coalesce(s.imported_by_count, 0),
+				0
+			FROM (
+				-- Only look at the versions indexed during the period, and
+				-- skip the modules that had versions before it.
+				SELECT m.module_path, min(m.created_at) AS indexed_at
+				FROM modules m
+				WHERE m.created_at >= $1::date - $3::integer
+				AND m.created_at < $1::date + 1
+				AND NOT EXISTS (
+					SELECT 1
+					FROM modules old
+					WHERE old.module_path = m.module_path
+					AND old.created_at < $1::date - $3::integer
+				)
+				AND NOT `+isExcludedExpr("m.module_path")+`
+				GROUP BY m.module_path
+			) f
+			INNER JOIN paths p ON p.path = f.module_path
+			INNER JOIN latest_module_versions l ON l.module_path_id = p.id
+			LEFT JOIN module_imported_by_snapshots s
+				ON s.module_path = f.module_path AND s.date = $1::date
+			WHERE l.good_version != ''
+			ORDER BY f.indexed_at DESC, f.module_path
+			LIMIT $4`,
+			d, NewModulesFeed, feedDays, feedLength); err != nil {
+			return err
+		}
+		// Measure growth from the oldest snapshot in the period, so that
+		// the feed is useful before there are snapshots for the whole
+		// period. Modules without a snapshot at that date are new, and
+		// belong in the other feed.
+		_, err := tx.Exec(ctx, `
+			INSERT INTO module_feeds (date, feed, rank, module_path, version,
+				indexed_at, imported_by_count, imported_by_growth)
+			SELECT
+				$1::date,
+				$2,
+				row_number() OVER (ORDER BY g.growth DESC, g.module_path),
+				g.module_path,
+				l.good_version,
+				NULL,
+				g.imported_by_count,
+				g.growth
+			FROM (
+				SELECT
+					cur.module_path,
+					cur.imported_by_count,
+					cur.imported_by_count - old.imported_by_count AS growth
+				FROM module_imported_by_snapshots cur
+				INNER JOIN module_imported_by_snapshots old
+					ON old.module_path = cur.module_path
+					AND old.date = (
+						SELECT min(date)
+						FROM module_imported_by_snapshots
+						WHERE date >= $1::date - $3::integer
+					)
+				WHERE cur.date = $1::date
+				AND cur.imported_by_count > old.imported_by_count
+				AND NOT `+isExcludedExpr("cur.module_path")+`
+			) g
+			INNER JOIN paths p ON p.path = g.module_path
+			INNER JOIN latest_module_versions l ON l.module_path_id = p.id
+			WHERE l.good_version != ''
+			ORDER BY g.growth DESC, g.module_path
+			LIMIT $4`,
+			d, TrendingModulesFeed, feedDays, feedLength)
+		return err
+	})
+}
+
+// GetModuleFeed returns the most recently computed modules of the named feed,
+// in order, and the date they were computed for. It returns a zero date if the
+// feed has not been computed.
+func (db *DB) GetModuleFeed(ctx context.Context, feed string) (_ []*FeedModule, _ time.Time, err error) {
+	defer derrors.WrapStack(&err, "GetModuleFeed(ctx, %q)", feed)
+
+	query := `
+		SELECT
+			date,
+			module_path,
+			version,
+			indexed_at,
+			imported_by_count,
+			imported_by_growth
+		FROM module_feeds
+		WHERE feed = $1
+		AND date = (SELECT max(date) FROM module_feeds WHERE feed = $1)
+		ORDER BY rank`
+	var (
+		mods []*FeedModule
+		date time.Time
+	)
+	collect := func(rows *sql.Rows) error {
+		var (
+			m         FeedModule
+			indexedAt sql.NullTime
+		)
+		if err := rows.Scan(&date, &m.ModulePath, &m.Version, &indexedAt,
+			&m.NumImportedBy, &m.ImportedByGrowth); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		m.IndexedAt = indexedAt.Time
+		mods = append(mods, &m)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, feed); err != nil {
+		return nil, time.Time{}, err
+	}
+	return mods, date, nil
+}
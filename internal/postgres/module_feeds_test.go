@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleFeeds(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range []string{"example.com/a", "example.com/b", "example.com/c", "example.com/old", "example.com/x"} {
+		MustInsertModule(ctx, t, testDB, sample.Module(m, "v1.0.0", "p"))
+	}
+	// example.com/old had a version before the period of the feeds.
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/old", "v1.1.0", "p"))
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE modules SET created_at = created_at - interval '60 days'
+		WHERE module_path = 'example.com/old' AND version = 'v1.0.0'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, "example.com/x", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+	setImportedByCount := func(modulePath string, n int) {
+		t.Helper()
+		if _, err := testDB.db.Exec(ctx, `
+			UPDATE search_documents SET imported_by_count = $2 WHERE module_path = $1`,
+			modulePath, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	setImportedByCount("example.com/a", 10)
+	setImportedByCount("example.com/b", 5)
+	setImportedByCount("example.com/x", 1)
+	if err := testDB.ComputeModuleFeeds(ctx, today.AddDate(0, 0, -10)); err != nil {
+		t.Fatal(err)
+	}
+	setImportedByCount("example.com/a", 12)
+	setImportedByCount("example.com/b", 50)
+	setImportedByCount("example.com/c", 3)
+	setImportedByCount("example.com/x", 100)
+	if err := testDB.ComputeModuleFeeds(ctx, today); err != nil {
+		t.Fatal(err)
+	}
+
+	got, date, err := testDB.GetModuleFeed(ctx, TrendingModulesFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !date.Equal(today) {
+		t.Errorf("got date %s, want %s", date, today)
+	}
+	want := []*FeedModule{
+		{ModulePath: "example.com/b", Version: "v1.0.0", NumImportedBy: 50, ImportedByGrowth: 45},
+		{ModulePath: "example.com/a", Version: "v1.0.0", NumImportedBy: 12, ImportedByGrowth: 2},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("trending mismatch (-want +got):\n%s", diff)
+	}
+
+	got, _, err = testDB.GetModuleFeed(ctx, NewModulesFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, m := range got {
+		if m.IndexedAt.IsZero() {
+			t.Errorf("%s: IndexedAt not set", m.ModulePath)
+		}
+		paths = append(paths, m.ModulePath)
+	}
+	// All modules were inserted at about the same time, so only check the
+	// set of modules.
+	wantPaths := []string{"example.com/a", "example.com/b", "example.com/c"}
+	if diff := cmp.Diff(wantPaths, paths, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("new mismatch (-want +got):\n%s", diff)
+	}
+}
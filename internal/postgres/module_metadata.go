@@ -0,0 +1,35 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetModuleMetadata returns the metadata that the authors of
+// modulePath@version provided in its .pkgsite.yaml file, or nil if it has
+// none. It returns a NotFound error if the module version is not in the
+// database.
+func (db *DB) GetModuleMetadata(ctx context.Context, modulePath, version string) (_ *internal.ModuleMetadata, err error) {
+	defer derrors.WrapStack(&err, "GetModuleMetadata(ctx, %q, %q)", modulePath, version)
+
+	var md *internal.ModuleMetadata
+	err = db.db.QueryRow(ctx, `
+		SELECT metadata
+		FROM modules
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(jsonbScanner{&md})
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return md, nil
+}
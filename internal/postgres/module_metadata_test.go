@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetModuleMetadata(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	MustInsertModule(ctx, t, testDB, m)
+	got, err := testDB.GetModuleMetadata(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got %+v, want no metadata", got)
+	}
+
+	m.Metadata = &internal.ModuleMetadata{
+		Description: "A module.",
+		Homepage:    "https://example.com",
+		Tags:        []string{"web"},
+		EntryPoints: []string{sample.ModulePath + "/a"},
+	}
+	MustInsertModule(ctx, t, testDB, m)
+	got, err = testDB.GetModuleMetadata(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m.Metadata, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	if _, err := testDB.GetModuleMetadata(ctx, m.ModulePath, "v9.9.9"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v for missing version, want NotFound", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/middleware"
+)
+
+// GetModuleReplacements returns the replace directives in the go.mod file of
+// modulePath@version, ordered by the path and version they replace.
+func (db *DB) GetModuleReplacements(ctx context.Context, modulePath, version string) (_ []*internal.ModuleReplacement, err error) {
+	defer derrors.WrapStack(&err, "GetModuleReplacements(ctx, %q, %q)", modulePath, version)
+	defer middleware.ElapsedStat(ctx, "GetModuleReplacements")()
+
+	query := `
+		SELECT r.old_path, r.old_version, r.new_path, r.new_version
+		FROM module_replacements r
+		INNER JOIN modules m ON m.id = r.module_id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY r.old_path, r.old_version`
+	var rs []*internal.ModuleReplacement
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var r internal.ModuleReplacement
+		if err := rows.Scan(&r.OldPath, &r.OldVersion, &r.NewPath, &r.NewVersion); err != nil {
+			return err
+		}
+		rs = append(rs, &r)
+		return nil
+	}, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetModuleReplacements(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	m.Replacements = []*internal.ModuleReplacement{
+		{OldPath: "example.com/z", NewPath: "../z"},
+		{OldPath: "example.com/b", OldVersion: "v1.0.0", NewPath: "example.com/fork", NewVersion: "v1.0.1"},
+	}
+	MustInsertModule(ctx, t, testDB, m)
+
+	got, err := testDB.GetModuleReplacements(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleReplacement{m.Replacements[1], m.Replacements[0]}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Reinserting the module with fewer replacements removes the old ones.
+	m.Replacements = m.Replacements[:1]
+	MustInsertModule(ctx, t, testDB, m)
+	got, err = testDB.GetModuleReplacements(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m.Replacements, got); diff != "" {
+		t.Errorf("after reinsert: mismatch (-want, +got):\n%s", diff)
+	}
+}
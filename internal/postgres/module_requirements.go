@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/middleware"
+)
+
+// GetModuleRequirements returns the require directives in the go.mod file of
+// modulePath@version, ordered by the path of the required module.
+func (db *DB) GetModuleRequirements(ctx context.Context, modulePath, version string) (_ []*internal.ModuleRequirement, err error) {
+	defer derrors.WrapStack(&err, "GetModuleRequirements(ctx, %q, %q)", modulePath, version)
+	defer middleware.ElapsedStat(ctx, "GetModuleRequirements")()
+
+	query := `
+		SELECT r.path, r.version, r.indirect
+		FROM module_requirements r
+		INNER JOIN modules m ON m.id = r.module_id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY r.path`
+	var rs []*internal.ModuleRequirement
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var r internal.ModuleRequirement
+		if err := rows.Scan(&r.Path, &r.Version, &r.Indirect); err != nil {
+			return err
+		}
+		rs = append(rs, &r)
+		return nil
+	}, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetModuleRequirements(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	m.Requirements = []*internal.ModuleRequirement{
+		{Path: "example.com/z", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v0.1.0", Indirect: true},
+	}
+	MustInsertModule(ctx, t, testDB, m)
+	// Requirements of other versions are not returned.
+	m2 := sample.Module(sample.ModulePath, "v1.2.4", "a")
+	m2.Requirements = []*internal.ModuleRequirement{{Path: "example.com/c", Version: "v1.0.0"}}
+	MustInsertModule(ctx, t, testDB, m2)
+
+	got, err := testDB.GetModuleRequirements(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleRequirement{m.Requirements[1], m.Requirements[0]}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/namespace"
+)
+
+// A NamespaceClaim is a claim of control of a module path prefix.
+type NamespaceClaim struct {
+	Prefix    string
+	Token     string
+	CreatedAt time.Time
+	// VerifiedAt is when the claim was last verified, Method how, and
+	// ExpiresAt when the verification expires. They are zero if the claim
+	// has not been verified.
+	VerifiedAt time.Time
+	Method     string
+	ExpiresAt  time.Time
+}
+
+// Verified reports whether c is verified at time now.
+func (c *NamespaceClaim) Verified(now time.Time) bool {
+	return !c.VerifiedAt.IsZero() && now.Before(c.ExpiresAt)
+}
+
+// InsertNamespaceClaim records an unverified claim of prefix with token,
+// made with the API key with ID keyID, or without a key if keyID is 0.
+func (db *DB) InsertNamespaceClaim(ctx context.Context, prefix, token string, keyID int) (err error) {
+	defer derrors.WrapStack(&err, "InsertNamespaceClaim(ctx, %q, %d)", prefix, keyID)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO namespace_claims (prefix, token, api_key_id) VALUES ($1, $2, NULLIF($3, 0))`,
+		prefix, token, keyID)
+	return err
+}
+
+// CountNamespaceClaims returns the number of unverified claims of prefix, and
+// the number of claims made with the API key with ID keyID (or without a key,
+// if keyID is 0), that were created after since.
+func (db *DB) CountNamespaceClaims(ctx context.Context, prefix string, keyID int, since time.Time) (byPrefix, byKey int, err error) {
+	defer derrors.WrapStack(&err, "CountNamespaceClaims(ctx, %q, %d, %s)", prefix, keyID, since)
+
+	err = db.db.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE prefix = $1 AND verified_at IS NULL),
+			COUNT(*) FILTER (WHERE COALESCE(api_key_id, 0) = $2)
+		FROM namespace_claims
+		WHERE created_at > $3`,
+		prefix, keyID, since).Scan(&byPrefix, &byKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	return byPrefix, byKey, nil
+}
+
+// GetNamespaceClaim returns the claim of prefix with token. It returns a
+// NotFound error if there is none.
+func (db *DB) GetNamespaceClaim(ctx context.Context, prefix, token string) (_ *NamespaceClaim, err error) {
+	defer derrors.WrapStack(&err, "GetNamespaceClaim(ctx, %q)", prefix)
+
+	c := &NamespaceClaim{Prefix: prefix, Token: token}
+	var (
+		verifiedAt, expiresAt sql.NullTime
+		method                sql.NullString
+	)
+	err = db.db.QueryRow(ctx, `
+		SELECT created_at, verified_at, method, expires_at
+		FROM namespace_claims
+		WHERE prefix = $1 AND token = $2`,
+		prefix, token).Scan(&c.CreatedAt, &verifiedAt, &method, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.VerifiedAt = verifiedAt.Time
+	c.Method = method.String
+	c.ExpiresAt = expiresAt.Time
+	return c, nil
+}
+
+// VerifyNamespaceClaim marks the claim of prefix with token as verified by
+// method until expiresAt. It returns a NotFound error if there is no such
+// claim.
+func (db *DB) VerifyNamespaceClaim(ctx context.Context, prefix, token, method string, expiresAt time.Time) (err error) {
+	defer derrors.WrapStack(&err, "VerifyNamespaceClaim(ctx, %q, %q, %s)", prefix, method, expiresAt)
+
+	n, err := db.db.Exec(ctx, `
+		UPDATE namespace_claims
+		SET verified_at = CURRENT_TIMESTAMP, method = $3, expires_at = $4
+		WHERE prefix = $1 AND token = $2`,
+		prefix, token, method, expiresAt)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// GetVerifiedNamespace returns the longest prefix of modulePath that has a
+// verified claim that has not expired, or the empty string if there is none.
+func (db *DB) GetVerifiedNamespace(ctx context.Context, modulePath string) (_ string, err error) {
+	defer derrors.WrapStack(&err, "GetVerifiedNamespace(ctx, %q)", modulePath)
+
+	var prefix string
+	err = db.db.QueryRow(ctx, `
+		SELECT prefix
+		FROM namespace_claims
+		WHERE prefix = ANY($1) AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY length(prefix) DESC
+		LIMIT 1`,
+		pq.Array(namespace.Prefixes(modulePath))).Scan(&prefix)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+// GetNamespaceClaimsToReverify returns up to limit verified claims whose
+// verifications expire before the given time, those that expire first
+// first.
+func (db *DB) GetNamespaceClaimsToReverify(ctx context.Context, before time.Time, limit int) (_ []*NamespaceClaim, err error) {
+	defer derrors.WrapStack(&err, "GetNamespaceClaimsToReverify(ctx, %s, %d)", before, limit)
+
+	var claims []*NamespaceClaim
+	err = db.db.RunQuery(ctx, `
+		SELECT prefix, token, created_at, verified_at, method, expires_at
+		FROM namespace_claims
+		WHERE verified_at IS NOT NULL AND expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2`, func(rows *sql.Rows) error {
+		c := &NamespaceClaim{}
+		if err := rows.Scan(&c.Prefix, &c.Token, &c.CreatedAt, &c.VerifiedAt, &c.Method, &c.ExpiresAt); err != nil {
+			return err
+		}
+		claims = append(claims, c)
+		return nil
+	}, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// DeleteStaleNamespaceClaims deletes the claims that were created before the
+// given time and never verified, and those whose verifications expired before
+// it. It returns the number of claims deleted.
+func (db *DB) DeleteStaleNamespaceClaims(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer derrors.WrapStack(&err, "DeleteStaleNamespaceClaims(ctx, %s)", before)
+
+	return db.db.Exec(ctx, `
+		DELETE FROM namespace_claims
+		WHERE (verified_at IS NULL AND created_at < $1) OR expires_at < $1`,
+		before)
+}
+
+// RevokeNamespaceClaims deletes all claims of prefix, verified or not. It
+// returns the number of claims deleted.
+func (db *DB) RevokeNamespaceClaims(ctx context.Context, prefix string) (_ int64, err error) {
+	defer derrors.WrapStack(&err, "RevokeNamespaceClaims(ctx, %q)", prefix)
+
+	return db.db.Exec(ctx, `DELETE FROM namespace_claims WHERE prefix = $1`, prefix)
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestNamespaceClaims(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, c := range []struct{ prefix, token string }{
+		{"example.com", "t1"},
+		{"example.com/org", "t2"},
+		{"example.com/org", "t3"},
+		{"example.com/org/repo", "t4"},
+	} {
+		if err := testDB.InsertNamespaceClaim(ctx, c.prefix, c.token, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(modulePath, want string) {
+		t.Helper()
+		got, err := testDB.GetVerifiedNamespace(ctx, modulePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("GetVerifiedNamespace(%q) = %q, want %q", modulePath, got, want)
+		}
+	}
+	// No claims are verified yet.
+	check("example.com/org/repo", "")
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	if err := testDB.VerifyNamespaceClaim(ctx, "example.com", "t1", "dns", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.VerifyNamespaceClaim(ctx, "example.com/org", "t3", "dns", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	check("example.com/org/repo", "example.com/org")
+	check("example.com/other", "example.com")
+	check("example.com/organization", "example.com")
+	check("other.com/org", "")
+
+	c, err := testDB.GetNamespaceClaim(ctx, "example.com/org", "t3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Verified(now) || c.Method != "dns" {
+		t.Errorf("got VerifiedAt %v, ExpiresAt %v, Method %q; want verified by %q", c.VerifiedAt, c.ExpiresAt, c.Method, "dns")
+	}
+	if c.Verified(expiresAt.Add(time.Second)) {
+		t.Errorf("claim is verified after it expires at %v", c.ExpiresAt)
+	}
+	c, err = testDB.GetNamespaceClaim(ctx, "example.com/org", "t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.VerifiedAt.IsZero() || c.Method != "" {
+		t.Errorf("unverified claim: got VerifiedAt %v, Method %q; want zero values", c.VerifiedAt, c.Method)
+	}
+
+	if _, err := testDB.GetNamespaceClaim(ctx, "example.com/org", "bad"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetNamespaceClaim with a bad token: got %v, want NotFound", err)
+	}
+	if err := testDB.VerifyNamespaceClaim(ctx, "example.com/org", "bad", "dns", expiresAt); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("VerifyNamespaceClaim with a bad token: got %v, want NotFound", err)
+	}
+
+	// An expired claim is no longer verified, and is checked again.
+	if err := testDB.VerifyNamespaceClaim(ctx, "example.com/org", "t3", "dns", now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	check("example.com/org/repo", "example.com")
+	claims, err := testDB.GetNamespaceClaimsToReverify(ctx, now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || claims[0].Prefix != "example.com/org" || claims[0].Token != "t3" {
+		t.Errorf("GetNamespaceClaimsToReverify(now) = %+v, want the claim of example.com/org with t3", claims)
+	}
+	claims, err = testDB.GetNamespaceClaimsToReverify(ctx, expiresAt.Add(time.Second), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 2 {
+		t.Errorf("GetNamespaceClaimsToReverify(after expiry) returned %d claims, want 2", len(claims))
+	}
+
+	byPrefix, byKey, err := testDB.CountNamespaceClaims(ctx, "example.com/org", 0, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byPrefix != 1 || byKey != 4 {
+		t.Errorf("CountNamespaceClaims = %d, %d; want 1, 4", byPrefix, byKey)
+	}
+
+	// Unverified and expired claims are deleted once stale.
+	n, err := testDB.DeleteStaleNamespaceClaims(ctx, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("DeleteStaleNamespaceClaims deleted %d claims, want 3", n)
+	}
+	check("example.com/org/repo", "example.com")
+
+	n, err = testDB.RevokeNamespaceClaims(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("RevokeNamespaceClaims deleted %d claims, want 1", n)
+	}
+	check("example.com/org/repo", "")
+}
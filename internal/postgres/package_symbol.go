@@ -8,6 +8,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 
 	"github.com/Masterminds/squirrel"
 	"golang.org/x/pkgsite/internal"
@@ -97,3 +98,121 @@ func collectSymbolHistory(check func(sh *internal.SymbolHistory, sm internal.Sym
 		return nil
 	}
 }
+
+// GetDocumentationSymbols returns the symbols in the documentation of the
+// package at unitPath in modulePath@version for the build context bc.
+func (db *DB) GetDocumentationSymbols(ctx context.Context, unitPath, modulePath, version string, bc internal.BuildContext,
+) (_ []*internal.SymbolMeta, err error) {
+	defer derrors.WrapStack(&err, "GetDocumentationSymbols(ctx, %q, %q, %q, %v)", unitPath, modulePath, version, bc)
+	defer middleware.ElapsedStat(ctx, "GetDocumentationSymbols")()
+
+	query := `
+		SELECT
+			s1.name AS symbol_name,
+			s2.name AS parent_symbol_name,
+			ps.section,
+			ps.type,
+			ps.synopsis
+		FROM modules m
+		INNER JOIN units u ON u.module_id = m.id
+		INNER JOIN paths p ON u.path_id = p.id
+		INNER JOIN documentation d ON d.unit_id = u.id
+		INNER JOIN documentation_symbols ds ON ds.documentation_id = d.id
+		INNER JOIN package_symbols ps ON ps.id = ds.package_symbol_id
+		INNER JOIN symbol_names s1 ON ps.symbol_name_id = s1.id
+		INNER JOIN symbol_names s2 ON ps.parent_symbol_name_id = s2.id
+		WHERE
+			p.path = $1
+			AND m.module_path = $2
+			AND m.version = $3
+			AND d.goos = $4
+			AND d.goarch = $5
+		ORDER BY s1.name`
+	var sms []*internal.SymbolMeta
+	collect := func(rows *sql.Rows) error {
+		var sm internal.SymbolMeta
+		if err := rows.Scan(&sm.Name, &sm.ParentName, &sm.Section, &sm.Kind, &sm.Synopsis); err != nil {
+			return err
+		}
+		sms = append(sms, &sm)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, unitPath, modulePath, version, bc.GOOS, bc.GOARCH); err != nil {
+		return nil, err
+	}
+	return sms, nil
+}
+
+// A ModuleSymbol is a symbol in the documentation of a package of a module.
+type ModuleSymbol struct {
+	PackagePath string
+	internal.SymbolMeta
+	// BuildContexts are the build contexts in which the package has the
+	// symbol with this SymbolMeta, in the order of internal.BuildContexts.
+	// The single context internal.BuildContextAll means the documentation
+	// is the same for all of them.
+	BuildContexts []internal.BuildContext
+}
+
+// GetModuleSymbols returns the symbols in the documentation of all packages
+// of modulePath@version, sorted by package path and then by name. A symbol
+// whose declaration differs between build contexts appears once for each
+// declaration.
+func (db *DB) GetModuleSymbols(ctx context.Context, modulePath, version string) (_ []*ModuleSymbol, err error) {
+	defer derrors.WrapStack(&err, "GetModuleSymbols(ctx, %q, %q)", modulePath, version)
+	defer middleware.ElapsedStat(ctx, "GetModuleSymbols")()
+
+	query := `
+		SELECT
+			p.path,
+			s1.name AS symbol_name,
+			s2.name AS parent_symbol_name,
+			ps.section,
+			ps.type,
+			ps.synopsis,
+			d.goos,
+			d.goarch
+		FROM modules m
+		INNER JOIN units u ON u.module_id = m.id
+		INNER JOIN paths p ON u.path_id = p.id
+		INNER JOIN documentation d ON d.unit_id = u.id
+		INNER JOIN documentation_symbols ds ON ds.documentation_id = d.id
+		INNER JOIN package_symbols ps ON ps.id = ds.package_symbol_id
+		INNER JOIN symbol_names s1 ON ps.symbol_name_id = s1.id
+		INNER JOIN symbol_names s2 ON ps.parent_symbol_name_id = s2.id
+		WHERE
+			m.module_path = $1
+			AND m.version = $2
+		ORDER BY p.path, s1.name, ps.synopsis`
+	var (
+		symbols []*ModuleSymbol
+		last    *ModuleSymbol
+	)
+	collect := func(rows *sql.Rows) error {
+		var (
+			ms    ModuleSymbol
+			build internal.BuildContext
+		)
+		if err := rows.Scan(&ms.PackagePath, &ms.Name, &ms.ParentName, &ms.Section, &ms.Kind, &ms.Synopsis,
+			&build.GOOS, &build.GOARCH); err != nil {
+			return err
+		}
+		if last != nil && last.PackagePath == ms.PackagePath && last.SymbolMeta == ms.SymbolMeta {
+			last.BuildContexts = append(last.BuildContexts, build)
+			return nil
+		}
+		ms.BuildContexts = []internal.BuildContext{build}
+		last = &ms
+		symbols = append(symbols, last)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	for _, ms := range symbols {
+		sort.Slice(ms.BuildContexts, func(i, j int) bool {
+			return internal.CompareBuildContexts(ms.BuildContexts[i], ms.BuildContexts[j]) < 0
+		})
+	}
+	return symbols, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A PageView counts the views of a tab of a package page on one day. It
+// deliberately contains nothing about the viewers.
+type PageView struct {
+	// Day is the UTC day of the views, at midnight.
+	Day         time.Time
+	PackagePath string
+	// Tab is the tab that was viewed, or empty for the documentation.
+	Tab   string
+	Views int
+}
+
+// AddPageViews adds the views in pvs to the page_views table. The views are
+// attributed to the module of the latest version of each package, as
+// recorded in search_documents. Views of paths that are not packages are
+// dropped.
+func (db *DB) AddPageViews(ctx context.Context, pvs []*PageView) (err error) {
+	defer derrors.WrapStack(&err, "AddPageViews(ctx, [%d page views])", len(pvs))
+
+	if len(pvs) == 0 {
+		return nil
+	}
+	var days, paths, tabs []string
+	var views []int64
+	for _, pv := range pvs {
+		days = append(days, pv.Day.UTC().Format("2006-01-02"))
+		paths = append(paths, pv.PackagePath)
+		tabs = append(tabs, pv.Tab)
+		views = append(views, int64(pv.Views))
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO page_views (day, module_path, package_path, tab, views)
+		SELECT v.day, sd.module_path, v.package_path, v.tab, v.views
+		FROM unnest($1::date[], $2::text[], $3::text[], $4::integer[]) AS v(day, package_path, tab, views)
+		INNER JOIN search_documents sd ON sd.package_path = v.package_path
+		ON CONFLICT (day, module_path, package_path, tab)
+		DO UPDATE SET views = page_views.views + excluded.views`,
+		pq.Array(days), pq.Array(paths), pq.Array(tabs), pq.Array(views))
+	return err
+}
+
+// A ViewedPackage is a package with the number of views of its pages.
+type ViewedPackage struct {
+	PackagePath string
+	ModulePath  string
+	Views       int
+	// DocViews is the number of views of the documentation tab.
+	DocViews int
+}
+
+// GetMostViewedPackages returns the limit packages whose pages were viewed
+// the most since the given time, most viewed first.
+func (db *DB) GetMostViewedPackages(ctx context.Context, since time.Time, limit int) (_ []*ViewedPackage, err error) {
+	defer derrors.WrapStack(&err, "GetMostViewedPackages(ctx, %s, %d)", since, limit)
+
+	var vps []*ViewedPackage
+	collect := func(rows *sql.Rows) error {
+		var vp ViewedPackage
+		if err := rows.Scan(&vp.PackagePath, &vp.ModulePath, &vp.Views, &vp.DocViews); err != nil {
+			return err
+		}
+		vps = append(vps, &vp)
+		return nil
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT
+			package_path,
+			module_path,
+			SUM(views),
+			COALESCE(SUM(views) FILTER (WHERE tab = ''), 0)
+		FROM page_views
+		WHERE day >= $1::date
+		GROUP BY package_path, module_path
+		ORDER BY 3 DESC, package_path
+		LIMIT $2`, collect, since.UTC().Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, err
+	}
+	return vps, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestPageViews(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/m", "v1.0.0", "a", "b"))
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/n", sample.VersionString, ""))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	old := today.AddDate(0, 0, -40)
+	for _, pvs := range [][]*PageView{
+		{
+			{Day: today, PackagePath: "example.com/m/a", Views: 3},
+			{Day: today, PackagePath: "example.com/m/a", Tab: "imports", Views: 1},
+			{Day: today, PackagePath: "example.com/m/b", Views: 2},
+			{Day: old, PackagePath: "example.com/n", Views: 100},
+			// Paths that are not packages are dropped.
+			{Day: today, PackagePath: "example.com/m", Views: 5},
+			{Day: today, PackagePath: "example.com/unknown", Views: 5},
+		},
+		// Views are added to the existing counts.
+		{
+			{Day: today, PackagePath: "example.com/m/b", Views: 4},
+		},
+	} {
+		if err := testDB.AddPageViews(ctx, pvs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetMostViewedPackages(ctx, today.AddDate(0, 0, -30), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ViewedPackage{
+		{PackagePath: "example.com/m/b", ModulePath: "example.com/m", Views: 6, DocViews: 6},
+		{PackagePath: "example.com/m/a", ModulePath: "example.com/m", Views: 4, DocViews: 3},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -73,6 +73,39 @@ func (db *DB) GetLatestMajorPathForV1Path(ctx context.Context, v1path string) (_
 	return majPath, maj, nil
 }
 
+// GetCanonicalUnitPath returns the path of a unit that matches fullPath
+// except for case, along with the path of its module. It is used to resolve
+// requests for paths like github.com/Sirupsen/logrus, which was renamed to
+// github.com/sirupsen/logrus. If several paths match, the one with the most
+// importers is returned. If none do, it returns an error wrapping
+// derrors.NotFound.
+func (db *DB) GetCanonicalUnitPath(ctx context.Context, fullPath string) (_, _ string, err error) {
+	defer derrors.WrapStack(&err, "DB.GetCanonicalUnitPath(ctx, %q)", fullPath)
+
+	// The condition on lower(p.path) uses the idx_paths_lower_path index.
+	q := `
+		SELECT p.path, m.module_path
+		FROM paths p
+		INNER JOIN units u ON u.path_id = p.id
+		INNER JOIN modules m ON m.id = u.module_id
+		LEFT JOIN search_documents sd ON sd.package_path = p.path
+		WHERE lower(p.path) = lower($1)
+		ORDER BY
+			COALESCE(sd.imported_by_count, 0) DESC,
+			p.path,
+			m.module_path DESC
+		LIMIT 1`
+	var path, modulePath string
+	err = db.db.QueryRow(ctx, q, fullPath).Scan(&path, &modulePath)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", "", derrors.NotFound
+	case err != nil:
+		return "", "", err
+	}
+	return path, modulePath, nil
+}
+
 // upsertPath adds path into the paths table if it does not exist, and returns
 // its ID either way.
 // It assumes it is running inside a transaction.
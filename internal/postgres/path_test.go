@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
 
@@ -84,6 +85,36 @@ func TestGetLatestMajorPathForV1Path(t *testing.T) {
 	}
 }
 
+func TestGetCanonicalUnitPath(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/sirupsen/logrus", "v1.8.1", "hooks/syslog"))
+
+	for _, test := range []struct {
+		path, wantPath string
+	}{
+		{"github.com/sirupsen/logrus", "github.com/sirupsen/logrus"},
+		{"github.com/Sirupsen/logrus", "github.com/sirupsen/logrus"},
+		{"github.com/Sirupsen/Logrus/Hooks/Syslog", "github.com/sirupsen/logrus/hooks/syslog"},
+	} {
+		gotPath, gotModulePath, err := testDB.GetCanonicalUnitPath(ctx, test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotPath != test.wantPath || gotModulePath != "github.com/sirupsen/logrus" {
+			t.Errorf("GetCanonicalUnitPath(%q) = %q, %q, want %q, %q",
+				test.path, gotPath, gotModulePath, test.wantPath, "github.com/sirupsen/logrus")
+		}
+	}
+	if _, _, err := testDB.GetCanonicalUnitPath(ctx, "github.com/Sirupsen/other"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v, want NotFound", err)
+	}
+}
+
 func TestUpsertPathConcurrently(t *testing.T) {
 	// Verify that we get no constraint violations or other errors when
 	// the same path is upserted multiple times concurrently.
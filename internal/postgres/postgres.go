@@ -15,6 +15,8 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/poller"
+	"golang.org/x/pkgsite/internal/postgres/search"
+	"golang.org/x/sync/singleflight"
 )
 
 type DB struct {
@@ -22,6 +24,8 @@ type DB struct {
 	bypassLicenseCheck bool
 	expoller           *poller.Poller
 	cancel             func()
+	group              singleflight.Group // for coalescing identical reads
+	synonyms           string             // ts_rewrite query for search synonyms
 }
 
 // New returns a new postgres DB.
@@ -58,9 +62,32 @@ func newdb(db *database.DB, bypass bool) *DB {
 		bypassLicenseCheck: bypass,
 		expoller:           p,
 		cancel:             cancel,
+		synonyms:           defaultSynonyms,
 	}
 }
 
+// defaultSynonyms is the ts_rewrite query for search.DefaultSynonyms.
+var defaultSynonyms = func() string {
+	q, err := search.SynonymRewriteQuery(search.DefaultSynonyms)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}()
+
+// SetSearchSynonyms replaces the groups of terms that are interchangeable in
+// search queries, which are search.DefaultSynonyms by default. It must be
+// called before db is used.
+func (db *DB) SetSearchSynonyms(groups [][]string) (err error) {
+	defer derrors.Wrap(&err, "SetSearchSynonyms(%q)", groups)
+	q, err := search.SynonymRewriteQuery(groups)
+	if err != nil {
+		return err
+	}
+	db.synonyms = q
+	return nil
+}
+
 // Close closes a DB.
 func (db *DB) Close() error {
 	db.cancel()
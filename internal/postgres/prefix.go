@@ -0,0 +1,178 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A PrefixModule is a module listed by GetModulesWithPrefix.
+type PrefixModule struct {
+	ModulePath string
+	// Version is the latest good version of the module.
+	Version    string
+	CommitTime time.Time
+	// Synopsis is the synopsis of the module's root package, or if the
+	// module has no root package, of its most imported package.
+	Synopsis string
+	// NumImportedBy is the largest imported-by count of the module's
+	// packages.
+	NumImportedBy int
+	NumPackages   int
+}
+
+// GetModulesWithPrefix returns the modules whose paths are under prefix, such
+// as the modules of a GitHub user or organization, most imported first. It
+// returns at most limit modules, skipping the first offset, along with the
+// total number of modules under prefix.
+func (db *DB) GetModulesWithPrefix(ctx context.Context, prefix string, limit, offset int) (_ []*PrefixModule, total int, err error) {
+	defer derrors.WrapStack(&err, "GetModulesWithPrefix(ctx, %q, %d, %d)", prefix, limit, offset)
+
+	// Each module has one row in search_documents per package, for its
+	// latest version. Pick the row of the root package, or if there is none,
+	// of the most imported package.
+	query := `
+		SELECT module_path, version, commit_time, synopsis, num_imported_by, num_packages, COUNT(*) OVER ()
+		FROM (
+			SELECT DISTINCT ON (module_path)
+				module_path,
+				version,
+				commit_time,
+				synopsis,
+				MAX(imported_by_count) OVER (PARTITION BY module_path) AS num_imported_by,
+				COUNT(*) OVER (PARTITION BY module_path) AS num_packages
+			FROM search_documents
+			WHERE module_path LIKE $1 || '/%'
+			ORDER BY module_path, package_path = module_path DESC, imported_by_count DESC, package_path
+		) m
+		ORDER BY num_imported_by DESC, module_path
+		LIMIT $2 OFFSET $3`
+	var mods []*PrefixModule
+	collect := func(rows *sql.Rows) error {
+		var m PrefixModule
+		if err := rows.Scan(&m.ModulePath, &m.Version, &m.CommitTime, &m.Synopsis,
+			&m.NumImportedBy, &m.NumPackages, &total); err != nil {
+			return err
+		}
+		excluded, err := db.IsExcluded(ctx, m.ModulePath)
+		if err != nil {
+			return err
+		}
+		if !excluded {
+			mods = append(mods, &m)
+		}
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, prefix, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return mods, total, nil
+}
+
+// A ListedPackage is a package returned by ListPackages.
+type ListedPackage struct {
+	PackagePath string `json:"packagePath"`
+	ModulePath  string `json:"modulePath"`
+	// Version is the latest good version of the package's module.
+	Version  string `json:"version"`
+	Synopsis string `json:"synopsis"`
+}
+
+// ListPackages returns the packages whose path is prefix or begins with
+// prefix + "/", at the latest version of their module, ordered by package
+// path. It returns at most limit packages, skipping the first offset.
+func (db *DB) ListPackages(ctx context.Context, prefix string, limit, offset int) (_ []*ListedPackage, err error) {
+	defer derrors.WrapStack(&err, "ListPackages(ctx, %q, %d, %d)", prefix, limit, offset)
+
+	if prefix == "" {
+		return nil, fmt.Errorf("empty prefix: %w", derrors.InvalidArgument)
+	}
+	if limit <= 0 || offset < 0 {
+		return nil, fmt.Errorf("bad limit or offset: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT package_path, module_path, version, synopsis
+		FROM search_documents
+		WHERE package_path = $1 OR package_path LIKE $1 || '/%'
+		ORDER BY package_path
+		LIMIT $2
+		OFFSET $3`
+	var pkgs []*ListedPackage
+	collect := func(rows *sql.Rows) error {
+		var p ListedPackage
+		if err := rows.Scan(&p.PackagePath, &p.ModulePath, &p.Version, &p.Synopsis); err != nil {
+			return err
+		}
+		excluded, err := db.IsExcluded(ctx, p.PackagePath)
+		if err != nil {
+			return err
+		}
+		if !excluded {
+			pkgs = append(pkgs, &p)
+		}
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, prefix, limit, offset); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// CompletePackagePaths returns the paths of packages that begin with prefix,
+// for completing a partially typed path. It returns at most limit paths: the
+// most imported ones, sorted.
+func (db *DB) CompletePackagePaths(ctx context.Context, prefix string, limit int) (_ []string, err error) {
+	defer derrors.WrapStack(&err, "CompletePackagePaths(ctx, %q, %d)", prefix, limit)
+
+	if prefix == "" {
+		return nil, fmt.Errorf("empty prefix: %w", derrors.InvalidArgument)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("bad limit: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT package_path
+		FROM (
+			SELECT package_path
+			FROM search_documents
+			WHERE package_path LIKE $1 || '%'
+			ORDER BY imported_by_count DESC, package_path
+			LIMIT $2
+		) p
+		ORDER BY package_path`
+	var paths []string
+	collect := func(rows *sql.Rows) error {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return err
+		}
+		excluded, err := db.IsExcluded(ctx, p)
+		if err != nil {
+			return err
+		}
+		if !excluded {
+			paths = append(paths, p)
+		}
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, escapeLike(prefix), limit); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// escapeLike escapes the characters of s that are special in the pattern of
+// a LIKE expression, so that it matches s literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,139 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetModulesWithPrefix(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.0.0", "", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.1.0", "", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/b", "v0.1.0", "p", "q"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/c", "v1.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/other/d", "v1.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/organization/e", "v1.0.0", "p"))
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE search_documents SET imported_by_count = 5 WHERE package_path = 'github.com/org/b/q'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, "github.com/org/c", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, total, err := testDB.GetModulesWithPrefix(ctx, "github.com/org", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// github.com/org/c is excluded after it is counted.
+	if want := 3; total != want {
+		t.Errorf("got total %d, want %d", total, want)
+	}
+	want := []*PrefixModule{
+		{ModulePath: "github.com/org/b", Version: "v0.1.0", Synopsis: sample.Doc.Synopsis, NumImportedBy: 5, NumPackages: 2},
+		{ModulePath: "github.com/org/a", Version: "v1.1.0", Synopsis: sample.Doc.Synopsis, NumImportedBy: 0, NumPackages: 2},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(PrefixModule{}, "CommitTime")); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	got, _, err = testDB.GetModulesWithPrefix(ctx, "github.com/org", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ModulePath != "github.com/org/a" {
+		t.Errorf("got %v, want only github.com/org/a", got)
+	}
+}
+
+func TestListPackages(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.0.0", "", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.1.0", "", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/b", "v0.1.0", "q"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/organization/c", "v1.0.0", "p"))
+
+	got, err := testDB.ListPackages(ctx, "github.com/org", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ListedPackage{
+		{PackagePath: "github.com/org/a", ModulePath: "github.com/org/a", Version: "v1.1.0", Synopsis: sample.Doc.Synopsis},
+		{PackagePath: "github.com/org/a/p", ModulePath: "github.com/org/a", Version: "v1.1.0", Synopsis: sample.Doc.Synopsis},
+		{PackagePath: "github.com/org/b/q", ModulePath: "github.com/org/b", Version: "v0.1.0", Synopsis: sample.Doc.Synopsis},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	got, err = testDB.ListPackages(ctx, "github.com/org/a", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1:2], got); diff != "" {
+		t.Errorf("second page mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCompletePackagePaths(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a", "v1.0.0", "", "p", "q"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/a_b", "v1.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/ab", "v1.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/org/c", "v1.0.0", "p"))
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE search_documents SET imported_by_count = 5 WHERE package_path = 'github.com/org/a/q'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, "github.com/org/c", "someone", "testing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"github.com/org/a", 10, []string{
+			"github.com/org/a", "github.com/org/a/p", "github.com/org/a/q",
+			"github.com/org/a_b/p", "github.com/org/ab/p",
+		}},
+		// The underscore is not a wildcard.
+		{"github.com/org/a_", 10, []string{"github.com/org/a_b/p"}},
+		// The most imported packages are kept.
+		{"github.com/org/a/", 1, []string{"github.com/org/a/q"}},
+		{"github.com/org/c", 10, nil},
+		{"github.com/none", 10, nil},
+	} {
+		got, err := testDB.CompletePackagePaths(ctx, test.prefix, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q, %d: mismatch (-want, +got):\n%s", test.prefix, test.limit, diff)
+		}
+	}
+}
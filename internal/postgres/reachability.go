@@ -0,0 +1,155 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// An ImportChain is the result of a search for a chain of imports from the
+// packages of one module to the packages of another.
+type ImportChain struct {
+	// Packages is a shortest chain of imports from a package of the first
+	// module to a package of the second: each package imports the next. It
+	// is empty if there is no chain.
+	Packages []string
+	// NumSearched is the number of packages reached by the search.
+	NumSearched int
+	// Truncated reports whether the search stopped after reaching its
+	// maximum number of packages. If it did, an empty chain does not mean
+	// that there is none.
+	Truncated bool
+}
+
+// ShortestImportChain searches for a shortest chain of imports from a package
+// of fromModule to a package of toModule, visiting at most maxPackages
+// packages. Like the imported-by counts, it uses the imports of the latest
+// version of each module. It returns a NotFound error if either module is
+// not in search_documents.
+//
+// The search is a breadth-first search over the imports_unique table, with
+// one query per level.
+func (db *DB) ShortestImportChain(ctx context.Context, fromModule, toModule string, maxPackages int) (_ *ImportChain, err error) {
+	defer derrors.WrapStack(&err, "ShortestImportChain(ctx, %q, %q, %d)", fromModule, toModule, maxPackages)
+
+	for _, m := range []string{fromModule, toModule} {
+		var exists bool
+		err := db.db.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM search_documents WHERE module_path = $1)`,
+			m).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, derrors.NotFound
+		}
+	}
+
+	frontier, err := database.Collect1[string](ctx, db.db, `
+		SELECT DISTINCT from_path FROM imports_unique WHERE from_module_path = $1 ORDER BY from_path`,
+		fromModule)
+	if err != nil {
+		return nil, err
+	}
+	// parent maps each reached package to the package that imports it in the
+	// chain, or to the empty string for the packages of fromModule.
+	parent := map[string]string{}
+	for _, p := range frontier {
+		parent[p] = ""
+	}
+	chain := &ImportChain{}
+	for len(frontier) > 0 {
+		var next []string
+		err := db.db.RunQuery(ctx, `
+			SELECT from_path, to_path
+			FROM imports_unique
+			WHERE from_path = ANY($1)
+			ORDER BY from_path, to_path`,
+			func(rows *sql.Rows) error {
+				var from, to string
+				if err := rows.Scan(&from, &to); err != nil {
+					return err
+				}
+				if _, ok := parent[to]; !ok {
+					parent[to] = from
+					next = append(next, to)
+				}
+				return nil
+			}, pq.Array(frontier))
+		if err != nil {
+			return nil, err
+		}
+		modules, err := packageModules(ctx, db.db, next)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(next)
+		for _, p := range next {
+			if inModule(p, modules[p], toModule) {
+				for ; p != ""; p = parent[p] {
+					chain.Packages = append(chain.Packages, p)
+				}
+				reverseStrings(chain.Packages)
+				chain.NumSearched = len(parent)
+				return chain, nil
+			}
+		}
+		if len(parent) >= maxPackages {
+			chain.Truncated = true
+			break
+		}
+		frontier = next
+	}
+	chain.NumSearched = len(parent)
+	return chain, nil
+}
+
+// packageModules returns a map from the paths in pkgPaths that are in
+// search_documents to the paths of their modules.
+func packageModules(ctx context.Context, db *database.DB, pkgPaths []string) (map[string]string, error) {
+	modules := map[string]string{}
+	if len(pkgPaths) == 0 {
+		return modules, nil
+	}
+	err := db.RunQuery(ctx, `
+		SELECT package_path, module_path
+		FROM search_documents
+		WHERE package_path = ANY($1)`,
+		func(rows *sql.Rows) error {
+			var p, m string
+			if err := rows.Scan(&p, &m); err != nil {
+				return err
+			}
+			modules[p] = m
+			return nil
+		}, pq.Array(pkgPaths))
+	if err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// inModule reports whether the package at pkgPath, whose module is
+// pkgModule, is in the module at modulePath. If the module of the package
+// is unknown, it is assumed from its path.
+func inModule(pkgPath, pkgModule, modulePath string) bool {
+	if pkgModule != "" {
+		return pkgModule == modulePath
+	}
+	return pkgPath == modulePath || strings.HasPrefix(pkgPath, modulePath+"/")
+}
+
+func reverseStrings(s []string) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
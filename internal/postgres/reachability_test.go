@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestShortestImportChain(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	imports := map[string][]string{
+		"example.com/a/p":  {"example.com/b/q", "fmt"},
+		"example.com/b/q":  {"example.com/b/q2", "example.com/c/r"},
+		"example.com/b/q2": {"example.com/d/s"},
+		"example.com/c/r":  nil,
+		"example.com/d/s":  nil,
+	}
+	for _, m := range []*internal.Module{
+		sample.Module("example.com/a", "v1.0.0", "p"),
+		sample.Module("example.com/b", "v1.0.0", "q", "q2"),
+		sample.Module("example.com/c", "v1.0.0", "r"),
+		sample.Module("example.com/d", "v1.0.0", "s"),
+	} {
+		for _, u := range m.Packages() {
+			u.Imports = imports[u.Path]
+		}
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	for _, test := range []struct {
+		from, to    string
+		maxPackages int
+		want        *ImportChain
+	}{
+		{
+			from: "example.com/a", to: "example.com/c", maxPackages: 100,
+			want: &ImportChain{Packages: []string{"example.com/a/p", "example.com/b/q", "example.com/c/r"}, NumSearched: 5},
+		},
+		{
+			from: "example.com/a", to: "example.com/d", maxPackages: 100,
+			want: &ImportChain{Packages: []string{"example.com/a/p", "example.com/b/q", "example.com/b/q2", "example.com/d/s"}, NumSearched: 6},
+		},
+		{
+			from: "example.com/d", to: "example.com/a", maxPackages: 100,
+			want: &ImportChain{NumSearched: 0},
+		},
+		{
+			from: "example.com/a", to: "example.com/d", maxPackages: 2,
+			want: &ImportChain{NumSearched: 3, Truncated: true},
+		},
+	} {
+		got, err := testDB.ShortestImportChain(ctx, test.from, test.to, test.maxPackages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s to %s: mismatch (-want, +got):\n%s", test.from, test.to, diff)
+		}
+	}
+
+	if _, err := testDB.ShortestImportChain(ctx, "example.com/a", "example.com/missing", 100); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+}
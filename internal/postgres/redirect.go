@@ -0,0 +1,136 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetModuleRedirect returns the redirect of the module containing fullPath,
+// which may be a module or package path. If several modules that contain
+// fullPath were moved, the redirect of the longest one is returned. If there
+// is none, GetModuleRedirect returns an error that wraps derrors.NotFound.
+func (db *DB) GetModuleRedirect(ctx context.Context, fullPath string) (_ *internal.ModuleRedirect, err error) {
+	defer derrors.WrapStack(&err, "GetModuleRedirect(ctx, %q)", fullPath)
+
+	var mr internal.ModuleRedirect
+	err = db.db.QueryRow(ctx, `
+		SELECT old_module_path, new_module_path, source, reason
+		FROM module_redirects
+		WHERE old_module_path = ANY($1)
+		ORDER BY length(old_module_path) DESC
+		LIMIT 1`,
+		pq.Array(internal.CandidateModulePaths(fullPath))).Scan(
+		&mr.OldModulePath, &mr.NewModulePath, &mr.Source, &mr.Reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetModuleRedirects returns all the module redirects, sorted by old module
+// path.
+func (db *DB) GetModuleRedirects(ctx context.Context) (_ []*internal.ModuleRedirect, err error) {
+	defer derrors.WrapStack(&err, "GetModuleRedirects(ctx)")
+
+	var mrs []*internal.ModuleRedirect
+	collect := func(rows *sql.Rows) error {
+		var mr internal.ModuleRedirect
+		if err := rows.Scan(&mr.OldModulePath, &mr.NewModulePath, &mr.Source, &mr.Reason); err != nil {
+			return err
+		}
+		mrs = append(mrs, &mr)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, `
+		SELECT old_module_path, new_module_path, source, reason
+		FROM module_redirects
+		ORDER BY old_module_path`, collect); err != nil {
+		return nil, err
+	}
+	return mrs, nil
+}
+
+// InsertModuleRedirect adds or replaces the redirect of mr.OldModulePath.
+func (db *DB) InsertModuleRedirect(ctx context.Context, mr *internal.ModuleRedirect) (err error) {
+	defer derrors.WrapStack(&err, "InsertModuleRedirect(ctx, %q, %q)", mr.OldModulePath, mr.NewModulePath)
+	return upsertModuleRedirect(ctx, db.db, mr)
+}
+
+// upsertModuleRedirect adds or replaces the redirect of mr.OldModulePath,
+// unless mr is derived from a deprecation and the existing redirect was added
+// by an administrator.
+func upsertModuleRedirect(ctx context.Context, db *database.DB, mr *internal.ModuleRedirect) error {
+	if mr.OldModulePath == "" || mr.NewModulePath == "" || mr.OldModulePath == mr.NewModulePath {
+		return derrors.InvalidArgument
+	}
+	switch mr.Source {
+	case internal.RedirectDeprecated, internal.RedirectAdmin:
+	default:
+		return derrors.InvalidArgument
+	}
+	_, err := db.Exec(ctx, `
+		INSERT INTO module_redirects (old_module_path, new_module_path, source, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (old_module_path)
+		DO UPDATE SET
+			new_module_path = excluded.new_module_path,
+			source = excluded.source,
+			reason = excluded.reason
+		WHERE excluded.source = 'admin' OR module_redirects.source = 'deprecated'`,
+		mr.OldModulePath, mr.NewModulePath, mr.Source, mr.Reason)
+	return err
+}
+
+// DeleteModuleRedirect removes the redirect of oldModulePath.
+func (db *DB) DeleteModuleRedirect(ctx context.Context, oldModulePath string) (err error) {
+	defer derrors.WrapStack(&err, "DeleteModuleRedirect(ctx, %q)", oldModulePath)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM module_redirects WHERE old_module_path = $1`, oldModulePath)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// updateDeprecationRedirect makes the deprecation redirect of the module of
+// lmv agree with its deprecation comment: it is added or replaced if the
+// comment names another module that is in the module index, and removed
+// otherwise. Redirects added by an administrator are left alone.
+func updateDeprecationRedirect(ctx context.Context, db *database.DB, lmv *internal.LatestModuleVersions) error {
+	if to := lmv.MovedTo(); to != "" {
+		var known bool
+		if err := db.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM module_version_states WHERE module_path = $1)`,
+			to).Scan(&known); err != nil {
+			return err
+		}
+		if known {
+			return upsertModuleRedirect(ctx, db, &internal.ModuleRedirect{
+				OldModulePath: lmv.ModulePath,
+				NewModulePath: to,
+				Source:        internal.RedirectDeprecated,
+				Reason:        lmv.DeprecationComment(),
+			})
+		}
+	}
+	_, err := db.Exec(ctx, `
+		DELETE FROM module_redirects
+		WHERE old_module_path = $1 AND source = 'deprecated'`, lmv.ModulePath)
+	return err
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestModuleRedirects(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	admin := &internal.ModuleRedirect{
+		OldModulePath: "example.com/old",
+		NewModulePath: "example.com/new",
+		Source:        internal.RedirectAdmin,
+		Reason:        "renamed",
+	}
+	if err := testDB.InsertModuleRedirect(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"example.com/old", "example.com/old/pkg"} {
+		got, err := testDB.GetModuleRedirect(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(admin, got); diff != "" {
+			t.Errorf("GetModuleRedirect(%q) mismatch (-want, +got):\n%s", path, diff)
+		}
+	}
+	if _, err := testDB.GetModuleRedirect(ctx, "example.com/older"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetModuleRedirect(example.com/older): got %v, want NotFound", err)
+	}
+
+	// A deprecation comment that names another module adds a redirect, but
+	// doesn't replace one added by an administrator.
+	updateLatest := func(modulePath, goMod string) {
+		t.Helper()
+		lmv, err := internal.NewLatestModuleVersions(modulePath, "v1.0.0", "v1.0.0", "", []byte(goMod))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := testDB.UpdateLatestModuleVersions(ctx, lmv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only modules in the index are redirected to.
+	if err := testDB.InsertIndexVersions(ctx, []*internal.IndexVersion{
+		{Path: "example.com/other", Version: "v1.0.0", Timestamp: time.Now()},
+		{Path: "example.com/dep2", Version: "v1.0.0", Timestamp: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	updateLatest("example.com/old", "// Deprecated: use example.com/other.\nmodule example.com/old")
+	updateLatest("example.com/dep", "// Deprecated: use example.com/dep2 instead.\nmodule example.com/dep")
+	updateLatest("example.com/gone", "// Deprecated: moved to github.com/owner/repo/issues/12.\nmodule example.com/gone")
+	got, err := testDB.GetModuleRedirects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleRedirect{
+		{
+			OldModulePath: "example.com/dep",
+			NewModulePath: "example.com/dep2",
+			Source:        internal.RedirectDeprecated,
+			Reason:        "use example.com/dep2 instead.",
+		},
+		admin,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetModuleRedirects mismatch (-want, +got):\n%s", diff)
+	}
+
+	if err := testDB.DeleteModuleRedirect(ctx, "example.com/old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.DeleteModuleRedirect(ctx, "example.com/old"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("second DeleteModuleRedirect: got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// A SchemaChange is a change to the schema, such as moving a column to a new
+// table, that is rolled out in phases so that it can ship without downtime.
+// The phase is chosen by experiments:
+//
+//  1. A migration adds the new tables or columns.
+//  2. DualWriteExperiment is turned on for the worker, so that writes go to
+//     both the old and the new schema, and existing rows are copied with
+//     Backfill.
+//  3. VerifyExperiment is turned on for the frontend, so that reads also read
+//     the new schema and compare the results.
+//  4. ReadNewExperiment is turned on for the frontend, so that reads use only
+//     the new schema.
+//  5. The code for the old schema is deleted, and a migration drops it.
+//
+// Writes must reach every row before the new schema is read, so
+// DualWriteExperiment should be rolled out to 100% of the worker's requests.
+// Until step 5, each phase can be undone by turning off its experiment.
+type SchemaChange struct {
+	// Name identifies the change in logs and metrics.
+	Name string
+
+	// DualWriteExperiment is the experiment that turns on writes to the new
+	// schema.
+	DualWriteExperiment string
+
+	// VerifyExperiment is the experiment that turns on comparing reads of the
+	// old and new schema.
+	VerifyExperiment string
+
+	// ReadNewExperiment is the experiment that turns on reading the new
+	// schema instead of the old one.
+	ReadNewExperiment string
+}
+
+// A SchemaChangePhase is the phase of a SchemaChange for a request.
+type SchemaChangePhase int
+
+const (
+	// PhaseOld reads and writes only the old schema.
+	PhaseOld SchemaChangePhase = iota
+	// PhaseDualWrite writes both schemas, and reads the old one.
+	PhaseDualWrite
+	// PhaseVerify writes both schemas, and reads both, returning the result
+	// from the old one.
+	PhaseVerify
+	// PhaseNew writes both schemas, and reads the new one. The old schema is
+	// still written so that reads can go back to it.
+	PhaseNew
+)
+
+func (p SchemaChangePhase) String() string {
+	switch p {
+	case PhaseOld:
+		return "old"
+	case PhaseDualWrite:
+		return "dual-write"
+	case PhaseVerify:
+		return "verify"
+	case PhaseNew:
+		return "new"
+	default:
+		return "unknown"
+	}
+}
+
+// Phase returns the phase of c for the experiments in ctx. The new schema is
+// never read unless it is also written, so PhaseVerify and PhaseNew require
+// DualWriteExperiment to be active.
+func (c *SchemaChange) Phase(ctx context.Context) SchemaChangePhase {
+	switch {
+	case !experiment.IsActive(ctx, c.DualWriteExperiment):
+		return PhaseOld
+	case experiment.IsActive(ctx, c.ReadNewExperiment):
+		return PhaseNew
+	case experiment.IsActive(ctx, c.VerifyExperiment):
+		return PhaseVerify
+	default:
+		return PhaseDualWrite
+	}
+}
+
+// Write calls writeOld, and then writeNew if c is in a phase that writes the
+// new schema. Both functions should use the same transaction, so that the two
+// schemas do not diverge if one of the writes fails.
+func (c *SchemaChange) Write(ctx context.Context, writeOld, writeNew func() error) (err error) {
+	defer derrors.Wrap(&err, "SchemaChange(%q).Write", c.Name)
+
+	if err := writeOld(); err != nil {
+		return err
+	}
+	if c.Phase(ctx) == PhaseOld {
+		return nil
+	}
+	return writeNew()
+}
+
+// DualRead reads a value with readOld or readNew, depending on the phase of c.
+//
+// In PhaseVerify, it calls both, and returns the result of readOld. If the
+// results are not equal according to equal, or readNew fails, the difference
+// is logged and counted in SchemaChangeVerifyCount, but no error is returned.
+// If equal is nil, reflect.DeepEqual is used.
+func DualRead[T any](ctx context.Context, c *SchemaChange, readOld, readNew func(context.Context) (T, error), equal func(T, T) bool) (_ T, err error) {
+	defer derrors.Wrap(&err, "DualRead(%q)", c.Name)
+
+	switch c.Phase(ctx) {
+	case PhaseNew:
+		return readNew(ctx)
+	case PhaseVerify:
+		old, err := readOld(ctx)
+		if err != nil {
+			return old, err
+		}
+		verify(ctx, c, old, readNew, equal)
+		return old, nil
+	default:
+		return readOld(ctx)
+	}
+}
+
+// verify compares old with the result of readNew.
+func verify[T any](ctx context.Context, c *SchemaChange, old T, readNew func(context.Context) (T, error), equal func(T, T) bool) {
+	if equal == nil {
+		equal = func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	}
+	result := "match"
+	newV, err := readNew(ctx)
+	switch {
+	case err != nil:
+		result = "error"
+		log.Errorf(ctx, "schema change %q: reading new schema: %v", c.Name, err)
+	case !equal(old, newV):
+		result = "mismatch"
+		log.Warningf(ctx, "schema change %q: old and new schema differ:\nold: %+v\nnew: %+v", c.Name, old, newV)
+	}
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keySchemaChangeName, c.Name),
+		tag.Upsert(keySchemaChangeResult, result),
+	}, schemaChangeVerify.M(1))
+}
+
+var (
+	schemaChangeVerify = stats.Int64(
+		"go-discovery/schema_change/verify",
+		"Reads compared between the old and new schema of a schema change.",
+		stats.UnitDimensionless,
+	)
+	keySchemaChangeName   = tag.MustNewKey("schema_change.name")
+	keySchemaChangeResult = tag.MustNewKey("schema_change.result")
+
+	// SchemaChangeVerifyCount counts the reads compared during the verify
+	// phase of schema changes, by change and by whether the results matched.
+	SchemaChangeVerifyCount = &view.View{
+		Name:        "go-discovery/schema_change/verify_count",
+		Measure:     schemaChangeVerify,
+		Aggregation: view.Count(),
+		Description: "Schema change verifications, by change and result.",
+		TagKeys:     []tag.Key{keySchemaChangeName, keySchemaChangeResult},
+	}
+)
+
+// Backfill copies existing rows to the new schema of c, in batches. Each call
+// to batch runs in its own transaction, so that no lock is held for long and
+// concurrent dual writes are not blocked.
+//
+// batch should copy the rows whose key is greater than after, up to some
+// limit, and return the largest key it copied and the number of rows. Backfill
+// starts with after set to 0, and stops when batch copies no rows. It returns
+// the total number of rows copied. Batches should be idempotent, so that an
+// interrupted backfill can be run again from the start.
+func (db *DB) Backfill(ctx context.Context, c *SchemaChange, batch func(ctx context.Context, tx *database.DB, after int64) (last int64, n int, err error)) (total int, err error) {
+	defer derrors.WrapStack(&err, "Backfill(%q)", c.Name)
+
+	var after int64
+	for {
+		var (
+			last int64
+			n    int
+		)
+		err := db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+			var err error
+			last, n, err = batch(ctx, tx, after)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			log.Infof(ctx, "schema change %q: backfill done; copied %d rows", c.Name, total)
+			return total, nil
+		}
+		total += n
+		after = last
+		log.Debugf(ctx, "schema change %q: backfilled %d rows, up to key %d", c.Name, total, after)
+	}
+}
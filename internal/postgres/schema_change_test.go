@@ -0,0 +1,128 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+var testSchemaChange = &SchemaChange{
+	Name:                "test",
+	DualWriteExperiment: "test-dual-write",
+	VerifyExperiment:    "test-verify",
+	ReadNewExperiment:   "test-read-new",
+}
+
+func TestSchemaChangePhase(t *testing.T) {
+	for _, test := range []struct {
+		experiments []string
+		want        SchemaChangePhase
+	}{
+		{nil, PhaseOld},
+		{[]string{"test-read-new"}, PhaseOld},
+		{[]string{"test-dual-write"}, PhaseDualWrite},
+		{[]string{"test-dual-write", "test-verify"}, PhaseVerify},
+		{[]string{"test-dual-write", "test-verify", "test-read-new"}, PhaseNew},
+	} {
+		ctx := experiment.NewContext(context.Background(), test.experiments...)
+		if got := testSchemaChange.Phase(ctx); got != test.want {
+			t.Errorf("%v: got %s, want %s", test.experiments, got, test.want)
+		}
+	}
+}
+
+func TestSchemaChangeWrite(t *testing.T) {
+	for _, test := range []struct {
+		experiments []string
+		wantNew     bool
+	}{
+		{nil, false},
+		{[]string{"test-dual-write"}, true},
+		{[]string{"test-dual-write", "test-read-new"}, true},
+	} {
+		ctx := experiment.NewContext(context.Background(), test.experiments...)
+		var wroteOld, wroteNew bool
+		err := testSchemaChange.Write(ctx,
+			func() error { wroteOld = true; return nil },
+			func() error { wroteNew = true; return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !wroteOld || wroteNew != test.wantNew {
+			t.Errorf("%v: wrote old %t, new %t; want true, %t", test.experiments, wroteOld, wroteNew, test.wantNew)
+		}
+	}
+}
+
+func TestDualRead(t *testing.T) {
+	readOld := func(context.Context) (string, error) { return "old", nil }
+	readNew := func(context.Context) (string, error) { return "new", nil }
+	failNew := func(context.Context) (string, error) { return "", errors.New("bad") }
+	for _, test := range []struct {
+		experiments []string
+		readNew     func(context.Context) (string, error)
+		want        string
+	}{
+		{nil, readNew, "old"},
+		{[]string{"test-dual-write"}, readNew, "old"},
+		{[]string{"test-dual-write", "test-verify"}, readNew, "old"},
+		// Failures of the new schema are only logged while verifying.
+		{[]string{"test-dual-write", "test-verify"}, failNew, "old"},
+		{[]string{"test-dual-write", "test-read-new"}, readNew, "new"},
+	} {
+		ctx := experiment.NewContext(context.Background(), test.experiments...)
+		got, err := DualRead(ctx, testSchemaChange, readOld, test.readNew, nil)
+		if err != nil {
+			t.Fatalf("%v: %v", test.experiments, err)
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.experiments, got, test.want)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx := context.Background()
+
+	const batchSize = 10
+	batches := 0
+	total, err := testDB.Backfill(ctx, testSchemaChange, func(ctx context.Context, tx *database.DB, after int64) (int64, int, error) {
+		batches++
+		if !tx.InTransaction() {
+			t.Error("batch not in a transaction")
+		}
+		var ids []int64
+		err := tx.RunQuery(ctx, `
+			SELECT id FROM generate_series(1, 25) AS id
+			WHERE id > $1 ORDER BY id LIMIT $2`,
+			func(rows *sql.Rows) error {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				ids = append(ids, id)
+				return nil
+			}, after, batchSize)
+		if err != nil || len(ids) == 0 {
+			return 0, 0, err
+		}
+		return ids[len(ids)-1], len(ids), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 25 || batches != 4 {
+		t.Errorf("got %d rows in %d batches, want 25 rows in 4 batches", total, batches)
+	}
+}
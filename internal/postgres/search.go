@@ -8,6 +8,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -90,6 +91,11 @@ var pkgSearchers = map[string]searcher{
 	"deep":    (*DB).deepSearch,
 }
 
+// The searchers used by Search with an alternative ranking or a filter.
+var deepSearchers = map[string]searcher{
+	"deep": (*DB).deepSearch,
+}
+
 var symbolSearchers = map[string]searcher{
 	"symbol": (*DB).symbolSearch,
 }
@@ -110,6 +116,47 @@ type SearchOptions struct {
 
 	// SymbolFilter is the word in a search query with a # prefix.
 	SymbolFilter string
+
+	// StdFilter restricts results to the standard library if it is StdOnly,
+	// or to other modules if it is StdExclude. Filtered package searches only
+	// use deep search, since popular search cannot apply the filter.
+	StdFilter string
+
+	// GoVersion restricts results to APIs available at a Go version, such as
+	// "1.18". See goVersionExpr and filterSymbolsByGoVersion. Filtered
+	// package searches only use deep search.
+	GoVersion string
+
+	// HasFuzzTargets restricts package search results to packages with fuzz
+	// targets. Filtered package searches only use deep search. It is ignored
+	// by symbol search.
+	HasFuzzTargets bool
+
+	// PathPrefix restricts results to the packages whose paths are
+	// PathPrefix or start with PathPrefix followed by a slash. Filtered
+	// package searches only use deep search.
+	PathPrefix string
+
+	// Ranking is the name of an alternative ranking to score packages with,
+	// from SearchRankings. If empty, the default ranking is used.
+	// Alternative rankings only use deep search, since popular search
+	// depends on the default score.
+	Ranking string
+
+	// Snippets makes Search set the Snippet of each result. It is costly, so
+	// it is only set when the search-snippets experiment is active.
+	Snippets bool
+
+	// SymbolTimeout, if positive, is the time allowed to the queries that
+	// symbol search runs in parallel for queries with a dot or several
+	// words. Those that have not finished by then are cancelled, and the
+	// results of the others are returned, marked as Incomplete.
+	SymbolTimeout time.Duration
+
+	// shadow makes deep search match and score packages with the text search
+	// tokens of the shadow index instead of the live ones. See
+	// CompareShadowSearch.
+	shadow bool
 }
 
 // SearchResult represents a single search result from SearchDocuments.
@@ -121,6 +168,12 @@ type SearchResult struct {
 	Synopsis    string
 	Licenses    []string
 
+	// Snippet is the synopsis of the package, or an excerpt of its README,
+	// with the terms that match the query between SnippetStart and
+	// SnippetEnd. It is only set if SearchOptions.Snippets is set and the
+	// text matches the query.
+	Snippet string
+
 	CommitTime time.Time
 
 	// Score is used to sort items in an array of SearchResult.
@@ -133,6 +186,15 @@ type SearchResult struct {
 	// with lower scores.
 	SameModule []*SearchResult
 
+	// RepoURL is the URL of the repository that contains the module, if
+	// known.
+	RepoURL string
+
+	// SameRepo is a list of SearchResults from other modules in the same
+	// repository as this one, with lower scores. Each is the best-matching
+	// package of its module, with its own SameModule and OtherMajor.
+	SameRepo []*SearchResult
+
 	// OtherMajor is a map from module paths with the same series path but at
 	// different major versions of this module, to major version.
 	// The major version for a non-vN module path (either 0 or 1) is computed
@@ -143,6 +205,10 @@ type SearchResult struct {
 	// search.
 	NumResults uint64
 
+	// Incomplete reports whether some of the queries of the search timed
+	// out, so that there may be other results.
+	Incomplete bool
+
 	// Symbol information returned by a search request.
 	// Only populated for symbol search mode.
 	SymbolName     string
@@ -207,33 +273,63 @@ func (db *DB) Search(ctx context.Context, q string, opts SearchOptions) (_ []*Se
 func (db *DB) search(ctx context.Context, q string, opts SearchOptions, limit int) (_ []*SearchResult, err error) {
 	defer derrors.WrapStack(&err, "search(limit=%d)", limit)
 
+	goVersionKey := goVersionSortKey(opts.GoVersion)
+	if opts.GoVersion != "" && !goVersionKey.Valid {
+		return nil, fmt.Errorf("invalid Go version %q: %w", opts.GoVersion, derrors.InvalidArgument)
+	}
 	var searchers map[string]searcher
-	if opts.SearchSymbols {
+	switch {
+	case opts.SearchSymbols:
 		searchers = symbolSearchers
-	} else {
+	case opts.Ranking != "" || opts.StdFilter != "" || opts.GoVersion != "" || opts.HasFuzzTargets || opts.PathPrefix != "":
+		if _, ok := SearchRankings[opts.Ranking]; !ok && opts.Ranking != "" {
+			return nil, fmt.Errorf("unknown ranking %q: %w", opts.Ranking, derrors.InvalidArgument)
+		}
+		if _, ok := stdFilterExprs[opts.StdFilter]; !ok {
+			return nil, fmt.Errorf("unknown std filter %q: %w", opts.StdFilter, derrors.InvalidArgument)
+		}
+		searchers = deepSearchers
+	case isCockroachDB(db.db):
+		// Popular search is a PL/pgSQL function, which CockroachDB can't run.
+		searchers = deepSearchers
+	default:
 		searchers = pkgSearchers
 	}
 	resp, err := db.hedgedSearch(ctx, q, limit, opts, searchers, nil)
 	if err != nil {
 		return nil, err
 	}
-	// Filter out excluded paths.
+	// Filter out excluded paths, and symbols that don't match the std
+	// filter or the path prefix.
 	var results []*SearchResult
 	for _, r := range resp.results {
 		ex, err := db.IsExcluded(ctx, r.PackagePath)
 		if err != nil {
 			return nil, err
 		}
-		if !ex {
+		if !ex && matchesStdFilter(r, opts.StdFilter) && matchesPathPrefix(r.PackagePath, opts.PathPrefix) {
 			results = append(results, r)
 		}
 	}
+	if opts.SearchSymbols && goVersionKey.Valid {
+		results, err = db.filterSymbolsByGoVersion(ctx, results, goVersionKey.String)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if !opts.SearchSymbols {
-		results = groupSearchResults(results)
+		results = groupSearchResultsByRepo(groupSearchResults(results))
 	}
 	if len(results) > opts.MaxResults {
 		results = results[:opts.MaxResults]
 	}
+	if opts.Snippets && !opts.SearchSymbols && !isCockroachDB(db.db) {
+		// Without snippets, results show their synopsis, so don't fail the
+		// search.
+		if err := db.addSnippets(ctx, q, results); err != nil {
+			log.Errorf(ctx, "search(%q): %v", q, err)
+		}
+	}
 	return results, nil
 }
 
@@ -247,6 +343,12 @@ const (
 	noGoModPenalty = 0.8
 )
 
+// tsQueryExpr is the expression that computes the text search query from the
+// query $1 in web search syntax, with the synonyms in the ts_rewrite query $4
+// expanded (see search.SynonymRewriteQuery). It is a subquery so that it is
+// evaluated once, instead of once per row.
+const tsQueryExpr = `(SELECT ts_rewrite(websearch_to_tsquery($1), $4::text))`
+
 // scoreExpr is the expression that computes the search score.
 // It is the product of:
 //   - The Postgres ts_rank score, based the relevance of the document to the query.
@@ -261,11 +363,87 @@ const (
 // in the order D, C, B, A.
 // The weights below match the defaults except for B.
 var scoreExpr = fmt.Sprintf(`
-		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, websearch_to_tsquery($1)) *
+		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, %[1]s) *
 		ln(exp(1)+imported_by_count) *
-		CASE WHEN redistributable THEN 1 ELSE %f END *
-		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %f END
-	`, nonRedistributablePenalty, noGoModPenalty)
+		CASE WHEN redistributable THEN 1 ELSE %[2]f END *
+		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %[3]f END
+	`, tsQueryExpr, nonRedistributablePenalty, noGoModPenalty)
+
+// Values of SearchOptions.StdFilter.
+const (
+	StdOnly    = "only"
+	StdExclude = "exclude"
+)
+
+// stdFilterExprs maps the values of SearchOptions.StdFilter to the predicates
+// that deep search adds to its query.
+var stdFilterExprs = map[string]string{
+	"":         "",
+	StdOnly:    fmt.Sprintf("AND module_path = '%s'", stdlib.ModulePath),
+	StdExclude: fmt.Sprintf("AND module_path <> '%s'", stdlib.ModulePath),
+}
+
+// matchesStdFilter reports whether r satisfies the std filter f.
+func matchesStdFilter(r *SearchResult, f string) bool {
+	switch f {
+	case StdOnly:
+		return r.ModulePath == stdlib.ModulePath
+	case StdExclude:
+		return r.ModulePath != stdlib.ModulePath
+	}
+	return true
+}
+
+// pathPrefixExpr returns the predicate that deep search adds to its query
+// when SearchOptions.PathPrefix is set, for the prefix in the given argument.
+// Comparing the start of the path, rather than using LIKE, avoids escaping
+// the '_' and '%' characters of paths.
+func pathPrefixExpr(arg string) string {
+	return fmt.Sprintf(`
+	AND (package_path = %[1]s OR left(package_path, length(%[1]s) + 1) = %[1]s || '/')`, arg)
+}
+
+// matchesPathPrefix reports whether path is prefix or below it.
+func matchesPathPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// fuzzTargetsExpr is the predicate that deep search adds to its query when
+// SearchOptions.HasFuzzTargets is set.
+const fuzzTargetsExpr = `
+	AND EXISTS (
+		SELECT 1
+		FROM units u
+		WHERE u.id = search_documents.unit_id
+		AND u.num_fuzz_targets > 0)`
+
+// RankingDampedPopularity is a ranking that reduces the weight of popularity
+// relative to relevance, by taking the square root of the popularity factor
+// of scoreExpr.
+const RankingDampedPopularity = "damped-popularity"
+
+// RankingRecentPopularity is a ranking that estimates popularity by
+// recent_imported_by_count instead of imported_by_count, so that importers
+// that have not been updated in a long time count for less.
+const RankingRecentPopularity = "recent-popularity"
+
+// SearchRankings maps the names of alternative rankings, which can be
+// compared with the default one by interleaving their results, to their
+// score expressions. They must have the same arguments as scoreExpr.
+var SearchRankings = map[string]string{
+	RankingDampedPopularity: fmt.Sprintf(`
+		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, %[1]s) *
+		sqrt(ln(exp(1)+imported_by_count)) *
+		CASE WHEN redistributable THEN 1 ELSE %[2]f END *
+		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %[3]f END
+	`, tsQueryExpr, nonRedistributablePenalty, noGoModPenalty),
+	RankingRecentPopularity: fmt.Sprintf(`
+		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, %[1]s) *
+		ln(exp(1)+recent_imported_by_count) *
+		CASE WHEN redistributable THEN 1 ELSE %[2]f END *
+		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %[3]f END
+	`, tsQueryExpr, nonRedistributablePenalty, noGoModPenalty),
+}
 
 // hedgedSearch executes multiple search methods and returns the first
 // available result.
@@ -328,42 +506,141 @@ func (db *DB) hedgedSearch(ctx context.Context, q string, limit int, opts Search
 
 const hllRegisterCount = 128
 
+// seriesKeyExpr is the expression that identifies a package across the major
+// versions of its module, by the series path of the module and the path of
+// the package within it. Standard library packages, and search documents
+// without a series path, are identified by their package path.
+var seriesKeyExpr = fmt.Sprintf(`
+	CASE WHEN series_path IS NULL OR module_path = '%s'
+		THEN package_path
+		ELSE series_path || substr(package_path, length(module_path)+1)
+	END`, stdlib.ModulePath)
+
+// majorVersionExpr is the expression that computes the major version of a
+// search document's module, as groupAndMajorVersion does: the N of a /vN or
+// .vN suffix of the module path, or else 0 for v0 versions and 1 otherwise.
+const majorVersionExpr = `
+	CASE WHEN series_path IS NULL OR module_path = series_path
+		THEN CASE WHEN version LIKE 'v0.%' THEN 0 ELSE 1 END
+		ELSE substr(module_path, length(series_path)+3)::int
+	END`
+
+// taggedExpr is the expression that reports whether the version of a search
+// document is tagged, rather than a pseudo-version.
+const taggedExpr = `(version !~ '\d{14}-[A-Za-z0-9]+(\+incompatible)?$')`
+
+// otherMajorVersions returns a map from the module paths in modules other
+// than modulePath to their major versions, or nil if there are none. The
+// versions of the modules are in the corresponding elements of versions.
+func otherMajorVersions(modulePath string, modules, versions []string) map[string]int {
+	var m map[string]int
+	for i, mp := range modules {
+		if mp == modulePath || i >= len(versions) {
+			continue
+		}
+		if m == nil {
+			m = map[string]int{}
+		}
+		_, m[mp] = groupAndMajorVersion(&SearchResult{ModulePath: mp, Version: versions[i]})
+	}
+	return m
+}
+
 // deepSearch searches all packages for the query. It is slower, but results
 // are always valid.
 func (db *DB) deepSearch(ctx context.Context, q string, limit int, opts SearchOptions) searchResponse {
+	score := scoreExpr
+	if opts.Ranking != "" {
+		score = SearchRankings[opts.Ranking]
+	}
+	from, tsv := "search_documents", "tsv_search_tokens"
+	if opts.shadow {
+		from, tsv = shadowSearchDocumentsExpr, "shadow_tsv_search_tokens"
+		score = strings.ReplaceAll(score, "tsv_search_tokens", tsv)
+	}
+	args := []interface{}{q, limit, opts.Offset, db.synonyms}
+	filter := stdFilterExprs[opts.StdFilter]
+	if opts.HasFuzzTargets {
+		filter += fuzzTargetsExpr
+	}
+	if key := goVersionSortKey(opts.GoVersion); key.Valid {
+		filter += goVersionExpr
+		args = append(args, key.String)
+	}
+	if opts.PathPrefix != "" {
+		args = append(args, opts.PathPrefix)
+		filter += pathPrefixExpr(fmt.Sprintf("$%d", len(args)))
+	}
+	// Each package is only returned for the latest major version of its
+	// module that matches the query, with the score of its best-scoring major
+	// version. The module paths and versions of the other major versions are
+	// returned with it.
 	query := fmt.Sprintf(`
-		SELECT *, COUNT(*) OVER() AS total
+		SELECT
+			package_path,
+			version,
+			module_path,
+			commit_time,
+			imported_by_count,
+			series_score,
+			series_modules,
+			series_versions,
+			COUNT(*) OVER() AS total
 		FROM (
 			SELECT
-				package_path,
-				version,
-				module_path,
-				commit_time,
-				imported_by_count,
-				(%s) AS score
+				*,
+				ROW_NUMBER() OVER series AS major_rank,
+				MAX(score) OVER series AS series_score,
+				ARRAY_AGG(module_path) OVER series AS series_modules,
+				ARRAY_AGG(version) OVER series AS series_versions
+			FROM (
+				SELECT
+					package_path,
+					version,
+					module_path,
+					commit_time,
+					imported_by_count,
+					(%s) AS score,
+					%s AS series_key,
+					%s AS major,
+					%s AS tagged
 				FROM
-					search_documents
-				WHERE tsv_search_tokens @@ websearch_to_tsquery($1)
-				ORDER BY
-					score DESC,
-					commit_time DESC,
-					package_path
+					%s
+				WHERE %s @@ %s
+				%s
+			) d
+			WHERE d.score > 0.1
+			WINDOW series AS (
+				PARTITION BY series_key
+				ORDER BY tagged DESC, major DESC, score DESC
+				ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
 		) r
-		WHERE r.score > 0.1
+		WHERE r.major_rank = 1
+		ORDER BY
+			series_score DESC,
+			commit_time DESC,
+			package_path
 		LIMIT $2
-		OFFSET $3`, scoreExpr)
+		OFFSET $3`, score, seriesKeyExpr, majorVersionExpr, taggedExpr, from, tsv, tsQueryExpr, filter)
+	if isCockroachDB(db.db) {
+		query = crdbSearchQuery(query)
+	}
 
 	var results []*SearchResult
 	collect := func(rows *sql.Rows) error {
-		var r SearchResult
+		var (
+			r                 SearchResult
+			modules, versions []string
+		)
 		if err := rows.Scan(&r.PackagePath, &r.Version, &r.ModulePath, &r.CommitTime,
-			&r.NumImportedBy, &r.Score, &r.NumResults); err != nil {
+			&r.NumImportedBy, &r.Score, pq.Array(&modules), pq.Array(&versions), &r.NumResults); err != nil {
 			return fmt.Errorf("rows.Scan(): %v", err)
 		}
+		r.OtherMajor = otherMajorVersions(r.ModulePath, modules, versions)
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, q, limit, opts.Offset)
+	err := db.db.RunQuery(ctx, query, collect, args...)
 	if err != nil {
 		results = nil
 	}
@@ -391,7 +668,7 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit int,
 			commit_time,
 			imported_by_count,
 			score
-		FROM popular_search($1, $2, $3, $4, $5)`
+		FROM popular_search($1, $2, $3, $4, $5, $6)`
 	var results []*SearchResult
 	collect := func(rows *sql.Rows) error {
 		var r SearchResult
@@ -402,7 +679,7 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit int,
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, searchQuery, limit, opts.Offset, nonRedistributablePenalty, noGoModPenalty)
+	err := db.db.RunQuery(ctx, query, collect, searchQuery, limit, opts.Offset, nonRedistributablePenalty, noGoModPenalty, db.synonyms)
 	if err != nil {
 		results = nil
 	}
@@ -450,7 +727,8 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*Sear
 			u.name,
 			d.synopsis,
 			u.license_types,
-			u.redistributable
+			u.redistributable,
+			sd.repo_url
 		FROM
 			units u
 		INNER JOIN
@@ -462,15 +740,19 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*Sear
 		LEFT JOIN
 			documentation d
 		ON u.id = d.unit_id
+		LEFT JOIN
+			search_documents sd
+		ON sd.package_path_id = p.id
 		WHERE
 			(p.path, m.version, m.module_path) IN (%s)`, strings.Join(keys, ","))
 	collect := func(rows *sql.Rows) error {
 		var (
-			path, name, synopsis string
-			licenseTypes         []string
-			redist               bool
+			path, name, synopsis, repoURL string
+			licenseTypes                  []string
+			redist                        bool
 		)
-		if err := rows.Scan(&path, &name, database.NullIsEmpty(&synopsis), pq.Array(&licenseTypes), &redist); err != nil {
+		if err := rows.Scan(&path, &name, database.NullIsEmpty(&synopsis), pq.Array(&licenseTypes), &redist,
+			database.NullIsEmpty(&repoURL)); err != nil {
 			return fmt.Errorf("rows.Scan(): %v", err)
 		}
 		r, ok := resultMap[path]
@@ -478,6 +760,7 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*Sear
 			return fmt.Errorf("BUG: unexpected package path: %q", path)
 		}
 		r.Name = name
+		r.RepoURL = repoURL
 		if redist || db.bypassLicenseCheck {
 			r.Synopsis = synopsis
 		}
@@ -522,10 +805,14 @@ func groupSearchResults(rs []*SearchResult) []*SearchResult {
 		b := bestInGroup[group]
 		if b == nil {
 			// First result (package) with this key; remember it.
+			// Deep search may have already found other major versions of it.
 			bestInGroup[group] = r
-			r.OtherMajor = map[string]int{}
+			if r.OtherMajor == nil {
+				r.OtherMajor = map[string]int{}
+			}
 		} else {
 			_, bMajor := groupAndMajorVersion(b)
+			others := r.OtherMajor
 			switch {
 			case !version.IsPseudo(r.Version) && (rMajor > bMajor || version.IsPseudo(b.Version)):
 				// r is tagged, and is either in a higher major version, or the current best
@@ -534,14 +821,17 @@ func groupSearchResults(rs []*SearchResult) []*SearchResult {
 				r.OtherMajor = b.OtherMajor
 				r.OtherMajor[b.ModulePath] = bMajor
 				r.Score = b.Score // inherit the lower major version's higher score
+				addOtherMajor(r, others)
 			case rMajor == bMajor:
 				// r is another package in b's group; remember it there.
 				b.SameModule = append(b.SameModule, r)
-
+				r.OtherMajor = nil
+				addOtherMajor(b, others)
 			default:
 				// A package from a different major version (either lower, or
 				// higher untagged). Remember the major version.
 				b.OtherMajor[r.ModulePath] = rMajor
+				addOtherMajor(b, others)
 			}
 		}
 	}
@@ -559,6 +849,39 @@ func groupSearchResults(rs []*SearchResult) []*SearchResult {
 	return results
 }
 
+// groupSearchResultsByRepo groups the results of groupSearchResults whose
+// modules are in the same repository, such as golang.org/x/tools and
+// golang.org/x/tools/gopls. The highest-scoring result of each repository is
+// kept, and the others are moved to its SameRepo. Results from the standard
+// library, or whose repository is unknown, are not grouped.
+func groupSearchResultsByRepo(rs []*SearchResult) []*SearchResult {
+	bestInRepo := map[string]*SearchResult{}
+	var results []*SearchResult
+	for _, r := range rs {
+		if r.RepoURL == "" || r.ModulePath == stdlib.ModulePath {
+			results = append(results, r)
+			continue
+		}
+		if b := bestInRepo[r.RepoURL]; b != nil {
+			b.SameRepo = append(b.SameRepo, r)
+			continue
+		}
+		bestInRepo[r.RepoURL] = r
+		results = append(results, r)
+	}
+	return results
+}
+
+// addOtherMajor adds the major versions in others to r.OtherMajor, except
+// for r's own module.
+func addOtherMajor(r *SearchResult, others map[string]int) {
+	for mp, major := range others {
+		if mp != r.ModulePath {
+			r.OtherMajor[mp] = major
+		}
+	}
+}
+
 func groupAndMajorVersion(r *SearchResult) (string, int) {
 	// Packages in the standard library are grouped by their top-level
 	// directory, and we can consider them all part of the same major version.
@@ -578,11 +901,27 @@ func groupAndMajorVersion(r *SearchResult) (string, int) {
 func numRows(rs []*SearchResult) int {
 	n := 0
 	for _, r := range rs {
-		n += 1 + len(r.SameModule)
+		n += 1 + len(r.SameModule) + numRows(r.SameRepo)
 	}
 	return n
 }
 
+// Expressions for the text search columns of search_documents, in terms of
+// the arguments of upsertSearchStatement: $4 is the path tokens, and $5, $6
+// and $7 are the sections B, C and D of the document (see
+// SearchDocumentSections). Changes to them only apply to the packages that
+// are upserted afterwards; see BuildShadowSearchDocuments to reindex the
+// others.
+var (
+	tsvPathTokensExpr   = fmt.Sprintf(`SETWEIGHT(TO_TSVECTOR('%s', replace($4, '_', '-')), 'A')`, search.SymbolTextSearchConfiguration)
+	tsvSearchTokensExpr = `(
+			SETWEIGHT(TO_TSVECTOR('path_tokens', $4), 'A') ||
+			SETWEIGHT(TO_TSVECTOR($5), 'B') ||
+			SETWEIGHT(TO_TSVECTOR($6), 'C') ||
+			SETWEIGHT(TO_TSVECTOR($7), 'D')
+		)`
+)
+
 var upsertSearchStatement = fmt.Sprintf(`
 	INSERT INTO search_documents (
 		package_path,
@@ -598,6 +937,9 @@ var upsertSearchStatement = fmt.Sprintf(`
 		version_updated_at,
 		commit_time,
 		has_go_mod,
+		go_version_sort,
+		repo_url,
+		series_path,
 		-- TODO(https://golang.org/issue/44142): The path_tokens column is used
 		-- to easily iterate on tsv_path_tokens, and can be removed once
 		-- symbol search implementation is done.
@@ -621,14 +963,12 @@ var upsertSearchStatement = fmt.Sprintf(`
 		CURRENT_TIMESTAMP,
 		m.commit_time,
 		m.has_go_mod,
+		m.go_version_sort,
+		m.source_info->>'RepoURL',
+		m.series_path,
 		$4,
-		SETWEIGHT(TO_TSVECTOR('%s', replace($4, '_', '-')), 'A'),
-		(
-			SETWEIGHT(TO_TSVECTOR('path_tokens', $4), 'A') ||
-			SETWEIGHT(TO_TSVECTOR($5), 'B') ||
-			SETWEIGHT(TO_TSVECTOR($6), 'C') ||
-			SETWEIGHT(TO_TSVECTOR($7), 'D')
-		),
+		%s,
+		%s,
 		hll_hash(p1.path) & (%d - 1),
 		hll_zeros(hll_hash(p1.path))
 	FROM units u
@@ -653,6 +993,9 @@ var upsertSearchStatement = fmt.Sprintf(`
 		redistributable=excluded.redistributable,
 		commit_time=excluded.commit_time,
 		has_go_mod=excluded.has_go_mod,
+		go_version_sort=excluded.go_version_sort,
+		repo_url=excluded.repo_url,
+		series_path=excluded.series_path,
 		path_tokens=excluded.path_tokens,
 		tsv_path_tokens=excluded.tsv_path_tokens,
 		tsv_search_tokens=excluded.tsv_search_tokens,
@@ -663,7 +1006,8 @@ var upsertSearchStatement = fmt.Sprintf(`
 			ELSE CURRENT_TIMESTAMP
 			END)
 	;`,
-	search.SymbolTextSearchConfiguration,
+	tsvPathTokensExpr,
+	tsvSearchTokensExpr,
 	hllRegisterCount)
 
 // upsertSearchDocuments adds search information for mod to the search_documents table.
@@ -733,6 +1077,17 @@ type UpsertSearchDocumentArgs struct {
 func UpsertSearchDocument(ctx context.Context, ddb *database.DB, args UpsertSearchDocumentArgs) (err error) {
 	defer derrors.WrapStack(&err, "DB.UpsertSearchDocument(ctx, ddb, %q, %q)", args.PackagePath, args.ModulePath)
 
+	stmt := upsertSearchStatement
+	if isCockroachDB(ddb) {
+		stmt = crdbUpsertSearchStatement
+	}
+	_, err = ddb.Exec(ctx, stmt, searchStatementArgs(args)...)
+	return err
+}
+
+// searchStatementArgs returns the arguments of upsertSearchStatement and
+// shadowSearchStatement for args.
+func searchStatementArgs(args UpsertSearchDocumentArgs) []interface{} {
 	// Only summarize the README if the package and module have the same path.
 	// If this changes, fix DB.ReconcileSearch.
 	if args.PackagePath != args.ModulePath {
@@ -741,8 +1096,7 @@ func UpsertSearchDocument(ctx context.Context, ddb *database.DB, args UpsertSear
 	}
 	pathTokens := strings.Join(GeneratePathTokens(args.PackagePath), " ")
 	sectionB, sectionC, sectionD := SearchDocumentSections(args.Synopsis, args.ReadmeFilePath, args.ReadmeContents)
-	_, err = ddb.Exec(ctx, upsertSearchStatement, args.PackagePath, args.ModulePath, args.Version, pathTokens, sectionB, sectionC, sectionD)
-	return err
+	return []interface{}{args.PackagePath, args.ModulePath, args.Version, pathTokens, sectionB, sectionC, sectionD}
 }
 
 // GetPackagesForSearchDocumentUpsert fetches search information for packages in search_documents
@@ -750,6 +1104,14 @@ func UpsertSearchDocument(ctx context.Context, ddb *database.DB, args UpsertSear
 func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before time.Time, limit int) (argsList []UpsertSearchDocumentArgs, err error) {
 	defer derrors.WrapStack(&err, "GetPackagesForSearchDocumentUpsert(ctx, %s, %d)", before, limit)
 
+	return db.getSearchDocumentArgs(ctx, `WHERE sd.updated_at < $1 LIMIT $2`, before, limit)
+}
+
+// getSearchDocumentArgs returns the arguments to upsert the search documents
+// selected by clauses, which are appended to a query of search_documents as
+// sd, joined with the tables of their packages. They can join other tables,
+// and must have a WHERE clause.
+func (db *DB) getSearchDocumentArgs(ctx context.Context, clauses string, args ...interface{}) (argsList []UpsertSearchDocumentArgs, err error) {
 	query := `
 		SELECT
 			sd.package_path,
@@ -770,8 +1132,7 @@ func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before tim
 		ON sd.package_path = p.path
 		    AND sd.module_path = m.module_path
 		    AND sd.version = m.version
-		WHERE sd.updated_at < $1
-		LIMIT $2`
+		` + clauses
 
 	collect := func(rows *sql.Rows) error {
 		var (
@@ -790,44 +1151,61 @@ func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before tim
 		argsList = append(argsList, a)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, before, limit); err != nil {
+	if err := db.db.RunQuery(ctx, query, collect, args...); err != nil {
 		return nil, err
 	}
 	return argsList, nil
 }
 
-// UpdateSearchDocumentsImportedByCount updates imported_by_count and
-// imported_by_count_updated_at.
+// UpdateSearchDocumentsImportedByCount updates imported_by_count,
+// imported_by_count_updated_at and recent_imported_by_count.
 //
 // It does so by completely recalculating the imported-by counts
 // from the imports_unique table.
 //
-// UpdateSearchDocumentsImportedByCount returns the number of rows updated.
+// UpdateSearchDocumentsImportedByCount returns the number of rows whose
+// imported_by_count was updated.
 func (db *DB) UpdateSearchDocumentsImportedByCount(ctx context.Context) (nUpdated int64, err error) {
 	defer derrors.WrapStack(&err, "UpdateSearchDocumentsImportedByCount(ctx)")
 
-	curCounts, err := db.getSearchPackages(ctx)
+	pkgs, err := db.getSearchPackages(ctx)
 	if err != nil {
 		return 0, err
 	}
-	newCounts, err := db.computeImportedByCounts(ctx, curCounts)
+	newCounts, newRecentCounts, err := db.computeImportedByCounts(ctx, pkgs, time.Now())
 	if err != nil {
 		return 0, err
 	}
 	// Include only changed counts for packages that are in search_documents.
 	changedCounts := map[string]int{}
 	for p, nc := range newCounts {
-		cc, present := curCounts[p]
-		if present && cc != nc {
+		if sp, present := pkgs[p]; present && sp.importedByCount != nc {
 			changedCounts[p] = nc
 		}
 	}
+	// Recent counts change a little whenever time passes, so include only
+	// those that changed noticeably. Packages that are no longer imported go
+	// back to zero.
+	changedRecentCounts := map[string]float64{}
+	for p, sp := range pkgs {
+		if nc := newRecentCounts[p]; recentCountChanged(sp.recentImportedByCount, nc) {
+			changedRecentCounts[p] = nc
+		}
+	}
 	pct := 0
-	if len(curCounts) > 0 {
-		pct = len(changedCounts) * 100 / len(curCounts)
+	if len(pkgs) > 0 {
+		pct = len(changedCounts) * 100 / len(pkgs)
 	}
-	log.Debugf(ctx, "update-imported-by-counts: %d changed (%d%%)", len(changedCounts), pct)
-	return db.UpdateSearchDocumentsImportedByCountWithCounts(ctx, changedCounts)
+	log.Debugf(ctx, "update-imported-by-counts: %d changed (%d%%), %d recent counts changed",
+		len(changedCounts), pct, len(changedRecentCounts))
+	nUpdated, err = db.UpdateSearchDocumentsImportedByCountWithCounts(ctx, changedCounts)
+	if err != nil {
+		return nUpdated, err
+	}
+	if err := db.updateRecentImportedByCounts(ctx, changedRecentCounts); err != nil {
+		return nUpdated, err
+	}
+	return nUpdated, nil
 }
 
 // How many imported-by counts to update at a time.
@@ -853,36 +1231,50 @@ func (db *DB) UpdateSearchDocumentsImportedByCountWithCounts(ctx context.Context
 	return nUpdated, nil
 }
 
-// getSearchPackages returns the set of package paths that are in the search_documents table,
-// along with their current imported-by count.
-func (db *DB) getSearchPackages(ctx context.Context) (counts map[string]int, err error) {
+// A searchPackage holds the data of a package in search_documents that is
+// used to compute imported-by counts.
+type searchPackage struct {
+	importedByCount       int
+	recentImportedByCount float64
+	// commitTime is the commit time of the version of the package in
+	// search_documents, which is the latest one.
+	commitTime time.Time
+}
+
+// getSearchPackages returns the packages that are in the search_documents
+// table, keyed by package path.
+func (db *DB) getSearchPackages(ctx context.Context) (pkgs map[string]*searchPackage, err error) {
 	defer derrors.WrapStack(&err, "DB.getSearchPackages(ctx)")
 
-	counts = map[string]int{}
+	pkgs = map[string]*searchPackage{}
 	err = db.db.RunQuery(ctx, `
-		SELECT package_path, imported_by_count
+		SELECT package_path, imported_by_count, recent_imported_by_count, commit_time
 		FROM search_documents
 	`, func(rows *sql.Rows) error {
 		var (
-			p string
-			c int
+			p  string
+			sp searchPackage
 		)
-		if err := rows.Scan(&p, &c); err != nil {
+		if err := rows.Scan(&p, &sp.importedByCount, &sp.recentImportedByCount, &sp.commitTime); err != nil {
 			return err
 		}
-		counts[p] = c
+		pkgs[p] = &sp
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return counts, nil
+	return pkgs, nil
 }
 
-func (db *DB) computeImportedByCounts(ctx context.Context, curCounts map[string]int) (newCounts map[string]int, err error) {
+// computeImportedByCounts computes the imported-by counts of packages from
+// the imports of the packages in pkgs. The recent counts weight each importer
+// by the recency of its commit time, relative to now.
+func (db *DB) computeImportedByCounts(ctx context.Context, pkgs map[string]*searchPackage, now time.Time) (newCounts map[string]int, newRecentCounts map[string]float64, err error) {
 	defer derrors.WrapStack(&err, "db.computeImportedByCounts(ctx)")
 
 	newCounts = map[string]int{}
+	newRecentCounts = map[string]float64{}
 	// Get all (from_path, to_path) pairs, deduped.
 	// Also get the from_path's module path.
 	err = db.db.RunQuery(ctx, `
@@ -894,7 +1286,8 @@ func (db *DB) computeImportedByCounts(ctx context.Context, curCounts map[string]
 			return err
 		}
 		// Don't count an importer if it's not in search_documents.
-		if _, ok := curCounts[from]; !ok {
+		sp, ok := pkgs[from]
+		if !ok {
 			return nil
 		}
 		// Don't count an importer if it's in the same module as what it's importing.
@@ -904,12 +1297,36 @@ func (db *DB) computeImportedByCounts(ctx context.Context, curCounts map[string]
 			return nil
 		}
 		newCounts[to]++
+		newRecentCounts[to] += importerWeight(sp.commitTime, now)
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return newCounts, nil
+	return newCounts, newRecentCounts, nil
+}
+
+// importRecencyHalfLife is the age of an importer at which it counts for half
+// as much in recent_imported_by_count. Without this decay, packages that were
+// popular long ago keep ranking above newer ones that are now more used.
+const importRecencyHalfLife = 2 * 365 * 24 * time.Hour
+
+// importerWeight returns how much an importer whose latest version was
+// committed at commitTime counts towards recent_imported_by_count at time
+// now. It is 1 for a new importer, and halves every importRecencyHalfLife.
+func importerWeight(commitTime, now time.Time) float64 {
+	age := now.Sub(commitTime)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(importRecencyHalfLife))
+}
+
+// recentCountChanged reports whether a recent imported-by count has changed
+// enough from cur to new to be worth updating. Small changes, such as those
+// caused only by the passing of time, are ignored.
+func recentCountChanged(cur, new float64) bool {
+	return math.Abs(new-cur) > 0.01*math.Max(1, cur)
 }
 
 // insertImportedByCounts creates a temporary table and inserts at most limit
@@ -972,6 +1389,51 @@ func updateImportedByCounts(ctx context.Context, db *database.DB) (int64, error)
 	return n, nil
 }
 
+// updateRecentImportedByCounts sets recent_imported_by_count in
+// search_documents to the values in counts, countBatchSize rows at a time.
+// The updated keys are deleted from counts.
+func (db *DB) updateRecentImportedByCounts(ctx context.Context, counts map[string]float64) (err error) {
+	defer derrors.WrapStack(&err, "updateRecentImportedByCounts(ctx, %d counts)", len(counts))
+
+	for len(counts) > 0 {
+		err := db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+			const createTableQuery = `
+				CREATE TEMPORARY TABLE computed_recent_imported_by_counts (
+					package_path             TEXT NOT NULL,
+					recent_imported_by_count REAL NOT NULL
+				) ON COMMIT DROP;
+			`
+			if _, err := tx.Exec(ctx, createTableQuery); err != nil {
+				return fmt.Errorf("CREATE TABLE: %v", err)
+			}
+			var values []interface{}
+			for p, c := range counts {
+				if len(values) >= 2*countBatchSize {
+					break
+				}
+				values = append(values, p, c)
+				delete(counts, p)
+			}
+			columns := []string{"package_path", "recent_imported_by_count"}
+			if err := tx.BulkInsert(ctx, "computed_recent_imported_by_counts", columns, values, ""); err != nil {
+				return err
+			}
+			// Lock the table for the same reason as updateImportedByCounts.
+			_, err := tx.Exec(ctx, `
+				LOCK TABLE search_documents IN SHARE ROW EXCLUSIVE MODE;
+				UPDATE search_documents s
+				SET recent_imported_by_count = c.recent_imported_by_count
+				FROM computed_recent_imported_by_counts c
+				WHERE s.package_path = c.package_path;`)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var (
 	commonHostnames = map[string]bool{
 		"bitbucket.org":         true,
@@ -26,10 +26,15 @@ package search
 
 // querySearchMultiWordExact is used when the search query is multiple elements.
 %s
+
+// querySearchPathScoped is used when the search query is a package or module
+// path followed by a symbol name, such as "golang.org/x/net/http2 Framer".
+%s
 `,
 	formatQuery("querySearchSymbol", SymbolQuery(SearchTypeSymbol)),
 	formatQuery("querySearchPackageDotSymbol", SymbolQuery(SearchTypePackageDotSymbol)),
-	formatQuery("querySearchMultiWordExact", SymbolQuery(SearchTypeMultiWordExact)))
+	formatQuery("querySearchMultiWordExact", SymbolQuery(SearchTypeMultiWordExact)),
+	formatQuery("querySearchPathScoped", SymbolQuery(SearchTypePathScoped)))
 
 func formatQuery(name, query string) string {
 	return fmt.Sprintf("const %s = `%s`", name, query)
@@ -133,3 +133,51 @@ INNER JOIN symbol_names s ON s.id=ssd.symbol_name_id
 INNER JOIN search_documents sd ON sd.unit_id = ssd.unit_id
 INNER JOIN package_symbols ps ON ps.id=ssd.package_symbol_id
 ORDER BY score DESC;`
+
+// querySearchPathScoped is used when the search query is a package or module
+// path followed by a symbol name, such as "golang.org/x/net/http2 Framer".
+const querySearchPathScoped = `
+WITH ssd AS (
+	SELECT
+		ssd.unit_id,
+		ssd.package_symbol_id,
+		ssd.symbol_name_id,
+		ssd.goos,
+		ssd.goarch,
+		ssd.imported_by_count AS score
+	FROM symbol_search_documents ssd
+	WHERE 
+		ssd.package_path_id IN (
+			SELECT package_path_id
+			FROM search_documents
+			WHERE package_path = $3 OR module_path = $3
+		)
+		AND ssd.symbol_name_id IN (
+			SELECT id
+			FROM symbol_names
+			WHERE lower(name) = lower($1)
+		)
+	ORDER BY
+		score DESC,
+		package_path
+	LIMIT $2
+)
+SELECT
+	s.name AS symbol_name,
+	sd.package_path,
+	sd.module_path,
+	sd.version,
+	sd.name,
+	sd.synopsis,
+	sd.license_types,
+	sd.commit_time,
+	sd.imported_by_count,
+	ssd.goos,
+	ssd.goarch,
+	ps.type AS symbol_kind,
+	ps.synopsis AS symbol_synopsis
+FROM ssd
+INNER JOIN symbol_names s ON s.id=ssd.symbol_name_id
+INNER JOIN search_documents sd ON sd.unit_id = ssd.unit_id
+INNER JOIN package_symbols ps ON ps.id=ssd.package_symbol_id
+ORDER BY score DESC;`
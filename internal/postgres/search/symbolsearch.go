@@ -19,7 +19,8 @@ const SymbolTextSearchConfiguration = "symbols"
 // Each query that is returned accepts the following args:
 // $1 = query
 // $2 = limit
-// $3 = only used by multi-word-exact for path tokens
+// $3 = only used by multi-word-exact for path tokens, by package-dot-symbol
+// for the package, and by path-scoped for the package or module path
 func SymbolQuery(st SearchType) string {
 	switch st {
 	case SearchTypeMultiWordExact:
@@ -29,6 +30,10 @@ func SymbolQuery(st SearchType) string {
 		// <package>.<type>.<methodOrField>, only match on the exact
 		// symbol name.
 		return fmt.Sprintf(baseQuery, fmt.Sprintf(symbolCTE, filterPackageDotSymbol))
+	case SearchTypePathScoped:
+		// When $3 is a package or module path, match on the exact symbol
+		// name within the packages that have that path or module path.
+		return fmt.Sprintf(baseQuery, fmt.Sprintf(symbolCTE, filterPathScoped))
 	case SearchTypeSymbol:
 		// When $1 is the full symbol name, either <symbol> or
 		// <type>.<methodOrField>, match on just the identifier name.
@@ -72,6 +77,22 @@ var filterPackageDotSymbol = fmt.Sprintf(`
 		)`,
 	"uuid_generate_v5(uuid_nil(), split_part($3, '.', 1))")
 
+// filterPathScoped looks up the few packages in scope and the symbol name
+// first, so that only the rows for those packages and that name are read
+// using the unique index on (package_path_id, symbol_name_id), instead of
+// the rows for that name in every package.
+const filterPathScoped = `
+		ssd.package_path_id IN (
+			SELECT package_path_id
+			FROM search_documents
+			WHERE package_path = $3 OR module_path = $3
+		)
+		AND ssd.symbol_name_id IN (
+			SELECT id
+			FROM symbol_names
+			WHERE lower(name) = lower($1)
+		)`
+
 var multiwordCTE = fmt.Sprintf(`
 	SELECT
 		ssd.unit_id,
@@ -40,6 +40,10 @@ func TestParseInputType(t *testing.T) {
 		{"multiword three words", "foo bar baz", InputTypeMultiWord},
 		{"two dots package path dot symbol name not supported", "github.com/foo/bar.DB", InputTypeNoMatch},
 		{"three dots package path dot symbol name not supported", "github.com/foo/bar.DB.Begin", InputTypeNoMatch},
+		{"path scoped symbol name", "golang.org/x/net/http2 Framer", InputTypePathScoped},
+		{"path scoped type dot method", "net/http Client.Do", InputTypePathScoped},
+		{"path without slash is not scoped", "sql DB", InputTypeMultiWord},
+		{"path scoped invalid symbol", "github.com/foo/bar a-b", InputTypeMultiWord},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			got := ParseInputType(test.q)
@@ -59,6 +63,7 @@ func TestGenerateQuery(t *testing.T) {
 		{"querySearchSymbol", SymbolQuery(SearchTypeSymbol), querySearchSymbol},
 		{"querySearchPackageDotSymbol", SymbolQuery(SearchTypePackageDotSymbol), querySearchPackageDotSymbol},
 		{"querySearchMultiWordExact", SymbolQuery(SearchTypeMultiWordExact), querySearchMultiWordExact},
+		{"querySearchPathScoped", SymbolQuery(SearchTypePathScoped), querySearchPathScoped},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			if diff := cmp.Diff(test.want, test.q); diff != "" {
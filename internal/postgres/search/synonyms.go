@@ -0,0 +1,61 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultSynonyms are the groups of terms that package search treats as
+// interchangeable by default. A query for any term of a group matches
+// documents that contain any other term of the group.
+var DefaultSynonyms = [][]string{
+	{"yaml", "yml"},
+	{"postgres", "postgresql"},
+	{"grpc", "rpc"},
+	{"kubernetes", "k8s"},
+	{"mongo", "mongodb"},
+	{"jwt", "jsonwebtoken"},
+	{"websocket", "ws"},
+}
+
+// synonymRegexp matches the terms allowed in synonym groups. Terms are
+// interpolated into SQL, so they must not need quoting.
+var synonymRegexp = regexp.MustCompile(`^[a-z0-9]{2,40}$`)
+
+// SynonymRewriteQuery returns a SQL query to pass as the second argument of
+// ts_rewrite, which rewrites a text search query to expand the terms of the
+// given synonym groups. For example, for the group {"yaml", "yml"}, "yaml"
+// is rewritten to "yaml | yml".
+//
+// Rewriting the query instead of the indexed documents means that synonyms
+// can change without reindexing.
+func SynonymRewriteQuery(groups [][]string) (string, error) {
+	var rows []string
+	for _, g := range groups {
+		if len(g) < 2 {
+			return "", fmt.Errorf("synonym group %q has fewer than two terms", g)
+		}
+		for _, t := range g {
+			if !synonymRegexp.MatchString(t) {
+				return "", fmt.Errorf("invalid synonym %q: must be 2 to 40 lowercase letters or digits", t)
+			}
+		}
+		sub := strings.Join(g, " | ")
+		for _, t := range g {
+			rows = append(rows, fmt.Sprintf("('%s', '%s')", t, sub))
+		}
+	}
+	if len(rows) == 0 {
+		// ts_rewrite leaves the query unchanged if there are no rows.
+		return "SELECT NULL::tsquery, NULL::tsquery WHERE false", nil
+	}
+	// The terms are normalized with to_tsquery, like those of the query, so
+	// that they match after stemming ("postgres" becomes "postgr").
+	return fmt.Sprintf("SELECT to_tsquery(t), to_tsquery(s) FROM (VALUES %s) AS synonyms(t, s)",
+		strings.Join(rows, ", ")), nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import "testing"
+
+func TestSynonymRewriteQuery(t *testing.T) {
+	got, err := SynonymRewriteQuery([][]string{{"yaml", "yml"}, {"a1", "b2", "c3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT to_tsquery(t), to_tsquery(s) FROM (VALUES " +
+		"('yaml', 'yaml | yml'), ('yml', 'yaml | yml'), " +
+		"('a1', 'a1 | b2 | c3'), ('b2', 'a1 | b2 | c3'), ('c3', 'a1 | b2 | c3')) AS synonyms(t, s)"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := SynonymRewriteQuery(DefaultSynonyms); err != nil {
+		t.Errorf("DefaultSynonyms: %v", err)
+	}
+	for _, groups := range [][][]string{
+		{{"yaml"}},
+		{{"yaml", "y'ml"}},
+		{{"Yaml", "yml"}},
+		{{"yaml", "yml; DROP TABLE"}},
+	} {
+		if _, err := SynonymRewriteQuery(groups); err == nil {
+			t.Errorf("%q: got no error, want one", groups)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package search
 
 import (
+	"go/token"
 	"strings"
 )
 
@@ -12,6 +13,9 @@ import (
 // InputType determines which symbol search query will be run.
 func ParseInputType(q string) InputType {
 	q = strings.TrimSpace(q)
+	if _, _, ok := ParsePathScopedQuery(q); ok {
+		return InputTypePathScoped
+	}
 	if strings.ContainsAny(q, " \t\n") {
 		return InputTypeMultiWord
 	}
@@ -35,6 +39,28 @@ func ParseInputType(q string) InputType {
 	}
 }
 
+// ParsePathScopedQuery reports whether q is a search for a symbol within a
+// package or module path, such as "golang.org/x/net/http2 Framer" or
+// "net/http Client.Do", and returns the path and the symbol name. The path
+// must contain a slash, so that it is not mistaken for a package name.
+func ParsePathScopedQuery(q string) (path, symbol string, ok bool) {
+	words := strings.Fields(q)
+	if len(words) != 2 || !strings.Contains(strings.Trim(words[0], "/"), "/") {
+		return "", "", false
+	}
+	path, symbol = strings.Trim(words[0], "/"), words[1]
+	parts := strings.Split(symbol, ".")
+	if len(parts) > 2 {
+		return "", "", false
+	}
+	for _, p := range parts {
+		if !token.IsIdentifier(p) {
+			return "", "", false
+		}
+	}
+	return path, symbol, true
+}
+
 // InputType is the type determined for the search query input.
 type InputType int
 
@@ -67,6 +93,10 @@ const (
 
 	// InputTypeMultiWord indicates that the query has multiple words.
 	InputTypeMultiWord
+
+	// InputTypePathScoped indicates that the query type is
+	// <package-or-module-path> <symbol>, as parsed by ParsePathScopedQuery.
+	InputTypePathScoped
 )
 
 // SearchType is the type of search that will be performed, based on the input
@@ -94,6 +124,11 @@ const (
 	// token combinations. In that case, multiple queries are run in parallel
 	// and the results are combined.
 	SearchTypeMultiWordExact
+
+	// SearchTypePathScoped is used for InputTypePathScoped. Only the symbols
+	// of the package with the given path, or of the packages of the module
+	// with that path, are searched.
+	SearchTypePathScoped
 )
 
 // String returns the name of the search type as a string.
@@ -107,6 +142,8 @@ func (st SearchType) String() string {
 		return "SearchTypeMultiWordOr"
 	case SearchTypeMultiWordExact:
 		return "SearchTypeMultiWordExact"
+	case SearchTypePathScoped:
+		return "SearchTypePathScoped"
 	default:
 		// This should never happen.
 		return "?unknown?"
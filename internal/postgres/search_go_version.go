@@ -0,0 +1,131 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// goVersionSortKey returns the sortable form of the Go version v, such as
+// "1.18" or "1.21.3", as computed by version.ForSorting. It returns NULL if v
+// is empty or is not a valid Go version.
+func goVersionSortKey(v string) sql.NullString {
+	if v == "" {
+		return sql.NullString{}
+	}
+	sv := stdlib.VersionForTag("go" + v)
+	if sv == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: version.ForSorting(sv), Valid: true}
+}
+
+// goVersionExpr is the predicate that deep search adds to its query when
+// SearchOptions.GoVersion is set, with the sort key of the Go version as $5.
+//
+// A standard library package matches if it had at least one exported symbol
+// at that version, according to symbol_history. Any other package matches if
+// the go directive of its module is no later than that version. Modules
+// without a go directive, or that haven't been processed since the go
+// directive was recorded, always match.
+var goVersionExpr = fmt.Sprintf(`
+	AND CASE WHEN module_path = '%s'
+		THEN EXISTS (
+			SELECT 1
+			FROM symbol_history sh
+			WHERE sh.package_path_id = search_documents.package_path_id
+			AND sh.module_path_id = search_documents.module_path_id
+			AND sh.sort_version <= $5)
+		ELSE go_version_sort IS NULL OR go_version_sort <= $5
+	END`, stdlib.ModulePath)
+
+// filterSymbolsByGoVersion returns the symbol search results that are
+// available at the Go version with the given sort key.
+//
+// A standard library symbol is available if symbol_history says that it was
+// introduced no later than that version. A symbol in any other module is
+// available if the go directive of its module is no later than that version.
+// Results whose availability is unknown are kept.
+func (db *DB) filterSymbolsByGoVersion(ctx context.Context, results []*SearchResult, sortKey string) (_ []*SearchResult, err error) {
+	defer derrors.WrapStack(&err, "filterSymbolsByGoVersion(%q)", sortKey)
+
+	type pkgSymbol struct{ pkgPath, name string }
+	var (
+		stdPaths, stdNames, modPaths []string
+		introduced                   = map[pkgSymbol]string{}
+		goVersions                   = map[string]string{}
+	)
+	for _, r := range results {
+		if r.ModulePath == stdlib.ModulePath {
+			stdPaths = append(stdPaths, r.PackagePath)
+			stdNames = append(stdNames, r.SymbolName)
+		} else {
+			modPaths = append(modPaths, r.PackagePath)
+		}
+	}
+	if len(stdPaths) > 0 {
+		query := `
+			SELECT p.path, s.name, MIN(sh.sort_version)
+			FROM symbol_history sh
+			INNER JOIN paths p ON p.id = sh.package_path_id
+			INNER JOIN paths m ON m.id = sh.module_path_id
+			INNER JOIN symbol_names s ON s.id = sh.symbol_name_id
+			WHERE m.path = $1
+			AND p.path = ANY($2)
+			AND s.name = ANY($3)
+			GROUP BY p.path, s.name`
+		collect := func(rows *sql.Rows) error {
+			var ps pkgSymbol
+			var sv string
+			if err := rows.Scan(&ps.pkgPath, &ps.name, &sv); err != nil {
+				return err
+			}
+			introduced[ps] = sv
+			return nil
+		}
+		if err := db.db.RunQuery(ctx, query, collect, stdlib.ModulePath, pq.Array(stdPaths), pq.Array(stdNames)); err != nil {
+			return nil, err
+		}
+	}
+	if len(modPaths) > 0 {
+		query := `
+			SELECT package_path, go_version_sort
+			FROM search_documents
+			WHERE package_path = ANY($1)
+			AND go_version_sort IS NOT NULL`
+		collect := func(rows *sql.Rows) error {
+			var path, sv string
+			if err := rows.Scan(&path, &sv); err != nil {
+				return err
+			}
+			goVersions[path] = sv
+			return nil
+		}
+		if err := db.db.RunQuery(ctx, query, collect, pq.Array(modPaths)); err != nil {
+			return nil, err
+		}
+	}
+
+	var filtered []*SearchResult
+	for _, r := range results {
+		var sv string
+		if r.ModulePath == stdlib.ModulePath {
+			sv = introduced[pkgSymbol{r.PackagePath, r.SymbolName}]
+		} else {
+			sv = goVersions[r.PackagePath]
+		}
+		if sv == "" || sv <= sortKey {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Teams of an interleaved search results page: each result is contributed
+// either by the default ranking or by the alternative one.
+const (
+	TeamControl   = "control"
+	TeamTreatment = "treatment"
+)
+
+// A SearchClick is a click on a result of an interleaved search results page.
+// It deliberately contains nothing about the query or the user.
+type SearchClick struct {
+	// ImpressionID is the random ID of the results page.
+	ImpressionID string
+	// Ranking is the alternative ranking that was interleaved with the
+	// default one.
+	Ranking string
+	// Team is TeamControl or TeamTreatment.
+	Team string
+	// Position is the 0-based position of the result on the page.
+	Position int
+}
+
+// InsertSearchClick stores c in the search_interleaving_clicks table.
+func (db *DB) InsertSearchClick(ctx context.Context, c *SearchClick) (err error) {
+	defer derrors.WrapStack(&err, "InsertSearchClick(ctx, %+v)", c)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO search_interleaving_clicks (impression_id, ranking, team, position)
+		VALUES ($1, $2, $3, $4)`,
+		c.ImpressionID, c.Ranking, c.Team, c.Position)
+	return err
+}
+
+// An InterleavingResult summarizes the comparison of an alternative ranking
+// with the default one. A page is won by the ranking whose results got more
+// clicks on it.
+type InterleavingResult struct {
+	Ranking string
+	// Pages is the number of results pages with at least one click.
+	Pages int
+	// Wins and Losses are the number of pages won and lost by the
+	// alternative ranking. The other pages are ties.
+	Wins, Losses int
+	// ControlClicks and TreatmentClicks are the total number of clicks on
+	// results from each ranking.
+	ControlClicks, TreatmentClicks int
+}
+
+// Ties returns the number of pages where both rankings got the same number of
+// clicks.
+func (r *InterleavingResult) Ties() int {
+	return r.Pages - r.Wins - r.Losses
+}
+
+// GetInterleavingResults returns the results of the interleaving experiments
+// for the clicks since the given time, by ranking.
+func (db *DB) GetInterleavingResults(ctx context.Context, since time.Time) (_ []*InterleavingResult, err error) {
+	defer derrors.WrapStack(&err, "GetInterleavingResults(ctx, %s)", since)
+
+	var results []*InterleavingResult
+	collect := func(rows *sql.Rows) error {
+		var r InterleavingResult
+		if err := rows.Scan(&r.Ranking, &r.Pages, &r.Wins, &r.Losses, &r.ControlClicks, &r.TreatmentClicks); err != nil {
+			return err
+		}
+		results = append(results, &r)
+		return nil
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT
+			ranking,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE treatment > control),
+			COUNT(*) FILTER (WHERE treatment < control),
+			COALESCE(SUM(control), 0),
+			COALESCE(SUM(treatment), 0)
+		FROM (
+			SELECT
+				impression_id,
+				ranking,
+				COUNT(*) FILTER (WHERE team = 'control') AS control,
+				COUNT(*) FILTER (WHERE team = 'treatment') AS treatment
+			FROM search_interleaving_clicks
+			WHERE created_at >= $1
+			GROUP BY impression_id, ranking
+		) p
+		GROUP BY ranking
+		ORDER BY ranking`, collect, since)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInterleavingResults(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const r = RankingDampedPopularity
+	for _, c := range []*SearchClick{
+		// Won by the treatment.
+		{"a", r, TeamTreatment, 0},
+		{"a", r, TeamTreatment, 3},
+		{"a", r, TeamControl, 1},
+		// Lost by the treatment.
+		{"b", r, TeamControl, 0},
+		// Tie.
+		{"c", r, TeamControl, 0},
+		{"c", r, TeamTreatment, 1},
+	} {
+		if err := testDB.InsertSearchClick(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := testDB.GetInterleavingResults(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*InterleavingResult{{
+		Ranking:         r,
+		Pages:           3,
+		Wins:            1,
+		Losses:          1,
+		ControlClicks:   3,
+		TreatmentClicks: 3,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if got := got[0].Ties(); got != 1 {
+		t.Errorf("got %d ties, want 1", got)
+	}
+}
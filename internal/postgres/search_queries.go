@@ -0,0 +1,102 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A SearchQuery counts the searches for a normalized query on one day, with
+// coarse information about their results. It deliberately contains nothing
+// about the searchers.
+type SearchQuery struct {
+	// Day is the UTC day of the searches, at midnight.
+	Day   time.Time
+	Query string
+	// Mode is the search mode, "package" or "symbol".
+	Mode     string
+	Searches int
+	// ZeroResultSearches is the number of searches that had no results.
+	ZeroResultSearches int
+	// ResultCount is the number of results of the last search.
+	ResultCount int
+	// TopResultPath is the path of the first result of the last search, or
+	// empty if it had no results.
+	TopResultPath string
+}
+
+// AddSearchQueries adds the searches in sqs to the search_queries table. The
+// result information of a query replaces that of earlier searches on the same
+// day.
+func (db *DB) AddSearchQueries(ctx context.Context, sqs []*SearchQuery) (err error) {
+	defer derrors.WrapStack(&err, "AddSearchQueries(ctx, [%d search queries])", len(sqs))
+
+	if len(sqs) == 0 {
+		return nil
+	}
+	var values []interface{}
+	for _, sq := range sqs {
+		values = append(values, sq.Day.UTC().Format("2006-01-02"), sq.Query, sq.Mode,
+			sq.Searches, sq.ZeroResultSearches, sq.ResultCount, sq.TopResultPath)
+	}
+	cols := []string{"day", "query", "mode", "searches", "zero_result_searches", "result_count", "top_result_path"}
+	return db.db.BulkInsert(ctx, "search_queries", cols, values,
+		`ON CONFLICT (day, query, mode) DO UPDATE SET
+			searches = search_queries.searches + excluded.searches,
+			zero_result_searches = search_queries.zero_result_searches + excluded.zero_result_searches,
+			result_count = excluded.result_count,
+			top_result_path = excluded.top_result_path`)
+}
+
+// A ZeroResultQuery is a query for which some searches had no results.
+type ZeroResultQuery struct {
+	Query string
+	Mode  string
+	// Searches is the number of searches for the query that had no results.
+	Searches int
+	// LastDay is the last day on which a search for the query had no
+	// results.
+	LastDay time.Time
+}
+
+// GetZeroResultQueries returns the limit queries with the most searches that
+// had no results since the given time, most searched first.
+func (db *DB) GetZeroResultQueries(ctx context.Context, since time.Time, limit int) (_ []*ZeroResultQuery, err error) {
+	defer derrors.WrapStack(&err, "GetZeroResultQueries(ctx, %s, %d)", since, limit)
+
+	var zqs []*ZeroResultQuery
+	collect := func(rows *sql.Rows) error {
+		var zq ZeroResultQuery
+		if err := rows.Scan(&zq.Query, &zq.Mode, &zq.Searches, &zq.LastDay); err != nil {
+			return err
+		}
+		zqs = append(zqs, &zq)
+		return nil
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT query, mode, SUM(zero_result_searches), MAX(day)
+		FROM search_queries
+		WHERE day >= $1::date AND zero_result_searches > 0
+		GROUP BY query, mode
+		ORDER BY 3 DESC, query, mode
+		LIMIT $2`, collect, since.UTC().Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, err
+	}
+	return zqs, nil
+}
+
+// DeleteSearchQueries deletes the counts of the searches before the given
+// time, and returns the number of rows deleted.
+func (db *DB) DeleteSearchQueries(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer derrors.WrapStack(&err, "DeleteSearchQueries(ctx, %s)", before)
+
+	return db.db.Exec(ctx, `DELETE FROM search_queries WHERE day < $1::date`,
+		before.UTC().Format("2006-01-02"))
+}
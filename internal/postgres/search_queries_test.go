@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchQueries(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+	old := today.AddDate(0, 0, -40)
+	for _, sqs := range [][]*SearchQuery{
+		{
+			{Day: today, Query: "yaml", Mode: "package", Searches: 5, ResultCount: 40, TopResultPath: "gopkg.in/yaml.v3"},
+			{Day: today, Query: "frobnicate", Mode: "package", Searches: 2, ZeroResultSearches: 2},
+			{Day: yesterday, Query: "frobnicate", Mode: "package", Searches: 1, ZeroResultSearches: 1},
+			{Day: today, Query: "quux", Mode: "symbol", Searches: 1, ZeroResultSearches: 1},
+			{Day: old, Query: "ancient", Mode: "package", Searches: 9, ZeroResultSearches: 9},
+		},
+		// Searches are added to the existing counts.
+		{
+			{Day: today, Query: "quux", Mode: "symbol", Searches: 3, ZeroResultSearches: 3},
+		},
+	} {
+		if err := testDB.AddSearchQueries(ctx, sqs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetZeroResultQueries(ctx, today.AddDate(0, 0, -30), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ZeroResultQuery{
+		{Query: "quux", Mode: "symbol", Searches: 4, LastDay: today},
+		{Query: "frobnicate", Mode: "package", Searches: 3, LastDay: today},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	n, err := testDB.DeleteSearchQueries(ctx, today.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d rows, want 1", n)
+	}
+}
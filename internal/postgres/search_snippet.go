@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// SnippetStart and SnippetEnd surround the terms of SearchResult.Snippet
+// that match the query. They are control characters, which don't occur in
+// synopses.
+const (
+	SnippetStart = "\x02"
+	SnippetEnd   = "\x03"
+)
+
+// snippetOptions are the options of ts_headline for search snippets. The
+// README excerpt of a package without a synopsis can have a match far from
+// its start, so up to two fragments are shown.
+var snippetOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MinWords=15, MaxWords=35, MaxFragments=2, FragmentDelimiter=" … "`,
+	SnippetStart, SnippetEnd)
+
+// snippetReadmeLength is the length of the start of a README that a snippet
+// is taken from.
+const snippetReadmeLength = 2000
+
+// addSnippets sets the Snippet of the results that match the query q,
+// but not of their sub-results.
+func (db *DB) addSnippets(ctx context.Context, q string, results []*SearchResult) (err error) {
+	defer derrors.WrapStack(&err, "addSnippets(ctx, %q)", q)
+
+	if len(results) == 0 {
+		return nil
+	}
+	var keys []string
+	byPath := map[string]*SearchResult{}
+	for _, r := range results {
+		byPath[r.PackagePath] = r
+		keys = append(keys, fmt.Sprintf("(%s, %s, %s)", pq.QuoteLiteral(r.PackagePath),
+			pq.QuoteLiteral(r.Version), pq.QuoteLiteral(r.ModulePath)))
+	}
+	// Compute the headlines in the outer query, so that it is done once per
+	// package even if it has documentation for several build contexts.
+	query := fmt.Sprintf(`
+		SELECT path, ts_headline(text, websearch_to_tsquery($1), $2)
+		FROM (
+			SELECT DISTINCT ON (p.path)
+				p.path,
+				CASE WHEN COALESCE(d.synopsis, '') <> '' THEN d.synopsis
+				ELSE left(COALESCE(r.contents, ''), %d)
+				END AS text
+			FROM units u
+			INNER JOIN paths p ON u.path_id = p.id
+			INNER JOIN modules m ON u.module_id = m.id
+			LEFT JOIN documentation d ON u.id = d.unit_id
+			LEFT JOIN readmes r ON u.id = r.unit_id
+			WHERE (p.path, m.version, m.module_path) IN (%s)
+			AND (u.redistributable OR $3)
+			ORDER BY p.path, d.goos, d.goarch
+		) t`, snippetReadmeLength, strings.Join(keys, ","))
+	collect := func(rows *sql.Rows) error {
+		var path, snippet string
+		if err := rows.Scan(&path, database.NullIsEmpty(&snippet)); err != nil {
+			return err
+		}
+		if r := byPath[path]; r != nil && strings.Contains(snippet, SnippetStart) {
+			r.Snippet = snippet
+		}
+		return nil
+	}
+	return db.db.RunQuery(ctx, query, collect, q, snippetOptions, db.bypassLicenseCheck)
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSearchSnippets(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("github.com/gorilla/mux", sample.VersionString, ""))
+	for _, test := range []struct {
+		q, want string
+	}{
+		{"package", "Package p is a " + SnippetStart + "package" + SnippetEnd + "."},
+		// The synopsis doesn't match a query that matches the path.
+		{"gorilla", ""},
+	} {
+		results, err := testDB.Search(ctx, test.q, SearchOptions{MaxResults: 10, MaxResultCount: 100, Snippets: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("%q: got %d results, want 1", test.q, len(results))
+		}
+		if got := results[0].Snippet; got != test.want {
+			t.Errorf("%q: got snippet %q, want %q", test.q, got, test.want)
+		}
+	}
+}
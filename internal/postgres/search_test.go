@@ -7,6 +7,7 @@ package postgres
 import (
 	"context"
 	"crypto/md5"
+	"database/sql"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/internal/version"
 )
 
 func TestPathTokens(t *testing.T) {
@@ -792,6 +794,207 @@ func TestSearchPenalties(t *testing.T) {
 	}
 }
 
+func TestSearchSynonyms(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/yml", sample.VersionString, "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("example.com/toml", sample.VersionString, "p"))
+
+	for method, searcher := range pkgSearchers {
+		t.Run(method, func(t *testing.T) {
+			res := searcher(testDB, ctx, "yaml", 10, SearchOptions{MaxResultCount: 100})
+			if res.err != nil {
+				t.Fatal(res.err)
+			}
+			var got []string
+			for _, r := range res.results {
+				got = append(got, r.ModulePath)
+			}
+			if want := []string{"example.com/yml"}; !cmp.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSearchGoVersion(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for path, goVersion := range map[string]string{
+		"gov.com/old":     "1.16",
+		"gov.com/new":     "1.20",
+		"gov.com/unknown": "",
+	} {
+		m := sample.Module(path, sample.VersionString, "p")
+		m.GoVersion = goVersion
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	for _, test := range []struct {
+		goVersion string
+		want      []string
+	}{
+		{"", []string{"gov.com/new", "gov.com/old", "gov.com/unknown"}},
+		{"1.18", []string{"gov.com/old", "gov.com/unknown"}},
+		{"1.20", []string{"gov.com/new", "gov.com/old", "gov.com/unknown"}},
+		{"1.15", []string{"gov.com/unknown"}},
+	} {
+		t.Run(test.goVersion, func(t *testing.T) {
+			res, err := testDB.Search(ctx, "gov.com", SearchOptions{MaxResults: 10, MaxResultCount: 100, GoVersion: test.goVersion})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range res {
+				got = append(got, r.ModulePath)
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := testDB.Search(ctx, "gov.com", SearchOptions{MaxResults: 10, GoVersion: "latest"}); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("got error %v, want InvalidArgument", err)
+	}
+}
+
+func TestSearchHasFuzzTargets(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for path, numFuzzTargets := range map[string]int{
+		"fuzz.com/fuzzed":   2,
+		"fuzz.com/tested":   0,
+		"fuzz.com/untested": -1,
+	} {
+		m := sample.Module(path, sample.VersionString, "p")
+		if numFuzzTargets >= 0 {
+			m.Packages()[0].TestStats = &internal.TestStats{NumTestFiles: 1, NumFuzzTargets: numFuzzTargets}
+		}
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	for _, test := range []struct {
+		hasFuzzTargets bool
+		want           []string
+	}{
+		{false, []string{"fuzz.com/fuzzed", "fuzz.com/tested", "fuzz.com/untested"}},
+		{true, []string{"fuzz.com/fuzzed"}},
+	} {
+		t.Run(fmt.Sprint(test.hasFuzzTargets), func(t *testing.T) {
+			res, err := testDB.Search(ctx, "fuzz.com", SearchOptions{MaxResults: 10, MaxResultCount: 100, HasFuzzTargets: test.hasFuzzTargets})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range res {
+				got = append(got, r.ModulePath)
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchPathPrefix(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range []*internal.Module{
+		sample.Module("corp.com/tools", sample.VersionString, "log"),
+		sample.Module("corp.com/tools_extra", sample.VersionString, "log"),
+		sample.Module("other.com/log", sample.VersionString),
+	} {
+		MustInsertModule(ctx, t, testDB, m)
+	}
+
+	for _, test := range []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"corp.com/tools/log", "corp.com/tools_extra/log", "other.com/log"}},
+		{"corp.com/tools", []string{"corp.com/tools/log"}},
+		{"corp.com/tools/log", []string{"corp.com/tools/log"}},
+		{"corp.com/tool", nil},
+	} {
+		t.Run(test.prefix, func(t *testing.T) {
+			res, err := testDB.Search(ctx, "log", SearchOptions{MaxResults: 10, MaxResultCount: 100, PathPrefix: test.prefix})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range res {
+				got = append(got, r.PackagePath)
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchesPathPrefix(t *testing.T) {
+	for _, test := range []struct {
+		path, prefix string
+		want         bool
+	}{
+		{"corp.com/tools/log", "", true},
+		{"corp.com/tools/log", "corp.com/tools", true},
+		{"corp.com/tools/log", "corp.com/tools/log", true},
+		{"corp.com/tools_extra/log", "corp.com/tools", false},
+		{"corp.com/tools", "corp.com/tools/log", false},
+	} {
+		if got := matchesPathPrefix(test.path, test.prefix); got != test.want {
+			t.Errorf("matchesPathPrefix(%q, %q) = %t, want %t", test.path, test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestGoVersionSortKey(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want sql.NullString
+	}{
+		{"", sql.NullString{}},
+		{"latest", sql.NullString{}},
+		{"1.0", sql.NullString{}},
+		{"1.18", sql.NullString{String: version.ForSorting("v1.18.0"), Valid: true}},
+		{"1.21.3", sql.NullString{String: version.ForSorting("v1.21.3"), Valid: true}},
+		{"1.21rc2", sql.NullString{String: version.ForSorting("v1.21.0-rc.2"), Valid: true}},
+	} {
+		if got := goVersionSortKey(test.in); got != test.want {
+			t.Errorf("goVersionSortKey(%q) = %v, want %v", test.in, got, test.want)
+		}
+	}
+	// A module that requires a later patch release is not available at the
+	// minor release.
+	if goVersionSortKey("1.21.3").String <= goVersionSortKey("1.21").String {
+		t.Error("1.21.3 sorts before 1.21")
+	}
+	if goVersionSortKey("1.9").String >= goVersionSortKey("1.18").String {
+		t.Error("1.9 sorts after 1.18")
+	}
+}
+
 func TestExcludedFromSearch(t *testing.T) {
 	// Verify that excluded paths are omitted from search results.
 	t.Parallel()
@@ -1131,6 +1334,17 @@ func TestUpdateSearchDocumentsImportedByCount(t *testing.T) {
 		sdA := validateImportedByCountAndGetSearchDocument(t, testDB, pkgPath(mA), 2)
 		sdC := validateImportedByCountAndGetSearchDocument(t, testDB, pkgPath(mC), 0)
 
+		// The importers of A were just committed, so they count fully
+		// towards its recent count.
+		var recent float64
+		if err := testDB.db.QueryRow(ctx, `SELECT recent_imported_by_count FROM search_documents WHERE package_path = $1`,
+			pkgPath(mA)).Scan(&recent); err != nil {
+			t.Fatal(err)
+		}
+		if recentCountChanged(2, recent) {
+			t.Fatalf("recent_imported_by_count for pkgA = %f, want about 2", recent)
+		}
+
 		// Nothing imports C, so it has never been updated.
 		if !sdC.importedByCountUpdatedAt.IsZero() {
 			t.Fatalf("pkgC imported_by_count_updated_at should be zero, but is %v", sdC.importedByCountUpdatedAt)
@@ -1458,6 +1672,21 @@ func TestGroupSearchResults(t *testing.T) {
 				{Name: "m1", ModulePath: "m1", Version: "v0.0.0", Score: 10, OtherMajor: map[string]int{"m1/v2": 2, "m1/v3": 3}},
 			},
 		},
+		{
+			name: "other majors from deep search",
+			in: []*SearchResult{
+				{Name: "m1", ModulePath: "m1/v3", Version: "v3.0.0", Score: 10, OtherMajor: map[string]int{"m1": 1}},
+				{Name: "m1", ModulePath: "m1/v2", Version: "v2.0.0", Score: 9, OtherMajor: map[string]int{"m1/v3": 3}},
+				{Name: "p", ModulePath: "m1/v3", Version: "v3.0.0", Score: 8, OtherMajor: map[string]int{"m1/v4": 4}},
+			},
+			want: []*SearchResult{
+				{Name: "m1", ModulePath: "m1/v3", Version: "v3.0.0", Score: 10,
+					OtherMajor: map[string]int{"m1": 1, "m1/v2": 2, "m1/v4": 4},
+					SameModule: []*SearchResult{
+						{Name: "p", ModulePath: "m1/v3", Version: "v3.0.0", Score: 8},
+					}},
+			},
+		},
 		{
 			name: "stdlib",
 			in: []*SearchResult{
@@ -1506,6 +1735,81 @@ func TestGroupSearchResults(t *testing.T) {
 	}
 }
 
+func TestDeepSearchMajorVersions(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	MustInsertModule(ctx, t, testDB, sample.Module("majors.com/m", "v1.2.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("majors.com/m/v2", "v2.1.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("majors.com/m/v3", "v3.0.0", "p"))
+	MustInsertModule(ctx, t, testDB, sample.Module("majors.com/other", "v1.0.0", "p"))
+
+	res := testDB.deepSearch(ctx, "majors.com", 10, SearchOptions{MaxResultCount: 100})
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	got := map[string]map[string]int{}
+	for _, r := range res.results {
+		got[r.ModulePath] = r.OtherMajor
+		if r.NumResults != 2 {
+			t.Errorf("%s: NumResults = %d, want 2", r.ModulePath, r.NumResults)
+		}
+	}
+	want := map[string]map[string]int{
+		"majors.com/m/v3":  {"majors.com/m": 1, "majors.com/m/v2": 2},
+		"majors.com/other": nil,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOtherMajorVersions(t *testing.T) {
+	got := otherMajorVersions("m.com/v3",
+		[]string{"m.com", "m.com/v3", "m.com/v2", "gopkg.in/yaml.v2"},
+		[]string{"v0.9.0", "v3.0.0", "v2.1.0", "v2.4.0"})
+	want := map[string]int{"m.com": 0, "m.com/v2": 2, "gopkg.in/yaml.v2": 2}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if got := otherMajorVersions("m.com", []string{"m.com"}, []string{"v1.0.0"}); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestGroupSearchResultsByRepo(t *testing.T) {
+	const repo = "https://go.googlesource.com/tools"
+	in := []*SearchResult{
+		{PackagePath: "golang.org/x/tools/gopls", ModulePath: "golang.org/x/tools/gopls", RepoURL: repo, Score: 10},
+		{PackagePath: "m1", ModulePath: "m1", Score: 9},
+		{PackagePath: "golang.org/x/tools/go/packages", ModulePath: "golang.org/x/tools", RepoURL: repo, Score: 8},
+		{PackagePath: "net", ModulePath: stdlib.ModulePath, RepoURL: "https://go.googlesource.com/go", Score: 7},
+		{PackagePath: "net/http", ModulePath: stdlib.ModulePath, RepoURL: "https://go.googlesource.com/go", Score: 6},
+		{PackagePath: "m2", ModulePath: "m2", Score: 5},
+		{PackagePath: "golang.org/x/tools/cmd/auth", ModulePath: "golang.org/x/tools/cmd/auth", RepoURL: repo, Score: 4},
+	}
+	want := []*SearchResult{
+		{PackagePath: "golang.org/x/tools/gopls", ModulePath: "golang.org/x/tools/gopls", RepoURL: repo, Score: 10, SameRepo: []*SearchResult{
+			{PackagePath: "golang.org/x/tools/go/packages", ModulePath: "golang.org/x/tools", RepoURL: repo, Score: 8},
+			{PackagePath: "golang.org/x/tools/cmd/auth", ModulePath: "golang.org/x/tools/cmd/auth", RepoURL: repo, Score: 4},
+		}},
+		{PackagePath: "m1", ModulePath: "m1", Score: 9},
+		{PackagePath: "net", ModulePath: stdlib.ModulePath, RepoURL: "https://go.googlesource.com/go", Score: 7},
+		{PackagePath: "net/http", ModulePath: stdlib.ModulePath, RepoURL: "https://go.googlesource.com/go", Score: 6},
+		{PackagePath: "m2", ModulePath: "m2", Score: 5},
+	}
+	got := groupSearchResultsByRepo(in)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got)\n%s", diff)
+	}
+	if got, want := numRows(got), len(in); got != want {
+		t.Errorf("numRows = %d, want %d", got, want)
+	}
+}
+
 func TestGroupAndMajorVersion(t *testing.T) {
 	for _, test := range []struct {
 		in         SearchResult
@@ -1539,3 +1843,38 @@ func TestGroupAndMajorVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestImporterWeight(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		commitTime time.Time
+		want       float64
+	}{
+		{now, 1},
+		{now.Add(time.Hour), 1},
+		{now.Add(-importRecencyHalfLife), 0.5},
+		{now.Add(-2 * importRecencyHalfLife), 0.25},
+	} {
+		if got := importerWeight(test.commitTime, now); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("importerWeight(%s, %s) = %f, want %f", test.commitTime, now, got, test.want)
+		}
+	}
+}
+
+func TestRecentCountChanged(t *testing.T) {
+	for _, test := range []struct {
+		cur, new float64
+		want     bool
+	}{
+		{0, 0, false},
+		{0, 0.005, false},
+		{0, 1, true},
+		{100, 100.5, false},
+		{100, 98, true},
+		{100, 0, true},
+	} {
+		if got := recentCountChanged(test.cur, test.new); got != test.want {
+			t.Errorf("recentCountChanged(%f, %f) = %t, want %t", test.cur, test.new, got, test.want)
+		}
+	}
+}
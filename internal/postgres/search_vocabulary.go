@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// UpdateSearchVocabulary recomputes the search_vocabulary table from the
+// package names and import path elements in search_documents. Terms are
+// lower-cased and must be 3 to 40 characters long. It returns the number of
+// terms.
+func (db *DB) UpdateSearchVocabulary(ctx context.Context) (n int64, err error) {
+	defer derrors.WrapStack(&err, "UpdateSearchVocabulary(ctx)")
+
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM search_vocabulary`); err != nil {
+			return err
+		}
+		// UNION removes duplicate terms within a package, like a name that
+		// is also the last element of the import path.
+		n, err = tx.Exec(ctx, `
+			INSERT INTO search_vocabulary (term, num_packages)
+			SELECT term, COUNT(*)
+			FROM (
+				SELECT package_path, lower(name) AS term
+				FROM search_documents
+				UNION
+				SELECT package_path, regexp_split_to_table(lower(package_path), '[/.]')
+				FROM search_documents
+			) t
+			WHERE term ~ '^[a-z][a-z0-9_-]{2,39}$'
+			GROUP BY term`)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// GetSearchVocabulary returns up to limit terms of the search vocabulary that
+// appear in the most packages, mapped to their number of packages.
+func (db *DB) GetSearchVocabulary(ctx context.Context, limit int) (_ map[string]int, err error) {
+	defer derrors.WrapStack(&err, "GetSearchVocabulary(ctx, %d)", limit)
+
+	vocab := map[string]int{}
+	collect := func(rows *sql.Rows) error {
+		var (
+			term string
+			n    int
+		)
+		if err := rows.Scan(&term, &n); err != nil {
+			return err
+		}
+		vocab[term] = n
+		return nil
+	}
+	err = db.db.RunQuery(ctx, `
+		SELECT term, num_packages
+		FROM search_vocabulary
+		ORDER BY num_packages DESC, term
+		LIMIT $1`, collect, limit)
+	if err != nil {
+		return nil, err
+	}
+	return vocab, nil
+}
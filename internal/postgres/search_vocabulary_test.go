@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSearchVocabulary(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, mod := range []string{"github.com/gorilla/mux", "github.com/gorilla/websocket"} {
+		MustInsertModule(ctx, t, testDB, sample.Module(mod, sample.VersionString, ""))
+	}
+	n, err := testDB.UpdateSearchVocabulary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("got %d terms, want 5", n)
+	}
+	got, err := testDB.GetSearchVocabulary(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		"com":     2,
+		"github":  2,
+		"gorilla": 2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
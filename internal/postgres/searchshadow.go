@@ -0,0 +1,238 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// The shadow search index holds the text search tokens of search_documents,
+// as computed by the current code, in its shadow_tsv_path_tokens and
+// shadow_tsv_search_tokens columns. When the way tokens are computed changes,
+// the shadow index is built while search is still served from the old
+// tokens, compared with them on sample queries, and then swapped in by
+// renaming the columns, instead of mixing old and new tokens during a long
+// reindexing.
+//
+// Packages that are upserted after the code changes already get the new
+// tokens. Shadow tokens computed for another version of a package are stale:
+// they are not counted as built, and are replaced by the live tokens of the
+// package when the index is swapped.
+
+// shadowSearchStatement computes the shadow tokens of a package. It has the
+// same arguments as upsertSearchStatement.
+var shadowSearchStatement = fmt.Sprintf(`
+	UPDATE search_documents
+	SET
+		shadow_tsv_path_tokens = %s,
+		shadow_tsv_search_tokens = %s,
+		shadow_indexed_module_path = module_path,
+		shadow_indexed_version = version
+	WHERE
+		package_path = $1
+		AND module_path = $2
+		AND version = $3`,
+	tsvPathTokensExpr,
+	tsvSearchTokensExpr)
+
+// shadowPendingExpr is true for the rows of search_documents sd whose shadow
+// tokens are missing or stale.
+const shadowPendingExpr = `(
+		sd.shadow_indexed_module_path IS DISTINCT FROM sd.module_path
+		OR sd.shadow_indexed_version IS DISTINCT FROM sd.version)`
+
+// shadowSearchDocumentsExpr replaces search_documents in the deep search
+// query to search the shadow index.
+const shadowSearchDocumentsExpr = `(
+		SELECT * FROM search_documents sd WHERE NOT ` + shadowPendingExpr + `
+	) search_documents`
+
+// shadowSwapStatements swap the shadow tokens with the live ones. The
+// indexes on the columns are renamed with them, and the shadow_indexed_*
+// columns are emptied by dropping them, so that the old tokens are pending.
+var shadowSwapStatements = []string{
+	`ALTER TABLE search_documents RENAME COLUMN tsv_path_tokens TO old_tsv_path_tokens`,
+	`ALTER TABLE search_documents RENAME COLUMN shadow_tsv_path_tokens TO tsv_path_tokens`,
+	`ALTER TABLE search_documents RENAME COLUMN old_tsv_path_tokens TO shadow_tsv_path_tokens`,
+	`ALTER TABLE search_documents RENAME COLUMN tsv_search_tokens TO old_tsv_search_tokens`,
+	`ALTER TABLE search_documents RENAME COLUMN shadow_tsv_search_tokens TO tsv_search_tokens`,
+	`ALTER TABLE search_documents RENAME COLUMN old_tsv_search_tokens TO shadow_tsv_search_tokens`,
+	`ALTER INDEX idx_path_documents_tsv_path_tokens RENAME TO idx_old_tsv_path_tokens`,
+	`ALTER INDEX idx_search_documents_shadow_tsv_path_tokens RENAME TO idx_path_documents_tsv_path_tokens`,
+	`ALTER INDEX idx_old_tsv_path_tokens RENAME TO idx_search_documents_shadow_tsv_path_tokens`,
+	`ALTER INDEX idx_search_documents_tsv_search_tokens RENAME TO idx_old_tsv_search_tokens`,
+	`ALTER INDEX idx_search_documents_shadow_tsv_search_tokens RENAME TO idx_search_documents_tsv_search_tokens`,
+	`ALTER INDEX idx_old_tsv_search_tokens RENAME TO idx_search_documents_shadow_tsv_search_tokens`,
+	`ALTER TABLE search_documents
+		DROP COLUMN shadow_indexed_module_path,
+		DROP COLUMN shadow_indexed_version`,
+	`ALTER TABLE search_documents
+		ADD COLUMN shadow_indexed_module_path text,
+		ADD COLUMN shadow_indexed_version text`,
+}
+
+// BuildShadowSearchDocuments computes the shadow tokens of up to limit
+// packages in search_documents that don't have them. It returns the number
+// of packages it processed.
+func (db *DB) BuildShadowSearchDocuments(ctx context.Context, limit int) (n int, err error) {
+	defer derrors.WrapStack(&err, "BuildShadowSearchDocuments(ctx, %d)", limit)
+
+	argsList, err := db.getSearchDocumentArgs(ctx, `
+		WHERE `+shadowPendingExpr+`
+		LIMIT $1`, limit)
+	if err != nil {
+		return 0, err
+	}
+	stmt := shadowSearchStatement
+	if isCockroachDB(db.db) {
+		stmt = crdbShadowSearchStatement
+	}
+	for _, args := range argsList {
+		if _, err := db.db.Exec(ctx, stmt, searchStatementArgs(args)...); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// CountShadowSearchDocuments returns the number of packages in
+// search_documents, and the number of those that don't have shadow tokens.
+func (db *DB) CountShadowSearchDocuments(ctx context.Context) (total, pending int, err error) {
+	defer derrors.WrapStack(&err, "CountShadowSearchDocuments(ctx)")
+
+	err = db.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE `+shadowPendingExpr+`)
+		FROM search_documents sd`).Scan(&total, &pending)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, pending, nil
+}
+
+// SampleSearchQueries returns up to n random terms of the search vocabulary
+// that occur in more than one package, to compare the shadow index with the
+// live one.
+func (db *DB) SampleSearchQueries(ctx context.Context, n int) (_ []string, err error) {
+	defer derrors.WrapStack(&err, "SampleSearchQueries(ctx, %d)", n)
+
+	return database.Collect1[string](ctx, db.db, `
+		SELECT term
+		FROM search_vocabulary
+		WHERE num_packages > 1
+		ORDER BY random()
+		LIMIT $1`, n)
+}
+
+// A ShadowSearchComparison compares the results of a query in the live
+// search index and in the shadow one.
+type ShadowSearchComparison struct {
+	Query string
+	// Live and Shadow are the package paths of the results.
+	Live, Shadow []string
+	// Overlap is the number of packages in both lists of results, divided by
+	// the length of the longer one. It is 1 if both are empty.
+	Overlap float64
+}
+
+// CompareShadowSearch runs each query with deep search on the live and the
+// shadow indexes, and compares their first limit results.
+func (db *DB) CompareShadowSearch(ctx context.Context, queries []string, limit int) (_ []*ShadowSearchComparison, err error) {
+	defer derrors.WrapStack(&err, "CompareShadowSearch(ctx, %d queries, %d)", len(queries), limit)
+
+	var cs []*ShadowSearchComparison
+	for _, q := range queries {
+		c := &ShadowSearchComparison{Query: q}
+		for _, shadow := range []bool{false, true} {
+			resp := db.deepSearch(ctx, q, limit, SearchOptions{MaxResultCount: limit, shadow: shadow})
+			if resp.err != nil {
+				return nil, resp.err
+			}
+			var paths []string
+			for _, r := range resp.results {
+				paths = append(paths, r.PackagePath)
+			}
+			if shadow {
+				c.Shadow = paths
+			} else {
+				c.Live = paths
+			}
+		}
+		c.Overlap = overlap(c.Live, c.Shadow)
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// overlap returns the number of strings in both a and b, divided by the
+// length of the longer one, or 1 if both are empty.
+func overlap(a, b []string) float64 {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 1
+	}
+	inA := map[string]bool{}
+	for _, s := range a {
+		inA[s] = true
+	}
+	common := 0
+	for _, s := range b {
+		if inA[s] {
+			common++
+		}
+	}
+	return float64(common) / float64(n)
+}
+
+// SwapShadowSearchDocuments replaces the text search tokens of the packages
+// in search_documents with their shadow tokens, in a single transaction, so
+// that searches see either the old index or the new one. The columns are
+// swapped by renaming them, so rows are only written for the packages whose
+// shadow tokens are missing or stale, which get their live tokens. Upserts
+// wait for the swap; searches only wait for the renames. It returns the
+// number of packages whose tokens were replaced.
+func (db *DB) SwapShadowSearchDocuments(ctx context.Context) (n int, err error) {
+	defer derrors.WrapStack(&err, "SwapShadowSearchDocuments(ctx)")
+
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		if !isCockroachDB(tx) {
+			// Keep upserts from changing the rows between the copy of the
+			// pending tokens and the renames.
+			if _, err := tx.Exec(ctx, `LOCK TABLE search_documents IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+				return err
+			}
+		}
+		if err := tx.QueryRow(ctx, `
+			SELECT COUNT(*) FROM search_documents sd WHERE NOT `+shadowPendingExpr).Scan(&n); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE search_documents sd
+			SET
+				shadow_tsv_path_tokens = tsv_path_tokens,
+				shadow_tsv_search_tokens = tsv_search_tokens
+			WHERE `+shadowPendingExpr); err != nil {
+			return err
+		}
+		for _, stmt := range shadowSwapStatements {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestShadowSearchDocuments(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, mod := range []string{"github.com/gorilla/mux", "github.com/gorilla/websocket"} {
+		MustInsertModule(ctx, t, testDB, sample.Module(mod, sample.VersionString, ""))
+	}
+	// Simulate an index built by code that computed no search tokens.
+	if _, err := testDB.db.Exec(ctx, `UPDATE search_documents SET tsv_search_tokens = ''::tsvector`); err != nil {
+		t.Fatal(err)
+	}
+
+	checkCounts := func(wantPending int) {
+		t.Helper()
+		total, pending, err := testDB.CountShadowSearchDocuments(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 2 || pending != wantPending {
+			t.Errorf("got %d pending of %d, want %d of 2", pending, total, wantPending)
+		}
+	}
+	checkCounts(2)
+	for _, want := range []int{1, 1, 0} {
+		n, err := testDB.BuildShadowSearchDocuments(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("built %d, want %d", n, want)
+		}
+	}
+	checkCounts(0)
+
+	cs, err := testDB.CompareShadowSearch(ctx, []string{"gorilla"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ShadowSearchComparison{{
+		Query:   "gorilla",
+		Shadow:  []string{"github.com/gorilla/mux", "github.com/gorilla/websocket"},
+		Overlap: 0,
+	}}
+	if diff := cmp.Diff(want, cs); diff != "" {
+		t.Errorf("CompareShadowSearch mismatch (-want +got):\n%s", diff)
+	}
+
+	n, err := testDB.SwapShadowSearchDocuments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("swapped %d, want 2", n)
+	}
+	resp := testDB.deepSearch(ctx, "gorilla", 10, SearchOptions{MaxResultCount: 10})
+	if resp.err != nil {
+		t.Fatal(resp.err)
+	}
+	if got := len(resp.results); got != 2 {
+		t.Errorf("got %d search results after swap, want 2", got)
+	}
+	checkCounts(2)
+}
+
+func TestOverlap(t *testing.T) {
+	for _, test := range []struct {
+		a, b []string
+		want float64
+	}{
+		{nil, nil, 1},
+		{[]string{"a"}, nil, 0},
+		{[]string{"a", "b"}, []string{"b", "a"}, 1},
+		{[]string{"a", "b", "c", "d"}, []string{"a", "e"}, 0.5},
+	} {
+		if got := overlap(test.a, test.b); got != test.want {
+			t.Errorf("overlap(%q, %q) = %g, want %g", test.a, test.b, got, test.want)
+		}
+	}
+}
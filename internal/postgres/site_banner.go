@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetSiteBanner returns the message of the site-wide banner, or the empty
+// string if there is none.
+func (db *DB) GetSiteBanner(ctx context.Context) (_ string, err error) {
+	defer derrors.WrapStack(&err, "GetSiteBanner(ctx)")
+
+	var msg string
+	err = db.db.QueryRow(ctx, `SELECT message FROM site_banner`).Scan(&msg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return msg, nil
+}
+
+// SetSiteBanner sets the message of the site-wide banner. If msg is empty,
+// the banner is removed.
+func (db *DB) SetSiteBanner(ctx context.Context, msg string) (err error) {
+	defer derrors.WrapStack(&err, "SetSiteBanner(ctx, %q)", msg)
+
+	if msg == "" {
+		_, err = db.db.Exec(ctx, `DELETE FROM site_banner`)
+		return err
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO site_banner (message) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET message = excluded.message`, msg)
+	return err
+}
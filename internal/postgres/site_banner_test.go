@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSiteBanner(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	check := func(want string) {
+		t.Helper()
+		got, err := testDB.GetSiteBanner(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	check("")
+	for _, msg := range []string{"Maintenance tonight.", "Maintenance tomorrow."} {
+		if err := testDB.SetSiteBanner(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+		check(msg)
+	}
+	if err := testDB.SetSiteBanner(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	check("")
+}
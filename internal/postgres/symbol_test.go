@@ -614,3 +614,73 @@ func TestDeleteOldSymbolSearchDocuments(t *testing.T) {
 	// Older version inserted, no effect.
 	checkRows(t, "v1.0.0", api2)
 }
+
+func TestGetDocumentationSymbols(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	mod := sample.DefaultModule()
+	api := []*internal.Symbol{sample.Function, sample.Type}
+	mod.Packages()[0].Documentation[0].API = api
+	MustInsertModule(ctx, t, testDB, mod)
+
+	got, err := testDB.GetDocumentationSymbols(ctx, mod.Packages()[0].Path, mod.ModulePath, mod.Version, internal.BuildContextAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.SymbolMeta{&sample.Function.SymbolMeta, &sample.Type.SymbolMeta}
+	want = append(want, sample.Type.Children...)
+	sort.Slice(want, func(i, j int) bool { return want[i].Name < want[j].Name })
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetModuleSymbols(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	withBuildContext := func(s *internal.Symbol, bc internal.BuildContext) *internal.Symbol {
+		s2 := *s
+		s2.GOOS = bc.GOOS
+		s2.GOARCH = bc.GOARCH
+		return &s2
+	}
+	mod := sample.Module("example.com/m", "v1.0.0", "a", "b")
+	pkgs := mod.Packages()
+	var docs []*internal.Documentation
+	for _, bc := range []internal.BuildContext{internal.BuildContextWindows, internal.BuildContextLinux} {
+		doc := sample.Documentation(bc.GOOS, bc.GOARCH, sample.DocContents)
+		doc.API = []*internal.Symbol{withBuildContext(sample.Function, bc)}
+		docs = append(docs, doc)
+	}
+	pkgs[0].Documentation = docs
+	pkgs[1].Documentation[0].API = []*internal.Symbol{sample.Variable}
+	MustInsertModule(ctx, t, testDB, mod)
+
+	got, err := testDB.GetModuleSymbols(ctx, mod.ModulePath, mod.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ModuleSymbol{
+		{
+			PackagePath:   "example.com/m/a",
+			SymbolMeta:    sample.Function.SymbolMeta,
+			BuildContexts: []internal.BuildContext{internal.BuildContextLinux, internal.BuildContextWindows},
+		},
+		{
+			PackagePath:   "example.com/m/b",
+			SymbolMeta:    sample.Variable.SymbolMeta,
+			BuildContexts: []internal.BuildContext{internal.BuildContextAll},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal/database"
@@ -100,20 +101,29 @@ func (db *DB) symbolSearch(ctx context.Context, q string, limit int, opts Search
 	defer middleware.ElapsedStat(ctx, "symbolSearch")()
 
 	var (
-		results []*SearchResult
-		err     error
+		results    []*SearchResult
+		incomplete bool
+		err        error
 	)
 	sr := searchResponse{source: "symbol"}
 	it := search.ParseInputType(q)
 	switch it {
 	case search.InputTypeOneDot:
-		results, err = runSymbolSearchOneDot(ctx, db.db, q, limit)
+		results, incomplete, err = runSymbolSearchOneDot(ctx, db.db, q, limit, opts.SymbolTimeout)
 	case search.InputTypeMultiWord:
-		results, err = runSymbolSearchMultiWord(ctx, db.db, q, limit, opts.SymbolFilter)
+		results, incomplete, err = runSymbolSearchMultiWord(ctx, db.db, q, limit, opts.SymbolFilter, opts.SymbolTimeout)
 	case search.InputTypeNoDot:
 		results, err = runSymbolSearch(ctx, db.db, search.SearchTypeSymbol, q, limit)
 	case search.InputTypeTwoDots:
 		results, err = runSymbolSearchPackageDotSymbol(ctx, db.db, q, limit)
+	case search.InputTypePathScoped:
+		results, err = runSymbolSearchPathScoped(ctx, db.db, q, limit)
+		if err == nil && len(results) == 0 {
+			// The path may be part of a path rather than a package or
+			// module path, as in "module_name/foo Function", so search
+			// with it as path tokens instead.
+			results, incomplete, err = runSymbolSearchMultiWord(ctx, db.db, q, limit, opts.SymbolFilter, opts.SymbolTimeout)
+		}
 	default:
 		// There is no supported situation where we will get results for one
 		// element containing more than 2 dots.
@@ -146,6 +156,7 @@ func (db *DB) symbolSearch(ctx context.Context, q string, limit int, opts Search
 	}
 	for _, r := range results {
 		r.NumResults = uint64(len(results))
+		r.Incomplete = incomplete
 	}
 	sr.results = results
 	return sr
@@ -153,33 +164,59 @@ func (db *DB) symbolSearch(ctx context.Context, q string, limit int, opts Search
 
 // runSymbolSearchMultiWord executes a symbol search for SearchTypeMultiWord.
 func runSymbolSearchMultiWord(ctx context.Context, ddb *database.DB, q string, limit int,
-	symbolFilter string) (_ []*SearchResult, err error) {
-	defer derrors.Wrap(&err, "runSymbolSearchMultiWord(ctx, ddb, query, %q, %d, %q)",
-		q, limit, symbolFilter)
+	symbolFilter string, timeout time.Duration) (_ []*SearchResult, incomplete bool, err error) {
+	defer derrors.Wrap(&err, "runSymbolSearchMultiWord(ctx, ddb, query, %q, %d, %q, %s)",
+		q, limit, symbolFilter, timeout)
 	defer middleware.ElapsedStat(ctx, "runSymbolSearchMultiWord")()
 
 	symbolToPathTokens := multiwordSearchCombinations(q, symbolFilter)
 	if len(symbolToPathTokens) == 0 {
 		// There are no words in the query that could be a symbol name.
-		return nil, derrors.NotFound
+		return nil, false, derrors.NotFound
 	}
 	if strings.Contains(q, "|") {
 		// TODO(golang/go#44142): The search.SearchTypeMultiWordOr case
 		// is currently not supported.
-		return nil, derrors.NotFound
+		return nil, false, derrors.NotFound
 	}
-	group, searchCtx := errgroup.WithContext(ctx)
-	resultsArray := make([][]*SearchResult, len(symbolToPathTokens))
-	count := 0
+	var searches []func(context.Context) ([]*SearchResult, error)
 	for symbol, pathTokens := range symbolToPathTokens {
 		symbol := symbol
 		pathTokens := pathTokens
-		i := count
-		count += 1
+		searches = append(searches, func(ctx context.Context) ([]*SearchResult, error) {
+			return runSymbolSearch(ctx, ddb, search.SearchTypeMultiWordExact, symbol, limit, pathTokens)
+		})
+	}
+	return runParallelSymbolSearches(ctx, searches, limit, timeout)
+}
+
+// runParallelSymbolSearches runs searches concurrently and merges their
+// results. If timeout is positive, the searches that have not finished after
+// it are cancelled, and the merged results of the others are returned with
+// incomplete set. It is an error for all the searches to time out.
+func runParallelSymbolSearches(ctx context.Context, searches []func(context.Context) ([]*SearchResult, error),
+	limit int, timeout time.Duration) (_ []*SearchResult, incomplete bool, err error) {
+	timeoutCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	group, searchCtx := errgroup.WithContext(timeoutCtx)
+	resultsArray := make([][]*SearchResult, len(searches))
+	timeoutErrs := make([]error, len(searches))
+	for i, s := range searches {
+		i := i
+		s := s
 		group.Go(func() error {
-			st := search.SearchTypeMultiWordExact
-			r, err := runSymbolSearch(searchCtx, ddb, st, symbol, limit, pathTokens)
+			r, err := s(searchCtx)
 			if err != nil {
+				// Only the timeout of this function leads to partial
+				// results; that of ctx fails the search.
+				if ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+					timeoutErrs[i] = err
+					return nil
+				}
 				return err
 			}
 			resultsArray[i] = r
@@ -187,9 +224,18 @@ func runSymbolSearchMultiWord(ctx context.Context, ddb *database.DB, q string, l
 		})
 	}
 	if err := group.Wait(); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	numTimedOut := 0
+	for _, err := range timeoutErrs {
+		if err != nil {
+			numTimedOut++
+		}
 	}
-	return mergedResults(resultsArray, limit), nil
+	if numTimedOut == len(searches) {
+		return nil, false, fmt.Errorf("all searches timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	return mergedResults(resultsArray, limit), numTimedOut > 0, nil
 }
 
 func mergedResults(resultsArray [][]*SearchResult, limit int) []*SearchResult {
@@ -257,39 +303,18 @@ func multiwordSearchCombinations(q, symbolFilter string) map[string]string {
 //
 // This search is split into two parallel queries, since the query is very slow
 // when using an OR in the WHERE clause.
-func runSymbolSearchOneDot(ctx context.Context, ddb *database.DB, q string, limit int) (_ []*SearchResult, err error) {
-	defer derrors.Wrap(&err, "runSymbolSearchOneDot(ctx, ddb, %q, %d)", q, limit)
+func runSymbolSearchOneDot(ctx context.Context, ddb *database.DB, q string, limit int, timeout time.Duration) (_ []*SearchResult, incomplete bool, err error) {
+	defer derrors.Wrap(&err, "runSymbolSearchOneDot(ctx, ddb, %q, %d, %s)", q, limit, timeout)
 	defer middleware.ElapsedStat(ctx, "runSymbolSearchOneDot")()
 
-	group, searchCtx := errgroup.WithContext(ctx)
-	resultsArray := make([][]*SearchResult, 2)
-	for i, st := range []search.SearchType{
-		search.SearchTypeSymbol,
-		search.SearchTypePackageDotSymbol,
-	} {
-		i := i
-		st := st
-		group.Go(func() error {
-			var (
-				results []*SearchResult
-				err     error
-			)
-			if st == search.SearchTypePackageDotSymbol {
-				results, err = runSymbolSearchPackageDotSymbol(searchCtx, ddb, q, limit)
-			} else {
-				results, err = runSymbolSearch(searchCtx, ddb, st, q, limit)
-			}
-			if err != nil {
-				return err
-			}
-			resultsArray[i] = results
-			return nil
-		})
-	}
-	if err := group.Wait(); err != nil {
-		return nil, err
-	}
-	return mergedResults(resultsArray, limit), nil
+	return runParallelSymbolSearches(ctx, []func(context.Context) ([]*SearchResult, error){
+		func(ctx context.Context) ([]*SearchResult, error) {
+			return runSymbolSearch(ctx, ddb, search.SearchTypeSymbol, q, limit)
+		},
+		func(ctx context.Context) ([]*SearchResult, error) {
+			return runSymbolSearchPackageDotSymbol(ctx, ddb, q, limit)
+		},
+	}, limit, timeout)
 }
 
 func runSymbolSearchPackageDotSymbol(ctx context.Context, ddb *database.DB, q string, limit int) (_ []*SearchResult, err error) {
@@ -300,6 +325,17 @@ func runSymbolSearchPackageDotSymbol(ctx context.Context, ddb *database.DB, q st
 	return runSymbolSearch(ctx, ddb, search.SearchTypePackageDotSymbol, symbol, limit, pkg)
 }
 
+// runSymbolSearchPathScoped is used when q is a package or module path
+// followed by a symbol name. Only the packages with that path or module path
+// are searched.
+func runSymbolSearchPathScoped(ctx context.Context, ddb *database.DB, q string, limit int) (_ []*SearchResult, err error) {
+	path, symbol, ok := search.ParsePathScopedQuery(q)
+	if !ok {
+		return nil, derrors.NotFound
+	}
+	return runSymbolSearch(ctx, ddb, search.SearchTypePathScoped, symbol, limit, path)
+}
+
 func splitPackageAndSymbolNames(q string) (pkgName string, symbolName string, err error) {
 	parts := strings.Split(q, ".")
 	if len(parts) != 2 && len(parts) != 3 {
@@ -6,8 +6,10 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal"
@@ -99,6 +101,16 @@ func TestSymbolSearch(t *testing.T) {
 			q:    "module_name/foo function",
 			want: checkResult(sample.Function.SymbolMeta),
 		},
+		{
+			name: "test search by <package-path> <identifier>",
+			q:    sample.PackagePath + " function",
+			want: checkResult(sample.Function.SymbolMeta),
+		},
+		{
+			name: "test search by <module-path> <type>.<methodName>",
+			q:    sample.ModulePath + " Type.Method",
+			want: checkResult(sample.Method),
+		},
 		{
 			name: "test invalid to_tsquery input returns no results instead of error",
 			q:    "foo:function",
@@ -203,3 +215,49 @@ func TestMultiwordSearchCombinations(t *testing.T) {
 		})
 	}
 }
+
+func TestRunParallelSymbolSearches(t *testing.T) {
+	ctx := context.Background()
+	found := func(ctx context.Context) ([]*SearchResult, error) {
+		return []*SearchResult{{PackagePath: "a.com/p", SymbolName: "Foo", NumImportedBy: 1}}, nil
+	}
+	slow := func(ctx context.Context) ([]*SearchResult, error) {
+		<-ctx.Done()
+		return nil, errors.New("pq: canceling statement due to user request")
+	}
+	failed := func(ctx context.Context) ([]*SearchResult, error) {
+		return nil, errors.New("bad query")
+	}
+	type search = func(context.Context) ([]*SearchResult, error)
+
+	t.Run("complete", func(t *testing.T) {
+		got, incomplete, err := runParallelSymbolSearches(ctx, []search{found, found}, 10, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || incomplete {
+			t.Errorf("got %d results, incomplete=%t; want 1, false", len(got), incomplete)
+		}
+	})
+	t.Run("partial", func(t *testing.T) {
+		got, incomplete, err := runParallelSymbolSearches(ctx, []search{found, slow}, 10, 10*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || !incomplete {
+			t.Errorf("got %d results, incomplete=%t; want 1, true", len(got), incomplete)
+		}
+	})
+	t.Run("all timed out", func(t *testing.T) {
+		_, _, err := runParallelSymbolSearches(ctx, []search{slow, slow}, 10, 10*time.Millisecond)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want DeadlineExceeded", err)
+		}
+	})
+	t.Run("failed", func(t *testing.T) {
+		_, _, err := runParallelSymbolSearches(ctx, []search{found, failed}, 10, time.Minute)
+		if err == nil || !strings.Contains(err.Error(), "bad query") {
+			t.Errorf("got %v, want bad query error", err)
+		}
+	})
+}
@@ -41,6 +41,25 @@ func (db *DB) GetUnitMeta(ctx context.Context, fullPath, requestedModulePath, re
 	defer derrors.WrapStack(&err, "DB.GetUnitMeta(ctx, %q, %q, %q)", fullPath, requestedModulePath, requestedVersion)
 	defer middleware.ElapsedStat(ctx, "DB.GetUnitMeta")()
 
+	key := fmt.Sprintf("GetUnitMeta %s %s %s", fullPath, requestedModulePath, requestedVersion)
+	v, err := db.coalesce(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return db.getUnitMeta(ctx, fullPath, requestedModulePath, requestedVersion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyUnitMeta(v.(*internal.UnitMeta)), nil
+}
+
+// copyUnitMeta returns a copy of um that can be changed without affecting the
+// other callers that share um through coalesce.
+func copyUnitMeta(um *internal.UnitMeta) *internal.UnitMeta {
+	c := *um
+	c.Licenses = copySlice(um.Licenses)
+	return &c
+}
+
+func (db *DB) getUnitMeta(ctx context.Context, fullPath, requestedModulePath, requestedVersion string) (_ *internal.UnitMeta, err error) {
 	modulePath := requestedModulePath
 	v := requestedVersion
 	var lmv *internal.LatestModuleVersions
@@ -247,6 +266,66 @@ func (db *DB) getLatestUnitVersion(ctx context.Context, fullPath, requestedModul
 func (db *DB) GetUnit(ctx context.Context, um *internal.UnitMeta, fields internal.FieldSet, bc internal.BuildContext) (_ *internal.Unit, err error) {
 	defer derrors.WrapStack(&err, "GetUnit(ctx, %q, %q, %q, %v)", um.Path, um.ModulePath, um.Version, bc)
 
+	key := fmt.Sprintf("GetUnit %s %s %s %d %v", um.Path, um.ModulePath, um.Version, fields, bc)
+	v, err := db.coalesce(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return db.getUnit(ctx, um, fields, bc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyUnit(v.(*internal.Unit)), nil
+}
+
+// copyUnit returns a copy of u that can be changed without affecting the
+// other callers that share u through coalesce. The slices and maps of u are
+// copied, as are the Documentation and Readme values, which callers change in
+// place. Other values that u points to are shared, and must not be changed.
+func copyUnit(u *internal.Unit) *internal.Unit {
+	c := *u
+	c.UnitMeta = *copyUnitMeta(&u.UnitMeta)
+	if u.Readme != nil {
+		r := *u.Readme
+		c.Readme = &r
+	}
+	if u.Documentation != nil {
+		c.Documentation = make([]*internal.Documentation, len(u.Documentation))
+		for i, d := range u.Documentation {
+			dc := *d
+			dc.API = copySlice(d.API)
+			c.Documentation[i] = &dc
+		}
+	}
+	c.BuildContexts = copySlice(u.BuildContexts)
+	c.Subdirectories = copySlice(u.Subdirectories)
+	c.Imports = copySlice(u.Imports)
+	c.LicenseContents = copySlice(u.LicenseContents)
+	c.Benchmarks = copySlice(u.Benchmarks)
+	c.FuzzTargets = copySlice(u.FuzzTargets)
+	c.Examples = copySlice(u.Examples)
+	if u.Symbols != nil {
+		c.Symbols = make(map[internal.BuildContext][]*internal.Symbol, len(u.Symbols))
+		for bc, syms := range u.Symbols {
+			c.Symbols[bc] = copySlice(syms)
+		}
+	}
+	if u.SymbolHistory != nil {
+		c.SymbolHistory = make(map[string]string, len(u.SymbolHistory))
+		for k, v := range u.SymbolHistory {
+			c.SymbolHistory[k] = v
+		}
+	}
+	return &c
+}
+
+// copySlice returns a shallow copy of s, or nil if s is nil.
+func copySlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func (db *DB) getUnit(ctx context.Context, um *internal.UnitMeta, fields internal.FieldSet, bc internal.BuildContext) (_ *internal.Unit, err error) {
 	u := &internal.Unit{UnitMeta: *um}
 	if fields&internal.WithMain != 0 {
 		u, err = db.getUnitWithAllFields(ctx, um, bc)
@@ -473,6 +552,8 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 			r.contents,
 			d.synopsis,
 			d.source,
+			COALESCE(d.truncated, false),
+			d.content_hash,
 			COALESCE((
 				SELECT COUNT(unit_id)
 				FROM imports
@@ -485,13 +566,19 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 				-- Only package_path_id is needed b/c it is the PK for
 				-- search_documents.
 				WHERE package_path_id = $1
-				), 0) AS num_imported_by
+				), 0) AS num_imported_by,
+			u.num_test_files,
+			COALESCE(u.num_tests, 0),
+			COALESCE(u.num_benchmarks, 0),
+			COALESCE(u.num_fuzz_targets, 0),
+			COALESCE(u.num_examples, 0),
+			u.benchmarks
 		FROM units u
 		LEFT JOIN readmes r
 		ON r.unit_id = u.id
 
 		LEFT JOIN (
-			SELECT synopsis, source, goos, goarch, unit_id
+			SELECT synopsis, source, truncated, content_hash, goos, goarch, unit_id
 			FROM documentation d
 			WHERE d.GOOS = $3 AND d.GOARCH = $4
         ) d
@@ -499,8 +586,11 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 		WHERE u.id = $2
 	`
 	var (
-		r internal.Readme
-		u internal.Unit
+		r  internal.Readme
+		u  internal.Unit
+		ts internal.TestStats
+		// The test counts are NULL if the unit has no tests.
+		numTestFiles sql.NullInt64
 	)
 	u.BuildContexts = bcs
 	var goos, goarch interface{}
@@ -515,13 +605,25 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 		database.NullIsEmpty(&r.Contents),
 		database.NullIsEmpty(&doc.Synopsis),
 		&doc.Source,
+		&doc.Truncated,
+		database.NullIsEmpty(&doc.ContentHash),
 		&u.NumImports,
 		&u.NumImportedBy,
+		&numTestFiles,
+		&ts.NumTests,
+		&ts.NumBenchmarks,
+		&ts.NumFuzzTargets,
+		&ts.NumExamples,
+		pq.Array(&u.Benchmarks),
 	)
 	switch err {
 	case sql.ErrNoRows:
 		// Neither a README nor documentation; that's OK, continue.
 	case nil:
+		if numTestFiles.Valid {
+			ts.NumTestFiles = int(numTestFiles.Int64)
+			u.TestStats = &ts
+		}
 		if r.Filepath != "" && um.ModulePath != stdlib.ModulePath {
 			u.Readme = &r
 		}
@@ -539,6 +641,12 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 	}
 	u.Subdirectories = pkgs
 	u.UnitMeta = *um
+	if u.TestStats != nil && u.TestStats.NumFuzzTargets > 0 {
+		u.FuzzTargets, err = getFuzzTargets(ctx, db.db, unitID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if um.IsPackage() && !um.IsCommand() && doc.Source != nil {
 		u.SymbolHistory, err = GetSymbolHistoryForBuildContext(ctx, db.db, pathID, um.ModulePath, bcMatched)
@@ -549,6 +657,31 @@ func (db *DB) getUnitWithAllFields(ctx context.Context, um *internal.UnitMeta, b
 	return &u, nil
 }
 
+// getFuzzTargets returns the fuzz targets of the unit with the given ID,
+// sorted by name.
+func getFuzzTargets(ctx context.Context, db *database.DB, unitID int) (_ []*internal.FuzzTarget, err error) {
+	defer derrors.WrapStack(&err, "getFuzzTargets(%d)", unitID)
+
+	var targets []*internal.FuzzTarget
+	err = db.RunQuery(ctx, `
+		SELECT name, num_seeds
+		FROM fuzz_targets
+		WHERE unit_id = $1
+		ORDER BY name`,
+		func(rows *sql.Rows) error {
+			var t internal.FuzzTarget
+			if err := rows.Scan(&t.Name, &t.NumSeeds); err != nil {
+				return err
+			}
+			targets = append(targets, &t)
+			return nil
+		}, unitID)
+	if err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
 type dbPath struct {
 	id              int64
 	path            string
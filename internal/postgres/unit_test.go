@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -782,6 +783,109 @@ func TestGetUnit_SubdirectoriesShowNonRedistPackages(t *testing.T) {
 	MustInsertModule(ctx, t, testDB, m)
 }
 
+func TestGetUnitTestStatsAndFuzzTargets(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "a", "b")
+	want := &internal.TestStats{NumTestFiles: 3, NumTests: 42, NumBenchmarks: 3, NumFuzzTargets: 2, NumExamples: 10}
+	wantFuzz := []*internal.FuzzTarget{{Name: "FuzzA", NumSeeds: 3}, {Name: "FuzzB"}}
+	m.Packages()[0].TestStats = want
+	m.Packages()[0].FuzzTargets = wantFuzz
+	wantBench := []string{"BenchmarkA", "BenchmarkB", "BenchmarkC"}
+	m.Packages()[0].Benchmarks = wantBench
+	MustInsertModule(ctx, t, testDB, m)
+	// Reinserting the module replaces its fuzz targets.
+	MustInsertModule(ctx, t, testDB, m)
+
+	for _, test := range []struct {
+		path      string
+		want      *internal.TestStats
+		wantFuzz  []*internal.FuzzTarget
+		wantBench []string
+	}{
+		{m.Packages()[0].Path, want, wantFuzz, wantBench},
+		{m.Packages()[1].Path, nil, nil, nil},
+	} {
+		um, err := testDB.GetUnitMeta(ctx, test.path, m.ModulePath, m.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := testDB.GetUnit(ctx, um, internal.AllFields, internal.BuildContext{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got.TestStats); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.path, diff)
+		}
+		if diff := cmp.Diff(test.wantFuzz, got.FuzzTargets); diff != "" {
+			t.Errorf("%s: fuzz targets mismatch (-want +got):\n%s", test.path, diff)
+		}
+		if diff := cmp.Diff(test.wantBench, got.Benchmarks); diff != "" {
+			t.Errorf("%s: benchmarks mismatch (-want +got):\n%s", test.path, diff)
+		}
+	}
+}
+
+func TestGetUnitConcurrent(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "a")
+	MustInsertModule(ctx, t, testDB, m)
+	um, err := testDB.GetUnitMeta(ctx, m.Packages()[0].Path, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lookups that are coalesced must not share values that callers change,
+	// as the frontend does with the build context of the documentation. Run
+	// with -race to detect sharing.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := testDB.GetUnit(ctx, um, internal.AllFields, internal.BuildContext{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for _, d := range u.Documentation {
+				d.GOOS = internal.All
+				d.GOARCH = internal.All
+			}
+			u.Licenses = append(u.Licenses[:0], nil)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCopyUnit(t *testing.T) {
+	u := &internal.Unit{
+		UnitMeta:      internal.UnitMeta{Licenses: sample.LicenseMetadata()},
+		Readme:        &internal.Readme{Contents: "readme"},
+		Documentation: []*internal.Documentation{{GOOS: "linux", GOARCH: "amd64"}},
+		Imports:       []string{"fmt"},
+		SymbolHistory: map[string]string{"F": "v1.0.0"},
+	}
+	want := copyUnit(u)
+	got := copyUnit(u)
+	got.Licenses[0] = nil
+	got.Readme.Contents = "changed"
+	got.Documentation[0].GOOS = internal.All
+	got.Imports[0] = "os"
+	got.SymbolHistory["F"] = "v2.0.0"
+	if diff := cmp.Diff(want, u); diff != "" {
+		t.Errorf("changing a copy changed the original (-want +got):\n%s", diff)
+	}
+}
+
 func TestGetUnitFieldSet(t *testing.T) {
 	t.Parallel()
 	testDB, release := acquire(t)
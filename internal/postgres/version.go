@@ -464,6 +464,8 @@ func rawIsMoreRecent(v1, v2 string) bool {
 // UpdateLatestModuleVersions upserts its argument into the latest_module_versions table
 // if the row doesn't exist, or the new version is later.
 // It returns the version that is in the DB when it completes.
+// When it updates the row, it also updates the redirect derived from the
+// deprecation comment of the module, if any.
 func (db *DB) UpdateLatestModuleVersions(ctx context.Context, vNew *internal.LatestModuleVersions) (_ *internal.LatestModuleVersions, err error) {
 	defer derrors.WrapStack(&err, "UpdateLatestModuleVersions(%q)", vNew.ModulePath)
 
@@ -504,7 +506,10 @@ func (db *DB) UpdateLatestModuleVersions(ctx context.Context, vNew *internal.Lat
 			}
 		}
 		vResult = vNew
-		return upsertLatestModuleVersions(ctx, tx, vNew.ModulePath, id, vNew, 200)
+		if err := upsertLatestModuleVersions(ctx, tx, vNew.ModulePath, id, vNew, 200); err != nil {
+			return err
+		}
+		return updateDeprecationRedirect(ctx, tx, vNew)
 	})
 	if err != nil {
 		return nil, err
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/version"
 )
 
@@ -131,7 +132,7 @@ func (db *DB) UpdateModuleVersionState(ctx context.Context, mvs *ModuleVersionSt
 		n := len(mvs.PackageVersionStates)
 		numPackages = &n
 	}
-	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
 		if err := updateModuleVersionState(ctx, tx, numPackages, mvs); err != nil {
 			return err
 		}
@@ -144,6 +145,26 @@ func (db *DB) UpdateModuleVersionState(ctx context.Context, mvs *ModuleVersionSt
 		}
 		return upsertPackageVersionStates(ctx, tx, mvs.PackageVersionStates)
 	})
+	if err != nil {
+		return err
+	}
+	// Count the version outside of the transaction, so that concurrent
+	// fetches only wait on the counter for one statement.
+	if err := countProcessedVersion(ctx, db.db); err != nil {
+		log.Errorf(ctx, "counting processed version %s@%s: %v", mvs.ModulePath, mvs.Version, err)
+	}
+	return nil
+}
+
+// countProcessedVersion adds one to the number of module versions processed
+// today.
+func countProcessedVersion(ctx context.Context, db *database.DB) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO daily_processed_versions (date, num_processed)
+		VALUES (CURRENT_DATE, 1)
+		ON CONFLICT (date)
+		DO UPDATE SET num_processed = daily_processed_versions.num_processed + 1`)
+	return err
 }
 
 func updateModuleVersionState(ctx context.Context, db *database.DB, numPackages *int, mvs *ModuleVersionStateForUpdate) (err error) {
@@ -472,6 +493,51 @@ func (db *DB) GetPackageVersionState(ctx context.Context, pkgPath, modulePath, r
 	}
 }
 
+// GetLatestPackageVersionState returns the package version state for pkgPath
+// at resolvedVersion, or if resolvedVersion is version.Latest, at the highest
+// version of any module that has a state for pkgPath.
+func (db *DB) GetLatestPackageVersionState(ctx context.Context, pkgPath, resolvedVersion string) (_ *internal.PackageVersionState, err error) {
+	defer derrors.WrapStack(&err, "GetLatestPackageVersionState(ctx, %q, %q)", pkgPath, resolvedVersion)
+
+	if resolvedVersion == version.Latest {
+		resolvedVersion = ""
+	}
+	query := `
+		SELECT
+			p.package_path,
+			p.module_path,
+			p.version,
+			p.status,
+			p.error
+		FROM
+			package_version_states p
+		INNER JOIN
+			module_version_states m
+		ON
+			m.module_path = p.module_path
+			AND m.version = p.version
+		WHERE
+			p.package_path = $1
+			AND ($2 = '' OR p.version = $2)
+		ORDER BY
+			m.sort_version DESC,
+			p.module_path
+		LIMIT 1;`
+
+	var pvs internal.PackageVersionState
+	err = db.db.QueryRow(ctx, query, pkgPath, resolvedVersion).Scan(
+		&pvs.PackagePath, &pvs.ModulePath, &pvs.Version,
+		&pvs.Status, &pvs.Error)
+	switch err {
+	case nil:
+		return &pvs, nil
+	case sql.ErrNoRows:
+		return nil, derrors.NotFound
+	default:
+		return nil, fmt.Errorf("row.Scan(): %v", err)
+	}
+}
+
 // VersionStats holds statistics extracted from the module_version_states
 // table.
 type VersionStats struct {
@@ -19,6 +19,7 @@ import (
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/internal/version"
 )
 
 func TestInsertIndexVersions(t *testing.T) {
@@ -151,6 +152,14 @@ func TestModuleVersionState(t *testing.T) {
 		PackageVersionStates: []*internal.PackageVersionState{pkgVersionState},
 	}
 	must(t, testDB.UpdateModuleVersionState(ctx, mvs))
+	var numProcessed int
+	if err := testDB.db.QueryRow(ctx, `
+		SELECT num_processed FROM daily_processed_versions WHERE date = CURRENT_DATE`).Scan(&numProcessed); err != nil {
+		t.Fatal(err)
+	}
+	if numProcessed != 1 {
+		t.Errorf("got %d versions processed today, want 1", numProcessed)
+	}
 	errString := fetchErr.Error()
 	numPackages := 1
 	wantFooState := &internal.ModuleVersionState{
@@ -179,6 +188,19 @@ func TestModuleVersionState(t *testing.T) {
 		t.Errorf("testDB.GetPackageVersionStates(ctx, %q, %q) mismatch (-want +got)\n%s", wantFooState.ModulePath, wantFooState.Version, diff)
 	}
 
+	for _, v := range []string{version.Latest, pkgVersionState.Version} {
+		gotPVS, err = testDB.GetLatestPackageVersionState(ctx, pkgVersionState.PackagePath, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(pkgVersionState, gotPVS); diff != "" {
+			t.Errorf("testDB.GetLatestPackageVersionState(ctx, %q, %q) mismatch (-want +got)\n%s", pkgVersionState.PackagePath, v, diff)
+		}
+	}
+	if _, err := testDB.GetLatestPackageVersionState(ctx, pkgVersionState.PackagePath, "v1.1.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v for missing version, want NotFound", err)
+	}
+
 	gotPkgVersionStates, err := testDB.GetPackageVersionStatesForModule(ctx,
 		wantFooState.ModulePath, wantFooState.Version)
 	if err != nil {
@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// A VulnReport holds the results of a govulncheck scan of a module version.
+type VulnReport struct {
+	ModulePath string
+	Version    string
+	UploadedAt time.Time
+	Findings   []*VulnFinding
+}
+
+// A VulnFinding is a vulnerability reported by govulncheck.
+type VulnFinding struct {
+	// ID is the vulndb ID, such as "GO-2022-0001".
+	ID      string
+	Summary string
+	// Module is the vulnerable module, and FixedVersion the version of it
+	// that fixes the vulnerability, if any.
+	Module       string
+	FixedVersion string
+	// Packages are the vulnerable packages that the scanned code imports.
+	Packages []string
+	// Symbols are the vulnerable functions and methods that the scanned code
+	// calls. The vulnerability is reachable if there are any.
+	Symbols []string
+}
+
+// UpsertVulnReport stores r as the govulncheck report of its module version,
+// replacing any earlier report. It returns a NotFound error if the module
+// version is not in the database.
+func (db *DB) UpsertVulnReport(ctx context.Context, r *VulnReport) (err error) {
+	defer derrors.WrapStack(&err, "UpsertVulnReport(ctx, %q, %q)", r.ModulePath, r.Version)
+
+	findings, err := json.Marshal(r.Findings)
+	if err != nil {
+		return err
+	}
+	n, err := db.db.Exec(ctx, `
+		INSERT INTO vuln_reports (module_id, findings)
+		SELECT id, $3
+		FROM modules
+		WHERE module_path = $1 AND version = $2
+		ON CONFLICT (module_id) DO UPDATE SET
+			findings = excluded.findings,
+			uploaded_at = CURRENT_TIMESTAMP`,
+		r.ModulePath, r.Version, findings)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// GetVulnReport returns the govulncheck report of modulePath@version. It
+// returns a NotFound error if none has been uploaded.
+func (db *DB) GetVulnReport(ctx context.Context, modulePath, version string) (_ *VulnReport, err error) {
+	defer derrors.WrapStack(&err, "GetVulnReport(ctx, %q, %q)", modulePath, version)
+
+	r := &VulnReport{ModulePath: modulePath, Version: version}
+	err = db.db.QueryRow(ctx, `
+		SELECT v.findings, v.uploaded_at
+		FROM vuln_reports v
+		INNER JOIN modules m ON m.id = v.module_id
+		WHERE m.module_path = $1 AND m.version = $2`,
+		modulePath, version).Scan(jsonbScanner{&r.Findings}, &r.UploadedAt)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
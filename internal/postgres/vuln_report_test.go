@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestVulnReport(t *testing.T) {
+	t.Parallel()
+	testDB, release := acquire(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.Module(sample.ModulePath, "v1.2.3", "a")
+	MustInsertModule(ctx, t, testDB, m)
+
+	if _, err := testDB.GetVulnReport(ctx, m.ModulePath, m.Version); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+	r := &VulnReport{ModulePath: "example.com/missing", Version: "v1.0.0"}
+	if err := testDB.UpsertVulnReport(ctx, r); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+
+	for _, findings := range [][]*VulnFinding{
+		{{ID: "GO-2022-0001", Module: "example.com/dep", Packages: []string{"example.com/dep/p"}}},
+		{{
+			ID:           "GO-2022-0002",
+			Summary:      "bad",
+			Module:       "example.com/dep",
+			FixedVersion: "v1.4.2",
+			Packages:     []string{"example.com/dep/p"},
+			Symbols:      []string{"F"},
+		}},
+	} {
+		want := &VulnReport{ModulePath: m.ModulePath, Version: m.Version, Findings: findings}
+		if err := testDB.UpsertVulnReport(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := testDB.GetVulnReport(ctx, m.ModulePath, m.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.UploadedAt.IsZero() {
+			t.Error("UploadedAt is zero")
+		}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(VulnReport{}, "UploadedAt")); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+// Sources of module redirects.
+const (
+	// RedirectDeprecated is a redirect derived from the deprecation comment
+	// of the latest go.mod file of a module.
+	RedirectDeprecated = "deprecated"
+	// RedirectAdmin is a redirect added by an administrator. It takes
+	// precedence over a RedirectDeprecated one for the same module.
+	RedirectAdmin = "admin"
+)
+
+// A ModuleRedirect records that a module was renamed or moved, so that users
+// of the old module path can be sent to the new one.
+type ModuleRedirect struct {
+	OldModulePath string
+	NewModulePath string
+	// Source is RedirectDeprecated or RedirectAdmin.
+	Source string
+	// Reason explains the redirect: it is the deprecation comment, or a
+	// note from the administrator.
+	Reason string
+}
+
+// RedirectedPath returns the path in the new module that corresponds to
+// fullPath, a path in the old module.
+func (mr *ModuleRedirect) RedirectedPath(fullPath string) string {
+	return mr.NewModulePath + fullPath[len(mr.OldModulePath):]
+}
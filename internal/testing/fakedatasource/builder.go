@@ -0,0 +1,105 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fakedatasource
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"path"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+// A ModuleBuilder builds a module to insert into a FakeDataSource. Its
+// methods panic on invalid input, since they are meant to be used in tests.
+type ModuleBuilder struct {
+	m *internal.Module
+}
+
+// NewModule returns a builder for a module with the given path and version.
+// The module starts out with the sample license, and a root directory with
+// the sample README but no package.
+func NewModule(modulePath, version string) *ModuleBuilder {
+	return &ModuleBuilder{m: sample.Module(modulePath, version)}
+}
+
+// CommitTime sets the commit time of the module.
+func (b *ModuleBuilder) CommitTime(t time.Time) *ModuleBuilder {
+	b.m.CommitTime = t
+	return b
+}
+
+// Deprecated marks the module as deprecated, with the given comment.
+func (b *ModuleBuilder) Deprecated(comment string) *ModuleBuilder {
+	b.m.Deprecated = true
+	b.m.DeprecationComment = comment
+	return b
+}
+
+// Package adds a package to the module. Its path is the module path joined
+// with suffix, or the module path if suffix is empty. Its name,
+// documentation, symbols and imports are those of the Go source file src,
+// for all build contexts.
+func (b *ModuleBuilder) Package(suffix, src string) *ModuleBuilder {
+	p := path.Join(b.m.ModulePath, suffix)
+	if b.m.ModulePath == stdlib.ModulePath {
+		p = suffix
+	}
+	u := findUnit(b.m, p)
+	if u == nil {
+		sample.AddPackage(b.m, sample.UnitForPackage(p, b.m.ModulePath, b.m.Version, path.Base(p), b.m.IsRedistributable))
+		u = findUnit(b.m, p)
+	}
+	name, doc, imports := documentation(p, &b.m.ModuleInfo, src)
+	u.Name = name
+	u.Documentation = []*internal.Documentation{doc}
+	u.BuildContexts = []internal.BuildContext{doc.BuildContext()}
+	u.Imports = imports
+	u.NumImports = len(imports)
+	return b
+}
+
+// Module returns the module that was built.
+func (b *ModuleBuilder) Module() *internal.Module {
+	return b.m
+}
+
+// documentation returns the package name, documentation and imports of the
+// package at pkgPath in mi, whose only file has the contents src.
+func documentation(pkgPath string, mi *internal.ModuleInfo, src string) (string, *internal.Documentation, []string) {
+	fset := token.NewFileSet()
+	pf, err := parser.ParseFile(fset, path.Base(pkgPath)+".go", src, parser.ParseComments)
+	if err != nil {
+		panic(err)
+	}
+	docPkg := godoc.NewPackage(fset, nil)
+	docPkg.AddFile(pf, true)
+	// Encode first, because DocInfo destroys the AST.
+	source, err := docPkg.Encode(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	innerPath := internal.Suffix(pkgPath, mi.ModulePath)
+	synopsis, imports, api, err := docPkg.DocInfo(context.Background(), innerPath, mi.SourceInfo, &godoc.ModuleInfo{
+		ModulePath:      mi.ModulePath,
+		ResolvedVersion: mi.Version,
+		ModulePackages:  map[string]bool{pkgPath: true},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return pf.Name.Name, &internal.Documentation{
+		GOOS:     internal.All,
+		GOARCH:   internal.All,
+		Synopsis: synopsis,
+		Source:   source,
+		API:      api,
+	}, imports
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fakedatasource provides an in-memory implementation of
+// internal.DataSource, so that tests can construct the modules they need
+// without a database.
+package fakedatasource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// FakeDataSource implements the internal.DataSource interface by reading
+// modules that were inserted into it. It is safe for concurrent use.
+type FakeDataSource struct {
+	mu sync.Mutex
+	// modules maps module paths to versions to modules.
+	modules map[string]map[string]*internal.Module
+}
+
+// New returns an empty FakeDataSource.
+func New() *FakeDataSource {
+	return &FakeDataSource{modules: map[string]map[string]*internal.Module{}}
+}
+
+// InsertModule adds m to ds, replacing any module with the same path and
+// version. ds keeps a reference to m, so m should not be modified afterwards.
+func (ds *FakeDataSource) InsertModule(m *internal.Module) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.modules[m.ModulePath] == nil {
+		ds.modules[m.ModulePath] = map[string]*internal.Module{}
+	}
+	ds.modules[m.ModulePath][m.Version] = m
+}
+
+// getModule returns the module with the given path at the requested version,
+// which may be version.Latest.
+func (ds *FakeDataSource) getModule(modulePath, requestedVersion string) (*internal.Module, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	versions := ds.modules[modulePath]
+	if requestedVersion == version.Latest {
+		var vs []string
+		for v := range versions {
+			vs = append(vs, v)
+		}
+		requestedVersion = version.LatestOf(vs)
+	}
+	m := versions[requestedVersion]
+	if m == nil {
+		return nil, fmt.Errorf("%s@%s: %w", modulePath, requestedVersion, derrors.NotFound)
+	}
+	return m, nil
+}
+
+// findModule returns the module at the requested version that contains path.
+// If modulePath is unknown, it is the longest module path that contains path.
+func (ds *FakeDataSource) findModule(path, modulePath, requestedVersion string) (*internal.Module, error) {
+	candidates := []string{modulePath}
+	if modulePath == internal.UnknownModulePath {
+		candidates = internal.CandidateModulePaths(path)
+	}
+	for _, mp := range candidates {
+		m, err := ds.getModule(mp, requestedVersion)
+		if err == nil && findUnit(m, path) != nil {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%s@%s: %w", path, requestedVersion, derrors.NotFound)
+}
+
+// GetUnitMeta returns information about a path.
+func (ds *FakeDataSource) GetUnitMeta(ctx context.Context, path, requestedModulePath, requestedVersion string) (_ *internal.UnitMeta, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetUnitMeta(%q, %q, %q)", path, requestedModulePath, requestedVersion)
+
+	m, err := ds.findModule(path, requestedModulePath, requestedVersion)
+	if err != nil {
+		return nil, err
+	}
+	u := findUnit(m, path)
+	return &internal.UnitMeta{
+		Path:              path,
+		Name:              u.Name,
+		IsRedistributable: u.IsRedistributable,
+		Licenses:          u.Licenses,
+		ModuleInfo:        m.ModuleInfo,
+	}, nil
+}
+
+// GetUnit returns information about a unit. Both the module path and
+// version must be known. Only the documentation matching bc is returned.
+func (ds *FakeDataSource) GetUnit(ctx context.Context, um *internal.UnitMeta, fields internal.FieldSet, bc internal.BuildContext) (_ *internal.Unit, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetUnit(%q, %q, %q)", um.Path, um.ModulePath, um.Version)
+
+	m, err := ds.getModule(um.ModulePath, um.Version)
+	if err != nil {
+		return nil, err
+	}
+	u := findUnit(m, um.Path)
+	if u == nil {
+		return nil, fmt.Errorf("import path %s not found in module %s: %w", um.Path, um.ModulePath, derrors.NotFound)
+	}
+	// Copy the unit, so that inserted modules are not modified.
+	u2 := *u
+	u2.UnitMeta = *um
+	u2.Documentation = nil
+	if d := matchingDoc(u.Documentation, bc); d != nil {
+		u2.Documentation = []*internal.Documentation{d}
+	}
+	u2.Subdirectories = subdirectories(m, u.Path)
+	return &u2, nil
+}
+
+// GetModuleReadme returns the README of the module root.
+func (ds *FakeDataSource) GetModuleReadme(ctx context.Context, modulePath, resolvedVersion string) (_ *internal.Readme, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetModuleReadme(%q, %q)", modulePath, resolvedVersion)
+
+	m, err := ds.getModule(modulePath, resolvedVersion)
+	if err != nil {
+		return nil, err
+	}
+	u := findUnit(m, modulePath)
+	if u == nil || u.Readme == nil {
+		return nil, fmt.Errorf("README of %s@%s: %w", modulePath, resolvedVersion, derrors.NotFound)
+	}
+	return u.Readme, nil
+}
+
+// GetModuleGuidePaths returns the file paths of the guides of the module.
+func (ds *FakeDataSource) GetModuleGuidePaths(ctx context.Context, modulePath, version string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetModuleGuidePaths(%q, %q)", modulePath, version)
+
+	m, err := ds.getModule(modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, g := range m.Guides {
+		paths = append(paths, g.Filepath)
+	}
+	return paths, nil
+}
+
+// GetModuleGuide returns the guide of the module with the given file path.
+func (ds *FakeDataSource) GetModuleGuide(ctx context.Context, modulePath, version, filePath string) (_ *internal.Readme, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetModuleGuide(%q, %q, %q)", modulePath, version, filePath)
+
+	m, err := ds.getModule(modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range m.Guides {
+		if g.Filepath == filePath {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("guide %s of %s@%s: %w", filePath, modulePath, version, derrors.NotFound)
+}
+
+// GetLatestInfo returns latest information for unitPath and modulePath.
+func (ds *FakeDataSource) GetLatestInfo(ctx context.Context, unitPath, modulePath string, latestUnitMeta *internal.UnitMeta) (latest internal.LatestInfo, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetLatestInfo(%q, %q)", unitPath, modulePath)
+
+	if latestUnitMeta == nil {
+		latestUnitMeta, err = ds.GetUnitMeta(ctx, unitPath, modulePath, version.Latest)
+		if err != nil {
+			return latest, err
+		}
+	}
+	latest.MinorVersion = latestUnitMeta.Version
+	latest.MinorModulePath = latestUnitMeta.ModulePath
+	if m, err := ds.getModule(modulePath, version.Latest); err == nil {
+		latest.UnitExistsAtMinor = findUnit(m, unitPath) != nil
+	}
+
+	latest.MajorModulePath = ds.latestMajorModulePath(modulePath)
+	latest.MajorUnitPath = latest.MajorModulePath
+	if latest.MajorModulePath == modulePath {
+		latest.MajorUnitPath = unitPath
+	} else if m, err := ds.getModule(latest.MajorModulePath, version.Latest); err == nil {
+		p := latest.MajorModulePath + strings.TrimPrefix(unitPath, modulePath)
+		if findUnit(m, p) != nil {
+			latest.MajorUnitPath = p
+		}
+	}
+	return latest, nil
+}
+
+// latestMajorModulePath returns the module path with the highest major
+// version in the series of modulePath.
+func (ds *FakeDataSource) latestMajorModulePath(modulePath string) string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	series := internal.SeriesPathForModule(modulePath)
+	latest := modulePath
+	for mp := range ds.modules {
+		if internal.SeriesPathForModule(mp) == series && majorLess(latest, mp) {
+			latest = mp
+		}
+	}
+	return latest
+}
+
+// GetNestedModules returns the latest major version of each module nested
+// under modulePath, at its latest version.
+func (ds *FakeDataSource) GetNestedModules(ctx context.Context, modulePath string) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "FakeDataSource.GetNestedModules(%q)", modulePath)
+
+	ds.mu.Lock()
+	series := map[string]string{} // series path to latest major module path
+	for mp := range ds.modules {
+		if !strings.HasPrefix(mp, modulePath+"/") {
+			continue
+		}
+		s := internal.SeriesPathForModule(mp)
+		if s == internal.SeriesPathForModule(modulePath) {
+			continue
+		}
+		if l, ok := series[s]; !ok || majorLess(l, mp) {
+			series[s] = mp
+		}
+	}
+	ds.mu.Unlock()
+
+	var mis []*internal.ModuleInfo
+	for _, mp := range series {
+		m, err := ds.getModule(mp, version.Latest)
+		if err != nil {
+			return nil, err
+		}
+		mi := m.ModuleInfo
+		mis = append(mis, &mi)
+	}
+	sort.Slice(mis, func(i, j int) bool { return mis[i].ModulePath < mis[j].ModulePath })
+	return mis, nil
+}
+
+// majorLess reports whether the major version of modulePath1 is lower than
+// that of modulePath2, which is in the same series.
+func majorLess(modulePath1, modulePath2 string) bool {
+	v1 := internal.MajorVersionForModule(modulePath1)
+	v2 := internal.MajorVersionForModule(modulePath2)
+	if v1 == "" {
+		v1 = "v1"
+	}
+	if v2 == "" {
+		v2 = "v1"
+	}
+	return semver.Compare(v1, v2) < 0
+}
+
+// findUnit returns the unit with the given path in m, or nil if none.
+func findUnit(m *internal.Module, path string) *internal.Unit {
+	for _, u := range m.Units {
+		if u.Path == path {
+			return u
+		}
+	}
+	return nil
+}
+
+// subdirectories returns the packages of m below path.
+func subdirectories(m *internal.Module, path string) []*internal.PackageMeta {
+	var pms []*internal.PackageMeta
+	for _, u := range m.Units {
+		if !u.IsPackage() || !strings.HasPrefix(u.Path, path+"/") && u.Path != path {
+			continue
+		}
+		var syn string
+		if len(u.Documentation) > 0 {
+			syn = u.Documentation[0].Synopsis
+		}
+		pms = append(pms, &internal.PackageMeta{
+			Path:              u.Path,
+			Name:              u.Name,
+			Synopsis:          syn,
+			IsRedistributable: u.IsRedistributable,
+			Licenses:          u.Licenses,
+		})
+	}
+	sort.Slice(pms, func(i, j int) bool { return pms[i].Path < pms[j].Path })
+	return pms
+}
+
+// matchingDoc returns the Documentation that matches the given build context
+// and comes earliest in build-context order. It returns nil if there is none.
+func matchingDoc(docs []*internal.Documentation, bc internal.BuildContext) *internal.Documentation {
+	var (
+		dMin  *internal.Documentation
+		bcMin = internal.BuildContext{GOOS: "unk", GOARCH: "unk"} // sorts last
+	)
+	for _, d := range docs {
+		dbc := d.BuildContext()
+		if bc.Match(dbc) && internal.CompareBuildContexts(dbc, bcMin) < 0 {
+			dMin = d
+			bcMin = dbc
+		}
+	}
+	return dMin
+}
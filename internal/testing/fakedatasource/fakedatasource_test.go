@@ -0,0 +1,148 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fakedatasource
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+const src = `
+// Package p does things.
+package p
+
+import "fmt"
+
+// F formats.
+func F() string { return fmt.Sprint(1) }
+
+// T is a type.
+type T struct{}
+`
+
+func newTestDataSource() *FakeDataSource {
+	ds := New()
+	ds.InsertModule(NewModule("example.com/m", "v1.0.0").Package("p", src).Module())
+	ds.InsertModule(NewModule("example.com/m", "v1.1.0").Package("p", src).Package("q/r", "package r").Module())
+	ds.InsertModule(NewModule("example.com/m/v2", "v2.0.0").Package("p", src).Module())
+	ds.InsertModule(NewModule("example.com/m/nested", "v0.1.0").Package("", "package nested").Module())
+	return ds
+}
+
+func TestGetUnitMeta(t *testing.T) {
+	ctx := context.Background()
+	ds := newTestDataSource()
+	for _, test := range []struct {
+		path, modulePath, version string
+		wantModulePath, wantVer   string
+	}{
+		{"example.com/m/p", internal.UnknownModulePath, version.Latest, "example.com/m", "v1.1.0"},
+		{"example.com/m/p", "example.com/m", "v1.0.0", "example.com/m", "v1.0.0"},
+		{"example.com/m/q", internal.UnknownModulePath, version.Latest, "example.com/m", "v1.1.0"},
+		{"example.com/m/nested", internal.UnknownModulePath, version.Latest, "example.com/m/nested", "v0.1.0"},
+		{"example.com/m/v2/p", internal.UnknownModulePath, version.Latest, "example.com/m/v2", "v2.0.0"},
+	} {
+		um, err := ds.GetUnitMeta(ctx, test.path, test.modulePath, test.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if um.ModulePath != test.wantModulePath || um.Version != test.wantVer {
+			t.Errorf("GetUnitMeta(%q, %q, %q) = %s@%s, want %s@%s", test.path, test.modulePath, test.version,
+				um.ModulePath, um.Version, test.wantModulePath, test.wantVer)
+		}
+	}
+	if _, err := ds.GetUnitMeta(ctx, "example.com/m/q/r", internal.UnknownModulePath, "v1.0.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want NotFound", err)
+	}
+}
+
+func TestGetUnit(t *testing.T) {
+	ctx := context.Background()
+	ds := newTestDataSource()
+	um, err := ds.GetUnitMeta(ctx, "example.com/m/p", "example.com/m", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ds.GetUnit(ctx, um, internal.AllFields, internal.BuildContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := u.Name, "p"; got != want {
+		t.Errorf("got name %q, want %q", got, want)
+	}
+	if got, want := u.Imports, []string{"fmt"}; !cmp.Equal(got, want) {
+		t.Errorf("got imports %v, want %v", got, want)
+	}
+	if len(u.Documentation) != 1 {
+		t.Fatalf("got %d documentation, want 1", len(u.Documentation))
+	}
+	d := u.Documentation[0]
+	if got, want := d.Synopsis, "Package p does things."; got != want {
+		t.Errorf("got synopsis %q, want %q", got, want)
+	}
+	var names []string
+	for _, s := range d.API {
+		names = append(names, s.Name)
+	}
+	if want := []string{"F", "T"}; !cmp.Equal(names, want) {
+		t.Errorf("got symbols %v, want %v", names, want)
+	}
+
+	root, err := ds.GetUnitMeta(ctx, "example.com/m", "example.com/m", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err = ds.GetUnit(ctx, root, internal.AllFields, internal.BuildContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subdirs []string
+	for _, pm := range u.Subdirectories {
+		subdirs = append(subdirs, pm.Path)
+	}
+	if want := []string{"example.com/m/p", "example.com/m/q/r"}; !cmp.Equal(subdirs, want) {
+		t.Errorf("got subdirectories %v, want %v", subdirs, want)
+	}
+}
+
+func TestGetLatestInfo(t *testing.T) {
+	ctx := context.Background()
+	ds := newTestDataSource()
+	got, err := ds.GetLatestInfo(ctx, "example.com/m/q/r", "example.com/m", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := internal.LatestInfo{
+		MinorVersion:      "v1.1.0",
+		MinorModulePath:   "example.com/m",
+		UnitExistsAtMinor: true,
+		MajorModulePath:   "example.com/m/v2",
+		MajorUnitPath:     "example.com/m/v2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestGetNestedModules(t *testing.T) {
+	ds := newTestDataSource()
+	mis, err := ds.GetNestedModules(context.Background(), "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, mi := range mis {
+		got = append(got, mi.ModulePath+"@"+mi.Version)
+	}
+	if want := []string{"example.com/m/nested@v0.1.0"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goldenpages renders every tab of every unit in a corpus of modules
+// with the frontend, so that the pages can be compared with golden files.
+// This checks changes to templates and to the documentation renderer against
+// many kinds of pages at once.
+//
+// The corpus is a directory of txtar files, in the format read by
+// proxytest.LoadTestModules. The golden files are under a separate
+// directory, in a tree of module versions and unit paths, with one file per
+// tab. Pages are normalized before they are compared, so that differences
+// in whitespace and attribute order are ignored.
+package goldenpages
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml/template"
+	"golang.org/x/net/html"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/frontend"
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/testing/fakedatasource"
+	"golang.org/x/pkgsite/static"
+	thirdparty "golang.org/x/pkgsite/third_party"
+)
+
+// Tabs are the tabs that are rendered for each unit. The main tab is the
+// empty string.
+var Tabs = []string{"", "versions", "imports", "importedby", "licenses", "security"}
+
+// A Page is a rendered tab of a unit.
+type Page struct {
+	ModulePath string
+	Version    string
+	UnitPath   string
+	Tab        string
+	// Status is the HTTP status of the response.
+	Status int
+	// Location is the target of a redirect.
+	Location string
+	// HTML is the normalized body of the response, if it is not a redirect.
+	HTML string
+}
+
+// URL returns the URL path of p.
+func (p *Page) URL() string {
+	u := fmt.Sprintf("/%s@%s%s", p.ModulePath, p.Version, strings.TrimPrefix(p.UnitPath, p.ModulePath))
+	if p.Tab != "" {
+		u += "?tab=" + p.Tab
+	}
+	return u
+}
+
+// Golden returns the contents of the golden file for p.
+func (p *Page) Golden() string {
+	if p.Location != "" {
+		return fmt.Sprintf("status: %d\nlocation: %s\n", p.Status, p.Location)
+	}
+	return fmt.Sprintf("status: %d\n%s", p.Status, p.HTML)
+}
+
+// GoldenPath returns the path of the golden file of p under dir.
+func GoldenPath(dir string, p *Page) string {
+	tab := p.Tab
+	if tab == "" {
+		tab = "main"
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(p.UnitPath, p.ModulePath), "/")
+	return filepath.Join(dir, filepath.FromSlash(p.ModulePath+"@"+p.Version), filepath.FromSlash(rel), tab+".golden")
+}
+
+// WriteGolden writes the golden file of p under dir, creating directories as
+// needed.
+func WriteGolden(dir string, p *Page) error {
+	filename := GoldenPath(dir, p)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(p.Golden()), 0644)
+}
+
+// LoadCorpus returns the modules in the corpus directory dir.
+func LoadCorpus(dir string) []*proxytest.Module {
+	return proxytest.LoadTestModules(dir)
+}
+
+// NewDataSource fetches mods from a fake proxy, and returns a FakeDataSource
+// that contains them, along with the fetched modules.
+func NewDataSource(ctx context.Context, mods []*proxytest.Module) (_ *fakedatasource.FakeDataSource, _ []*internal.Module, err error) {
+	client, teardown, err := proxytest.NewClientForServer(proxytest.NewServer(mods))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer teardown()
+
+	ds := fakedatasource.New()
+	getter := fetch.NewProxyModuleGetter(client, source.NewClientForTesting())
+	var fetched []*internal.Module
+	for _, m := range mods {
+		fr := fetch.FetchModule(ctx, m.ModulePath, m.Version, getter)
+		if fr.Error != nil {
+			return nil, nil, fr.Error
+		}
+		ds.InsertModule(fr.Module)
+		fetched = append(fetched, fr.Module)
+	}
+	return ds, fetched, nil
+}
+
+// NewHandler returns a handler that serves the frontend from ds, using the
+// templates and static files embedded in the binary.
+func NewHandler(ds internal.DataSource) (http.Handler, error) {
+	s, err := frontend.NewServer(frontend.ServerConfig{
+		DataSourceGetter: func(context.Context) internal.DataSource { return ds },
+		TemplateFS:       template.TrustedFSFromEmbed(static.FS),
+		StaticFS:         static.FS,
+		ThirdPartyFS:     thirdparty.FS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil, nil)
+	return mux, nil
+}
+
+// RenderAll renders each tab in Tabs for each unit of mods with h. The pages
+// are ordered by module, unit path and tab. Tabs that the data source does
+// not support are omitted.
+func RenderAll(h http.Handler, mods []*internal.Module) ([]*Page, error) {
+	var pages []*Page
+	for _, m := range mods {
+		var paths []string
+		for _, u := range m.Units {
+			paths = append(paths, u.Path)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			for _, tab := range Tabs {
+				page := &Page{ModulePath: m.ModulePath, Version: m.Version, UnitPath: p, Tab: tab}
+				if err := render(h, page); err != nil {
+					return nil, err
+				}
+				if page.Status != http.StatusFailedDependency {
+					pages = append(pages, page)
+				}
+			}
+		}
+	}
+	return pages, nil
+}
+
+// render requests p from h and sets p's Status and HTML.
+func render(h http.Handler, p *Page) error {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", p.URL(), nil))
+	p.Status = w.Code
+	if w.Code >= 300 && w.Code < 400 {
+		p.Location = w.Header().Get("Location")
+		return nil
+	}
+	n, err := Normalize(w.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %v", p.URL(), err)
+	}
+	p.HTML = n
+	return nil
+}
+
+// Normalize parses the HTML document read from r, and returns it with one
+// element or text node per line, indented by depth. Attributes are sorted,
+// whitespace in text is collapsed, whitespace-only text and comments are
+// dropped, and the contents of script and style elements are omitted. Two
+// documents that differ only in formatting have the same normalized form.
+func Normalize(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch n.Type {
+		case html.ElementNode:
+			attrs := make([]string, len(n.Attr))
+			for i, a := range n.Attr {
+				attrs[i] = fmt.Sprintf(" %s=%q", a.Key, strings.Join(strings.Fields(a.Val), " "))
+			}
+			sort.Strings(attrs)
+			fmt.Fprintf(&b, "%s<%s%s>\n", indent, n.Data, strings.Join(attrs, ""))
+			if n.Data == "script" || n.Data == "style" {
+				return
+			}
+			depth++
+		case html.TextNode:
+			if t := strings.Join(strings.Fields(n.Data), " "); t != "" {
+				fmt.Fprintf(&b, "%s%s\n", indent, t)
+			}
+			return
+		case html.CommentNode, html.DoctypeNode:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, depth)
+		}
+	}
+	walk(doc, 0)
+	return b.String(), nil
+}
+
+// Diff returns a line diff between the normalized documents want and got,
+// or the empty string if they are the same.
+func Diff(want, got string) string {
+	return cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n"))
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goldenpages
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update goldens instead of checking against them")
+
+const (
+	corpusDir = "testdata/corpus"
+	goldenDir = "testdata/golden"
+)
+
+func TestGoldenPages(t *testing.T) {
+	ctx := context.Background()
+	ds, mods, err := NewDataSource(ctx, LoadCorpus(corpusDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := RenderAll(h, mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pages {
+		p := p
+		t.Run(strings.TrimPrefix(p.URL(), "/"), func(t *testing.T) {
+			if *update {
+				if err := WriteGolden(goldenDir, p); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			filename := GoldenPath(goldenDir, p)
+			want, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			if diff := Diff(string(want), p.Golden()); diff != "" {
+				t.Errorf("%s: mismatch (-want, +got):\n%s", filename, diff)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a := `<!DOCTYPE html><html><body>
+		<div class="x"   id="y"><!-- comment --><p>Hello,
+		world</p></div><script>var x = 1;</script></body></html>`
+	b := `<html><body><div id="y" class="x"><p>Hello, world</p></div><script>var x = 2;</script></body></html>`
+	na, err := Normalize(strings.NewReader(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb, err := Normalize(strings.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := Diff(na, nb); diff != "" {
+		t.Errorf("mismatch (-a, +b):\n%s", diff)
+	}
+	want := `<html>
+  <head>
+  <body>
+    <div class="x" id="y">
+      <p>
+        Hello, world
+    <script>
+`
+	if diff := Diff(want, na); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
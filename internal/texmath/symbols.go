@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texmath
+
+// identifiers maps commands to the symbols they produce that are displayed
+// as identifiers, in italics.
+var identifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"ell": "ℓ", "hbar": "ℏ", "imath": "ı", "jmath": "ȷ", "wp": "℘",
+}
+
+// uprightIdentifiers are like identifiers, but are displayed upright.
+var uprightIdentifiers = map[string]string{
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ",
+	"Omega": "Ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅",
+	"varnothing": "∅", "aleph": "ℵ", "Re": "ℜ", "Im": "ℑ", "forall": "∀",
+	"exists": "∃", "nexists": "∄", "neg": "¬", "lnot": "¬", "top": "⊤",
+	"bot": "⊥", "angle": "∠", "triangle": "△", "prime": "′",
+	"$": "$", "%": "%", "#": "#", "_": "_",
+}
+
+// operators maps commands to the symbols they produce that are displayed as
+// operators, relations or punctuation.
+var operators = map[string]string{
+	// Binary operators.
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "⋅", "ast": "∗",
+	"star": "⋆", "circ": "∘", "bullet": "∙", "oplus": "⊕", "ominus": "⊖",
+	"otimes": "⊗", "oslash": "⊘", "odot": "⊙", "cap": "∩", "cup": "∪",
+	"sqcap": "⊓", "sqcup": "⊔", "wedge": "∧", "land": "∧", "vee": "∨",
+	"lor": "∨", "setminus": "∖", "wr": "≀", "dagger": "†", "ddagger": "‡",
+	"amalg": "⨿",
+	// Relations.
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+	"ll": "≪", "gg": "≫", "lt": "<", "gt": ">", "approx": "≈", "sim": "∼",
+	"simeq": "≃", "cong": "≅", "equiv": "≡", "propto": "∝", "prec": "≺",
+	"succ": "≻", "preceq": "⪯", "succeq": "⪰", "subset": "⊂", "supset": "⊃",
+	"subseteq": "⊆", "supseteq": "⊇", "nsubseteq": "⊈", "sqsubseteq": "⊑",
+	"sqsupseteq": "⊒", "in": "∈", "notin": "∉", "ni": "∋", "mid": "∣",
+	"nmid": "∤", "parallel": "∥", "perp": "⊥", "models": "⊨", "vdash": "⊢",
+	"dashv": "⊣", "asymp": "≍", "doteq": "≐", "coloneqq": "≔",
+	// Arrows.
+	"to": "→", "gets": "←", "rightarrow": "→", "leftarrow": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "impliedby": "⟸", "iff": "⟺",
+	"mapsto": "↦", "longrightarrow": "⟶", "longleftarrow": "⟵",
+	"longmapsto": "⟼", "uparrow": "↑", "downarrow": "↓", "updownarrow": "↕",
+	"Uparrow": "⇑", "Downarrow": "⇓", "hookrightarrow": "↪",
+	"hookleftarrow": "↩", "nearrow": "↗", "searrow": "↘", "swarrow": "↙",
+	"nwarrow": "↖", "rightleftharpoons": "⇌",
+	// Punctuation and delimiters.
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"colon": ":", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "vert": "|", "Vert": "‖", "|": "‖",
+	"backslash": "∖", "{": "{", "}": "}", "&": "&",
+}
+
+// largeOperators maps commands to the large operators they produce, whose
+// limits are placed below and above them in display mode.
+var largeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+	"bigvee": "⋁", "bigwedge": "⋀", "bigoplus": "⨁", "bigotimes": "⨂",
+	"bigodot": "⨀", "bigsqcup": "⨆",
+}
+
+// integrals maps commands to the integral signs they produce, whose limits
+// are always placed as subscripts and superscripts.
+var integrals = map[string]string{
+	"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+}
+
+// functions are the names of functions that are displayed upright.
+var functions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true,
+	"csc": true, "arcsin": true, "arccos": true, "arctan": true,
+	"sinh": true, "cosh": true, "tanh": true, "coth": true, "log": true,
+	"ln": true, "lg": true, "exp": true, "arg": true, "deg": true,
+	"dim": true, "hom": true, "ker": true,
+}
+
+// limitFunctions are like functions, but their limits are placed below them
+// in display mode.
+var limitFunctions = map[string]bool{
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true,
+	"sup": true, "inf": true, "det": true, "gcd": true, "Pr": true,
+}
+
+// spaces maps spacing commands to their widths. Negative spaces are ignored.
+var spaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ">": "0.2222em", ";": "0.2778em",
+	" ": "0.3333em", "quad": "1em", "qquad": "2em", "!": "",
+	"thinspace": "0.1667em", "medspace": "0.2222em", "thickspace": "0.2778em",
+	"enspace": "0.5em",
+}
+
+// accents maps accent commands to the symbols placed above (or, for
+// \underline, below) their argument.
+var accents = map[string]string{
+	"hat": "^", "widehat": "^", "check": "ˇ", "tilde": "~",
+	"widetilde": "~", "acute": "´", "grave": "`", "dot": "˙", "ddot": "¨",
+	"breve": "˘", "bar": "‾", "overline": "‾", "vec": "→",
+	"overrightarrow": "→", "overleftarrow": "←", "underline": "_",
+}
+
+// delimiters maps the commands allowed after \left and \right to the
+// delimiters they produce.
+var delimiters = map[string]string{
+	"{": "{", "}": "}", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊",
+	"rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "vert": "|", "Vert": "‖",
+	"|": "‖", "lvert": "|", "rvert": "|", "lVert": "‖", "rVert": "‖",
+}
+
+// variants maps font commands to the fonts they select.
+var variants = map[string]string{
+	"mathbb": "double-struck", "mathbf": "bold", "boldsymbol": "bold",
+	"mathcal": "script", "mathscr": "script", "mathfrak": "fraktur",
+	"mathsf": "sans-serif", "mathtt": "monospace", "mathrm": "normal",
+	"mathit": "italic",
+}
+
+// A font describes the Unicode mathematical alphanumeric symbols of a font:
+// the first capital letter, small letter and digit, which are followed by
+// the others in order, and the characters that are outside of that range.
+type font struct {
+	upper, lower, digit rune
+	exceptions          map[rune]rune
+}
+
+var fonts = map[string]font{
+	"bold": {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	"double-struck": {upper: 0x1D538, lower: 0x1D552, digit: 0x1D7D8, exceptions: map[rune]rune{
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+	}},
+	"script": {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ',
+		'R': 'ℛ', 'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	}},
+	"fraktur": {upper: 0x1D504, lower: 0x1D51E, exceptions: map[rune]rune{
+		'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ',
+	}},
+	"sans-serif": {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	"monospace":  {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+}
@@ -0,0 +1,662 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package texmath converts mathematical formulas written in LaTeX to MathML.
+//
+// It supports the subset of LaTeX math commonly used in documentation, and
+// understood by both MathJax and KaTeX: letters, numbers and operators,
+// superscripts and subscripts, fractions, roots, Greek letters and other
+// symbols, functions such as \sin, font commands such as \mathbb, accents,
+// \left and \right delimiters, \text, spacing, and matrix and cases
+// environments. Anything else is an error, so that callers can display the
+// source of the formula instead.
+//
+// The MathML is generated from scratch, with all text escaped, so it can be
+// included in a page without further sanitization.
+package texmath
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/uncheckedconversions"
+)
+
+const (
+	// MaxSourceSize is the maximum size of the source of a formula.
+	MaxSourceSize = 4096
+
+	// maxDepth is the maximum nesting depth of groups in a formula.
+	maxDepth = 32
+)
+
+// ToMathML converts the LaTeX formula tex to a MathML <math> element. If
+// display is true, the formula is displayed as a block; otherwise it is
+// displayed inline. The source of the formula is included as an annotation.
+func ToMathML(tex string, display bool) (safehtml.HTML, error) {
+	if len(tex) > MaxSourceSize {
+		return safehtml.HTML{}, fmt.Errorf("formula is larger than %d bytes", MaxSourceSize)
+	}
+	if !utf8.ValidString(tex) {
+		return safehtml.HTML{}, errors.New("formula is not valid UTF-8")
+	}
+	p := &parser{src: tex, display: display}
+	items, err := p.parseRow()
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	if p.pos < len(p.src) {
+		return safehtml.HTML{}, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	var b strings.Builder
+	b.WriteString("<math")
+	if display {
+		b.WriteString(` display="block"`)
+	}
+	b.WriteString("><semantics>")
+	b.WriteString(mrow(items))
+	b.WriteString(`<annotation encoding="application/x-tex">`)
+	b.WriteString(html.EscapeString(strings.TrimSpace(tex)))
+	b.WriteString("</annotation></semantics></math>")
+	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(b.String()), nil
+}
+
+// parser is a recursive-descent parser for LaTeX formulas. Its methods
+// return MathML elements as strings.
+type parser struct {
+	src     string
+	pos     int
+	display bool
+	depth   int
+	// variant is the font of letters and digits, set by commands such as
+	// \mathbb.
+	variant string
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && isSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// peek returns the next byte, or 0 at the end of the source.
+func (p *parser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// peekCommand returns the name of the command at the current position, such
+// as "frac" for \frac, or the empty string if there is none. Control symbols
+// such as "\{" have one-character names.
+func (p *parser) peekCommand() string {
+	if p.peek() != '\\' || p.pos+1 >= len(p.src) {
+		return ""
+	}
+	i := p.pos + 1
+	if !isLetter(p.src[i]) {
+		return p.src[i : i+1]
+	}
+	for i < len(p.src) && isLetter(p.src[i]) {
+		i++
+	}
+	return p.src[p.pos+1 : i]
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parseRow parses a sequence of terms, up to the end of the source, a
+// closing brace, or the end of a row or cell of an environment.
+func (p *parser) parseRow() ([]string, error) {
+	var items []string
+	for {
+		p.skipSpace()
+		switch c := p.peek(); {
+		case c == 0 || c == '}' || c == '&':
+			return items, nil
+		case c == '\\':
+			switch p.peekCommand() {
+			case "\\", "right", "end":
+				return items, nil
+			}
+		}
+		t, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if t != "" {
+			items = append(items, t)
+		}
+	}
+}
+
+// parseTerm parses an atom followed by any superscripts and subscripts.
+func (p *parser) parseTerm() (string, error) {
+	base, limits, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+	var sub, sup string
+	for {
+		p.skipSpace()
+		switch name := p.peekCommand(); name {
+		case "limits", "nolimits":
+			p.pos += 1 + len(name)
+			limits = name == "limits"
+			continue
+		}
+		switch p.peek() {
+		case '_', '^':
+			c := p.src[p.pos]
+			p.pos++
+			arg, err := p.parseArg()
+			if err != nil {
+				return "", err
+			}
+			if c == '_' {
+				if sub != "" {
+					return "", p.errorf("double subscript")
+				}
+				sub = arg
+			} else {
+				if sup != "" {
+					return "", p.errorf("double superscript")
+				}
+				sup = arg
+			}
+			continue
+		case '\'':
+			if sup != "" {
+				return "", p.errorf("prime after superscript")
+			}
+			n := 0
+			for p.peek() == '\'' {
+				p.pos++
+				n++
+			}
+			sup = "<mo>" + strings.Repeat("′", n) + "</mo>"
+			continue
+		}
+		break
+	}
+	if sub == "" && sup == "" {
+		return base, nil
+	}
+	if base == "" {
+		base = "<mrow></mrow>"
+	}
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if limits && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case sup == "":
+		return elem(under, base+sub), nil
+	case sub == "":
+		return elem(over, base+sup), nil
+	default:
+		return elem(both, base+sub+sup), nil
+	}
+}
+
+// parseArg parses the argument of a command or script: a group, or a single
+// atom.
+func (p *parser) parseArg() (string, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == 0:
+		return "", p.errorf("missing argument")
+	case c == '{':
+		return p.parseGroup()
+	case isDigit(c):
+		// Only one digit: x^23 is x²3.
+		p.pos++
+		return p.number(p.src[p.pos-1 : p.pos]), nil
+	}
+	a, _, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+	if a == "" {
+		return "<mrow></mrow>", nil
+	}
+	return a, nil
+}
+
+// parseGroup parses a group in braces.
+func (p *parser) parseGroup() (string, error) {
+	if p.peek() != '{' {
+		return "", p.errorf("expected {")
+	}
+	p.pos++
+	items, err := p.parseRow()
+	if err != nil {
+		return "", err
+	}
+	if p.peek() != '}' {
+		return "", p.errorf("missing }")
+	}
+	p.pos++
+	return mrow(items), nil
+}
+
+// parseAtom parses a single element of a formula. It reports whether
+// subscripts and superscripts of the element are placed below and above it
+// in display mode.
+func (p *parser) parseAtom() (_ string, limits bool, err error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return "", false, p.errorf("formula is nested more than %d levels deep", maxDepth)
+	}
+	c := p.peek()
+	switch {
+	case c == '{':
+		s, err := p.parseGroup()
+		return s, false, err
+	case c == '\\':
+		return p.parseCommand()
+	case isDigit(c) || c == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]):
+		start := p.pos
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return p.number(p.src[start:p.pos]), false, nil
+	case isLetter(c):
+		p.pos++
+		return p.identifier(string(c)), false, nil
+	case c == '~':
+		p.pos++
+		return `<mspace width="0.3333em"></mspace>`, false, nil
+	case c == '#' || c == '$' || c == '%' || c == '^' || c == '_':
+		return "", false, p.errorf("unexpected %q", c)
+	case c < utf8.RuneSelf:
+		p.pos++
+		s := string(c)
+		if c == '-' {
+			s = "−"
+		}
+		return mo(s), false, nil
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	if unicode.IsLetter(r) {
+		return p.identifier(string(r)), false, nil
+	}
+	if unicode.IsDigit(r) {
+		return p.number(string(r)), false, nil
+	}
+	return mo(string(r)), false, nil
+}
+
+// parseCommand parses a command, such as \frac{1}{2} or \alpha.
+func (p *parser) parseCommand() (_ string, limits bool, err error) {
+	name := p.peekCommand()
+	if name == "" {
+		return "", false, p.errorf("incomplete command")
+	}
+	p.pos += 1 + len(name)
+	if s, ok := identifiers[name]; ok {
+		return "<mi>" + html.EscapeString(s) + "</mi>", false, nil
+	}
+	if s, ok := uprightIdentifiers[name]; ok {
+		return `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>", false, nil
+	}
+	if s, ok := operators[name]; ok {
+		return mo(s), false, nil
+	}
+	if s, ok := largeOperators[name]; ok {
+		return `<mo largeop="true" movablelimits="true">` + s + "</mo>", true, nil
+	}
+	if name == "int" || name == "iint" || name == "iiint" || name == "oint" {
+		return `<mo largeop="true">` + integrals[name] + "</mo>", false, nil
+	}
+	if functions[name] {
+		return "<mi>" + name + "</mi>", false, nil
+	}
+	if limitFunctions[name] {
+		s := `<mo movablelimits="true" form="prefix">` + name + "</mo>"
+		if name == "liminf" || name == "limsup" {
+			s = `<mo movablelimits="true" form="prefix">lim ` + name[3:] + "</mo>"
+		}
+		return s, true, nil
+	}
+	if w, ok := spaces[name]; ok {
+		if w == "" {
+			return "", false, nil
+		}
+		return `<mspace width="` + w + `"></mspace>`, false, nil
+	}
+	if a, ok := accents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "underline" {
+			return `<munder accentunder="true">` + arg + `<mo stretchy="true">` + a + "</mo></munder>", false, nil
+		}
+		stretchy := "false"
+		if strings.HasPrefix(name, "wide") || name == "overline" || name == "overrightarrow" || name == "overleftarrow" {
+			stretchy = "true"
+		}
+		return `<mover accent="true">` + arg + `<mo stretchy="` + stretchy + `">` + a + "</mo></mover>", false, nil
+	}
+	if v, ok := variants[name]; ok {
+		return p.parseVariant(v)
+	}
+	switch name {
+	case "frac", "dfrac", "tfrac", "binom":
+		num, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "binom" {
+			return "<mrow>" + mo("(") + `<mfrac linethickness="0">` + num + den + "</mfrac>" + mo(")") + "</mrow>", false, nil
+		}
+		return elem("mfrac", num+den), false, nil
+	case "sqrt":
+		p.skipSpace()
+		var index string
+		if p.peek() == '[' {
+			p.pos++
+			items, err := p.parseUntil(']')
+			if err != nil {
+				return "", false, err
+			}
+			index = mrow(items)
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if index != "" {
+			return elem("mroot", arg+index), false, nil
+		}
+		return elem("msqrt", arg), false, nil
+	case "text", "textrm", "textit", "textbf", "mbox":
+		s, err := p.parseText()
+		if err != nil {
+			return "", false, err
+		}
+		return "<mtext>" + html.EscapeString(s) + "</mtext>", false, nil
+	case "operatorname":
+		s, err := p.parseText()
+		if err != nil {
+			return "", false, err
+		}
+		return `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>", false, nil
+	case "left":
+		return p.parseLeftRight()
+	case "begin":
+		return p.parseEnvironment()
+	case "displaystyle", "textstyle":
+		return "", false, nil
+	}
+	return "", false, p.errorf("unsupported command \\%s", name)
+}
+
+// parseUntil parses terms up to the closing character c, which it consumes.
+func (p *parser) parseUntil(c byte) ([]string, error) {
+	var items []string
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return nil, p.errorf("missing %c", c)
+		case c:
+			p.pos++
+			return items, nil
+		}
+		t, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if t != "" {
+			items = append(items, t)
+		}
+	}
+}
+
+// parseText parses the argument of a command such as \text, whose contents
+// are text rather than math.
+func (p *parser) parseText() (string, error) {
+	p.skipSpace()
+	if p.peek() != '{' {
+		return "", p.errorf("expected {")
+	}
+	end := strings.IndexByte(p.src[p.pos:], '}')
+	if end < 0 {
+		return "", p.errorf("missing }")
+	}
+	s := p.src[p.pos+1 : p.pos+end]
+	if strings.ContainsAny(s, "{\\") {
+		return "", p.errorf("unsupported text")
+	}
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseVariant parses the argument of a font command such as \mathbb.
+func (p *parser) parseVariant(v string) (string, bool, error) {
+	old := p.variant
+	p.variant = v
+	defer func() { p.variant = old }()
+	s, err := p.parseArg()
+	return s, false, err
+}
+
+// parseLeftRight parses the rest of a \left ... \right construct.
+func (p *parser) parseLeftRight() (string, bool, error) {
+	left, err := p.parseDelimiter()
+	if err != nil {
+		return "", false, err
+	}
+	items, err := p.parseRow()
+	if err != nil {
+		return "", false, err
+	}
+	if p.peekCommand() != "right" {
+		return "", false, p.errorf("missing \\right")
+	}
+	p.pos += len(`\right`)
+	right, err := p.parseDelimiter()
+	if err != nil {
+		return "", false, err
+	}
+	return "<mrow>" + fence(left) + strings.Join(items, "") + fence(right) + "</mrow>", false, nil
+}
+
+// parseDelimiter parses the delimiter after \left or \right. It returns the
+// empty string for the null delimiter ".".
+func (p *parser) parseDelimiter() (string, error) {
+	p.skipSpace()
+	c := p.peek()
+	switch c {
+	case '(', ')', '[', ']', '|', '/':
+		p.pos++
+		return string(c), nil
+	case '.':
+		p.pos++
+		return "", nil
+	case '\\':
+		name := p.peekCommand()
+		if d, ok := delimiters[name]; ok {
+			p.pos += 1 + len(name)
+			return d, nil
+		}
+	}
+	return "", p.errorf("invalid delimiter")
+}
+
+func fence(d string) string {
+	if d == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + html.EscapeString(d) + "</mo>"
+}
+
+// environments maps the supported environments to their delimiters.
+var environments = map[string][2]string{
+	"matrix":   {"", ""},
+	"pmatrix":  {"(", ")"},
+	"bmatrix":  {"[", "]"},
+	"Bmatrix":  {"{", "}"},
+	"vmatrix":  {"|", "|"},
+	"Vmatrix":  {"‖", "‖"},
+	"cases":    {"{", ""},
+	"aligned":  {"", ""},
+	"gathered": {"", ""},
+}
+
+// parseEnvironment parses the rest of a \begin{...} ... \end{...} construct.
+func (p *parser) parseEnvironment() (string, bool, error) {
+	name, err := p.parseText()
+	if err != nil {
+		return "", false, err
+	}
+	delims, ok := environments[name]
+	if !ok {
+		return "", false, p.errorf("unsupported environment %q", name)
+	}
+	var rows []string
+	for {
+		var cells []string
+		for {
+			items, err := p.parseRow()
+			if err != nil {
+				return "", false, err
+			}
+			attr := ""
+			switch name {
+			case "cases":
+				attr = ` columnalign="left"`
+			case "aligned":
+				// Alternate right and left alignment, around relations.
+				if len(cells)%2 == 0 {
+					attr = ` columnalign="right"`
+				} else {
+					attr = ` columnalign="left"`
+				}
+			}
+			cells = append(cells, "<mtd"+attr+">"+mrow(items)+"</mtd>")
+			if p.peek() != '&' {
+				break
+			}
+			p.pos++
+		}
+		rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+		if p.peekCommand() != "\\" {
+			break
+		}
+		p.pos += 2
+		// Allow a final \\ before \end.
+		p.skipSpace()
+		if p.peekCommand() == "end" {
+			break
+		}
+	}
+	if p.peekCommand() != "end" {
+		return "", false, p.errorf("missing \\end{%s}", name)
+	}
+	p.pos += len(`\end`)
+	end, err := p.parseText()
+	if err != nil {
+		return "", false, err
+	}
+	if end != name {
+		return "", false, p.errorf("\\begin{%s} ended by \\end{%s}", name, end)
+	}
+	table := "<mtable>"
+	if name == "aligned" || name == "gathered" {
+		table = `<mtable displaystyle="true">`
+	}
+	table += strings.Join(rows, "") + "</mtable>"
+	if delims[0] == "" && delims[1] == "" {
+		return table, false, nil
+	}
+	return "<mrow>" + fence(delims[0]) + table + fence(delims[1]) + "</mrow>", false, nil
+}
+
+// identifier returns an <mi> element for the letter s, in the current font.
+func (p *parser) identifier(s string) string {
+	if p.variant == "normal" {
+		return `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>"
+	}
+	return "<mi>" + html.EscapeString(p.styled(s)) + "</mi>"
+}
+
+// number returns an <mn> element for the digits s, in the current font.
+func (p *parser) number(s string) string {
+	return "<mn>" + html.EscapeString(p.styled(s)) + "</mn>"
+}
+
+// styled converts the ASCII letters and digits of s to the Unicode
+// mathematical alphanumeric symbols of the current font. Browsers do not
+// consistently support the mathvariant attribute, so fonts are expressed
+// with characters instead.
+func (p *parser) styled(s string) string {
+	f, ok := fonts[p.variant]
+	if !ok {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if e, ok := f.exceptions[r]; ok {
+			b.WriteRune(e)
+			continue
+		}
+		switch {
+		case 'A' <= r && r <= 'Z' && f.upper != 0:
+			b.WriteRune(f.upper + r - 'A')
+		case 'a' <= r && r <= 'z' && f.lower != 0:
+			b.WriteRune(f.lower + r - 'a')
+		case '0' <= r && r <= '9' && f.digit != 0:
+			b.WriteRune(f.digit + r - '0')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func mo(s string) string {
+	return "<mo>" + html.EscapeString(s) + "</mo>"
+}
+
+func elem(name, contents string) string {
+	return "<" + name + ">" + contents + "</" + name + ">"
+}
+
+// mrow returns items as a single element.
+func mrow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return elem("mrow", strings.Join(items, ""))
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texmath
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToMathML(t *testing.T) {
+	for _, test := range []struct {
+		tex     string
+		display bool
+		want    string // contents of <semantics>, without the annotation
+	}{
+		{`x`, false, `<mi>x</mi>`},
+		{`x + 1`, false, `<mrow><mi>x</mi><mo>+</mo><mn>1</mn></mrow>`},
+		{`a - 3.14`, false, `<mrow><mi>a</mi><mo>−</mo><mn>3.14</mn></mrow>`},
+		{`a < b`, false, `<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow>`},
+		{`x^2`, false, `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{`x^23`, false, `<mrow><msup><mi>x</mi><mn>2</mn></msup><mn>3</mn></mrow>`},
+		{`x_i^{n+1}`, false, `<msubsup><mi>x</mi><mi>i</mi><mrow><mi>n</mi><mo>+</mo><mn>1</mn></mrow></msubsup>`},
+		{`f'`, false, `<msup><mi>f</mi><mo>′</mo></msup>`},
+		{`\frac{a}{b}`, false, `<mfrac><mi>a</mi><mi>b</mi></mfrac>`},
+		{`\frac12`, false, `<mfrac><mn>1</mn><mn>2</mn></mfrac>`},
+		{`\sqrt{x}`, false, `<msqrt><mi>x</mi></msqrt>`},
+		{`\sqrt[3]{x}`, false, `<mroot><mi>x</mi><mn>3</mn></mroot>`},
+		{`\alpha \Gamma`, false, `<mrow><mi>α</mi><mi mathvariant="normal">Γ</mi></mrow>`},
+		{`a \le b`, false, `<mrow><mi>a</mi><mo>≤</mo><mi>b</mi></mrow>`},
+		{`\sin x`, false, `<mrow><mi>sin</mi><mi>x</mi></mrow>`},
+		{`\sum_{i=1}^n i`, false,
+			`<mrow><msubsup><mo largeop="true" movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup><mi>i</mi></mrow>`},
+		{`\sum_{i=1}^n i`, true,
+			`<mrow><munderover><mo largeop="true" movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`},
+		{`\lim_{x \to 0}`, true,
+			`<munder><mo movablelimits="true" form="prefix">lim</mo><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder>`},
+		{`\int_0^1`, true, `<msubsup><mo largeop="true">∫</mo><mn>0</mn><mn>1</mn></msubsup>`},
+		{`\mathbb{R}^n`, false, `<msup><mi>ℝ</mi><mi>n</mi></msup>`},
+		{`\mathbf{v1}`, false, `<mrow><mi>𝐯</mi><mn>𝟏</mn></mrow>`},
+		{`\mathrm{d}x`, false, `<mrow><mi mathvariant="normal">d</mi><mi>x</mi></mrow>`},
+		{`\hat{x}`, false, `<mover accent="true"><mi>x</mi><mo stretchy="false">^</mo></mover>`},
+		{`\text{if } x`, false, `<mrow><mtext>if </mtext><mi>x</mi></mrow>`},
+		{`a\,b`, false, `<mrow><mi>a</mi><mspace width="0.1667em"></mspace><mi>b</mi></mrow>`},
+		{`\left( x \right.`, false, `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi></mrow>`},
+		{`\left\{ x \right\}`, false,
+			`<mrow><mo fence="true" stretchy="true">{</mo><mi>x</mi><mo fence="true" stretchy="true">}</mo></mrow>`},
+		{`\begin{pmatrix} a & b \\ c & d \\ \end{pmatrix}`, true,
+			`<mrow><mo fence="true" stretchy="true">(</mo><mtable>` +
+				`<mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr>` +
+				`<mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr>` +
+				`</mtable><mo fence="true" stretchy="true">)</mo></mrow>`},
+		{`\begin{cases} 1 & x > 0 \end{cases}`, true,
+			`<mrow><mo fence="true" stretchy="true">{</mo><mtable><mtr>` +
+				`<mtd columnalign="left"><mn>1</mn></mtd>` +
+				`<mtd columnalign="left"><mrow><mi>x</mi><mo>&gt;</mo><mn>0</mn></mrow></mtd>` +
+				`</mtr></mtable></mrow>`},
+		{`é`, false, `<mi>é</mi>`},
+	} {
+		got, err := ToMathML(test.tex, test.display)
+		if err != nil {
+			t.Errorf("ToMathML(%q): %v", test.tex, err)
+			continue
+		}
+		want := "<math><semantics>"
+		if test.display {
+			want = `<math display="block"><semantics>`
+		}
+		want += test.want
+		s := got.String()
+		if !strings.HasPrefix(s, want+"<annotation") {
+			t.Errorf("ToMathML(%q) =\n%s\nwant prefix\n%s", test.tex, s, want)
+		}
+	}
+}
+
+func TestToMathMLAnnotation(t *testing.T) {
+	got, err := ToMathML(` a<b `, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `<annotation encoding="application/x-tex">a&lt;b</annotation></semantics></math>`
+	if !strings.HasSuffix(got.String(), want) {
+		t.Errorf("got %s, want suffix %s", got, want)
+	}
+}
+
+func TestToMathMLErrors(t *testing.T) {
+	for _, tex := range []string{
+		`\unknown`,
+		`\frac{a}`,
+		`{x`,
+		`x}`,
+		`x^1^2`,
+		`a & b`,
+		`a \\ b`,
+		`\left( x`,
+		`\left< x \right>`,
+		`\begin{foo} x \end{foo}`,
+		`\begin{matrix} x \end{pmatrix}`,
+		`\text{\alpha}`,
+		`x \right)`,
+		strings.Repeat("{", maxDepth+1) + strings.Repeat("}", maxDepth+1),
+		strings.Repeat("x", MaxSourceSize+1),
+	} {
+		if got, err := ToMathML(tex, false); err == nil {
+			t.Errorf("ToMathML(%.40q) = %s, want error", tex, got)
+		}
+	}
+}
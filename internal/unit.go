@@ -53,6 +53,20 @@ type Unit struct {
 	Symbols         map[BuildContext][]*Symbol
 	NumImports      int
 	NumImportedBy   int
+	// TestStats summarizes the tests of a package. It is nil if the package
+	// has no _test.go files, or if they were not counted when it was fetched.
+	TestStats *TestStats
+	// Benchmarks are the names of the benchmark functions of a package,
+	// sorted.
+	Benchmarks []string
+	// FuzzTargets are the fuzz targets of a package, sorted by name.
+	FuzzTargets []*FuzzTarget
+	// CodeIndex holds the code navigation data of a package. It is nil if
+	// the unit is not a package, or is not redistributable.
+	CodeIndex *CodeIndex
+	// Examples are the examples of a package, sorted by name. Like
+	// CodeIndex, they are only set when the module is fetched.
+	Examples []*Example
 
 	// SymbolHistory is a map of symbolName to the version when the symbol was
 	// first added to the package.
@@ -69,6 +83,44 @@ type Documentation struct {
 	Synopsis string
 	Source   []byte // encoded ast.Files; see godoc.Package.Encode
 	API      []*Symbol
+	// Truncated reports whether the documentation of the package is
+	// incomplete because loading it exceeded a resource limit.
+	Truncated bool
+	// ContentHash is a hash of Source. Documentation is encoded
+	// deterministically, so it can be compared to detect whether the
+	// documentation changed when a module is reprocessed.
+	ContentHash string
+}
+
+// TestStats counts the _test.go files of a package and the test functions
+// they declare, as recognized by go test.
+type TestStats struct {
+	NumTestFiles   int
+	NumTests       int
+	NumBenchmarks  int
+	NumFuzzTargets int
+	NumExamples    int
+}
+
+// A FuzzTarget is a fuzz test of a package.
+type FuzzTarget struct {
+	Name string
+	// NumSeeds is the number of files in the seed corpus of the target, which
+	// is the testdata/fuzz/Name directory of the package.
+	NumSeeds int
+}
+
+// An Example is an example function of a package, declared in one of its
+// _test.go files.
+type Example struct {
+	// Name is the name of the function, such as "ExampleClient_Do".
+	Name string
+	Doc  string
+	// Code is a complete program if Runnable is true, and otherwise the body
+	// of the function.
+	Code     string
+	Output   string
+	Runnable bool
 }
 
 // Readme is a README at the specified filepath.
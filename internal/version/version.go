@@ -39,6 +39,10 @@ const (
 
 	// Master represents the master branch.
 	Master = "master"
+
+	// Recommended signifies the version that the owners of a module
+	// recommend, or the latest version if they don't recommend one.
+	Recommended = "recommended"
 )
 
 func (t Type) String() string {
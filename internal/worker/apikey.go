@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/apikey"
+)
+
+// handleAPIKeys creates or revokes an API key of the frontend. If the form
+// value "revoke" is the ID of a key, that key is revoked. Otherwise a key is
+// created from the form values "name", "scopes" (separated by spaces or
+// commas) and "rate_limit", and its value is written to the response. It is
+// not stored, so it cannot be shown again.
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("API keys can only be updated with POST")}
+	}
+	ctx := r.Context()
+	if id := r.FormValue("revoke"); id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("invalid key ID %q", id)}
+		}
+		if err := s.db.RevokeAPIKey(ctx, n); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Revoked key %d.", n)
+		return nil
+	}
+	k, err := parseAPIKey(r)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	key, prefix, err := apikey.Generate()
+	if err != nil {
+		return err
+	}
+	k.Prefix = prefix
+	id, err := s.db.InsertAPIKey(ctx, k, apikey.Hash(key))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Created key %d, %q. Copy it now, it will not be shown again:\n\n%s\n", id, k.Name, key)
+	return nil
+}
+
+// parseAPIKey returns the API key described by the form values of r.
+func parseAPIKey(r *http.Request) (*apikey.Key, error) {
+	k := &apikey.Key{Name: strings.TrimSpace(r.FormValue("name"))}
+	if k.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	scopes, err := apikey.ParseScopes(r.FormValue("scopes"))
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = scopes
+	if l := r.FormValue("rate_limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q", l)
+		}
+		k.RateLimit = n
+	}
+	return k, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/apikey"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	for _, test := range []struct {
+		form    url.Values
+		want    *apikey.Key
+		wantErr bool
+	}{
+		{
+			form: url.Values{"name": {" ci "}, "scopes": {"read, fetch"}, "rate_limit": {"120"}},
+			want: &apikey.Key{Name: "ci", Scopes: []string{"read", "fetch"}, RateLimit: 120},
+		},
+		{
+			form: url.Values{"name": {"admin"}, "scopes": {"admin"}},
+			want: &apikey.Key{Name: "admin", Scopes: []string{"admin"}},
+		},
+		{form: url.Values{"scopes": {"read"}}, wantErr: true},
+		{form: url.Values{"name": {"ci"}}, wantErr: true},
+		{form: url.Values{"name": {"ci"}, "scopes": {"write"}}, wantErr: true},
+		{form: url.Values{"name": {"ci"}, "scopes": {"read"}, "rate_limit": {"-1"}}, wantErr: true},
+	} {
+		r := httptest.NewRequest("POST", "/api-keys", strings.NewReader(test.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		got, err := parseAPIKey(r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: got error %v, want error: %t", test.form, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%v: mismatch (-want, +got):\n%s", test.form, diff)
+		}
+	}
+}
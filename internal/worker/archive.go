@@ -0,0 +1,306 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// An archive holds the processed data of module versions, so that they can
+// be inserted into the database of another instance without fetching them
+// from the proxy and processing them again. It is a gzipped stream of JSON
+// values: an archiveHeader, followed by an archivedModule for each module
+// version.
+const (
+	archiveFormat  = "pkgsite-archive"
+	archiveVersion = 1
+)
+
+type archiveHeader struct {
+	Format  string
+	Version int
+	Created time.Time
+	Since   time.Time
+}
+
+// An archivedModule is a module version in an archive.
+type archivedModule struct {
+	internal.ModuleInfo
+	Licenses       []*licenses.License
+	Units          []*archivedUnit
+	Replacements   []*internal.ModuleReplacement
+	Requirements   []*internal.ModuleRequirement
+	MigrationGuide string
+	Metadata       *internal.ModuleMetadata
+	Guides         []*internal.Readme
+	// Latest holds the latest versions of the module. It is only set on the
+	// first version of each module in the archive.
+	Latest *archivedLatest
+}
+
+// An archivedUnit is a unit of a module version in an archive. It has only
+// the fields of internal.Unit that are inserted by InsertModule.
+type archivedUnit struct {
+	Path              string
+	Name              string
+	IsRedistributable bool
+	Licenses          []*licenses.Metadata
+	Readme            *internal.Readme
+	Documentation     []*internal.Documentation
+	Imports           []string
+	TestStats         *internal.TestStats
+	Benchmarks        []string
+	FuzzTargets       []*internal.FuzzTarget
+	CodeIndex         *internal.CodeIndex
+}
+
+// archivedLatest is an internal.LatestModuleVersions in an archive.
+type archivedLatest struct {
+	RawVersion    string
+	CookedVersion string
+	GoodVersion   string
+	GoMod         []byte
+}
+
+func newArchivedModule(m *internal.Module, lmv *internal.LatestModuleVersions) (*archivedModule, error) {
+	am := &archivedModule{
+		ModuleInfo:     m.ModuleInfo,
+		Licenses:       m.Licenses,
+		Replacements:   m.Replacements,
+		Requirements:   m.Requirements,
+		MigrationGuide: m.MigrationGuide,
+		Metadata:       m.Metadata,
+		Guides:         m.Guides,
+	}
+	for _, u := range m.Units {
+		am.Units = append(am.Units, &archivedUnit{
+			Path:              u.Path,
+			Name:              u.Name,
+			IsRedistributable: u.IsRedistributable,
+			Licenses:          u.Licenses,
+			Readme:            u.Readme,
+			Documentation:     u.Documentation,
+			Imports:           u.Imports,
+			TestStats:         u.TestStats,
+			Benchmarks:        u.Benchmarks,
+			FuzzTargets:       u.FuzzTargets,
+			CodeIndex:         u.CodeIndex,
+		})
+	}
+	if lmv != nil {
+		goMod, err := lmv.GoModFile.Format()
+		if err != nil {
+			return nil, err
+		}
+		am.Latest = &archivedLatest{
+			RawVersion:    lmv.RawVersion,
+			CookedVersion: lmv.CookedVersion,
+			GoodVersion:   lmv.GoodVersion,
+			GoMod:         goMod,
+		}
+	}
+	return am, nil
+}
+
+// module returns the internal.Module of am.
+func (am *archivedModule) module() *internal.Module {
+	m := &internal.Module{
+		ModuleInfo:     am.ModuleInfo,
+		Licenses:       am.Licenses,
+		Replacements:   am.Replacements,
+		Requirements:   am.Requirements,
+		MigrationGuide: am.MigrationGuide,
+		Metadata:       am.Metadata,
+		Guides:         am.Guides,
+	}
+	for _, au := range am.Units {
+		u := &internal.Unit{
+			UnitMeta: internal.UnitMeta{
+				Path:              au.Path,
+				Name:              au.Name,
+				IsRedistributable: au.IsRedistributable,
+				Licenses:          au.Licenses,
+				ModuleInfo:        am.ModuleInfo,
+			},
+			Readme:        au.Readme,
+			Documentation: au.Documentation,
+			Imports:       au.Imports,
+			TestStats:     au.TestStats,
+			Benchmarks:    au.Benchmarks,
+			FuzzTargets:   au.FuzzTargets,
+			CodeIndex:     au.CodeIndex,
+		}
+		for _, d := range u.Documentation {
+			u.BuildContexts = append(u.BuildContexts, internal.BuildContext{GOOS: d.GOOS, GOARCH: d.GOARCH})
+		}
+		m.Units = append(m.Units, u)
+	}
+	return m
+}
+
+// latest returns the latest versions of the module of am, or nil if the
+// archive does not have them.
+func (am *archivedModule) latest() (*internal.LatestModuleVersions, error) {
+	if am.Latest == nil {
+		return nil, nil
+	}
+	l := am.Latest
+	return internal.NewLatestModuleVersions(am.ModulePath, l.RawVersion, l.CookedVersion, l.GoodVersion, l.GoMod)
+}
+
+// ExportArchive writes an archive of the module versions in db that were
+// processed since the given time to w. It returns the number of module
+// versions in the archive.
+func ExportArchive(ctx context.Context, db *postgres.DB, since time.Time, w io.Writer) (n int, err error) {
+	defer derrors.Wrap(&err, "ExportArchive(%s)", since)
+
+	mvs, err := db.GetModuleVersionsUpdatedSince(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	if err := enc.Encode(&archiveHeader{
+		Format:  archiveFormat,
+		Version: archiveVersion,
+		Created: time.Now(),
+		Since:   since,
+	}); err != nil {
+		return 0, err
+	}
+	prevPath := ""
+	for _, mv := range mvs {
+		m, err := db.GetModule(ctx, mv.Path, mv.Version)
+		if err != nil {
+			return n, err
+		}
+		var lmv *internal.LatestModuleVersions
+		if mv.Path != prevPath {
+			lmv, err = db.GetLatestModuleVersions(ctx, mv.Path)
+			if err != nil {
+				return n, err
+			}
+			prevPath = mv.Path
+		}
+		am, err := newArchivedModule(m, lmv)
+		if err != nil {
+			return n, err
+		}
+		if err := enc.Encode(am); err != nil {
+			return n, err
+		}
+		n++
+		log.Debugf(ctx, "exported %s", mv)
+	}
+	if err := zw.Close(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// ImportArchive inserts the module versions in the archive read from r into
+// db, and records them as processed by appVersion. It returns the number of
+// module versions inserted.
+func ImportArchive(ctx context.Context, db *postgres.DB, r io.Reader, appVersion string) (n int, err error) {
+	defer derrors.Wrap(&err, "ImportArchive")
+
+	dec, err := newArchiveDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var am archivedModule
+		err := dec.Decode(&am)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := importModule(ctx, db, &am, appVersion); err != nil {
+			return n, err
+		}
+		n++
+		log.Debugf(ctx, "imported %s@%s", am.ModulePath, am.Version)
+	}
+}
+
+// newArchiveDecoder returns a decoder for the module versions of the archive
+// read from r, after checking its header.
+func newArchiveDecoder(r io.Reader) (*json.Decoder, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(zr)
+	var h archiveHeader
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("reading archive header: %v", err)
+	}
+	if h.Format != archiveFormat {
+		return nil, fmt.Errorf("not an archive: format %q", h.Format)
+	}
+	if h.Version != archiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (want %d)", h.Version, archiveVersion)
+	}
+	return dec, nil
+}
+
+func importModule(ctx context.Context, db *postgres.DB, am *archivedModule, appVersion string) (err error) {
+	defer derrors.Wrap(&err, "importModule(%q, %q)", am.ModulePath, am.Version)
+
+	lmv, err := am.latest()
+	if err != nil {
+		return err
+	}
+	if lmv != nil {
+		if lmv, err = db.UpdateLatestModuleVersions(ctx, lmv); err != nil {
+			return err
+		}
+	} else {
+		// Only the first version of a module carries its latest versions.
+		// Use the ones stored when it was imported, so that InsertModule
+		// does not pick a retracted or incompatible version as the latest
+		// good one.
+		if lmv, err = db.GetLatestModuleVersions(ctx, am.ModulePath); err != nil {
+			return err
+		}
+	}
+	m := am.module()
+	if _, err := db.InsertModule(ctx, m, lmv); err != nil {
+		return err
+	}
+	if err := db.ReconcileSearch(ctx, m.ModulePath, m.Version, http.StatusOK); err != nil {
+		return err
+	}
+	if err := db.InsertIndexVersions(ctx, []*internal.IndexVersion{{
+		Path:      m.ModulePath,
+		Version:   m.Version,
+		Timestamp: time.Now(),
+	}}); err != nil {
+		return err
+	}
+	return db.UpdateModuleVersionState(ctx, &postgres.ModuleVersionStateForUpdate{
+		ModulePath: m.ModulePath,
+		Version:    m.Version,
+		AppVersion: appVersion,
+		Timestamp:  time.Now(),
+		Status:     http.StatusOK,
+		HasGoMod:   m.HasGoMod,
+		GoModPath:  m.ModulePath,
+	})
+}
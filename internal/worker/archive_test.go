@@ -0,0 +1,100 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	m := sample.Module("example.com/m", "v1.2.3", "a", "a/b")
+	lmv, err := internal.NewLatestModuleVersions(m.ModulePath, "v1.2.4", "v1.2.3", "v1.2.3",
+		[]byte("module example.com/m\n\nretract v1.2.4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := newArchivedModule(m, lmv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	if err := enc.Encode(&archiveHeader{Format: archiveFormat, Version: archiveVersion}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(am); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := newArchiveDecoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got archivedModule
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&got); err != io.EOF {
+		t.Fatalf("got %v, want EOF", err)
+	}
+
+	opts := []cmp.Option{
+		cmp.AllowUnexported(source.Info{}),
+		cmpopts.EquateEmpty(),
+		cmpopts.EquateApproxTime(time.Millisecond),
+		cmpopts.IgnoreFields(internal.Unit{}, "Symbols", "Subdirectories", "LicenseContents",
+			"NumImports", "NumImportedBy", "SymbolHistory"),
+		// Units get the ModuleInfo of their module.
+		cmpopts.IgnoreFields(internal.UnitMeta{}, "ModuleInfo"),
+	}
+	if diff := cmp.Diff(m, got.module(), opts...); diff != "" {
+		t.Errorf("module mismatch (-want +got):\n%s", diff)
+	}
+	gotLatest, err := got.latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotLatest.RawVersion != "v1.2.4" || gotLatest.CookedVersion != "v1.2.3" || gotLatest.GoodVersion != "v1.2.3" {
+		t.Errorf("got latest versions %+v", gotLatest)
+	}
+	if len(gotLatest.GoModFile.Retract) != 1 {
+		t.Errorf("got %d retractions, want 1", len(gotLatest.GoModFile.Retract))
+	}
+}
+
+func TestArchiveHeader(t *testing.T) {
+	for _, h := range []archiveHeader{
+		{Format: "other", Version: archiveVersion},
+		{Format: archiveFormat, Version: archiveVersion + 1},
+	} {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if err := json.NewEncoder(zw).Encode(h); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := newArchiveDecoder(&buf); err == nil {
+			t.Errorf("%+v: got nil error, want one", h)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// handleSiteBanner sets the site-wide banner of the frontend to the form value
+// "message". If the message is empty, or the form value "remove" is "true",
+// the banner is removed.
+func (s *Server) handleSiteBanner(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("the banner can only be updated with POST")}
+	}
+	msg := strings.TrimSpace(r.FormValue("message"))
+	if r.FormValue("remove") == "true" {
+		msg = ""
+	}
+	if err := s.db.SetSiteBanner(r.Context(), msg); err != nil {
+		return err
+	}
+	if msg == "" {
+		fmt.Fprint(w, "Removed the banner.")
+	} else {
+		fmt.Fprintf(w, "Set the banner to %q.", msg)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handleCleanCSPReports deletes the CSP violation reports that are older than
+// the configured number of days.
+func (s *Server) handleCleanCSPReports(w http.ResponseWriter, r *http.Request) error {
+	days := s.cfg.CSP.ReportRetentionDays
+	if days <= 0 {
+		return &serverError{http.StatusBadRequest, fmt.Errorf("invalid CSP report retention of %d days", days)}
+	}
+	n, err := s.db.DeleteCSPReports(r.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "deleted %d CSP reports older than %d days", n, days)
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"time"
+
+	"github.com/google/safehtml/template"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/vuln/osv"
+)
+
+// digestPeriod is the period covered by a digest.
+const digestPeriod = 7 * 24 * time.Hour
+
+// A Digest summarizes the versions of the modules under a set of module path
+// prefixes that were released during a period, for teams that track those
+// modules as dependencies.
+type Digest struct {
+	Prefixes []string        `json:"prefixes"`
+	Since    time.Time       `json:"since"`
+	Until    time.Time       `json:"until"`
+	Modules  []*DigestModule `json:"modules"`
+}
+
+// A DigestModule lists the releases of a module in a Digest, along with
+// other notable changes to it.
+type DigestModule struct {
+	ModulePath string           `json:"modulePath"`
+	Releases   []*DigestRelease `json:"releases"`
+	// Retractions are the retract directives of the go.mod file of the
+	// latest version of the module, if it was released during the period.
+	Retractions []*DigestRetraction `json:"retractions,omitempty"`
+	// Vulns are the vulnerabilities in the module that were published
+	// during the period.
+	Vulns []*DigestVuln `json:"vulns,omitempty"`
+}
+
+// A DigestRelease is a release or prerelease version of a module.
+type DigestRelease struct {
+	Version    string    `json:"version"`
+	CommitTime time.Time `json:"commitTime"`
+	// PreviousVersion is the highest earlier version in the series of the
+	// module, which may belong to an earlier major version.
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// NewModule reports whether this is the first version of the series.
+	NewModule bool `json:"newModule,omitempty"`
+	// MajorBump reports whether the major version differs from that of
+	// PreviousVersion.
+	MajorBump bool `json:"majorBump,omitempty"`
+	// Retracted reports whether the latest go.mod file of the module retracts
+	// the version.
+	Retracted bool `json:"retracted,omitempty"`
+}
+
+// A DigestRetraction is a retract directive.
+type DigestRetraction struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// Versions describes the versions retracted by r.
+func (r *DigestRetraction) Versions() string {
+	if r.Low == r.High {
+		return r.Low
+	}
+	return fmt.Sprintf("[%s, %s]", r.Low, r.High)
+}
+
+// A DigestVuln is a vulnerability in a module.
+type DigestVuln struct {
+	ID        string    `json:"id"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Published time.Time `json:"published"`
+	Details   string    `json:"details"`
+}
+
+// handleDigest writes a Digest of the last digestPeriod for the prefixes in
+// the "prefix" query params, or the configured digest prefixes if there are
+// none. The "format" query param selects the output: an HTML page (the
+// default), JSON, or an HTML email message that can be passed to a mail
+// transfer agent.
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleDigest")
+
+	prefixes := r.URL.Query()["prefix"]
+	if len(prefixes) == 0 {
+		prefixes = s.cfg.DigestPrefixes
+	}
+	if len(prefixes) == 0 {
+		return &serverError{http.StatusBadRequest, errors.New("no prefixes")}
+	}
+	format := r.FormValue("format")
+	switch format {
+	case "", "html", "json", "email":
+	default:
+		return &serverError{http.StatusBadRequest, fmt.Errorf("unknown format %q", format)}
+	}
+	until := time.Now().UTC()
+	d, err := s.computeDigest(r.Context(), prefixes, until.Add(-digestPeriod), until)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case "email":
+		w.Header().Set("Content-Type", "message/rfc822")
+		return writeDigestEmail(w, d, s.templates[digestTemplate])
+	default:
+		return renderPage(r.Context(), w, d, s.templates[digestTemplate])
+	}
+}
+
+// computeDigest returns the Digest of the modules under prefixes for the
+// period from since to until.
+func (s *Server) computeDigest(ctx context.Context, prefixes []string, since, until time.Time) (_ *Digest, err error) {
+	defer derrors.Wrap(&err, "computeDigest(%q)", prefixes)
+
+	versions, err := s.db.GetDigestVersions(ctx, prefixes, since)
+	if err != nil {
+		return nil, err
+	}
+	d := &Digest{Prefixes: prefixes, Since: since, Until: until, Modules: []*DigestModule{}}
+	// versions are ordered by module path.
+	for len(versions) > 0 {
+		n := 1
+		for n < len(versions) && versions[n].ModulePath == versions[0].ModulePath {
+			n++
+		}
+		modulePath := versions[0].ModulePath
+		lmv, err := s.db.GetLatestModuleVersions(ctx, modulePath)
+		if err != nil {
+			return nil, err
+		}
+		var vulns []*osv.Entry
+		if s.vulnClient != nil {
+			vulns, err = s.vulnClient.GetByModule(modulePath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		d.Modules = append(d.Modules, newDigestModule(versions[:n], lmv, vulns, since))
+		versions = versions[n:]
+	}
+	return d, nil
+}
+
+// newDigestModule returns the DigestModule for versions, which are of the
+// same module. lmv, which may be nil, describes the latest versions of the
+// module, and vulns are the vulnerabilities in the module.
+func newDigestModule(versions []*postgres.DigestVersion, lmv *internal.LatestModuleVersions, vulns []*osv.Entry, since time.Time) *DigestModule {
+	dm := &DigestModule{ModulePath: versions[0].ModulePath}
+	hasLatest := false
+	for _, v := range versions {
+		r := &DigestRelease{
+			Version:         v.Version,
+			CommitTime:      v.CommitTime,
+			PreviousVersion: v.PreviousVersion,
+			NewModule:       v.PreviousVersion == "",
+			MajorBump:       v.PreviousVersion != "" && semver.Major(v.Version) != semver.Major(v.PreviousVersion),
+		}
+		if lmv != nil {
+			mi := &internal.ModuleInfo{Version: v.Version}
+			lmv.PopulateModuleInfo(mi)
+			r.Retracted = mi.Retracted
+			if v.Version == lmv.RawVersion {
+				hasLatest = true
+			}
+		}
+		dm.Releases = append(dm.Releases, r)
+	}
+	if hasLatest && lmv.GoModFile != nil {
+		for _, r := range lmv.GoModFile.Retract {
+			dm.Retractions = append(dm.Retractions, &DigestRetraction{Low: r.Low, High: r.High, Rationale: r.Rationale})
+		}
+	}
+	for _, e := range vulns {
+		if e.Withdrawn != nil || e.Published.Before(since) {
+			continue
+		}
+		dm.Vulns = append(dm.Vulns, &DigestVuln{
+			ID:        e.ID,
+			Aliases:   e.Aliases,
+			Published: e.Published,
+			Details:   e.Details,
+		})
+	}
+	return dm
+}
+
+// writeDigestEmail writes d to w as an email message, whose HTML body is
+// rendered with tmpl. The message has a subject but no sender or
+// recipients, which are added by whatever sends it.
+func writeDigestEmail(w io.Writer, d *Digest, tmpl *template.Template) (err error) {
+	defer derrors.Wrap(&err, "writeDigestEmail")
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, d); err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("Go module releases from %s to %s",
+		d.Since.Format("2006-01-02"), d.Until.Format("2006-01-02"))
+	if _, err := fmt.Fprintf(w, "Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/html; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: quoted-printable\r\n"+
+		"\r\n", mime.QEncoding.Encode("utf-8", subject)); err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write(body.Bytes()); err != nil {
+		return err
+	}
+	return qw.Close()
+}
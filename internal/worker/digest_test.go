@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/vuln/osv"
+)
+
+func TestNewDigestModule(t *testing.T) {
+	since := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	versions := []*postgres.DigestVersion{
+		{ModulePath: "example.com/m", Version: "v0.9.0"},
+		{ModulePath: "example.com/m", Version: "v1.0.0", PreviousVersion: "v0.9.0"},
+		{ModulePath: "example.com/m", Version: "v1.0.1", PreviousVersion: "v1.0.0"},
+	}
+	lmv, err := internal.NewLatestModuleVersions("example.com/m", "v1.0.1", "v1.0.1", "", []byte(`
+		module example.com/m
+		retract v1.0.0 // broken build
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulns := []*osv.Entry{
+		{ID: "GO-2022-0001", Published: since.AddDate(0, 0, 1), Details: "new"},
+		{ID: "GO-2021-0001", Published: since.AddDate(0, -1, 0), Details: "old"},
+	}
+
+	got := newDigestModule(versions, lmv, vulns, since)
+	want := &DigestModule{
+		ModulePath: "example.com/m",
+		Releases: []*DigestRelease{
+			{Version: "v0.9.0", NewModule: true},
+			{Version: "v1.0.0", PreviousVersion: "v0.9.0", MajorBump: true, Retracted: true},
+			{Version: "v1.0.1", PreviousVersion: "v1.0.0"},
+		},
+		Retractions: []*DigestRetraction{{Low: "v1.0.0", High: "v1.0.0", Rationale: "broken build"}},
+		Vulns:       []*DigestVuln{{ID: "GO-2022-0001", Published: since.AddDate(0, 0, 1), Details: "new"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWriteDigestEmail(t *testing.T) {
+	tmpl, err := parseTemplate(template.TrustedSourceFromConstant("../../static"),
+		template.TrustedSourceFromConstant(digestTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	d := &Digest{
+		Prefixes: []string{"example.com"},
+		Since:    since,
+		Until:    since.Add(digestPeriod),
+		Modules: []*DigestModule{{
+			ModulePath: "example.com/m",
+			Releases:   []*DigestRelease{{Version: "v2.0.0", PreviousVersion: "v1.3.0", MajorBump: true, CommitTime: since}},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := writeDigestEmail(&buf, d, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := msg.Header.Get("Subject"), "Go module releases from 2022-06-01 to 2022-06-08"; got != want {
+		t.Errorf("got subject %q, want %q", got, want)
+	}
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(quotedprintable.NewReader(msg.Body)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"example.com/m", "v2.0.0", "new major version"} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("body does not contain %q", want)
+		}
+	}
+}
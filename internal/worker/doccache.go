@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// docCacheMaxUnused is how long an entry of the doc cache is kept without
+// being used.
+const docCacheMaxUnused = 90 * 24 * time.Hour
+
+// docCache is a fetch.DocCache in the database, so that it is shared by all
+// workers.
+type docCache struct {
+	db *postgres.DB
+}
+
+func (c docCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.db.GetDocCacheEntry(ctx, key)
+}
+
+func (c docCache) Put(ctx context.Context, key, modulePath string, data []byte) error {
+	return c.db.PutDocCacheEntry(ctx, key, fetch.RendererVersion, modulePath, data)
+}
+
+// handleGCDocCache deletes the entries of the doc cache that were produced by
+// older renderer versions, or have not been used for docCacheMaxUnused.
+func (s *Server) handleGCDocCache(w http.ResponseWriter, r *http.Request) error {
+	n, err := s.db.DeleteDocCacheEntries(r.Context(), fetch.RendererVersion, time.Now().Add(-docCacheMaxUnused))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "deleted %d doc cache entries", n)
+	return nil
+}
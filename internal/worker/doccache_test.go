@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestFetchDocCache(t *testing.T) {
+	ctx := context.Background()
+	defer postgres.ResetTestDB(testDB, t)
+
+	// Two versions with the same contents are processed once.
+	files := map[string]string{"a.go": "// Package a is a package.\npackage a\n\nfunc F() {}\n"}
+	prox, teardown := proxytest.SetupTestClient(t, []*proxytest.Module{
+		{ModulePath: "m.com", Version: "v1.0.0", Files: files},
+		{ModulePath: "m.com", Version: "v1.0.1", Files: files},
+	})
+	defer teardown()
+
+	f := &Fetcher{
+		ProxyClient:  prox,
+		SourceClient: source.NewClient(sourceTimeout),
+		DB:           testDB,
+		UseDocCache:  true,
+	}
+	for _, v := range []string{"v1.0.0", "v1.0.1"} {
+		if _, _, err := f.FetchAndUpdateState(ctx, "m.com", v, testAppVersion); err != nil {
+			t.Fatal(err)
+		}
+		um, err := testDB.GetUnitMeta(ctx, "m.com", "m.com", v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if um.Version != v || um.Name != "a" {
+			t.Errorf("%s: got version %q and name %q", v, um.Version, um.Name)
+		}
+	}
+	var n int
+	if err := testDB.Underlying().QueryRow(ctx, `SELECT COUNT(*) FROM doc_cache`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d doc cache entries, want 1", n)
+	}
+}
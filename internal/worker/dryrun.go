@@ -0,0 +1,259 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sort"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// A DryRunReport describes the result of processing a module version
+// without writing it to the database, so that module authors can check that
+// a version will be displayed correctly before tagging it.
+type DryRunReport struct {
+	ModulePath       string `json:"modulePath"`
+	RequestedVersion string `json:"requestedVersion"`
+	ResolvedVersion  string `json:"resolvedVersion,omitempty"`
+	// Status is the status that processing the module version would record
+	// in the module_version_states table.
+	Status    int    `json:"status"`
+	Error     string `json:"error,omitempty"`
+	HasGoMod  bool   `json:"hasGoMod"`
+	GoModPath string `json:"goModPath,omitempty"`
+	// IsRedistributable reports whether the licenses of the module allow
+	// its documentation and source to be shown.
+	IsRedistributable bool             `json:"isRedistributable"`
+	Licenses          []*DryRunLicense `json:"licenses"`
+	Packages          []*DryRunPackage `json:"packages"`
+}
+
+// A DryRunLicense is a license file of a module.
+type DryRunLicense struct {
+	FilePath string   `json:"filePath"`
+	Types    []string `json:"types"`
+	// Unknown reports whether the file did not match any known license. Such
+	// files are held for review when the module is processed.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// A DryRunPackage is a package of a module in a DryRunReport.
+type DryRunPackage struct {
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// IsRedistributable reports whether the licenses that apply to the
+	// package allow its documentation to be shown.
+	IsRedistributable bool   `json:"isRedistributable"`
+	Synopsis          string `json:"synopsis,omitempty"`
+	// Docs describe the documentation of the package in each build context
+	// for which it was rendered.
+	Docs []*DryRunDoc `json:"docs,omitempty"`
+}
+
+// A DryRunDoc describes the documentation of a package for a build context.
+type DryRunDoc struct {
+	BuildContext string `json:"buildContext"`
+	// Truncated reports whether the documentation is incomplete because
+	// loading it exceeded a resource limit.
+	Truncated   bool   `json:"truncated,omitempty"`
+	RenderError string `json:"renderError,omitempty"`
+}
+
+// handleDryRun handles requests for /dry-run/<module>/@v/<version>. It
+// fetches and processes the module version as /fetch does, and renders the
+// documentation of its packages, but does not write anything to the
+// database. It writes a DryRunReport of the result as JSON.
+func (s *Server) handleDryRun(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleDryRun")
+
+	modulePath, requestedVersion, err := parseModulePathAndVersion(r.URL.Path)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	f := &Fetcher{
+		ProxyClient:  s.proxyClient,
+		SourceClient: s.sourceClient,
+		DB:           s.db,
+		loadShedder:  s.loadShedder,
+	}
+	report, err := f.DryRun(r.Context(), modulePath, requestedVersion)
+	if err != nil {
+		return &serverError{derrors.ToStatus(err), err}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// DryRun fetches and processes a module version, and renders the
+// documentation of its packages, without writing to the database or the
+// cache. Errors processing the module are described by the returned report;
+// DryRun only returns an error if the module is excluded or cannot be
+// processed right now.
+func (f *Fetcher) DryRun(ctx context.Context, modulePath, requestedVersion string) (_ *DryRunReport, err error) {
+	defer derrors.Wrap(&err, "DryRun(%q, %q)", modulePath, requestedVersion)
+
+	proxyGetter := fetch.NewProxyModuleGetter(f.ProxyClient, f.SourceClient)
+	fr, _, err := f.fetchWithoutInserting(ctx, modulePath, requestedVersion, proxyGetter, -1)
+	if err != nil {
+		return nil, err
+	}
+	return newDryRunReport(ctx, fr), nil
+}
+
+// fetchWithoutInserting fetches and processes a module version from mg like
+// fetchAndInsertModule, but does not write anything to the database. It also
+// returns the contents of the module, which are nil if they could not be
+// read. zipSize is the size of the module zip, used for load shedding, or -1
+// if it must be obtained from the proxy.
+//
+// Errors processing the module are recorded in the FetchResult.
+// fetchWithoutInserting only returns an error if the module is excluded or
+// load must be shed.
+func (f *Fetcher) fetchWithoutInserting(ctx context.Context, modulePath, requestedVersion string, mg fetch.ModuleGetter, zipSize int64) (_ *fetch.FetchResult, contentDir fs.FS, err error) {
+	exc, err := f.DB.IsExcluded(ctx, modulePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exc {
+		return nil, nil, derrors.Excluded
+	}
+	failed := func(resolvedVersion string, err error) *fetch.FetchResult {
+		return &fetch.FetchResult{
+			ModulePath:       modulePath,
+			RequestedVersion: requestedVersion,
+			ResolvedVersion:  resolvedVersion,
+			Status:           derrors.ToStatus(err),
+			Error:            err,
+		}
+	}
+	info, err := fetch.GetInfo(ctx, modulePath, requestedVersion, mg)
+	if err != nil {
+		return failed("", err), nil, nil
+	}
+	var deferFunc func()
+	if zipSize < 0 {
+		deferFunc, _, err = f.maybeShed(ctx, modulePath, info.Version)
+	} else {
+		deferFunc, err = f.shedBySize(ctx, modulePath, info.Version, zipSize)
+	}
+	defer deferFunc()
+	if errors.Is(err, derrors.SheddingLoad) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return failed(info.Version, err), nil, nil
+	}
+	cr := &contentRecorder{ModuleGetter: mg}
+	return fetch.FetchModule(ctx, modulePath, requestedVersion, cr), cr.contentDir, nil
+}
+
+// A contentRecorder is a fetch.ModuleGetter that records the contents of
+// the last module it returned.
+type contentRecorder struct {
+	fetch.ModuleGetter
+	contentDir fs.FS
+}
+
+// ContentDir returns an fs.FS for the module's contents.
+func (g *contentRecorder) ContentDir(ctx context.Context, path, version string) (fs.FS, error) {
+	dir, err := g.ModuleGetter.ContentDir(ctx, path, version)
+	g.contentDir = dir
+	return dir, err
+}
+
+// newDryRunReport returns the DryRunReport of fr, rendering the
+// documentation of its packages.
+func newDryRunReport(ctx context.Context, fr *fetch.FetchResult) *DryRunReport {
+	report := &DryRunReport{
+		ModulePath:       fr.ModulePath,
+		RequestedVersion: fr.RequestedVersion,
+		ResolvedVersion:  fr.ResolvedVersion,
+		Status:           fr.Status,
+		HasGoMod:         fr.HasGoMod,
+		GoModPath:        fr.GoModPath,
+		Licenses:         []*DryRunLicense{},
+		Packages:         []*DryRunPackage{},
+	}
+	if fr.Error != nil {
+		report.Error = fr.Error.Error()
+	}
+	units := map[string]*internal.Unit{}
+	if m := fr.Module; m != nil {
+		report.IsRedistributable = m.IsRedistributable
+		for _, l := range m.Licenses {
+			report.Licenses = append(report.Licenses, &DryRunLicense{
+				FilePath: l.FilePath,
+				Types:    l.Types,
+				Unknown:  l.IsUnknown(),
+			})
+		}
+		for _, u := range m.Units {
+			units[u.Path] = u
+		}
+	}
+	for _, pvs := range fr.PackageVersionStates {
+		p := &DryRunPackage{
+			Path:   pvs.PackagePath,
+			Status: pvs.Status,
+			Error:  pvs.Error,
+		}
+		if u := units[pvs.PackagePath]; u != nil {
+			p.IsRedistributable = u.IsRedistributable
+			for _, d := range u.Documentation {
+				if p.Synopsis == "" {
+					p.Synopsis = d.Synopsis
+				}
+				p.Docs = append(p.Docs, &DryRunDoc{
+					BuildContext: d.GOOS + "/" + d.GOARCH,
+					Truncated:    d.Truncated,
+					RenderError:  renderError(ctx, u, d),
+				})
+			}
+		}
+		report.Packages = append(report.Packages, p)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Path < report.Packages[j].Path
+	})
+	return report
+}
+
+// renderError renders the documentation d of the unit u, and returns the
+// text of the error, if any.
+func renderError(ctx context.Context, u *internal.Unit, d *internal.Documentation) string {
+	docPkg, err := godoc.DecodePackage(d.Source)
+	if err != nil {
+		return err.Error()
+	}
+	var innerPath string
+	if u.ModulePath == stdlib.ModulePath {
+		innerPath = u.Path
+	} else if u.Path != u.ModulePath {
+		innerPath = u.Path[len(u.ModulePath)+1:]
+	}
+	modInfo := &godoc.ModuleInfo{
+		ModulePath:      u.ModulePath,
+		ResolvedVersion: u.Version,
+	}
+	bc := internal.BuildContext{GOOS: d.GOOS, GOARCH: d.GOARCH}
+	if _, err := docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, nil, nil, bc); err != nil {
+		log.Infof(ctx, "dry run: rendering %s for %s: %v", u.Path, bc, err)
+		return err.Error()
+	}
+	return ""
+}
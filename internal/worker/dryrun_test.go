@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/proxy/proxytest"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	defer postgres.ResetTestDB(testDB, t)
+
+	prox, teardown := proxytest.SetupTestClient(t, testModules)
+	defer teardown()
+	f := &Fetcher{
+		ProxyClient:  prox,
+		SourceClient: source.NewClient(sourceTimeout),
+		DB:           testDB,
+	}
+
+	const modulePath = "example.com/nonredist"
+	got, err := f.DryRun(ctx, modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != http.StatusOK || got.ResolvedVersion != "v1.0.0" || !got.IsRedistributable {
+		t.Errorf("got status %d, resolved version %q, redistributable %t; want 200, v1.0.0, true",
+			got.Status, got.ResolvedVersion, got.IsRedistributable)
+	}
+	var unknown []string
+	for _, l := range got.Licenses {
+		if l.Unknown {
+			unknown = append(unknown, l.FilePath)
+		}
+	}
+	if diff := cmp.Diff([]string{"unk/LICENSE.md"}, unknown); diff != "" {
+		t.Errorf("unknown licenses mismatch (-want, +got):\n%s", diff)
+	}
+	redist := map[string]bool{}
+	for _, p := range got.Packages {
+		redist[p.Path] = p.IsRedistributable
+		for _, d := range p.Docs {
+			if d.RenderError != "" {
+				t.Errorf("%s, %s: got render error %q", p.Path, d.BuildContext, d.RenderError)
+			}
+		}
+	}
+	wantRedist := map[string]bool{
+		"example.com/nonredist/bar":     true,
+		"example.com/nonredist/bar/baz": true,
+		"example.com/nonredist/unk":     false,
+	}
+	if diff := cmp.Diff(wantRedist, redist); diff != "" {
+		t.Errorf("redistributable packages mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Nothing was written to the database.
+	if _, err := testDB.GetModuleInfo(ctx, modulePath, "v1.0.0"); err == nil {
+		t.Error("module was inserted into the database")
+	}
+
+	// An unknown version is reported, not returned as an error.
+	got, err = f.DryRun(ctx, modulePath, "v9.9.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != http.StatusNotFound || got.Error == "" {
+		t.Errorf("got status %d, error %q; want 404 and an error", got.Status, got.Error)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// handleExperiments sets or removes an experiment in the database, using the
+// form values "name", "rollout", "allowlist" (a comma-separated list of IP
+// addresses and CIDR ranges) and "description". If the form value "remove" is
+// "true", the experiment named "name" is removed instead.
+func (s *Server) handleExperiments(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("experiments can only be updated with POST")}
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		return &serverError{http.StatusBadRequest, errors.New("need name form value")}
+	}
+	if r.FormValue("remove") == "true" {
+		err := s.db.RemoveExperiment(r.Context(), name)
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, err}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed experiment %q.", name)
+		return nil
+	}
+	e, err := parseExperiment(name, r.FormValue("rollout"), r.FormValue("allowlist"), r.FormValue("description"))
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	if err := s.db.UpsertExperiment(r.Context(), e); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Set experiment %q to %d%%.", e.Name, e.Rollout)
+	return nil
+}
+
+// parseExperiment returns the experiment described by the given form values.
+// The experiment must be one of internal.Experiments, and its description
+// defaults to the one there.
+func parseExperiment(name, rollout, allowlist, description string) (*internal.Experiment, error) {
+	desc, ok := internal.Experiments[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown experiment %q", name)
+	}
+	r, err := strconv.ParseUint(rollout, 10, 0)
+	if err != nil || r > 100 {
+		return nil, fmt.Errorf("rollout must be a number from 0 to 100, not %q", rollout)
+	}
+	e := &internal.Experiment{Name: name, Rollout: uint(r), Description: desc}
+	if d := strings.TrimSpace(description); d != "" {
+		e.Description = d
+	}
+	for _, a := range strings.Split(allowlist, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(a); err != nil && net.ParseIP(a) == nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", a)
+		}
+		e.Allowlist = append(e.Allowlist, a)
+	}
+	return e, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestParseExperiment(t *testing.T) {
+	name := internal.ExperimentStyleGuide
+	got, err := parseExperiment(name, "5", " 1.2.3.4, 10.0.0.0/8 ,", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &internal.Experiment{
+		Name:        name,
+		Rollout:     5,
+		Description: internal.Experiments[name],
+		Allowlist:   []string{"1.2.3.4", "10.0.0.0/8"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, test := range []struct {
+		name, rollout, allowlist string
+	}{
+		{"no-such-experiment", "5", ""},
+		{name, "", ""},
+		{name, "101", ""},
+		{name, "-1", ""},
+		{name, "5", "example.com"},
+	} {
+		if _, err := parseExperiment(test.name, test.rollout, test.allowlist, ""); err == nil {
+			t.Errorf("parseExperiment(%q, %q, %q): got nil error, want error", test.name, test.rollout, test.allowlist)
+		}
+	}
+}
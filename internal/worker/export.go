@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// handleExport exports the tables in postgres.ExportTables to the export
+// bucket, so that the corpus can be analyzed without querying the database.
+// Each table is written as gzipped newline-delimited JSON to an object named
+// DATE/TABLE.json.gz, which can be loaded into BigQuery. The "table" query
+// parameter restricts the export to a single table.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleExport")
+
+	if s.cfg.ExportBucket == "" {
+		return &serverError{http.StatusBadRequest, errors.New("no export bucket configured")}
+	}
+	tables := postgres.ExportTables
+	if t := r.FormValue("table"); t != "" {
+		if !isExportTable(t) {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("unknown table %q", t)}
+		}
+		tables = []string{t}
+	}
+
+	ctx := r.Context()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	bucket := client.Bucket(s.cfg.ExportBucket)
+	dir := time.Now().UTC().Format("2006-01-02")
+	for _, t := range tables {
+		name := path.Join(dir, t+".json.gz")
+		n, err := exportTable(ctx, s.db, t, bucket.Object(name))
+		if err != nil {
+			return err
+		}
+		log.Infof(ctx, "exported %d rows of %s to gs://%s/%s", n, t, s.cfg.ExportBucket, name)
+		fmt.Fprintf(w, "exported %d rows of %s to gs://%s/%s\n", n, t, s.cfg.ExportBucket, name)
+	}
+	return nil
+}
+
+// exportTable writes the named table to obj, compressed with gzip. If the
+// export fails, obj is left unchanged.
+func exportTable(ctx context.Context, db *postgres.DB, table string, obj *storage.ObjectHandle) (n int, err error) {
+	defer derrors.Wrap(&err, "exportTable(%q)", table)
+
+	// Canceling the context passed to NewWriter aborts the upload.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ow := obj.NewWriter(ctx)
+	ow.ContentType = "application/json"
+	gw := gzip.NewWriter(ow)
+	n, err = db.ExportTable(ctx, table, gw)
+	if err != nil {
+		return 0, err
+	}
+	if err := gw.Close(); err != nil {
+		return 0, err
+	}
+	if err := ow.Close(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func isExportTable(name string) bool {
+	for _, t := range postgres.ExportTables {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,7 @@ import (
 	"go.opencensus.io/trace"
 	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/analysis"
 	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -100,6 +101,12 @@ type Fetcher struct {
 	Cache        *cache.Cache
 	loadShedder  *loadShedder
 	Source       string
+	// UseDocCache is whether to look up the processed packages of modules
+	// in the doc cache of DB before processing them, and to store them
+	// there afterwards.
+	UseDocCache bool
+	// Analyzers are run over each module version after it is inserted.
+	Analyzers []analysis.Analyzer
 }
 
 // FetchAndUpdateState fetches and processes a module version, and then updates
@@ -311,6 +318,9 @@ func (f *Fetcher) fetchAndInsertModule(ctx context.Context, modulePath, requeste
 		return ft
 	}
 
+	if f.UseDocCache {
+		ctx = fetch.NewContextWithDocCache(ctx, docCache{f.DB})
+	}
 	proxyGetter := fetch.NewProxyModuleGetter(f.ProxyClient, f.SourceClient)
 	// Fetch the module, and the current @main and @master version of this module.
 	// The @main and @master version will be used to update the version_map
@@ -361,6 +371,12 @@ func (f *Fetcher) fetchAndInsertModule(ctx context.Context, modulePath, requeste
 	// The module was successfully fetched.
 	log.Debugf(ctx, "fetch.FetchModule succeeded for %s@%s", ft.ModulePath, ft.RequestedVersion)
 
+	if err := reviewUnknownLicenses(ctx, f.DB, ft.Module); err != nil {
+		// Don't fail the fetch; the unknown licenses will be considered again
+		// the next time the module is processed.
+		log.Errorf(ctx, "%v", err)
+	}
+
 	// Determine the current latest-version information for this module.
 
 	start := time.Now()
@@ -388,9 +404,28 @@ func (f *Fetcher) fetchAndInsertModule(ctx context.Context, modulePath, requeste
 			log.Debugf(ctx, "invalidated cache for %s", ft.ModulePath)
 		}
 	}
+	if len(f.Analyzers) > 0 {
+		start = time.Now()
+		f.analyze(ctx, ft.Module)
+		ft.timings["analyze"] = time.Since(start)
+	}
 	return ft
 }
 
+// analyze runs f.Analyzers over m and stores their results. An analyzer that
+// fails does not fail the fetch; its earlier results, if any, are kept.
+func (f *Fetcher) analyze(ctx context.Context, m *internal.Module) {
+	for _, a := range f.Analyzers {
+		results, err := analysis.Run(ctx, a, m)
+		if err == nil {
+			err = f.DB.UpsertAnalysisResults(ctx, m.ModulePath, m.Version, a.Name(), results)
+		}
+		if err != nil {
+			log.Errorf(ctx, "%v", err)
+		}
+	}
+}
+
 // invalidateCache deletes the series path for modulePath, as well as any
 // possible URL path of which it is a componentwise prefix. That is, it deletes
 // example.com/mod, example.com/mod@v1.2.3 and example.com/mod/pkg, but not the
@@ -552,6 +587,19 @@ func (f *Fetcher) maybeShed(ctx context.Context, modulePath, version string) (fu
 	if err != nil {
 		return func() {}, 0, err
 	}
+	deferFunc, err := f.shedBySize(ctx, modulePath, version, zipSize)
+	if err != nil {
+		return deferFunc, 0, err
+	}
+	return deferFunc, zipSize, nil
+}
+
+// shedBySize is like maybeShed, for a module version whose zip size is
+// known.
+func (f *Fetcher) shedBySize(ctx context.Context, modulePath, version string, zipSize int64) (func(), error) {
+	if f.loadShedder == nil {
+		return func() {}, nil
+	}
 	// Load shed or mark module as too large.
 	// We treat zip size as a proxy for the total memory consumed by
 	// processing a module, and use it to decide whether we can currently
@@ -559,14 +607,14 @@ func (f *Fetcher) maybeShed(ctx context.Context, modulePath, version string) (fu
 	shouldShed, deferFunc := f.loadShedder.shouldShed(uint64(zipSize))
 	if shouldShed {
 		stats.Record(ctx, fetchesShedded.M(1))
-		return deferFunc, 0, fmt.Errorf("%w: size=%dMi", derrors.SheddingLoad, zipSize/mib)
+		return deferFunc, fmt.Errorf("%w: size=%dMi", derrors.SheddingLoad, zipSize/mib)
 	}
 	if zipSize > maxModuleZipSize {
 		log.Warningf(ctx, "FetchModule: %s@%s zip size %dMi exceeds max %dMi",
 			modulePath, version, zipSize/mib, maxModuleZipSize/mib)
-		return deferFunc, 0, derrors.ModuleTooLarge
+		return deferFunc, derrors.ModuleTooLarge
 	}
-	return deferFunc, zipSize, nil
+	return deferFunc, nil
 }
 
 func getZipSize(ctx context.Context, modulePath, resolvedVersion string, prox *proxy.Client) (_ int64, err error) {
@@ -0,0 +1,85 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// handleHomepageSections adds or removes a curated section of the frontend
+// homepage. If the form value "remove" is the ID of a section, that section
+// is removed. Otherwise a section is added from the form values "kind",
+// "title", "description", "paths" (separated by whitespace or commas),
+// "prefix" and "position".
+func (s *Server) handleHomepageSections(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("homepage sections can only be updated with POST")}
+	}
+	ctx := r.Context()
+	if id := r.FormValue("remove"); id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("invalid section ID %q", id)}
+		}
+		if err := s.db.DeleteHomepageSection(ctx, n); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed section %d.", n)
+		return nil
+	}
+	sec, err := parseHomepageSection(r)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	id, err := s.db.InsertHomepageSection(ctx, sec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Added section %d, %q.", id, sec.Title)
+	return nil
+}
+
+// parseHomepageSection returns the homepage section described by the form
+// values of r.
+func parseHomepageSection(r *http.Request) (*internal.HomepageSection, error) {
+	sec := &internal.HomepageSection{
+		Kind:        r.FormValue("kind"),
+		Title:       strings.TrimSpace(r.FormValue("title")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		PathPrefix:  strings.TrimSpace(r.FormValue("prefix")),
+		Paths: strings.FieldsFunc(r.FormValue("paths"), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		}),
+	}
+	if p := r.FormValue("position"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q", p)
+		}
+		sec.Position = n
+	}
+	if sec.Title == "" {
+		return nil, errors.New("missing title")
+	}
+	switch sec.Kind {
+	case internal.HomepagePinned, internal.HomepageCategory:
+		if len(sec.Paths) == 0 {
+			return nil, fmt.Errorf("a %s section needs paths", sec.Kind)
+		}
+	case internal.HomepageRecent:
+		if sec.PathPrefix == "" {
+			return nil, errors.New("a recent section needs a path prefix")
+		}
+	default:
+		return nil, fmt.Errorf("unknown section kind %q", sec.Kind)
+	}
+	return sec, nil
+}
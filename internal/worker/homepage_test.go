@@ -0,0 +1,49 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestParseHomepageSection(t *testing.T) {
+	for _, test := range []struct {
+		form    url.Values
+		want    *internal.HomepageSection
+		wantErr bool
+	}{
+		{
+			form: url.Values{"kind": {"pinned"}, "title": {" Pinned "}, "paths": {"corp.com/a, corp.com/b\ncorp.com/c"}, "position": {"2"}},
+			want: &internal.HomepageSection{Kind: internal.HomepagePinned, Title: "Pinned", Position: 2,
+				Paths: []string{"corp.com/a", "corp.com/b", "corp.com/c"}},
+		},
+		{
+			form: url.Values{"kind": {"recent"}, "title": {"Recent"}, "prefix": {"corp.com/"}},
+			want: &internal.HomepageSection{Kind: internal.HomepageRecent, Title: "Recent", PathPrefix: "corp.com/", Paths: []string{}},
+		},
+		{form: url.Values{"kind": {"recent"}, "title": {"Recent"}}, wantErr: true},
+		{form: url.Values{"kind": {"category"}, "title": {"Databases"}}, wantErr: true},
+		{form: url.Values{"kind": {"pinned"}, "paths": {"corp.com/a"}}, wantErr: true},
+		{form: url.Values{"kind": {"other"}, "title": {"Other"}, "paths": {"corp.com/a"}}, wantErr: true},
+		{form: url.Values{"kind": {"pinned"}, "title": {"P"}, "paths": {"corp.com/a"}, "position": {"x"}}, wantErr: true},
+	} {
+		r := httptest.NewRequest("POST", "/homepage-sections", strings.NewReader(test.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		got, err := parseHomepageSection(r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: got error %v, want error: %t", test.form, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%v: mismatch (-want, +got):\n%s", test.form, diff)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// maxLicenseSampleSize is the maximum number of bytes of an unknown license
+// file that are stored for review.
+const maxLicenseSampleSize = 4096
+
+// reviewUnknownLicenses looks for license files in m that did not match any
+// known license. If an operator has reviewed the file, the license types they
+// chose replace the detected ones, and the redistributability of m and its
+// units is recomputed. Otherwise, the file is added to the review queue.
+func reviewUnknownLicenses(ctx context.Context, db *postgres.DB, m *internal.Module) (err error) {
+	defer derrors.Wrap(&err, "reviewUnknownLicenses(%q, %q)", m.ModulePath, m.Version)
+
+	var unknown []*licenses.License
+	for _, l := range m.Licenses {
+		if l.IsUnknown() {
+			unknown = append(unknown, l)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	reviews, err := db.GetLicenseReviews(ctx, m.ModulePath)
+	if err != nil {
+		return err
+	}
+	decisions := map[string][]string{}
+	for _, r := range reviews {
+		if r.IsReviewed() {
+			decisions[r.FilePath+" "+r.ContentsHash] = r.Types
+		}
+	}
+	changed := false
+	for _, l := range unknown {
+		hash := licenseContentsHash(l.Contents)
+		if types, ok := decisions[l.FilePath+" "+hash]; ok {
+			// The Metadata is shared with the units, so this changes their
+			// licenses as well.
+			l.Types = types
+			changed = true
+			continue
+		}
+		if err := db.InsertUnknownLicense(ctx, &postgres.LicenseReview{
+			ModulePath:   m.ModulePath,
+			FilePath:     l.FilePath,
+			ContentsHash: hash,
+			Version:      m.Version,
+			Sample:       licenseSample(l.Contents),
+		}); err != nil {
+			return err
+		}
+	}
+	if changed {
+		recomputeRedistributability(m)
+	}
+	return nil
+}
+
+// licenseContentsHash returns a string that identifies the contents of a
+// license file.
+func licenseContentsHash(contents []byte) string {
+	h := sha256.Sum256(contents)
+	return hex.EncodeToString(h[:])
+}
+
+// licenseSample returns a prefix of contents suitable for showing to an
+// operator.
+func licenseSample(contents []byte) string {
+	if len(contents) > maxLicenseSampleSize {
+		contents = contents[:maxLicenseSampleSize]
+		// Don't cut a multi-byte character in half.
+		for len(contents) > 0 && !utf8.Valid(contents) {
+			contents = contents[:len(contents)-1]
+		}
+	}
+	return strings.ToValidUTF8(string(contents), "�")
+}
+
+// recomputeRedistributability recomputes whether m and its units are
+// redistributable from their license types, following the same rules as
+// licenses.Detector: the module is redistributable if its top-level licenses
+// are, and a unit is redistributable if the module is and the licenses in the
+// unit's directory and the directories above it, other than the module root,
+// are.
+func recomputeRedistributability(m *internal.Module) {
+	var rootTypes []string
+	for _, l := range m.Licenses {
+		if path.Dir(l.FilePath) == "." {
+			rootTypes = append(rootTypes, l.Types...)
+		}
+	}
+	m.IsRedistributable = licenses.Redistributable(rootTypes)
+	for _, u := range m.Units {
+		u.ModuleInfo.IsRedistributable = m.IsRedistributable
+		var dirTypes []string
+		for _, l := range u.Licenses {
+			if path.Dir(l.FilePath) != "." {
+				dirTypes = append(dirTypes, l.Types...)
+			}
+		}
+		u.IsRedistributable = m.IsRedistributable && (len(dirTypes) == 0 || licenses.Redistributable(dirTypes))
+	}
+}
+
+// handleLicenseReviews serves the license review queue. A POST records an
+// operator's decision about one license file, using the form values "module",
+// "file" and "hash" to identify it. Either "types", a comma-separated list of
+// license types, or "proprietary=true" must be provided.
+func (s *Server) handleLicenseReviews(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return s.doLicenseReviewsPage(w, r)
+	}
+	modulePath := r.FormValue("module")
+	filePath := r.FormValue("file")
+	hash := r.FormValue("hash")
+	if modulePath == "" || filePath == "" || hash == "" {
+		return &serverError{http.StatusBadRequest, errors.New("need module, file and hash form values")}
+	}
+	var types []string
+	if r.FormValue("proprietary") == "true" {
+		types = []string{licenses.ProprietaryLicenseType}
+	} else {
+		types = parseLicenseTypes(r.FormValue("types"))
+	}
+	if len(types) == 0 {
+		return &serverError{http.StatusBadRequest, errors.New("need types or proprietary form value")}
+	}
+	user := r.Header.Get("X-Goog-Authenticated-User-Email")
+	if user == "" {
+		user = "unknown"
+	}
+	err := s.db.ReviewLicense(r.Context(), modulePath, filePath, hash, types, user)
+	if errors.Is(err, derrors.NotFound) {
+		return &serverError{http.StatusNotFound, err}
+	}
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/license-reviews", http.StatusSeeOther)
+	return nil
+}
+
+// parseLicenseTypes parses a comma-separated list of license types.
+func parseLicenseTypes(s string) []string {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
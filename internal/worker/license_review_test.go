@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+func TestRecomputeRedistributability(t *testing.T) {
+	root := &licenses.Metadata{Types: []string{"MIT"}, FilePath: "LICENSE"}
+	sub := &licenses.Metadata{Types: []string{licenses.ProprietaryLicenseType}, FilePath: "a/LICENSE"}
+	unit := func(path string, lics ...*licenses.Metadata) *internal.Unit {
+		return &internal.Unit{UnitMeta: internal.UnitMeta{Path: path, Licenses: lics}}
+	}
+	m := &internal.Module{
+		Licenses: []*licenses.License{{Metadata: root}, {Metadata: sub}},
+		Units: []*internal.Unit{
+			unit("m", root),
+			unit("m/a", sub, root),
+			unit("m/b", root),
+		},
+	}
+	recomputeRedistributability(m)
+	if !m.IsRedistributable {
+		t.Error("module: got not redistributable, want redistributable")
+	}
+	var got []bool
+	for _, u := range m.Units {
+		if u.ModuleInfo.IsRedistributable != m.IsRedistributable {
+			t.Errorf("%s: module info not updated", u.Path)
+		}
+		got = append(got, u.IsRedistributable)
+	}
+	if diff := cmp.Diff([]bool{true, false, true}, got); diff != "" {
+		t.Errorf("units mismatch (-want +got):\n%s", diff)
+	}
+
+	// Marking the root license as proprietary makes everything non-redistributable.
+	root.Types = []string{licenses.ProprietaryLicenseType}
+	recomputeRedistributability(m)
+	if m.IsRedistributable {
+		t.Error("module: got redistributable, want not redistributable")
+	}
+	for _, u := range m.Units {
+		if u.IsRedistributable {
+			t.Errorf("%s: got redistributable, want not redistributable", u.Path)
+		}
+	}
+}
+
+func TestLicenseSample(t *testing.T) {
+	if got, want := licenseSample([]byte("short")), "short"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// A long file ending in multi-byte characters is cut at a character boundary.
+	long := strings.Repeat("a", maxLicenseSampleSize-1) + "世界"
+	got := licenseSample([]byte(long))
+	if len(got) > maxLicenseSampleSize || !utf8.ValidString(got) {
+		t.Errorf("got sample of length %d, valid UTF-8 = %t", len(got), utf8.ValidString(got))
+	}
+}
+
+func TestParseLicenseTypes(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"MIT", []string{"MIT"}},
+		{" MIT ,Apache-2.0,, ", []string{"MIT", "Apache-2.0"}},
+	} {
+		if diff := cmp.Diff(test.want, parseLicenseTypes(test.in)); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.in, diff)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// handleBackfillLicenseSearch computes the search tokens of licenses that
+// don't have them, up to the "limit" query param.
+func (s *Server) handleBackfillLicenseSearch(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	n, err := s.db.BackfillLicenseSearch(ctx, parseLimitParam(r, 1000))
+	if err != nil {
+		return err
+	}
+	pending, err := s.db.CountLicenseSearchPending(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "computed search tokens for %d licenses; %d licenses pending", n, pending)
+	return nil
+}
@@ -0,0 +1,363 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	modzip "golang.org/x/mod/zip"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// The checks of a LintWarning.
+const (
+	lintLicense  = "license"
+	lintReadme   = "readme"
+	lintPackage  = "package"
+	lintDocLink  = "doc-link"
+	lintFileSize = "file-size"
+)
+
+// A LintReport lists the problems that would affect how a module version is
+// displayed, so that module authors can fix them before tagging it.
+type LintReport struct {
+	ModulePath string `json:"modulePath"`
+	Version    string `json:"version,omitempty"`
+	// Status and Error describe the result of processing the module
+	// version, as in a DryRunReport. There are no warnings if the module
+	// could not be processed.
+	Status   int            `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Warnings []*LintWarning `json:"warnings"`
+}
+
+// A LintWarning is a problem found by a check of a LintReport.
+type LintWarning struct {
+	Check string `json:"check"`
+	// Path is the package path, or the path of the file relative to the
+	// module root, that the warning is about. It is empty for warnings about
+	// the whole module.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// handleLint handles requests for /lint/<module>/@v/<version>, and POST
+// requests for /lint/ whose body is a module zip. It processes the module
+// version from the proxy or the zip without writing to the database, and
+// writes a LintReport of the result as JSON. The version can be a branch or
+// a commit, which the proxy resolves.
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleLint")
+
+	f := &Fetcher{
+		ProxyClient:  s.proxyClient,
+		SourceClient: s.sourceClient,
+		DB:           s.db,
+		loadShedder:  s.loadShedder,
+	}
+	var report *LintReport
+	if r.Method == http.MethodPost {
+		if r.URL.Path != "/" {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("unexpected path %q for a zip", r.URL.Path)}
+		}
+		maxSize := maxModuleZipSize
+		if maxSize > modzip.MaxZipFile {
+			maxSize = modzip.MaxZipFile
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+		mg, err := fetch.NewZipModuleGetter(zr)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+		report, err = f.lint(r.Context(), mg.ModulePath(), mg.Version(), mg, int64(len(data)))
+		if err != nil {
+			return &serverError{derrors.ToStatus(err), err}
+		}
+	} else {
+		modulePath, requestedVersion, err := parseModulePathAndVersion(r.URL.Path)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+		report, err = f.Lint(r.Context(), modulePath, requestedVersion)
+		if err != nil {
+			return &serverError{derrors.ToStatus(err), err}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// Lint processes a module version like DryRun, and returns the problems that
+// would affect how it is displayed.
+func (f *Fetcher) Lint(ctx context.Context, modulePath, requestedVersion string) (_ *LintReport, err error) {
+	defer derrors.Wrap(&err, "Lint(%q, %q)", modulePath, requestedVersion)
+
+	proxyGetter := fetch.NewProxyModuleGetter(f.ProxyClient, f.SourceClient)
+	return f.lint(ctx, modulePath, requestedVersion, proxyGetter, -1)
+}
+
+// lint is like Lint, for a module version from mg whose zip has the given
+// size, or -1 if it must be obtained from the proxy.
+func (f *Fetcher) lint(ctx context.Context, modulePath, requestedVersion string, mg fetch.ModuleGetter, zipSize int64) (*LintReport, error) {
+	fr, contentDir, err := f.fetchWithoutInserting(ctx, modulePath, requestedVersion, mg, zipSize)
+	if err != nil {
+		return nil, err
+	}
+	return newLintReport(ctx, fr, contentDir), nil
+}
+
+// newLintReport returns the LintReport of fr. contentDir holds the files of
+// the module, and may be nil.
+func newLintReport(ctx context.Context, fr *fetch.FetchResult, contentDir fs.FS) *LintReport {
+	dr := newDryRunReport(ctx, fr)
+	report := &LintReport{
+		ModulePath: dr.ModulePath,
+		Version:    dr.ResolvedVersion,
+		Status:     dr.Status,
+		Error:      dr.Error,
+		Warnings:   []*LintWarning{},
+	}
+	if fr.Module == nil {
+		return report
+	}
+	warn := func(check, path, format string, args ...any) {
+		report.Warnings = append(report.Warnings, &LintWarning{
+			Check:   check,
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(dr.Licenses) == 0 {
+		warn(lintLicense, "", "the module has no license file, so its documentation will not be shown")
+	} else if !dr.IsRedistributable {
+		warn(lintLicense, "", "the licenses of the module do not allow its documentation to be shown")
+	}
+	for _, l := range dr.Licenses {
+		if l.Unknown {
+			warn(lintLicense, l.FilePath, "the license file was not recognized, so the documentation of the packages it applies to will not be shown until it is reviewed")
+		}
+	}
+
+	for _, u := range fr.Module.Units {
+		if u.Readme != nil && !isMarkdownFile(u.Readme.Filepath) {
+			warn(lintReadme, u.Readme.Filepath, "the README is not markdown, so it will be shown as plain text; name it README.md to have it rendered")
+		}
+	}
+
+	for _, p := range dr.Packages {
+		if p.Status != http.StatusOK {
+			msg := p.Error
+			if msg == "" {
+				msg = http.StatusText(p.Status)
+			}
+			warn(lintPackage, p.Path, "the package could not be processed: %s", msg)
+			continue
+		}
+		if dr.IsRedistributable && !p.IsRedistributable {
+			warn(lintLicense, p.Path, "the licenses that apply to the package do not allow its documentation to be shown")
+		}
+		for _, d := range p.Docs {
+			if d.Truncated {
+				warn(lintPackage, p.Path, "the documentation for %s is incomplete because the package is too large", d.BuildContext)
+			}
+			if d.RenderError != "" {
+				warn(lintPackage, p.Path, "the documentation for %s could not be rendered: %s", d.BuildContext, d.RenderError)
+			}
+		}
+	}
+
+	seen := map[LintWarning]bool{}
+	for _, u := range fr.Module.Units {
+		for _, d := range u.Documentation {
+			for _, w := range docLinkWarnings(u, d) {
+				if !seen[*w] {
+					seen[*w] = true
+					report.Warnings = append(report.Warnings, w)
+				}
+			}
+		}
+	}
+
+	if contentDir != nil {
+		err := fs.WalkDir(contentDir, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() > fetch.MaxFileSize {
+				warn(lintFileSize, p, "the file has %d bytes, more than the %d that are read, so it will be ignored",
+					info.Size(), fetch.MaxFileSize)
+			}
+			return nil
+		})
+		if err != nil {
+			warn(lintFileSize, "", "the files of the module could not be read: %v", err)
+		}
+	}
+
+	sort.SliceStable(report.Warnings, func(i, j int) bool {
+		return report.Warnings[i].Path < report.Warnings[j].Path
+	})
+	return report
+}
+
+// isMarkdownFile reports whether the name of a README says that it is
+// markdown, which is rendered instead of shown as plain text.
+func isMarkdownFile(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// bracketLinkRx matches a doc link of the form "[Name]" or "[pkg.Name]",
+// which the documentation renderer does not support.
+var bracketLinkRx = regexp.MustCompile(`(?:^|[^\w\]])(\[\*?[A-Za-z_]\w*(?:\.[A-Za-z_]\w*){0,2}\])(?:[^\w(:\[]|$)`)
+
+// docLinkWarnings returns the warnings about the links in the doc comments
+// of the documentation d of the package u that will not be shown as links.
+func docLinkWarnings(u *internal.Unit, d *internal.Documentation) []*LintWarning {
+	docPkg, err := godoc.DecodePackage(d.Source)
+	if err != nil {
+		// The error is reported by newDryRunReport.
+		return nil
+	}
+	dir := internal.Suffix(u.Path, u.ModulePath)
+	if u.ModulePath == stdlib.ModulePath {
+		dir = u.Path
+	}
+	var ws []*LintWarning
+	for _, f := range docPkg.Files {
+		if f.AST == nil {
+			continue
+		}
+		filePath := path.Join(dir, f.Name)
+		if f.AST.Doc != nil {
+			for _, line := range badLinksSectionLines(f.AST.Doc.Text()) {
+				ws = append(ws, &LintWarning{
+					Check:   lintDocLink,
+					Path:    filePath,
+					Message: fmt.Sprintf("the line %q in the Links section of the package comment is not of the form \"- title, https://url\", so it will not be shown", line),
+				})
+			}
+		}
+		ast.Inspect(f.AST, func(n ast.Node) bool {
+			cg, ok := n.(*ast.CommentGroup)
+			if !ok {
+				return true
+			}
+			for _, m := range bracketLinkRx.FindAllStringSubmatch(cg.Text(), -1) {
+				ws = append(ws, &LintWarning{
+					Check:   lintDocLink,
+					Path:    filePath,
+					Message: fmt.Sprintf("the doc link %s will be shown as text; use the name without brackets, or a URL", m[1]),
+				})
+			}
+			return false
+		})
+	}
+	return ws
+}
+
+// badLinksSectionLines returns the lines of the Links section of the package
+// comment doc that the documentation renderer will not show as links,
+// because they are not of the form "- title, url" with an http or https URL.
+func badLinksSectionLines(doc string) []string {
+	// Split the comment into paragraphs.
+	var paras [][]string
+	var para []string
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(para) > 0 {
+				paras = append(paras, para)
+				para = nil
+			}
+			continue
+		}
+		para = append(para, line)
+	}
+	if len(para) > 0 {
+		paras = append(paras, para)
+	}
+
+	var bad []string
+	inLinks := false
+	for i, para := range paras {
+		// A heading is a single line that is followed by a paragraph, and
+		// that looks like a title.
+		if i > 0 && i+1 < len(paras) && len(para) == 1 && looksLikeHeading(para[0]) {
+			inLinks = para[0] == "Links"
+			continue
+		}
+		if !inLinks {
+			continue
+		}
+		for _, line := range para {
+			if !isLinkLine(line) {
+				bad = append(bad, line)
+			}
+		}
+	}
+	return bad
+}
+
+// looksLikeHeading reports whether line could be a heading of a doc
+// comment: it starts with an upper case letter, and does not end with
+// punctuation.
+func looksLikeHeading(line string) bool {
+	r := []rune(line)
+	if !unicode.IsUpper(r[0]) {
+		return false
+	}
+	last := r[len(r)-1]
+	return unicode.IsLetter(last) || unicode.IsDigit(last)
+}
+
+// isLinkLine reports whether line is of the form "- title, url", where url
+// is an http or https URL.
+func isLinkLine(line string) bool {
+	if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "-\t") {
+		return false
+	}
+	_, href, found := strings.Cut(line[2:], ",")
+	if !found {
+		return false
+	}
+	u, err := url.Parse(strings.TrimSpace(href))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
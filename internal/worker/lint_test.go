@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/fetch"
+)
+
+func TestNewLintReport(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range map[string]string{
+		"go.mod":     "module example.com/m\n",
+		"README.rst": "Title\n=====\n",
+		"p/p.go": `// Package p does things, like [F] and a[i].
+//
+// Links
+//
+//   - Home, https://example.com
+//   - Not a link
+//   - FTP, ftp://example.com
+package p
+
+// F calls [G].
+func F() {}
+`,
+	} {
+		w, err := zw.Create("example.com/m@v1.0.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg, err := fetch.NewZipModuleGetter(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := &contentRecorder{ModuleGetter: mg}
+	fr := fetch.FetchModule(ctx, mg.ModulePath(), mg.Version(), cr)
+	got := newLintReport(ctx, fr, cr.contentDir)
+
+	want := &LintReport{
+		ModulePath: "example.com/m",
+		Version:    "v1.0.0",
+		Status:     200,
+		Warnings: []*LintWarning{
+			{Check: lintLicense, Message: "the module has no license file, so its documentation will not be shown"},
+			{Check: lintReadme, Path: "README.rst", Message: "the README is not markdown, so it will be shown as plain text; name it README.md to have it rendered"},
+			{Check: lintDocLink, Path: "p/p.go", Message: `the line "- Not a link" in the Links section of the package comment is not of the form "- title, https://url", so it will not be shown`},
+			{Check: lintDocLink, Path: "p/p.go", Message: `the line "- FTP, ftp://example.com" in the Links section of the package comment is not of the form "- title, https://url", so it will not be shown`},
+			{Check: lintDocLink, Path: "p/p.go", Message: "the doc link [F] will be shown as text; use the name without brackets, or a URL"},
+			{Check: lintDocLink, Path: "p/p.go", Message: "the doc link [G] will be shown as text; use the name without brackets, or a URL"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
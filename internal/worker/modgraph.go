@@ -0,0 +1,75 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
+)
+
+// handleModuleGraph handles requests for /mod-graph/<module>/@v/<version>.
+// It writes the module requirement graph of the module version in the
+// format of "go mod graph", respecting module graph pruning. With the query
+// param "list", it writes the selected module versions instead, in the
+// format of "go list -m all".
+func (s *Server) handleModuleGraph(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleModuleGraph")
+
+	modulePath, requestedVersion, err := parseModulePathAndVersion(r.URL.Path)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	ctx := r.Context()
+	if exc, err := s.db.IsExcluded(ctx, modulePath); err != nil {
+		return err
+	} else if exc {
+		return &serverError{http.StatusForbidden, derrors.Excluded}
+	}
+	mg := fetch.NewProxyModuleGetter(s.proxyClient, s.sourceClient)
+	info, err := fetch.GetInfo(ctx, modulePath, requestedVersion, mg)
+	if err != nil {
+		return &serverError{derrors.ToStatus(err), err}
+	}
+	g, err := fetch.LoadModuleGraph(ctx, modulePath, info.Version, mg)
+	if err != nil {
+		return &serverError{derrors.ToStatus(err), err}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, list := r.URL.Query()["list"]
+	return writeModuleGraph(w, g, list)
+}
+
+// writeModuleGraph writes g to w as "go mod graph" does, or if list is true,
+// its build list as "go list -m all" does.
+func writeModuleGraph(w io.Writer, g *fetch.ModuleGraph, list bool) error {
+	if list {
+		if _, err := fmt.Fprintln(w, g.Main.Path); err != nil {
+			return err
+		}
+		for _, m := range g.BuildList() {
+			if _, err := fmt.Fprintln(w, m.Path, m.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	format := func(m module.Version) string {
+		if m == g.Main {
+			return m.Path
+		}
+		return m.String()
+	}
+	for _, e := range g.Edges() {
+		if _, err := fmt.Fprintln(w, format(e[0]), format(e[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
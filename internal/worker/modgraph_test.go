@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/fetch"
+)
+
+func TestWriteModuleGraph(t *testing.T) {
+	v := func(path, version string) module.Version { return module.Version{Path: path, Version: version} }
+	g := &fetch.ModuleGraph{
+		Main: v("m", "v1.0.0"),
+		Requirements: map[module.Version][]module.Version{
+			v("m", "v1.0.0"): {v("a", "v1.0.0"), v("b", "v1.0.0")},
+			v("a", "v1.0.0"): {v("b", "v1.1.0")},
+		},
+	}
+	for _, test := range []struct {
+		list bool
+		want string
+	}{
+		{false, "m a@v1.0.0\nm b@v1.0.0\na@v1.0.0 b@v1.1.0\n"},
+		{true, "m\na v1.0.0\nb v1.1.0\n"},
+	} {
+		var b strings.Builder
+		if err := writeModuleGraph(&b, g, test.list); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, b.String()); diff != "" {
+			t.Errorf("list=%t: mismatch (-want, +got):\n%s", test.list, diff)
+		}
+	}
+}
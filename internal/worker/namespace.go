@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/namespace"
+)
+
+const (
+	// namespaceReverifyWindow is how long before their verifications expire
+	// verified namespace claims are checked again.
+	namespaceReverifyWindow = 14 * 24 * time.Hour
+	// staleNamespaceClaimAge is how long claims that are not verified are
+	// kept.
+	staleNamespaceClaimAge = 7 * 24 * time.Hour
+)
+
+// handleReverifyNamespaces checks again up to "limit" verified namespace
+// claims that expire soon, and extends the verifications of those whose
+// challenges are still met. The others expire. It then deletes the claims
+// that have not been verified for a while.
+func (s *Server) handleReverifyNamespaces(w http.ResponseWriter, r *http.Request) error {
+	if s.namespaceVerifier == nil {
+		return &serverError{http.StatusFailedDependency, errors.New("namespace verification is not configured")}
+	}
+	ctx := r.Context()
+	now := time.Now()
+	claims, err := s.db.GetNamespaceClaimsToReverify(ctx, now.Add(namespaceReverifyWindow), parseLimitParam(r, 100))
+	if err != nil {
+		return err
+	}
+	var verified int
+	for _, claim := range claims {
+		c, err := namespace.NewChallenge(claim.Prefix, claim.Token)
+		if err != nil {
+			return err
+		}
+		ok, err := s.namespaceVerifier(ctx, c)
+		if err != nil {
+			// Leave the claim to the next run; it expires if the check keeps
+			// failing.
+			log.Warningf(ctx, "checking the claim of %q: %v", claim.Prefix, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.db.VerifyNamespaceClaim(ctx, claim.Prefix, claim.Token, c.Method, now.Add(namespace.VerificationTTL)); err != nil {
+			return err
+		}
+		verified++
+	}
+	n, err := s.db.DeleteStaleNamespaceClaims(ctx, now.Add(-staleNamespaceClaimAge))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "verified %d of %d namespace claims again; deleted %d stale claims", verified, len(claims), n)
+	return nil
+}
+
+// handleNamespaceClaims revokes all claims of the namespace in the form
+// value "revoke", so that its modules are no longer displayed as coming from
+// a verified publisher.
+func (s *Server) handleNamespaceClaims(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("namespace claims can only be updated with POST")}
+	}
+	prefix := strings.Trim(r.FormValue("revoke"), "/")
+	if prefix == "" {
+		return &serverError{http.StatusBadRequest, errors.New("missing namespace to revoke")}
+	}
+	n, err := s.db.RevokeNamespaceClaims(r.Context(), prefix)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Revoked %d claims of %s.", n, prefix)
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apikey"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
@@ -35,11 +36,44 @@ var startTime = time.Now()
 
 // doIndexPage writes the status page. On error it returns the error and a short
 // string to be written back to the client.
+// interleavingReportPeriod is the period of the search clicks that the
+// search interleaving report on the index page is computed from.
+const interleavingReportPeriod = 30 * 24 * time.Hour
+
+// pageViewReportPeriod is the period of the page views that the most viewed
+// packages report on the index page is computed from, and mostViewedLimit
+// is the number of packages in it.
+const (
+	pageViewReportPeriod = 30 * 24 * time.Hour
+	mostViewedLimit      = 25
+)
+
+// zeroResultReportPeriod is the period of the searches that the zero-result
+// searches page is computed from, and zeroResultLimit is the number of
+// queries on it.
+const (
+	zeroResultReportPeriod = 30 * 24 * time.Hour
+	zeroResultLimit        = 200
+)
+
+// cspReportPeriod is the period of the reports that the CSP violations page
+// is computed from, and cspViolationLimit is the number of violations on it.
+const (
+	cspReportPeriod   = 7 * 24 * time.Hour
+	cspViolationLimit = 200
+)
+
 func (s *Server) doIndexPage(w http.ResponseWriter, r *http.Request) (err error) {
 	defer derrors.Wrap(&err, "doIndexPage")
 	var (
 		experiments []*internal.Experiment
 		excluded    []string
+		banner      string
+		sections    []*internal.HomepageSection
+		apiKeys     []*apikey.Key
+		redirects   []*internal.ModuleRedirect
+		rankings    []*postgres.InterleavingResult
+		mostViewed  []*postgres.ViewedPackage
 	)
 	if s.getExperiments != nil {
 		experiments = s.getExperiments()
@@ -53,6 +87,54 @@ func (s *Server) doIndexPage(w http.ResponseWriter, r *http.Request) (err error)
 		}
 		return nil
 	})
+	g.Go(func() error {
+		var err error
+		banner, err = s.db.GetSiteBanner(ctx)
+		if err != nil {
+			return annotation{err, "error fetching site banner"}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		sections, err = s.db.GetHomepageSections(ctx)
+		if err != nil {
+			return annotation{err, "error fetching homepage sections"}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		apiKeys, err = s.db.GetAPIKeys(ctx)
+		if err != nil {
+			return annotation{err, "error fetching API keys"}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		redirects, err = s.db.GetModuleRedirects(ctx)
+		if err != nil {
+			return annotation{err, "error fetching module redirects"}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		rankings, err = s.db.GetInterleavingResults(ctx, time.Now().Add(-interleavingReportPeriod))
+		if err != nil {
+			return annotation{err, "error fetching search interleaving results"}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		mostViewed, err = s.db.GetMostViewedPackages(ctx, time.Now().Add(-pageViewReportPeriod), mostViewedLimit)
+		if err != nil {
+			return annotation{err, "error fetching most viewed packages"}
+		}
+		return nil
+	})
 	if err := g.Wait(); err != nil {
 		var e annotation
 		if errors.As(err, &e) {
@@ -88,40 +170,60 @@ func (s *Server) doIndexPage(w http.ResponseWriter, r *http.Request) (err error)
 	}
 
 	page := struct {
-		Config          *config.Config
-		Env             string
-		ResourcePrefix  string
-		LatestTimestamp *time.Time
-		LocationID      string
-		Hostname        string
-		StartTime       time.Time
-		Experiments     []*internal.Experiment
-		Excluded        []string
-		LoadShedStats   LoadShedStats
-		GoMemStats      runtime.MemStats
-		ProcessStats    memory.ProcessStats
-		SystemStats     memory.SystemStats
-		CgroupStats     map[string]uint64
-		Fetches         []*FetchInfo
-		LogsURL         string
-		DBInfo          *postgres.UserInfo
+		Config           *config.Config
+		Env              string
+		ResourcePrefix   string
+		LatestTimestamp  *time.Time
+		LocationID       string
+		Hostname         string
+		StartTime        time.Time
+		Experiments      []*internal.Experiment
+		Excluded         []string
+		SiteBanner       string
+		HomepageSections []*internal.HomepageSection
+		HomepageKinds    []string
+		APIKeys          []*apikey.Key
+		APIKeyScopes     []string
+		ModuleRedirects  []*internal.ModuleRedirect
+		Rankings         []*postgres.InterleavingResult
+		RankingsDays     int
+		MostViewed       []*postgres.ViewedPackage
+		MostViewedDays   int
+		LoadShedStats    LoadShedStats
+		GoMemStats       runtime.MemStats
+		ProcessStats     memory.ProcessStats
+		SystemStats      memory.SystemStats
+		CgroupStats      map[string]uint64
+		Fetches          []*FetchInfo
+		LogsURL          string
+		DBInfo           *postgres.UserInfo
 	}{
-		Config:         s.cfg,
-		Env:            env(s.cfg),
-		ResourcePrefix: strings.ToLower(env(s.cfg)) + "-",
-		LocationID:     s.cfg.LocationID,
-		Hostname:       os.Getenv("HOSTNAME"),
-		StartTime:      startTime,
-		Experiments:    experiments,
-		Excluded:       excluded,
-		LoadShedStats:  s.ZipLoadShedStats(),
-		GoMemStats:     gms,
-		ProcessStats:   pms,
-		SystemStats:    sms,
-		CgroupStats:    cms,
-		Fetches:        FetchInfos(),
-		LogsURL:        logsURL,
-		DBInfo:         s.workerDBInfo(),
+		Config:           s.cfg,
+		Env:              env(s.cfg),
+		ResourcePrefix:   strings.ToLower(env(s.cfg)) + "-",
+		LocationID:       s.cfg.LocationID,
+		Hostname:         os.Getenv("HOSTNAME"),
+		StartTime:        startTime,
+		Experiments:      experiments,
+		Excluded:         excluded,
+		SiteBanner:       banner,
+		HomepageSections: sections,
+		HomepageKinds:    internal.HomepageSectionKinds,
+		APIKeys:          apiKeys,
+		APIKeyScopes:     apikey.Scopes,
+		ModuleRedirects:  redirects,
+		Rankings:         rankings,
+		RankingsDays:     int(interleavingReportPeriod.Hours() / 24),
+		MostViewed:       mostViewed,
+		MostViewedDays:   int(pageViewReportPeriod.Hours() / 24),
+		LoadShedStats:    s.ZipLoadShedStats(),
+		GoMemStats:       gms,
+		ProcessStats:     pms,
+		SystemStats:      sms,
+		CgroupStats:      cms,
+		Fetches:          FetchInfos(),
+		LogsURL:          logsURL,
+		DBInfo:           s.workerDBInfo(),
 	}
 	return renderPage(ctx, w, page, s.templates[indexTemplate])
 }
@@ -208,6 +310,73 @@ func (s *Server) doVersionsPage(w http.ResponseWriter, r *http.Request) (err err
 	return renderPage(ctx, w, page, s.templates[versionsTemplate])
 }
 
+func (s *Server) doLicenseReviewsPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doLicenseReviewsPage")
+	const pageSize = 50
+	reviews, err := s.db.GetPendingLicenseReviews(r.Context(), pageSize)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Env     string
+		Reviews []*postgres.LicenseReview
+	}{
+		Env:     env(s.cfg),
+		Reviews: reviews,
+	}
+	return renderPage(r.Context(), w, page, s.templates[licenseReviewsTemplate])
+}
+
+func (s *Server) doLicenseSearchPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doLicenseSearchPage")
+	const pageSize = 100
+	query := strings.TrimSpace(r.FormValue("q"))
+	var results []*postgres.LicenseSearchResult
+	if query != "" {
+		results, err = s.db.SearchLicenses(r.Context(), query, pageSize)
+		if err != nil {
+			return err
+		}
+	}
+	page := struct {
+		Env      string
+		Query    string
+		Results  []*postgres.LicenseSearchResult
+		PageSize int
+	}{
+		Env:      env(s.cfg),
+		Query:    query,
+		Results:  results,
+		PageSize: pageSize,
+	}
+	return renderPage(r.Context(), w, page, s.templates[licenseSearchTemplate])
+}
+
+// doZeroResultSearchesPage lists the queries of the searches on the frontend
+// that had no results, most searched first, so that operators can see what
+// users look for and fail to find.
+func (s *Server) doZeroResultSearchesPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doZeroResultSearchesPage")
+	queries, err := s.db.GetZeroResultQueries(r.Context(), time.Now().Add(-zeroResultReportPeriod), zeroResultLimit)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Env       string
+		Queries   []*postgres.ZeroResultQuery
+		Days      int
+		Limit     int
+		Recording bool
+	}{
+		Env:       env(s.cfg),
+		Queries:   queries,
+		Days:      int(zeroResultReportPeriod.Hours() / 24),
+		Limit:     zeroResultLimit,
+		Recording: s.cfg.RecordSearchQueries,
+	}
+	return renderPage(r.Context(), w, page, s.templates[zeroResultSearchesTemplate])
+}
+
 func env(cfg *config.Config) string {
 	e := cfg.DeploymentEnvironment()
 	return strings.ToUpper(e[:1]) + e[1:]
@@ -225,3 +394,28 @@ func renderPage(ctx context.Context, w http.ResponseWriter, page interface{}, tm
 	}
 	return nil
 }
+
+// doCSPViolationsPage lists the Content-Security-Policy violations reported
+// by browsers to the frontend, most reported first, so that operators can see
+// what a policy would break before enforcing it.
+func (s *Server) doCSPViolationsPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doCSPViolationsPage")
+	violations, err := s.db.GetCSPViolations(r.Context(), time.Now().Add(-cspReportPeriod), cspViolationLimit)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Env        string
+		Violations []*postgres.CSPViolation
+		Days       int
+		Limit      int
+		SampleRate float64
+	}{
+		Env:        env(s.cfg),
+		Violations: violations,
+		Days:       int(cspReportPeriod.Hours() / 24),
+		Limit:      cspViolationLimit,
+		SampleRate: s.cfg.CSP.ReportSampleRate,
+	}
+	return renderPage(r.Context(), w, page, s.templates[cspViolationsTemplate])
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+)
+
+// handleModuleRedirects adds or removes a redirect from the path of a module
+// that was renamed or moved. If the form value "remove" is an old module
+// path, its redirect is removed. Otherwise a redirect is added from the form
+// values "from", "to" and "reason".
+func (s *Server) handleModuleRedirects(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("module redirects can only be updated with POST")}
+	}
+	ctx := r.Context()
+	if from := r.FormValue("remove"); from != "" {
+		if err := s.db.DeleteModuleRedirect(ctx, from); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed the redirect of %s.", from)
+		return nil
+	}
+	mr, err := parseModuleRedirect(r)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	if err := s.db.InsertModuleRedirect(ctx, mr); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Added a redirect from %s to %s.", mr.OldModulePath, mr.NewModulePath)
+	return nil
+}
+
+// parseModuleRedirect returns the redirect described by the form values of r.
+func parseModuleRedirect(r *http.Request) (*internal.ModuleRedirect, error) {
+	mr := &internal.ModuleRedirect{
+		OldModulePath: strings.Trim(strings.TrimSpace(r.FormValue("from")), "/"),
+		NewModulePath: strings.Trim(strings.TrimSpace(r.FormValue("to")), "/"),
+		Source:        internal.RedirectAdmin,
+		Reason:        strings.TrimSpace(r.FormValue("reason")),
+	}
+	for _, p := range []string{mr.OldModulePath, mr.NewModulePath} {
+		if err := module.CheckPath(p); err != nil {
+			return nil, fmt.Errorf("invalid module path %q: %v", p, err)
+		}
+	}
+	if mr.OldModulePath == mr.NewModulePath {
+		return nil, errors.New("a module cannot be redirected to itself")
+	}
+	return mr, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestParseModuleRedirect(t *testing.T) {
+	for _, test := range []struct {
+		form    url.Values
+		want    *internal.ModuleRedirect
+		wantErr bool
+	}{
+		{
+			form: url.Values{"from": {" example.com/old/ "}, "to": {"example.com/new"}, "reason": {" renamed "}},
+			want: &internal.ModuleRedirect{OldModulePath: "example.com/old", NewModulePath: "example.com/new",
+				Source: internal.RedirectAdmin, Reason: "renamed"},
+		},
+		{form: url.Values{"to": {"example.com/new"}}, wantErr: true},
+		{form: url.Values{"from": {"example.com/old"}}, wantErr: true},
+		{form: url.Values{"from": {"old"}, "to": {"example.com/new"}}, wantErr: true},
+		{form: url.Values{"from": {"example.com/old"}, "to": {"example.com/old"}}, wantErr: true},
+	} {
+		r := httptest.NewRequest("POST", "/module-redirects", strings.NewReader(test.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		got, err := parseModuleRedirect(r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: got error %v, want error: %t", test.form, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%v: mismatch (-want, +got):\n%s", test.form, diff)
+		}
+	}
+}
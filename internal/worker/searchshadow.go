@@ -0,0 +1,145 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const (
+	// numShadowSearchQueries is the number of sampled queries that the shadow
+	// search index is compared with the live one on, by default.
+	numShadowSearchQueries = 50
+	// shadowSearchResults is the number of results of each query that are
+	// compared.
+	shadowSearchResults = 10
+	// defaultMinShadowOverlap is the mean overlap of the results of the
+	// shadow and live indexes below which the shadow index is not swapped in,
+	// by default.
+	defaultMinShadowOverlap = 0.5
+	// maxShadowPendingFraction is the fraction of the packages in
+	// search_documents that can be without shadow tokens when the shadow
+	// index is swapped in. It leaves room for the packages fetched while the
+	// index is being built, which have the new tokens already.
+	maxShadowPendingFraction = 0.01
+)
+
+// handleBuildSearchShadow computes the shadow search tokens of the packages
+// that don't have them, up to the "limit" query param.
+func (s *Server) handleBuildSearchShadow(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	n, err := s.db.BuildShadowSearchDocuments(ctx, parseLimitParam(r, 1000))
+	if err != nil {
+		return err
+	}
+	total, pending, err := s.db.CountShadowSearchDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "built shadow search tokens for %d packages; %d of %d packages pending", n, pending, total)
+	return nil
+}
+
+// handleVerifySearchShadow compares the results of the live and shadow search
+// indexes on the queries in the "q" query params, or on "n" queries sampled
+// from the search vocabulary.
+func (s *Server) handleVerifySearchShadow(w http.ResponseWriter, r *http.Request) error {
+	cs, err := s.compareSearchShadow(r.Context(), r)
+	if err != nil {
+		return err
+	}
+	writeShadowSearchComparisons(w, cs)
+	return nil
+}
+
+// handleSwapSearchShadow replaces the live search tokens with the shadow
+// ones, if the shadow index is built and the mean overlap of their results
+// on sampled queries is at least the "min_overlap" query param.
+func (s *Server) handleSwapSearchShadow(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("the search index can only be swapped with POST")}
+	}
+	ctx := r.Context()
+	minOverlap := defaultMinShadowOverlap
+	if p := r.FormValue("min_overlap"); p != "" {
+		var err error
+		minOverlap, err = strconv.ParseFloat(p, 64)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+	}
+	total, pending, err := s.db.CountShadowSearchDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	if float64(pending) > maxShadowPendingFraction*float64(total) {
+		return &serverError{http.StatusPreconditionFailed, fmt.Errorf("%d of %d packages have no shadow search tokens", pending, total)}
+	}
+	cs, err := s.compareSearchShadow(ctx, r)
+	if err != nil {
+		return err
+	}
+	if m := meanOverlap(cs); m < minOverlap {
+		return &serverError{http.StatusPreconditionFailed, fmt.Errorf("mean overlap of shadow search results is %.2f, want at least %.2f", m, minOverlap)}
+	}
+	n, err := s.db.SwapShadowSearchDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	writeShadowSearchComparisons(w, cs)
+	fmt.Fprintf(w, "swapped shadow search tokens for %d packages\n", n)
+	return nil
+}
+
+// compareSearchShadow compares the live and shadow search indexes on the
+// queries of r.
+func (s *Server) compareSearchShadow(ctx context.Context, r *http.Request) ([]*postgres.ShadowSearchComparison, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, &serverError{http.StatusBadRequest, err}
+	}
+	queries := r.Form["q"]
+	if len(queries) == 0 {
+		n := numShadowSearchQueries
+		if p := r.FormValue("n"); p != "" {
+			var err error
+			n, err = strconv.Atoi(p)
+			if err != nil {
+				return nil, &serverError{http.StatusBadRequest, err}
+			}
+		}
+		var err error
+		queries, err = s.db.SampleSearchQueries(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.db.CompareShadowSearch(ctx, queries, shadowSearchResults)
+}
+
+func writeShadowSearchComparisons(w io.Writer, cs []*postgres.ShadowSearchComparison) {
+	for _, c := range cs {
+		fmt.Fprintf(w, "%.2f\t%q\t%d live results\t%d shadow results\n", c.Overlap, c.Query, len(c.Live), len(c.Shadow))
+	}
+	fmt.Fprintf(w, "mean overlap of %d queries: %.2f\n", len(cs), meanOverlap(cs))
+}
+
+// meanOverlap returns the mean overlap of cs, or 1 if cs is empty.
+func meanOverlap(cs []*postgres.ShadowSearchComparison) float64 {
+	if len(cs) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, c := range cs {
+		sum += c.Overlap
+	}
+	return sum / float64(len(cs))
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/google/safehtml/template"
 	"go.opencensus.io/trace"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/analysis"
 	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -32,6 +33,7 @@ import (
 	"golang.org/x/pkgsite/internal/index"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/namespace"
 	"golang.org/x/pkgsite/internal/poller"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
@@ -39,6 +41,7 @@ import (
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
+	vulnc "golang.org/x/vuln/client"
 )
 
 // Server can be installed to serve the go discovery worker.
@@ -55,8 +58,12 @@ type Server struct {
 	templates       map[string]*template.Template
 	staticPath      template.TrustedSource
 	getExperiments  func() []*internal.Experiment
+	vulnClient      vulnc.Client
 	workerDBInfo    func() *postgres.UserInfo
 	loadShedder     *loadShedder
+	analyzers       []analysis.Analyzer
+
+	namespaceVerifier func(context.Context, *namespace.Challenge) (bool, error)
 }
 
 // ServerConfig contains everything needed by a Server.
@@ -71,11 +78,23 @@ type ServerConfig struct {
 	ReportingClient      *errorreporting.Client
 	StaticPath           template.TrustedSource
 	GetExperiments       func() []*internal.Experiment
+	VulnClient           vulnc.Client
+	// Analyzers are run over each module version that is fetched.
+	Analyzers []analysis.Analyzer
+	// NamespaceVerifier reports whether the claimant of a namespace still
+	// meets its challenge, as namespace.Verifier.Verify does.
+	NamespaceVerifier func(context.Context, *namespace.Challenge) (bool, error)
 }
 
 const (
-	indexTemplate    = "index.tmpl"
-	versionsTemplate = "versions.tmpl"
+	indexTemplate          = "index.tmpl"
+	versionsTemplate       = "versions.tmpl"
+	licenseReviewsTemplate = "license_reviews.tmpl"
+	licenseSearchTemplate  = "license_search.tmpl"
+	digestTemplate         = "digest.tmpl"
+
+	zeroResultSearchesTemplate = "zero_result_searches.tmpl"
+	cspViolationsTemplate      = "csp_violations.tmpl"
 )
 
 // NewServer creates a new Server with the given dependencies.
@@ -89,12 +108,38 @@ func NewServer(cfg *config.Config, scfg ServerConfig) (_ *Server, err error) {
 	if err != nil {
 		return nil, err
 	}
+	t3, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(licenseReviewsTemplate))
+	if err != nil {
+		return nil, err
+	}
+	t4, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(licenseSearchTemplate))
+	if err != nil {
+		return nil, err
+	}
+	t5, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(digestTemplate))
+	if err != nil {
+		return nil, err
+	}
+	t6, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(zeroResultSearchesTemplate))
+	if err != nil {
+		return nil, err
+	}
+	t7, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(cspViolationsTemplate))
+	if err != nil {
+		return nil, err
+	}
 	ts := template.TrustedSourceJoin(scfg.StaticPath)
 	tfs := template.TrustedFSFromTrustedSource(ts)
 	dochtml.LoadTemplates(tfs)
 	templates := map[string]*template.Template{
-		indexTemplate:    t1,
-		versionsTemplate: t2,
+		indexTemplate:          t1,
+		versionsTemplate:       t2,
+		licenseReviewsTemplate: t3,
+		licenseSearchTemplate:  t4,
+		digestTemplate:         t5,
+
+		zeroResultSearchesTemplate: t6,
+		cspViolationsTemplate:      t7,
 	}
 	var c *cache.Cache
 	if scfg.RedisCacheClient != nil {
@@ -112,19 +157,22 @@ func NewServer(cfg *config.Config, scfg ServerConfig) (_ *Server, err error) {
 	p.Start(context.Background(), 10*time.Second)
 
 	s := &Server{
-		cfg:             cfg,
-		db:              scfg.DB,
-		indexClient:     scfg.IndexClient,
-		proxyClient:     scfg.ProxyClient,
-		sourceClient:    scfg.SourceClient,
-		cache:           c,
-		betaCache:       bc,
-		queue:           scfg.Queue,
-		reportingClient: scfg.ReportingClient,
-		templates:       templates,
-		staticPath:      scfg.StaticPath,
-		getExperiments:  scfg.GetExperiments,
-		workerDBInfo:    func() *postgres.UserInfo { return p.Current().(*postgres.UserInfo) },
+		cfg:               cfg,
+		db:                scfg.DB,
+		indexClient:       scfg.IndexClient,
+		proxyClient:       scfg.ProxyClient,
+		sourceClient:      scfg.SourceClient,
+		cache:             c,
+		betaCache:         bc,
+		queue:             scfg.Queue,
+		reportingClient:   scfg.ReportingClient,
+		templates:         templates,
+		staticPath:        scfg.StaticPath,
+		getExperiments:    scfg.GetExperiments,
+		vulnClient:        scfg.VulnClient,
+		workerDBInfo:      func() *postgres.UserInfo { return p.Current().(*postgres.UserInfo) },
+		analyzers:         scfg.Analyzers,
+		namespaceVerifier: scfg.NamespaceVerifier,
 	}
 	s.setLoadShedder(context.Background())
 	return s, nil
@@ -164,6 +212,68 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// This endpoint is intended to be invoked periodically by a scheduler.
 	handle("/update-imported-by-count", rmw(s.errorHandler(s.handleUpdateImportedByCount)))
 
+	// scheduled: update-search-vocabulary recomputes the vocabulary of
+	// package names and import path elements that the frontend uses to
+	// correct misspelled search queries.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/update-search-vocabulary", rmw(s.errorHandler(s.handleUpdateSearchVocabulary)))
+
+	// scheduled: compute-corpus-stats computes the daily rollup of corpus
+	// statistics shown on the frontend's /stats page, for the previous day or
+	// the day in the "date" query param (YYYY-MM-DD).
+	// This endpoint is intended to be invoked nightly by a scheduler.
+	handle("/compute-corpus-stats", rmw(s.errorHandler(s.handleComputeCorpusStats)))
+
+	// scheduled: compute-module-feeds snapshots the imported-by counts of
+	// all modules and computes the lists of new and trending modules shown on
+	// the frontend's /new and /trending pages.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/compute-module-feeds", rmw(s.errorHandler(s.handleComputeModuleFeeds)))
+
+	// scheduled: export writes denormalized tables of the latest version of
+	// each module to the export bucket, for analysis outside of the serving
+	// database. The "table" query param restricts the export to one table.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/export", rmw(s.errorHandler(s.handleExport)))
+
+	// scheduled: gc-doc-cache deletes the entries of the doc cache that were
+	// produced by older versions of the worker, or have not been used for a
+	// while.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/gc-doc-cache", rmw(s.errorHandler(s.handleGCDocCache)))
+
+	// scheduled: clean-search-queries deletes the search counts recorded by
+	// the frontend that are older than the configured retention period.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/clean-search-queries", rmw(s.errorHandler(s.handleCleanSearchQueries)))
+
+	// scheduled: clean-csp-reports deletes the CSP violation reports stored
+	// by the frontend that are older than the configured retention period.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/clean-csp-reports", rmw(s.errorHandler(s.handleCleanCSPReports)))
+
+	// scheduled: reverify-namespaces checks again the verified namespace
+	// claims that expire soon, up to "limit", and deletes the claims that
+	// were never verified or have expired.
+	// This endpoint is intended to be invoked daily by a scheduler.
+	handle("/reverify-namespaces", rmw(s.errorHandler(s.handleReverifyNamespaces)))
+
+	// manual: build-search-shadow computes the text search tokens of up to
+	// "limit" packages in the shadow search index. It is invoked repeatedly
+	// after a change to how tokens are computed, until no packages are
+	// pending. See doc/worker.md.
+	handle("/build-search-shadow", rmw(s.errorHandler(s.handleBuildSearchShadow)))
+
+	// manual: verify-search-shadow compares the results of the shadow search
+	// index with those of the live one, on the queries in the "q" query
+	// params or on "n" queries sampled from the search vocabulary.
+	handle("/verify-search-shadow", rmw(s.errorHandler(s.handleVerifySearchShadow)))
+
+	// manual: swap-search-shadow replaces the live search tokens with the
+	// shadow ones in a single transaction, if the shadow index is built and
+	// its results are close enough to the live ones ("min_overlap").
+	handle("/swap-search-shadow", rmw(s.errorHandler(s.handleSwapSearchShadow)))
+
 	// task-queue: fetch fetches a module version from the Module Mirror, and
 	// processes the contents, and inserts it into the database. If a fetch
 	// request fails for any reason other than an http.StatusInternalServerError,
@@ -178,6 +288,25 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// is queued to refresh the std@master version.
 	handle("/fetch-std-master", rmw(s.errorHandler(s.handleFetchStdSupportedBranches)))
 
+	// manual: dry-run processes a module version like fetch, including
+	// rendering its documentation, but does not write to the database. It
+	// returns a JSON report of the result, so that module authors can check a
+	// version, such as a commit on a branch, before tagging it.
+	handle("/dry-run/", http.StripPrefix("/dry-run", rmw(s.errorHandler(s.handleDryRun))))
+
+	// manual: lint processes a module version like dry-run, from the proxy
+	// or from a module zip in the body of a POST request to /lint/, and
+	// returns a JSON list of the problems that would affect how it is
+	// displayed, such as a missing license or a README that is not markdown.
+	// It is not served by the frontend; see doc/worker.md.
+	handle("/lint/", http.StripPrefix("/lint", rmw(s.errorHandler(s.handleLint))))
+
+	// manual: mod-graph writes the module requirement graph of a module
+	// version as "go mod graph" would report it, respecting module graph
+	// pruning for modules at go 1.17 or later. With the "list" query param,
+	// it writes the selected module versions, as "go list -m all" would.
+	handle("/mod-graph/", http.StripPrefix("/mod-graph", rmw(s.errorHandler(s.handleModuleGraph))))
+
 	// scheduled: enqueue queries the module_version_states table for the next
 	// batch of module versions to process, and enqueues them for processing.
 	// Normally this will not cause duplicate processing, because Cloud Tasks
@@ -237,6 +366,57 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// returns an HTML page displaying information about recent versions that were processed.
 	handle("/versions", http.HandlerFunc(s.handleHTMLPage(s.doVersionsPage)))
 
+	// returns an HTML page listing license files awaiting review, and records
+	// review decisions on POST.
+	handle("/license-reviews", rmw(s.errorHandler(s.handleLicenseReviews)))
+
+	// returns an HTML page listing the license files whose contents match
+	// the "q" query param, for compliance queries.
+	handle("/license-search", rmw(s.errorHandler(s.doLicenseSearchPage)))
+
+	// manual: backfill-license-search computes the search tokens of up to
+	// "limit" licenses that were inserted before license search was added.
+	// It is invoked repeatedly until no licenses are pending.
+	handle("/backfill-license-search", rmw(s.errorHandler(s.handleBackfillLicenseSearch)))
+
+	// returns an HTML page listing the search queries on the frontend that
+	// had no results in the last 30 days, most searched first.
+	handle("/zero-result-searches", rmw(s.errorHandler(s.doZeroResultSearchesPage)))
+
+	// returns an HTML page listing the Content-Security-Policy violations
+	// reported to the frontend in the last 7 days, most reported first.
+	handle("/csp-violations", rmw(s.errorHandler(s.doCSPViolationsPage)))
+
+	// scheduled: digest returns a report of the versions released in the
+	// last week under the "prefix" query params, or the configured digest
+	// prefixes. The "format" query param is "html" (the default), "json" or
+	// "email". This endpoint is intended to be invoked weekly by a scheduler.
+	handle("/digest", rmw(s.errorHandler(s.handleDigest)))
+
+	// manual: experiments sets or removes an experiment in the database, on
+	// POST. See the form on the index page.
+	handle("/experiments", rmw(s.errorHandler(s.handleExperiments)))
+
+	// manual: site-banner sets or removes the banner shown on every
+	// frontend page, using the form value "message".
+	handle("/site-banner", rmw(s.errorHandler(s.handleSiteBanner)))
+
+	// manual: homepage-sections adds or removes a curated section of the
+	// frontend homepage. See the form on the index page.
+	handle("/homepage-sections", rmw(s.errorHandler(s.handleHomepageSections)))
+
+	// manual: api-keys creates or revokes an API key of the frontend. See
+	// the form on the index page.
+	handle("/api-keys", rmw(s.errorHandler(s.handleAPIKeys)))
+
+	// manual: namespace-claims revokes the claims of a namespace, using the
+	// form value "revoke". See the form on the index page.
+	handle("/namespace-claims", rmw(s.errorHandler(s.handleNamespaceClaims)))
+
+	// manual: module-redirects adds or removes a redirect from the path of a
+	// renamed or moved module. See the form on the index page.
+	handle("/module-redirects", rmw(s.errorHandler(s.handleModuleRedirects)))
+
 	// Health check.
 	handle("/healthz", http.HandlerFunc(s.handleHealthCheck))
 
@@ -258,6 +438,43 @@ func (s *Server) handleUpdateImportedByCount(w http.ResponseWriter, r *http.Requ
 	return nil
 }
 
+// handleUpdateSearchVocabulary recomputes the search vocabulary.
+func (s *Server) handleUpdateSearchVocabulary(w http.ResponseWriter, r *http.Request) error {
+	n, err := s.db.UpdateSearchVocabulary(r.Context())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "updated search vocabulary with %d terms", n)
+	return nil
+}
+
+// handleComputeCorpusStats computes the corpus statistics rollup for a day.
+func (s *Server) handleComputeCorpusStats(w http.ResponseWriter, r *http.Request) error {
+	date := time.Now().UTC().AddDate(0, 0, -1)
+	if d := r.FormValue("date"); d != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", d)
+		if err != nil {
+			return &serverError{http.StatusBadRequest, err}
+		}
+	}
+	if err := s.db.ComputeCorpusStats(r.Context(), date); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "computed corpus stats for %s", date.Format("2006-01-02"))
+	return nil
+}
+
+// handleComputeModuleFeeds computes today's module feeds.
+func (s *Server) handleComputeModuleFeeds(w http.ResponseWriter, r *http.Request) error {
+	date := time.Now().UTC()
+	if err := s.db.ComputeModuleFeeds(r.Context(), date); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "computed module feeds for %s", date.Format("2006-01-02"))
+	return nil
+}
+
 // handleRepopulateSearchDocuments repopulates every row in the search_documents table
 // that was last updated before the given time.
 func (s *Server) handleRepopulateSearchDocuments(w http.ResponseWriter, r *http.Request) error {
@@ -330,6 +547,8 @@ func (s *Server) doFetch(w http.ResponseWriter, r *http.Request) (string, int) {
 		DB:           s.db,
 		Cache:        s.cache,
 		loadShedder:  s.loadShedder,
+		UseDocCache:  s.cfg.DocCache,
+		Analyzers:    s.analyzers,
 	}
 	if r.FormValue(queue.DisableProxyFetchParam) == queue.DisableProxyFetchValue {
 		f.ProxyClient = f.ProxyClient.WithFetchDisabled()
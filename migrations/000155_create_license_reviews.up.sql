@@ -0,0 +1,36 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE license_reviews (
+    module_path text NOT NULL,
+    file_path text NOT NULL,
+    contents_hash text NOT NULL,
+    version text NOT NULL,
+    sample text NOT NULL,
+    types text[],
+    reviewed_by text,
+    reviewed_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (module_path, file_path, contents_hash)
+);
+COMMENT ON TABLE license_reviews IS
+'TABLE license_reviews contains license files that did not match any known license, along with the license types chosen by an operator after reviewing them.';
+COMMENT ON COLUMN license_reviews.version IS
+'COLUMN version is the most recently processed version of the module that contained the file.';
+COMMENT ON COLUMN license_reviews.sample IS
+'COLUMN sample is a prefix of the contents of the license file.';
+COMMENT ON COLUMN license_reviews.types IS
+'COLUMN types is NULL until the file has been reviewed. After that, it holds the license types to use for the file instead of the detected ones.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON license_reviews
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON license_reviews IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+CREATE INDEX idx_license_reviews_pending ON license_reviews (created_at) WHERE types IS NULL;
+
+END;
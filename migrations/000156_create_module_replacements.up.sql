@@ -0,0 +1,23 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_replacements (
+    module_id integer NOT NULL,
+    old_path text NOT NULL,
+    old_version text NOT NULL,
+    new_path text NOT NULL,
+    new_version text NOT NULL,
+    PRIMARY KEY (module_id, old_path, old_version),
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+COMMENT ON TABLE module_replacements IS
+'TABLE module_replacements contains the replace directives of the go.mod file of each module version.';
+COMMENT ON COLUMN module_replacements.old_version IS
+'COLUMN old_version is empty if the directive replaces all versions of old_path.';
+COMMENT ON COLUMN module_replacements.new_version IS
+'COLUMN new_version is empty if new_path is a directory on the local filesystem.';
+
+END;
@@ -0,0 +1,11 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP TRIGGER set_tsv_contents ON licenses;
+DROP FUNCTION trigger_modify_licenses_tsv_contents;
+ALTER TABLE licenses DROP COLUMN tsv_contents;
+
+END;
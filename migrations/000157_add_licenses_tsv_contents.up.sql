@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+-- The column is added without a default, so that the table is not rewritten.
+-- It is set by a trigger for inserted and updated rows. Existing rows are
+-- filled in batches by calling the worker's /backfill-license-search endpoint
+-- until no licenses are pending, and the column is indexed concurrently in
+-- the next license search migration, 000194.
+
+BEGIN;
+
+ALTER TABLE licenses ADD COLUMN tsv_contents tsvector;
+COMMENT ON COLUMN licenses.tsv_contents IS
+'COLUMN tsv_contents is used for full-text search over the contents of license files. Only the first 100,000 characters of the contents are indexed, to stay well below the size limit of a tsvector. It is NULL for licenses that have not been backfilled yet.';
+
+CREATE FUNCTION trigger_modify_licenses_tsv_contents() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.tsv_contents = to_tsvector('english', left(NEW.contents, 100000));
+    RETURN NEW;
+END
+$$ LANGUAGE PLPGSQL;
+CREATE TRIGGER set_tsv_contents BEFORE INSERT OR UPDATE OF contents ON licenses
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_licenses_tsv_contents();
+COMMENT ON TRIGGER set_tsv_contents ON licenses IS
+'TRIGGER set_tsv_contents updates the value of the tsv_contents column whenever a row is inserted or its contents are updated.';
+
+END;
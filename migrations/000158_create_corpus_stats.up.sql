@@ -0,0 +1,26 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE corpus_stats (
+    date date NOT NULL PRIMARY KEY,
+    num_modules integer NOT NULL,
+    num_versions integer NOT NULL,
+    num_packages integer NOT NULL,
+    versions_processed integer NOT NULL,
+    most_imported jsonb NOT NULL,
+    license_counts jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+COMMENT ON TABLE corpus_stats IS
+'TABLE corpus_stats contains a daily rollup of corpus-wide statistics, computed by the worker.';
+COMMENT ON COLUMN corpus_stats.versions_processed IS
+'COLUMN versions_processed is the number of module versions that were processed on the date.';
+COMMENT ON COLUMN corpus_stats.most_imported IS
+'COLUMN most_imported is a JSON array of the most imported packages and their imported-by counts.';
+COMMENT ON COLUMN corpus_stats.license_counts IS
+'COLUMN license_counts is a JSON array of license types and the number of modules whose latest version has a license of that type.';
+
+END;
@@ -0,0 +1,10 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP TABLE module_feeds;
+DROP TABLE module_imported_by_snapshots;
+
+END;
@@ -0,0 +1,36 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_imported_by_snapshots (
+    date date NOT NULL,
+    module_path text NOT NULL,
+    imported_by_count integer NOT NULL,
+    PRIMARY KEY (date, module_path)
+);
+COMMENT ON TABLE module_imported_by_snapshots IS
+'TABLE module_imported_by_snapshots contains a daily snapshot of the largest imported-by count of the packages in each module, used to compute the growth of modules over time. Modules whose packages are not imported are omitted.';
+
+CREATE TABLE module_feeds (
+    date date NOT NULL,
+    feed text NOT NULL,
+    rank integer NOT NULL,
+    module_path text NOT NULL,
+    version text NOT NULL,
+    indexed_at timestamp with time zone,
+    imported_by_count integer NOT NULL,
+    imported_by_growth integer NOT NULL,
+    PRIMARY KEY (date, feed, rank)
+);
+COMMENT ON TABLE module_feeds IS
+'TABLE module_feeds contains the daily rollups of the lists of modules shown on the /new and /trending pages.';
+COMMENT ON COLUMN module_feeds.feed IS
+'COLUMN feed is either "new", for recently indexed modules, or "trending", for the modules with the fastest imported-by growth.';
+COMMENT ON COLUMN module_feeds.indexed_at IS
+'COLUMN indexed_at is the time the first version of the module was added to the database.';
+COMMENT ON COLUMN module_feeds.imported_by_growth IS
+'COLUMN imported_by_growth is the increase in imported_by_count over the last 30 days.';
+
+END;
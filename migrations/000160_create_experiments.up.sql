@@ -0,0 +1,31 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE experiments (
+    name text NOT NULL PRIMARY KEY,
+    rollout integer DEFAULT 0 NOT NULL,
+    description text NOT NULL,
+    allowlist text[],
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT experiments_rollout_check CHECK (((rollout >= 0) AND (rollout <= 100)))
+);
+COMMENT ON TABLE experiments IS
+'TABLE experiments contains data for running experiments. The experiments in this table take precedence over those of the same name in the dynamic config.';
+COMMENT ON COLUMN experiments.name IS
+'COLUMN name is the name of the experiment.';
+COMMENT ON COLUMN experiments.rollout IS
+'COLUMN rollout is the percentage of total requests that are included for the experiment.';
+COMMENT ON COLUMN experiments.description IS
+'COLUMN description describes the experiment.';
+COMMENT ON COLUMN experiments.allowlist IS
+'COLUMN allowlist contains IP addresses and CIDR ranges of clients whose requests are always included in the experiment.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON experiments
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON experiments IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+END;
@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE csp_reports (
+    id bigserial PRIMARY KEY,
+    document_uri text NOT NULL,
+    violated_directive text NOT NULL,
+    effective_directive text NOT NULL,
+    blocked_uri text NOT NULL,
+    source_file text NOT NULL,
+    line_number integer NOT NULL,
+    column_number integer NOT NULL,
+    disposition text NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+COMMENT ON TABLE csp_reports IS
+'TABLE csp_reports contains a sample of the Content-Security-Policy violation reports sent by browsers to the frontend.';
+COMMENT ON COLUMN csp_reports.document_uri IS
+'COLUMN document_uri is the URI of the page where the violation occurred, without its query or fragment.';
+COMMENT ON COLUMN csp_reports.disposition IS
+'COLUMN disposition is "enforce" if the violated policy was enforced, or "report" if it was a report-only policy.';
+
+CREATE INDEX idx_csp_reports_created_at ON csp_reports (created_at);
+
+END;
@@ -0,0 +1,21 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE site_banner (
+    id boolean DEFAULT true NOT NULL PRIMARY KEY,
+    message text NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT site_banner_single_row CHECK (id)
+);
+COMMENT ON TABLE site_banner IS
+'TABLE site_banner contains the message shown at the top of every frontend page, such as an announcement of maintenance. It has at most one row.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON site_banner
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON site_banner IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+END;
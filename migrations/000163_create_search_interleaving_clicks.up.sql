@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE search_interleaving_clicks (
+    id bigserial PRIMARY KEY,
+    impression_id text NOT NULL,
+    ranking text NOT NULL,
+    team text NOT NULL CHECK (team IN ('control', 'treatment')),
+    position integer NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+COMMENT ON TABLE search_interleaving_clicks IS
+'TABLE search_interleaving_clicks contains the clicks on search results pages that interleave the default ranking with an alternative one. It does not contain queries or anything identifying users.';
+COMMENT ON COLUMN search_interleaving_clicks.impression_id IS
+'COLUMN impression_id is a random ID of the results page the click was on, used to compare the clicks for each ranking on the same page.';
+COMMENT ON COLUMN search_interleaving_clicks.ranking IS
+'COLUMN ranking is the name of the alternative ranking.';
+COMMENT ON COLUMN search_interleaving_clicks.team IS
+'COLUMN team is "control" if the clicked result was contributed by the default ranking, or "treatment" if it was contributed by the alternative one.';
+COMMENT ON COLUMN search_interleaving_clicks.position IS
+'COLUMN position is the 0-based position of the clicked result on the page.';
+
+CREATE INDEX idx_search_interleaving_clicks_created_at ON search_interleaving_clicks (created_at);
+
+END;
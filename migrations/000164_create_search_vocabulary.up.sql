@@ -0,0 +1,18 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE search_vocabulary (
+    term text PRIMARY KEY,
+    num_packages integer NOT NULL
+);
+COMMENT ON TABLE search_vocabulary IS
+'TABLE search_vocabulary contains the package names and import path elements of the packages in search_documents. It is used to correct misspelled search queries, and is recomputed periodically by the worker.';
+COMMENT ON COLUMN search_vocabulary.num_packages IS
+'COLUMN num_packages is the number of packages whose name or import path contains the term.';
+
+CREATE INDEX idx_search_vocabulary_num_packages ON search_vocabulary (num_packages);
+
+END;
@@ -0,0 +1,9 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real, synonyms text);
+
+END;
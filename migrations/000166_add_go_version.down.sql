@@ -0,0 +1,11 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents DROP COLUMN go_version_sort;
+ALTER TABLE modules DROP COLUMN go_version_sort;
+ALTER TABLE modules DROP COLUMN go_version;
+
+END;
@@ -0,0 +1,18 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN go_version text;
+ALTER TABLE modules ADD COLUMN go_version_sort text;
+ALTER TABLE search_documents ADD COLUMN go_version_sort text;
+
+COMMENT ON COLUMN modules.go_version IS
+'COLUMN go_version is the version from the go directive of the module''s go.mod file, such as "1.18". It is NULL if there is no go directive.';
+COMMENT ON COLUMN modules.go_version_sort IS
+'COLUMN go_version_sort is a sortable form of go_version, computed with version.ForSorting.';
+COMMENT ON COLUMN search_documents.go_version_sort IS
+'COLUMN go_version_sort is copied from modules.go_version_sort. It is used to filter search results by Go version.';
+
+END;
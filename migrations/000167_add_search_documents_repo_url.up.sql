@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents ADD COLUMN repo_url text;
+
+COMMENT ON COLUMN search_documents.repo_url IS
+'COLUMN repo_url is the URL of the repository that contains the module, from modules.source_info. It is used to group modules from the same repository in search results. It is NULL if the repository is unknown.';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents ADD COLUMN series_path text;
+
+COMMENT ON COLUMN search_documents.series_path IS
+'COLUMN series_path is copied from modules.series_path. It is used to deduplicate a package across the major versions of its module in search results.';
+
+END;
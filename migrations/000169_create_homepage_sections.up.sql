@@ -0,0 +1,29 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE homepage_sections (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    position integer DEFAULT 0 NOT NULL,
+    kind text NOT NULL CHECK (kind IN ('pinned', 'category', 'recent')),
+    title text NOT NULL,
+    description text DEFAULT '' NOT NULL,
+    paths text[] DEFAULT '{}' NOT NULL,
+    path_prefix text DEFAULT '' NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT homepage_sections_recent_prefix CHECK (kind <> 'recent' OR path_prefix <> '')
+);
+COMMENT ON TABLE homepage_sections IS
+'TABLE homepage_sections contains the curated sections of the frontend homepage, for private instances. If it is empty, the public homepage is shown.';
+COMMENT ON COLUMN homepage_sections.kind IS
+'COLUMN kind is "pinned" for pinned modules, "category" for a curated category of packages, or "recent" for the most recently updated packages under path_prefix.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON homepage_sections
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON homepage_sections IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+END;
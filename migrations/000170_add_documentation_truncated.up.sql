@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE documentation ADD COLUMN truncated boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN documentation.truncated IS
+'COLUMN truncated reports whether the documentation of the package is incomplete because loading it exceeded a resource limit.';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE documentation ADD COLUMN content_hash text;
+
+COMMENT ON COLUMN documentation.content_hash IS
+'COLUMN content_hash is the hex-encoded SHA-256 hash of source. It is NULL for rows inserted before it was added.';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN migration_guide text;
+
+COMMENT ON COLUMN modules.migration_guide IS
+'COLUMN migration_guide is the name of a file at the root of the module, such as MIGRATION.md, that explains how to upgrade to it from an earlier major version. It is NULL if there is none.';
+
+END;
@@ -0,0 +1,17 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE vuln_reports (
+    module_id integer NOT NULL PRIMARY KEY REFERENCES modules(id) ON DELETE CASCADE,
+    findings jsonb NOT NULL,
+    uploaded_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+COMMENT ON TABLE vuln_reports IS
+'TABLE vuln_reports contains the results of govulncheck scans of module versions, uploaded through the API. Only the most recent upload for each module version is kept.';
+COMMENT ON COLUMN vuln_reports.findings IS
+'COLUMN findings is a JSON array of the vulnerabilities found by the scan.';
+
+END;
@@ -0,0 +1,13 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE units DROP COLUMN num_test_files;
+ALTER TABLE units DROP COLUMN num_tests;
+ALTER TABLE units DROP COLUMN num_benchmarks;
+ALTER TABLE units DROP COLUMN num_fuzz_targets;
+ALTER TABLE units DROP COLUMN num_examples;
+
+END;
@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE units ADD COLUMN num_test_files integer;
+ALTER TABLE units ADD COLUMN num_tests integer;
+ALTER TABLE units ADD COLUMN num_benchmarks integer;
+ALTER TABLE units ADD COLUMN num_fuzz_targets integer;
+ALTER TABLE units ADD COLUMN num_examples integer;
+
+COMMENT ON COLUMN units.num_test_files IS
+'COLUMN num_test_files is the number of _test.go files in the package. It and the other test counts are NULL if the package has no _test.go files or was fetched before they were counted.';
+
+COMMENT ON COLUMN units.num_tests IS
+'COLUMN num_tests is the number of Test functions in the _test.go files of the package.';
+
+COMMENT ON COLUMN units.num_benchmarks IS
+'COLUMN num_benchmarks is the number of Benchmark functions in the _test.go files of the package.';
+
+COMMENT ON COLUMN units.num_fuzz_targets IS
+'COLUMN num_fuzz_targets is the number of Fuzz functions in the _test.go files of the package.';
+
+COMMENT ON COLUMN units.num_examples IS
+'COLUMN num_examples is the number of Example functions in the _test.go files of the package.';
+
+END;
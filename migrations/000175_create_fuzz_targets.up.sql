@@ -0,0 +1,18 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE fuzz_targets (
+    unit_id integer NOT NULL REFERENCES units(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    num_seeds integer NOT NULL,
+    PRIMARY KEY (unit_id, name)
+);
+COMMENT ON TABLE fuzz_targets IS
+'TABLE fuzz_targets contains the fuzz targets declared in the _test.go files of each package.';
+COMMENT ON COLUMN fuzz_targets.num_seeds IS
+'COLUMN num_seeds is the number of files in the seed corpus of the target, the testdata/fuzz/<name> directory of the package.';
+
+END;
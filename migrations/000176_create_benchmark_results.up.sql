@@ -0,0 +1,31 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE units ADD COLUMN benchmarks text[];
+
+COMMENT ON COLUMN units.benchmarks IS
+'COLUMN benchmarks holds the names of the benchmark functions in the _test.go files of the package, sorted. It is NULL if there are none.';
+
+CREATE TABLE benchmark_results (
+    module_id integer NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    package_path text NOT NULL,
+    name text NOT NULL,
+    unit text NOT NULL,
+    value double precision NOT NULL,
+    num_runs integer NOT NULL,
+    uploaded_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (module_id, package_path, name, unit)
+);
+COMMENT ON TABLE benchmark_results IS
+'TABLE benchmark_results contains the results of benchmarks of module versions, uploaded through the API in the Go benchmark format. Only the most recent upload for each module version is kept.';
+COMMENT ON COLUMN benchmark_results.name IS
+'COLUMN name is the name of the benchmark, including sub-benchmarks but without the GOMAXPROCS suffix, such as BenchmarkDecode/small.';
+COMMENT ON COLUMN benchmark_results.unit IS
+'COLUMN unit is the unit of measurement, such as ns/op or B/op.';
+COMMENT ON COLUMN benchmark_results.value IS
+'COLUMN value is the mean of the values of the runs of the benchmark.';
+
+END;
@@ -0,0 +1,16 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE code_indexes (
+    unit_id integer PRIMARY KEY REFERENCES units(id) ON DELETE CASCADE,
+    contents jsonb NOT NULL
+);
+COMMENT ON TABLE code_indexes IS
+'TABLE code_indexes contains code navigation data for the non-test .go files of each package: the definitions of its package-level declarations and the references to package-level declarations. It is exported in the LSIF format.';
+COMMENT ON COLUMN code_indexes.contents IS
+'COLUMN contents is the JSON encoding of an internal.CodeIndex.';
+
+END;
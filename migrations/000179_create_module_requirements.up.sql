@@ -0,0 +1,22 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_requirements (
+    module_id integer NOT NULL,
+    path text NOT NULL,
+    version text NOT NULL,
+    indirect boolean NOT NULL,
+    PRIMARY KEY (module_id, path),
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+COMMENT ON TABLE module_requirements IS
+'TABLE module_requirements contains the require directives of the go.mod file of each module version.';
+COMMENT ON COLUMN module_requirements.indirect IS
+'COLUMN indirect is true if the directive is marked with an "// indirect" comment.';
+
+CREATE INDEX idx_module_requirements_path ON module_requirements(path);
+
+END;
@@ -0,0 +1,24 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE namespace_claims (
+    prefix text NOT NULL,
+    token text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    verified_at timestamp with time zone,
+    method text,
+    PRIMARY KEY (prefix, token)
+);
+COMMENT ON TABLE namespace_claims IS
+'TABLE namespace_claims contains the claims of control of module path prefixes, such as example.com or github.com/org. Modules under a prefix with a verified claim are displayed as coming from a verified publisher.';
+COMMENT ON COLUMN namespace_claims.token IS
+'COLUMN token is the random token that the claimant publishes to prove control of the prefix.';
+COMMENT ON COLUMN namespace_claims.verified_at IS
+'COLUMN verified_at is when the claim was verified, or NULL if it has not been.';
+COMMENT ON COLUMN namespace_claims.method IS
+'COLUMN method is how the claim was verified: "dns" for a DNS TXT record, or "repo" for a repository on a code host.';
+
+END;
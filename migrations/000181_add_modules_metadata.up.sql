@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN metadata jsonb;
+
+COMMENT ON COLUMN modules.metadata IS
+'COLUMN metadata is the information that the authors of the module provide in a .pkgsite.yaml file at its root, such as a description, homepage and tags. It is NULL if there is no such file, or it is invalid.';
+
+END;
@@ -0,0 +1,19 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_guides (
+    module_id integer NOT NULL,
+    file_path text NOT NULL,
+    contents text NOT NULL,
+    PRIMARY KEY (module_id, file_path),
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+COMMENT ON TABLE module_guides IS
+'TABLE module_guides contains the markdown files in the docs or doc directory at the root of each module version, which are displayed in the Guides tab.';
+COMMENT ON COLUMN module_guides.file_path IS
+'COLUMN file_path is the path of the file relative to the module root, such as docs/intro.md.';
+
+END;
@@ -0,0 +1,26 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE fetch_requests (
+    id bigserial PRIMARY KEY,
+    requested_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    requester text NOT NULL,
+    path text NOT NULL,
+    requested_version text NOT NULL,
+    allowed boolean NOT NULL,
+    status integer NOT NULL
+);
+CREATE INDEX idx_fetch_requests_requested_at ON fetch_requests(requested_at);
+COMMENT ON TABLE fetch_requests IS
+'TABLE fetch_requests is the audit trail of the requests to fetch modules from the frontend, on instances that restrict fetching to some users.';
+COMMENT ON COLUMN fetch_requests.requester IS
+'COLUMN requester is the user who made the request, as identified by their ID token, or empty if they were not authenticated.';
+COMMENT ON COLUMN fetch_requests.allowed IS
+'COLUMN allowed is whether the user was allowed to fetch modules.';
+COMMENT ON COLUMN fetch_requests.status IS
+'COLUMN status is the HTTP status of the response to the request.';
+
+END;
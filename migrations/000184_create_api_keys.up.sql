@@ -0,0 +1,30 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE api_keys (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    key_hash bytea NOT NULL UNIQUE,
+    key_prefix text NOT NULL,
+    scopes text[] NOT NULL,
+    rate_limit integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at timestamp with time zone
+);
+COMMENT ON TABLE api_keys IS
+'TABLE api_keys contains the keys that authenticate clients of the JSON API of the frontend.';
+COMMENT ON COLUMN api_keys.key_hash IS
+'COLUMN key_hash is the SHA-256 hash of the key. The key itself is not stored.';
+COMMENT ON COLUMN api_keys.key_prefix IS
+'COLUMN key_prefix is the start of the key, to help recognize it.';
+COMMENT ON COLUMN api_keys.scopes IS
+'COLUMN scopes are what the key allows: read, search, fetch or admin.';
+COMMENT ON COLUMN api_keys.rate_limit IS
+'COLUMN rate_limit is the number of requests per minute allowed for the key, or 0 for the default limit.';
+COMMENT ON COLUMN api_keys.revoked_at IS
+'COLUMN revoked_at is when the key was revoked, or NULL if it is active.';
+
+END;
@@ -0,0 +1,22 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE page_views (
+    day date NOT NULL,
+    module_path text NOT NULL,
+    package_path text NOT NULL,
+    tab text NOT NULL,
+    views integer NOT NULL,
+    PRIMARY KEY (day, module_path, package_path, tab)
+);
+COMMENT ON TABLE page_views IS
+'TABLE page_views counts the views of package pages on the frontend, per day and tab. It contains nothing about the viewers.';
+COMMENT ON COLUMN page_views.day IS
+'COLUMN day is the UTC day of the views.';
+COMMENT ON COLUMN page_views.tab IS
+'COLUMN tab is the tab of the package page that was viewed, such as "imports", or empty for the documentation.';
+
+END;
@@ -0,0 +1,29 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE doc_cache (
+    key text PRIMARY KEY,
+    renderer_version integer NOT NULL,
+    module_path text NOT NULL,
+    contents bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_doc_cache_used_at ON doc_cache(used_at);
+COMMENT ON TABLE doc_cache IS
+'TABLE doc_cache holds the processed packages of module contents, so that workers do not process the same contents twice.';
+COMMENT ON COLUMN doc_cache.key IS
+'COLUMN key is a hash of the renderer version, the module path and the contents of the module zip.';
+COMMENT ON COLUMN doc_cache.renderer_version IS
+'COLUMN renderer_version is the version of the processing code that produced the contents. Entries of older versions are garbage collected.';
+COMMENT ON COLUMN doc_cache.module_path IS
+'COLUMN module_path is the path of the module the contents came from, for debugging.';
+COMMENT ON COLUMN doc_cache.contents IS
+'COLUMN contents is the gzipped JSON encoding of the processed packages.';
+COMMENT ON COLUMN doc_cache.used_at IS
+'COLUMN used_at is the last time the entry was read or written.';
+
+END;
@@ -0,0 +1,29 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE analysis_results (
+    id bigserial PRIMARY KEY,
+    module_id integer NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    analyzer text NOT NULL,
+    package_path text NOT NULL,
+    severity text NOT NULL,
+    message text NOT NULL,
+    url text NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_analysis_results_module_id ON analysis_results(module_id);
+COMMENT ON TABLE analysis_results IS
+'TABLE analysis_results contains the results of the analyses that the operator of the site runs over module versions when the worker processes them. Only the results of the most recent run of each analyzer are kept.';
+COMMENT ON COLUMN analysis_results.analyzer IS
+'COLUMN analyzer is the name of the analyzer that produced the result.';
+COMMENT ON COLUMN analysis_results.package_path IS
+'COLUMN package_path is the path of the package the result is about, or empty if it is about the whole module.';
+COMMENT ON COLUMN analysis_results.severity IS
+'COLUMN severity is one of info, warning or error.';
+COMMENT ON COLUMN analysis_results.url IS
+'COLUMN url links to more information about the result, or is empty.';
+
+END;
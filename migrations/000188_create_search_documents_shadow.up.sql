@@ -0,0 +1,23 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE search_documents_shadow (
+    package_path_id integer PRIMARY KEY REFERENCES search_documents(package_path_id) ON DELETE CASCADE,
+    indexed_module_path text NOT NULL,
+    indexed_version text NOT NULL,
+    tsv_path_tokens tsvector NOT NULL,
+    tsv_search_tokens tsvector NOT NULL,
+    built_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_search_documents_shadow_tsv_search_tokens ON search_documents_shadow USING gin (tsv_search_tokens);
+COMMENT ON TABLE search_documents_shadow IS
+'TABLE search_documents_shadow holds the text search tokens of search_documents, as computed by the current code, while search is served from the old ones. It is emptied when the tokens are swapped into search_documents.';
+COMMENT ON COLUMN search_documents_shadow.indexed_module_path IS
+'COLUMN indexed_module_path is the module path of the search document when the tokens were computed. The tokens are stale if it has changed since.';
+COMMENT ON COLUMN search_documents_shadow.indexed_version IS
+'COLUMN indexed_version is the version of the search document when the tokens were computed. The tokens are stale if it has changed since.';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents ADD COLUMN recent_imported_by_count REAL NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN search_documents.recent_imported_by_count IS
+'COLUMN recent_imported_by_count is imported_by_count with each importer weighted by how recently its module was updated. It is computed with imported_by_count, and used by the recent-popularity search ranking.';
+
+END;
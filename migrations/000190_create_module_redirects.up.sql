@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_redirects (
+    old_module_path text PRIMARY KEY,
+    new_module_path text NOT NULL,
+    source text NOT NULL CHECK (source IN ('deprecated', 'admin')),
+    reason text DEFAULT '' NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT module_redirects_different_paths CHECK (old_module_path <> new_module_path)
+);
+COMMENT ON TABLE module_redirects IS
+'TABLE module_redirects maps the paths of modules that were renamed or moved to their new paths. Requests for pages of an old module path show an interstitial linking to the new one.';
+COMMENT ON COLUMN module_redirects.source IS
+'COLUMN source is "deprecated" for redirects derived from the deprecation comment in the go.mod file of the latest version of the old module, or "admin" for redirects added by an administrator. Admin redirects are never replaced by deprecated ones.';
+COMMENT ON COLUMN module_redirects.reason IS
+'COLUMN reason explains the redirect: the deprecation comment, or a note from the administrator.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON module_redirects
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON module_redirects IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+END;
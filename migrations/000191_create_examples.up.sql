@@ -0,0 +1,21 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE examples (
+    unit_id integer NOT NULL REFERENCES units(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    doc text DEFAULT '' NOT NULL,
+    code text NOT NULL,
+    output text DEFAULT '' NOT NULL,
+    runnable boolean NOT NULL,
+    PRIMARY KEY (unit_id, name)
+);
+COMMENT ON TABLE examples IS
+'TABLE examples contains the example functions declared in the _test.go files of each redistributable package.';
+COMMENT ON COLUMN examples.code IS
+'COLUMN code is a complete program if runnable is true, which can be run in the Go playground, and otherwise the body of the example function.';
+
+END;
@@ -0,0 +1,28 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE search_queries (
+    day date NOT NULL,
+    query text NOT NULL,
+    mode text NOT NULL,
+    searches integer NOT NULL,
+    zero_result_searches integer NOT NULL,
+    result_count integer NOT NULL,
+    top_result_path text NOT NULL,
+    PRIMARY KEY (day, query, mode)
+);
+COMMENT ON TABLE search_queries IS
+'TABLE search_queries counts the searches on the frontend, per day and normalized query, with coarse information about their results. It contains nothing about the searchers.';
+COMMENT ON COLUMN search_queries.day IS
+'COLUMN day is the UTC day of the searches.';
+COMMENT ON COLUMN search_queries.mode IS
+'COLUMN mode is the search mode, "package" or "symbol".';
+COMMENT ON COLUMN search_queries.result_count IS
+'COLUMN result_count is the number of results of the last search, which is an estimate for large counts.';
+COMMENT ON COLUMN search_queries.top_result_path IS
+'COLUMN top_result_path is the path of the first result of the last search, or empty if it had no results.';
+
+END;
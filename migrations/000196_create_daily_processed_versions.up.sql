@@ -0,0 +1,14 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE daily_processed_versions (
+    date date NOT NULL PRIMARY KEY,
+    num_processed integer NOT NULL
+);
+COMMENT ON TABLE daily_processed_versions IS
+'TABLE daily_processed_versions counts the module versions processed by the worker on each date, including reprocessed versions. Unlike module_version_states.last_processed_at, the counts are not changed when a version is processed again later. They are read into corpus_stats.versions_processed.';
+
+END;
@@ -0,0 +1,25 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents
+    DROP COLUMN shadow_tsv_path_tokens,
+    DROP COLUMN shadow_tsv_search_tokens,
+    DROP COLUMN shadow_indexed_module_path,
+    DROP COLUMN shadow_indexed_version,
+    ALTER COLUMN tsv_path_tokens SET NOT NULL,
+    ALTER COLUMN tsv_search_tokens SET NOT NULL;
+
+CREATE TABLE search_documents_shadow (
+    package_path_id integer PRIMARY KEY REFERENCES search_documents(package_path_id) ON DELETE CASCADE,
+    indexed_module_path text NOT NULL,
+    indexed_version text NOT NULL,
+    tsv_path_tokens tsvector NOT NULL,
+    tsv_search_tokens tsvector NOT NULL,
+    built_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_search_documents_shadow_tsv_search_tokens ON search_documents_shadow USING gin (tsv_search_tokens);
+
+END;
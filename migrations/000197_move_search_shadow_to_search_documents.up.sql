@@ -0,0 +1,20 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP TABLE search_documents_shadow;
+
+-- The shadow tokens are swapped with the live ones by renaming the columns,
+-- so both must have the same constraints. The shadow_indexed_* columns are
+-- dropped and added again at each swap.
+ALTER TABLE search_documents
+    ALTER COLUMN tsv_path_tokens DROP NOT NULL,
+    ALTER COLUMN tsv_search_tokens DROP NOT NULL,
+    ADD COLUMN shadow_tsv_path_tokens tsvector,
+    ADD COLUMN shadow_tsv_search_tokens tsvector,
+    ADD COLUMN shadow_indexed_module_path text,
+    ADD COLUMN shadow_indexed_version text;
+
+END;
@@ -0,0 +1,5 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+CREATE INDEX CONCURRENTLY idx_search_documents_shadow_tsv_search_tokens ON search_documents USING gin (shadow_tsv_search_tokens);
@@ -0,0 +1,12 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP INDEX idx_namespace_claims_created_at;
+ALTER TABLE namespace_claims
+    DROP COLUMN expires_at,
+    DROP COLUMN api_key_id;
+
+END;
@@ -0,0 +1,21 @@
+-- Copyright 2022 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE namespace_claims
+    ADD COLUMN expires_at timestamp with time zone,
+    ADD COLUMN api_key_id integer REFERENCES api_keys(id);
+COMMENT ON COLUMN namespace_claims.expires_at IS
+'COLUMN expires_at is when the verification of the claim expires, or NULL if it has not been verified. Verified claims are checked again before they expire.';
+COMMENT ON COLUMN namespace_claims.api_key_id IS
+'COLUMN api_key_id is the API key that the claim was made with, or NULL if it was made without one. It is used to limit the rate of claims.';
+CREATE INDEX idx_namespace_claims_created_at ON namespace_claims (created_at);
+
+-- Claims verified before verifications expired are checked again soon.
+UPDATE namespace_claims
+SET expires_at = CURRENT_TIMESTAMP + interval '7 days'
+WHERE verified_at IS NOT NULL;
+
+END;
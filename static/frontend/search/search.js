@@ -1,4 +1,4 @@
-var c=3.5,r=document.querySelector(".js-siteHeader"),n=document.createElement("div");r==null||r.prepend(n);var l=new IntersectionObserver(([o])=>{if(o.intersectionRatio<1)for(let e of document.querySelectorAll('[class^="SearchResults-header"'))e.setAttribute("data-fixed","true");else for(let e of document.querySelectorAll('[class^="SearchResults-header"'))e.removeAttribute("data-fixed")},{threshold:1,rootMargin:`${c*16*3}px`});l.observe(n);var t=document.querySelector(".js-searchHeader");t==null||t.addEventListener("dblclick",o=>{var s;let e=o.target;(e===t||e===t.lastElementChild)&&((s=window.getSelection())==null||s.removeAllRanges(),window.scrollTo({top:0,behavior:"smooth"}))});
+var m=3.5,s=document.querySelector(".js-siteHeader"),d=document.createElement("div");s==null||s.prepend(d);var h=new IntersectionObserver(([t])=>{if(t.intersectionRatio<1)for(let e of document.querySelectorAll('[class^="SearchResults-header"'))e.setAttribute("data-fixed","true");else for(let e of document.querySelectorAll('[class^="SearchResults-header"'))e.removeAttribute("data-fixed")},{threshold:1,rootMargin:`${m*16*3}px`});h.observe(d);var r=document.querySelector(".js-searchHeader");r==null||r.addEventListener("dblclick",t=>{var o;let e=t.target;(e===r||e===r.lastElementChild)&&((o=window.getSelection())==null||o.removeAllRanges(),window.scrollTo({top:0,behavior:"smooth"}))});var n=document.querySelector(".js-searchInterleaving");n==null||n.addEventListener("click",t=>{var a,c,i,l;let e=t.target.closest("a[data-team]");if(!e)return;let o=new URLSearchParams({impression:(a=n.dataset.impression)!=null?a:"",ranking:(c=n.dataset.ranking)!=null?c:"",team:(i=e.dataset.team)!=null?i:"",position:(l=e.dataset.gtmv)!=null?l:""});navigator.sendBeacon("/search-click",o)});
 /**
  * @license
  * Copyright 2020 The Go Authors. All rights reserved.
@@ -43,4 +43,20 @@ searchHeader?.addEventListener('dblclick', e => {
   }
 });
 
+// On pages that interleave the results of two search rankings, report which
+// ranking contributed each clicked result. Only the position of the result is
+// sent, along with the random ID of the page.
+const interleaving = document.querySelector<HTMLElement>('.js-searchInterleaving');
+interleaving?.addEventListener('click', e => {
+  const link = (e.target as HTMLElement).closest<HTMLAnchorElement>('a[data-team]');
+  if (!link) return;
+  const data = new URLSearchParams({
+    impression: interleaving.dataset.impression ?? '',
+    ranking: interleaving.dataset.ranking ?? '',
+    team: link.dataset.team ?? '',
+    position: link.dataset.gtmv ?? '',
+  });
+  navigator.sendBeacon('/search-click', data);
+});
+
 export {};
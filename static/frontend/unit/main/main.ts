@@ -107,6 +107,30 @@ function openDeprecatedSymbol() {
 openDeprecatedSymbol();
 window.addEventListener('hashchange', () => openDeprecatedSymbol());
 
+/**
+ * Show the notice for a symbol that is linked to but not on the page,
+ * because it was removed, renamed or not yet added in this version. If the
+ * symbol was renamed, show the notice above the symbol with the new name.
+ */
+function showSymbolNotice() {
+  document.querySelectorAll<HTMLElement>('.js-symbolNotice').forEach(el => (el.hidden = true));
+  const id = decodeURIComponent(location.hash.slice(1));
+  if (!id || document.getElementById(id)) return;
+  const notice = Array.from(document.querySelectorAll<HTMLElement>('.js-symbolNotice')).find(
+    el => el.dataset.symbolId === id
+  );
+  if (!notice) return;
+  notice.hidden = false;
+  const newSymbol = notice.dataset.newSymbolId && document.getElementById(notice.dataset.newSymbolId);
+  if (newSymbol) {
+    // Show the notice next to the renamed symbol.
+    newSymbol.before(notice);
+  }
+  notice.scrollIntoView();
+}
+showSymbolNotice();
+window.addEventListener('hashchange', () => showSymbolNotice());
+
 /**
  * Listen for changes in the build context dropdown.
  */